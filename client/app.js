@@ -17,6 +17,11 @@ async function api(method, path, body) {
   if (body) opts.body = JSON.stringify(body);
 
   const resp = await fetch(API + path, opts);
+  // 会话过期或未登录：跳转登录页
+  if (resp.status === 401) {
+    window.location.href = '/login';
+    throw new Error('需要登录');
+  }
   const data = await resp.json();
   if (!resp.ok) throw new Error(data.error || `请求失败 ${resp.status}`);
   return data;
@@ -41,13 +46,75 @@ async function checkHealth() {
         badge.textContent = '现货' + (t.dry_run ? ' (模拟)' : '');
         badge.className = 'mode-badge mode-spot';
       }
+      renderKeyAlert(data.trading.key_alert);
+      renderVolatilityAlert(data.trading.volatility_halts);
+      renderDrawdownAlert(data.trading.drawdown_halt);
     }
+    checkDataSources();
   } catch {
     dot.className = 'dot dot-off';
     txt.textContent = '服务离线';
   }
 }
 
+// 外部数据源异常提示：有失败或陈旧的数据源时显示，悬停查看明细
+async function checkDataSources() {
+  const el = document.getElementById('datasource-badge');
+  try {
+    const data = await api('GET', '/datasources/status');
+    const bad = (data.sources || []).filter(s => s.status === 'stale' || s.status === 'failing');
+    if (bad.length === 0) {
+      el.hidden = true;
+      return;
+    }
+    el.textContent = `数据源异常 ${bad.length}`;
+    el.title = bad.map(s => `${s.name}: ${s.status}` + (s.last_error ? ` (${s.last_error})` : '')).join('\n');
+    el.hidden = false;
+  } catch {
+    el.hidden = true;
+  }
+}
+
+// API Key 告警横幅（-2015 / 交易权限即将到期），告警解除前持续显示
+function renderKeyAlert(alert) {
+  const el = document.getElementById('key-alert');
+  if (!alert) {
+    el.hidden = true;
+    return;
+  }
+  let text = '⚠ ' + alert.message;
+  if (alert.public_ip && !alert.message.includes(alert.public_ip)) {
+    text += `（当前公网 IP: ${alert.public_ip}）`;
+  }
+  el.textContent = text;
+  el.hidden = false;
+}
+
+// 波动熔断横幅：冷却期内的交易对暂停开仓
+function renderVolatilityAlert(halts) {
+  const el = document.getElementById('volatility-alert');
+  if (!halts || halts.length === 0) {
+    el.hidden = true;
+    return;
+  }
+  el.textContent = halts.map(h => {
+    const until = new Date(h.until).toLocaleTimeString('zh-CN', { hour12: false, hour: '2-digit', minute: '2-digit' });
+    return `🚨 ${h.message}（${until} 恢复）`;
+  }).join('　');
+  el.hidden = false;
+}
+
+// 回撤熔断横幅：所有交易对暂停开仓，需手动恢复
+function renderDrawdownAlert(halt) {
+  const el = document.getElementById('drawdown-alert');
+  if (!halt || !halt.halted) {
+    el.hidden = true;
+    return;
+  }
+  el.textContent = `🚨 ${halt.reason}（调用 POST /api/v1/risk/resume 恢复）`;
+  el.hidden = false;
+}
+
 // ===== 提示消息 =====
 function showToast(msg, type) {
   const existing = document.querySelector('.toast');
@@ -70,6 +137,7 @@ const STATUS_MAP = {
   rejected: '已拒绝',
   failed: '失败',
   running: '运行中',
+  pending_approval: '待审批',
 };
 
 const SIDE_MAP = {
@@ -80,7 +148,7 @@ const SIDE_MAP = {
 };
 
 function statusBadge(status) {
-  const map = { success: 'success', rejected: 'rejected', failed: 'failed', running: 'running' };
+  const map = { success: 'success', rejected: 'rejected', failed: 'failed', running: 'running', pending_approval: 'running' };
   return badge(STATUS_MAP[status] || status, map[status] || 'running');
 }
 
@@ -237,6 +305,45 @@ document.getElementById('run-form').addEventListener('submit', async (e) => {
   }
 });
 
+// ===== 人工下单 =====
+document.getElementById('manual-form').addEventListener('submit', async (e) => {
+  e.preventDefault();
+
+  const enforceRisk = document.getElementById('manual_risk').value === 'true';
+  if (!enforceRisk && !confirm('确认跳过风控直接下单？')) return;
+
+  const btn = document.getElementById('manual-btn');
+  const btnText = btn.querySelector('.btn-text');
+  const btnLoad = btn.querySelector('.btn-loading');
+  btn.disabled = true;
+  btnText.hidden = true;
+  btnLoad.hidden = false;
+
+  const body = {
+    pair: document.getElementById('manual_pair').value,
+    side: document.getElementById('manual_side').value,
+    stake_usdt: parseFloat(document.getElementById('manual_stake').value) || 0,
+    quantity: parseFloat(document.getElementById('manual_quantity').value) || 0,
+    enforce_risk: enforceRisk,
+    note: document.getElementById('manual_note').value,
+  };
+
+  try {
+    const data = await api('POST', '/orders/manual', body);
+    const panel = document.getElementById('result-panel');
+    panel.hidden = false;
+    renderResult(data, panel);
+    panel.scrollIntoView({ behavior: 'smooth' });
+    loadCycles(1);
+  } catch (err) {
+    showToast('下单失败: ' + err.message);
+  } finally {
+    btn.disabled = false;
+    btnText.hidden = false;
+    btnLoad.hidden = true;
+  }
+});
+
 // ===== 历史周期列表（分页） =====
 let cyclesCurrentPage = 1;
 const CYCLES_PAGE_SIZE = 15;
@@ -261,10 +368,11 @@ async function loadCycles(page) {
     }
 
     const STATUS_LABEL = {
-      running: '运行中', success: '成功', rejected: '已拒绝', failed: '失败',
+      running: '运行中', success: '成功', rejected: '已拒绝', failed: '失败', pending_approval: '待审批',
     };
     const STATUS_CLS = {
       success: 'badge-success', rejected: 'badge-rejected', failed: 'badge-failed', running: 'badge-running',
+      pending_approval: 'badge-running',
     };
 
     function fmtTime(ts) {
@@ -299,10 +407,12 @@ async function loadCycles(page) {
       const stake = c.stake_usdt > 0 ? c.stake_usdt.toFixed(2) : '-';
       const reason = truncate(c.signal_reason || c.error_message || c.reject_reason, 40);
       const modelDisplay = c.model_name ? truncate(c.model_name, 15) : '-';
+      const tagsHtml = (c.tags || []).map(t => `<span class="badge badge-none" style="font-size:0.65rem;margin-left:4px">${escapeHtml(t)}</span>`).join('');
+      const manualHtml = c.type === 'manual' ? '<span class="badge badge-running" style="font-size:0.65rem;margin-left:4px">人工</span>' : '';
 
       html += `<tr>
         <td style="white-space:nowrap">${fmtTime(c.created_at)}</td>
-        <td><strong>${c.pair}</strong></td>
+        <td><strong>${c.pair}</strong>${manualHtml}${tagsHtml}</td>
         <td><span class="badge ${sCls}">${sLabel}</span></td>
         <td><span class="badge ${sideCls}">${sideText}</span></td>
         <td>${c.confidence > 0 ? (c.confidence * 100).toFixed(0) + '%' : '-'}</td>
@@ -314,6 +424,8 @@ async function loadCycles(page) {
         <td title="${(c.signal_reason || '').replace(/"/g, '&quot;')}" style="color:var(--text-dim);font-size:0.8rem;max-width:200px;overflow:hidden;text-overflow:ellipsis;white-space:nowrap">${reason}</td>
         <td>
           <button class="btn-view" onclick="viewCycleDetail('${c.cycle_id}')">查看</button>
+          ${c.status === 'pending_approval' ? `<button class="btn-view" onclick="decideCycle('${c.cycle_id}', 'approve')" style="margin-left:4px">批准</button>
+          <button class="btn-delete" onclick="decideCycle('${c.cycle_id}', 'reject')" style="margin-left:4px">驳回</button>` : ''}
           <button class="btn-delete" onclick="deleteCycle('${c.cycle_id}')" style="margin-left:4px">删除</button>
         </td>
       </tr>`;
@@ -383,10 +495,10 @@ async function viewCycleDetail(cycleId) {
 
   try {
     const data = await api('GET', '/cycles/' + encodeURIComponent(cycleId));
-    const { cycle, signal, risk, position_strategy, order, logs } = data;
+    const { cycle, signal, risk, position_strategy, order, order_events, order_groups, logs } = data;
 
-    const STATUS_LABEL = { running: '运行中', success: '成功', rejected: '已拒绝', failed: '失败' };
-    const STATUS_CLS = { success: 'badge-success', rejected: 'badge-rejected', failed: 'badge-failed', running: 'badge-running' };
+    const STATUS_LABEL = { running: '运行中', success: '成功', rejected: '已拒绝', failed: '失败', pending_approval: '待审批' };
+    const STATUS_CLS = { success: 'badge-success', rejected: 'badge-rejected', failed: 'badge-failed', running: 'badge-running', pending_approval: 'badge-running' };
 
     function fmtFullTime(ts) {
       if (!ts) return '-';
@@ -455,6 +567,7 @@ async function viewCycleDetail(cycleId) {
           <div class="detail-item"><span class="detail-label">评估时间</span><span class="detail-value">${fmtFullTime(risk.created_at)}</span></div>
           ${risk.reject_reason ? `<div class="detail-item" style="grid-column:1/-1"><span class="detail-label">拒绝原因</span><span class="detail-value" style="color:var(--red)">${risk.reject_reason}</span></div>` : ''}
         </div>
+        ${risk.sizing ? renderSizing(risk.sizing) : ''}
       </div>`;
     }
 
@@ -539,8 +652,46 @@ async function viewCycleDetail(cycleId) {
           <div class="detail-item"><span class="detail-label">成交数量</span><span class="detail-value" style="font-family:monospace">${order.filled_qty > 0 ? order.filled_qty : '-'}</span></div>
           <div class="detail-item"><span class="detail-label">订单号</span><span class="detail-value" style="font-size:0.8rem;font-family:monospace">${order.exchange_order_id || order.client_order_id || '-'}</span></div>
           <div class="detail-item"><span class="detail-label">创建时间</span><span class="detail-value">${fmtFullTime(order.created_at)}</span></div>
-        </div>
-      </div>`;
+        </div>`;
+      // 订单状态历史
+      if (order_events && order_events.length > 0) {
+        html += `<div class="detail-logs" style="margin-top:0.75rem">`;
+        for (const e of order_events) {
+          const qty = e.filled_qty > 0 ? ` 成交=${e.filled_qty} @ ${fmtPrice(e.filled_price)}` : '';
+          html += `<div class="detail-log-entry">
+            <span class="detail-log-time">${fmtFullTime(e.created_at)}</span>
+            <span class="detail-log-stage">${e.status}</span>
+            <span class="detail-log-msg">${qty} ${e.detail || ''}</span>
+          </div>`;
+        }
+        html += '</div>';
+      }
+      html += `</div>`;
+    }
+
+    // 订单组（OCO / 止盈止损 / TWAP 子单）
+    if (order_groups && order_groups.length > 0) {
+      const GROUP_LABEL = { oco: 'OCO', tp_sl: '止盈止损', twap: 'TWAP' };
+      const ROLE_LABEL = { stop_loss: '止损', take_profit: '止盈', child: '子单' };
+      html += `<div class="detail-section">
+        <div class="detail-section-title">订单组 (${order_groups.length})</div>`;
+      for (const g of order_groups) {
+        const listId = g.exchange_list_id ? ` 列表ID=${g.exchange_list_id}` : '';
+        html += `<div style="margin-bottom:0.5rem;font-size:0.85rem;color:var(--text-dim)">${GROUP_LABEL[g.kind] || g.kind} · ${g.status}${listId} · ${fmtFullTime(g.created_at)}</div>
+          <div class="holdings-table"><table><thead><tr><th>#</th><th>类型</th><th>价格</th><th>数量</th><th>状态</th><th>订单号</th></tr></thead><tbody>`;
+        for (const leg of g.legs || []) {
+          html += `<tr>
+            <td>${leg.leg_no}</td>
+            <td>${ROLE_LABEL[leg.role] || leg.role}</td>
+            <td style="font-family:monospace">${fmtPrice(leg.price)}</td>
+            <td style="font-family:monospace">${leg.quantity > 0 ? leg.quantity : '-'}</td>
+            <td>${leg.status}</td>
+            <td style="font-size:0.8rem;font-family:monospace">${leg.exchange_order_id || '-'}</td>
+          </tr>`;
+        }
+        html += '</tbody></table></div>';
+      }
+      html += `</div>`;
     }
 
     // 执行日志
@@ -567,6 +718,35 @@ async function viewCycleDetail(cycleId) {
   }
 }
 
+// 开仓金额计算过程：风控限额、组合敞口、余额快照与逐步调整
+const SIZING_STAGE_MAP = { capital: '资金分配', allocation: '分配权重', batch: '分批建仓', balance: '可用余额', notional: '交易对上限', manual: '人工指定' };
+
+function renderSizing(sz) {
+  const usdt = v => `${(v || 0).toFixed(2)} USDT`;
+  const item = (label, value) => `<div class="detail-item"><span class="detail-label">${label}</span><span class="detail-value">${value}</span></div>`;
+  const steps = (sz.adjustments || []).map(a =>
+    `<div class="detail-item" style="grid-column:1/-1"><span class="detail-label">${SIZING_STAGE_MAP[a.stage] || escapeHtml(a.stage)}</span>` +
+    `<span class="detail-value">${usdt(a.from_usdt)} → ${usdt(a.to_usdt)}（×${(a.factor || 0).toFixed(2)}）${a.note ? ' ' + escapeHtml(a.note) : ''}</span></div>`
+  ).join('');
+  return `<div class="detail-grid" style="margin-top:0.5rem">
+    ${item('单笔上限', usdt(sz.max_single_stake_usdt))}
+    ${item('敞口上限', usdt(sz.max_exposure_usdt))}
+    ${item('当前敞口', usdt(sz.open_exposure_usdt))}
+    ${item('剩余敞口', usdt(sz.remaining_exposure_usdt))}
+    ${item('风控金额', usdt(sz.risk_stake_usdt))}
+    ${sz.balance_free_usdt ? item('可用余额', usdt(sz.balance_free_usdt)) : ''}
+    ${steps}
+    ${sz.final_stake_usdt ? item('实际下单', usdt(sz.final_stake_usdt)) : ''}
+  </div>`;
+}
+
+// 报表展示币种换算值（后端按缓存汇率换算），未配置展示币种时不显示
+function fiatHint(display, key) {
+  if (!display || !display.values || display.values[key] === undefined) return '';
+  const stale = display.stale ? '（汇率过期）' : '';
+  return `<div style="color:var(--text-dim);font-size:0.8rem">≈ ${display.values[key].toFixed(2)} ${display.currency}${stale}</div>`;
+}
+
 // ===== 账户余额 =====
 async function loadBalance() {
   const summaryEl = document.getElementById('balance-summary');
@@ -576,6 +756,7 @@ async function loadBalance() {
     const usdtFree = data.usdt_free || 0;
     const usdtLocked = data.usdt_locked || 0;
     const usdtTotal = data.usdt_total || 0;
+    const usdtEarn = data.usdt_earn || 0;
     const assets = data.assets || [];
 
     summaryEl.innerHTML = `
@@ -590,14 +771,20 @@ async function loadBalance() {
       <div class="holdings-stat">
         <div class="stat-label">USDT 总计</div>
         <div class="stat-value" style="font-weight:700">${usdtTotal.toFixed(4)} U</div>
+        ${fiatHint(data.display, 'usdt_total')}
       </div>
+      ${usdtEarn > 0 ? `
+      <div class="holdings-stat">
+        <div class="stat-label">USDT 活期理财</div>
+        <div class="stat-value">${usdtEarn.toFixed(4)} U</div>
+      </div>` : ''}
     `;
 
     // 其他币种资产明细
     const others = assets.filter(a => a.symbol !== 'USDT');
     if (others.length > 0) {
       let html = '<details style="margin-top:0.5rem"><summary style="cursor:pointer;color:var(--text-dim);font-size:0.85rem">其他币种资产 (' + others.length + ')</summary>';
-      html += '<div class="holdings-table" style="margin-top:0.5rem"><table><thead><tr><th>币种</th><th>可用</th><th>冻结</th><th>总计</th></tr></thead><tbody>';
+      html += '<div class="holdings-table" style="margin-top:0.5rem"><table><thead><tr><th>币种</th><th>可用</th><th>冻结</th><th>总计</th><th>理财</th></tr></thead><tbody>';
       for (const a of others) {
         const fmtVal = (v) => v >= 1 ? v.toFixed(4) : v >= 0.0001 ? v.toFixed(6) : v.toFixed(8);
         html += `<tr>
@@ -605,6 +792,7 @@ async function loadBalance() {
           <td style="font-family:monospace">${fmtVal(a.free)}</td>
           <td style="font-family:monospace">${fmtVal(a.locked)}</td>
           <td style="font-family:monospace">${fmtVal(a.total)}</td>
+          <td style="font-family:monospace">${a.earn ? fmtVal(a.earn) : '-'}</td>
         </tr>`;
       }
       html += '</tbody></table></div></details>';
@@ -659,10 +847,12 @@ async function loadHoldings() {
       <div class="holdings-stat">
         <div class="stat-label">当前市值</div>
         <div class="stat-value">${totalValue.toFixed(2)} U</div>
+        ${fiatHint(data.display, 'total_value')}
       </div>
       <div class="holdings-stat">
         <div class="stat-label">未实现盈亏</div>
         <div class="stat-value ${pnlClass}">${pnlSign}${totalPnL.toFixed(2)} U</div>
+        ${fiatHint(data.display, 'total_pnl')}
       </div>
       <div class="holdings-stat">
         <div class="stat-label">盈亏比例</div>
@@ -691,30 +881,66 @@ async function loadHoldings() {
       return qty.toFixed(6);
     }
 
-    let html = '<div class="holdings-table"><table><thead><tr>';
-    html += '<th>币种</th><th>持有数量</th><th>均价</th><th>现价</th><th>成本(U)</th><th>市值(U)</th><th>盈亏(U)</th><th>盈亏%</th><th>来源</th>';
-    html += '</tr></thead><tbody>';
+    const spotHoldings = holdings.filter(h => !h.futures);
+    const futuresHoldings = holdings.filter(h => h.futures);
 
-    for (const h of holdings) {
-      const pnl = h.unrealized_pnl || 0;
-      const pct = h.pnl_percent || 0;
-      const pnlCls = pnl > 0 ? 'pnl-positive' : pnl < 0 ? 'pnl-negative' : 'pnl-zero';
-      const sign = pnl >= 0 ? '+' : '';
-      const sourceText = h.source === 'exchange' ? '交易所' : '本地';
+    let html = '';
+    if (spotHoldings.length > 0) {
+      html += '<div class="holdings-table"><table><thead><tr>';
+      html += '<th>币种</th><th>持有数量</th><th>均价</th><th>现价</th><th>成本(U)</th><th>市值(U)</th><th>盈亏(U)</th><th>盈亏%</th><th>来源</th><th>操作</th>';
+      html += '</tr></thead><tbody>';
+
+      for (const h of spotHoldings) {
+        const pnl = h.unrealized_pnl || 0;
+        const pct = h.pnl_percent || 0;
+        const pnlCls = pnl > 0 ? 'pnl-positive' : pnl < 0 ? 'pnl-negative' : 'pnl-zero';
+        const sign = pnl >= 0 ? '+' : '';
+        const sourceText = h.source === 'exchange' ? '交易所' : '本地';
 
-      html += `<tr>
-        <td><strong>${h.symbol}</strong></td>
-        <td style="font-family:monospace">${fmtQty(h.quantity)}</td>
-        <td style="font-family:monospace">${fmtPrice(h.avg_price)}</td>
-        <td style="font-family:monospace">${fmtPrice(h.current_price)}</td>
-        <td>${h.total_cost.toFixed(2)}</td>
-        <td>${(h.market_value || 0).toFixed(2)}</td>
-        <td class="${pnlCls}">${sign}${pnl.toFixed(2)}</td>
-        <td class="${pnlCls}">${sign}${pct.toFixed(2)}%</td>
-        <td style="color:var(--text-dim);font-size:0.8rem">${sourceText}</td>
-      </tr>`;
+        html += `<tr>
+          <td><strong>${h.symbol}</strong></td>
+          <td style="font-family:monospace">${fmtQty(h.quantity)}</td>
+          <td style="font-family:monospace">${fmtPrice(h.avg_price)}</td>
+          <td style="font-family:monospace">${fmtPrice(h.current_price)}</td>
+          <td>${h.total_cost.toFixed(2)}</td>
+          <td>${(h.market_value || 0).toFixed(2)}</td>
+          <td class="${pnlCls}">${sign}${pnl.toFixed(2)}</td>
+          <td class="${pnlCls}">${sign}${pct.toFixed(2)}%</td>
+          <td style="color:var(--text-dim);font-size:0.8rem">${sourceText}</td>
+          <td><button class="btn-view" onclick="forceClosePosition('${h.pair}')">强制平仓</button></td>
+        </tr>`;
+      }
+      html += '</tbody></table></div>';
+    }
+
+    // 合约持仓：开仓价 / 标记价 / 强平价 / 保证金 / 杠杆（来自 positionRisk）
+    if (futuresHoldings.length > 0) {
+      html += '<div class="holdings-table" style="margin-top:0.5rem"><table><thead><tr>';
+      html += '<th>合约</th><th>持仓数量</th><th>开仓价</th><th>标记价</th><th>强平价</th><th>保证金(U)</th><th>杠杆</th><th>盈亏(U)</th><th>收益率</th><th>操作</th>';
+      html += '</tr></thead><tbody>';
+      for (const h of futuresHoldings) {
+        const f = h.futures;
+        const pnl = f.unrealized_pnl || 0;
+        const pnlCls = pnl > 0 ? 'pnl-positive' : pnl < 0 ? 'pnl-negative' : 'pnl-zero';
+        const sign = pnl >= 0 ? '+' : '';
+        const marginType = f.margin_type === 'isolated' ? '逐仓' : f.margin_type === 'cross' ? '全仓' : '';
+        const est = f.estimated ? ' <span style="color:var(--text-dim);font-size:0.75rem">估算</span>' : '';
+
+        html += `<tr>
+          <td><strong>${h.symbol}</strong>${est}</td>
+          <td style="font-family:monospace">${fmtQty(h.quantity)}</td>
+          <td style="font-family:monospace">${fmtPrice(f.entry_price)}</td>
+          <td style="font-family:monospace">${fmtPrice(f.mark_price)}</td>
+          <td style="font-family:monospace;color:var(--red)">${fmtPrice(f.liquidation_price)}</td>
+          <td>${(f.margin || 0).toFixed(2)}</td>
+          <td>${f.leverage}x ${marginType}</td>
+          <td class="${pnlCls}">${sign}${pnl.toFixed(2)}</td>
+          <td class="${pnlCls}">${sign}${(f.roe || 0).toFixed(2)}%</td>
+          <td><button class="btn-view" onclick="forceClosePosition('${h.pair}')">强制平仓</button></td>
+        </tr>`;
+      }
+      html += '</tbody></table></div>';
     }
-    html += '</tbody></table></div>';
     listEl.innerHTML = html;
   } catch (err) {
     summaryEl.innerHTML = '';
@@ -722,6 +948,19 @@ async function loadHoldings() {
   }
 }
 
+// 强制平仓（紧急按钮）：不经过大模型与风控，直接市价全部平仓
+async function forceClosePosition(pair) {
+  if (!confirm(`确认立即市价平掉 ${pair} 的全部持仓？`)) return;
+  try {
+    await api('POST', `/holdings/${pair.replace('/', '-')}/close`);
+    showToast(`${pair} 已强制平仓`, 'success');
+    await loadHoldings();
+    await loadPositions();
+  } catch (err) {
+    showToast('强制平仓失败: ' + err.message);
+  }
+}
+
 // 从币安同步持仓
 document.getElementById('sync-exchange').addEventListener('click', async () => {
   const btn = document.getElementById('sync-exchange');
@@ -855,6 +1094,77 @@ document.getElementById('sync-trades').addEventListener('click', async () => {
   }
 });
 
+// ===== 交易所挂单 =====
+async function loadOpenOrders() {
+  const container = document.getElementById('open-orders-list');
+  try {
+    const data = await api('GET', '/orders/open');
+    const orders = data.orders || [];
+    if (orders.length === 0) {
+      container.innerHTML = '<p style="color:var(--text-dim)">暂无挂单</p>';
+      return;
+    }
+
+    const KIND = { stop_loss: '止损', take_profit: '止盈' };
+    let html = '<div class="positions-table"><table><thead><tr>';
+    html += '<th>时间</th><th>交易对</th><th>方向</th><th>类型</th><th>价格</th><th>触发价</th><th>数量</th><th>已成交</th><th>来源</th><th></th>';
+    html += '</tr></thead><tbody>';
+    for (const o of orders) {
+      const sideClass = o.side === 'buy' ? 'badge-long' : 'badge-short';
+      const qty = o.close_position ? '全部仓位' : (o.orig_qty || 0);
+      html += `<tr>
+        <td>${formatTime(o.created_at)}</td>
+        <td><strong>${escapeHtml(o.pair)}</strong> <span style="color:var(--text-dim);font-size:0.75rem">${o.mode}</span></td>
+        <td><span class="badge ${sideClass}">${o.side === 'buy' ? '买入' : '卖出'}</span></td>
+        <td>${escapeHtml(o.type)}</td>
+        <td style="font-family:monospace">${o.price > 0 ? o.price : '-'}</td>
+        <td style="font-family:monospace">${o.stop_price > 0 ? o.stop_price : '-'}</td>
+        <td style="font-family:monospace">${qty}</td>
+        <td style="font-family:monospace">${o.executed_qty || 0}</td>
+        <td style="color:var(--text-dim);font-size:0.8rem">${KIND[o.protective_kind] || '-'}</td>
+        <td><button class="btn btn-secondary" style="padding:0.2rem 0.6rem;font-size:0.75rem" onclick="cancelOpenOrder('${escapeHtml(o.pair)}', '${escapeHtml(o.exchange_order_id)}', ${!!o.protective_kind})">撤单</button></td>
+      </tr>`;
+    }
+    html += '</tbody></table></div>';
+    container.innerHTML = html;
+  } catch (err) {
+    container.innerHTML = `<p style="color:var(--red)">加载失败: ${err.message}</p>`;
+  }
+}
+
+async function cancelOpenOrder(pair, orderId, protective) {
+  const msg = protective
+    ? `确认撤销 ${pair} 的保护单 ${orderId}？\n\n撤销后该持仓在交易所将没有止盈止损保护。`
+    : `确认撤销 ${pair} 的挂单 ${orderId}？`;
+  if (!confirm(msg)) return;
+
+  try {
+    await api('DELETE', `/orders/${encodeURIComponent(orderId)}?pair=${encodeURIComponent(pair)}`);
+    showToast('已撤单', 'success');
+    loadOpenOrders();
+  } catch (err) {
+    showToast('撤单失败: ' + err.message);
+  }
+}
+
+document.getElementById('refresh-open-orders').addEventListener('click', loadOpenOrders);
+
+// ===== 人工审批 =====
+async function decideCycle(cycleId, action) {
+  const approve = action === 'approve';
+  if (!confirm(approve ? '确认按当前价格执行这笔下单？' : '确定驳回这笔下单？')) {
+    return;
+  }
+
+  try {
+    await api('POST', `/cycles/${cycleId}/${action}`, {});
+    showToast(approve ? '已批准并下单' : '已驳回');
+    loadCycles(cyclesCurrentPage);
+  } catch (err) {
+    showToast((approve ? '批准失败: ' : '驳回失败: ') + err.message);
+  }
+}
+
 // ===== 删除周期 =====
 async function deleteCycle(cycleId) {
   if (!confirm('确定要删除这个周期记录吗？此操作不可恢复。')) {
@@ -875,8 +1185,15 @@ checkHealth();
 loadBalance();
 loadHoldings();
 loadPositions();
+loadOpenOrders();
 loadCycles(1);
 setInterval(checkHealth, 15000);
 setInterval(loadBalance, 60000);   // 每分钟自动刷新余额
 setInterval(loadHoldings, 60000);  // 每分钟自动刷新持仓
-setInterval(() => loadCycles(cyclesCurrentPage), 60000); // 每分钟自动刷新周期列表
\ No newline at end of file
+setInterval(() => loadCycles(cyclesCurrentPage), 60000); // 每分钟自动刷新周期列表
+
+// ===== 退出登录 =====
+document.getElementById('logout-btn').addEventListener('click', async () => {
+  await fetch('/logout', { method: 'POST' }).catch(() => {});
+  window.location.href = '/login';
+});
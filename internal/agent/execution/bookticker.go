@@ -0,0 +1,54 @@
+package execution
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"ai_quant/internal/bookticker"
+	"ai_quant/internal/config"
+	"ai_quant/internal/domain"
+)
+
+// newBookTicker 按配置创建 bookTicker 缓存，并预先订阅定时任务的交易对；未启用时返回 nil
+func newBookTicker(cfg config.Config, name, streamURL string) *bookticker.Cache {
+	if !cfg.BookTickerEnabled || streamURL == "" {
+		return nil
+	}
+	book := bookticker.New(name, streamURL, time.Duration(cfg.BookTickerMaxAgeSec)*time.Second)
+	book.Track(strings.Split(cfg.AutoRunPairs, ",")...)
+	log.Printf("[盘口] %s bookTicker 已启用: %s", name, streamURL)
+	return book
+}
+
+// fillPrice 模拟成交与卖出估值使用的价格：bookTicker 有效时买入取卖一价、卖出取买一价，否则使用 fallback
+func fillPrice(book *bookticker.Cache, pair string, side domain.Side, fallback float64) float64 {
+	q, ok := book.Get(pair)
+	if !ok {
+		return fallback
+	}
+	if side == domain.SideLong {
+		return q.Ask
+	}
+	return q.Bid
+}
+
+// checkPriceSanity 实盘下单前用 bookTicker 中间价校验预估成交价，偏离超过 maxPct 时拒绝；
+// 缓存不可用或未设置阈值时跳过
+func checkPriceSanity(book *bookticker.Cache, pair string, estimated, maxPct float64) error {
+	if maxPct <= 0 || estimated <= 0 {
+		return nil
+	}
+	q, ok := book.Get(pair)
+	if !ok {
+		return nil
+	}
+	mid := q.Mid()
+	if dev := math.Abs(estimated-mid) / mid * 100; dev > maxPct {
+		return fmt.Errorf("预估成交价 %.8f 偏离实时盘口中间价 %.8f 达 %.2f%%（上限 %.2f%%），行情可能已过期",
+			estimated, mid, dev, maxPct)
+	}
+	return nil
+}
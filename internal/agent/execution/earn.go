@@ -0,0 +1,179 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EarnRedeemer 支持从活期理财（Binance Simple Earn Flexible）赎回到现货账户的执行器
+type EarnRedeemer interface {
+	RedeemEarn(ctx context.Context, asset string, amount float64) (float64, error)
+}
+
+// earnPosition 活期理财持仓
+type earnPosition struct {
+	ProductID string
+	Amount    float64
+	CanRedeem bool
+}
+
+// earnAsset 识别现货账户里的理财凭证资产（LDUSDT -> USDT）。
+// 只认 LD + 至少两位的币种，避免把 LDO 之类的真实币种当成凭证
+func earnAsset(asset string) (string, bool) {
+	rest, ok := strings.CutPrefix(asset, "LD")
+	if !ok || len(rest) < 2 {
+		return "", false
+	}
+	return rest, true
+}
+
+// foldEarnBalances 把 LD* 凭证并入对应币种的 Earn，凭证本身不再作为独立资产返回
+func foldEarnBalances(raw []Balance) []Balance {
+	out := make([]Balance, 0, len(raw))
+	earn := make(map[string]float64)
+	for _, b := range raw {
+		if underlying, ok := earnAsset(b.Symbol); ok {
+			earn[underlying] += b.Total
+			continue
+		}
+		out = append(out, b)
+	}
+	return applyEarn(out, earn)
+}
+
+// applyEarn 把理财数量写入对应币种，现货账户里没有该币种时补一条只含 Earn 的记录
+func applyEarn(balances []Balance, earn map[string]float64) []Balance {
+	for i := range balances {
+		if amt, ok := earn[balances[i].Symbol]; ok {
+			balances[i].Earn = amt
+			delete(earn, balances[i].Symbol)
+		}
+	}
+	for asset, amt := range earn {
+		if amt > 0 {
+			balances = append(balances, Balance{Symbol: asset, Earn: amt})
+		}
+	}
+	return balances
+}
+
+// fetchEarnPositions 查询活期理财持仓（需要 API Key 开启理财读取权限）
+func (e *BinanceExecutor) fetchEarnPositions(ctx context.Context) (map[string]earnPosition, error) {
+	params := url.Values{}
+	params.Set("size", "100")
+	setTimestamp(params)
+	params.Set("signature", e.sign(params.Encode()))
+
+	body, status, err := signedRequest(ctx, e.httpClient, e.apiKey, http.MethodGet, e.baseURL+"/sapi/v1/simple-earn/flexible/position", params)
+	if err != nil {
+		return nil, err
+	}
+	if status >= 300 {
+		return nil, fmt.Errorf("Binance HTTP %d: %s", status, string(body))
+	}
+
+	var result struct {
+		Rows []struct {
+			Asset       string `json:"asset"`
+			ProductID   string `json:"productId"`
+			TotalAmount string `json:"totalAmount"`
+			CanRedeem   bool   `json:"canRedeem"`
+		} `json:"rows"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析理财持仓失败: %w", err)
+	}
+	out := make(map[string]earnPosition, len(result.Rows))
+	for _, r := range result.Rows {
+		amt, _ := strconv.ParseFloat(r.TotalAmount, 64)
+		if amt <= 0 {
+			continue
+		}
+		out[r.Asset] = earnPosition{ProductID: r.ProductID, Amount: amt, CanRedeem: r.CanRedeem}
+	}
+	return out, nil
+}
+
+// earnRetryAfter 理财接口失败（如 Key 未开理财权限）后的重试间隔，避免每次查余额都多一次失败请求
+const earnRetryAfter = 10 * time.Minute
+
+// withEarnPositions 用理财接口的持仓覆盖 LD* 凭证推算的 Earn；接口不可用时保留凭证推算结果
+func (e *BinanceExecutor) withEarnPositions(ctx context.Context, balances []Balance) []Balance {
+	if time.Since(time.UnixMilli(e.earnFailedAt.Load())) < earnRetryAfter {
+		return balances
+	}
+	positions, err := e.fetchEarnPositions(ctx)
+	if err != nil {
+		e.earnFailedAt.Store(time.Now().UnixMilli())
+		log.Printf("[理财] ⚠ 查询活期理财持仓失败: %v，按 LD 凭证估算（%s 后重试）", err, earnRetryAfter)
+		return balances
+	}
+	earn := make(map[string]float64, len(positions))
+	for asset, p := range positions {
+		earn[asset] = p.Amount
+	}
+	for i := range balances {
+		balances[i].Earn = 0
+	}
+	return applyEarn(balances, earn)
+}
+
+// RedeemEarn 从活期理财赎回 amount 到现货账户，返回实际提交赎回的数量（不超过理财持仓）
+func (e *BinanceExecutor) RedeemEarn(ctx context.Context, asset string, amount float64) (float64, error) {
+	if e.dryRun {
+		return 0, fmt.Errorf("模拟盘不支持理财赎回")
+	}
+	if e.apiKey == "" || e.secretKey == "" {
+		return 0, fmt.Errorf("交易所 API Key 未配置，无法赎回理财")
+	}
+	positions, err := e.fetchEarnPositions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pos, ok := positions[asset]
+	if !ok || !pos.CanRedeem {
+		return 0, fmt.Errorf("%s 没有可赎回的活期理财", asset)
+	}
+	if amount > pos.Amount {
+		amount = pos.Amount
+	}
+
+	params := url.Values{}
+	params.Set("productId", pos.ProductID)
+	params.Set("amount", strconv.FormatFloat(amount, 'f', 8, 64))
+	params.Set("destAccount", "SPOT")
+	setTimestamp(params)
+	params.Set("signature", e.sign(params.Encode()))
+
+	body, status, err := signedRequest(ctx, e.httpClient, e.apiKey, http.MethodPost, e.baseURL+"/sapi/v1/simple-earn/flexible/redeem", params)
+	if err != nil {
+		return 0, err
+	}
+	if status >= 300 {
+		return 0, fmt.Errorf("Binance HTTP %d: %s", status, string(body))
+	}
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || !result.Success {
+		return 0, fmt.Errorf("理财赎回未成功: %s", string(body))
+	}
+	log.Printf("[理财] ✔ 已从活期理财赎回 %.4f %s 到现货账户", amount, asset)
+	return amount, nil
+}
+
+// RedeemEarn 理财属于现货账户，始终路由到现货执行器
+func (r *Router) RedeemEarn(ctx context.Context, asset string, amount float64) (float64, error) {
+	er, ok := r.executors["spot"].(EarnRedeemer)
+	if !ok {
+		return 0, fmt.Errorf("当前执行器不支持理财赎回")
+	}
+	return er.RedeemEarn(ctx, asset, amount)
+}
@@ -0,0 +1,91 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 支持的交易所
+const (
+	ExchangeBinance = "binance"
+	ExchangeOKX     = "okx"
+)
+
+// Ticker 24h 行情摘要
+type Ticker struct {
+	Price        float64
+	Change24hPct float64 // 24h 涨跌幅（百分比）
+}
+
+// Quoter 交易所行情报价。交易所适配层 = Executor（下单、余额、成交）+ Quoter（报价），
+// 可选能力（撤单查单 OrderTracker、止盈止损 ProtectiveOrderManager）按接口断言使用
+type Quoter interface {
+	FetchTicker(ctx context.Context, pair string) (Ticker, error)
+}
+
+// FetchTicker 通过交易对对应的执行器获取行情
+func FetchTicker(ctx context.Context, e Executor, pair string) (Ticker, error) {
+	q, ok := ForPair(e, pair).(Quoter)
+	if !ok {
+		return Ticker{}, fmt.Errorf("执行器不支持行情查询")
+	}
+	return q.FetchTicker(ctx, pair)
+}
+
+// normalizeExchange 规范化 EXCHANGE 配置，未知值返回错误
+func normalizeExchange(name string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", ExchangeBinance:
+		return ExchangeBinance, nil
+	case ExchangeOKX:
+		return ExchangeOKX, nil
+	default:
+		return "", fmt.Errorf("不支持的交易所: %q（可选 binance / okx）", name)
+	}
+}
+
+// FetchTicker 从 Binance 公开 API 获取 24h 价格和涨跌幅
+func (e *BinanceExecutor) FetchTicker(ctx context.Context, pair string) (Ticker, error) {
+	return binanceTicker(ctx, e.httpClient, "https://api.binance.com/api/v3/ticker/24hr?symbol="+pairToSymbol(pair))
+}
+
+// FetchTicker 从 Binance 合约公开 API 获取 24h 价格和涨跌幅
+func (e *BinanceFuturesExecutor) FetchTicker(ctx context.Context, pair string) (Ticker, error) {
+	return binanceTicker(ctx, e.httpClient, "https://fapi.binance.com/fapi/v1/ticker/24hr?symbol="+pairToSymbol(pair))
+}
+
+// FetchTicker 按交易对路由到对应执行器
+func (r *Router) FetchTicker(ctx context.Context, pair string) (Ticker, error) {
+	return FetchTicker(ctx, r.For(pair), pair)
+}
+
+func binanceTicker(ctx context.Context, client *http.Client, apiURL string) (Ticker, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return Ticker{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Ticker{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Ticker{}, fmt.Errorf("Binance ticker API %d", resp.StatusCode)
+	}
+
+	var raw struct {
+		LastPrice          string `json:"lastPrice"`
+		PriceChangePercent string `json:"priceChangePercent"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return Ticker{}, err
+	}
+	var t Ticker
+	t.Price, _ = strconv.ParseFloat(raw.LastPrice, 64)
+	t.Change24hPct, _ = strconv.ParseFloat(raw.PriceChangePercent, 64)
+	return t, nil
+}
@@ -9,15 +9,17 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"math"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"ai_quant/internal/bookticker"
 	"ai_quant/internal/config"
 	"ai_quant/internal/domain"
+	"ai_quant/internal/exchangeinfo"
 
 	"github.com/google/uuid"
 )
@@ -29,7 +31,28 @@ type Input struct {
 	Side          domain.Side
 	StakeUSDT     float64
 	EstimatedFill float64
-	SellQuantity  float64 // 卖出时的币数量（close 信号用）
+	SellQuantity  float64 // 卖出时的币数量（close 信号用，为全部持仓）
+	CloseFraction float64 // 平仓比例，(0,1) 时只卖出 SellQuantity 的对应部分，0 或 1 = 全部平仓
+	Leverage      int     // 合约杠杆，0 表示使用默认杠杆（现货忽略）
+	ForceMarket   bool    // 强制市价（止损类平仓不等待限价成交）
+	OrderType     string  // 下单方式覆盖：market / limit，空 = 按 ORDER_TYPE；限价只对 Binance 现货生效
+
+	// 幂等键：同一键只允许下一次单，并据此生成固定的 clientOrderId，交易所侧也能识别重复提交
+	IdempotencyKey string
+}
+
+// clientOrderID 有幂等键时由键派生固定的 clientOrderId，否则随机生成；n 为随机部分长度
+func (in Input) clientOrderID(n int) string {
+	if in.IdempotencyKey != "" {
+		sum := sha256.Sum256([]byte(in.IdempotencyKey))
+		return "aq" + hex.EncodeToString(sum[:])[:n]
+	}
+	return "aq" + strings.ReplaceAll(uuid.NewString(), "-", "")[:n]
+}
+
+// sellQuantity 按平仓比例计算本次实际卖出数量
+func (in Input) sellQuantity() float64 {
+	return in.SellQuantity * domain.NormalizeCloseFraction(in.CloseFraction)
 }
 
 // Balance 交易所账户余额
@@ -38,10 +61,12 @@ type Balance struct {
 	Free   float64 // 可用余额
 	Locked float64 // 冻结余额
 	Total  float64 // Free + Locked
+	Earn   float64 // 活期理财中可赎回的数量（LD* 凭证 / Simple Earn），不计入 Free/Total
 }
 
-// Trade 币安成交记录
+// Trade 交易所成交记录
 type Trade struct {
+	Exchange  string // binance / okx
 	TradeID   int64
 	OrderID   int64
 	Symbol    string
@@ -70,34 +95,72 @@ type BinanceExecutor struct {
 	apiKey     string
 	secretKey  string
 	dryRun     bool
+
+	exchangeInfo      *exchangeinfo.Cache // 交易规则（tickSize / stepSize / 最小名义价值）
+	stopLimitSlippage float64             // 止损限价相对触发价的下浮比例（%）
+	earnFailedAt      atomic.Int64        // 理财接口最近一次失败时间（毫秒），用于退避
+
+	book           *bookticker.Cache // 实时买一卖一价，未启用时为 nil
+	priceSanityPct float64           // 预估成交价偏离盘口中间价的上限（%）
+	paperSlippage  float64           // 模拟成交滑点（基点）
+
+	limit LimitOrder // ORDER_TYPE=limit 时的限价下单规则
+
+	stream *userStream // 用户数据流，未启用时为 nil
 }
 
 func New(cfg config.Config) Executor {
-	return &BinanceExecutor{
-		httpClient: &http.Client{Timeout: 15 * time.Second},
+	e := &BinanceExecutor{
+		httpClient: newSignedClient(15 * time.Second),
 		baseURL:    strings.TrimRight(cfg.ExchangeBaseURL, "/"),
 		apiKey:     cfg.ExchangeAPIKey,
 		secretKey:  cfg.ExchangeSecretKey,
 		dryRun:     cfg.DryRun,
+
+		exchangeInfo:      exchangeinfo.NewSpot(cfg.ExchangeBaseURL),
+		stopLimitSlippage: cfg.StopLimitSlippagePct,
+
+		book:           newBookTicker(cfg, "现货", cfg.SpotStreamURL),
+		priceSanityPct: cfg.PriceSanityMaxPct,
+		paperSlippage:  paperSlippageBps(cfg),
+
+		limit: limitOrderFromConfig(cfg),
 	}
+	e.stream = newUserStream(cfg, "现货", e.httpClient, e.baseURL+"/api/v3/userDataStream", cfg.SpotStreamURL)
+	preloadExchangeInfo(e.exchangeInfo)
+	if e.apiKey != "" {
+		go calibrateClock(e.httpClient, e.baseURL+"/api/v3/time")
+	}
+	return e
 }
 
 func (e *BinanceExecutor) Execute(ctx context.Context, input Input) (domain.Order, error) {
 	order := domain.Order{
-		ID:            uuid.NewString(),
-		CycleID:       input.CycleID,
-		SignalID:      input.SignalID,
-		ClientOrderID: fmt.Sprintf("aq%s", uuid.NewString()[:8]),
-		Pair:          input.Pair,
-		Side:          input.Side,
-		StakeUSDT:     input.StakeUSDT,
-		Status:        "created",
-		CreatedAt:     time.Now().UTC(),
-	}
+		ID:             uuid.NewString(),
+		CycleID:        input.CycleID,
+		SignalID:       input.SignalID,
+		ClientOrderID:  input.clientOrderID(16),
+		IdempotencyKey: input.IdempotencyKey,
+		Pair:           input.Pair,
+		Side:           input.Side,
+		StakeUSDT:      input.StakeUSDT,
+		ExpectedPrice:  input.EstimatedFill,
+		FillStrategy:   domain.FillMarket,
+		Status:         "created",
+		CreatedAt:      time.Now().UTC(),
+	}
+	useLimit := (e.limit.Enabled || input.OrderType == OrderTypeLimit) && input.OrderType != OrderTypeMarket && !input.ForceMarket
 
 	// 模拟模式：不调交易所
 	if e.dryRun {
-		estimatedFill := input.EstimatedFill
+		estimatedFill := fillPrice(e.book, input.Pair, input.Side, input.EstimatedFill)
+		if useLimit {
+			// 模拟限价单按挂单价（买一买入 / 卖一卖出）立即成交
+			if q, ok := e.book.Get(input.Pair); ok {
+				estimatedFill = e.limit.limitPrice(input.Side, bookQuote{Bid: q.Bid, Ask: q.Ask}, 0)
+			}
+			order.FillStrategy = domain.FillLimit
+		}
 		// 如果没有价格，尝试从 Binance 获取实时价格
 		if estimatedFill <= 0 {
 			if price, err := e.fetchCurrentPrice(ctx, input.Pair); err == nil && price > 0 {
@@ -105,6 +168,10 @@ func (e *BinanceExecutor) Execute(ctx context.Context, input Input) (domain.Orde
 				log.Printf("[执行] 获取实时价格: %s = %.8f", input.Pair, price)
 			}
 		}
+		// 限价单以挂单价成交，不计滑点
+		if !useLimit {
+			estimatedFill = paperFillPrice(estimatedFill, input.Side, e.paperSlippage)
+		}
 
 		order.Status = "simulated_filled"
 		order.ExchangeOrderID = "dryrun-" + order.ID
@@ -114,8 +181,8 @@ func (e *BinanceExecutor) Execute(ctx context.Context, input Input) (domain.Orde
 		// 计算模拟成交数量
 		if estimatedFill > 0 && input.Side == domain.SideLong {
 			order.FilledQuantity = input.StakeUSDT / estimatedFill
-		} else if input.SellQuantity > 0 {
-			order.FilledQuantity = input.SellQuantity
+		} else if input.sellQuantity() > 0 {
+			order.FilledQuantity = input.sellQuantity()
 		}
 
 		action := "买入"
@@ -133,6 +200,14 @@ func (e *BinanceExecutor) Execute(ctx context.Context, input Input) (domain.Orde
 		return order, fmt.Errorf("交易所 API Key 未配置，无法实盘下单")
 	}
 
+	if err := checkPriceSanity(e.book, input.Pair, input.EstimatedFill, e.priceSanityPct); err != nil {
+		order.Status = "rejected"
+		return order, err
+	}
+	if useLimit {
+		return e.executeLimit(ctx, input, order)
+	}
+
 	symbol := pairToSymbol(input.Pair)
 	side := "BUY"
 	if input.Side == domain.SideClose {
@@ -144,29 +219,31 @@ func (e *BinanceExecutor) Execute(ctx context.Context, input Input) (domain.Orde
 	params.Set("side", side)
 	params.Set("type", "MARKET")
 	params.Set("newClientOrderId", order.ClientOrderID)
-	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	setTimestamp(params)
 
+	lot := lotFilters(ctx, e.exchangeInfo, symbol, false)
 	if side == "BUY" {
 		// 买入：用 quoteOrderQty 按 USDT 金额
+		if lot.MinNotional > 0 && input.StakeUSDT < lot.MinNotional {
+			order.Status = "rejected"
+			return order, fmt.Errorf("下单金额 %.2f USDT 低于 %s 最小名义价值 %g USDT", input.StakeUSDT, symbol, lot.MinNotional)
+		}
 		params.Set("quoteOrderQty", strconv.FormatFloat(input.StakeUSDT, 'f', 2, 64))
 	} else {
 		// 卖出：用 quantity 按币数量
-		if input.SellQuantity > 0 {
-			// 根据交易对调整数量精度（Binance LOT_SIZE 要求）
-			qty := quantityPrecision(symbol, input.SellQuantity)
+		if input.sellQuantity() > 0 {
+			// 按交易所 LOT_SIZE stepSize 调整数量精度
+			qty := formatQuantity(lot, input.sellQuantity())
 
-			// 检查格式化后的数量是否有效（防止灰尘持仓）
-			qtyFloat, _ := strconv.ParseFloat(qty, 64)
-			if qtyFloat <= 0 {
+			// 检查格式化后的数量是否满足最小交易量 / 名义价值（防止灰尘持仓）
+			if err := checkLot(lot, symbol, qty, fillPrice(e.book, input.Pair, input.Side, input.EstimatedFill)); err != nil {
 				order.Status = "rejected"
-				minQty := getMinQuantity(symbol)
-				log.Printf("[执行] ⚠ 卖出数量不足: %.8f < 最小交易量 %.0f，跳过交易", input.SellQuantity, minQty)
-				return order, fmt.Errorf("卖出数量不足: %.8f %s 低于最小交易量 %.0f（灰尘持仓无法交易）",
-					input.SellQuantity, symbol, minQty)
+				log.Printf("[执行] ⚠ 卖出数量不足: 原始=%.8f %v，跳过交易", input.sellQuantity(), err)
+				return order, fmt.Errorf("卖出数量不足: %w", err)
 			}
 
 			params.Set("quantity", qty)
-			log.Printf("[执行] 卖出数量: 原始=%.8f 格式化=%s", input.SellQuantity, qty)
+			log.Printf("[执行] 卖出数量: 原始=%.8f 格式化=%s", input.sellQuantity(), qty)
 		} else {
 			// 没有指定数量，按 USDT 金额估算
 			params.Set("quoteOrderQty", strconv.FormatFloat(input.StakeUSDT, 'f', 2, 64))
@@ -200,6 +277,7 @@ func (e *BinanceExecutor) Execute(ctx context.Context, input Input) (domain.Orde
 		return order, fmt.Errorf("读取响应失败: %w", err)
 	}
 	order.RawResponse = string(respBytes)
+	noteKeyResponse(resp.StatusCode, respBytes)
 
 	if resp.StatusCode >= 300 {
 		order.Status = "rejected"
@@ -212,6 +290,7 @@ func (e *BinanceExecutor) Execute(ctx context.Context, input Input) (domain.Orde
 		OrderID       int64  `json:"orderId"`
 		ClientOrderID string `json:"clientOrderId"`
 		Status        string `json:"status"`
+		OrigQty       string `json:"origQty"`
 		Fills         []struct {
 			Price string `json:"price"`
 			Qty   string `json:"qty"`
@@ -235,6 +314,8 @@ func (e *BinanceExecutor) Execute(ctx context.Context, input Input) (domain.Orde
 				order.FilledQuantity = totalQty
 			}
 		}
+		order.RequestedQty, _ = strconv.ParseFloat(result.OrigQty, 64)
+		order.Status = settleStatus(order.Status, order.FilledQuantity)
 	}
 
 	log.Printf("[执行] ✔ Binance 订单完成: ID=%s 状态=%s 成交价=%.4f",
@@ -320,7 +401,7 @@ func (e *BinanceExecutor) FetchAccountBalances(ctx context.Context) ([]Balance,
 	}
 
 	params := url.Values{}
-	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	setTimestamp(params)
 	signature := e.sign(params.Encode())
 	params.Set("signature", signature)
 
@@ -362,8 +443,11 @@ func (e *BinanceExecutor) FetchAccountBalances(ctx context.Context) ([]Balance,
 		free, _ := strconv.ParseFloat(b.Free, 64)
 		locked, _ := strconv.ParseFloat(b.Locked, 64)
 		total := free + locked
-		// 只保留非零余额，过滤掉 USDT 本身（我们关心的是持仓币种）
-		if total > 0 && b.Asset != "USDT" && b.Asset != "BNB" && b.Asset != "LDUSDT" {
+		// 只保留非零余额，过滤掉 USDT 本身和理财凭证（我们关心的是持仓币种）
+		if _, isEarn := earnAsset(b.Asset); isEarn {
+			continue
+		}
+		if total > 0 && b.Asset != "USDT" && b.Asset != "BNB" {
 			balances = append(balances, Balance{
 				Symbol: b.Asset,
 				Free:   free,
@@ -377,14 +461,14 @@ func (e *BinanceExecutor) FetchAccountBalances(ctx context.Context) ([]Balance,
 	return balances, nil
 }
 
-// FetchFullBalance 获取完整余额（含 USDT、BNB 等所有非零资产）
+// FetchFullBalance 获取完整余额（含 USDT、BNB 等所有非零资产），活期理财计入对应币种的 Earn
 func (e *BinanceExecutor) FetchFullBalance(ctx context.Context) ([]Balance, error) {
 	if e.apiKey == "" || e.secretKey == "" {
 		return nil, fmt.Errorf("交易所 API Key 未配置")
 	}
 
 	params := url.Values{}
-	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	setTimestamp(params)
 	signature := e.sign(params.Encode())
 	params.Set("signature", signature)
 
@@ -434,7 +518,7 @@ func (e *BinanceExecutor) FetchFullBalance(ctx context.Context) ([]Balance, erro
 			})
 		}
 	}
-	return balances, nil
+	return e.withEarnPositions(ctx, foldEarnBalances(balances)), nil
 }
 
 // FetchTradeHistory 从 Binance 获取指定交易对的成交历史
@@ -450,7 +534,7 @@ func (e *BinanceExecutor) FetchTradeHistory(ctx context.Context, pair string, li
 	params := url.Values{}
 	params.Set("symbol", symbol)
 	params.Set("limit", strconv.Itoa(limit))
-	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	setTimestamp(params)
 	signature := e.sign(params.Encode())
 	params.Set("signature", signature)
 
@@ -495,6 +579,7 @@ func (e *BinanceExecutor) FetchTradeHistory(ctx context.Context, pair string, li
 		qty, _ := strconv.ParseFloat(r.Qty, 64)
 		quoteQty, _ := strconv.ParseFloat(r.QuoteQty, 64)
 		trades = append(trades, Trade{
+			Exchange:  ExchangeBinance,
 			TradeID:   r.ID,
 			OrderID:   r.OrderID,
 			Symbol:    symbol,
@@ -520,55 +605,3 @@ func pairToSymbol(pair string) string {
 	}
 	return out
 }
-
-// getMinQuantity 获取交易对的最小交易数量
-// Binance 每个交易对有不同的 minQty 要求
-func getMinQuantity(symbol string) float64 {
-	sym := strings.ToUpper(symbol)
-	switch {
-	case strings.HasPrefix(sym, "DOGE"):
-		return 1 // DOGE 最小交易 1 个
-	case strings.HasPrefix(sym, "XRP"):
-		return 1 // XRP 最小交易 1 个
-	case strings.HasPrefix(sym, "BNB"):
-		return 0.01
-	case strings.HasPrefix(sym, "SOL"):
-		return 0.01
-	case strings.HasPrefix(sym, "ETH"):
-		return 0.0001
-	case strings.HasPrefix(sym, "BTC"):
-		return 0.00001
-	default:
-		return 1
-	}
-}
-
-// quantityPrecision 根据交易对返回正确精度的数量字符串
-// Binance LOT_SIZE 要求不同币的 stepSize 不同：
-//
-//	DOGEUSDT stepSize=1（整数）, XRPUSDT stepSize=0.1, BTCUSDT stepSize=0.00001
-func quantityPrecision(symbol string, qty float64) string {
-	sym := strings.ToUpper(symbol)
-	var decimals int
-	switch {
-	case strings.HasPrefix(sym, "DOGE"):
-		decimals = 0          // stepSize=1，必须整数
-		qty = math.Floor(qty) // 向下取整，避免超过持仓
-	case strings.HasPrefix(sym, "XRP"):
-		decimals = 1 // stepSize=0.1
-		qty = math.Floor(qty*10) / 10
-	case strings.HasPrefix(sym, "BNB"), strings.HasPrefix(sym, "SOL"):
-		decimals = 2 // stepSize=0.01
-		qty = math.Floor(qty*100) / 100
-	case strings.HasPrefix(sym, "ETH"):
-		decimals = 4 // stepSize=0.0001
-		qty = math.Floor(qty*10000) / 10000
-	case strings.HasPrefix(sym, "BTC"):
-		decimals = 5 // stepSize=0.00001
-		qty = math.Floor(qty*100000) / 100000
-	default:
-		decimals = 2
-		qty = math.Floor(qty*100) / 100
-	}
-	return strconv.FormatFloat(qty, 'f', decimals, 64)
-}
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -14,14 +15,23 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"ai_quant/internal/bookticker"
 	"ai_quant/internal/config"
 	"ai_quant/internal/domain"
+	"ai_quant/internal/exchangeinfo"
 
 	"github.com/google/uuid"
 )
 
+// codePositionSideMismatch 订单的 positionSide 与账户持仓模式不符（运行中切换了单向 / 双向持仓）
+const codePositionSideMismatch = -4061
+
+// ErrShortPositionOpen 交易对存在空头仓位；本系统只做多，不在有空头敞口的交易对上开多
+var ErrShortPositionOpen = errors.New("short position open")
+
 // BinanceFuturesExecutor 通过 Binance USDT-M 永续合约 API 下单
 type BinanceFuturesExecutor struct {
 	httpClient *http.Client
@@ -31,18 +41,37 @@ type BinanceFuturesExecutor struct {
 	dryRun     bool
 	leverage   int
 	marginType string // "CROSSED" 或 "ISOLATED"
+
+	mu             sync.Mutex
+	hedgeMode      bool           // 账户为双向持仓模式，下单需带 positionSide；下单报 -4061 时重新查询
+	symbolLeverage map[string]int // 各交易对在交易所上已设置的杠杆
+
+	exchangeInfo *exchangeinfo.Cache // 交易规则（tickSize / stepSize / 最小名义价值）
+
+	book           *bookticker.Cache // 实时买一卖一价，未启用时为 nil
+	priceSanityPct float64           // 预估成交价偏离盘口中间价的上限（%）
+	paperSlippage  float64           // 模拟成交滑点（基点）
+
+	stream *userStream // 用户数据流，未启用时为 nil
 }
 
 // NewFutures 创建合约 Executor，启动时自动设置杠杆和保证金模式
 func NewFutures(cfg config.Config) Executor {
 	e := &BinanceFuturesExecutor{
-		httpClient: &http.Client{Timeout: 15 * time.Second},
+		httpClient: newSignedClient(15 * time.Second),
 		baseURL:    strings.TrimRight(cfg.FuturesBaseURL, "/"),
 		apiKey:     cfg.ExchangeAPIKey,
 		secretKey:  cfg.ExchangeSecretKey,
 		dryRun:     cfg.DryRun,
 		leverage:   cfg.FuturesLeverage,
 		marginType: cfg.FuturesMarginType,
+
+		symbolLeverage: make(map[string]int),
+		exchangeInfo:   exchangeinfo.NewFutures(cfg.FuturesBaseURL),
+
+		book:           newBookTicker(cfg, "合约", cfg.FuturesStreamURL),
+		priceSanityPct: cfg.PriceSanityMaxPct,
+		paperSlippage:  paperSlippageBps(cfg),
 	}
 
 	// 限制杠杆范围 2-20
@@ -53,6 +82,9 @@ func NewFutures(cfg config.Config) Executor {
 		e.leverage = 20
 	}
 
+	preloadExchangeInfo(e.exchangeInfo)
+	e.stream = newUserStream(cfg, "合约", e.httpClient, e.baseURL+"/fapi/v1/listenKey", cfg.FuturesStreamURL)
+
 	log.Printf("[合约] 初始化: baseURL=%s 杠杆=%dx 保证金=%s dryRun=%v",
 		e.baseURL, e.leverage, e.marginType, e.dryRun)
 
@@ -61,6 +93,10 @@ func NewFutures(cfg config.Config) Executor {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
+		// 设置杠杆等签名请求前先校准服务器时间，避免本机时钟漂移导致 -1021
+		calibrateClock(e.httpClient, e.baseURL+"/fapi/v1/time")
+		e.detectPositionMode(ctx)
+
 		pairs := strings.Split(cfg.AutoRunPairs, ",")
 		for _, pair := range pairs {
 			pair = strings.TrimSpace(pair)
@@ -68,7 +104,9 @@ func NewFutures(cfg config.Config) Executor {
 				continue
 			}
 			symbol := strings.ReplaceAll(strings.ToUpper(pair), "/", "")
-			e.setupLeverage(ctx, symbol)
+			if e.setupLeverage(ctx, symbol, e.leverage) {
+				e.symbolLeverage[symbol] = e.leverage
+			}
 			e.setupMarginType(ctx, symbol)
 		}
 	}
@@ -76,12 +114,73 @@ func NewFutures(cfg config.Config) Executor {
 	return e
 }
 
-// setupLeverage 设置交易对的杠杆倍数
-func (e *BinanceFuturesExecutor) setupLeverage(ctx context.Context, symbol string) {
+// detectPositionMode 查询账户持仓模式：双向持仓下单必须带 positionSide，否则交易所拒单。
+// 查询失败时按单向持仓处理
+func (e *BinanceFuturesExecutor) detectPositionMode(ctx context.Context) {
+	params := url.Values{}
+	setTimestamp(params)
+	params.Set("signature", e.sign(params.Encode()))
+
+	body, status, err := signedRequest(ctx, e.httpClient, e.apiKey, http.MethodGet, e.baseURL+"/fapi/v1/positionSide/dual", params)
+	if err != nil {
+		log.Printf("[合约] ⚠ 查询持仓模式失败: %v，按单向持仓处理", err)
+		return
+	}
+	if status >= 300 {
+		log.Printf("[合约] ⚠ 查询持仓模式失败: HTTP %d %s，按单向持仓处理", status, string(body))
+		return
+	}
+	var result struct {
+		DualSidePosition bool `json:"dualSidePosition"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		log.Printf("[合约] ⚠ 解析持仓模式失败: %v，按单向持仓处理", err)
+		return
+	}
+	e.mu.Lock()
+	e.hedgeMode = result.DualSidePosition
+	e.mu.Unlock()
+	if result.DualSidePosition {
+		log.Printf("[合约] ✔ 账户为双向持仓模式，订单使用 positionSide=LONG")
+	} else {
+		log.Printf("[合约] ✔ 账户为单向持仓模式")
+	}
+}
+
+// isHedgeMode 账户是否为双向持仓模式
+func (e *BinanceFuturesExecutor) isHedgeMode() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.hedgeMode
+}
+
+// postOrder 签名并提交合约订单。交易所返回 -4061 说明持仓模式已在运行中切换，
+// 重新查询持仓模式后用 build 重建参数（positionSide / reduceOnly）再提交一次
+func (e *BinanceFuturesExecutor) postOrder(ctx context.Context, build func() url.Values) ([]byte, int, error) {
+	send := func() ([]byte, int, error) {
+		params := build()
+		setTimestamp(params)
+		params.Set("signature", e.sign(params.Encode()))
+		return signedRequest(ctx, e.httpClient, e.apiKey, http.MethodPost, e.baseURL+"/fapi/v1/order", params)
+	}
+	body, status, err := send()
+	if err != nil || status < 300 {
+		return body, status, err
+	}
+	if code, _ := binanceError(body); code == codePositionSideMismatch {
+		log.Printf("[合约] ⚠ 持仓模式与订单不符（%d），重新查询持仓模式后重试", code)
+		e.detectPositionMode(ctx)
+		return send()
+	}
+	return body, status, nil
+}
+
+// setupLeverage 设置交易对的杠杆倍数，返回是否设置成功
+func (e *BinanceFuturesExecutor) setupLeverage(ctx context.Context, symbol string, leverage int) bool {
 	params := url.Values{}
 	params.Set("symbol", symbol)
-	params.Set("leverage", strconv.Itoa(e.leverage))
-	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	params.Set("leverage", strconv.Itoa(leverage))
+	setTimestamp(params)
 
 	signature := e.sign(params.Encode())
 	params.Set("signature", signature)
@@ -90,7 +189,7 @@ func (e *BinanceFuturesExecutor) setupLeverage(ctx context.Context, symbol strin
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, strings.NewReader(params.Encode()))
 	if err != nil {
 		log.Printf("[合约] 设置杠杆请求构建失败 %s: %v", symbol, err)
-		return
+		return false
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("X-MBX-APIKEY", e.apiKey)
@@ -98,16 +197,65 @@ func (e *BinanceFuturesExecutor) setupLeverage(ctx context.Context, symbol strin
 	resp, err := e.httpClient.Do(req)
 	if err != nil {
 		log.Printf("[合约] 设置杠杆请求失败 %s: %v", symbol, err)
-		return
+		return false
 	}
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode >= 300 {
 		log.Printf("[合约] ⚠ 设置杠杆失败 %s: HTTP %d %s", symbol, resp.StatusCode, string(body))
-	} else {
-		log.Printf("[合约] ✔ 杠杆已设置 %s: %dx", symbol, e.leverage)
+		return false
 	}
+	log.Printf("[合约] ✔ 杠杆已设置 %s: %dx", symbol, leverage)
+	return true
+}
+
+// effectiveLeverage 返回本次下单使用的杠杆：风险预设指定了不同杠杆时先同步到交易所，
+// 设置失败则沿用交易所当前杠杆；平仓不调整杠杆
+func (e *BinanceFuturesExecutor) effectiveLeverage(ctx context.Context, input Input) int {
+	want := input.Leverage
+	if want <= 0 {
+		want = e.leverage
+	}
+	if want > 20 {
+		want = 20
+	}
+	if e.dryRun || e.apiKey == "" {
+		return want
+	}
+
+	symbol := strings.ReplaceAll(strings.ToUpper(input.Pair), "/", "")
+	e.mu.Lock()
+	current, ok := e.symbolLeverage[symbol]
+	e.mu.Unlock()
+	if !ok {
+		current = e.leverage
+	}
+	if current == want || input.Side == domain.SideClose {
+		return current
+	}
+	if !e.setupLeverage(ctx, symbol, want) {
+		return current
+	}
+	e.mu.Lock()
+	e.symbolLeverage[symbol] = want
+	e.mu.Unlock()
+	return want
+}
+
+// SetLeverage 手动调整交易对杠杆：先同步到交易所，成功后记为该交易对当前杠杆
+func (e *BinanceFuturesExecutor) SetLeverage(ctx context.Context, pair string, leverage int) error {
+	if leverage < 1 || leverage > 20 {
+		return fmt.Errorf("杠杆倍数需在 1-20 之间: %d", leverage)
+	}
+	symbol := strings.ReplaceAll(strings.ToUpper(pair), "/", "")
+	if !e.dryRun && e.apiKey != "" && !e.setupLeverage(ctx, symbol, leverage) {
+		return fmt.Errorf("交易所设置 %s 杠杆 %dx 失败", symbol, leverage)
+	}
+	e.mu.Lock()
+	e.symbolLeverage[symbol] = leverage
+	e.mu.Unlock()
+	return nil
 }
 
 // setupMarginType 设置保证金模式（全仓/逐仓）
@@ -115,7 +263,7 @@ func (e *BinanceFuturesExecutor) setupMarginType(ctx context.Context, symbol str
 	params := url.Values{}
 	params.Set("symbol", symbol)
 	params.Set("marginType", e.marginType)
-	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	setTimestamp(params)
 
 	signature := e.sign(params.Encode())
 	params.Set("signature", signature)
@@ -147,39 +295,43 @@ func (e *BinanceFuturesExecutor) setupMarginType(ctx context.Context, symbol str
 
 // Execute 执行合约交易
 func (e *BinanceFuturesExecutor) Execute(ctx context.Context, input Input) (domain.Order, error) {
+	lev := e.effectiveLeverage(ctx, input)
 	order := domain.Order{
-		ID:            uuid.NewString(),
-		CycleID:       input.CycleID,
-		SignalID:      input.SignalID,
-		ClientOrderID: fmt.Sprintf("aq%s", uuid.NewString()[:8]),
-		Pair:          input.Pair,
-		Side:          input.Side,
-		StakeUSDT:     input.StakeUSDT,
-		Leverage:      e.leverage,
-		Status:        "created",
-		CreatedAt:     time.Now().UTC(),
+		ID:             uuid.NewString(),
+		CycleID:        input.CycleID,
+		SignalID:       input.SignalID,
+		ClientOrderID:  input.clientOrderID(16),
+		IdempotencyKey: input.IdempotencyKey,
+		Pair:           input.Pair,
+		Side:           input.Side,
+		StakeUSDT:      input.StakeUSDT,
+		ExpectedPrice:  input.EstimatedFill,
+		Leverage:       lev,
+		Status:         "created",
+		CreatedAt:      time.Now().UTC(),
 	}
 
 	// 模拟模式
 	if e.dryRun {
-		estimatedFill := input.EstimatedFill
+		estimatedFill := fillPrice(e.book, input.Pair, input.Side, input.EstimatedFill)
 		if estimatedFill <= 0 {
 			if price, err := e.fetchCurrentPrice(ctx, input.Pair); err == nil && price > 0 {
 				estimatedFill = price
 				log.Printf("[合约] 获取实时价格: %s = %.8f", input.Pair, price)
 			}
 		}
+		estimatedFill = paperFillPrice(estimatedFill, input.Side, e.paperSlippage)
 
 		order.Status = "simulated_filled"
 		order.ExchangeOrderID = "dryrun-futures-" + order.ID
 		order.FilledPrice = estimatedFill
-		order.RawResponse = fmt.Sprintf(`{"mode":"dry_run","leverage":%d}`, e.leverage)
+		order.RawResponse = fmt.Sprintf(`{"mode":"dry_run","leverage":%d}`, lev)
 
 		if estimatedFill > 0 && input.Side == domain.SideLong {
 			// 合约：保证金 * 杠杆 / 价格 = 开仓数量
-			order.FilledQuantity = (input.StakeUSDT * float64(e.leverage)) / estimatedFill
-		} else if input.SellQuantity > 0 {
-			order.FilledQuantity = input.SellQuantity
+			order.FilledQuantity = (input.StakeUSDT * float64(lev)) / estimatedFill
+		} else if input.sellQuantity() > 0 {
+			order.FilledQuantity = input.sellQuantity()
 		}
 
 		action := "开多"
@@ -187,7 +339,7 @@ func (e *BinanceFuturesExecutor) Execute(ctx context.Context, input Input) (doma
 			action = "平仓"
 		}
 		log.Printf("[合约] 模拟%s: %s %s 保证金=%.2f USDT x%d @ %.8f 数量=%.4f",
-			action, input.Side, input.Pair, input.StakeUSDT, e.leverage, estimatedFill, order.FilledQuantity)
+			action, input.Side, input.Pair, input.StakeUSDT, lev, estimatedFill, order.FilledQuantity)
 		return order, nil
 	}
 
@@ -197,38 +349,50 @@ func (e *BinanceFuturesExecutor) Execute(ctx context.Context, input Input) (doma
 		return order, fmt.Errorf("交易所 API Key 未配置，无法实盘下单")
 	}
 
+	if err := checkPriceSanity(e.book, input.Pair, input.EstimatedFill, e.priceSanityPct); err != nil {
+		order.Status = "rejected"
+		return order, err
+	}
+
 	symbol := strings.ReplaceAll(strings.ToUpper(input.Pair), "/", "")
 	side := "BUY"
 	if input.Side == domain.SideClose {
 		side = "SELL"
 	}
 
-	params := url.Values{}
-	params.Set("symbol", symbol)
-	params.Set("side", side)
-	params.Set("type", "MARKET")
-	params.Set("newClientOrderId", order.ClientOrderID)
-	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
-
+	lot := lotFilters(ctx, e.exchangeInfo, symbol, true)
+	var qty string
 	if side == "BUY" {
+		// 本系统只做多：交易对已有空头仓位（手动开的）时拒绝开多，避免与未跟踪的空头敞口叠加
+		if _, shortAmt, err := e.fetchPositionRisk(ctx, input.Pair); err != nil {
+			log.Printf("[合约] ⚠ %s 查询空头仓位失败: %v", symbol, err)
+		} else if shortAmt > 0 {
+			order.Status = "rejected"
+			return order, fmt.Errorf("%w: %s 空头数量 %.8f，拒绝开多", ErrShortPositionOpen, symbol, shortAmt)
+		}
 		// 开多：用保证金 * 杠杆计算开仓数量
-		if input.EstimatedFill > 0 {
-			rawQty := (input.StakeUSDT * float64(e.leverage)) / input.EstimatedFill
-			qty := futuresQuantityPrecision(symbol, rawQty)
-			params.Set("quantity", qty)
+		if price := fillPrice(e.book, input.Pair, input.Side, input.EstimatedFill); price > 0 {
+			rawQty := (input.StakeUSDT * float64(lev)) / price
+			qty = formatQuantity(lot, rawQty)
+			if err := checkLot(lot, symbol, qty, price); err != nil {
+				order.Status = "rejected"
+				return order, fmt.Errorf("开仓数量不足: %w", err)
+			}
 			log.Printf("[合约] 开多数量: 保证金=%.2f x%d / 价格=%.8f = %s",
-				input.StakeUSDT, e.leverage, input.EstimatedFill, qty)
+				input.StakeUSDT, lev, price, qty)
 		} else {
 			// 没有预估价格，无法计算数量
 			order.Status = "rejected"
 			return order, fmt.Errorf("无法计算开仓数量：缺少价格数据")
 		}
 	} else {
-		// 平仓：用 quantity + reduceOnly
-		params.Set("reduceOnly", "true")
-		if input.SellQuantity > 0 {
-			qty := futuresQuantityPrecision(symbol, input.SellQuantity)
-			params.Set("quantity", qty)
+		if input.sellQuantity() > 0 {
+			// reduceOnly 平仓不受最小名义价值限制，只校验最小数量
+			qty = formatQuantity(lot, input.sellQuantity())
+			if err := checkLot(lot, symbol, qty, 0); err != nil {
+				order.Status = "rejected"
+				return order, fmt.Errorf("平仓数量不足: %w", err)
+			}
 			log.Printf("[合约] 平仓数量: %s", qty)
 		} else {
 			order.Status = "rejected"
@@ -236,38 +400,33 @@ func (e *BinanceFuturesExecutor) Execute(ctx context.Context, input Input) (doma
 		}
 	}
 
-	// HMAC-SHA256 签名
-	signature := e.sign(params.Encode())
-	params.Set("signature", signature)
-
-	apiURL := e.baseURL + "/fapi/v1/order"
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, strings.NewReader(params.Encode()))
-	if err != nil {
-		return order, fmt.Errorf("构建请求失败: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("X-MBX-APIKEY", e.apiKey)
-
-	log.Printf("[合约] 发送 Binance 合约订单: %s %s 保证金=%.2f USDT x%d", side, symbol, input.StakeUSDT, e.leverage)
-
-	resp, err := e.httpClient.Do(req)
-	if err != nil {
-		order.Status = "failed"
-		return order, fmt.Errorf("Binance 请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBytes, err := io.ReadAll(resp.Body)
+	log.Printf("[合约] 发送 Binance 合约订单: %s %s 保证金=%.2f USDT x%d", side, symbol, input.StakeUSDT, lev)
+
+	respBytes, status, err := e.postOrder(ctx, func() url.Values {
+		params := url.Values{}
+		params.Set("symbol", symbol)
+		params.Set("side", side)
+		params.Set("type", "MARKET")
+		params.Set("quantity", qty)
+		params.Set("newClientOrderId", order.ClientOrderID)
+		if e.isHedgeMode() {
+			// 双向持仓：只做多，开仓与平仓都作用于 LONG 仓位；不接受 reduceOnly，SELL + positionSide=LONG 本身只会减仓
+			params.Set("positionSide", "LONG")
+		} else if side == "SELL" {
+			params.Set("reduceOnly", "true")
+		}
+		return params
+	})
 	if err != nil {
 		order.Status = "failed"
-		return order, fmt.Errorf("读取响应失败: %w", err)
+		return order, err
 	}
 	order.RawResponse = string(respBytes)
 
-	if resp.StatusCode >= 300 {
+	if status >= 300 {
 		order.Status = "rejected"
-		log.Printf("[合约] ✘ Binance 拒绝: HTTP %d %s", resp.StatusCode, string(respBytes))
-		return order, fmt.Errorf("Binance HTTP %d: %s", resp.StatusCode, string(respBytes))
+		log.Printf("[合约] ✘ Binance 拒绝: HTTP %d %s", status, string(respBytes))
+		return order, fmt.Errorf("Binance HTTP %d: %s", status, string(respBytes))
 	}
 
 	// 解析返回
@@ -277,6 +436,7 @@ func (e *BinanceFuturesExecutor) Execute(ctx context.Context, input Input) (doma
 		Status        string `json:"status"`
 		AvgPrice      string `json:"avgPrice"`
 		ExecutedQty   string `json:"executedQty"`
+		OrigQty       string `json:"origQty"`
 	}
 	if err := json.Unmarshal(respBytes, &result); err == nil {
 		order.ExchangeOrderID = strconv.FormatInt(result.OrderID, 10)
@@ -287,6 +447,8 @@ func (e *BinanceFuturesExecutor) Execute(ctx context.Context, input Input) (doma
 		if q, e := strconv.ParseFloat(result.ExecutedQty, 64); e == nil {
 			order.FilledQuantity = q
 		}
+		order.RequestedQty, _ = strconv.ParseFloat(result.OrigQty, 64)
+		order.Status = settleStatus(order.Status, order.FilledQuantity)
 	}
 
 	action := "开多"
@@ -294,7 +456,7 @@ func (e *BinanceFuturesExecutor) Execute(ctx context.Context, input Input) (doma
 		action = "平仓"
 	}
 	log.Printf("[合约] ✔ %s成功: %s %s 价格=%.8f 数量=%.4f x%d 状态=%s",
-		action, side, symbol, order.FilledPrice, order.FilledQuantity, e.leverage, order.Status)
+		action, side, symbol, order.FilledPrice, order.FilledQuantity, lev, order.Status)
 	return order, nil
 }
 
@@ -315,48 +477,168 @@ func (e *BinanceFuturesExecutor) FetchPositionRisk(ctx context.Context, pair str
 	if e.dryRun {
 		return 0, nil
 	}
+	p, _, err := e.fetchPositionRisk(ctx, pair)
+	if err != nil || p == nil {
+		return 0, err
+	}
+	amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+	return math.Abs(amt), nil // 返回绝对值
+}
+
+// FetchPositionDetail 从 positionRisk 获取合约持仓详情（开仓价、标记价、强平价、保证金、杠杆），无持仓时返回 nil
+func (e *BinanceFuturesExecutor) FetchPositionDetail(ctx context.Context, pair string) (*domain.FuturesPosition, error) {
+	if e.dryRun {
+		return nil, nil
+	}
+	p, _, err := e.fetchPositionRisk(ctx, pair)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+	if amt == 0 {
+		return nil, nil
+	}
 
+	d := &domain.FuturesPosition{MarginType: strings.ToLower(p.MarginType), PositionSide: p.PositionSide}
+	d.EntryPrice, _ = strconv.ParseFloat(p.EntryPrice, 64)
+	d.MarkPrice, _ = strconv.ParseFloat(p.MarkPrice, 64)
+	d.LiquidationPrice, _ = strconv.ParseFloat(p.LiquidationPrice, 64)
+	d.UnrealizedPnL, _ = strconv.ParseFloat(p.UnRealizedProfit, 64)
+	d.Leverage, _ = strconv.Atoi(p.Leverage)
+	notional, _ := strconv.ParseFloat(p.Notional, 64)
+	d.Notional = math.Abs(notional)
+	if d.MarginType == "isolated" {
+		// isolatedMargin 已包含未实现盈亏，占用保证金取逐仓钱包余额
+		d.Margin, _ = strconv.ParseFloat(p.IsolatedWallet, 64)
+		if d.Margin <= 0 {
+			d.Margin, _ = strconv.ParseFloat(p.IsolatedMargin, 64)
+			d.Margin -= d.UnrealizedPnL
+		}
+	} else if d.Leverage > 0 {
+		// 全仓没有单独的保证金字段，按名义价值 / 杠杆 计算起始保证金
+		d.Margin = d.Notional / float64(d.Leverage)
+	}
+	if d.Margin > 0 {
+		d.ROE = d.UnrealizedPnL / d.Margin * 100
+	}
+	return d, nil
+}
+
+// positionRisk /fapi/v2/positionRisk 返回的单个持仓
+type positionRisk struct {
+	Symbol           string `json:"symbol"`
+	PositionSide     string `json:"positionSide"` // 单向持仓为 BOTH，双向持仓为 LONG / SHORT
+	PositionAmt      string `json:"positionAmt"`
+	EntryPrice       string `json:"entryPrice"`
+	MarkPrice        string `json:"markPrice"`
+	UnRealizedProfit string `json:"unRealizedProfit"`
+	LiquidationPrice string `json:"liquidationPrice"`
+	Leverage         string `json:"leverage"`
+	MarginType       string `json:"marginType"`
+	IsolatedMargin   string `json:"isolatedMargin"`
+	IsolatedWallet   string `json:"isolatedWallet"`
+	Notional         string `json:"notional"`
+}
+
+// fetchPositionRisk 查询交易对的多头 positionRisk（未找到时返回 nil）与空头数量（绝对值）。
+// 双向持仓时交易所分别返回 LONG / SHORT 两条，只取 LONG；单向持仓 BOTH 为负数时是空头，不作为多头返回。
+// 空头仓位不由本系统开仓（同步到持仓的 SHORT 一侧），Execute 在有空头时拒绝开多
+func (e *BinanceFuturesExecutor) fetchPositionRisk(ctx context.Context, pair string) (*positionRisk, float64, error) {
 	symbol := strings.ReplaceAll(strings.ToUpper(pair), "/", "")
+	positions, err := e.fetchPositionRows(ctx, symbol)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		found    *positionRisk
+		shortAmt float64
+	)
+	for i := range positions {
+		p := &positions[i]
+		if !strings.EqualFold(p.Symbol, symbol) {
+			continue
+		}
+		if side, amt := p.legSide(); side == domain.PositionSideShort {
+			if amt != 0 {
+				log.Printf("[合约] ⚠ %s 存在空头仓位 %s（非本系统开仓），暂停开多", symbol, p.PositionAmt)
+			}
+			shortAmt += amt
+			continue
+		}
+		if found == nil {
+			found = p
+		}
+	}
+	return found, shortAmt, nil
+}
+
+// legSide 持仓方向与数量（绝对值）：双向持仓按 positionSide，单向持仓 BOTH 按数量正负
+func (p positionRisk) legSide() (string, float64) {
+	amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+	if strings.EqualFold(p.PositionSide, "SHORT") || amt < 0 {
+		return domain.PositionSideShort, math.Abs(amt)
+	}
+	return domain.PositionSideLong, amt
+}
+
+// FetchPositionLegs 账户全部非零合约持仓，多头 / 空头分别返回一条，用于同步持仓表
+func (e *BinanceFuturesExecutor) FetchPositionLegs(ctx context.Context) ([]PositionLeg, error) {
+	if e.dryRun {
+		return nil, nil
+	}
+	positions, err := e.fetchPositionRows(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	legs := make([]PositionLeg, 0)
+	for _, p := range positions {
+		side, amt := p.legSide()
+		if amt == 0 {
+			continue
+		}
+		leg := PositionLeg{Symbol: strings.ToUpper(p.Symbol), Side: side, Quantity: amt}
+		leg.EntryPrice, _ = strconv.ParseFloat(p.EntryPrice, 64)
+		leg.MarkPrice, _ = strconv.ParseFloat(p.MarkPrice, 64)
+		leg.UnrealizedPnL, _ = strconv.ParseFloat(p.UnRealizedProfit, 64)
+		legs = append(legs, leg)
+	}
+	return legs, nil
+}
 
+// fetchPositionRows 查询 /fapi/v2/positionRisk，symbol 为空时返回账户全部交易对
+func (e *BinanceFuturesExecutor) fetchPositionRows(ctx context.Context, symbol string) ([]positionRisk, error) {
 	params := url.Values{}
-	params.Set("symbol", symbol)
-	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	if symbol != "" {
+		params.Set("symbol", symbol)
+	}
+	setTimestamp(params)
 	signature := e.sign(params.Encode())
 	params.Set("signature", signature)
 
 	apiURL := e.baseURL + "/fapi/v2/positionRisk?" + params.Encode()
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	req.Header.Set("X-MBX-APIKEY", e.apiKey)
 
 	resp, err := e.httpClient.Do(req)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
 	}
 
-	var positions []struct {
-		Symbol      string `json:"symbol"`
-		PositionAmt string `json:"positionAmt"`
-	}
+	var positions []positionRisk
 	if err := json.NewDecoder(resp.Body).Decode(&positions); err != nil {
-		return 0, err
-	}
-
-	for _, p := range positions {
-		if strings.EqualFold(p.Symbol, symbol) {
-			amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
-			return math.Abs(amt), nil // 返回绝对值
-		}
+		return nil, err
 	}
-	return 0, nil
+	return positions, nil
 }
 
 // FetchAccountBalances 获取合约账户 USDT 余额
@@ -375,7 +657,7 @@ func (e *BinanceFuturesExecutor) fetchFuturesBalance(ctx context.Context, includ
 	}
 
 	params := url.Values{}
-	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	setTimestamp(params)
 	signature := e.sign(params.Encode())
 	params.Set("signature", signature)
 
@@ -436,7 +718,7 @@ func (e *BinanceFuturesExecutor) FetchTradeHistory(ctx context.Context, pair str
 	params := url.Values{}
 	params.Set("symbol", symbol)
 	params.Set("limit", strconv.Itoa(limit))
-	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	setTimestamp(params)
 	signature := e.sign(params.Encode())
 	params.Set("signature", signature)
 
@@ -478,6 +760,7 @@ func (e *BinanceFuturesExecutor) FetchTradeHistory(ctx context.Context, pair str
 		qty, _ := strconv.ParseFloat(r.Qty, 64)
 		quoteQty, _ := strconv.ParseFloat(r.QuoteQty, 64)
 		trades = append(trades, Trade{
+			Exchange:  ExchangeBinance,
 			TradeID:   r.ID,
 			OrderID:   r.OrderID,
 			Symbol:    r.Symbol,
@@ -526,30 +809,3 @@ func (e *BinanceFuturesExecutor) sign(queryString string) string {
 	mac.Write([]byte(queryString))
 	return hex.EncodeToString(mac.Sum(nil))
 }
-
-// futuresQuantityPrecision 合约数量精度（与现货类似但合约规则可能不同）
-func futuresQuantityPrecision(symbol string, qty float64) string {
-	sym := strings.ToUpper(symbol)
-	var decimals int
-	switch {
-	case strings.HasPrefix(sym, "DOGE"):
-		decimals = 0 // stepSize=1
-		qty = math.Floor(qty)
-	case strings.HasPrefix(sym, "XRP"):
-		decimals = 1
-		qty = math.Floor(qty*10) / 10
-	case strings.HasPrefix(sym, "BNB"), strings.HasPrefix(sym, "SOL"):
-		decimals = 2
-		qty = math.Floor(qty*100) / 100
-	case strings.HasPrefix(sym, "ETH"):
-		decimals = 3
-		qty = math.Floor(qty*1000) / 1000
-	case strings.HasPrefix(sym, "BTC"):
-		decimals = 3
-		qty = math.Floor(qty*1000) / 1000
-	default:
-		decimals = 2
-		qty = math.Floor(qty*100) / 100
-	}
-	return strconv.FormatFloat(qty, 'f', decimals, 64)
-}
@@ -0,0 +1,148 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ai_quant/internal/ratelimit"
+)
+
+// codeInvalidKeyIP Binance -2015：Invalid API-key, IP, or permissions for action
+const codeInvalidKeyIP = -2015
+
+// KeyAlert 交易所 API Key 的持续告警状态（供前端横幅展示）
+type KeyAlert struct {
+	Code      int        `json:"code,omitempty"`
+	Message   string     `json:"message"`
+	PublicIP  string     `json:"public_ip,omitempty"` // 本机出口 IP，需加入 Key 的 IP 白名单
+	Since     time.Time  `json:"since"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // 交易权限到期时间
+}
+
+var keyAlerts = struct {
+	sync.Mutex
+	rejected   *KeyAlert
+	expiresAt  time.Time
+	warnBefore time.Duration
+	probeURL   string
+}{warnBefore: 7 * 24 * time.Hour}
+
+// ConfigureKeyAlert 设置公网 IP 探测地址（为空则不探测）与 Key 到期提前告警天数
+func ConfigureKeyAlert(probeURL string, expiryWarnDays int) {
+	keyAlerts.Lock()
+	defer keyAlerts.Unlock()
+	keyAlerts.probeURL = strings.TrimSpace(probeURL)
+	if expiryWarnDays > 0 {
+		keyAlerts.warnBefore = time.Duration(expiryWarnDays) * 24 * time.Hour
+	}
+}
+
+// CurrentKeyAlert 返回当前告警；-2015 优先，其次是即将到期的交易权限，无告警时返回 nil
+func CurrentKeyAlert() *KeyAlert {
+	keyAlerts.Lock()
+	defer keyAlerts.Unlock()
+	if keyAlerts.rejected != nil {
+		a := *keyAlerts.rejected
+		return &a
+	}
+	if exp := keyAlerts.expiresAt; !exp.IsZero() && time.Until(exp) < keyAlerts.warnBefore {
+		msg := "API Key 交易权限将于 " + exp.UTC().Format("2006-01-02") + " 到期，请及时续期"
+		if time.Now().After(exp) {
+			msg = "API Key 交易权限已于 " + exp.UTC().Format("2006-01-02") + " 到期"
+		}
+		return &KeyAlert{Message: msg, Since: exp.Add(-keyAlerts.warnBefore), ExpiresAt: &exp}
+	}
+	return nil
+}
+
+// noteKeyResponse 根据交易所响应更新告警：-2015 置位，签名请求成功则清除
+func noteKeyResponse(status int, body []byte) {
+	if status > 0 && status < 300 {
+		keyAlerts.Lock()
+		if keyAlerts.rejected != nil {
+			log.Printf("[密钥] ✔ API Key 恢复正常，清除 -2015 告警")
+			keyAlerts.rejected = nil
+		}
+		keyAlerts.Unlock()
+		return
+	}
+
+	code, msg := binanceError(body)
+	if code != codeInvalidKeyIP {
+		return
+	}
+
+	keyAlerts.Lock()
+	if keyAlerts.rejected != nil {
+		keyAlerts.Unlock()
+		return
+	}
+	keyAlerts.rejected = &KeyAlert{
+		Code:    code,
+		Message: "Binance 拒绝了 API Key（-2015）：Key 无效、权限不足或当前 IP 不在白名单中",
+		Since:   time.Now().UTC(),
+	}
+	probeURL := keyAlerts.probeURL
+	keyAlerts.Unlock()
+
+	log.Printf("[密钥] ✘ Binance -2015: %s", msg)
+	if probeURL != "" {
+		go probePublicIP(probeURL)
+	}
+}
+
+// binanceError 解析 Binance 错误响应 {"code":-2015,"msg":"..."}，非错误响应返回 0
+func binanceError(body []byte) (int, string) {
+	var apiErr struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if json.Unmarshal(body, &apiErr) != nil {
+		return 0, ""
+	}
+	return apiErr.Code, apiErr.Msg
+}
+
+// noteKeyExpiry 记录交易权限到期时间（来自 apiRestrictions）
+func noteKeyExpiry(t time.Time) {
+	keyAlerts.Lock()
+	keyAlerts.expiresAt = t
+	keyAlerts.Unlock()
+}
+
+// probePublicIP 探测本机出口 IP 并写入告警，便于迁移服务器后更新白名单
+func probePublicIP(probeURL string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL, nil)
+	if err != nil {
+		return
+	}
+	resp, err := ratelimit.NewClient(5 * time.Second).Do(req)
+	if err != nil {
+		log.Printf("[密钥] 探测公网 IP 失败: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		log.Printf("[密钥] 探测公网 IP 失败: HTTP %d", resp.StatusCode)
+		return
+	}
+	ip := strings.TrimSpace(string(body))
+
+	keyAlerts.Lock()
+	if keyAlerts.rejected != nil {
+		keyAlerts.rejected.PublicIP = ip
+		keyAlerts.rejected.Message += "，请将 " + ip + " 加入 API Key 的 IP 白名单"
+	}
+	keyAlerts.Unlock()
+	log.Printf("[密钥] 当前公网 IP: %s", ip)
+}
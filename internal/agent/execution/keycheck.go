@@ -0,0 +1,129 @@
+package execution
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"ai_quant/internal/config"
+)
+
+// KeyCheck 交易所 API Key 校验结果
+type KeyCheck struct {
+	OK              bool      `json:"ok"`
+	SpotTrading     bool      `json:"spot_trading"`
+	FuturesTrading  bool      `json:"futures_trading"`
+	Withdrawals     bool      `json:"withdrawals"` // 开启提现权限视为不安全，校验失败
+	IPRestricted    bool      `json:"ip_restricted"`
+	FuturesRequired bool      `json:"futures_required"`
+	Errors          []string  `json:"errors,omitempty"`
+	Warnings        []string  `json:"warnings,omitempty"`
+	Alert           *KeyAlert `json:"alert,omitempty"`
+	CheckedAt       time.Time `json:"checked_at"`
+}
+
+// KeyValidator 通过账户接口校验 API Key 是否可用及其权限
+type KeyValidator struct {
+	httpClient      *http.Client
+	spotBaseURL     string
+	futuresBaseURL  string
+	apiKey          string
+	secretKey       string
+	futuresRequired bool
+}
+
+// NewKeyValidator 创建校验器；futuresRequired 为 true 时要求 Key 开通合约权限
+func NewKeyValidator(cfg config.Config, futuresRequired bool) *KeyValidator {
+	return &KeyValidator{
+		httpClient:      newSignedClient(10 * time.Second),
+		spotBaseURL:     strings.TrimRight(cfg.ExchangeBaseURL, "/"),
+		futuresBaseURL:  strings.TrimRight(cfg.FuturesBaseURL, "/"),
+		apiKey:          cfg.ExchangeAPIKey,
+		secretKey:       cfg.ExchangeSecretKey,
+		futuresRequired: futuresRequired,
+	}
+}
+
+// Validate 调用 apiRestrictions 检查权限：Key 可用、未开启提现、需要时已开通合约
+func (v *KeyValidator) Validate(ctx context.Context) KeyCheck {
+	res := KeyCheck{FuturesRequired: v.futuresRequired, CheckedAt: time.Now().UTC()}
+	if v.apiKey == "" || v.secretKey == "" {
+		res.Errors = append(res.Errors, "EXCHANGE_API_KEY / EXCHANGE_SECRET_KEY 未配置")
+		return res
+	}
+
+	var restrictions struct {
+		IPRestrict                 bool  `json:"ipRestrict"`
+		EnableWithdrawals          bool  `json:"enableWithdrawals"`
+		EnableSpotAndMarginTrading bool  `json:"enableSpotAndMarginTrading"`
+		EnableFutures              bool  `json:"enableFutures"`
+		TradingAuthorityExpiration int64 `json:"tradingAuthorityExpirationTime"`
+	}
+	if err := v.get(ctx, v.spotBaseURL+"/sapi/v1/account/apiRestrictions", &restrictions); err != nil {
+		res.Errors = append(res.Errors, "API Key 校验失败: "+err.Error())
+		res.Alert = CurrentKeyAlert()
+		return res
+	}
+	res.SpotTrading = restrictions.EnableSpotAndMarginTrading
+	res.FuturesTrading = restrictions.EnableFutures
+	res.Withdrawals = restrictions.EnableWithdrawals
+	res.IPRestricted = restrictions.IPRestrict
+
+	if res.Withdrawals {
+		res.Errors = append(res.Errors, "API Key 开启了提现权限，请在交易所关闭后重试")
+	}
+	if !res.SpotTrading && !v.futuresRequired {
+		res.Errors = append(res.Errors, "API Key 未开启现货交易权限")
+	}
+	if v.futuresRequired {
+		if !res.FuturesTrading {
+			res.Errors = append(res.Errors, "合约模式需要 API Key 开通合约权限")
+		} else {
+			// 权限已开通时再确认合约账户可访问（未开通合约账户也会失败）
+			var balances []json.RawMessage
+			if err := v.get(ctx, v.futuresBaseURL+"/fapi/v2/balance", &balances); err != nil {
+				res.Errors = append(res.Errors, "合约账户访问失败: "+err.Error())
+			}
+		}
+	}
+	if !res.IPRestricted {
+		res.Warnings = append(res.Warnings, "API Key 未限制 IP，建议在交易所配置 IP 白名单")
+	}
+	if restrictions.TradingAuthorityExpiration > 0 {
+		exp := time.UnixMilli(restrictions.TradingAuthorityExpiration)
+		noteKeyExpiry(exp)
+		res.Warnings = append(res.Warnings, fmt.Sprintf("交易权限将于 %s 到期", exp.UTC().Format("2006-01-02")))
+	}
+
+	res.OK = len(res.Errors) == 0
+	res.Alert = CurrentKeyAlert()
+	return res
+}
+
+// get 发送已签名的 GET 请求并解析 JSON 响应
+func (v *KeyValidator) get(ctx context.Context, apiURL string, out any) error {
+	params := url.Values{}
+	setTimestamp(params)
+	mac := hmac.New(sha256.New, []byte(v.secretKey))
+	mac.Write([]byte(params.Encode()))
+	params.Set("signature", hex.EncodeToString(mac.Sum(nil)))
+
+	body, status, err := signedRequest(ctx, v.httpClient, v.apiKey, http.MethodGet, apiURL, params)
+	if err != nil {
+		return err
+	}
+	if status >= 300 {
+		if code, _ := binanceError(body); code == codeInvalidKeyIP {
+			return fmt.Errorf("Key 无效、权限不足或当前 IP 不在白名单中（-2015）")
+		}
+		return fmt.Errorf("Binance HTTP %d: %s", status, string(body))
+	}
+	return json.Unmarshal(body, out)
+}
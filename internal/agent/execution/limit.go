@@ -0,0 +1,343 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai_quant/internal/config"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/exchangeinfo"
+)
+
+// Input.OrderType 可选值
+const (
+	OrderTypeMarket = "market"
+	OrderTypeLimit  = "limit"
+)
+
+// 限价单超时未全部成交时的处理
+const (
+	LimitFallbackMarket = "market" // 剩余部分转市价
+	LimitFallbackCancel = "cancel" // 撤单，只保留已成交部分
+)
+
+// LimitOrder 限价下单规则：只做 maker 挂在盘口，等待 Wait 后撤单重挂，重挂 Replaces 次后仍未成交按 Fallback 处理
+type LimitOrder struct {
+	Enabled      bool
+	Wait         time.Duration
+	Replaces     int
+	ImproveTicks int // 挂单价向盘口内侧移动的跳数（买单高于买一、卖单低于卖一，不穿过对手价）
+	Fallback     string
+}
+
+// limitOrderFromConfig 读取 ORDER_TYPE=limit 相关配置
+func limitOrderFromConfig(cfg config.Config) LimitOrder {
+	l := LimitOrder{
+		Enabled:      strings.EqualFold(strings.TrimSpace(cfg.OrderType), "limit"),
+		Wait:         time.Duration(cfg.LimitOrderWaitSec) * time.Second,
+		Replaces:     cfg.LimitOrderReplaces,
+		ImproveTicks: cfg.LimitOrderImproveTicks,
+		Fallback:     strings.ToLower(strings.TrimSpace(cfg.LimitOrderFallback)),
+	}
+	if l.Wait <= 0 {
+		l.Wait = 10 * time.Second
+	}
+	if l.Replaces < 0 {
+		l.Replaces = 0
+	}
+	if l.Fallback != LimitFallbackCancel {
+		l.Fallback = LimitFallbackMarket
+	}
+	if l.Enabled {
+		log.Printf("[执行] 现货限价下单已启用: 等待 %s 重挂 %d 次 内移 %d 跳 超时处理=%s",
+			l.Wait, l.Replaces, l.ImproveTicks, l.Fallback)
+	}
+	return l
+}
+
+// limitPrice 只做 maker 的挂单价：买单挂买一、卖单挂卖一，按 ImproveTicks 向内移动但不触及对手价
+func (l LimitOrder) limitPrice(side domain.Side, q bookQuote, tick float64) float64 {
+	if side == domain.SideLong {
+		price := q.Bid + float64(l.ImproveTicks)*tick
+		if tick > 0 && price >= q.Ask {
+			price = q.Ask - tick
+		}
+		return math.Max(price, q.Bid)
+	}
+	price := q.Ask - float64(l.ImproveTicks)*tick
+	if tick > 0 && price <= q.Bid {
+		price = q.Bid + tick
+	}
+	return math.Min(price, q.Ask)
+}
+
+// bookQuote 最优买卖价
+type bookQuote struct {
+	Bid float64
+	Ask float64
+}
+
+// fetchBook 最优买卖价：优先 bookTicker 缓存，否则查 REST
+func (e *BinanceExecutor) fetchBook(ctx context.Context, pair string) (bookQuote, error) {
+	if q, ok := e.book.Get(pair); ok {
+		return bookQuote{Bid: q.Bid, Ask: q.Ask}, nil
+	}
+	apiURL := e.baseURL + "/api/v3/ticker/bookTicker?symbol=" + pairToSymbol(pair)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return bookQuote{}, err
+	}
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return bookQuote{}, fmt.Errorf("获取盘口失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return bookQuote{}, fmt.Errorf("Binance bookTicker API %d", resp.StatusCode)
+	}
+	var raw struct {
+		BidPrice string `json:"bidPrice"`
+		AskPrice string `json:"askPrice"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return bookQuote{}, err
+	}
+	var q bookQuote
+	q.Bid, _ = strconv.ParseFloat(raw.BidPrice, 64)
+	q.Ask, _ = strconv.ParseFloat(raw.AskPrice, 64)
+	if q.Bid <= 0 || q.Ask <= 0 {
+		return bookQuote{}, fmt.Errorf("%s 盘口为空", pair)
+	}
+	return q, nil
+}
+
+// limitLeg 限价流程中提交的一笔交易所订单
+type limitLeg struct {
+	Type     string  `json:"type"`
+	OrderID  string  `json:"order_id"`
+	Price    float64 `json:"price,omitempty"`
+	Executed float64 `json:"executed_qty"`
+	AvgPrice float64 `json:"avg_price,omitempty"`
+}
+
+// executeLimit 现货限价下单：LIMIT_MAKER 挂在盘口，超时撤单后按最新盘口重挂，
+// 次数用尽仍有剩余时按 Fallback 转市价或放弃；order 累计所有子单的成交并记录成交方式
+func (e *BinanceExecutor) executeLimit(ctx context.Context, input Input, order domain.Order) (domain.Order, error) {
+	symbol := pairToSymbol(input.Pair)
+	side := "BUY"
+	if input.Side == domain.SideClose {
+		side = "SELL"
+	}
+	lot := lotFilters(ctx, e.exchangeInfo, symbol, false)
+	tick, _ := e.exchangeInfo.TickSize(ctx, symbol)
+
+	book, err := e.fetchBook(ctx, input.Pair)
+	if err != nil {
+		order.Status = "failed"
+		return order, err
+	}
+	// 买单按 USDT 金额换算数量，卖单按持仓数量
+	target := input.sellQuantity()
+	if side == "BUY" {
+		target = input.StakeUSDT / e.limit.limitPrice(input.Side, book, tick)
+	}
+	qty := formatQuantity(lot, target)
+	if err := checkLot(lot, symbol, qty, e.limit.limitPrice(input.Side, book, tick)); err != nil {
+		order.Status = "rejected"
+		return order, err
+	}
+	order.RequestedQty, _ = strconv.ParseFloat(qty, 64)
+
+	var (
+		legs      []limitLeg
+		filledQty float64
+		filledUSD float64
+		strategy  = domain.FillLimit
+	)
+	remaining := func() float64 { return order.RequestedQty - filledQty }
+	tradable := func() bool {
+		return checkLot(lot, symbol, formatQuantity(lot, remaining()), book.Bid) == nil
+	}
+
+	for attempt := 0; attempt <= e.limit.Replaces && tradable() && ctx.Err() == nil; attempt++ {
+		if attempt > 0 {
+			if book, err = e.fetchBook(ctx, input.Pair); err != nil {
+				break
+			}
+			strategy = domain.FillLimitReplaced
+		}
+		price := exchangeinfo.FloorToTick(e.limit.limitPrice(input.Side, book, tick), tick)
+		legQty := formatQuantity(lot, remaining())
+		clientID := order.ClientOrderID
+		if attempt > 0 {
+			clientID = fmt.Sprintf("%sr%d", order.ClientOrderID, attempt)
+		}
+
+		id, err := e.placeLimitMaker(ctx, symbol, side, legQty, price, clientID)
+		if err != nil {
+			// 盘口移动导致挂单会立即吃单，按最新盘口重挂
+			log.Printf("[执行] ⚠ %s 限价单第%d次挂单失败: %v", symbol, attempt+1, err)
+			continue
+		}
+		order.ExchangeOrderID = id
+		st := e.awaitLimit(ctx, input.Pair, id)
+		if !isFinalStatus(st.Status) {
+			// 请求已取消时也要撤掉挂单，避免留下无人跟踪的订单
+			cctx := context.WithoutCancel(ctx)
+			if err := e.CancelOrder(cctx, input.Pair, id); err != nil {
+				log.Printf("[执行] ⚠ %s 撤销限价单失败: %v", symbol, err)
+			}
+			// 撤单期间可能又有成交，以撤单后的状态为准
+			if final, err := e.QueryOrder(cctx, input.Pair, id); err == nil {
+				st = final
+			}
+		}
+		p, _ := strconv.ParseFloat(price, 64)
+		legs = append(legs, limitLeg{Type: "LIMIT_MAKER", OrderID: id, Price: p, Executed: st.ExecutedQty, AvgPrice: st.AvgPrice})
+		filledQty += st.ExecutedQty
+		filledUSD += st.ExecutedQty * st.AvgPrice
+		log.Printf("[执行] %s 限价单 #%d 价格=%s 成交 %.8f/%s", symbol, attempt+1, price, st.ExecutedQty, legQty)
+	}
+
+	if tradable() && e.limit.Fallback == LimitFallbackMarket && ctx.Err() == nil {
+		legQty := formatQuantity(lot, remaining())
+		id, qty, avg, err := e.placeMarketQty(ctx, symbol, side, legQty, order.ClientOrderID+"m")
+		if err != nil {
+			log.Printf("[执行] ✘ %s 限价转市价失败: %v", symbol, err)
+		} else {
+			order.ExchangeOrderID = id
+			legs = append(legs, limitLeg{Type: "MARKET", OrderID: id, Executed: qty, AvgPrice: avg})
+			filledQty += qty
+			filledUSD += qty * avg
+			strategy = domain.FillLimitToMarket
+			log.Printf("[执行] %s 限价未全部成交，剩余 %s 转市价 成交 %.8f @ %.8f", symbol, legQty, qty, avg)
+		}
+	}
+
+	raw, _ := json.Marshal(map[string]any{"mode": "limit", "legs": legs})
+	order.RawResponse = string(raw)
+	order.FillStrategy = strategy
+	order.FilledQuantity = filledQty
+	if filledQty > 0 {
+		order.FilledPrice = filledUSD / filledQty
+	}
+	switch {
+	case filledQty <= 0:
+		order.Status = "rejected"
+		return order, fmt.Errorf("限价单 %d 次挂单均未成交", len(legs))
+	case tradable():
+		// 子单都已撤销，剩余量不再由部分成交任务跟踪
+		order.Status = "partial_cancelled"
+	default:
+		order.Status = "filled"
+	}
+	log.Printf("[执行] ✔ Binance 限价订单完成: 状态=%s 方式=%s 成交价=%.8f 数量=%.8f",
+		order.Status, order.FillStrategy, order.FilledPrice, order.FilledQuantity)
+	return order, nil
+}
+
+// awaitLimit 轮询限价单直到完全成交或等待超时，返回最后一次查询到的状态
+func (e *BinanceExecutor) awaitLimit(ctx context.Context, pair, id string) OrderState {
+	deadline := time.Now().Add(e.limit.Wait)
+	var st OrderState
+	for {
+		if s, err := e.QueryOrder(ctx, pair, id); err == nil {
+			st = s
+			if isFinalStatus(st.Status) {
+				return st
+			}
+		}
+		if time.Now().After(deadline) {
+			return st
+		}
+		select {
+		case <-ctx.Done():
+			return st
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// isFinalStatus 订单是否已不会再有成交
+func isFinalStatus(status string) bool {
+	return status == "filled" || status == "rejected"
+}
+
+// placeLimitMaker 提交只做 maker 的现货限价单，会立即成交时交易所直接拒绝
+func (e *BinanceExecutor) placeLimitMaker(ctx context.Context, symbol, side, qty, price, clientID string) (string, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("side", side)
+	params.Set("type", "LIMIT_MAKER")
+	params.Set("quantity", qty)
+	params.Set("price", price)
+	params.Set("newClientOrderId", clientID)
+	setTimestamp(params)
+	params.Set("signature", e.sign(params.Encode()))
+
+	body, status, err := signedRequest(ctx, e.httpClient, e.apiKey, http.MethodPost, e.baseURL+"/api/v3/order", params)
+	if err != nil {
+		return "", err
+	}
+	if status >= 300 {
+		return "", fmt.Errorf("Binance HTTP %d: %s", status, string(body))
+	}
+	var result struct {
+		OrderID int64 `json:"orderId"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("解析订单响应失败: %w", err)
+	}
+	return strconv.FormatInt(result.OrderID, 10), nil
+}
+
+// placeMarketQty 按币数量提交现货市价单，返回订单 ID、成交数量与均价
+func (e *BinanceExecutor) placeMarketQty(ctx context.Context, symbol, side, qty, clientID string) (string, float64, float64, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("side", side)
+	params.Set("type", "MARKET")
+	params.Set("quantity", qty)
+	params.Set("newClientOrderId", clientID)
+	params.Set("newOrderRespType", "FULL")
+	setTimestamp(params)
+	params.Set("signature", e.sign(params.Encode()))
+
+	body, status, err := signedRequest(ctx, e.httpClient, e.apiKey, http.MethodPost, e.baseURL+"/api/v3/order", params)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if status >= 300 {
+		return "", 0, 0, fmt.Errorf("Binance HTTP %d: %s", status, string(body))
+	}
+	var result struct {
+		OrderID int64 `json:"orderId"`
+		Fills   []struct {
+			Price string `json:"price"`
+			Qty   string `json:"qty"`
+		} `json:"fills"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", 0, 0, fmt.Errorf("解析订单响应失败: %w", err)
+	}
+	var totalQty, totalCost float64
+	for _, f := range result.Fills {
+		p, _ := strconv.ParseFloat(f.Price, 64)
+		q, _ := strconv.ParseFloat(f.Qty, 64)
+		totalQty += q
+		totalCost += p * q
+	}
+	avg := 0.0
+	if totalQty > 0 {
+		avg = totalCost / totalQty
+	}
+	return strconv.FormatInt(result.OrderID, 10), totalQty, avg, nil
+}
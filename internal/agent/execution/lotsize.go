@@ -0,0 +1,76 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai_quant/internal/exchangeinfo"
+)
+
+// lotFilters 返回交易对的数量规则（exchangeInfo LOT_SIZE / NOTIONAL）；
+// 交易所暂时拿不到时按常见币种的步长兜底，未知币种取 0.01
+func lotFilters(ctx context.Context, info *exchangeinfo.Cache, symbol string, futures bool) exchangeinfo.Filters {
+	if f, ok := info.Filters(ctx, symbol); ok && f.StepSize > 0 {
+		return f
+	}
+	step := fallbackStepSize(symbol, futures)
+	log.Printf("[精度] ⚠ %s 缺少 LOT_SIZE，按兜底步长 %g 处理", symbol, step)
+	return exchangeinfo.Filters{StepSize: step}
+}
+
+// fallbackStepSize exchangeInfo 不可用时的兜底数量步长
+func fallbackStepSize(symbol string, futures bool) float64 {
+	sym := strings.ToUpper(symbol)
+	switch {
+	case strings.HasPrefix(sym, "DOGE"):
+		return 1
+	case strings.HasPrefix(sym, "XRP"):
+		return 0.1
+	case strings.HasPrefix(sym, "BNB"), strings.HasPrefix(sym, "SOL"):
+		return 0.01
+	case strings.HasPrefix(sym, "ETH"):
+		if futures {
+			return 0.001
+		}
+		return 0.0001
+	case strings.HasPrefix(sym, "BTC"):
+		if futures {
+			return 0.001
+		}
+		return 0.00001
+	default:
+		return 0.01
+	}
+}
+
+// formatQuantity 按 stepSize 向下取整数量，避免超过持仓或触发 LOT_SIZE 错误
+func formatQuantity(f exchangeinfo.Filters, qty float64) string {
+	return exchangeinfo.FloorToTick(qty, f.StepSize)
+}
+
+// checkLot 校验取整后的数量和名义价值是否满足交易所下限；price 为 0 时跳过名义价值检查
+func checkLot(f exchangeinfo.Filters, symbol, qty string, price float64) error {
+	q, _ := strconv.ParseFloat(qty, 64)
+	if q <= 0 || (f.MinQty > 0 && q < f.MinQty) {
+		return fmt.Errorf("%s 数量 %s 低于最小交易量 %g（灰尘持仓无法交易）", symbol, qty, f.MinQty)
+	}
+	if price > 0 && f.MinNotional > 0 && q*price < f.MinNotional {
+		return fmt.Errorf("%s 名义价值 %.4f USDT 低于交易所下限 %g USDT", symbol, q*price, f.MinNotional)
+	}
+	return nil
+}
+
+// preloadExchangeInfo 启动时后台预热 exchangeInfo，首笔下单无需等待
+func preloadExchangeInfo(info *exchangeinfo.Cache) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := info.Preload(ctx); err != nil {
+			log.Printf("[精度] ⚠ 预加载 exchangeInfo 失败，下单时按需拉取: %v", err)
+		}
+	}()
+}
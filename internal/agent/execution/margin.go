@@ -0,0 +1,551 @@
+package execution
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai_quant/internal/bookticker"
+	"ai_quant/internal/config"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/exchangeinfo"
+
+	"github.com/google/uuid"
+)
+
+// BinanceMarginExecutor 通过 Binance 现货杠杆（全仓 / 逐仓）API 下单：
+// 买入时自动借入 USDT（MARGIN_BUY），卖出时自动归还借款（AUTO_REPAY），只做多
+type BinanceMarginExecutor struct {
+	httpClient *http.Client
+	baseURL    string // 与现货同一地址，接口在 /sapi/v1/margin 下
+	apiKey     string
+	secretKey  string
+	dryRun     bool
+	leverage   int  // 开仓金额 = 本金 × 杠杆，超出本金的部分自动借入
+	isolated   bool // true = 逐仓，false = 全仓
+
+	exchangeInfo *exchangeinfo.Cache // 杠杆交易对与现货共用交易规则
+
+	book           *bookticker.Cache // 实时买一卖一价，未启用时为 nil
+	priceSanityPct float64           // 预估成交价偏离盘口中间价的上限（%）
+	paperSlippage  float64           // 模拟成交滑点（基点）
+
+	stream *userStream // 用户数据流（仅全仓），未启用时为 nil
+}
+
+// NewMargin 创建现货杠杆 Executor
+func NewMargin(cfg config.Config) Executor {
+	e := &BinanceMarginExecutor{
+		httpClient: newSignedClient(15 * time.Second),
+		baseURL:    strings.TrimRight(cfg.ExchangeBaseURL, "/"),
+		apiKey:     cfg.ExchangeAPIKey,
+		secretKey:  cfg.ExchangeSecretKey,
+		dryRun:     cfg.DryRun,
+		leverage:   cfg.MarginLeverage,
+		isolated:   strings.EqualFold(cfg.MarginType, "isolated"),
+
+		exchangeInfo: exchangeinfo.NewSpot(cfg.ExchangeBaseURL),
+
+		book:           newBookTicker(cfg, "杠杆", cfg.SpotStreamURL),
+		priceSanityPct: cfg.PriceSanityMaxPct,
+		paperSlippage:  paperSlippageBps(cfg),
+	}
+
+	// 全仓最高 3x（部分币种 5x），逐仓最高 10x
+	maxLev := 3
+	if e.isolated {
+		maxLev = 10
+	}
+	if e.leverage < 1 {
+		e.leverage = 2
+	}
+	if e.leverage > maxLev {
+		e.leverage = maxLev
+	}
+
+	preloadExchangeInfo(e.exchangeInfo)
+	if !e.isolated {
+		e.stream = newUserStream(cfg, "杠杆", e.httpClient, e.baseURL+"/sapi/v1/userDataStream", cfg.SpotStreamURL)
+	}
+	if e.apiKey != "" {
+		go calibrateClock(e.httpClient, e.baseURL+"/api/v3/time")
+	}
+
+	log.Printf("[杠杆] 初始化: baseURL=%s 杠杆=%dx 模式=%s dryRun=%v",
+		e.baseURL, e.leverage, e.marginType(), e.dryRun)
+	return e
+}
+
+func (e *BinanceMarginExecutor) marginType() string {
+	if e.isolated {
+		return "isolated"
+	}
+	return "cross"
+}
+
+// Execute 执行杠杆交易：买入按 本金 × 杠杆 下单并自动借款，卖出后自动还款
+func (e *BinanceMarginExecutor) Execute(ctx context.Context, input Input) (domain.Order, error) {
+	lev := input.Leverage
+	if lev <= 0 || lev > e.leverage {
+		lev = e.leverage
+	}
+	order := domain.Order{
+		ID:             uuid.NewString(),
+		CycleID:        input.CycleID,
+		SignalID:       input.SignalID,
+		ClientOrderID:  input.clientOrderID(16),
+		IdempotencyKey: input.IdempotencyKey,
+		Pair:           input.Pair,
+		Side:           input.Side,
+		StakeUSDT:      input.StakeUSDT,
+		ExpectedPrice:  input.EstimatedFill,
+		Leverage:       lev,
+		Status:         "created",
+		CreatedAt:      time.Now().UTC(),
+	}
+	notional := input.StakeUSDT * float64(lev)
+
+	// 模拟模式
+	if e.dryRun {
+		estimatedFill := fillPrice(e.book, input.Pair, input.Side, input.EstimatedFill)
+		if estimatedFill <= 0 {
+			if price, err := e.fetchCurrentPrice(ctx, input.Pair); err == nil && price > 0 {
+				estimatedFill = price
+				log.Printf("[杠杆] 获取实时价格: %s = %.8f", input.Pair, price)
+			}
+		}
+		estimatedFill = paperFillPrice(estimatedFill, input.Side, e.paperSlippage)
+
+		order.Status = "simulated_filled"
+		order.ExchangeOrderID = "dryrun-margin-" + order.ID
+		order.FilledPrice = estimatedFill
+		order.RawResponse = fmt.Sprintf(`{"mode":"dry_run","margin_type":%q,"leverage":%d}`, e.marginType(), lev)
+
+		if estimatedFill > 0 && input.Side == domain.SideLong {
+			order.FilledQuantity = notional / estimatedFill
+		} else if input.sellQuantity() > 0 {
+			order.FilledQuantity = input.sellQuantity()
+		}
+
+		action := "借款买入"
+		if input.Side == domain.SideClose {
+			action = "卖出还款"
+		}
+		log.Printf("[杠杆] 模拟%s: %s %s 本金=%.2f USDT x%d @ %.8f 数量=%.4f",
+			action, input.Side, input.Pair, input.StakeUSDT, lev, estimatedFill, order.FilledQuantity)
+		return order, nil
+	}
+
+	// 实盘模式
+	if e.apiKey == "" || e.secretKey == "" {
+		order.Status = "rejected"
+		return order, fmt.Errorf("交易所 API Key 未配置，无法实盘下单")
+	}
+
+	if err := checkPriceSanity(e.book, input.Pair, input.EstimatedFill, e.priceSanityPct); err != nil {
+		order.Status = "rejected"
+		return order, err
+	}
+
+	symbol := pairToSymbol(input.Pair)
+	side := "BUY"
+	if input.Side == domain.SideClose {
+		side = "SELL"
+	}
+
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("isIsolated", strings.ToUpper(strconv.FormatBool(e.isolated)))
+	params.Set("side", side)
+	params.Set("type", "MARKET")
+	params.Set("newClientOrderId", order.ClientOrderID)
+	params.Set("newOrderRespType", "FULL")
+	setTimestamp(params)
+
+	lot := lotFilters(ctx, e.exchangeInfo, symbol, false)
+	if side == "BUY" {
+		// 买入：按 本金 × 杠杆 的 USDT 金额下单，可用余额不足的部分由交易所自动借入
+		if lot.MinNotional > 0 && notional < lot.MinNotional {
+			order.Status = "rejected"
+			return order, fmt.Errorf("下单金额 %.2f USDT 低于 %s 最小名义价值 %g USDT", notional, symbol, lot.MinNotional)
+		}
+		params.Set("quoteOrderQty", strconv.FormatFloat(notional, 'f', 2, 64))
+		params.Set("sideEffectType", "MARGIN_BUY")
+		log.Printf("[杠杆] 借款买入: 本金=%.2f x%d = %.2f USDT", input.StakeUSDT, lev, notional)
+	} else {
+		// 卖出：按币数量下单，成交所得自动归还借款和利息
+		if input.sellQuantity() <= 0 {
+			order.Status = "rejected"
+			return order, fmt.Errorf("卖出缺少数量参数")
+		}
+		qty := formatQuantity(lot, input.sellQuantity())
+		if err := checkLot(lot, symbol, qty, fillPrice(e.book, input.Pair, input.Side, input.EstimatedFill)); err != nil {
+			order.Status = "rejected"
+			return order, fmt.Errorf("卖出数量不足: %w", err)
+		}
+		params.Set("quantity", qty)
+		params.Set("sideEffectType", "AUTO_REPAY")
+		log.Printf("[杠杆] 卖出还款数量: 原始=%.8f 格式化=%s", input.sellQuantity(), qty)
+	}
+
+	params.Set("signature", e.sign(params.Encode()))
+
+	apiURL := e.baseURL + "/sapi/v1/margin/order"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return order, fmt.Errorf("构建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-MBX-APIKEY", e.apiKey)
+
+	log.Printf("[杠杆] 发送 Binance 杠杆订单: %s %s %s", side, symbol, e.marginType())
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		order.Status = "failed"
+		return order, fmt.Errorf("Binance 请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		order.Status = "failed"
+		return order, fmt.Errorf("读取响应失败: %w", err)
+	}
+	order.RawResponse = string(respBytes)
+	noteKeyResponse(resp.StatusCode, respBytes)
+
+	if resp.StatusCode >= 300 {
+		order.Status = "rejected"
+		log.Printf("[杠杆] ✘ Binance 拒绝: HTTP %d %s", resp.StatusCode, string(respBytes))
+		return order, fmt.Errorf("Binance HTTP %d: %s", resp.StatusCode, string(respBytes))
+	}
+
+	var result struct {
+		OrderID int64  `json:"orderId"`
+		Status  string `json:"status"`
+		OrigQty string `json:"origQty"`
+		Fills   []struct {
+			Price string `json:"price"`
+			Qty   string `json:"qty"`
+		} `json:"fills"`
+	}
+	if err := json.Unmarshal(respBytes, &result); err == nil {
+		order.ExchangeOrderID = strconv.FormatInt(result.OrderID, 10)
+		order.Status = mapBinanceStatus(result.Status)
+
+		var totalQty, totalCost float64
+		for _, f := range result.Fills {
+			p, _ := strconv.ParseFloat(f.Price, 64)
+			q, _ := strconv.ParseFloat(f.Qty, 64)
+			totalQty += q
+			totalCost += p * q
+		}
+		if totalQty > 0 {
+			order.FilledPrice = totalCost / totalQty
+			order.FilledQuantity = totalQty
+		}
+		order.RequestedQty, _ = strconv.ParseFloat(result.OrigQty, 64)
+		order.Status = settleStatus(order.Status, order.FilledQuantity)
+	}
+
+	log.Printf("[杠杆] ✔ Binance 杠杆订单完成: ID=%s 状态=%s 成交价=%.4f",
+		order.ExchangeOrderID, order.Status, order.FilledPrice)
+	return order, nil
+}
+
+// sign 使用 HMAC-SHA256 对请求参数签名
+func (e *BinanceMarginExecutor) sign(queryString string) string {
+	mac := hmac.New(sha256.New, []byte(e.secretKey))
+	mac.Write([]byte(queryString))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// fetchCurrentPrice 从 Binance 公开 API 获取当前价格（用于 dry-run 模拟）
+func (e *BinanceMarginExecutor) fetchCurrentPrice(ctx context.Context, pair string) (float64, error) {
+	apiURL := fmt.Sprintf("%s/api/v3/ticker/price?symbol=%s", e.baseURL, pairToSymbol(pair))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("Binance price API %d", resp.StatusCode)
+	}
+	var result struct {
+		Price string `json:"price"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(result.Price, 64)
+}
+
+// signedGet 发送签名 GET 请求并返回响应体
+func (e *BinanceMarginExecutor) signedGet(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	if e.apiKey == "" || e.secretKey == "" {
+		return nil, fmt.Errorf("交易所 API Key 未配置")
+	}
+	setTimestamp(params)
+	params.Set("signature", e.sign(params.Encode()))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("构建请求失败: %w", err)
+	}
+	req.Header.Set("X-MBX-APIKEY", e.apiKey)
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Binance 请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Binance HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// marginAsset 杠杆账户单个资产
+type marginAsset struct {
+	Asset    string `json:"asset"`
+	Free     string `json:"free"`
+	Locked   string `json:"locked"`
+	Borrowed string `json:"borrowed"`
+	Interest string `json:"interest"`
+}
+
+// balance 转换为扣除借款和利息后的净余额
+func (a marginAsset) balance() Balance {
+	free, _ := strconv.ParseFloat(a.Free, 64)
+	locked, _ := strconv.ParseFloat(a.Locked, 64)
+	borrowed, _ := strconv.ParseFloat(a.Borrowed, 64)
+	interest, _ := strconv.ParseFloat(a.Interest, 64)
+	debt := borrowed + interest
+	return Balance{
+		Symbol: a.Asset,
+		Free:   max(free-debt, 0),
+		Locked: locked,
+		Total:  free + locked - debt,
+	}
+}
+
+// fetchMarginAssets 查询杠杆账户资产：全仓返回账户全部资产，逐仓合并各交易对的基础币与计价币
+func (e *BinanceMarginExecutor) fetchMarginAssets(ctx context.Context) ([]marginAsset, error) {
+	if !e.isolated {
+		body, err := e.signedGet(ctx, "/sapi/v1/margin/account", url.Values{})
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			UserAssets []marginAsset `json:"userAssets"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("解析响应失败: %w", err)
+		}
+		return result.UserAssets, nil
+	}
+
+	body, err := e.signedGet(ctx, "/sapi/v1/margin/isolated/account", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Assets []struct {
+			BaseAsset  marginAsset `json:"baseAsset"`
+			QuoteAsset marginAsset `json:"quoteAsset"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	out := make([]marginAsset, 0, len(result.Assets)*2)
+	for _, a := range result.Assets {
+		out = append(out, a.BaseAsset, a.QuoteAsset)
+	}
+	return out, nil
+}
+
+// mergedBalances 按币种合并净余额（逐仓同一计价币分布在多个交易对中）
+func (e *BinanceMarginExecutor) mergedBalances(ctx context.Context) ([]Balance, error) {
+	assets, err := e.fetchMarginAssets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int)
+	balances := make([]Balance, 0, len(assets))
+	for _, a := range assets {
+		b := a.balance()
+		if i, ok := index[b.Symbol]; ok {
+			balances[i].Free += b.Free
+			balances[i].Locked += b.Locked
+			balances[i].Total += b.Total
+			continue
+		}
+		index[b.Symbol] = len(balances)
+		balances = append(balances, b)
+	}
+	return balances, nil
+}
+
+// FetchAccountBalances 杠杆账户中非零的持仓币种（净额，不含 USDT / BNB）
+func (e *BinanceMarginExecutor) FetchAccountBalances(ctx context.Context) ([]Balance, error) {
+	all, err := e.mergedBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]Balance, 0)
+	for _, b := range all {
+		if b.Total > 0 && b.Symbol != "USDT" && b.Symbol != "BNB" {
+			balances = append(balances, b)
+		}
+	}
+	log.Printf("[杠杆] 同步到 %d 个币种余额", len(balances))
+	return balances, nil
+}
+
+// FetchFullBalance 杠杆账户全部非零资产（含 USDT，已扣除借款）
+func (e *BinanceMarginExecutor) FetchFullBalance(ctx context.Context) ([]Balance, error) {
+	all, err := e.mergedBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]Balance, 0, len(all))
+	for _, b := range all {
+		if b.Total != 0 {
+			balances = append(balances, b)
+		}
+	}
+	return balances, nil
+}
+
+// FetchTradeHistory 获取杠杆账户指定交易对的成交历史
+func (e *BinanceMarginExecutor) FetchTradeHistory(ctx context.Context, pair string, limit int) ([]Trade, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 500
+	}
+	symbol := pairToSymbol(pair)
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("isIsolated", strings.ToUpper(strconv.FormatBool(e.isolated)))
+	params.Set("limit", strconv.Itoa(limit))
+	body, err := e.signedGet(ctx, "/sapi/v1/margin/myTrades", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []struct {
+		ID      int64  `json:"id"`
+		OrderID int64  `json:"orderId"`
+		Price   string `json:"price"`
+		Qty     string `json:"qty"`
+		Time    int64  `json:"time"`
+		IsBuyer bool   `json:"isBuyer"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	trades := make([]Trade, 0, len(raw))
+	for _, r := range raw {
+		price, _ := strconv.ParseFloat(r.Price, 64)
+		qty, _ := strconv.ParseFloat(r.Qty, 64)
+		trades = append(trades, Trade{
+			Exchange:  ExchangeBinance,
+			TradeID:   r.ID,
+			OrderID:   r.OrderID,
+			Symbol:    symbol,
+			Price:     price,
+			Quantity:  qty,
+			QuoteQty:  price * qty,
+			IsBuyer:   r.IsBuyer,
+			Timestamp: time.UnixMilli(r.Time).UTC(),
+		})
+	}
+	log.Printf("[杠杆] 获取 %s 成交记录 %d 笔", pair, len(trades))
+	return trades, nil
+}
+
+// FetchMarginStatus 查询杠杆账户风险率：全仓为整个账户，逐仓为该交易对的独立账户
+func (e *BinanceMarginExecutor) FetchMarginStatus(ctx context.Context, pair string) (*domain.MarginStatus, error) {
+	st := &domain.MarginStatus{MarginType: e.marginType(), Leverage: e.leverage, Pair: pair}
+	if e.dryRun && e.apiKey == "" {
+		st.Estimated = true
+		return st, nil
+	}
+
+	var raw struct {
+		MarginLevel         string `json:"marginLevel"`
+		TotalAssetOfBtc     string `json:"totalAssetOfBtc"`
+		TotalLiabilityOfBtc string `json:"totalLiabilityOfBtc"`
+	}
+	if !e.isolated {
+		st.Pair = ""
+		body, err := e.signedGet(ctx, "/sapi/v1/margin/account", url.Values{})
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("解析响应失败: %w", err)
+		}
+	} else {
+		params := url.Values{}
+		params.Set("symbols", pairToSymbol(pair))
+		body, err := e.signedGet(ctx, "/sapi/v1/margin/isolated/account", params)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Assets []struct {
+				MarginLevel string `json:"marginLevel"`
+			} `json:"assets"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("解析响应失败: %w", err)
+		}
+		if len(result.Assets) > 0 {
+			raw.MarginLevel = result.Assets[0].MarginLevel
+		}
+	}
+	st.MarginLevel, _ = strconv.ParseFloat(raw.MarginLevel, 64)
+	st.TotalAssetBTC, _ = strconv.ParseFloat(raw.TotalAssetOfBtc, 64)
+	st.TotalLiabilityBTC, _ = strconv.ParseFloat(raw.TotalLiabilityOfBtc, 64)
+	return st, nil
+}
+
+// IsDryRun 返回当前是否为模拟模式
+func (e *BinanceMarginExecutor) IsDryRun() bool {
+	return e.dryRun
+}
+
+func (e *BinanceMarginExecutor) TradingMode() string {
+	return "margin"
+}
+
+func (e *BinanceMarginExecutor) Leverage() int {
+	return e.leverage
+}
+
+// FetchPositionRisk 杠杆持仓即账户中的币，不单独返回合约持仓
+func (e *BinanceMarginExecutor) FetchPositionRisk(ctx context.Context, pair string) (float64, error) {
+	return 0, nil
+}
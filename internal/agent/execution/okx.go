@@ -0,0 +1,546 @@
+package execution
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai_quant/internal/config"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/ratelimit"
+
+	"github.com/google/uuid"
+)
+
+// OKXExecutor 通过 OKX v5 API 现货下单
+type OKXExecutor struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	secretKey  string
+	passphrase string
+	simulated  bool // OKX 模拟盘（x-simulated-trading: 1）
+	dryRun     bool
+
+	paperSlippage float64 // 模拟成交滑点（基点）
+
+	mu      sync.Mutex
+	lotSize map[string]float64 // instId -> 下单数量步长
+}
+
+func NewOKX(cfg config.Config) Executor {
+	return &OKXExecutor{
+		httpClient: ratelimit.NewClient(15 * time.Second),
+		baseURL:    strings.TrimRight(cfg.OKXBaseURL, "/"),
+		apiKey:     cfg.OKXAPIKey,
+		secretKey:  cfg.OKXSecretKey,
+		passphrase: cfg.OKXPassphrase,
+		simulated:  cfg.OKXSimulated,
+		dryRun:     cfg.DryRun,
+		lotSize:    make(map[string]float64),
+
+		paperSlippage: paperSlippageBps(cfg),
+	}
+}
+
+// okxInstID 将 "BTC/USDT" 转为 OKX 现货 instId "BTC-USDT"
+func okxInstID(pair string) string {
+	return strings.ReplaceAll(strings.ToUpper(pair), "/", "-")
+}
+
+func (e *OKXExecutor) Execute(ctx context.Context, input Input) (domain.Order, error) {
+	order := domain.Order{
+		ID:             uuid.NewString(),
+		CycleID:        input.CycleID,
+		SignalID:       input.SignalID,
+		ClientOrderID:  input.clientOrderID(16),
+		IdempotencyKey: input.IdempotencyKey,
+		Pair:           input.Pair,
+		Side:           input.Side,
+		StakeUSDT:      input.StakeUSDT,
+		ExpectedPrice:  input.EstimatedFill,
+		Status:         "created",
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	// 模拟模式：不调交易所
+	if e.dryRun {
+		estimatedFill := input.EstimatedFill
+		if estimatedFill <= 0 {
+			if t, err := e.FetchTicker(ctx, input.Pair); err == nil && t.Price > 0 {
+				estimatedFill = t.Price
+				log.Printf("[执行] 获取 OKX 实时价格: %s = %.8f", input.Pair, t.Price)
+			}
+		}
+
+		estimatedFill = paperFillPrice(estimatedFill, input.Side, e.paperSlippage)
+
+		order.Status = "simulated_filled"
+		order.ExchangeOrderID = "dryrun-" + order.ID
+		order.FilledPrice = estimatedFill
+		order.RawResponse = `{"mode":"dry_run","exchange":"okx"}`
+		if estimatedFill > 0 && input.Side == domain.SideLong {
+			order.FilledQuantity = input.StakeUSDT / estimatedFill
+		} else if input.sellQuantity() > 0 {
+			order.FilledQuantity = input.sellQuantity()
+		}
+		action := "买入"
+		if input.Side == domain.SideClose {
+			action = "卖出"
+		}
+		log.Printf("[执行] OKX 模拟%s: %s %.2f USDT @ %.8f 数量=%.4f",
+			action, input.Pair, input.StakeUSDT, estimatedFill, order.FilledQuantity)
+		return order, nil
+	}
+
+	if e.apiKey == "" || e.secretKey == "" || e.passphrase == "" {
+		order.Status = "rejected"
+		return order, fmt.Errorf("OKX API Key / Secret / Passphrase 未配置，无法实盘下单")
+	}
+
+	instID := okxInstID(input.Pair)
+	body := map[string]string{
+		"instId":  instID,
+		"tdMode":  "cash",
+		"ordType": "market",
+		"clOrdId": order.ClientOrderID,
+	}
+	if input.Side == domain.SideClose && input.sellQuantity() > 0 {
+		// 卖出：按币数量，需对齐 lotSz
+		qty, err := e.formatQty(ctx, instID, input.sellQuantity())
+		if err != nil {
+			order.Status = "rejected"
+			return order, err
+		}
+		body["side"] = "sell"
+		body["sz"] = qty
+	} else {
+		// 买入（或未指定数量的卖出）：按 USDT 金额
+		body["side"] = "buy"
+		if input.Side == domain.SideClose {
+			body["side"] = "sell"
+		}
+		body["sz"] = strconv.FormatFloat(input.StakeUSDT, 'f', 2, 64)
+		body["tgtCcy"] = "quote_ccy"
+	}
+
+	log.Printf("[执行] 发送 OKX 订单: %s %s sz=%s", body["side"], instID, body["sz"])
+	raw, err := e.request(ctx, http.MethodPost, "/api/v5/trade/order", nil, body)
+	order.RawResponse = string(raw)
+	if err != nil {
+		order.Status = "rejected"
+		log.Printf("[执行] ✘ OKX 拒绝: %v", err)
+		return order, err
+	}
+
+	var placed []struct {
+		OrdID string `json:"ordId"`
+	}
+	if err := decodeOKXData(raw, &placed); err != nil || len(placed) == 0 {
+		order.Status = "failed"
+		return order, fmt.Errorf("解析 OKX 下单响应失败: %s", string(raw))
+	}
+	order.ExchangeOrderID = placed[0].OrdID
+	order.Status = "submitted"
+
+	// 市价单下单响应不含成交信息，查询一次订单获取成交价与数量
+	if st, qErr := e.QueryOrder(ctx, input.Pair, order.ExchangeOrderID); qErr == nil {
+		order.Status = st.Status
+		order.FilledPrice = st.AvgPrice
+		order.FilledQuantity = st.ExecutedQty
+		order.RequestedQty = st.OrigQty
+	} else {
+		log.Printf("[执行] ⚠ 查询 OKX 订单失败: %v", qErr)
+	}
+
+	log.Printf("[执行] ✔ OKX 订单完成: ID=%s 状态=%s 成交价=%.4f",
+		order.ExchangeOrderID, order.Status, order.FilledPrice)
+	return order, nil
+}
+
+// QueryOrder 查询 OKX 现货订单状态
+func (e *OKXExecutor) QueryOrder(ctx context.Context, pair, exchangeOrderID string) (OrderState, error) {
+	if e.dryRun || strings.HasPrefix(exchangeOrderID, "dryrun-") {
+		return OrderState{Status: "simulated_filled"}, nil
+	}
+
+	q := url.Values{}
+	q.Set("instId", okxInstID(pair))
+	q.Set("ordId", exchangeOrderID)
+	raw, err := e.request(ctx, http.MethodGet, "/api/v5/trade/order", q, nil)
+	if err != nil {
+		return OrderState{}, err
+	}
+
+	var data []struct {
+		State     string `json:"state"`
+		Sz        string `json:"sz"`
+		TgtCcy    string `json:"tgtCcy"`
+		AccFillSz string `json:"accFillSz"`
+		AvgPx     string `json:"avgPx"`
+	}
+	if err := decodeOKXData(raw, &data); err != nil || len(data) == 0 {
+		return OrderState{}, fmt.Errorf("解析 OKX 订单响应失败: %s", string(raw))
+	}
+	d := data[0]
+	st := OrderState{Status: mapOKXState(d.State)}
+	st.ExecutedQty, _ = strconv.ParseFloat(d.AccFillSz, 64)
+	st.AvgPrice, _ = strconv.ParseFloat(d.AvgPx, 64)
+	if d.TgtCcy != "quote_ccy" {
+		st.OrigQty, _ = strconv.ParseFloat(d.Sz, 64)
+	}
+	st.Status = settleStatus(st.Status, st.ExecutedQty)
+	return st, nil
+}
+
+// CancelOrder 撤销 OKX 挂单；订单已成交或已撤销时视为成功
+func (e *OKXExecutor) CancelOrder(ctx context.Context, pair, exchangeOrderID string) error {
+	if e.dryRun || strings.HasPrefix(exchangeOrderID, "dryrun-") {
+		log.Printf("[执行] 模拟撤单: %s 订单ID=%s", pair, exchangeOrderID)
+		return nil
+	}
+	_, err := e.request(ctx, http.MethodPost, "/api/v5/trade/cancel-order", nil, map[string]string{
+		"instId": okxInstID(pair),
+		"ordId":  exchangeOrderID,
+	})
+	// 51400 撤单失败（订单不存在）/ 51401 已撤销 / 51402 已完成
+	if err != nil && !strings.Contains(err.Error(), "5140") {
+		return err
+	}
+	log.Printf("[执行] ✔ 已撤单: %s 订单ID=%s", pair, exchangeOrderID)
+	return nil
+}
+
+// FetchTicker 获取 OKX 24h 行情（公开接口）
+func (e *OKXExecutor) FetchTicker(ctx context.Context, pair string) (Ticker, error) {
+	apiURL := e.baseURL + "/api/v5/market/ticker?instId=" + okxInstID(pair)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return Ticker{}, err
+	}
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return Ticker{}, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Ticker{}, err
+	}
+
+	var data []struct {
+		Last    string `json:"last"`
+		Open24h string `json:"open24h"`
+	}
+	if err := decodeOKXData(raw, &data); err != nil || len(data) == 0 {
+		return Ticker{}, fmt.Errorf("OKX ticker: %s", string(raw))
+	}
+	var t Ticker
+	t.Price, _ = strconv.ParseFloat(data[0].Last, 64)
+	open, _ := strconv.ParseFloat(data[0].Open24h, 64)
+	if open > 0 {
+		t.Change24hPct = (t.Price - open) / open * 100
+	}
+	return t, nil
+}
+
+// FetchAccountBalances 获取非零币种余额（不含 USDT）
+func (e *OKXExecutor) FetchAccountBalances(ctx context.Context) ([]Balance, error) {
+	all, err := e.FetchFullBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]Balance, 0, len(all))
+	for _, b := range all {
+		if b.Symbol != "USDT" {
+			balances = append(balances, b)
+		}
+	}
+	log.Printf("[交易所] 同步到 %d 个 OKX 币种余额", len(balances))
+	return balances, nil
+}
+
+// FetchFullBalance 获取交易账户完整余额（含 USDT）
+func (e *OKXExecutor) FetchFullBalance(ctx context.Context) ([]Balance, error) {
+	if e.apiKey == "" || e.secretKey == "" || e.passphrase == "" {
+		return nil, fmt.Errorf("OKX API Key 未配置")
+	}
+	raw, err := e.request(ctx, http.MethodGet, "/api/v5/account/balance", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []struct {
+		Details []struct {
+			Ccy       string `json:"ccy"`
+			AvailBal  string `json:"availBal"`
+			FrozenBal string `json:"frozenBal"`
+		} `json:"details"`
+	}
+	if err := decodeOKXData(raw, &data); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	balances := make([]Balance, 0)
+	for _, acct := range data {
+		for _, d := range acct.Details {
+			free, _ := strconv.ParseFloat(d.AvailBal, 64)
+			locked, _ := strconv.ParseFloat(d.FrozenBal, 64)
+			if free+locked > 0 {
+				balances = append(balances, Balance{Symbol: d.Ccy, Free: free, Locked: locked, Total: free + locked})
+			}
+		}
+	}
+	return balances, nil
+}
+
+// FetchTradeHistory 获取近 3 天的现货成交明细（OKX 单次最多 100 条）
+func (e *OKXExecutor) FetchTradeHistory(ctx context.Context, pair string, limit int) ([]Trade, error) {
+	if e.apiKey == "" || e.secretKey == "" || e.passphrase == "" {
+		return nil, fmt.Errorf("OKX API Key 未配置")
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	q := url.Values{}
+	q.Set("instType", "SPOT")
+	q.Set("instId", okxInstID(pair))
+	q.Set("limit", strconv.Itoa(limit))
+	raw, err := e.request(ctx, http.MethodGet, "/api/v5/trade/fills", q, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []struct {
+		TradeID string `json:"tradeId"`
+		OrdID   string `json:"ordId"`
+		FillPx  string `json:"fillPx"`
+		FillSz  string `json:"fillSz"`
+		Side    string `json:"side"`
+		Ts      string `json:"ts"`
+	}
+	if err := decodeOKXData(raw, &data); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	trades := make([]Trade, 0, len(data))
+	for _, d := range data {
+		price, _ := strconv.ParseFloat(d.FillPx, 64)
+		qty, _ := strconv.ParseFloat(d.FillSz, 64)
+		tradeID, _ := strconv.ParseInt(d.TradeID, 10, 64)
+		orderID, _ := strconv.ParseInt(d.OrdID, 10, 64)
+		ts, _ := strconv.ParseInt(d.Ts, 10, 64)
+		trades = append(trades, Trade{
+			Exchange:  ExchangeOKX,
+			TradeID:   tradeID,
+			OrderID:   orderID,
+			Symbol:    pairToSymbol(pair),
+			Price:     price,
+			Quantity:  qty,
+			QuoteQty:  price * qty,
+			IsBuyer:   d.Side == "buy",
+			Timestamp: time.UnixMilli(ts).UTC(),
+		})
+	}
+
+	log.Printf("[交易所] 获取 OKX %s 成交记录 %d 笔", pair, len(trades))
+	return trades, nil
+}
+
+// FetchPositionRisk 现货模式不支持，返回 0
+func (e *OKXExecutor) FetchPositionRisk(ctx context.Context, pair string) (float64, error) {
+	return 0, nil
+}
+
+func (e *OKXExecutor) IsDryRun() bool {
+	return e.dryRun
+}
+
+func (e *OKXExecutor) TradingMode() string {
+	return "spot"
+}
+
+func (e *OKXExecutor) Leverage() int {
+	return 1
+}
+
+// formatQty 按交易对 lotSz 向下取整数量
+func (e *OKXExecutor) formatQty(ctx context.Context, instID string, qty float64) (string, error) {
+	step, err := e.lotStep(ctx, instID)
+	if err != nil {
+		return "", err
+	}
+	floored := math.Floor(qty/step+1e-9) * step
+	if floored <= 0 {
+		return "", fmt.Errorf("卖出数量不足: %.8f %s 低于最小交易单位 %g（灰尘持仓无法交易）", qty, instID, step)
+	}
+	decimals := 0
+	for s := step; s < 1 && decimals < 12; s *= 10 {
+		decimals++
+	}
+	return strconv.FormatFloat(floored, 'f', decimals, 64), nil
+}
+
+// lotStep 查询并缓存交易对的下单数量步长
+func (e *OKXExecutor) lotStep(ctx context.Context, instID string) (float64, error) {
+	e.mu.Lock()
+	step, ok := e.lotSize[instID]
+	e.mu.Unlock()
+	if ok {
+		return step, nil
+	}
+
+	q := url.Values{}
+	q.Set("instType", "SPOT")
+	q.Set("instId", instID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.baseURL+"/api/v5/public/instruments?"+q.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("查询 OKX 交易对信息失败: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	var data []struct {
+		LotSz string `json:"lotSz"`
+	}
+	if err := decodeOKXData(raw, &data); err != nil || len(data) == 0 {
+		return 0, fmt.Errorf("OKX 交易对信息: %s", string(raw))
+	}
+	step, _ = strconv.ParseFloat(data[0].LotSz, 64)
+	if step <= 0 {
+		return 0, fmt.Errorf("OKX 交易对 %s lotSz 无效", instID)
+	}
+
+	e.mu.Lock()
+	e.lotSize[instID] = step
+	e.mu.Unlock()
+	return step, nil
+}
+
+// request 发送带签名的 OKX 私有接口请求，返回原始响应体；code != "0" 时返回错误
+func (e *OKXExecutor) request(ctx context.Context, method, path string, query url.Values, body any) ([]byte, error) {
+	requestPath := path
+	if len(query) > 0 {
+		requestPath += "?" + query.Encode()
+	}
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, e.baseURL+requestPath, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("构建请求失败: %w", err)
+	}
+	ts := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	req.Header.Set("OK-ACCESS-KEY", e.apiKey)
+	req.Header.Set("OK-ACCESS-SIGN", e.sign(ts+method+requestPath+string(payload)))
+	req.Header.Set("OK-ACCESS-TIMESTAMP", ts)
+	req.Header.Set("OK-ACCESS-PASSPHRASE", e.passphrase)
+	req.Header.Set("Content-Type", "application/json")
+	if e.simulated {
+		req.Header.Set("x-simulated-trading", "1")
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("OKX 请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if err := okxError(resp.StatusCode, raw); err != nil {
+		return raw, err
+	}
+	return raw, nil
+}
+
+// sign OKX 签名：Base64(HMAC-SHA256(timestamp + method + requestPath + body))
+func (e *OKXExecutor) sign(prehash string) string {
+	mac := hmac.New(sha256.New, []byte(e.secretKey))
+	mac.Write([]byte(prehash))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// okxError 解析 OKX 响应错误：外层 code 非 0，或下单类接口 data[].sCode 非 0
+func okxError(status int, raw []byte) error {
+	var resp struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			SCode string `json:"sCode"`
+			SMsg  string `json:"sMsg"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		if status >= 300 {
+			return fmt.Errorf("OKX HTTP %d: %s", status, string(raw))
+		}
+		return nil
+	}
+	for _, d := range resp.Data {
+		if d.SCode != "" && d.SCode != "0" {
+			return fmt.Errorf("OKX %s: %s", d.SCode, d.SMsg)
+		}
+	}
+	if resp.Code != "0" {
+		return fmt.Errorf("OKX %s: %s", resp.Code, resp.Msg)
+	}
+	return nil
+}
+
+// decodeOKXData 解析 OKX 响应中的 data 数组
+func decodeOKXData(raw []byte, out any) error {
+	if err := okxError(http.StatusOK, raw); err != nil {
+		return err
+	}
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return err
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// mapOKXState 将 OKX 订单状态映射为内部状态
+func mapOKXState(s string) string {
+	switch s {
+	case "filled":
+		return "filled"
+	case "partially_filled":
+		return "partial_filled"
+	case "live":
+		return "submitted"
+	case "canceled", "mmp_canceled":
+		return "rejected"
+	default:
+		return s
+	}
+}
@@ -0,0 +1,199 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// OpenOrderLister 支持查询交易所未完成挂单的执行器
+type OpenOrderLister interface {
+	// FetchOpenOrders 查询挂单，pair 为空时返回账户全部挂单
+	FetchOpenOrders(ctx context.Context, pair string) ([]domain.OpenOrder, error)
+}
+
+// quoteAssets 从 Binance symbol 还原交易对时识别的计价币，按长度优先匹配
+var quoteAssets = []string{"FDUSD", "USDT", "USDC", "BUSD", "BTC", "ETH", "BNB"}
+
+// symbolToPair 将 "BTCUSDT" 转为 "BTC/USDT"，无法识别计价币时原样返回
+func symbolToPair(symbol string) string {
+	for _, q := range quoteAssets {
+		if strings.HasSuffix(symbol, q) && len(symbol) > len(q) {
+			return symbol[:len(symbol)-len(q)] + "/" + q
+		}
+	}
+	return symbol
+}
+
+// binanceOpenOrder /api/v3/openOrders 与 /fapi/v1/openOrders 返回的单个挂单
+type binanceOpenOrder struct {
+	Symbol        string `json:"symbol"`
+	OrderID       int64  `json:"orderId"`
+	ClientOrderID string `json:"clientOrderId"`
+	Side          string `json:"side"`
+	Type          string `json:"type"`
+	Price         string `json:"price"`
+	StopPrice     string `json:"stopPrice"`
+	OrigQty       string `json:"origQty"`
+	ExecutedQty   string `json:"executedQty"`
+	Status        string `json:"status"`
+	ClosePosition bool   `json:"closePosition"`
+	Time          int64  `json:"time"`
+}
+
+// fetchBinanceOpenOrders 查询 Binance 现货 / 合约挂单，解析为统一结构
+func fetchBinanceOpenOrders(ctx context.Context, client *http.Client, apiKey, apiURL, mode, symbol string, sign func(string) string) ([]domain.OpenOrder, error) {
+	params := url.Values{}
+	if symbol != "" {
+		params.Set("symbol", symbol)
+	}
+	setTimestamp(params)
+	params.Set("signature", sign(params.Encode()))
+
+	body, status, err := signedRequest(ctx, client, apiKey, http.MethodGet, apiURL, params)
+	if err != nil {
+		return nil, err
+	}
+	if status >= 300 {
+		return nil, fmt.Errorf("Binance HTTP %d: %s", status, string(body))
+	}
+	var raw []binanceOpenOrder
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("解析挂单响应失败: %w", err)
+	}
+
+	out := make([]domain.OpenOrder, 0, len(raw))
+	for _, r := range raw {
+		o := domain.OpenOrder{
+			Pair:            symbolToPair(r.Symbol),
+			Mode:            mode,
+			ExchangeOrderID: strconv.FormatInt(r.OrderID, 10),
+			ClientOrderID:   r.ClientOrderID,
+			Side:            strings.ToLower(r.Side),
+			Type:            strings.ToLower(r.Type),
+			Status:          mapBinanceStatus(r.Status),
+			ClosePosition:   r.ClosePosition,
+			CreatedAt:       time.UnixMilli(r.Time).UTC(),
+		}
+		o.Price, _ = strconv.ParseFloat(r.Price, 64)
+		o.StopPrice, _ = strconv.ParseFloat(r.StopPrice, 64)
+		o.OrigQty, _ = strconv.ParseFloat(r.OrigQty, 64)
+		o.ExecutedQty, _ = strconv.ParseFloat(r.ExecutedQty, 64)
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+// FetchOpenOrders 查询现货挂单（限价单、OCO 止盈止损腿）
+func (e *BinanceExecutor) FetchOpenOrders(ctx context.Context, pair string) ([]domain.OpenOrder, error) {
+	if e.dryRun {
+		return []domain.OpenOrder{}, nil
+	}
+	symbol := ""
+	if pair != "" {
+		symbol = pairToSymbol(strings.ToUpper(pair))
+	}
+	return fetchBinanceOpenOrders(ctx, e.httpClient, e.apiKey, e.baseURL+"/api/v3/openOrders", "spot", symbol, e.sign)
+}
+
+// FetchOpenOrders 查询合约挂单（含 STOP_MARKET / TAKE_PROFIT_MARKET 条件单）
+func (e *BinanceFuturesExecutor) FetchOpenOrders(ctx context.Context, pair string) ([]domain.OpenOrder, error) {
+	if e.dryRun {
+		return []domain.OpenOrder{}, nil
+	}
+	symbol := ""
+	if pair != "" {
+		symbol = strings.ReplaceAll(strings.ToUpper(pair), "/", "")
+	}
+	return fetchBinanceOpenOrders(ctx, e.httpClient, e.apiKey, e.baseURL+"/fapi/v1/openOrders", "futures", symbol, e.sign)
+}
+
+// FetchOpenOrders 查询 OKX 现货挂单
+func (e *OKXExecutor) FetchOpenOrders(ctx context.Context, pair string) ([]domain.OpenOrder, error) {
+	if e.dryRun {
+		return []domain.OpenOrder{}, nil
+	}
+	q := url.Values{}
+	q.Set("instType", "SPOT")
+	if pair != "" {
+		q.Set("instId", okxInstID(pair))
+	}
+	raw, err := e.request(ctx, http.MethodGet, "/api/v5/trade/orders-pending", q, nil)
+	if err != nil {
+		return nil, err
+	}
+	var data []struct {
+		InstID    string `json:"instId"`
+		OrdID     string `json:"ordId"`
+		ClOrdID   string `json:"clOrdId"`
+		Side      string `json:"side"`
+		OrdType   string `json:"ordType"`
+		Px        string `json:"px"`
+		Sz        string `json:"sz"`
+		AccFillSz string `json:"accFillSz"`
+		State     string `json:"state"`
+		CTime     string `json:"cTime"`
+	}
+	if err := decodeOKXData(raw, &data); err != nil {
+		return nil, fmt.Errorf("解析 OKX 挂单响应失败: %w", err)
+	}
+
+	out := make([]domain.OpenOrder, 0, len(data))
+	for _, d := range data {
+		o := domain.OpenOrder{
+			Pair:            strings.ReplaceAll(d.InstID, "-", "/"),
+			Mode:            "spot",
+			ExchangeOrderID: d.OrdID,
+			ClientOrderID:   d.ClOrdID,
+			Side:            d.Side,
+			Type:            d.OrdType,
+			Status:          mapOKXState(d.State),
+		}
+		o.Price, _ = strconv.ParseFloat(d.Px, 64)
+		o.OrigQty, _ = strconv.ParseFloat(d.Sz, 64)
+		o.ExecutedQty, _ = strconv.ParseFloat(d.AccFillSz, 64)
+		if ms, err := strconv.ParseInt(d.CTime, 10, 64); err == nil {
+			o.CreatedAt = time.UnixMilli(ms).UTC()
+		}
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+// FetchOpenOrders 指定交易对时路由到对应执行器，否则汇总所有支持查询挂单的执行器
+func (r *Router) FetchOpenOrders(ctx context.Context, pair string) ([]domain.OpenOrder, error) {
+	if pair != "" {
+		l, ok := r.For(pair).(OpenOrderLister)
+		if !ok {
+			return []domain.OpenOrder{}, nil
+		}
+		return l.FetchOpenOrders(ctx, pair)
+	}
+	modes := make([]string, 0, len(r.executors))
+	for m := range r.executors {
+		modes = append(modes, m)
+	}
+	sort.Strings(modes)
+
+	out := make([]domain.OpenOrder, 0)
+	for _, m := range modes {
+		l, ok := r.executors[m].(OpenOrderLister)
+		if !ok {
+			continue
+		}
+		orders, err := l.FetchOpenOrders(ctx, "")
+		if err != nil {
+			return nil, fmt.Errorf("查询%s挂单失败: %w", m, err)
+		}
+		out = append(out, orders...)
+	}
+	return out, nil
+}
@@ -0,0 +1,25 @@
+package execution
+
+import (
+	"ai_quant/internal/config"
+	"ai_quant/internal/domain"
+)
+
+// paperSlippageBps 模拟盘成交的滑点（基点），未启用模拟盘钱包时为 0
+func paperSlippageBps(cfg config.Config) float64 {
+	if !cfg.DryRun || !cfg.PaperTrading || cfg.PaperSlippageBps < 0 {
+		return 0
+	}
+	return cfg.PaperSlippageBps
+}
+
+// paperFillPrice 模拟成交价按滑点模型调整：买入上浮、卖出下浮 bps 个基点
+func paperFillPrice(price float64, side domain.Side, bps float64) float64 {
+	if price <= 0 || bps <= 0 {
+		return price
+	}
+	if side == domain.SideLong {
+		return price * (1 + bps/10000)
+	}
+	return price * (1 - bps/10000)
+}
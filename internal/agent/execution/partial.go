@@ -0,0 +1,179 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// OrderState 交易所上订单的最新状态（Status 已映射为内部状态）
+type OrderState struct {
+	Status      string
+	OrigQty     float64 // 委托数量
+	ExecutedQty float64 // 累计成交数量
+	AvgPrice    float64 // 成交均价
+}
+
+// Remaining 未成交的剩余数量
+func (s OrderState) Remaining() float64 {
+	if s.OrigQty <= s.ExecutedQty {
+		return 0
+	}
+	return s.OrigQty - s.ExecutedQty
+}
+
+// OrderTracker 支持查询 / 撤销订单的执行器，用于处理部分成交的剩余量
+type OrderTracker interface {
+	QueryOrder(ctx context.Context, pair, exchangeOrderID string) (OrderState, error)
+	CancelOrder(ctx context.Context, pair, exchangeOrderID string) error
+}
+
+// settleStatus 已撤销 / 已过期但有成交量的订单（如市价单流动性不足）按部分成交处理，
+// 成交部分计入持仓，剩余量由 orchestrator 超时后统一撤销或重新提交
+func settleStatus(status string, filledQty float64) string {
+	if status == "rejected" && filledQty > 0 {
+		return "partial_filled"
+	}
+	return status
+}
+
+// QueryOrder 查询现货订单状态
+func (e *BinanceExecutor) QueryOrder(ctx context.Context, pair, exchangeOrderID string) (OrderState, error) {
+	if e.dryRun || strings.HasPrefix(exchangeOrderID, "dryrun-") {
+		return OrderState{Status: "simulated_filled"}, nil
+	}
+
+	params := url.Values{}
+	params.Set("symbol", pairToSymbol(pair))
+	params.Set("orderId", exchangeOrderID)
+	setTimestamp(params)
+	params.Set("signature", e.sign(params.Encode()))
+
+	body, status, err := signedRequest(ctx, e.httpClient, e.apiKey, http.MethodGet, e.baseURL+"/api/v3/order", params)
+	if err != nil {
+		return OrderState{}, err
+	}
+	if status >= 300 {
+		return OrderState{}, fmt.Errorf("Binance HTTP %d: %s", status, string(body))
+	}
+
+	var result struct {
+		Status              string `json:"status"`
+		OrigQty             string `json:"origQty"`
+		ExecutedQty         string `json:"executedQty"`
+		CummulativeQuoteQty string `json:"cummulativeQuoteQty"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return OrderState{}, fmt.Errorf("解析订单响应失败: %w", err)
+	}
+	st := OrderState{Status: mapBinanceStatus(result.Status)}
+	st.OrigQty, _ = strconv.ParseFloat(result.OrigQty, 64)
+	st.ExecutedQty, _ = strconv.ParseFloat(result.ExecutedQty, 64)
+	if st.ExecutedQty > 0 {
+		quote, _ := strconv.ParseFloat(result.CummulativeQuoteQty, 64)
+		st.AvgPrice = quote / st.ExecutedQty
+	}
+	st.Status = settleStatus(st.Status, st.ExecutedQty)
+	return st, nil
+}
+
+// CancelOrder 撤销现货挂单；订单已不存在（已成交或已撤销）时视为成功
+func (e *BinanceExecutor) CancelOrder(ctx context.Context, pair, exchangeOrderID string) error {
+	if e.dryRun || strings.HasPrefix(exchangeOrderID, "dryrun-") {
+		log.Printf("[执行] 模拟撤单: %s 订单ID=%s", pair, exchangeOrderID)
+		return nil
+	}
+
+	params := url.Values{}
+	params.Set("symbol", pairToSymbol(pair))
+	params.Set("orderId", exchangeOrderID)
+	setTimestamp(params)
+	params.Set("signature", e.sign(params.Encode()))
+
+	body, status, err := signedRequest(ctx, e.httpClient, e.apiKey, http.MethodDelete, e.baseURL+"/api/v3/order", params)
+	if err != nil {
+		return err
+	}
+	// -2011 = Unknown order sent：订单已成交或已撤销
+	if status >= 300 && !strings.Contains(string(body), "-2011") {
+		return fmt.Errorf("Binance HTTP %d: %s", status, string(body))
+	}
+	log.Printf("[执行] ✔ 已撤单: %s 订单ID=%s", pair, exchangeOrderID)
+	return nil
+}
+
+// QueryOrder 查询合约订单状态
+func (e *BinanceFuturesExecutor) QueryOrder(ctx context.Context, pair, exchangeOrderID string) (OrderState, error) {
+	if e.dryRun || strings.HasPrefix(exchangeOrderID, "dryrun-") {
+		return OrderState{Status: "simulated_filled"}, nil
+	}
+
+	params := url.Values{}
+	params.Set("symbol", strings.ReplaceAll(strings.ToUpper(pair), "/", ""))
+	params.Set("orderId", exchangeOrderID)
+	setTimestamp(params)
+	params.Set("signature", e.sign(params.Encode()))
+
+	body, status, err := signedRequest(ctx, e.httpClient, e.apiKey, http.MethodGet, e.baseURL+"/fapi/v1/order", params)
+	if err != nil {
+		return OrderState{}, err
+	}
+	if status >= 300 {
+		return OrderState{}, fmt.Errorf("Binance HTTP %d: %s", status, string(body))
+	}
+
+	var result struct {
+		Status      string `json:"status"`
+		OrigQty     string `json:"origQty"`
+		ExecutedQty string `json:"executedQty"`
+		AvgPrice    string `json:"avgPrice"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return OrderState{}, fmt.Errorf("解析订单响应失败: %w", err)
+	}
+	st := OrderState{Status: mapBinanceStatus(result.Status)}
+	st.OrigQty, _ = strconv.ParseFloat(result.OrigQty, 64)
+	st.ExecutedQty, _ = strconv.ParseFloat(result.ExecutedQty, 64)
+	st.AvgPrice, _ = strconv.ParseFloat(result.AvgPrice, 64)
+	st.Status = settleStatus(st.Status, st.ExecutedQty)
+	return st, nil
+}
+
+// signedRequest 发送已签名的请求（params 需已包含 signature），返回响应体与状态码
+func signedRequest(ctx context.Context, client *http.Client, apiKey, method, apiURL string, params url.Values) ([]byte, int, error) {
+	var (
+		req *http.Request
+		err error
+	)
+	if method == http.MethodPost {
+		req, err = http.NewRequestWithContext(ctx, method, apiURL, strings.NewReader(params.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, apiURL+"?"+params.Encode(), nil)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("构建请求失败: %w", err)
+	}
+	req.Header.Set("X-MBX-APIKEY", apiKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Binance 请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("读取响应失败: %w", err)
+	}
+	noteKeyResponse(resp.StatusCode, body)
+	return body, resp.StatusCode, nil
+}
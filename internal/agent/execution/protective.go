@@ -0,0 +1,273 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"ai_quant/internal/domain"
+	"ai_quant/internal/exchangeinfo"
+
+	"github.com/google/uuid"
+)
+
+// ProtectionRequest 开仓成交后需要挂出的止盈止损，价格为 0 表示不挂该腿
+type ProtectionRequest struct {
+	Pair            string
+	Quantity        float64 // 现货卖出数量；合约使用 closePosition，忽略
+	StopPrice       float64
+	TakeProfitPrice float64
+}
+
+// ProtectiveLeg 交易所上已挂出的一条保护单
+type ProtectiveLeg struct {
+	Kind            string // domain.ProtectiveStopLoss / domain.ProtectiveTakeProfit
+	ExchangeOrderID string
+	TriggerPrice    float64
+	ListID          string // 同属一个交易所订单列表（OCO）时的 orderListId
+}
+
+// ProtectiveOrderManager 支持交易所止盈止损保护单的执行器：
+// 合约挂 STOP_MARKET / TAKE_PROFIT_MARKET（closePosition），现货挂 OCO。
+// 保护单挂在交易所，程序离线时依然有效；状态通过 OrderTracker 查询对账。
+type ProtectiveOrderManager interface {
+	OrderTracker
+	// PlaceProtection 挂出保护单；部分腿失败时返回已挂出的腿和错误
+	PlaceProtection(ctx context.Context, req ProtectionRequest) ([]ProtectiveLeg, error)
+}
+
+// PlaceProtection 为多仓挂 STOP_MARKET / TAKE_PROFIT_MARKET + closePosition=true，触发时平掉整个仓位
+func (e *BinanceFuturesExecutor) PlaceProtection(ctx context.Context, req ProtectionRequest) ([]ProtectiveLeg, error) {
+	var legs []ProtectiveLeg
+	if req.StopPrice > 0 {
+		id, err := e.placeCloseTrigger(ctx, req.Pair, "STOP_MARKET", req.StopPrice)
+		if err != nil {
+			return legs, fmt.Errorf("挂止损单失败: %w", err)
+		}
+		legs = append(legs, ProtectiveLeg{Kind: domain.ProtectiveStopLoss, ExchangeOrderID: id, TriggerPrice: req.StopPrice})
+	}
+	if req.TakeProfitPrice > 0 {
+		id, err := e.placeCloseTrigger(ctx, req.Pair, "TAKE_PROFIT_MARKET", req.TakeProfitPrice)
+		if err != nil {
+			return legs, fmt.Errorf("挂止盈单失败: %w", err)
+		}
+		legs = append(legs, ProtectiveLeg{Kind: domain.ProtectiveTakeProfit, ExchangeOrderID: id, TriggerPrice: req.TakeProfitPrice})
+	}
+	return legs, nil
+}
+
+// placeCloseTrigger 挂按标记价格触发的 closePosition 条件单
+func (e *BinanceFuturesExecutor) placeCloseTrigger(ctx context.Context, pair, orderType string, triggerPrice float64) (string, error) {
+	symbol := strings.ReplaceAll(strings.ToUpper(pair), "/", "")
+	price := e.formatPrice(ctx, symbol, triggerPrice)
+
+	if e.dryRun {
+		id := "dryrun-" + strings.ToLower(orderType[:4]) + "-" + uuid.NewString()[:8]
+		log.Printf("[合约] 模拟条件单: %s %s 触发价=%s closePosition=true", symbol, orderType, price)
+		return id, nil
+	}
+	if e.apiKey == "" || e.secretKey == "" {
+		return "", fmt.Errorf("交易所 API Key 未配置，无法挂条件单")
+	}
+
+	clientID := fmt.Sprintf("aqpt%s", uuid.NewString()[:8])
+	body, status, err := e.postOrder(ctx, func() url.Values {
+		params := url.Values{}
+		params.Set("symbol", symbol)
+		params.Set("side", "SELL")
+		params.Set("type", orderType)
+		params.Set("stopPrice", price)
+		params.Set("closePosition", "true")
+		if e.isHedgeMode() {
+			params.Set("positionSide", "LONG")
+		}
+		params.Set("workingType", "MARK_PRICE")
+		params.Set("newClientOrderId", clientID)
+		return params
+	})
+	if err != nil {
+		return "", err
+	}
+	if status >= 300 {
+		return "", fmt.Errorf("Binance HTTP %d: %s", status, string(body))
+	}
+
+	var result struct {
+		OrderID int64 `json:"orderId"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("解析条件单响应失败: %w", err)
+	}
+	id := strconv.FormatInt(result.OrderID, 10)
+	log.Printf("[合约] ✔ 条件单已挂出: %s %s 触发价=%s 订单ID=%s", symbol, orderType, price, id)
+	return id, nil
+}
+
+// CancelOrder 撤销挂单；订单已不存在（已触发或已撤销）时视为成功
+func (e *BinanceFuturesExecutor) CancelOrder(ctx context.Context, pair, exchangeOrderID string) error {
+	symbol := strings.ReplaceAll(strings.ToUpper(pair), "/", "")
+	if e.dryRun || strings.HasPrefix(exchangeOrderID, "dryrun-") {
+		log.Printf("[合约] 模拟撤单: %s 订单ID=%s", symbol, exchangeOrderID)
+		return nil
+	}
+
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("orderId", exchangeOrderID)
+	setTimestamp(params)
+	params.Set("signature", e.sign(params.Encode()))
+
+	body, status, err := signedRequest(ctx, e.httpClient, e.apiKey, http.MethodDelete, e.baseURL+"/fapi/v1/order", params)
+	if err != nil {
+		return err
+	}
+	// -2011 = Unknown order sent：订单已成交或已撤销
+	if status >= 300 && !strings.Contains(string(body), "-2011") {
+		return fmt.Errorf("Binance HTTP %d: %s", status, string(body))
+	}
+	log.Printf("[合约] ✔ 已撤单: %s 订单ID=%s", symbol, exchangeOrderID)
+	return nil
+}
+
+// formatPrice 按交易所 PRICE_FILTER tickSize 向下取整价格，tickSize 未知时按价格量级估算精度
+func (e *BinanceFuturesExecutor) formatPrice(ctx context.Context, symbol string, price float64) string {
+	tick, _ := e.exchangeInfo.TickSize(ctx, symbol)
+	return exchangeinfo.FloorToTick(price, tick)
+}
+
+// PlaceProtection 现货止盈止损：两个价格都有时挂 OCO（LIMIT_MAKER 止盈 + STOP_LOSS_LIMIT 止损），
+// 只有一个价格时挂单条 STOP_LOSS_LIMIT 或 LIMIT 卖单。止损限价按 STOP_LIMIT_SLIPPAGE_PCT 下浮，保证触发后能成交。
+func (e *BinanceExecutor) PlaceProtection(ctx context.Context, req ProtectionRequest) ([]ProtectiveLeg, error) {
+	symbol := pairToSymbol(req.Pair)
+	qty := formatQuantity(lotFilters(ctx, e.exchangeInfo, symbol, false), req.Quantity)
+	if q, _ := strconv.ParseFloat(qty, 64); q <= 0 {
+		return nil, fmt.Errorf("保护单数量过小: %.8f", req.Quantity)
+	}
+	tick, _ := e.exchangeInfo.TickSize(ctx, symbol)
+	stop := exchangeinfo.FloorToTick(req.StopPrice, tick)
+	stopLimit := exchangeinfo.FloorToTick(req.StopPrice*(1-e.stopLimitSlippage/100), tick)
+	tp := exchangeinfo.FloorToTick(req.TakeProfitPrice, tick)
+
+	if e.dryRun {
+		suffix := uuid.NewString()[:8]
+		var legs []ProtectiveLeg
+		if req.StopPrice > 0 {
+			legs = append(legs, ProtectiveLeg{Kind: domain.ProtectiveStopLoss, ExchangeOrderID: "dryrun-sl-" + suffix, TriggerPrice: req.StopPrice})
+		}
+		if req.TakeProfitPrice > 0 {
+			legs = append(legs, ProtectiveLeg{Kind: domain.ProtectiveTakeProfit, ExchangeOrderID: "dryrun-tp-" + suffix, TriggerPrice: req.TakeProfitPrice})
+		}
+		if len(legs) == 2 {
+			// 与实盘一致，两条腿视为一个 OCO
+			for i := range legs {
+				legs[i].ListID = "dryrun-oco-" + suffix
+			}
+		}
+		log.Printf("[执行] 模拟保护单: %s 数量=%s 止损=%s 止盈=%s", symbol, qty, stop, tp)
+		return legs, nil
+	}
+	if e.apiKey == "" || e.secretKey == "" {
+		return nil, fmt.Errorf("交易所 API Key 未配置，无法挂保护单")
+	}
+
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("side", "SELL")
+	params.Set("quantity", qty)
+	switch {
+	case req.StopPrice > 0 && req.TakeProfitPrice > 0:
+		return e.placeOCO(ctx, params, req, tp, stop, stopLimit)
+	case req.StopPrice > 0:
+		params.Set("type", "STOP_LOSS_LIMIT")
+		params.Set("timeInForce", "GTC")
+		params.Set("price", stopLimit)
+		params.Set("stopPrice", stop)
+		id, err := e.placeSpotOrder(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("挂止损单失败: %w", err)
+		}
+		return []ProtectiveLeg{{Kind: domain.ProtectiveStopLoss, ExchangeOrderID: id, TriggerPrice: req.StopPrice}}, nil
+	case req.TakeProfitPrice > 0:
+		params.Set("type", "LIMIT")
+		params.Set("timeInForce", "GTC")
+		params.Set("price", tp)
+		id, err := e.placeSpotOrder(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("挂止盈单失败: %w", err)
+		}
+		return []ProtectiveLeg{{Kind: domain.ProtectiveTakeProfit, ExchangeOrderID: id, TriggerPrice: req.TakeProfitPrice}}, nil
+	}
+	return nil, nil
+}
+
+// placeOCO 挂现货 OCO 卖单，任一腿成交后交易所自动撤销另一腿
+func (e *BinanceExecutor) placeOCO(ctx context.Context, params url.Values, req ProtectionRequest, tp, stop, stopLimit string) ([]ProtectiveLeg, error) {
+	params.Set("price", tp)
+	params.Set("stopPrice", stop)
+	params.Set("stopLimitPrice", stopLimit)
+	params.Set("stopLimitTimeInForce", "GTC")
+	params.Set("listClientOrderId", fmt.Sprintf("aqoco%s", uuid.NewString()[:8]))
+	setTimestamp(params)
+	params.Set("signature", e.sign(params.Encode()))
+
+	body, status, err := signedRequest(ctx, e.httpClient, e.apiKey, http.MethodPost, e.baseURL+"/api/v3/order/oco", params)
+	if err != nil {
+		return nil, err
+	}
+	if status >= 300 {
+		return nil, fmt.Errorf("挂 OCO 失败 Binance HTTP %d: %s", status, string(body))
+	}
+
+	var result struct {
+		OrderListID  int64 `json:"orderListId"`
+		OrderReports []struct {
+			OrderID int64  `json:"orderId"`
+			Type    string `json:"type"`
+		} `json:"orderReports"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析 OCO 响应失败: %w", err)
+	}
+	listID := strconv.FormatInt(result.OrderListID, 10)
+	var legs []ProtectiveLeg
+	for _, r := range result.OrderReports {
+		id := strconv.FormatInt(r.OrderID, 10)
+		if r.Type == "STOP_LOSS_LIMIT" || r.Type == "STOP_LOSS" {
+			legs = append(legs, ProtectiveLeg{Kind: domain.ProtectiveStopLoss, ExchangeOrderID: id, TriggerPrice: req.StopPrice, ListID: listID})
+		} else {
+			legs = append(legs, ProtectiveLeg{Kind: domain.ProtectiveTakeProfit, ExchangeOrderID: id, TriggerPrice: req.TakeProfitPrice, ListID: listID})
+		}
+	}
+	log.Printf("[执行] ✔ OCO 已挂出: %s 止盈=%s 止损=%s/%s 共%d条腿", params.Get("symbol"), tp, stop, stopLimit, len(legs))
+	return legs, nil
+}
+
+// placeSpotOrder 提交单条现货限价 / 止损限价单，返回交易所订单 ID
+func (e *BinanceExecutor) placeSpotOrder(ctx context.Context, params url.Values) (string, error) {
+	params.Set("newClientOrderId", fmt.Sprintf("aqpt%s", uuid.NewString()[:8]))
+	setTimestamp(params)
+	params.Set("signature", e.sign(params.Encode()))
+
+	body, status, err := signedRequest(ctx, e.httpClient, e.apiKey, http.MethodPost, e.baseURL+"/api/v3/order", params)
+	if err != nil {
+		return "", err
+	}
+	if status >= 300 {
+		return "", fmt.Errorf("Binance HTTP %d: %s", status, string(body))
+	}
+
+	var result struct {
+		OrderID int64 `json:"orderId"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("解析订单响应失败: %w", err)
+	}
+	id := strconv.FormatInt(result.OrderID, 10)
+	log.Printf("[执行] ✔ %s 已挂出: %s 价格=%s 订单ID=%s", params.Get("type"), params.Get("symbol"), params.Get("price"), id)
+	return id, nil
+}
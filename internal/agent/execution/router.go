@@ -0,0 +1,229 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"ai_quant/internal/config"
+	"ai_quant/internal/domain"
+)
+
+// Router 按交易对把请求路由到现货、杠杆或合约执行器，多个执行器同时可用。
+// 不带交易对的方法（余额、模式、杠杆）使用默认模式（TRADING_MODE）的执行器。
+type Router struct {
+	defaultMode string
+	executors   map[string]Executor // "spot" / "margin" / "futures"
+	modes       map[string]string   // 交易对 -> 模式
+}
+
+// NewFromConfig 根据配置创建执行器：EXCHANGE=okx 时返回 OKX 现货执行器；
+// 否则未配置 PAIR_TRADING_MODES 时返回单一 Binance 执行器，配置了则返回 Router
+func NewFromConfig(cfg config.Config) (Executor, error) {
+	modes, err := ParsePairModes(cfg.PairTradingModes)
+	if err != nil {
+		return nil, err
+	}
+	defaultMode := normalizeMode(cfg.TradingMode)
+
+	exchange, err := normalizeExchange(cfg.Exchange)
+	if err != nil {
+		return nil, err
+	}
+	if exchange == ExchangeOKX {
+		// OKX 适配目前只实现现货
+		if defaultMode != "spot" {
+			return nil, fmt.Errorf("OKX 暂只支持现货，请设置 TRADING_MODE=spot")
+		}
+		for pair, m := range modes {
+			if m != "spot" {
+				return nil, fmt.Errorf("OKX 暂只支持现货，PAIR_TRADING_MODES 中 %s 不能设为 %s", pair, m)
+			}
+		}
+		log.Printf("[执行] 使用 OKX 现货执行器")
+		return NewOKX(cfg), nil
+	}
+
+	if len(modes) == 0 {
+		switch defaultMode {
+		case "futures":
+			return NewFutures(cfg), nil
+		case "margin":
+			return NewMargin(cfg), nil
+		}
+		return New(cfg), nil
+	}
+
+	r := &Router{
+		defaultMode: defaultMode,
+		executors:   map[string]Executor{"spot": New(cfg)},
+		modes:       modes,
+	}
+	need := map[string]bool{defaultMode: true}
+	for _, m := range modes {
+		need[m] = true
+	}
+	if need["futures"] {
+		r.executors["futures"] = NewFutures(cfg)
+	}
+	if need["margin"] {
+		r.executors["margin"] = NewMargin(cfg)
+	}
+	log.Printf("[执行] 按交易对路由 默认=%s 指定=%v", defaultMode, modes)
+	return r, nil
+}
+
+// ParsePairModes 解析 "DOGE/USDT=spot,ETH/USDT=margin,BTC/USDT=futures" 格式的交易对模式配置
+func ParsePairModes(spec string) (map[string]string, error) {
+	modes := make(map[string]string)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pair, mode, ok := strings.Cut(item, "=")
+		pair = strings.ToUpper(strings.TrimSpace(pair))
+		mode = strings.ToLower(strings.TrimSpace(mode))
+		if !ok || pair == "" || (mode != "spot" && mode != "margin" && mode != "futures") {
+			return nil, fmt.Errorf("交易对模式配置格式错误: %q（应为 BTC/USDT=futures）", item)
+		}
+		modes[pair] = mode
+	}
+	return modes, nil
+}
+
+func normalizeMode(mode string) string {
+	switch m := strings.ToLower(strings.TrimSpace(mode)); m {
+	case "futures", "margin":
+		return m
+	}
+	return "spot"
+}
+
+// For 返回交易对对应的执行器
+func (r *Router) For(pair string) Executor {
+	mode, ok := r.modes[strings.ToUpper(pair)]
+	if !ok {
+		mode = r.defaultMode
+	}
+	return r.executors[mode]
+}
+
+// ForPair 单一执行器直接返回自身，Router 按交易对路由
+func ForPair(e Executor, pair string) Executor {
+	if r, ok := e.(*Router); ok {
+		return r.For(pair)
+	}
+	return e
+}
+
+func (r *Router) def() Executor {
+	return r.executors[r.defaultMode]
+}
+
+func (r *Router) Execute(ctx context.Context, input Input) (domain.Order, error) {
+	return r.For(input.Pair).Execute(ctx, input)
+}
+
+func (r *Router) FetchAccountBalances(ctx context.Context) ([]Balance, error) {
+	return r.def().FetchAccountBalances(ctx)
+}
+
+func (r *Router) FetchFullBalance(ctx context.Context) ([]Balance, error) {
+	return r.def().FetchFullBalance(ctx)
+}
+
+func (r *Router) FetchTradeHistory(ctx context.Context, pair string, limit int) ([]Trade, error) {
+	return r.For(pair).FetchTradeHistory(ctx, pair, limit)
+}
+
+func (r *Router) FetchPositionRisk(ctx context.Context, pair string) (float64, error) {
+	return r.For(pair).FetchPositionRisk(ctx, pair)
+}
+
+func (r *Router) IsDryRun() bool {
+	return r.def().IsDryRun()
+}
+
+func (r *Router) TradingMode() string {
+	return r.defaultMode
+}
+
+func (r *Router) Leverage() int {
+	return r.def().Leverage()
+}
+
+// PairModes 返回按交易对指定的模式（用于状态展示）
+func (r *Router) PairModes() map[string]string {
+	out := make(map[string]string, len(r.modes))
+	for p, m := range r.modes {
+		out[p] = m
+	}
+	return out
+}
+
+// PositionDetailer 支持查询合约持仓详情的执行器
+type PositionDetailer interface {
+	FetchPositionDetail(ctx context.Context, pair string) (*domain.FuturesPosition, error)
+}
+
+// PositionLeg 合约账户的单侧持仓（双向持仓时多头、空头各一条）
+type PositionLeg struct {
+	Symbol        string  // 如 BTCUSDT
+	Side          string  // domain.PositionSideLong / domain.PositionSideShort
+	Quantity      float64 // 数量（绝对值）
+	EntryPrice    float64
+	MarkPrice     float64
+	UnrealizedPnL float64
+}
+
+// PositionLegLister 支持列出合约账户全部持仓（含空头）的执行器，用于按方向同步持仓
+type PositionLegLister interface {
+	FetchPositionLegs(ctx context.Context) ([]PositionLeg, error)
+}
+
+// FetchPositionLegs 合约执行器的全部持仓，未启用合约时返回空
+func (r *Router) FetchPositionLegs(ctx context.Context) ([]PositionLeg, error) {
+	if l, ok := r.executors["futures"].(PositionLegLister); ok {
+		return l.FetchPositionLegs(ctx)
+	}
+	return nil, nil
+}
+
+// LeverageSetter 支持手动调整交易对杠杆的执行器
+type LeverageSetter interface {
+	SetLeverage(ctx context.Context, pair string, leverage int) error
+}
+
+// SetLeverage 把杠杆调整路由到交易对对应的执行器，现货交易对返回错误
+func (r *Router) SetLeverage(ctx context.Context, pair string, leverage int) error {
+	ls, ok := r.For(pair).(LeverageSetter)
+	if !ok {
+		return fmt.Errorf("%s 不是合约交易对，无法设置杠杆", pair)
+	}
+	return ls.SetLeverage(ctx, pair, leverage)
+}
+
+// MarginMonitor 支持查询现货杠杆风险率的执行器
+type MarginMonitor interface {
+	FetchMarginStatus(ctx context.Context, pair string) (*domain.MarginStatus, error)
+}
+
+// NeedsFutures 执行器是否会用到合约（合约模式或路由中包含合约交易对）
+func NeedsFutures(e Executor) bool {
+	if r, ok := e.(*Router); ok {
+		_, ok := r.executors["futures"]
+		return ok
+	}
+	return e.TradingMode() == "futures"
+}
+
+// NeedsMargin 执行器是否会用到现货杠杆（杠杆模式或路由中包含杠杆交易对）
+func NeedsMargin(e Executor) bool {
+	if r, ok := e.(*Router); ok {
+		_, ok := r.executors["margin"]
+		return ok
+	}
+	return e.TradingMode() == "margin"
+}
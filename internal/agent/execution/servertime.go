@@ -0,0 +1,178 @@
+package execution
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai_quant/internal/ratelimit"
+)
+
+// codeTimestampOutOfWindow Binance -1021：Timestamp for this request is outside of the recvWindow
+const codeTimestampOutOfWindow = -1021
+
+const (
+	// 距上次校准超过该时间后，下一次签名请求在后台重新校准
+	clockResyncInterval = 30 * time.Minute
+	// 本机时钟与交易所相差超过该值时打印告警
+	clockDriftWarn = time.Second
+	maxRecvWindow  = 60000
+)
+
+// serverClock 本机时钟与交易所服务器时间的偏移，签名请求的 timestamp 按服务器时间计算
+var serverClock = struct {
+	sync.Mutex
+	offset     time.Duration // 服务器时间 - 本机时间
+	syncedAt   time.Time
+	timeURL    string // 最近一次校准使用的 /api/v3/time 或 /fapi/v1/time
+	syncing    bool
+	recvWindow int64 // 毫秒，0 = 不传，使用交易所默认 5000
+}{}
+
+// SetRecvWindow 设置签名请求的 recvWindow（毫秒，交易所上限 60000），0 = 使用交易所默认值
+func SetRecvWindow(ms int) {
+	if ms > maxRecvWindow {
+		ms = maxRecvWindow
+	}
+	serverClock.Lock()
+	serverClock.recvWindow = int64(max(ms, 0))
+	serverClock.Unlock()
+}
+
+// ClockOffset 当前使用的本机与交易所时间偏移，未校准时 ok=false
+func ClockOffset() (offset time.Duration, syncedAt time.Time, ok bool) {
+	serverClock.Lock()
+	defer serverClock.Unlock()
+	return serverClock.offset, serverClock.syncedAt, !serverClock.syncedAt.IsZero()
+}
+
+// setTimestamp 为签名请求写入按服务器时间校正的 timestamp 与 recvWindow，需在签名前调用
+func setTimestamp(params url.Values) {
+	serverClock.Lock()
+	now := time.Now().Add(serverClock.offset)
+	recvWindow := serverClock.recvWindow
+	stale := serverClock.timeURL != "" && !serverClock.syncing && time.Since(serverClock.syncedAt) > clockResyncInterval
+	timeURL := serverClock.timeURL
+	if stale {
+		serverClock.syncing = true
+	}
+	serverClock.Unlock()
+
+	params.Set("timestamp", strconv.FormatInt(now.UnixMilli(), 10))
+	if recvWindow > 0 {
+		params.Set("recvWindow", strconv.FormatInt(recvWindow, 10))
+	}
+	if stale {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := syncServerTime(ctx, newSignedClient(5*time.Second), timeURL); err != nil {
+				log.Printf("[执行] ⚠ 交易所时间校准失败: %v", err)
+			}
+		}()
+	}
+}
+
+// timeEndpoint 根据请求地址推断对应的服务器时间接口（合约 /fapi，其余按现货）
+func timeEndpoint(u *url.URL) string {
+	path := "/api/v3/time"
+	if strings.HasPrefix(u.Path, "/fapi/") {
+		path = "/fapi/v1/time"
+	}
+	return u.Scheme + "://" + u.Host + path
+}
+
+// syncServerTime 请求交易所服务器时间，按往返时间的中点计算偏移
+func syncServerTime(ctx context.Context, client *http.Client, timeURL string) error {
+	defer func() {
+		serverClock.Lock()
+		serverClock.syncing = false
+		serverClock.Unlock()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, timeURL, nil)
+	if err != nil {
+		return err
+	}
+	sent := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	received := time.Now()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var body struct {
+		ServerTime int64 `json:"serverTime"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.ServerTime == 0 {
+		return fmt.Errorf("解析服务器时间失败: %v", err)
+	}
+
+	local := sent.Add(received.Sub(sent) / 2)
+	offset := time.UnixMilli(body.ServerTime).Sub(local).Round(time.Millisecond)
+
+	serverClock.Lock()
+	serverClock.offset = offset
+	serverClock.syncedAt = time.Now()
+	serverClock.timeURL = timeURL
+	serverClock.Unlock()
+
+	if offset > clockDriftWarn || offset < -clockDriftWarn {
+		log.Printf("[执行] ⚠ 本机时钟与交易所相差 %s，签名时间戳已按服务器时间校正", offset)
+	}
+	return nil
+}
+
+// calibrateClock 启动时校准一次服务器时间，失败时沿用本机时间
+func calibrateClock(client *http.Client, timeURL string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := syncServerTime(ctx, client, timeURL); err != nil {
+		log.Printf("[执行] ⚠ 交易所时间校准失败，使用本机时间: %v", err)
+	}
+}
+
+// clockTransport 收到 -1021（时间戳超出 recvWindow）时立即重新校准，下一次请求即使用新偏移
+type clockTransport struct {
+	Base http.RoundTripper
+}
+
+func (t *clockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		return resp, err
+	}
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		return resp, nil
+	}
+	if code, _ := binanceError(body); code == codeTimestampOutOfWindow {
+		log.Printf("[执行] ⚠ Binance -1021 时间戳超出 recvWindow，重新校准服务器时间")
+		client := &http.Client{Timeout: 5 * time.Second, Transport: t.Base}
+		if err := syncServerTime(req.Context(), client, timeEndpoint(req.URL)); err != nil {
+			log.Printf("[执行] ⚠ 交易所时间校准失败: %v", err)
+		}
+	}
+	return resp, nil
+}
+
+// newSignedClient 创建用于 Binance 签名请求的 HTTP 客户端（限流 + -1021 自动校准）
+func newSignedClient(timeout time.Duration) *http.Client {
+	client := ratelimit.NewClient(timeout)
+	client.Transport = &clockTransport{Base: client.Transport}
+	return client
+}
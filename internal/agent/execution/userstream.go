@@ -0,0 +1,283 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai_quant/internal/config"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// listenKey 60 分钟无续期即失效，按 30 分钟续期
+	listenKeyKeepalive = 30 * time.Minute
+	// 用户数据流只在有账户变动时推送，超过该时长无消息时重连以确认连接有效
+	userStreamReadTimeout = 65 * time.Minute
+	userStreamRetryMin    = time.Second
+	userStreamRetryMax    = time.Minute
+)
+
+// OrderUpdate 用户数据流推送的订单更新（现货 / 杠杆 executionReport，合约 ORDER_TRADE_UPDATE）
+type OrderUpdate struct {
+	Pair            string
+	ClientOrderID   string
+	ExchangeOrderID string
+	Side            string // BUY / SELL
+	Status          string // 与 mapBinanceStatus 一致：submitted / partial_filled / filled / rejected
+	OrigQty         float64
+	ExecutedQty     float64 // 累计成交数量
+	AvgPrice        float64 // 累计成交均价
+	EventTime       time.Time
+}
+
+// State 转成与 QueryOrder 一致的订单状态
+func (u OrderUpdate) State() OrderState {
+	return OrderState{Status: u.Status, OrigQty: u.OrigQty, ExecutedQty: u.ExecutedQty, AvgPrice: u.AvgPrice}
+}
+
+// UserStreamer 支持订阅用户数据流的执行器；未启用或不支持时 StartUserStream 返回 false
+type UserStreamer interface {
+	StartUserStream(handle func(OrderUpdate)) bool
+}
+
+// userStream 单个账户（现货 / 杠杆 / 合约）的用户数据流：申请 listenKey、定时续期，断开后换新 listenKey 重连
+type userStream struct {
+	name       string // 日志标识：现货 / 杠杆 / 合约
+	client     *http.Client
+	apiKey     string
+	keyURL     string // listenKey 接口，如 https://api.binance.com/api/v3/userDataStream
+	streamBase string // WebSocket 地址，如 wss://stream.binance.com:9443
+}
+
+// newUserStream 按配置创建用户数据流；未启用、模拟盘或未配置 Key 时返回 nil
+func newUserStream(cfg config.Config, name string, client *http.Client, keyURL, streamBase string) *userStream {
+	if !cfg.UserStreamEnabled || cfg.DryRun || cfg.ExchangeAPIKey == "" || streamBase == "" {
+		return nil
+	}
+	return &userStream{
+		name:       name,
+		client:     client,
+		apiKey:     cfg.ExchangeAPIKey,
+		keyURL:     keyURL,
+		streamBase: strings.TrimRight(streamBase, "/"),
+	}
+}
+
+// start 启动后台连接；nil 时返回 false
+func (u *userStream) start(handle func(OrderUpdate)) bool {
+	if u == nil {
+		return false
+	}
+	go u.run(handle)
+	return true
+}
+
+// run 维持连接，断开后按指数退避重连
+func (u *userStream) run(handle func(OrderUpdate)) {
+	backoff := userStreamRetryMin
+	for {
+		start := time.Now()
+		err := u.connect(handle)
+		if time.Since(start) > userStreamRetryMax {
+			backoff = userStreamRetryMin
+		}
+		log.Printf("[成交推送] ⚠ %s 用户数据流断开: %v，%s 后重连", u.name, err, backoff)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > userStreamRetryMax {
+			backoff = userStreamRetryMax
+		}
+	}
+}
+
+// connect 申请 listenKey 并持续读取推送，返回断开原因
+func (u *userStream) connect(handle func(OrderUpdate)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	key, err := u.listenKey(ctx, http.MethodPost, "")
+	cancel()
+	if err != nil {
+		return fmt.Errorf("申请 listenKey 失败: %w", err)
+	}
+
+	cfg, err := websocket.NewConfig(u.streamBase+"/ws/"+key, "http://localhost/")
+	if err != nil {
+		return err
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 15*time.Second)
+	conn, err := cfg.DialContext(ctx)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	log.Printf("[成交推送] ✔ %s 用户数据流已连接", u.name)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go u.keepalive(key, stop)
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(userStreamReadTimeout)); err != nil {
+			return err
+		}
+		var raw []byte
+		if err := websocket.Message.Receive(conn, &raw); err != nil {
+			return err
+		}
+		upd, event, ok := parseUserEvent(raw)
+		if event == "listenKeyExpired" {
+			return fmt.Errorf("listenKey 已过期")
+		}
+		if ok {
+			handle(upd)
+		}
+	}
+}
+
+// keepalive 定时续期 listenKey，直到连接关闭
+func (u *userStream) keepalive(key string, stop <-chan struct{}) {
+	ticker := time.NewTicker(listenKeyKeepalive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			if _, err := u.listenKey(ctx, http.MethodPut, key); err != nil {
+				log.Printf("[成交推送] ⚠ %s listenKey 续期失败: %v", u.name, err)
+			}
+			cancel()
+		}
+	}
+}
+
+// listenKey 申请（POST）或续期（PUT）listenKey；只需 API Key，不需要签名
+func (u *userStream) listenKey(ctx context.Context, method, key string) (string, error) {
+	apiURL := u.keyURL
+	if key != "" {
+		apiURL += "?listenKey=" + key
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-MBX-APIKEY", u.apiKey)
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	noteKeyResponse(resp.StatusCode, body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	var out struct {
+		ListenKey string `json:"listenKey"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", err
+	}
+	if method == http.MethodPost && out.ListenKey == "" {
+		return "", fmt.Errorf("响应中没有 listenKey")
+	}
+	return out.ListenKey, nil
+}
+
+// parseUserEvent 解析用户数据流消息，只有订单更新返回 ok=true。
+// 推送字段同时使用大小写区分的单字母键（如 c / C、ap / AP），按键名精确读取，不走结构体的大小写不敏感匹配
+func parseUserEvent(raw []byte) (OrderUpdate, string, bool) {
+	var msg map[string]json.RawMessage
+	if json.Unmarshal(raw, &msg) != nil {
+		return OrderUpdate{}, "", false
+	}
+	event := rawString(msg["e"])
+	upd := OrderUpdate{EventTime: time.UnixMilli(rawInt(msg["E"])).UTC()}
+
+	var o map[string]json.RawMessage
+	switch event {
+	case "executionReport":
+		o = msg
+	case "ORDER_TRADE_UPDATE":
+		if json.Unmarshal(msg["o"], &o) != nil {
+			return OrderUpdate{}, event, false
+		}
+	default:
+		return OrderUpdate{}, event, false
+	}
+
+	upd.Pair = symbolToPair(rawString(o["s"]))
+	upd.ClientOrderID = rawString(o["c"])
+	upd.ExchangeOrderID = strconv.FormatInt(rawInt(o["i"]), 10)
+	upd.Side = rawString(o["S"])
+	upd.Status = mapBinanceStatus(rawString(o["X"]))
+	upd.OrigQty = rawFloat(o["q"])
+	upd.ExecutedQty = rawFloat(o["z"])
+	if ap := rawFloat(o["ap"]); ap > 0 {
+		upd.AvgPrice = ap // 合约直接给出均价
+	} else if upd.ExecutedQty > 0 {
+		upd.AvgPrice = rawFloat(o["Z"]) / upd.ExecutedQty // 现货：累计成交额 / 累计成交量
+	}
+	return upd, event, upd.Pair != "" && upd.ExchangeOrderID != "0"
+}
+
+// rawString 读取 JSON 字符串值
+func rawString(v json.RawMessage) string {
+	var s string
+	_ = json.Unmarshal(v, &s)
+	return s
+}
+
+// rawInt 读取 JSON 整数（订单 ID、事件时间）
+func rawInt(v json.RawMessage) int64 {
+	var n int64
+	_ = json.Unmarshal(v, &n)
+	return n
+}
+
+// rawFloat 读取 JSON 数字或数字字符串（Binance 的价格与数量均为字符串）
+func rawFloat(v json.RawMessage) float64 {
+	var f float64
+	if json.Unmarshal(v, &f) == nil {
+		return f
+	}
+	f, _ = strconv.ParseFloat(rawString(v), 64)
+	return f
+}
+
+// StartUserStream 订阅现货用户数据流
+func (e *BinanceExecutor) StartUserStream(handle func(OrderUpdate)) bool {
+	return e.stream.start(handle)
+}
+
+// StartUserStream 订阅合约用户数据流
+func (e *BinanceFuturesExecutor) StartUserStream(handle func(OrderUpdate)) bool {
+	return e.stream.start(handle)
+}
+
+// StartUserStream 订阅杠杆用户数据流（仅全仓；逐仓需按交易对分别申请 listenKey，暂不支持）
+func (e *BinanceMarginExecutor) StartUserStream(handle func(OrderUpdate)) bool {
+	return e.stream.start(handle)
+}
+
+// StartUserStream 启动各执行器的用户数据流，任一启动即返回 true
+func (r *Router) StartUserStream(handle func(OrderUpdate)) bool {
+	started := false
+	for _, ex := range r.executors {
+		if s, ok := ex.(UserStreamer); ok && s.StartUserStream(handle) {
+			started = true
+		}
+	}
+	return started
+}
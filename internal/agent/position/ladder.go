@@ -0,0 +1,53 @@
+package position
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ai_quant/internal/domain"
+)
+
+// ParseExitLadder 解析 "5:0.5,10:1" 格式的分批止盈阶梯：盈利 5% 卖出 50%，盈利 10% 卖出剩余全部。
+// 比例为触发时持仓的占比，按盈利百分比从低到高排序
+func ParseExitLadder(spec string) ([]domain.ExitBatch, error) {
+	var ladder []domain.ExitBatch
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pctStr, fracStr, ok := strings.Cut(item, ":")
+		pct, err1 := strconv.ParseFloat(strings.TrimSpace(pctStr), 64)
+		frac, err2 := strconv.ParseFloat(strings.TrimSpace(fracStr), 64)
+		if !ok || err1 != nil || err2 != nil || pct <= 0 || frac <= 0 || frac > 1 {
+			return nil, fmt.Errorf("分批止盈配置格式错误: %q（应为 盈利百分比:卖出比例，如 5:0.5，比例在 (0,1] 之间）", item)
+		}
+		ladder = append(ladder, domain.ExitBatch{ProfitPercent: pct, Fraction: frac})
+	}
+	sort.Slice(ladder, func(i, j int) bool { return ladder[i].ProfitPercent < ladder[j].ProfitPercent })
+	for i := 1; i < len(ladder); i++ {
+		if ladder[i].ProfitPercent == ladder[i-1].ProfitPercent {
+			return nil, fmt.Errorf("分批止盈配置中盈利百分比 %g 重复", ladder[i].ProfitPercent)
+		}
+	}
+	return ladder, nil
+}
+
+// exitBatches 按阶梯生成待触发的分批止盈批次
+func exitBatches(ladder []domain.ExitBatch) []domain.ExitBatch {
+	if len(ladder) == 0 {
+		return nil
+	}
+	exits := make([]domain.ExitBatch, len(ladder))
+	for i, l := range ladder {
+		exits[i] = domain.ExitBatch{
+			BatchNo:       i + 1,
+			ProfitPercent: l.ProfitPercent,
+			Fraction:      l.Fraction,
+			Status:        domain.BatchPending,
+		}
+	}
+	return exits
+}
@@ -19,6 +19,13 @@ type Input struct {
 	MaxStakeUSDT float64
 	CurrentPrice float64
 	Volatility   float64 // 波动率（可选）
+
+	// 可选：风险预设指定的止盈/止损百分比，0 表示按策略默认值
+	TakeProfitPercent float64
+	StopLossPercent   float64
+
+	// 可选：分批止盈阶梯（TAKE_PROFIT_LADDER），为空时止盈一次性卖出
+	ExitLadder []domain.ExitBatch
 }
 
 // Agent 建仓策略生成器
@@ -89,6 +96,19 @@ func (a *agent) Generate(ctx context.Context, input Input) (domain.PositionStrat
 		return domain.PositionStrategy{}, fmt.Errorf("未知策略类型: %s", strategy)
 	}
 
+	if input.TakeProfitPercent > 0 {
+		takeProfitPercent = input.TakeProfitPercent
+	}
+	if input.StopLossPercent > 0 {
+		stopLossPercent = input.StopLossPercent
+	}
+
+	exits := exitBatches(input.ExitLadder)
+	if n := len(exits); n > 0 && exits[n-1].Fraction >= 1 {
+		// 最后一档卖出剩余全部：交易所止盈保护单与最后一档对齐
+		takeProfitPercent = exits[n-1].ProfitPercent
+	}
+
 	log.Printf("[建仓策略] %s 策略=%s 总金额=%.2f 分批=%d 止盈=%.1f%% 止损=%.1f%%",
 		input.Pair, strategy, input.MaxStakeUSDT, len(batches), takeProfitPercent, stopLossPercent)
 
@@ -102,6 +122,7 @@ func (a *agent) Generate(ctx context.Context, input Input) (domain.PositionStrat
 		TotalAmount:       input.MaxStakeUSDT,
 		EntryLevels:       len(batches),
 		Batches:           batches,
+		ExitBatches:       exits,
 		TakeProfitPercent: takeProfitPercent,
 		StopLossPercent:   stopLossPercent,
 		Reason:            reason,
@@ -9,6 +9,7 @@ import (
 
 	"ai_quant/internal/config"
 	"ai_quant/internal/domain"
+	"ai_quant/internal/tradingday"
 
 	"github.com/google/uuid"
 )
@@ -17,19 +18,38 @@ type Input struct {
 	CycleID   string
 	Signal    domain.Signal
 	Portfolio domain.PortfolioState
+
+	// 可选：本周期生效的风险预设（为空时使用配置参数）
+	Preset *domain.RiskPreset
+	// 该币对最近一次开仓时间（用于冷却判断），零值表示没有记录
+	LastEntryAt time.Time
+	// 该币对最近连续亏损的平仓次数与最后一次亏损平仓时间（用于连亏冷却）
+	LossStreak int
+	LastLossAt time.Time
+	// 该币对当前交易日已成交的订单数（开仓与平仓），用于每日交易次数上限
+	DailyTrades int
 }
 
 type Agent interface {
 	Evaluate(ctx context.Context, input Input) (domain.RiskDecision, error)
 }
 
+// TradeLimiter 提供每日交易次数上限；不经过 Evaluate 的开仓（如分批建仓的后续批次）也按该上限拦截
+type TradeLimiter interface {
+	MaxDailyTrades() int
+}
+
 type RuleAgent struct {
 	maxSingleStakeUSDT float64 // 单笔最大下单金额上限
 	maxDailyLossUSDT   float64
 	maxExposureUSDT    float64
 	minConfidence      float64
-	tradingMode        string // "spot" 或 "futures"
-	leverage           int    // 杠杆倍数
+	tradingMode        string        // "spot" 或 "futures"
+	leverage           int           // 杠杆倍数
+	cooldownSec        int           // 同一币对开仓冷却时间（秒）
+	lossStreakMax      int           // 连续亏损达到该次数后暂停开仓，0 = 不启用
+	lossStreakCooldown time.Duration // 连亏冷却时长，从最后一次亏损平仓起算
+	maxDailyTrades     int           // 同一币对每日最多成交订单数，0 = 不限制
 }
 
 func New(cfg config.Config) Agent {
@@ -47,11 +67,21 @@ func New(cfg config.Config) Agent {
 		minConfidence:      cfg.MinConfidence,
 		tradingMode:        cfg.TradingMode,
 		leverage:           leverage,
+		cooldownSec:        cfg.CooldownSec,
+		lossStreakMax:      cfg.LossStreakMax,
+		lossStreakCooldown: time.Duration(cfg.LossStreakCooldownMin) * time.Minute,
+		maxDailyTrades:     cfg.MaxDailyTradesPerPair,
 	}
 }
 
+// MaxDailyTrades 同一币对每日最多成交订单数，0 = 不限制
+func (a *RuleAgent) MaxDailyTrades() int {
+	return a.maxDailyTrades
+}
+
 func (a *RuleAgent) Evaluate(_ context.Context, input Input) (domain.RiskDecision, error) {
 	now := time.Now().UTC()
+	limits := a.limitsFor(input.Preset)
 	decision := domain.RiskDecision{
 		ID:           uuid.NewString(),
 		CycleID:      input.CycleID,
@@ -63,14 +93,16 @@ func (a *RuleAgent) Evaluate(_ context.Context, input Input) (domain.RiskDecisio
 	}
 
 	if input.Signal.Side == domain.SideNone {
+		decision.RejectCode = domain.ReasonSignalNone
 		decision.RejectReason = "signal side is none"
 		return decision, nil
 	}
 
 	// close（卖出）信号：只检查置信度，不检查敞口限制
 	if input.Signal.Side == domain.SideClose {
-		if input.Signal.Confidence < a.minConfidence {
-			decision.RejectReason = fmt.Sprintf("close signal confidence %.2f below min %.2f", input.Signal.Confidence, a.minConfidence)
+		if input.Signal.Confidence < limits.minConfidence {
+			decision.RejectCode = domain.ReasonLowConfidence
+			decision.RejectReason = fmt.Sprintf("close signal confidence %.2f below min %.2f", input.Signal.Confidence, limits.minConfidence)
 			return decision, nil
 		}
 		decision.Approved = true
@@ -79,34 +111,108 @@ func (a *RuleAgent) Evaluate(_ context.Context, input Input) (domain.RiskDecisio
 	}
 
 	// long（买入）信号：检查置信度 + 敞口 + 每日亏损
-	if input.Signal.Confidence < a.minConfidence {
-		decision.RejectReason = fmt.Sprintf("signal confidence %.2f below min %.2f", input.Signal.Confidence, a.minConfidence)
+	if input.Signal.Confidence < limits.minConfidence {
+		decision.RejectCode = domain.ReasonLowConfidence
+		decision.RejectReason = fmt.Sprintf("signal confidence %.2f below min %.2f", input.Signal.Confidence, limits.minConfidence)
 		return decision, nil
 	}
-	if input.Portfolio.DailyPnLUSDT <= -math.Abs(a.maxDailyLossUSDT) {
-		decision.RejectReason = fmt.Sprintf("daily pnl %.2f below max loss limit -%.2f", input.Portfolio.DailyPnLUSDT, math.Abs(a.maxDailyLossUSDT))
+	if input.Portfolio.DailyPnLUSDT <= -math.Abs(limits.maxDailyLossUSDT) {
+		decision.RejectCode = domain.ReasonDailyLossLimit
+		decision.RejectReason = fmt.Sprintf("daily pnl %.2f below max loss limit -%.2f (trading day %s %s)",
+			input.Portfolio.DailyPnLUSDT, math.Abs(limits.maxDailyLossUSDT), tradingday.Key(now), tradingday.Location())
 		return decision, nil
 	}
 
-	remainingExposure := a.maxExposureUSDT - input.Portfolio.OpenExposureUSDT
+	if limits.cooldownSec > 0 && !input.LastEntryAt.IsZero() {
+		if wait := time.Duration(limits.cooldownSec)*time.Second - now.Sub(input.LastEntryAt); wait > 0 {
+			decision.RejectCode = domain.ReasonCooldown
+			decision.RejectReason = fmt.Sprintf("cooldown active: last entry %s ago, wait %s", now.Sub(input.LastEntryAt).Round(time.Second), wait.Round(time.Second))
+			return decision, nil
+		}
+	}
+
+	if a.lossStreakMax > 0 && input.LossStreak >= a.lossStreakMax && !input.LastLossAt.IsZero() {
+		if wait := a.lossStreakCooldown - now.Sub(input.LastLossAt); wait > 0 {
+			decision.RejectCode = domain.ReasonLossStreak
+			decision.RejectReason = fmt.Sprintf("%d consecutive losing closes (limit %d), last loss %s ago, cooldown wait %s",
+				input.LossStreak, a.lossStreakMax, now.Sub(input.LastLossAt).Round(time.Second), wait.Round(time.Second))
+			return decision, nil
+		}
+	}
+
+	// 每日交易次数上限只拦截开仓，平仓始终放行，避免达到上限后无法离场
+	if a.maxDailyTrades > 0 && input.DailyTrades >= a.maxDailyTrades {
+		decision.RejectCode = domain.ReasonDailyTradeLimit
+		decision.RejectReason = fmt.Sprintf("daily trade limit reached: %d filled orders today (limit %d, trading day %s %s)",
+			input.DailyTrades, a.maxDailyTrades, tradingday.Key(now), tradingday.Location())
+		return decision, nil
+	}
+
+	remainingExposure := limits.maxExposureUSDT - input.Portfolio.OpenExposureUSDT
 	if remainingExposure <= 0 {
+		decision.RejectCode = domain.ReasonMaxExposure
 		decision.RejectReason = "max exposure limit reached"
 		return decision, nil
 	}
 
-	decision.MaxStakeUSDT = math.Min(a.maxSingleStakeUSDT, remainingExposure)
+	decision.MaxStakeUSDT = math.Min(limits.maxSingleStakeUSDT, remainingExposure)
+	decision.Sizing = &domain.SizingBreakdown{
+		MaxSingleStakeUSDT:    limits.maxSingleStakeUSDT,
+		MaxExposureUSDT:       limits.maxExposureUSDT,
+		OpenExposureUSDT:      input.Portfolio.OpenExposureUSDT,
+		RemainingExposureUSDT: remainingExposure,
+		RiskStakeUSDT:         decision.MaxStakeUSDT,
+		Leverage:              limits.leverage,
+	}
 	if decision.MaxStakeUSDT <= 0 {
+		decision.RejectCode = domain.ReasonZeroStake
 		decision.RejectReason = "computed max stake is zero"
 		return decision, nil
 	}
 
 	// 合约模式：显示杠杆放大后的实际仓位
-	if a.tradingMode == "futures" && a.leverage > 1 {
-		actualPosition := decision.MaxStakeUSDT * float64(a.leverage)
+	if a.tradingMode == "futures" && limits.leverage > 1 {
+		actualPosition := decision.MaxStakeUSDT * float64(limits.leverage)
 		log.Printf("[风控] 合约模式: 保证金=%.2f USDT x%d倍杠杆 = 实际仓位 %.2f USDT",
-			decision.MaxStakeUSDT, a.leverage, actualPosition)
+			decision.MaxStakeUSDT, limits.leverage, actualPosition)
 	}
 
 	decision.Approved = true
 	return decision, nil
 }
+
+// riskLimits 单次评估使用的风控参数
+type riskLimits struct {
+	maxSingleStakeUSDT float64
+	maxDailyLossUSDT   float64
+	maxExposureUSDT    float64
+	minConfidence      float64
+	leverage           int
+	cooldownSec        int
+}
+
+// limitsFor 有预设时使用预设参数，否则使用配置参数
+func (a *RuleAgent) limitsFor(p *domain.RiskPreset) riskLimits {
+	if p == nil {
+		return riskLimits{
+			maxSingleStakeUSDT: a.maxSingleStakeUSDT,
+			maxDailyLossUSDT:   a.maxDailyLossUSDT,
+			maxExposureUSDT:    a.maxExposureUSDT,
+			minConfidence:      a.minConfidence,
+			leverage:           a.leverage,
+			cooldownSec:        a.cooldownSec,
+		}
+	}
+	leverage := a.leverage
+	if a.tradingMode == "futures" && p.Leverage > 0 {
+		leverage = p.Leverage
+	}
+	return riskLimits{
+		maxSingleStakeUSDT: p.MaxSingleStakeUSDT,
+		maxDailyLossUSDT:   p.MaxDailyLossUSDT,
+		maxExposureUSDT:    p.MaxExposureUSDT,
+		minConfidence:      p.MinConfidence,
+		leverage:           leverage,
+		cooldownSec:        p.CooldownSec,
+	}
+}
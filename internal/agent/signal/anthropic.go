@@ -0,0 +1,278 @@
+package signal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"ai_quant/internal/auth"
+	"ai_quant/internal/config"
+	"ai_quant/internal/trace"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+const anthropicVersion = "2023-06-01"
+
+// anthropicThinkingBudgets reasoning effort 对应的 extended thinking token 预算
+var anthropicThinkingBudgets = map[string]int{"low": 1024, "medium": 4096, "high": 16384}
+
+// anthropicModel Claude Messages API 客户端，实现 llms.Model。
+// 认证由 LLMAuthManager 决定：API Key 走 x-api-key，OAuth Token 走 Bearer；每次请求重新取 token 以便 OAuth 自动刷新
+type anthropicModel struct {
+	client         *http.Client
+	baseURL        string
+	model          string
+	maxTokens      int
+	thinkingBudget int // > 0 时开启 extended thinking；0 时按 reasoning effort 决定
+	apiKey         string
+	authManager    *auth.LLMAuthManager
+}
+
+// newAnthropicModel 创建 Claude 客户端（LLM_AUTH_PROVIDER=anthropic 或 LLM_BACKEND=anthropic）
+func newAnthropicModel(cfg config.Config, authService *auth.Service) (llms.Model, string, error) {
+	apiKey := strings.TrimSpace(cfg.AnthropicAPIKey)
+	authManager := auth.NewLLMAuthManager(authService, apiKey, auth.AuthMode(cfg.LLMAuthMode), auth.ProviderAnthropic)
+	if _, err := authManager.GetToken(); err != nil {
+		return nil, "", fmt.Errorf("获取 Anthropic 认证失败: %w", err)
+	}
+	model := strings.TrimSpace(cfg.AnthropicModel)
+	if model == "" {
+		return nil, "", fmt.Errorf("ANTHROPIC_MODEL 未配置")
+	}
+	maxTokens := cfg.AnthropicMaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 4096
+	}
+
+	status := authManager.GetStatus()
+	log.Printf("[信号] Anthropic 已配置 模型=%s 认证模式=%s OAuth可用=%v thinking预算=%d",
+		model, status["mode"], status["oauth_available"], cfg.AnthropicThinkingBudget)
+	return &anthropicModel{
+		client:         trace.NewClient(0),
+		baseURL:        strings.TrimRight(cfg.AnthropicBaseURL, "/"),
+		model:          model,
+		maxTokens:      maxTokens,
+		thinkingBudget: cfg.AnthropicThinkingBudget,
+		apiKey:         apiKey,
+		authManager:    authManager,
+	}, "anthropic/" + model, nil
+}
+
+type anthropicContent struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	Thinking string          `json:"thinking,omitempty"`
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	Input    json.RawMessage `json:"input,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string             `json:"role"`
+	Content []anthropicContent `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Temperature *float64           `json:"temperature,omitempty"`
+	TopP        *float64           `json:"top_p,omitempty"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice  map[string]string  `json:"tool_choice,omitempty"`
+	Thinking    map[string]any     `json:"thinking,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content    []anthropicContent `json:"content"`
+	StopReason string             `json:"stop_reason"`
+	Usage      struct {
+		InputTokens              int `json:"input_tokens"`
+		OutputTokens             int `json:"output_tokens"`
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Call 单轮文本调用
+func (m *anthropicModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	resp, err := m.GenerateContent(ctx, []llms.MessageContent{{
+		Role:  llms.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{llms.TextContent{Text: prompt}},
+	}}, options...)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("anthropic API 返回空结果")
+	}
+	return resp.Choices[0].Content, nil
+}
+
+// GenerateContent 调用 Messages API；思考内容放入 ReasoningContent，工具调用转换为 ToolCalls，
+// token 用量按 PromptTokens / CompletionTokens / TotalTokens 写入 GenerationInfo（与 OpenAI 路径一致）
+func (m *anthropicModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+	req := m.buildRequest(ctx, messages, opts)
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := m.authManager.GetToken()
+	if err != nil {
+		return nil, fmt.Errorf("获取 Anthropic 认证失败: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+	if token == m.apiKey {
+		httpReq.Header.Set("x-api-key", token)
+	} else {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+		httpReq.Header.Set("anthropic-beta", "oauth-2025-04-20")
+	}
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var out anthropicResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("anthropic API %d: 解析响应失败: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || out.Error != nil {
+		msg := string(raw)
+		if out.Error != nil {
+			msg = out.Error.Type + ": " + out.Error.Message
+		}
+		return nil, fmt.Errorf("anthropic API %d: %s", resp.StatusCode, msg)
+	}
+
+	choice := &llms.ContentChoice{StopReason: out.StopReason}
+	var text, thinking []string
+	for _, c := range out.Content {
+		switch c.Type {
+		case "text":
+			text = append(text, c.Text)
+		case "thinking":
+			thinking = append(thinking, c.Thinking)
+		case "tool_use":
+			choice.ToolCalls = append(choice.ToolCalls, llms.ToolCall{
+				ID:           c.ID,
+				Type:         "function",
+				FunctionCall: &llms.FunctionCall{Name: c.Name, Arguments: string(c.Input)},
+			})
+		}
+	}
+	choice.Content = strings.Join(text, "")
+	choice.ReasoningContent = strings.Join(thinking, "\n")
+
+	prompt := out.Usage.InputTokens + out.Usage.CacheCreationInputTokens + out.Usage.CacheReadInputTokens
+	choice.GenerationInfo = map[string]any{
+		"PromptTokens":     prompt,
+		"CompletionTokens": out.Usage.OutputTokens,
+		"TotalTokens":      prompt + out.Usage.OutputTokens,
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{choice}}, nil
+}
+
+// buildRequest 把 langchaingo 消息与调用参数转换为 Messages API 请求：system 消息单独放入 system 字段，
+// 开启 thinking 时不传 temperature / top_p，工具选择只能为 auto
+func (m *anthropicModel) buildRequest(ctx context.Context, messages []llms.MessageContent, opts llms.CallOptions) anthropicRequest {
+	req := anthropicRequest{Model: m.model, MaxTokens: m.maxTokens}
+	if opts.Model != "" {
+		req.Model = strings.TrimPrefix(opts.Model, "anthropic/")
+	}
+	if opts.MaxTokens > 0 {
+		req.MaxTokens = opts.MaxTokens
+	}
+
+	var system []string
+	for _, msg := range messages {
+		var text []string
+		for _, p := range msg.Parts {
+			if t, ok := p.(llms.TextContent); ok {
+				text = append(text, t.Text)
+			}
+		}
+		content := strings.Join(text, "\n")
+		switch msg.Role {
+		case llms.ChatMessageTypeSystem:
+			system = append(system, content)
+			continue
+		case llms.ChatMessageTypeAI:
+			req.Messages = append(req.Messages, anthropicMessage{Role: "assistant", Content: []anthropicContent{{Type: "text", Text: content}}})
+		default:
+			req.Messages = append(req.Messages, anthropicMessage{Role: "user", Content: []anthropicContent{{Type: "text", Text: content}}})
+		}
+	}
+	req.System = strings.Join(system, "\n\n")
+
+	budget := m.thinkingBudget
+	if budget <= 0 {
+		budget = anthropicThinkingBudgets[reasoningEffortFromContext(ctx)]
+	}
+	if budget > 0 {
+		if req.MaxTokens <= budget {
+			req.MaxTokens = budget + m.maxTokens
+		}
+		req.Thinking = map[string]any{"type": "enabled", "budget_tokens": budget}
+	} else {
+		if opts.Temperature > 0 {
+			t := opts.Temperature
+			req.Temperature = &t
+		}
+		if opts.TopP > 0 {
+			p := opts.TopP
+			req.TopP = &p
+		}
+	}
+
+	for _, t := range opts.Tools {
+		if t.Function == nil {
+			continue
+		}
+		req.Tools = append(req.Tools, anthropicTool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: t.Function.Parameters,
+		})
+	}
+	if len(req.Tools) > 0 {
+		req.ToolChoice = map[string]string{"type": "auto"}
+		if tc, ok := opts.ToolChoice.(llms.ToolChoice); ok && tc.Function != nil && budget <= 0 {
+			req.ToolChoice = map[string]string{"type": "tool", "name": tc.Function.Name}
+		}
+	}
+	return req
+}
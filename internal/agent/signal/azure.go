@@ -0,0 +1,63 @@
+package signal
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"ai_quant/internal/config"
+	"ai_quant/internal/trace"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// newAzureModel 创建 Azure OpenAI 客户端。
+// Azure 按部署名路由模型，请求地址形如
+// {endpoint}/openai/deployments/{deployment}/chat/completions?api-version={version}；
+// 支持 api-key 认证和 Azure AD (Entra ID) Bearer Token 认证。
+func newAzureModel(cfg config.Config) (llms.Model, string, error) {
+	endpoint := strings.TrimRight(strings.TrimSpace(cfg.AzureOpenAIEndpoint), "/")
+	if endpoint == "" {
+		return nil, "", fmt.Errorf("AZURE_OPENAI_ENDPOINT 未配置")
+	}
+	deployment := strings.TrimSpace(cfg.AzureOpenAIDeployment)
+	if deployment == "" {
+		return nil, "", fmt.Errorf("AZURE_OPENAI_DEPLOYMENT 未配置")
+	}
+
+	apiType := openai.APITypeAzure
+	var token string
+	switch strings.ToLower(strings.TrimSpace(cfg.AzureOpenAIAuth)) {
+	case "aad", "azure_ad", "entra":
+		apiType = openai.APITypeAzureAD
+		token = cfg.AzureOpenAIADToken
+		if token == "" {
+			return nil, "", fmt.Errorf("AZURE_OPENAI_AUTH=aad 但 AZURE_OPENAI_AD_TOKEN 未配置")
+		}
+	default:
+		token = cfg.AzureOpenAIAPIKey
+		if token == "" {
+			token = cfg.OpenAIAPIKey
+		}
+		if token == "" {
+			return nil, "", fmt.Errorf("AZURE_OPENAI_API_KEY 未配置")
+		}
+	}
+
+	llm, err := openai.New(
+		openai.WithAPIType(apiType),
+		openai.WithBaseURL(endpoint),
+		openai.WithAPIVersion(cfg.AzureOpenAIAPIVersion),
+		openai.WithModel(deployment),
+		openai.WithToken(token),
+		openai.WithHTTPClient(&reasoningDoer{client: trace.NewClient(0)}),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	log.Printf("[信号] Azure OpenAI 已配置 endpoint=%s 部署=%s api-version=%s 认证=%s",
+		endpoint, deployment, cfg.AzureOpenAIAPIVersion, apiType)
+	return llm, "azure/" + deployment, nil
+}
@@ -0,0 +1,117 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ai_quant/internal/config"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/llmcost"
+	"ai_quant/internal/tradingday"
+
+	"github.com/google/uuid"
+)
+
+// SpendFunc 查询某时间点之后已花费的大模型成本（USD），由 orchestrator 注入
+type SpendFunc func(ctx context.Context, since time.Time) (float64, error)
+
+// budget 大模型花费上限：单轮 / 每日 / 每月，任一为 0 表示不限制
+type budget struct {
+	prices              *llmcost.Table
+	estCompletionTokens int
+	maxPerCycle         float64
+	maxDaily            float64
+	maxMonthly          float64
+	spentSince          SpendFunc
+}
+
+func newBudget(cfg config.Config) budget {
+	def := llmcost.Price{PromptPerM: cfg.LLMPromptPricePerM, CompletionPerM: cfg.LLMCompletionPricePerM}
+	prices, err := llmcost.ParseTable(def, cfg.LLMModelPrices)
+	if err != nil {
+		log.Printf("[信号] ⚠ LLM_MODEL_PRICES 配置错误: %v，全部模型按默认价格计算", err)
+		prices, _ = llmcost.ParseTable(def, "")
+	}
+	return budget{
+		prices:              prices,
+		estCompletionTokens: cfg.LLMEstCompletionTokens,
+		maxPerCycle:         cfg.LLMMaxCostPerCycleUSD,
+		maxDaily:            cfg.LLMMaxDailyCostUSD,
+		maxMonthly:          cfg.LLMMaxMonthlyCostUSD,
+	}
+}
+
+// BudgetLimits 返回大模型花费上限（单轮 / 每日 / 每月，0 表示不限制），用于成本汇总展示
+func BudgetLimits(agent Agent) (perCycle, daily, monthly float64) {
+	if lca, ok := agent.(*LangChainAgent); ok {
+		return lca.budget.maxPerCycle, lca.budget.maxDaily, lca.budget.maxMonthly
+	}
+	return 0, 0, 0
+}
+
+// SetSpendFunc 设置已花费成本查询回调（由 orchestrator 在启动时注入）
+func SetSpendFunc(agent Agent, fn SpendFunc) {
+	if lca, ok := agent.(*LangChainAgent); ok {
+		lca.budget.spentSince = fn
+	}
+}
+
+// cost 按模型价格和 token 数计算成本（USD）
+func (b budget) cost(model string, promptTokens, completionTokens int) float64 {
+	return b.prices.Cost(model, promptTokens, completionTokens)
+}
+
+// estimate 调用前粗略估算本轮成本：提示词按 4 字节/token 估算，回复取配置的预估 token 数
+func (b budget) estimate(model string, promptBytes int) float64 {
+	return b.cost(model, promptBytes/4+1, b.estCompletionTokens)
+}
+
+// check 检查本轮调用是否超出预算，超出时返回原因
+func (b budget) check(ctx context.Context, estimated float64) (string, bool) {
+	if b.maxPerCycle > 0 && estimated > b.maxPerCycle {
+		return fmt.Sprintf("本轮预估成本 $%.4f 超过单轮上限 $%.4f", estimated, b.maxPerCycle), false
+	}
+	if b.spentSince == nil {
+		return "", true
+	}
+
+	now := time.Now()
+	if b.maxDaily > 0 {
+		spent, err := b.spentSince(ctx, tradingday.Start(now))
+		if err != nil {
+			// 查询失败时不放行，避免在无法核算的情况下持续花费
+			return fmt.Sprintf("查询今日花费失败: %v", err), false
+		}
+		if spent+estimated > b.maxDaily {
+			return fmt.Sprintf("今日已花费 $%.4f，加上本轮预估 $%.4f 将超过每日上限 $%.2f", spent, estimated, b.maxDaily), false
+		}
+	}
+	if b.maxMonthly > 0 {
+		spent, err := b.spentSince(ctx, tradingday.MonthStart(now))
+		if err != nil {
+			return fmt.Sprintf("查询本月花费失败: %v", err), false
+		}
+		if spent+estimated > b.maxMonthly {
+			return fmt.Sprintf("本月已花费 $%.4f，加上本轮预估 $%.4f 将超过每月上限 $%.2f", spent, estimated, b.maxMonthly), false
+		}
+	}
+	return "", true
+}
+
+// budgetExhausted 预算耗尽时跳过大模型，输出明确的 hold 信号
+func (a *LangChainAgent) budgetExhausted(input Input, reason string) (domain.Signal, error) {
+	log.Printf("[信号] ⛔ 大模型预算耗尽，跳过本轮调用: %s", reason)
+	return domain.Signal{
+		ID:         uuid.NewString(),
+		CycleID:    input.CycleID,
+		Pair:       input.Pair,
+		Side:       domain.SideNone,
+		Confidence: 0,
+		Reason:     "预算耗尽(budget exhausted)，跳过大模型: " + trimReason(reason),
+		ModelName:  "budget_exhausted",
+		TTLSeconds: 60,
+		CreatedAt:  time.Now().UTC(),
+	}, nil
+}
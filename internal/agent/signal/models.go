@@ -0,0 +1,153 @@
+package signal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai_quant/internal/config"
+	"ai_quant/internal/trace"
+)
+
+// ModelInfo 可用模型信息（价格单位：USD / 百万 token）
+type ModelInfo struct {
+	ID                  string  `json:"id"`
+	Name                string  `json:"name,omitempty"`
+	ContextLength       int     `json:"context_length,omitempty"`
+	PromptPricePerM     float64 `json:"prompt_price_per_m,omitempty"`
+	CompletionPricePerM float64 `json:"completion_price_per_m,omitempty"`
+}
+
+// ModelCatalog 从当前 LLM 后端拉取可用模型列表（带缓存）。
+// OpenRouter 与大多数 OpenAI 兼容接口都提供 GET {base}/models。
+type ModelCatalog struct {
+	http     *http.Client
+	baseURL  string
+	token    string
+	backend  string
+	current  string
+	cacheTTL time.Duration
+
+	mu        sync.Mutex
+	cached    []ModelInfo
+	fetchedAt time.Time
+}
+
+// NewModelCatalog 根据配置创建模型目录
+func NewModelCatalog(cfg config.Config) *ModelCatalog {
+	backend := strings.ToLower(strings.TrimSpace(cfg.LLMBackend))
+	baseURL := cfg.OpenAIBaseURL
+	token := cfg.OpenAIAPIKey
+	switch backend {
+	case "openrouter":
+		baseURL = cfg.OpenRouterBaseURL
+		if cfg.OpenRouterAPIKey != "" {
+			token = cfg.OpenRouterAPIKey
+		}
+	case "azure":
+		baseURL = ""
+	default:
+		if strings.TrimSpace(baseURL) == "" {
+			baseURL = "https://api.openai.com/v1"
+		}
+	}
+
+	return &ModelCatalog{
+		http:     trace.NewClient(15 * time.Second),
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		token:    token,
+		backend:  backend,
+		current:  cfg.OpenAIModel,
+		cacheTTL: 10 * time.Minute,
+	}
+}
+
+// Backend 返回当前 LLM 后端名称
+func (c *ModelCatalog) Backend() string {
+	return c.backend
+}
+
+// CurrentModel 返回配置中的默认模型
+func (c *ModelCatalog) CurrentModel() string {
+	return c.current
+}
+
+// List 返回可用模型列表，refresh=true 时忽略缓存
+func (c *ModelCatalog) List(ctx context.Context, refresh bool) ([]ModelInfo, error) {
+	if c.baseURL == "" {
+		return nil, fmt.Errorf("后端 %s 不支持模型列表查询", c.backend)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !refresh && c.cached != nil && time.Since(c.fetchedAt) < c.cacheTTL {
+		return c.cached, nil
+	}
+
+	models, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.cached = models
+	c.fetchedAt = time.Now()
+	return models, nil
+}
+
+func (c *ModelCatalog) fetch(ctx context.Context) ([]ModelInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求模型列表失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("模型列表 HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data []struct {
+			ID            string `json:"id"`
+			Name          string `json:"name"`
+			ContextLength int    `json:"context_length"`
+			Pricing       struct {
+				Prompt     string `json:"prompt"`
+				Completion string `json:"completion"`
+			} `json:"pricing"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析模型列表失败: %w", err)
+	}
+
+	models := make([]ModelInfo, 0, len(result.Data))
+	for _, m := range result.Data {
+		// OpenRouter 价格为 USD / token 的字符串
+		prompt, _ := strconv.ParseFloat(m.Pricing.Prompt, 64)
+		completion, _ := strconv.ParseFloat(m.Pricing.Completion, 64)
+		models = append(models, ModelInfo{
+			ID:                  m.ID,
+			Name:                m.Name,
+			ContextLength:       m.ContextLength,
+			PromptPricePerM:     prompt * 1e6,
+			CompletionPricePerM: completion * 1e6,
+		})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
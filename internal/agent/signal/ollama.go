@@ -0,0 +1,250 @@
+package signal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ai_quant/internal/config"
+	"ai_quant/internal/trace"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// newOllamaModel 创建本地模型客户端（LLM_BACKEND=ollama），不需要 OpenAI 凭证：
+// OLLAMA_API=openai 走 /v1 OpenAI 兼容接口（Ollama、llama.cpp server 均支持），native 走 Ollama /api/chat
+func newOllamaModel(cfg config.Config) (llms.Model, string, error) {
+	model := strings.TrimSpace(cfg.OllamaModel)
+	if model == "" {
+		return nil, "", fmt.Errorf("OLLAMA_MODEL 未配置")
+	}
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.OllamaBaseURL), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	timeout := time.Duration(cfg.OllamaTimeoutSec) * time.Second
+	modelName := "ollama/" + model
+
+	api := strings.ToLower(strings.TrimSpace(cfg.OllamaAPI))
+	log.Printf("[信号] 本地模型已配置 地址=%s 模型=%s 接口=%s 超时=%s 上下文=%d 提示词上限=%d字符",
+		baseURL, model, api, timeout, cfg.OllamaNumCtx, cfg.OllamaMaxPromptChars)
+	switch api {
+	case "native":
+		return &ollamaModel{
+			client:  trace.NewClient(timeout),
+			baseURL: baseURL,
+			model:   model,
+			numCtx:  cfg.OllamaNumCtx,
+		}, modelName, nil
+	case "", "openai":
+		// 本地服务不校验 token，但 OpenAI 客户端要求非空
+		llm, err := openai.New(
+			openai.WithToken("ollama"),
+			openai.WithModel(model),
+			openai.WithBaseURL(strings.TrimSuffix(baseURL, "/v1")+"/v1"),
+			openai.WithHTTPClient(&reasoningDoer{client: trace.NewClient(timeout)}),
+		)
+		if err != nil {
+			return nil, "", err
+		}
+		return llm, modelName, nil
+	default:
+		return nil, "", fmt.Errorf("OLLAMA_API 只支持 openai / native，当前为 %q", cfg.OllamaAPI)
+	}
+}
+
+// ollamaModel Ollama 原生 /api/chat 客户端，实现 llms.Model
+type ollamaModel struct {
+	client  *http.Client
+	baseURL string
+	model   string
+	numCtx  int // 上下文窗口（num_ctx），0 = 使用模型默认值
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+}
+
+type ollamaTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		Parameters  any    `json:"parameters"`
+	} `json:"function"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   string          `json:"format,omitempty"`
+	Tools    []ollamaTool    `json:"tools,omitempty"`
+	Options  map[string]any  `json:"options,omitempty"`
+}
+
+type ollamaResponse struct {
+	Message         ollamaMessage `json:"message"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+// Call 单轮文本调用
+func (m *ollamaModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	resp, err := m.GenerateContent(ctx, []llms.MessageContent{{
+		Role:  llms.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{llms.TextContent{Text: prompt}},
+	}}, options...)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("ollama 返回空结果")
+	}
+	return resp.Choices[0].Content, nil
+}
+
+// GenerateContent 调用 /api/chat（非流式）；工具调用转换为 ToolCalls，token 用量写入 GenerationInfo（与 OpenAI 路径一致）
+func (m *ollamaModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+	body, err := json.Marshal(m.buildRequest(messages, opts))
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var out ollamaResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("ollama %d: 解析响应失败: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || out.Error != "" {
+		msg := out.Error
+		if msg == "" {
+			msg = string(raw)
+		}
+		return nil, fmt.Errorf("ollama %d: %s", resp.StatusCode, msg)
+	}
+
+	choice := &llms.ContentChoice{
+		Content:          out.Message.Content,
+		ReasoningContent: out.Message.Thinking,
+		StopReason:       out.DoneReason,
+		GenerationInfo: map[string]any{
+			"PromptTokens":     out.PromptEvalCount,
+			"CompletionTokens": out.EvalCount,
+			"TotalTokens":      out.PromptEvalCount + out.EvalCount,
+		},
+	}
+	for i, tc := range out.Message.ToolCalls {
+		choice.ToolCalls = append(choice.ToolCalls, llms.ToolCall{
+			ID:           fmt.Sprintf("call_%d", i),
+			Type:         "function",
+			FunctionCall: &llms.FunctionCall{Name: tc.Function.Name, Arguments: string(tc.Function.Arguments)},
+		})
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{choice}}, nil
+}
+
+// buildRequest 把 langchaingo 消息与调用参数转换为 /api/chat 请求；生成参数放入 options，
+// Ollama 不支持强制指定工具，tool_choice 忽略
+func (m *ollamaModel) buildRequest(messages []llms.MessageContent, opts llms.CallOptions) ollamaRequest {
+	req := ollamaRequest{Model: m.model, Options: map[string]any{}}
+	if opts.Model != "" {
+		req.Model = strings.TrimPrefix(opts.Model, "ollama/")
+	}
+	for _, msg := range messages {
+		var text []string
+		for _, p := range msg.Parts {
+			if t, ok := p.(llms.TextContent); ok {
+				text = append(text, t.Text)
+			}
+		}
+		role := "user"
+		switch msg.Role {
+		case llms.ChatMessageTypeSystem:
+			role = "system"
+		case llms.ChatMessageTypeAI:
+			role = "assistant"
+		}
+		req.Messages = append(req.Messages, ollamaMessage{Role: role, Content: strings.Join(text, "\n")})
+	}
+
+	if opts.JSONMode {
+		req.Format = "json"
+	}
+	for _, t := range opts.Tools {
+		if t.Function == nil {
+			continue
+		}
+		tool := ollamaTool{Type: "function"}
+		tool.Function.Name = t.Function.Name
+		tool.Function.Description = t.Function.Description
+		tool.Function.Parameters = t.Function.Parameters
+		req.Tools = append(req.Tools, tool)
+	}
+	if m.numCtx > 0 {
+		req.Options["num_ctx"] = m.numCtx
+	}
+	if opts.MaxTokens > 0 {
+		req.Options["num_predict"] = opts.MaxTokens
+	}
+	if opts.Temperature > 0 {
+		req.Options["temperature"] = opts.Temperature
+	}
+	if opts.TopP > 0 {
+		req.Options["top_p"] = opts.TopP
+	}
+	return req
+}
+
+// truncatePrompt 提示词超过 maxChars 个字符时保留开头与结尾（行情数据在前、输出要求在后），截掉中间部分
+func truncatePrompt(prompt string, maxChars int) string {
+	runes := []rune(prompt)
+	if maxChars <= 0 || len(runes) <= maxChars {
+		return prompt
+	}
+	const marker = "\n...(内容过长，已截断)...\n"
+	keep := maxChars - len([]rune(marker))
+	if keep <= 0 {
+		return string(runes[:maxChars])
+	}
+	head := keep * 2 / 3
+	tail := keep - head
+	return string(runes[:head]) + marker + string(runes[len(runes)-tail:])
+}
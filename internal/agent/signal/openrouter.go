@@ -0,0 +1,126 @@
+package signal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ai_quant/internal/config"
+	"ai_quant/internal/trace"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// ProviderPreferences OpenRouter 上游提供商路由偏好（对应请求体中的 provider 字段）
+// 参考: https://openrouter.ai/docs/provider-routing
+type ProviderPreferences struct {
+	Order          []string `json:"order,omitempty"`           // 按顺序尝试的提供商
+	AllowFallbacks *bool    `json:"allow_fallbacks,omitempty"` // 是否允许回退到列表外的提供商
+	Ignore         []string `json:"ignore,omitempty"`          // 排除的提供商
+	Sort           string   `json:"sort,omitempty"`            // "price" / "throughput" / "latency"
+}
+
+func (p ProviderPreferences) isZero() bool {
+	return len(p.Order) == 0 && p.AllowFallbacks == nil && len(p.Ignore) == 0 && p.Sort == ""
+}
+
+type providerPrefsKey struct{}
+
+// WithProviderPreferences 在 ctx 中携带本次请求的提供商偏好，覆盖全局配置
+func WithProviderPreferences(ctx context.Context, prefs ProviderPreferences) context.Context {
+	return context.WithValue(ctx, providerPrefsKey{}, prefs)
+}
+
+func providerPrefsFromContext(ctx context.Context) (ProviderPreferences, bool) {
+	prefs, ok := ctx.Value(providerPrefsKey{}).(ProviderPreferences)
+	return prefs, ok
+}
+
+// openRouterDoer 包装 HTTP 客户端：为聊天请求注入 provider 偏好、reasoning 参数与 OpenRouter 归属请求头
+type openRouterDoer struct {
+	client   *http.Client
+	defaults ProviderPreferences
+	appName  string
+	siteURL  string
+}
+
+func (d *openRouterDoer) Do(req *http.Request) (*http.Response, error) {
+	if d.appName != "" {
+		req.Header.Set("X-Title", d.appName)
+	}
+	if d.siteURL != "" {
+		req.Header.Set("HTTP-Referer", d.siteURL)
+	}
+
+	prefs := d.defaults
+	if p, ok := providerPrefsFromContext(req.Context()); ok {
+		prefs = p
+	}
+	effort := reasoningEffortFromContext(req.Context())
+	if !prefs.isZero() || effort != "" {
+		err := patchJSONBody(req, func(payload map[string]json.RawMessage) {
+			if !prefs.isZero() {
+				payload["provider"], _ = json.Marshal(prefs)
+			}
+			if effort != "" {
+				// OpenRouter 统一的 reasoning 参数，由其转换为各上游模型的格式
+				payload["reasoning"], _ = json.Marshal(map[string]string{"effort": effort})
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d.client.Do(req)
+}
+
+// newOpenRouterModel 创建 OpenRouter 客户端（OpenAI 兼容接口 + provider 路由偏好）
+func newOpenRouterModel(cfg config.Config) (llms.Model, string, error) {
+	token := cfg.OpenRouterAPIKey
+	if token == "" {
+		token = cfg.OpenAIAPIKey
+	}
+	if token == "" {
+		return nil, "", fmt.Errorf("OPENROUTER_API_KEY 未配置")
+	}
+
+	doer := &openRouterDoer{
+		client:   trace.NewClient(5 * time.Minute),
+		defaults: defaultProviderPreferences(cfg),
+		appName:  cfg.OpenRouterAppName,
+		siteURL:  cfg.OpenRouterSiteURL,
+	}
+
+	llm, err := openai.New(
+		openai.WithToken(token),
+		openai.WithModel(cfg.OpenAIModel),
+		openai.WithBaseURL(strings.TrimRight(cfg.OpenRouterBaseURL, "/")),
+		openai.WithHTTPClient(doer),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	log.Printf("[信号] OpenRouter 已配置 模型=%s 提供商优先级=%v 允许回退=%v",
+		cfg.OpenAIModel, doer.defaults.Order, cfg.OpenRouterAllowFallbacks)
+	return llm, cfg.OpenAIModel, nil
+}
+
+func defaultProviderPreferences(cfg config.Config) ProviderPreferences {
+	var prefs ProviderPreferences
+	for _, p := range strings.Split(cfg.OpenRouterProviderOrder, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			prefs.Order = append(prefs.Order, p)
+		}
+	}
+	if !cfg.OpenRouterAllowFallbacks {
+		allow := false
+		prefs.AllowFallbacks = &allow
+	}
+	return prefs
+}
@@ -0,0 +1,228 @@
+package signal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ai_quant/internal/config"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// GenParams 大模型生成参数，零值表示不传该参数（使用服务端默认值）
+type GenParams struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	MaxTokens       int      `json:"max_tokens,omitempty"`
+	ReasoningEffort string   `json:"reasoning_effort,omitempty"` // low / medium / high，思考型模型使用
+}
+
+// merge 用 o 中设置了的字段覆盖 p
+func (p GenParams) merge(o GenParams) GenParams {
+	if o.Temperature != nil {
+		p.Temperature = o.Temperature
+	}
+	if o.MaxTokens > 0 {
+		p.MaxTokens = o.MaxTokens
+	}
+	if o.ReasoningEffort != "" {
+		p.ReasoningEffort = o.ReasoningEffort
+	}
+	return p
+}
+
+// callOptions 转为 langchaingo 调用参数；reasoning effort 不在其中，由 withReasoningEffort 写入请求体
+func (p GenParams) callOptions() []llms.CallOption {
+	var opts []llms.CallOption
+	if p.Temperature != nil {
+		opts = append(opts, llms.WithTemperature(*p.Temperature))
+	}
+	if p.MaxTokens > 0 {
+		opts = append(opts, llms.WithMaxTokens(p.MaxTokens))
+	}
+	return opts
+}
+
+func (p GenParams) String() string {
+	var parts []string
+	if p.Temperature != nil {
+		parts = append(parts, fmt.Sprintf("temperature=%.2f", *p.Temperature))
+	}
+	if p.MaxTokens > 0 {
+		parts = append(parts, fmt.Sprintf("max_tokens=%d", p.MaxTokens))
+	}
+	if p.ReasoningEffort != "" {
+		parts = append(parts, "reasoning="+p.ReasoningEffort)
+	}
+	if len(parts) == 0 {
+		return "默认"
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParamTable 按模型的生成参数：模型配置覆盖默认参数中对应的字段，
+// 模型名先精确匹配，其次匹配去掉 "provider/" 前缀的名称
+type ParamTable struct {
+	Default GenParams
+	models  map[string]GenParams
+}
+
+// newParamTable 从配置读取默认参数与按模型参数
+func newParamTable(cfg config.Config) (*ParamTable, error) {
+	def, err := parseGenParams(map[string]string{
+		"temperature": cfg.LLMTemperature,
+		"max_tokens":  strconv.Itoa(cfg.LLMMaxTokens),
+		"reasoning":   cfg.LLMReasoningEffort,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ParseParamTable(def, cfg.LLMModelParams)
+}
+
+// ParseParamTable 解析 "openai/o3-mini=max_tokens:4000;reasoning:high,deepseek/deepseek-chat=temperature:0.2" 格式的按模型参数
+func ParseParamTable(def GenParams, spec string) (*ParamTable, error) {
+	t := &ParamTable{Default: def, models: make(map[string]GenParams)}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		model, body, ok := strings.Cut(item, "=")
+		model = strings.ToLower(strings.TrimSpace(model))
+		if !ok || model == "" {
+			return nil, fmt.Errorf("模型参数配置格式错误: %q（应为 gpt-4o=temperature:0.2;max_tokens:1200）", item)
+		}
+		kv := make(map[string]string)
+		for _, field := range strings.Split(body, ";") {
+			if field = strings.TrimSpace(field); field == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(field, ":")
+			if !ok {
+				return nil, fmt.Errorf("模型参数配置格式错误: %q（参数应为 key:value）", item)
+			}
+			kv[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+		}
+		p, err := parseGenParams(kv)
+		if err != nil {
+			return nil, fmt.Errorf("模型参数配置 %q: %w", item, err)
+		}
+		t.models[model] = p
+	}
+	return t, nil
+}
+
+func parseGenParams(kv map[string]string) (GenParams, error) {
+	var p GenParams
+	for k, v := range kv {
+		if v == "" || (k == "max_tokens" && v == "0") {
+			continue
+		}
+		switch k {
+		case "temperature":
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < 0 || f > 2 {
+				return p, fmt.Errorf("temperature %q 无效（0-2）", v)
+			}
+			p.Temperature = &f
+		case "max_tokens":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return p, fmt.Errorf("max_tokens %q 无效", v)
+			}
+			p.MaxTokens = n
+		case "reasoning", "reasoning_effort":
+			switch v = strings.ToLower(v); v {
+			case "low", "medium", "high":
+				p.ReasoningEffort = v
+			default:
+				return p, fmt.Errorf("reasoning %q 无效（low / medium / high）", v)
+			}
+		default:
+			return p, fmt.Errorf("未知参数 %q（支持 temperature / max_tokens / reasoning）", k)
+		}
+	}
+	return p, nil
+}
+
+// For 返回模型最终使用的生成参数
+func (t *ParamTable) For(model string) GenParams {
+	m := strings.ToLower(strings.TrimSpace(model))
+	if p, ok := t.models[m]; ok {
+		return t.Default.merge(p)
+	}
+	if _, short, ok := strings.Cut(m, "/"); ok {
+		if p, ok := t.models[short]; ok {
+			return t.Default.merge(p)
+		}
+	}
+	return t.Default
+}
+
+type reasoningEffortKey struct{}
+
+// withReasoningEffort 在 ctx 中携带本次请求的 reasoning effort，由 HTTP 客户端写入请求体
+func withReasoningEffort(ctx context.Context, effort string) context.Context {
+	if effort == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, reasoningEffortKey{}, effort)
+}
+
+func reasoningEffortFromContext(ctx context.Context) string {
+	effort, _ := ctx.Value(reasoningEffortKey{}).(string)
+	return effort
+}
+
+// reasoningDoer 包装 HTTP 客户端：为 OpenAI / Azure 聊天请求注入 reasoning_effort；
+// 思考型模型不接受 max_tokens，同时改为 max_completion_tokens
+type reasoningDoer struct {
+	client *http.Client
+}
+
+func (d *reasoningDoer) Do(req *http.Request) (*http.Response, error) {
+	if effort := reasoningEffortFromContext(req.Context()); effort != "" {
+		err := patchJSONBody(req, func(payload map[string]json.RawMessage) {
+			payload["reasoning_effort"], _ = json.Marshal(effort)
+			if v, ok := payload["max_tokens"]; ok {
+				payload["max_completion_tokens"] = v
+				delete(payload, "max_tokens")
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d.client.Do(req)
+}
+
+// patchJSONBody 读取 POST 请求的 JSON 请求体交给 patch 修改后写回；非 JSON 请求体原样保留
+func patchJSONBody(req *http.Request, patch func(payload map[string]json.RawMessage)) error {
+	if req.Method != http.MethodPost || req.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	var payload map[string]json.RawMessage
+	if json.Unmarshal(body, &payload) == nil {
+		patch(payload)
+		if patched, err := json.Marshal(payload); err == nil {
+			body = patched
+		}
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package signal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"ai_quant/internal/domain"
+	"ai_quant/internal/trace"
+
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// PairSnapshot 组合分配提示词中单个交易对的轻量快照（不含 K 线 / 指标）
+type PairSnapshot struct {
+	Pair         string
+	Price        float64
+	Change24h    float64 // 24h 涨跌幅（%）
+	HoldingQty   float64
+	AvgPrice     float64
+	HoldingValue float64 // 按当前价估算的持仓价值（USDT）
+}
+
+// AllocationInput 组合分配调用的输入
+type AllocationInput struct {
+	Pairs           []PairSnapshot
+	CashUSDT        float64 // 可用 USDT
+	MaxExposureUSDT float64 // 当前风险预设的最大总敞口
+}
+
+// Allocator 可选能力：一次调用同时看所有交易对，给出排序后的分配计划
+type Allocator interface {
+	Allocate(ctx context.Context, input AllocationInput) (domain.AllocationPlan, error)
+}
+
+const allocationSystemPrompt = `You are a crypto portfolio allocator. You see a light snapshot of every tradable pair plus current holdings and cash.
+Rank the pairs from most to least attractive for the next cycle and give each one an action and a target weight.
+
+Rules:
+- action is one of "buy" (open or add), "hold" (keep as is), "reduce" (trim or close), "avoid" (do not open).
+- weight is the share of the maximum total exposure this pair may use, between 0 and 1; the weights of all pairs must sum to at most 1.
+- Pairs with action "avoid" or "reduce" must have weight 0 unless they are already held and you want to keep part of the position.
+- Prefer concentration in the strongest 1-3 pairs over spreading evenly. Keep reasons short (one sentence).
+
+Respond with JSON only:
+{"summary": "<one sentence>", "allocations": [{"pair": "BTC/USDT", "rank": 1, "action": "buy", "weight": 0.4, "reason": "..."}]}`
+
+type allocationResponse struct {
+	Summary     string                  `json:"summary"`
+	Allocations []domain.PairAllocation `json:"allocations"`
+}
+
+// Allocate 组合级大模型调用，预算不足或调用失败时返回错误，由调用方回退为逐个交易对独立决策
+func (a *LangChainAgent) Allocate(ctx context.Context, input AllocationInput) (domain.AllocationPlan, error) {
+	userPrompt := buildAllocationPrompt(input)
+	modelName := a.modelName
+
+	estimated := a.budget.estimate(modelName, len(allocationSystemPrompt)+len(userPrompt))
+	if reason, ok := a.budget.check(ctx, estimated); !ok {
+		return domain.AllocationPlan{}, fmt.Errorf("大模型预算不足: %s", reason)
+	}
+
+	messages := []llms.MessageContent{
+		{
+			Role:  llms.ChatMessageTypeSystem,
+			Parts: []llms.ContentPart{llms.TextContent{Text: allocationSystemPrompt}},
+		},
+		{
+			Role:  llms.ChatMessageTypeHuman,
+			Parts: []llms.ContentPart{llms.TextContent{Text: userPrompt}},
+		},
+	}
+
+	params := a.params.For(modelName)
+	ctx = withReasoningEffort(ctx, params.ReasoningEffort)
+
+	log.Printf("[组合] 正在调用大模型 %s 生成分配计划（%d 个交易对）... %s", modelName, len(input.Pairs), trace.Fields(ctx))
+	t0 := time.Now()
+	resp, err := a.model.GenerateContent(ctx, messages, params.callOptions()...)
+	if err != nil {
+		return domain.AllocationPlan{}, fmt.Errorf("大模型调用失败: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return domain.AllocationPlan{}, fmt.Errorf("大模型返回空结果")
+	}
+	choice := resp.Choices[0]
+	promptTokens, completionTokens, _ := extractTokenUsage(choice.GenerationInfo)
+	log.Printf("[组合] ✔ 大模型响应成功 (耗时%s)，Token: prompt=%d completion=%d", time.Since(t0), promptTokens, completionTokens)
+
+	plan := domain.AllocationPlan{
+		ID:               uuid.NewString(),
+		ModelName:        modelName,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		CostUSD:          a.budget.cost(modelName, promptTokens, completionTokens),
+		CreatedAt:        time.Now().UTC(),
+	}
+	parsed, err := parseAllocationOutput(choice.Content)
+	if err != nil {
+		return plan, err // 调用已产生费用，计划仍带上成本
+	}
+	plan.Summary = trimReason(parsed.Summary)
+	plan.Allocations = parsed.Allocations
+	return plan, nil
+}
+
+// buildAllocationPrompt 渲染组合分配的用户提示词
+func buildAllocationPrompt(input AllocationInput) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Cash available: %.2f USDT\n", input.CashUSDT)
+	if input.MaxExposureUSDT > 0 {
+		fmt.Fprintf(&b, "Maximum total exposure: %.2f USDT\n", input.MaxExposureUSDT)
+	}
+	b.WriteString("\nPairs:\n")
+	for _, p := range input.Pairs {
+		fmt.Fprintf(&b, "- %s: price %.6g, 24h change %+.2f%%", p.Pair, p.Price, p.Change24h)
+		if p.HoldingQty > 0 {
+			fmt.Fprintf(&b, ", holding %.6g @ avg %.6g (value %.2f USDT)", p.HoldingQty, p.AvgPrice, p.HoldingValue)
+		} else {
+			b.WriteString(", no holding")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func parseAllocationOutput(raw string) (allocationResponse, error) {
+	var out allocationResponse
+	clean := strings.TrimSpace(raw)
+	if err := json.Unmarshal([]byte(clean), &out); err == nil {
+		return out, nil
+	}
+	match := regexp.MustCompile(`(?s)\{.*\}`).FindString(clean)
+	if match == "" {
+		return out, fmt.Errorf("大模型响应中未找到JSON对象")
+	}
+	if err := json.Unmarshal([]byte(match), &out); err != nil {
+		return out, fmt.Errorf("解析分配计划JSON失败: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,145 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai_quant/internal/config"
+	"ai_quant/internal/trace"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// maxRetryBackoff 单次退避等待的上限
+const maxRetryBackoff = 30 * time.Second
+
+// llmEndpoint 可调用的大模型端点：主模型或 LLM_FAILOVER 中的备用端点
+type llmEndpoint struct {
+	model llms.Model
+	name  string // 模型名（计费、日志与信号中的 model_name）
+}
+
+// retryPolicy 单个端点遇到可重试错误时的重试次数与初始退避
+type retryPolicy struct {
+	maxRetries int
+	backoff    time.Duration
+}
+
+func newRetryPolicy(cfg config.Config) retryPolicy {
+	p := retryPolicy{maxRetries: cfg.LLMRetryMax, backoff: time.Duration(cfg.LLMRetryBackoffMs) * time.Millisecond}
+	if p.maxRetries < 0 {
+		p.maxRetries = 0
+	}
+	if p.backoff <= 0 {
+		p.backoff = time.Second
+	}
+	return p
+}
+
+// newFailoverEndpoints 解析 "base_url|model|token,..."，token 省略时使用 OPENAI_API_KEY；均按 OpenAI 兼容接口调用
+func newFailoverEndpoints(spec, defaultToken string) ([]llmEndpoint, error) {
+	var eps []llmEndpoint
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "|", 3)
+		if len(parts) < 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("备用端点格式错误: %q（应为 base_url|model|token）", item)
+		}
+		baseURL, model := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		token := defaultToken
+		if len(parts) == 3 && strings.TrimSpace(parts[2]) != "" {
+			token = strings.TrimSpace(parts[2])
+		}
+		llm, err := openai.New(
+			openai.WithToken(token),
+			openai.WithModel(model),
+			openai.WithBaseURL(baseURL),
+			openai.WithHTTPClient(&reasoningDoer{client: trace.NewClient(0)}),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("创建备用端点 %s: %w", baseURL, err)
+		}
+		eps = append(eps, llmEndpoint{model: llm, name: model})
+	}
+	return eps, nil
+}
+
+// retryableLLMError 限流、超时、网络错误与 5xx 可以重试；认证失败、参数错误等直接放弃
+func retryableLLMError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, kw := range []string{
+		"429", "rate limit", "too many requests", "timeout", "timed out", "deadline exceeded",
+		"connection reset", "connection refused", "eof", "500", "502", "503", "504", "overloaded", "unavailable",
+	} {
+		if strings.Contains(msg, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// generate 依次尝试主模型与备用端点：每个端点的可重试错误按指数退避重试，仍失败则切换下一个端点。
+// override 只作用于主模型（如本次请求指定的模型），shared 对所有端点生效；
+// 返回实际使用的模型名，以及写入周期日志的重试 / 切换记录
+func (a *LangChainAgent) generate(ctx context.Context, messages []llms.MessageContent, modelName string, override, shared []llms.CallOption) (*llms.ContentResponse, string, []string, error) {
+	endpoints := append([]llmEndpoint{{model: a.model, name: modelName}}, a.failover...)
+	var (
+		attempts []string
+		lastErr  error
+	)
+	for i, ep := range endpoints {
+		opts := shared
+		if i == 0 {
+			opts = append(append([]llms.CallOption{}, override...), shared...)
+		} else {
+			msg := fmt.Sprintf("切换到备用端点 #%d 模型=%s", i, ep.name)
+			log.Printf("[信号] ⚠ %s", msg)
+			attempts = append(attempts, msg)
+		}
+
+		backoff := a.retry.backoff
+		for try := 0; ; try++ {
+			t0 := time.Now()
+			resp, err := ep.model.GenerateContent(ctx, messages, opts...)
+			if err == nil {
+				return resp, ep.name, attempts, nil
+			}
+			lastErr = err
+			if ctx.Err() != nil {
+				return nil, ep.name, attempts, err
+			}
+			if !retryableLLMError(err) || try >= a.retry.maxRetries {
+				msg := fmt.Sprintf("模型=%s 第%d次调用失败 (耗时%s): %v", ep.name, try+1, time.Since(t0).Round(time.Millisecond), err)
+				log.Printf("[信号] ✘ %s", msg)
+				attempts = append(attempts, msg)
+				break
+			}
+			msg := fmt.Sprintf("模型=%s 第%d次调用失败 (耗时%s): %v，%s 后重试", ep.name, try+1, time.Since(t0).Round(time.Millisecond), err, backoff)
+			log.Printf("[信号] ⚠ %s", msg)
+			attempts = append(attempts, msg)
+			select {
+			case <-ctx.Done():
+				return nil, ep.name, attempts, ctx.Err()
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > maxRetryBackoff {
+				backoff = maxRetryBackoff
+			}
+		}
+	}
+	return nil, modelName, attempts, lastErr
+}
@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +15,8 @@ import (
 	"ai_quant/internal/config"
 	"ai_quant/internal/domain"
 	"ai_quant/internal/market"
+	"ai_quant/internal/prompt"
+	"ai_quant/internal/trace"
 
 	"github.com/google/uuid"
 	"github.com/tmc/langchaingo/llms"
@@ -25,6 +27,20 @@ type Input struct {
 	CycleID  string
 	Pair     string
 	Snapshot domain.MarketSnapshot
+
+	// 可选：本次调用覆盖默认模型 / OpenRouter 提供商偏好
+	Model    string
+	Provider *ProviderPreferences
+
+	// 程序规则生成的风险提示（如持仓资金费率成本过高），写入提示词
+	Alerts []string
+
+	// 该交易对最近几个周期的决策与结果（按时间倒序），写入提示词避免反复开平
+	History []domain.DecisionMemory
+
+	// 可选：按交易对路由时本次周期使用的交易模式与杠杆，为空则使用全局设置
+	TradingMode string
+	Leverage    int
 }
 
 type Agent interface {
@@ -41,23 +57,35 @@ type llmResponse struct {
 	Thinking      string  `json:"thinking"`
 	Reason        string  `json:"reason"`
 	Justification string  `json:"justification"`
+	CloseFraction float64 `json:"close_fraction"`
 	TTLSeconds    int     `json:"ttl_seconds"`
 }
 
 // AccountDataFunc 获取真实账户数据的回调函数
 type AccountDataFunc func(ctx context.Context, pair string) (balance float64, positions []market.PositionData)
 
+// PerformanceFunc 获取账户近期收益率（%）与夏普比率的回调函数
+type PerformanceFunc func(ctx context.Context) (returnPct, sharpe float64)
+
 type LangChainAgent struct {
 	model          llms.Model
 	fallback       Agent
 	marketClient   *market.Client
-	systemPrompt   string
-	userTemplate   string
+	prompts        *prompt.Library // 系统 / 用户提示词模板（按交易对覆盖，可热加载）
 	startTime      time.Time
 	getAccountData AccountDataFunc // 由 orchestrator 注入
+	getPerformance PerformanceFunc // 由 orchestrator 注入
 	tradingMode    string          // "spot" 或 "futures"
 	leverage       int             // 杠杆倍数
 	modelName      string          // 模型名称
+	budget         budget          // 花费上限
+	params         *ParamTable     // 按模型的生成参数
+	degrade        market.DegradePolicy
+	referencePairs market.ReferencePairs
+	outputMode     string // tools / json / text
+	retry          retryPolicy
+	failover       []llmEndpoint // 主模型重试仍失败后按顺序切换的备用端点
+	maxPromptChars int           // 用户提示词字符上限（本地小上下文模型），0 = 不限制
 }
 
 func New(cfg config.Config) Agent {
@@ -67,6 +95,110 @@ func New(cfg config.Config) Agent {
 func NewWithAuth(cfg config.Config, authService *auth.Service) Agent {
 	fallback := &RuleBasedAgent{}
 
+	var (
+		llm       llms.Model
+		modelName string
+		err       error
+	)
+	switch strings.ToLower(strings.TrimSpace(cfg.LLMBackend)) {
+	case "azure":
+		llm, modelName, err = newAzureModel(cfg)
+	case "openrouter":
+		llm, modelName, err = newOpenRouterModel(cfg)
+	case "anthropic":
+		llm, modelName, err = newAnthropicModel(cfg, authService)
+	case "ollama":
+		llm, modelName, err = newOllamaModel(cfg)
+		// 本地模型不计费，LLM_MODEL_PRICES 中显式配置的价格仍然优先
+		cfg.LLMModelPrices = modelName + "=0/0," + cfg.LLMModelPrices
+	default:
+		if auth.Provider(strings.ToLower(strings.TrimSpace(cfg.LLMAuthProvider))) == auth.ProviderAnthropic {
+			llm, modelName, err = newAnthropicModel(cfg, authService)
+		} else {
+			llm, modelName, err = newOpenAIModel(cfg, authService)
+		}
+	}
+	if err != nil {
+		log.Printf("[信号] 初始化大模型客户端失败: %v，使用规则引擎", err)
+		return fallback
+	}
+
+	prompts, err := prompt.New(cfg.PromptDir)
+	if err != nil {
+		log.Printf("[信号] 加载提示词模板失败: %v，使用规则引擎", err)
+		return fallback
+	}
+	info := prompts.Info()
+	log.Printf("[信号] 大模型已就绪 模型=%s 系统提示词=%d字符 用户模板=%d字符 提示词版本=%s",
+		modelName, info.SystemLen, info.UserLen, info.Version)
+
+	mc := market.NewClient()
+	mc.CryptoPanicKey = cfg.CryptoPanicAPIKey
+	mc.LunarCrushKey = cfg.LunarCrushAPIKey
+	headers, err := market.ParseSourceHeaders(cfg.DataSourceHeaders)
+	if err != nil {
+		log.Printf("[信号] ⚠ DATA_SOURCE_HEADERS 配置错误: %v，不附加请求头", err)
+	}
+	mc.SetEndpoints(market.Endpoints{
+		CryptoPanic: cfg.CryptoPanicBaseURL,
+		LunarCrush:  cfg.LunarCrushBaseURL,
+		CoinGecko:   cfg.CoinGeckoBaseURL,
+		FearGreed:   cfg.FearGreedBaseURL,
+		Headers:     headers,
+	})
+
+	refs, err := market.ParseReferencePairs(cfg.ReferencePairs)
+	if err != nil {
+		log.Printf("[信号] ⚠ REFERENCE_PAIRS 配置错误: %v，使用默认 BTC/USDT", err)
+		refs = market.ReferencePairs{"*": {"BTC/USDT"}}
+	}
+
+	degrade, err := market.ParseDegradePolicy(cfg.DegradePolicy)
+	if err != nil {
+		log.Printf("[信号] ⚠ DEGRADE_POLICY 配置错误: %v，使用默认策略", err)
+		degrade = market.DefaultDegradePolicy()
+	}
+
+	params, err := newParamTable(cfg)
+	if err != nil {
+		log.Printf("[信号] ⚠ 大模型生成参数配置错误: %v，不传生成参数", err)
+		params, _ = ParseParamTable(GenParams{}, "")
+	}
+	log.Printf("[信号] 生成参数 模型=%s %s 输出模式=%s", modelName, params.For(modelName), NormalizeOutputMode(cfg.LLMOutputMode))
+
+	maxPromptChars := 0
+	if strings.EqualFold(strings.TrimSpace(cfg.LLMBackend), "ollama") {
+		maxPromptChars = cfg.OllamaMaxPromptChars
+	}
+
+	failover, err := newFailoverEndpoints(cfg.LLMFailover, cfg.OpenAIAPIKey)
+	if err != nil {
+		log.Printf("[信号] ⚠ LLM_FAILOVER 配置错误: %v，不启用备用端点", err)
+	}
+	retry := newRetryPolicy(cfg)
+	log.Printf("[信号] 调用重试 最多%d次 初始退避=%s 备用端点=%d个", retry.maxRetries, retry.backoff, len(failover))
+
+	return &LangChainAgent{
+		model:        llm,
+		params:       params,
+		fallback:     fallback,
+		marketClient: mc,
+		prompts:      prompts,
+		startTime:    time.Now(),
+		modelName:    modelName,
+		budget:       newBudget(cfg),
+		degrade:      degrade,
+
+		referencePairs: refs,
+		outputMode:     NormalizeOutputMode(cfg.LLMOutputMode),
+		retry:          retry,
+		failover:       failover,
+		maxPromptChars: maxPromptChars,
+	}
+}
+
+// newOpenAIModel 创建 OpenAI（及兼容接口）客户端，认证由 LLMAuthManager 决定
+func newOpenAIModel(cfg config.Config, authService *auth.Service) (llms.Model, string, error) {
 	// 创建 LLM 认证管理器
 	authMode := auth.AuthMode(cfg.LLMAuthMode)
 	provider := auth.Provider(cfg.LLMAuthProvider)
@@ -75,8 +207,7 @@ func NewWithAuth(cfg config.Config, authService *auth.Service) Agent {
 	// 获取认证 token
 	token, err := authManager.GetToken()
 	if err != nil {
-		log.Printf("[信号] 获取认证失败: %v，使用规则引擎", err)
-		return fallback
+		return nil, "", fmt.Errorf("获取认证失败: %w", err)
 	}
 
 	// 显示认证状态
@@ -87,6 +218,7 @@ func NewWithAuth(cfg config.Config, authService *auth.Service) Agent {
 	opts := []openai.Option{
 		openai.WithToken(token),
 		openai.WithModel(cfg.OpenAIModel),
+		openai.WithHTTPClient(&reasoningDoer{client: trace.NewClient(0)}),
 	}
 	if strings.TrimSpace(cfg.OpenAIBaseURL) != "" {
 		opts = append(opts, openai.WithBaseURL(cfg.OpenAIBaseURL))
@@ -94,29 +226,9 @@ func NewWithAuth(cfg config.Config, authService *auth.Service) Agent {
 
 	llm, err := openai.New(opts...)
 	if err != nil {
-		log.Printf("[信号] 初始化大模型客户端失败: %v，使用规则引擎", err)
-		return fallback
-	}
-
-	sysProm := loadFile("SystemPrompt.md")
-	userTmpl := loadFile("UserPrompt.md")
-
-	log.Printf("[信号] 大模型已就绪 模型=%s 系统提示词=%d字符 用户模板=%d字符",
-		cfg.OpenAIModel, len(sysProm), len(userTmpl))
-
-	mc := market.NewClient()
-	mc.CryptoPanicKey = cfg.CryptoPanicAPIKey
-	mc.LunarCrushKey = cfg.LunarCrushAPIKey
-
-	return &LangChainAgent{
-		model:        llm,
-		fallback:     fallback,
-		marketClient: mc,
-		systemPrompt: sysProm,
-		userTemplate: userTmpl,
-		startTime:    time.Now(),
-		modelName:    cfg.OpenAIModel,
+		return nil, "", err
 	}
+	return llm, cfg.OpenAIModel, nil
 }
 
 // SetAccountDataFunc 设置账户数据回调（由 orchestrator 在启动时注入）
@@ -126,6 +238,13 @@ func SetAccountDataFunc(agent Agent, fn AccountDataFunc) {
 	}
 }
 
+// SetPerformanceFunc 设置账户收益表现回调（由 orchestrator 在启动时注入）
+func SetPerformanceFunc(agent Agent, fn PerformanceFunc) {
+	if lca, ok := agent.(*LangChainAgent); ok {
+		lca.getPerformance = fn
+	}
+}
+
 // SetTradingMode 设置交易模式信息（由 orchestrator 在启动时注入）
 func SetTradingMode(agent Agent, mode string, leverage int) {
 	if lca, ok := agent.(*LangChainAgent); ok {
@@ -134,13 +253,37 @@ func SetTradingMode(agent Agent, mode string, leverage int) {
 	}
 }
 
-func loadFile(path string) string {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		log.Printf("[信号] 加载文件 %s 失败: %v", path, err)
-		return ""
+// ReloadPrompts 重新加载提示词模板；规则引擎（未配置大模型）返回错误
+func ReloadPrompts(agent Agent) (prompt.Info, error) {
+	lca, ok := agent.(*LangChainAgent)
+	if !ok {
+		return prompt.Info{}, fmt.Errorf("当前未使用大模型，没有提示词模板")
+	}
+	return lca.prompts.Reload()
+}
+
+// PromptInfo 当前加载的提示词模板概况；规则引擎返回 false
+func PromptInfo(agent Agent) (prompt.Info, bool) {
+	lca, ok := agent.(*LangChainAgent)
+	if !ok {
+		return prompt.Info{}, false
+	}
+	return lca.prompts.Info(), true
+}
+
+// modeFor 返回本次调用的交易模式与杠杆：优先使用 Input 中按交易对指定的模式，否则使用全局设置
+func (a *LangChainAgent) modeFor(input Input) (string, int) {
+	mode, leverage := a.tradingMode, a.leverage
+	if input.TradingMode != "" {
+		mode, leverage = input.TradingMode, input.Leverage
+	}
+	if mode == "" {
+		mode = "spot"
+	}
+	if leverage < 1 {
+		leverage = 1
 	}
-	return string(data)
+	return mode, leverage
 }
 
 func (a *RuleBasedAgent) Generate(_ context.Context, input Input) (domain.Signal, error) {
@@ -178,7 +321,25 @@ func (a *LangChainAgent) Generate(ctx context.Context, input Input) (domain.Sign
 	// 从币安获取实时行情
 	log.Printf("[信号] 正在从 Binance 获取 %s 的行情数据 ...", input.Pair)
 	t0 := time.Now()
-	userPrompt, err := a.buildUserPrompt(ctx, input)
+	tmpl := a.prompts.For(input.Pair)
+	userPrompt, gaps, err := a.buildUserPrompt(ctx, input, tmpl.User)
+	if err != nil {
+		gaps = []string{market.ComponentTicker}
+	}
+
+	// 行情数据不完整时按降级策略决定继续 / 观望 / 中止
+	if len(gaps) > 0 {
+		action, blocking := a.degrade.Decide(gaps)
+		switch action {
+		case market.DegradeAbort:
+			log.Printf("[信号] ✘ 行情数据缺失 %v，按降级策略中止本轮", blocking)
+			return domain.Signal{}, fmt.Errorf("行情数据不完整(%s)，按降级策略中止周期", strings.Join(blocking, ","))
+		case market.DegradeHold:
+			return a.degradedHold(input, blocking, gaps), nil
+		}
+		log.Printf("[信号] ⚠ 行情数据缺失 %v，按降级策略继续", gaps)
+	}
+
 	if err != nil {
 		log.Printf("[信号] ⚠️ Binance 数据获取失败 (耗时%s): %v，使用简化提示词", time.Since(t0), err)
 		userPrompt = a.buildSimplePrompt(input)
@@ -186,9 +347,22 @@ func (a *LangChainAgent) Generate(ctx context.Context, input Input) (domain.Sign
 		log.Printf("[信号] ✔ 行情数据就绪 (耗时%s)，提示词长度=%d字符", time.Since(t0), len(userPrompt))
 	}
 
+	sig, err := a.complete(ctx, input, tmpl, userPrompt)
+	sig.DataGaps = gaps
+	return sig, err
+}
+
+// complete 组装系统/用户提示词并调用大模型，解析为交易信号
+func (a *LangChainAgent) complete(ctx context.Context, input Input, tmpl prompt.Template, userPrompt string) (domain.Signal, error) {
 	// 根据交易模式动态调整系统提示词
-	sysPrompt := a.adaptSystemPrompt()
-	log.Printf("[信号] 系统提示词已加载=%v (%d字符) 模式=%s", sysPrompt != "", len(sysPrompt), a.tradingMode)
+	mode, leverage := a.modeFor(input)
+	sysPrompt := adaptSystemPrompt(tmpl.System, mode, leverage)
+	log.Printf("[信号] 系统提示词已加载=%v (%d字符) 模式=%s 模板=%s 版本=%s", sysPrompt != "", len(sysPrompt), mode, tmpl.Source, tmpl.Version)
+
+	if trimmed := truncatePrompt(userPrompt, a.maxPromptChars); len(trimmed) != len(userPrompt) {
+		log.Printf("[信号] ⚠ 用户提示词超过 %d 字符上限，已截断中间部分", a.maxPromptChars)
+		userPrompt = trimmed
+	}
 
 	// 组装消息：系统提示词 + 用户提示词
 	messages := []llms.MessageContent{
@@ -205,33 +379,88 @@ func (a *LangChainAgent) Generate(ctx context.Context, input Input) (domain.Sign
 	// 调试日志：打印完整用户提示词（便于排查敏感词问题）
 	log.Printf("[信号] 用户提示词内容:\n%s", userPrompt)
 
-	log.Printf("[信号] 正在调用大模型 ...")
-	t1 := time.Now()
-	resp, err := a.model.GenerateContent(ctx, messages)
-	llmElapsed := time.Since(t1)
-	if err != nil {
-		log.Printf("[信号] ✘ 大模型调用失败 (耗时%s): %v → 降级为规则引擎", llmElapsed, err)
-		return a.fallbackGenerate(ctx, input, "大模型调用失败: "+err.Error())
-	}
+	modelName := a.modelName
+	var override, callOpts []llms.CallOption
+	if m := strings.TrimSpace(input.Model); m != "" {
+		modelName = m
+		override = append(override, llms.WithModel(m))
+	}
+	if input.Provider != nil {
+		ctx = WithProviderPreferences(ctx, *input.Provider)
+	}
+	params := a.params.For(modelName)
+	callOpts = append(callOpts, params.callOptions()...)
+	ctx = withReasoningEffort(ctx, params.ReasoningEffort)
+
+	// 预算检查：超出单轮、每日或每月上限时不调用大模型
+	estimated := a.budget.estimate(modelName, len(sysPrompt)+len(userPrompt))
+	if reason, ok := a.budget.check(ctx, estimated); !ok {
+		return a.budgetExhausted(input, reason)
+	}
+
+	callOpts = append(callOpts, outputOptions(a.outputMode)...)
+
+	// 输出不符合 Schema 时把错误回传给模型重试一次，两次调用的用量与费用合并计入；
+	// 调用本身失败时按退避重试并切换备用端点（见 generate），全部失败才降级
+	var (
+		parsed                                      llmResponse
+		promptTokens, completionTokens, totalTokens int
+		costUSD                                     float64
+		callLog                                     []string
+		reasoning                                   string
+	)
+	for attempt := 1; ; attempt++ {
+		log.Printf("[信号] 正在调用大模型 %s 参数=%s 输出=%s 第%d次 ... %s", modelName, params, a.outputMode, attempt, trace.Fields(ctx))
+		t1 := time.Now()
+		resp, usedModel, attempts, err := a.generate(ctx, messages, modelName, override, callOpts)
+		llmElapsed := time.Since(t1)
+		callLog = append(callLog, attempts...)
+		if err != nil {
+			log.Printf("[信号] ✘ 大模型调用失败 (耗时%s): %v → 降级为规则引擎", llmElapsed, err)
+			sig, fbErr := a.fallbackGenerate(ctx, input, "大模型调用失败: "+err.Error())
+			sig.CostUSD = costUSD
+			sig.CallAttempts = callLog
+			return sig, fbErr
+		}
+		modelName = usedModel
+
+		if len(resp.Choices) == 0 {
+			log.Printf("[信号] ✘ 大模型返回空结果 (耗时%s) → 降级为规则引擎", llmElapsed)
+			sig, fbErr := a.fallbackGenerate(ctx, input, "大模型返回空结果")
+			sig.CostUSD = costUSD
+			sig.CallAttempts = callLog
+			return sig, fbErr
+		}
 
-	if len(resp.Choices) == 0 {
-		log.Printf("[信号] ✘ 大模型返回空结果 (耗时%s) → 降级为规则引擎", llmElapsed)
-		return a.fallbackGenerate(ctx, input, "大模型返回空结果")
+		choice := resp.Choices[0]
+		reasoning = choice.ReasoningContent
+
+		// 提取 token 用量
+		pt, ct, tt := extractTokenUsage(choice.GenerationInfo)
+		promptTokens, completionTokens, totalTokens = promptTokens+pt, completionTokens+ct, totalTokens+tt
+		costUSD += a.budget.cost(modelName, pt, ct)
+
+		var raw string
+		parsed, raw, err = decodeChoice(choice)
+		log.Printf("[信号] ✔ 大模型响应成功 (耗时%s)，响应长度=%d字符，函数调用=%d，Token: prompt=%d completion=%d total=%d",
+			llmElapsed, len(raw), len(choice.ToolCalls), pt, ct, tt)
+		log.Printf("[信号] 大模型原始输出: %.500s", raw)
+		if err == nil {
+			break
+		}
+		if attempt >= 2 {
+			log.Printf("[信号] ✘ 解析大模型输出失败: %v → 降级为规则引擎", err)
+			sig, fbErr := a.fallbackGenerate(ctx, input, "解析大模型输出失败: "+err.Error())
+			sig.CostUSD = costUSD // 调用已产生费用，仍需计入预算
+			sig.CallAttempts = callLog
+			return sig, fbErr
+		}
+		log.Printf("[信号] ⚠ 大模型输出不符合 Schema: %v，重试一次", err)
+		messages = retryMessages(messages, raw, err, a.outputMode)
 	}
 
-	choice := resp.Choices[0]
-	completion := choice.Content
-
-	// 提取 token 用量
-	promptTokens, completionTokens, totalTokens := extractTokenUsage(choice.GenerationInfo)
-	log.Printf("[信号] ✔ 大模型响应成功 (耗时%s)，响应长度=%d字符，Token: prompt=%d completion=%d total=%d",
-		llmElapsed, len(completion), promptTokens, completionTokens, totalTokens)
-	log.Printf("[信号] 大模型原始输出: %.500s", completion)
-
-	parsed, err := parseLLMOutput(completion)
-	if err != nil {
-		log.Printf("[信号] ✘ 解析大模型输出失败: %v → 降级为规则引擎", err)
-		return a.fallbackGenerate(ctx, input, "解析大模型输出失败: "+err.Error())
+	if a.budget.maxPerCycle > 0 && costUSD > a.budget.maxPerCycle {
+		log.Printf("[信号] ⚠ 本轮实际成本 $%.4f 超过单轮上限 $%.4f（预估 $%.4f）", costUSD, a.budget.maxPerCycle, estimated)
 	}
 
 	side := normalizeSide(parsed.Side, parsed.Signal)
@@ -245,6 +474,10 @@ func (a *LangChainAgent) Generate(ctx context.Context, input Input) (domain.Sign
 	}
 
 	thinking := parsed.Thinking
+	// Claude extended thinking 等模型原生的思考内容
+	if thinking == "" {
+		thinking = reasoning
+	}
 	// 如果没有单独的 thinking，把完整 reason/justification 当作思维链
 	if thinking == "" && len(parsed.Justification) > len(parsed.Reason) {
 		thinking = parsed.Justification
@@ -264,20 +497,25 @@ func (a *LangChainAgent) Generate(ctx context.Context, input Input) (domain.Sign
 		PromptTokens:     promptTokens,
 		CompletionTokens: completionTokens,
 		TotalTokens:      totalTokens,
-		ModelName:        a.modelName,
+		ModelName:        modelName,
+		CostUSD:          costUSD,
+		CloseFraction:    closeFraction(side, parsed.CloseFraction),
 		TTLSeconds:       clampInt(parsed.TTLSeconds, 60, 1800),
+		CallAttempts:     callLog,
+		PromptVersion:    tmpl.Version,
 		CreatedAt:        time.Now().UTC(),
 	}, nil
 }
 
-func (a *LangChainAgent) buildUserPrompt(ctx context.Context, input Input) (string, error) {
-	if a.userTemplate == "" {
-		return "", fmt.Errorf("未加载用户提示词模板")
+// buildUserPrompt 拉取行情快照并渲染用户提示词，同时返回快照中缺失的数据组件
+func (a *LangChainAgent) buildUserPrompt(ctx context.Context, input Input, userTemplate string) (string, []string, error) {
+	if userTemplate == "" {
+		return "", nil, fmt.Errorf("未加载用户提示词模板")
 	}
 
 	snap, err := a.marketClient.FetchSnapshot(ctx, input.Pair)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	// 情绪数据日志
@@ -308,68 +546,69 @@ func (a *LangChainAgent) buildUserPrompt(ctx context.Context, input Input) (stri
 		totalValue += qty * price
 	}
 
-	tradingMode := a.tradingMode
-	if tradingMode == "" {
-		tradingMode = "spot"
-	}
-	leverage := a.leverage
-	if leverage < 1 {
-		leverage = 1
+	tradingMode, leverage := a.modeFor(input)
+
+	// 收益率与夏普比率来自权益快照
+	var returnPct, sharpe float64
+	if a.getPerformance != nil {
+		returnPct, sharpe = a.getPerformance(ctx)
 	}
 
 	account := market.AccountInfo{
 		AccountValue:   totalValue,
 		CashAvailable:  cashAvailable,
-		ReturnPct:      0,
-		SharpeRatio:    0,
+		ReturnPct:      returnPct,
+		SharpeRatio:    sharpe,
 		MinutesElapsed: elapsed,
 		TradingMode:    tradingMode,
 		Leverage:       leverage,
 		Positions:      positions,
+		Alerts:         input.Alerts,
+		History:        decisionHistory(input.History, time.Now()),
 	}
 
-	// 获取关联币对数据（BTC 作为市场风向标）
+	// 获取关联币对数据（按 REFERENCE_PAIRS 配置，默认 BTC 作为市场风向标）
 	var extraSnaps []market.CoinSnapshot
-	mainCoin := strings.Split(strings.ToUpper(input.Pair), "/")[0]
-	if mainCoin != "BTC" {
-		btcSnap, btcErr := a.marketClient.FetchLightSnapshot(ctx, "BTC/USDT")
-		if btcErr == nil {
-			extraSnaps = append(extraSnaps, btcSnap)
-			log.Printf("[信号] 📊 BTC参考: 价格=%.2f 24h涨跌=%.2f%% 资金费率=%.6f",
-				btcSnap.Price, btcSnap.Change24hPct, btcSnap.FundingRate)
-		} else {
-			log.Printf("[信号] ⚠ BTC参考数据获取失败: %v（不影响主信号）", btcErr)
+	for _, ref := range a.referencePairs.For(input.Pair) {
+		refSnap, refErr := a.marketClient.FetchReferenceSnapshot(ctx, ref)
+		if refErr != nil {
+			log.Printf("[信号] ⚠ %s 参考数据获取失败: %v（不影响主信号）", ref, refErr)
+			continue
 		}
+		extraSnaps = append(extraSnaps, refSnap)
+		log.Printf("[信号] 📊 %s参考: 价格=%.2f 24h涨跌=%.2f%% 资金费率=%.6f",
+			ref, refSnap.Price, refSnap.Change24hPct, refSnap.FundingRate)
 	}
 
-	return market.BuildPrompt(a.userTemplate, snap, account, extraSnaps)
+	prompt, err := market.BuildPrompt(userTemplate, snap, account, extraSnaps)
+	return prompt, snap.Missing, err
 }
 
 // adaptSystemPrompt 根据交易模式动态修改系统提示词
-func (a *LangChainAgent) adaptSystemPrompt() string {
-	if a.tradingMode != "futures" {
-		return a.systemPrompt // 现货模式：原样返回
+func adaptSystemPrompt(system, mode string, leverage int) string {
+	if mode != "futures" {
+		return system // 现货模式：原样返回
 	}
 
 	// 合约模式：替换关键段落
-	prompt := a.systemPrompt
+	prompt := system
 
 	// 替换合规声明
 	prompt = strings.Replace(prompt,
 		"The system only performs spot trading (buying and selling digital assets) on regulated exchanges.",
-		fmt.Sprintf("The system performs USDT-M perpetual futures trading with %dx leverage (long only) on regulated exchanges.", a.leverage),
+		fmt.Sprintf("The system performs USDT-M perpetual futures trading with %dx leverage (long only) on regulated exchanges.", leverage),
 		1)
 
 	// 替换角色描述
 	prompt = strings.Replace(prompt,
 		"on Binance spot market",
-		fmt.Sprintf("on Binance USDT-M Futures market (%dx leverage, long only)", a.leverage),
+		fmt.Sprintf("on Binance USDT-M Futures market (%dx leverage, long only)", leverage),
 		1)
 
 	// 替换交易模式
 	prompt = strings.Replace(prompt,
 		"- **Trading Mode**: Spot only (NO leverage, NO margin, NO futures)",
-		fmt.Sprintf("- **Trading Mode**: USDT-M Perpetual Futures (%dx leverage, long only)", a.leverage),
+		fmt.Sprintf("- **Trading Mode**: USDT-M Perpetual Futures (%dx leverage, long only)", leverage),
 		1)
 	prompt = strings.Replace(prompt,
 		"- **Exchange**: Binance (spot market)",
@@ -388,7 +627,7 @@ func (a *LangChainAgent) adaptSystemPrompt() string {
 - **Funding Rate**: Paid/received every 8 hours — factor this into holding decisions
 - **Liquidation Risk**: With %dx leverage, liquidation occurs at ~%.0f%% price drop from entry
 - **Trading Fees**: ~0.04%% per trade (maker/taker, lower than spot)
-- **Slippage**: Expect 0.01-0.05%% on market orders`, a.leverage, a.leverage, a.leverage, 100.0/float64(a.leverage)*0.8),
+- **Slippage**: Expect 0.01-0.05%% on market orders`, leverage, leverage, leverage, 100.0/float64(leverage)*0.8),
 		1)
 
 	// 移除 "不能做空" 的强制提示
@@ -400,7 +639,7 @@ func (a *LangChainAgent) adaptSystemPrompt() string {
 	// 替换仓位框架中的无杠杆说明
 	prompt = strings.Replace(prompt,
 		"5. **NO leverage**: Maximum risk is 100% of position value (coin goes to zero)",
-		fmt.Sprintf("5. **%dx Leverage**: Maximum risk is the margin amount (liquidation before 100%% loss). With %dx leverage, a %.1f%% adverse move will liquidate your position.", a.leverage, a.leverage, 100.0/float64(a.leverage)*0.8),
+		fmt.Sprintf("5. **%dx Leverage**: Maximum risk is the margin amount (liquidation before 100%% loss). With %dx leverage, a %.1f%% adverse move will liquidate your position.", leverage, leverage, 100.0/float64(leverage)*0.8),
 		1)
 
 	// 替换策略指南标题
@@ -418,19 +657,41 @@ func (a *LangChainAgent) adaptSystemPrompt() string {
 	// 替换最终指示中的 short 提醒
 	prompt = strings.Replace(prompt,
 		"5. **NEVER output \"short\" as signal — spot trading supports \"long\", \"close\", \"hold\", or \"none\"**",
-		fmt.Sprintf("5. **NEVER output \"short\"** — only \"long\", \"close\", \"hold\", or \"none\" (long-only mode, %dx leverage)", a.leverage),
+		fmt.Sprintf("5. **NEVER output \"short\"** — only \"long\", \"close\", \"hold\", or \"none\" (long-only mode, %dx leverage)", leverage),
 		1)
 
 	return prompt
 }
 
 func (a *LangChainAgent) buildSimplePrompt(input Input) string {
+	alerts := ""
+	for _, a := range input.Alerts {
+		alerts += "风险提示: " + a + "\n"
+	}
 	return fmt.Sprintf(`请分析并给出交易决策（交易对=%s）。
 last_price=%.8f change_24h=%.4f volume_24h=%.4f funding_rate=%.6f
-
+%s
 请严格输出 JSON，reason/justification 必须为中文。`,
 		input.Pair, input.Snapshot.LastPrice, input.Snapshot.Change24h,
-		input.Snapshot.Volume24h, input.Snapshot.FundingRate)
+		input.Snapshot.Volume24h, input.Snapshot.FundingRate, alerts)
+}
+
+// degradedHold 关键行情数据缺失且策略为 hold 时不调用大模型，输出观望信号
+func (a *LangChainAgent) degradedHold(input Input, blocking, gaps []string) domain.Signal {
+	reason := "行情数据不完整，按降级策略观望: 缺失 " + strings.Join(blocking, ",")
+	log.Printf("[信号] ⏸ %s", reason)
+	return domain.Signal{
+		ID:         uuid.NewString(),
+		CycleID:    input.CycleID,
+		Pair:       input.Pair,
+		Side:       domain.SideNone,
+		Confidence: 0,
+		Reason:     reason,
+		ModelName:  "degraded",
+		DataGaps:   gaps,
+		TTLSeconds: 60,
+		CreatedAt:  time.Now().UTC(),
+	}
 }
 
 func (a *LangChainAgent) fallbackGenerate(_ context.Context, input Input, reason string) (domain.Signal, error) {
@@ -448,6 +709,14 @@ func (a *LangChainAgent) fallbackGenerate(_ context.Context, input Input, reason
 	}, nil
 }
 
+// closeFraction 只有 close 信号保留平仓比例，区间外的值视为全部平仓
+func closeFraction(side domain.Side, f float64) float64 {
+	if side != domain.SideClose {
+		return 0
+	}
+	return domain.NormalizeCloseFraction(f)
+}
+
 func parseLLMOutput(raw string) (llmResponse, error) {
 	var out llmResponse
 	clean := strings.TrimSpace(raw)
@@ -550,3 +819,47 @@ func clampInt(v, min, max int) int {
 	}
 	return v
 }
+
+// decisionHistory 把历史决策转换为提示词展示格式
+func decisionHistory(list []domain.DecisionMemory, now time.Time) []market.DecisionData {
+	out := make([]market.DecisionData, 0, len(list))
+	for _, d := range list {
+		item := market.DecisionData{
+			Ago:        formatAgo(now.Sub(d.CreatedAt)),
+			Side:       string(d.Side),
+			Confidence: fmt.Sprintf("%.2f", d.Confidence),
+			Reason:     d.Reason,
+		}
+		switch {
+		case d.FilledPrice > 0:
+			item.Outcome = "filled"
+			item.Price = strconv.FormatFloat(d.FilledPrice, 'f', -1, 64)
+			if d.Side == domain.SideClose {
+				item.PnL = fmt.Sprintf("%+.2f", d.RealizedPnL)
+			}
+		case d.Status == domain.CycleStatusFailed:
+			item.Outcome = "failed"
+		case d.Status == domain.CycleStatusRejected || (!d.Approved && d.Side != domain.SideNone):
+			item.Outcome = "rejected by risk"
+		default:
+			item.Outcome = "no trade"
+		}
+		if r := []rune(item.Reason); len(r) > 120 {
+			item.Reason = string(r[:120]) + "…"
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// formatAgo 把时间间隔格式化为 "35m" / "3h" / "2d"
+func formatAgo(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
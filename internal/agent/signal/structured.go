@@ -0,0 +1,136 @@
+package signal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// 信号输出模式
+const (
+	OutputTools = "tools" // function calling：强制调用 submit_trade_decision，参数按 JSON Schema 校验
+	OutputJSON  = "json"  // response_format=json_object
+	OutputText  = "text"  // 旧行为：从自由文本中提取 JSON
+)
+
+// decisionToolName 信号大模型必须调用的函数名
+const decisionToolName = "submit_trade_decision"
+
+// decisionSchema 交易决策的 JSON Schema，与 llmResponse 字段一致
+var decisionSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"signal":         map[string]any{"type": "string", "enum": []string{"long", "close", "hold", "none"}, "description": "Trading action"},
+		"side":           map[string]any{"type": "string", "enum": []string{"long", "close", "none"}, "description": "Same as signal; hold maps to none"},
+		"coin":           map[string]any{"type": "string", "description": "Base asset, e.g. DOGE"},
+		"confidence":     map[string]any{"type": "number", "minimum": 0, "maximum": 1},
+		"thinking":       map[string]any{"type": "string", "description": "Step-by-step analysis"},
+		"reason":         map[string]any{"type": "string", "description": "Short reason in Chinese"},
+		"justification":  map[string]any{"type": "string", "description": "Detailed justification in Chinese"},
+		"close_fraction": map[string]any{"type": "number", "minimum": 0, "maximum": 1, "description": "Fraction of the position to close (close only, 1 = all)"},
+		"ttl_seconds":    map[string]any{"type": "integer", "minimum": 60, "maximum": 1800, "description": "Seconds the decision stays valid"},
+	},
+	"required": []string{"signal", "confidence", "reason"},
+}
+
+// NormalizeOutputMode 规范化输出模式，未知值按 tools 处理
+func NormalizeOutputMode(mode string) string {
+	switch m := strings.ToLower(strings.TrimSpace(mode)); m {
+	case OutputJSON, OutputText:
+		return m
+	}
+	return OutputTools
+}
+
+// outputOptions 按输出模式返回调用参数
+func outputOptions(mode string) []llms.CallOption {
+	switch mode {
+	case OutputTools:
+		return []llms.CallOption{
+			llms.WithTools([]llms.Tool{{
+				Type: "function",
+				Function: &llms.FunctionDefinition{
+					Name:        decisionToolName,
+					Description: "Submit the trading decision for the analysed pair",
+					Parameters:  decisionSchema,
+				},
+			}}),
+			llms.WithToolChoice(llms.ToolChoice{
+				Type:     "function",
+				Function: &llms.FunctionReference{Name: decisionToolName},
+			}),
+		}
+	case OutputJSON:
+		return []llms.CallOption{llms.WithJSONMode()}
+	}
+	return nil
+}
+
+// decodeChoice 从模型回复中取出决策：优先使用函数调用参数，没有调用时回退到文本中的 JSON；
+// 返回原始输出便于重试时回显
+func decodeChoice(choice *llms.ContentChoice) (llmResponse, string, error) {
+	for _, tc := range choice.ToolCalls {
+		if tc.FunctionCall == nil || tc.FunctionCall.Name != decisionToolName {
+			continue
+		}
+		var out llmResponse
+		if err := json.Unmarshal([]byte(tc.FunctionCall.Arguments), &out); err != nil {
+			return out, tc.FunctionCall.Arguments, fmt.Errorf("函数参数不是合法 JSON: %w", err)
+		}
+		return out, tc.FunctionCall.Arguments, validateResponse(out)
+	}
+	out, err := parseLLMOutput(choice.Content)
+	if err != nil {
+		return out, choice.Content, err
+	}
+	return out, choice.Content, validateResponse(out)
+}
+
+// validateResponse 按 decisionSchema 校验决策字段
+func validateResponse(r llmResponse) error {
+	action := strings.ToLower(strings.TrimSpace(r.Signal))
+	if action == "" {
+		action = strings.ToLower(strings.TrimSpace(r.Side))
+	}
+	switch action {
+	case "long", "close", "hold", "none", "buy", "sell", "buy_to_enter", "sell_to_exit":
+	case "":
+		return fmt.Errorf("缺少 signal 字段")
+	default:
+		return fmt.Errorf("signal=%q 不在 long/close/hold/none 中", r.Signal)
+	}
+	if r.Confidence < 0 || r.Confidence > 1 {
+		return fmt.Errorf("confidence=%v 超出 0-1", r.Confidence)
+	}
+	if strings.TrimSpace(r.Reason) == "" && strings.TrimSpace(r.Justification) == "" {
+		return fmt.Errorf("缺少 reason 字段")
+	}
+	if r.CloseFraction < 0 || r.CloseFraction > 1 {
+		return fmt.Errorf("close_fraction=%v 超出 0-1", r.CloseFraction)
+	}
+	return nil
+}
+
+// retryMessages 输出不符合 Schema 时，在原对话后追加模型的输出与纠正提示，用于一次重试
+func retryMessages(messages []llms.MessageContent, raw string, cause error, mode string) []llms.MessageContent {
+	hint := fmt.Sprintf("Your previous output was invalid: %v. ", cause)
+	if mode == OutputTools {
+		hint += "Call " + decisionToolName + " again with arguments that satisfy its schema."
+	} else {
+		hint += "Reply again with a single JSON object only: signal (long/close/hold/none), confidence (0-1), reason, and the optional fields."
+	}
+	out := make([]llms.MessageContent, 0, len(messages)+2)
+	out = append(out, messages...)
+	if strings.TrimSpace(raw) != "" {
+		out = append(out, llms.MessageContent{
+			Role:  llms.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{llms.TextContent{Text: raw}},
+		})
+	}
+	return append(out, llms.MessageContent{
+		Role:  llms.ChatMessageTypeHuman,
+		Parts: []llms.ContentPart{llms.TextContent{Text: hint}},
+	})
+}
@@ -0,0 +1,245 @@
+// Package bookticker 订阅 Binance bookTicker 推送，按交易对缓存最优买卖价，
+// 执行路径（模拟成交、卖出估值、下单前价格校验）优先使用缓存，减少 REST 行情请求。
+package bookticker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	reconnectMin = time.Second
+	reconnectMax = time.Minute
+	readTimeout  = 2 * time.Minute // 超过该时长没有任何推送视为连接失效
+)
+
+// Quote 交易对的最优买卖价
+type Quote struct {
+	Bid       float64
+	BidQty    float64
+	Ask       float64
+	AskQty    float64
+	UpdatedAt time.Time
+}
+
+// Mid 买一卖一中间价
+func (q Quote) Mid() float64 {
+	return (q.Bid + q.Ask) / 2
+}
+
+// SpreadPct 买卖价差占中间价的百分比
+func (q Quote) SpreadPct() float64 {
+	mid := q.Mid()
+	if mid <= 0 {
+		return 0
+	}
+	return (q.Ask - q.Bid) / mid * 100
+}
+
+// Cache 单个 WebSocket 连接上的 bookTicker 缓存
+type Cache struct {
+	name   string // 日志标识：现货 / 合约
+	url    string // 如 wss://stream.binance.com:9443
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	quotes  map[string]Quote
+	symbols map[string]bool
+	conn    *websocket.Conn
+	started bool
+	nextID  int
+}
+
+// New 创建缓存，maxAge 内的报价视为有效
+func New(name, streamURL string, maxAge time.Duration) *Cache {
+	if maxAge <= 0 {
+		maxAge = 5 * time.Second
+	}
+	return &Cache{
+		name:    name,
+		url:     strings.TrimRight(streamURL, "/"),
+		maxAge:  maxAge,
+		quotes:  make(map[string]Quote),
+		symbols: make(map[string]bool),
+	}
+}
+
+// Track 订阅交易对（如 DOGE/USDT 或 DOGEUSDT），首次调用时启动后台连接；未启用（nil）时忽略
+func (c *Cache) Track(pairs ...string) {
+	if c == nil {
+		return
+	}
+	var added []string
+	c.mu.Lock()
+	for _, p := range pairs {
+		symbol := toSymbol(p)
+		if symbol == "" || c.symbols[symbol] {
+			continue
+		}
+		c.symbols[symbol] = true
+		added = append(added, symbol)
+	}
+	conn := c.conn
+	if !c.started && len(c.symbols) > 0 {
+		c.started = true
+		go c.run()
+	}
+	c.mu.Unlock()
+
+	if conn != nil && len(added) > 0 {
+		if err := c.subscribe(conn, added); err != nil {
+			log.Printf("[盘口] ⚠ %s 订阅 %v 失败: %v", c.name, added, err)
+		}
+	}
+}
+
+// Get 返回交易对的最新报价；未启用、未订阅或超过 maxAge 未更新时返回 false，并顺带订阅该交易对
+func (c *Cache) Get(pair string) (Quote, bool) {
+	if c == nil {
+		return Quote{}, false
+	}
+	symbol := toSymbol(pair)
+	c.mu.Lock()
+	q, ok := c.quotes[symbol]
+	tracked := c.symbols[symbol]
+	c.mu.Unlock()
+	if !tracked {
+		c.Track(symbol)
+	}
+	if !ok || time.Since(q.UpdatedAt) > c.maxAge || q.Bid <= 0 || q.Ask <= 0 {
+		return Quote{}, false
+	}
+	return q, true
+}
+
+// run 维持连接，断开后按指数退避重连
+func (c *Cache) run() {
+	backoff := reconnectMin
+	for {
+		start := time.Now()
+		err := c.connect()
+		if time.Since(start) > reconnectMax {
+			backoff = reconnectMin
+		}
+		log.Printf("[盘口] ⚠ %s bookTicker 连接断开: %v，%s 后重连", c.name, err, backoff)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > reconnectMax {
+			backoff = reconnectMax
+		}
+	}
+}
+
+// connect 建立一次连接并持续读取推送，返回断开原因
+func (c *Cache) connect() error {
+	c.mu.Lock()
+	symbols := make([]string, 0, len(c.symbols))
+	for s := range c.symbols {
+		symbols = append(symbols, s)
+	}
+	c.mu.Unlock()
+
+	streams := make([]string, len(symbols))
+	for i, s := range symbols {
+		streams[i] = strings.ToLower(s) + "@bookTicker"
+	}
+	cfg, err := websocket.NewConfig(c.url+"/stream?streams="+strings.Join(streams, "/"), "http://localhost/")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	conn, err := cfg.DialContext(ctx)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	log.Printf("[盘口] ✔ %s bookTicker 已连接: %d 个交易对", c.name, len(symbols))
+
+	// 连接建立期间新增的交易对补充订阅
+	c.mu.Lock()
+	c.conn = conn
+	var missed []string
+	for s := range c.symbols {
+		if !containsString(symbols, s) {
+			missed = append(missed, s)
+		}
+	}
+	c.mu.Unlock()
+	if len(missed) > 0 {
+		if err := c.subscribe(conn, missed); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		c.mu.Lock()
+		c.conn = nil
+		c.mu.Unlock()
+	}()
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+			return err
+		}
+		var msg struct {
+			Data struct {
+				Symbol string `json:"s"`
+				Bid    string `json:"b"`
+				BidQty string `json:"B"`
+				Ask    string `json:"a"`
+				AskQty string `json:"A"`
+			} `json:"data"`
+		}
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			return err
+		}
+		if msg.Data.Symbol == "" {
+			continue // 订阅确认等非行情消息
+		}
+		q := Quote{UpdatedAt: time.Now()}
+		q.Bid, _ = strconv.ParseFloat(msg.Data.Bid, 64)
+		q.BidQty, _ = strconv.ParseFloat(msg.Data.BidQty, 64)
+		q.Ask, _ = strconv.ParseFloat(msg.Data.Ask, 64)
+		q.AskQty, _ = strconv.ParseFloat(msg.Data.AskQty, 64)
+		c.mu.Lock()
+		c.quotes[msg.Data.Symbol] = q
+		c.mu.Unlock()
+	}
+}
+
+// subscribe 在已建立的连接上追加订阅
+func (c *Cache) subscribe(conn *websocket.Conn, symbols []string) error {
+	params := make([]string, len(symbols))
+	for i, s := range symbols {
+		params[i] = strings.ToLower(s) + "@bookTicker"
+	}
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	c.mu.Unlock()
+	body, err := json.Marshal(map[string]any{"method": "SUBSCRIBE", "params": params, "id": id})
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(body)
+	return err
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func toSymbol(pair string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(pair), "/", ""))
+}
@@ -12,43 +12,324 @@ import (
 type Config struct {
 	HTTPAddr          string
 	SQLiteDSN         string
+	ReadOnly          bool // 只读实例：禁用所有写接口与后台任务，数据库以只读方式打开
 	RequestTimeoutSec int
 
 	OpenAIAPIKey  string
 	OpenAIModel   string
 	OpenAIBaseURL string
 
+	// LLM 后端: "openai"（默认，含所有 OpenAI 兼容接口）、"azure"、"openrouter"、"anthropic" 或 "ollama"
+	LLMBackend string
+
+	// 本地模型（LLM_BACKEND=ollama 时生效，Ollama / llama.cpp server，无需 OpenAI 凭证）
+	OllamaBaseURL        string
+	OllamaModel          string
+	OllamaAPI            string // "openai"（默认，/v1 兼容接口）或 "native"（Ollama /api/chat）
+	OllamaTimeoutSec     int    // 单次调用超时，本地推理通常比云端慢
+	OllamaNumCtx         int    // 上下文窗口（native 接口的 num_ctx），0 = 模型默认
+	OllamaMaxPromptChars int    // 用户提示词字符上限，超出时截断中间部分，0 = 不限制
+
+	// OpenRouter 配置（LLM_BACKEND=openrouter 时生效）
+	OpenRouterAPIKey         string // 为空则回退 OPENAI_API_KEY
+	OpenRouterBaseURL        string
+	OpenRouterProviderOrder  string // 逗号分隔的上游提供商优先级，如 "DeepInfra,Together"
+	OpenRouterAllowFallbacks bool   // 优先提供商不可用时是否允许回退到其他提供商
+	OpenRouterAppName        string // X-Title 请求头，用于 OpenRouter 排行榜归属
+	OpenRouterSiteURL        string // HTTP-Referer 请求头
+
+	// Azure OpenAI 配置（LLM_BACKEND=azure 时生效）
+	AzureOpenAIEndpoint   string // 资源地址，如 https://my-resource.openai.azure.com
+	AzureOpenAIDeployment string // 部署名称（Azure 按部署名路由模型）
+	AzureOpenAIAPIVersion string // api-version 查询参数
+	AzureOpenAIAuth       string // "key"（默认）或 "aad"
+	AzureOpenAIAPIKey     string // key 认证时使用，为空则回退 OPENAI_API_KEY
+	AzureOpenAIADToken    string // aad 认证时使用的 Bearer Token
+
+	// LLM 成本控制（价格单位: USD / 百万 token，上限为 0 表示不限制）
+	LLMPromptPricePerM     float64
+	LLMCompletionPricePerM float64
+	LLMEstCompletionTokens int // 调用前预估的回复 token 数
+	LLMMaxCostPerCycleUSD  float64
+	LLMMaxDailyCostUSD     float64
+	LLMMaxMonthlyCostUSD   float64
+	LLMModelPrices         string // 按模型的价格，如 "openai/gpt-4o=2.5/10"，未列出的模型使用上面的默认价格
+
+	// 模型输出分布监控：每个模型最近 BehaviorWindow 个信号对比之前 BehaviorBaseline 个，方向占比或平均置信度突变时告警
+	BehaviorWindow          int     // 0 = 不监控
+	BehaviorBaseline        int     // 0 = 最近窗口的 5 倍
+	BehaviorSideShiftPct    float64 // 方向占比变化阈值（百分点）
+	BehaviorConfidenceShift float64 // 平均置信度变化阈值（0-1）
+	BehaviorCheckSec        int     // 巡检间隔（秒）
+
+	// 写库重试：周期中订单、成交与周期状态写库失败时放入进程内队列，每 OutboxRetrySec 秒按顺序重试
+	OutboxRetrySec   int // 重试间隔（秒），≤ 0 时按 30 秒
+	OutboxAlertAfter int // 重试多少次仍失败时告警
+	OutboxDeadAfter  int // 重试多少次仍失败时移出队列（不再阻塞后续写入），只保留在待补录列表
+
+	// 提示词模板目录：SystemPrompt.md / UserPrompt.md，pairs/<交易对或币种>/ 下按交易对覆盖
+	PromptDir string
+
+	// 决策记忆：提示词附带该交易对最近 N 个周期的决策与结果，0 = 不启用
+	DecisionMemoryCycles int
+
+	// LLM 生成参数（为空 / 0 表示不传，使用服务端默认值）
+	LLMTemperature     string // 如 "0.2"
+	LLMMaxTokens       int
+	LLMReasoningEffort string // low / medium / high
+	LLMModelParams     string // 按模型覆盖，如 "openai/o3-mini=max_tokens:4000;reasoning:high"
+	LLMOutputMode      string // 信号输出格式: tools（默认，function calling）/ json / text
+
+	// LLM 调用重试与故障切换：限流 / 超时 / 5xx 按指数退避重试，仍失败则按顺序切换备用端点，全部失败才降级为观望
+	LLMRetryMax       int    // 每个端点的最大重试次数
+	LLMRetryBackoffMs int    // 初始退避（毫秒），每次翻倍，上限 30 秒
+	LLMFailover       string // 备用端点，如 "https://api.deepseek.com/v1|deepseek-chat|sk-xxx,https://openrouter.ai/api/v1|openai/gpt-4o-mini"
+
+	// 关联参考币对，如 "DOGE/USDT=BTC/USDT+ETH/USDT;SOL/USDT=BTC/USDT+TOTAL;*=BTC/USDT"
+	// TOTAL 表示加密货币总市值（CoinGecko）
+	ReferencePairs string
+
 	CryptoPanicAPIKey string
 	LunarCrushAPIKey  string
 
-	ExchangeBaseURL   string
-	ExchangeAPIKey    string
-	ExchangeSecretKey string
+	// 第三方数据源地址（为空使用官方地址），用于走镜像或缓存代理
+	CryptoPanicBaseURL string
+	LunarCrushBaseURL  string
+	CoinGeckoBaseURL   string
+	FearGreedBaseURL   string
+	DataSourceHeaders  string // 额外请求头，如 "coingecko=x-cg-pro-api-key:KEY;X-Proxy-Token:abc"
+
+	// 行情数据缺失时的降级策略，如 "klines=abort,funding=hold,news=proceed"
+	DegradePolicy string
+
+	Exchange                string // 交易所: binance（默认）/ okx
+	ExchangeBaseURL         string
+	ExchangeAPIKey          string
+	ExchangeSecretKey       string
+	ExchangeValidateOnStart bool   // 实盘启动时校验 API Key 权限，失败则退出
+	PublicIPProbeURL        string // 出现 -2015 时探测本机公网 IP 的地址，为空不探测
+	KeyExpiryWarnDays       int    // 交易权限到期前多少天开始告警
+	EarnAutoRedeem          bool   // 现货 USDT 不足时自动从 Binance 活期理财赎回差额
+
+	// 交易所 API 限流：按主机跟踪响应头中的已用权重，达到上限的 RateLimitSafetyPct% 时延后到下一分钟
+	SpotWeightLimit    int // 现货每分钟权重上限，0 = 只响应 429 / 418
+	FuturesWeightLimit int // 合约每分钟权重上限
+	RateLimitSafetyPct int
+
+	// 签名请求的 recvWindow（毫秒），timestamp 按启动时与 -1021 后校准的服务器时间计算
+	RecvWindowMs int
+
+	// OKX（EXCHANGE=okx 时使用，目前只支持现货）
+	OKXBaseURL    string
+	OKXAPIKey     string
+	OKXSecretKey  string
+	OKXPassphrase string
+	OKXSimulated  bool // 使用 OKX 模拟盘
 
 	MaxSingleStakeUSDT float64 // 单笔最大下单金额上限
+	PairNotionalLimits string  // 按交易对的单笔开仓金额上下限，如 "DOGE/USDT=10-100,BTC/USDT=20-500"
 	MaxDailyLossUSDT   float64
 	MaxExposureUSDT    float64
 	MinConfidence      float64
+	CooldownSec        int // 同一币对两次开仓的最小间隔（秒），0 = 不限制
+	// 连亏冷却：同一币对连续 LossStreakMax 笔亏损平仓后，LossStreakCooldownMin 分钟内不再开仓
+	LossStreakMax         int // 0 = 不启用
+	LossStreakCooldownMin int
+	MaxDailyTradesPerPair int    // 同一币对每个交易日最多成交的订单数（开仓与平仓都计入），达到后不再开仓，0 = 不限制
+	RiskPreset            string // 启动时使用的风险偏好预设，空 = 直接使用上述参数
 
 	DryRun bool
 
-	// 交易模式: "spot"（现货）或 "futures"（永续合约）
+	// 模拟盘钱包：DRY_RUN 下用 SQLite 中的虚拟余额替代交易所余额，模拟成交计入手续费与滑点
+	PaperTrading     bool
+	PaperInitialUSDT float64
+	PaperFeeRate     float64 // 按名义价值收取，如 0.001 = 0.1%
+	PaperSlippageBps float64 // 市价模拟成交相对盘口价的滑点（基点）
+
+	// 交易模式: "spot"（现货）、"margin"（现货杠杆）或 "futures"（永续合约）
 	TradingMode       string
 	FuturesBaseURL    string
 	FuturesLeverage   int
 	FuturesMarginType string // "CROSSED" 或 "ISOLATED"
+	PairTradingModes  string // 按交易对指定模式，如 "DOGE/USDT=spot,BTC/USDT=futures"，未列出的使用 TradingMode
+
+	// 现货杠杆：买入自动借款、卖出自动还款；风险率低于 MarginMinLevel 时暂停开仓并告警
+	MarginType     string  // "cross" 或 "isolated"
+	MarginLeverage int     // 开仓金额 = 本金 × 杠杆（全仓最高 3，逐仓最高 10）
+	MarginMinLevel float64 // 风险率预警线，如 1.5，0 = 不监控
+	MarginCheckSec int     // 风险率巡检间隔（秒），0 = 只在开仓前检查
+
+	// 合约资金费率规则：持续高费率时提示离场，累计成本超限时可自动平仓
+	FundingHighRate         float64 // 单期高费率阈值，如 0.0005 = 0.05%/8h，0 = 不提示
+	FundingSustainedPeriods int
+	FundingMaxCostPct       float64 // 开仓以来累计费率成本上限（% 名义价值），0 = 不限制
+	FundingAutoClose        bool
+
+	// 合约强平预警：标记价距强平价不足 LiqBufferPct 时告警，LiqReduceFraction > 0 时自动减仓
+	LiqBufferPct      float64 // 0 = 不监控
+	LiqReduceFraction float64 // 自动减仓比例（0-1），0 = 只告警
+	LiqCheckSec       int     // 巡检间隔（秒）
+
+	// 波动熔断：VolatilityWindowMin 分钟内波动超过 VolatilityMovePct 时暂停该交易对开仓
+	VolatilityMovePct     float64 // 0 = 不启用
+	VolatilityWindowMin   int
+	VolatilityCooldownMin int
+
+	// 防反复开平：上一笔成交后 ChurnWindowMin 分钟内的反向信号需置信度达到 ChurnMinConfidence
+	ChurnWindowMin     int // 0 = 不启用
+	ChurnMinConfidence float64
+
+	// 回撤熔断：账户权益较 DrawdownWindowDays 天内峰值回撤超过 DrawdownMaxPct 时暂停全部开仓，需手动恢复
+	DrawdownMaxPct     float64 // 0 = 不启用
+	DrawdownWindowDays int
+	DrawdownForceClose bool // 熔断期间直接平掉已有持仓
+
+	// 组合分配模式：定时器每轮先用一次大模型调用看全部交易对，生成排序后的分配计划再逐个执行
+	PortfolioMode bool
+
+	// 多交易对资金分配：CapitalBudgetUSDT 按权重分给 AUTO_RUN_PAIRS，开仓金额 = 份额 × 置信度 − 已有持仓，预算同时是组合总敞口上限
+	CapitalBudgetUSDT  float64 // 0 = 不启用
+	CapitalWeighting   string  // equal / volatility
+	CapitalATRInterval string
+	CapitalATRPeriod   int
+
+	// 人工审批：off / live（只审批实盘下单）/ all，超过 ApprovalTimeoutMin 未确认自动取消
+	ApprovalMode       string
+	ApprovalTimeoutMin int
+
+	// 同一交易对已有周期在执行时再次触发：reject（拒绝，HTTP 409）/ queue（排队等待）
+	CycleConcurrency string
+
+	// 周期队列：定时与手动触发的周期排队执行（手动优先），Workers 为并发执行数，QueueMax 为等待上限
+	CycleWorkers  int
+	CycleQueueMax int
+
+	// 交易通知（Telegram），NotifyEvents 为逗号分隔的通知类型，空 = 全部
+	TelegramBotToken string
+	TelegramChatID   string
+	NotifyEvents     string
+
+	// 日报 / 周报估算手续费的费率（按成交额），模拟盘使用 PaperFeeRate
+	ReportFeeRate float64
+
+	// 报表展示币种（CNY / EUR 等），USDT = 不换算；DisplayFXRate > 0 时使用固定汇率，否则按 FXCacheMin 缓存在线汇率
+	DisplayCurrency string
+	DisplayFXRate   float64
+	FXCacheMin      int
+
+	// 策略模块：未指定的交易对使用 Strategy，PairStrategies 形如 "BTC/USDT=trend,DOGE/USDT=rules"
+	Strategy       string
+	PairStrategies string
+
+	// 部分成交：超时后撤销剩余量，或撤销后按剩余量重新下单（超时为 0 表示不处理）
+	PartialFillTimeoutSec int
+	PartialFillAction     string // "cancel"（默认）或 "resubmit"
+
+	// 止盈止损保护单：开仓成交后挂到交易所，定时对账（间隔为 0 表示不对账）
+	ProtectiveReconcileSec int
+	StopLimitSlippagePct   float64 // 现货止损限价相对触发价的下浮比例（%）
+
+	// 盘口缓存：订阅 bookTicker 推送，模拟成交 / 卖出估值使用实时买一卖一价，实盘下单前校验预估价格
+	BookTickerEnabled   bool
+	BookTickerMaxAgeSec int     // 超过该时长未更新的报价不使用
+	SpotStreamURL       string  // 现货 WebSocket 地址
+	FuturesStreamURL    string  // 合约 WebSocket 地址
+	PriceSanityMaxPct   float64 // 预估成交价偏离盘口中间价的上限（%），0 = 不校验
+
+	// 用户数据流：订阅账户的订单推送（listenKey 自动续期），成交与保护单触发实时更新订单和持仓，使用上面的 WebSocket 地址
+	UserStreamEnabled bool
+
+	// 下单方式（现货）：market（默认）/ limit（只做 maker 挂盘口，超时撤单重挂，仍未成交按 LimitOrderFallback 处理）
+	OrderType              string
+	LimitOrderWaitSec      int    // 每次挂单等待成交的时间（秒）
+	LimitOrderReplaces     int    // 超时后撤单重挂的次数
+	LimitOrderImproveTicks int    // 挂单价向盘口内侧移动的跳数
+	LimitOrderFallback     string // market（剩余转市价，默认）/ cancel（只保留已成交部分）
+
+	// 拆单执行（TWAP）：周期下单名义金额达到阈值时按时间拆成多笔（阈值为 0 = 不拆单）
+	TWAPMinNotionalUSDT float64
+	TWAPSlices          int
+	TWAPIntervalSec     int
+
+	// 按名义金额选择执行方式，如 "*=limit:50|twap:1000,DOGE/USDT=limit:20|twap:300"，空 = 沿用 ORDER_TYPE 与 TWAP 阈值
+	ExecutionPolicy string
+
+	// 分批建仓：定时检查后续批次触发价（间隔为 0 表示只执行首批）
+	BatchTriggerIntervalSec int
+	BatchExpireHours        int // 待触发批次有效期，0 = 不过期
+
+	// 移动止损：止损价跟随持仓最高价上移，回撤距离取百分比与 ATR 倍数中较大者（都为 0 = 不启用）
+	TrailingStopPct         float64
+	TrailingStopATRMult     float64
+	TrailingStopATRInterval string
+	TrailingStopATRPeriod   int
+	TrailingStopCheckSec    int
+
+	// 分批止盈：如 "5:0.5,10:1" 表示盈利 5% 卖出一半、10% 卖出剩余全部，空 = 不启用（由分批触发任务检查）
+	TakeProfitLadder string
 
 	// 定时任务
 	AutoRunEnabled  bool
 	AutoRunInterval int // 秒
 	AutoRunPairs    string
 
+	// 影子周期：只模拟不下单的交易对，定时跑预览并在观察期后评估假设盈亏
+	ShadowPairs       string
+	ShadowIntervalSec int
+	ShadowHorizonMin  int
+
+	// 沙盒会话定时运行间隔（秒），0 = 只能通过 API 手动运行
+	SandboxIntervalSec int
+
+	// 交易对筛选：定时扫描全市场 24h 行情给出候选，可自动加入影子周期
+	ScreenerEnabled         bool
+	ScreenerIntervalMin     int
+	ScreenerQuote           string
+	ScreenerMinQuoteVolume  float64
+	ScreenerMinChangePct    float64
+	ScreenerMinRangePct     float64
+	ScreenerRequireTrending bool
+	ScreenerMaxCandidates   int
+	ScreenerAutoShadow      bool
+	ScreenerExclude         string
+
+	// 交易日时区（每日亏损上限、每日预算、按日盈亏统计的日切时区）
+	TradingTimezone string
+
+	// 成本核算方法: "average"（加权平均，默认）或 "fifo"（先进先出），影响持仓成本与已实现盈亏
+	CostBasisMethod string
+
+	// 关闭的流水线环节，如 "news,position_strategy"，可通过 API 运行时调整
+	DisabledStages string
+
+	// 数据清理（默认关闭，需显式开启；保留天数为 0 表示不清理）
+	PruneEnabled             bool
+	PruneIntervalMin         int // 分钟
+	PruneLogRetentionDays    int // cycle_logs 保留天数
+	PruneFailedRetentionDays int // failed/rejected 周期保留天数
+
+	// 链路追踪：对外请求附带 X-Cycle-ID / X-Request-ID 头，记录每次对外请求
+	TraceHTTPHeaders bool
+	TraceLogOutbound bool
+
+	// Web UI 登录（UI_PASSWORD_HASH 为空时不启用）
+	UIPasswordHash  string // bcrypt 哈希，可用 `go run . hash-password` 生成
+	SessionTTLHours int
+	APIKeys         string // name:scope:key,...（scope 为 read / trade / admin）
+
 	// OAuth 配置
 	OAuthStoragePath string
 
 	// LLM 认证配置
 	LLMAuthMode     string // "api_key", "oauth", "auto"（默认）
-	LLMAuthProvider string // "openai", "anthropic"（默认 openai）
+	LLMAuthProvider string // "openai", "anthropic"（默认 openai）；anthropic 时信号使用 Claude Messages API
+
+	// Anthropic（LLM_AUTH_PROVIDER=anthropic 或 LLM_BACKEND=anthropic 时生效）
+	AnthropicAPIKey         string
+	AnthropicModel          string
+	AnthropicBaseURL        string
+	AnthropicMaxTokens      int
+	AnthropicThinkingBudget int // extended thinking 的 token 预算，0 = 按 LLM_REASONING_EFFORT 决定（未设置则不开启）
 }
 
 func Load() Config {
@@ -60,39 +341,263 @@ func Load() Config {
 	return Config{
 		HTTPAddr:          getEnv("HTTP_ADDR", ":8080"),
 		SQLiteDSN:         getEnv("SQLITE_DSN", "file:./ai_quant.db?_pragma=busy_timeout(5000)"),
+		ReadOnly:          getEnvBool("READ_ONLY", false),
 		RequestTimeoutSec: getEnvInt("REQUEST_TIMEOUT_SEC", 15),
 
 		OpenAIAPIKey:  getEnv("OPENAI_API_KEY", ""),
 		OpenAIModel:   getEnv("OPENAI_MODEL", "gpt-4o-mini"),
 		OpenAIBaseURL: getEnv("OPENAI_BASE_URL", ""),
 
+		LLMBackend: getEnv("LLM_BACKEND", "openai"),
+
+		OllamaBaseURL:        getEnv("OLLAMA_BASE_URL", "http://localhost:11434"),
+		OllamaModel:          getEnv("OLLAMA_MODEL", "qwen2.5:7b"),
+		OllamaAPI:            getEnv("OLLAMA_API", "openai"),
+		OllamaTimeoutSec:     getEnvInt("OLLAMA_TIMEOUT_SEC", 300),
+		OllamaNumCtx:         getEnvInt("OLLAMA_NUM_CTX", 8192),
+		OllamaMaxPromptChars: getEnvInt("OLLAMA_MAX_PROMPT_CHARS", 0),
+
+		OpenRouterAPIKey:         getEnv("OPENROUTER_API_KEY", ""),
+		OpenRouterBaseURL:        getEnv("OPENROUTER_BASE_URL", "https://openrouter.ai/api/v1"),
+		OpenRouterProviderOrder:  getEnv("OPENROUTER_PROVIDER_ORDER", ""),
+		OpenRouterAllowFallbacks: getEnvBool("OPENROUTER_ALLOW_FALLBACKS", true),
+		OpenRouterAppName:        getEnv("OPENROUTER_APP_NAME", "ai_quant"),
+		OpenRouterSiteURL:        getEnv("OPENROUTER_SITE_URL", ""),
+
+		AzureOpenAIEndpoint:   getEnv("AZURE_OPENAI_ENDPOINT", ""),
+		AzureOpenAIDeployment: getEnv("AZURE_OPENAI_DEPLOYMENT", ""),
+		AzureOpenAIAPIVersion: getEnv("AZURE_OPENAI_API_VERSION", "2024-06-01"),
+		AzureOpenAIAuth:       getEnv("AZURE_OPENAI_AUTH", "key"),
+		AzureOpenAIAPIKey:     getEnv("AZURE_OPENAI_API_KEY", ""),
+		AzureOpenAIADToken:    getEnv("AZURE_OPENAI_AD_TOKEN", ""),
+
+		LLMPromptPricePerM:     getEnvFloat("LLM_PROMPT_PRICE_PER_M", 0.15),
+		LLMCompletionPricePerM: getEnvFloat("LLM_COMPLETION_PRICE_PER_M", 0.60),
+		LLMEstCompletionTokens: getEnvInt("LLM_EST_COMPLETION_TOKENS", 800),
+		LLMMaxCostPerCycleUSD:  getEnvFloat("LLM_MAX_COST_PER_CYCLE_USD", 0),
+		LLMMaxDailyCostUSD:     getEnvFloat("LLM_MAX_DAILY_COST_USD", 0),
+		LLMMaxMonthlyCostUSD:   getEnvFloat("LLM_MAX_MONTHLY_COST_USD", 0),
+		LLMModelPrices:         getEnv("LLM_MODEL_PRICES", ""),
+
+		BehaviorWindow:          getEnvInt("MODEL_BEHAVIOR_WINDOW", 0),
+		BehaviorBaseline:        getEnvInt("MODEL_BEHAVIOR_BASELINE", 0),
+		BehaviorSideShiftPct:    getEnvFloat("MODEL_BEHAVIOR_SIDE_SHIFT_PCT", 40),
+		BehaviorConfidenceShift: getEnvFloat("MODEL_BEHAVIOR_CONFIDENCE_SHIFT", 0.15),
+		BehaviorCheckSec:        getEnvInt("MODEL_BEHAVIOR_CHECK_SEC", 900),
+
+		OutboxRetrySec:   getEnvInt("DB_OUTBOX_RETRY_SEC", 30),
+		OutboxAlertAfter: getEnvInt("DB_OUTBOX_ALERT_AFTER", 10),
+		OutboxDeadAfter:  getEnvInt("DB_OUTBOX_DEAD_AFTER", 30),
+
+		PromptDir:            getEnv("PROMPT_DIR", "."),
+		DecisionMemoryCycles: getEnvInt("DECISION_MEMORY_CYCLES", 5),
+
+		LLMTemperature:     getEnv("LLM_TEMPERATURE", ""),
+		LLMMaxTokens:       getEnvInt("LLM_MAX_TOKENS", 0),
+		LLMReasoningEffort: getEnv("LLM_REASONING_EFFORT", ""),
+		LLMModelParams:     getEnv("LLM_MODEL_PARAMS", ""),
+		LLMOutputMode:      getEnv("LLM_OUTPUT_MODE", "tools"),
+
+		LLMRetryMax:       getEnvInt("LLM_RETRY_MAX", 2),
+		LLMRetryBackoffMs: getEnvInt("LLM_RETRY_BACKOFF_MS", 1000),
+		LLMFailover:       getEnv("LLM_FAILOVER", ""),
+
+		ReferencePairs: getEnv("REFERENCE_PAIRS", "*=BTC/USDT"),
+
 		CryptoPanicAPIKey: getEnv("CRYPTOPANIC_API_KEY", ""),
 		LunarCrushAPIKey:  getEnv("LUNARCRUSH_API_KEY", ""),
 
-		ExchangeBaseURL:   getEnv("EXCHANGE_BASE_URL", "https://api.binance.com"),
-		ExchangeAPIKey:    getEnv("EXCHANGE_API_KEY", ""),
-		ExchangeSecretKey: getEnv("EXCHANGE_SECRET_KEY", ""),
+		CryptoPanicBaseURL: getEnv("CRYPTOPANIC_BASE_URL", ""),
+		LunarCrushBaseURL:  getEnv("LUNARCRUSH_BASE_URL", ""),
+		CoinGeckoBaseURL:   getEnv("COINGECKO_BASE_URL", ""),
+		FearGreedBaseURL:   getEnv("FEAR_GREED_BASE_URL", ""),
+		DataSourceHeaders:  getEnv("DATA_SOURCE_HEADERS", ""),
+
+		DegradePolicy: getEnv("DEGRADE_POLICY", ""),
+
+		Exchange:                getEnv("EXCHANGE", "binance"),
+		ExchangeBaseURL:         getEnv("EXCHANGE_BASE_URL", "https://api.binance.com"),
+		ExchangeAPIKey:          getEnv("EXCHANGE_API_KEY", ""),
+		ExchangeSecretKey:       getEnv("EXCHANGE_SECRET_KEY", ""),
+		ExchangeValidateOnStart: getEnvBool("EXCHANGE_VALIDATE_ON_START", true),
+		PublicIPProbeURL:        getEnv("PUBLIC_IP_PROBE_URL", ""),
+		KeyExpiryWarnDays:       getEnvInt("KEY_EXPIRY_WARN_DAYS", 7),
+		EarnAutoRedeem:          getEnvBool("EARN_AUTO_REDEEM", false),
+
+		SpotWeightLimit:    getEnvInt("BINANCE_SPOT_WEIGHT_LIMIT", 6000),
+		FuturesWeightLimit: getEnvInt("BINANCE_FUTURES_WEIGHT_LIMIT", 2400),
+		RateLimitSafetyPct: getEnvInt("RATE_LIMIT_SAFETY_PCT", 90),
+		RecvWindowMs:       getEnvInt("BINANCE_RECV_WINDOW_MS", 5000),
+
+		OKXBaseURL:    getEnv("OKX_BASE_URL", "https://www.okx.com"),
+		OKXAPIKey:     getEnv("OKX_API_KEY", ""),
+		OKXSecretKey:  getEnv("OKX_SECRET_KEY", ""),
+		OKXPassphrase: getEnv("OKX_PASSPHRASE", ""),
+		OKXSimulated:  getEnvBool("OKX_SIMULATED", false),
 
 		MaxSingleStakeUSDT: getEnvFloatWithFallback("MAX_SINGLE_STAKE_USDT", "DEFAULT_STAKE_USDT", 50),
+		PairNotionalLimits: getEnv("PAIR_NOTIONAL_LIMITS", ""),
 		MaxDailyLossUSDT:   getEnvFloat("MAX_DAILY_LOSS_USDT", 100),
 		MaxExposureUSDT:    getEnvFloat("MAX_EXPOSURE_USDT", 200),
 		MinConfidence:      getEnvFloat("MIN_CONFIDENCE", 0.55),
+		CooldownSec:        getEnvInt("COOLDOWN_SEC", 0),
+
+		LossStreakMax:         getEnvInt("LOSS_STREAK_MAX", 0),
+		LossStreakCooldownMin: getEnvInt("LOSS_STREAK_COOLDOWN_MIN", 240),
+		MaxDailyTradesPerPair: getEnvInt("MAX_DAILY_TRADES_PER_PAIR", 0),
+		RiskPreset:            getEnv("RISK_PRESET", ""),
 
 		DryRun: getEnvBool("DRY_RUN", true),
 
+		PaperTrading:     getEnvBool("PAPER_TRADING", true),
+		PaperInitialUSDT: getEnvFloat("PAPER_INITIAL_USDT", 1000),
+		PaperFeeRate:     getEnvFloat("PAPER_FEE_RATE", 0.001),
+		PaperSlippageBps: getEnvFloat("PAPER_SLIPPAGE_BPS", 5),
+
 		TradingMode:       getEnv("TRADING_MODE", "spot"),
 		FuturesBaseURL:    getEnv("FUTURES_BASE_URL", "https://fapi.binance.com"),
 		FuturesLeverage:   getEnvInt("FUTURES_LEVERAGE", 3),
 		FuturesMarginType: getEnv("FUTURES_MARGIN_TYPE", "CROSSED"),
+		PairTradingModes:  getEnv("PAIR_TRADING_MODES", ""),
+
+		MarginType:     getEnv("MARGIN_TYPE", "cross"),
+		MarginLeverage: getEnvInt("MARGIN_LEVERAGE", 2),
+		MarginMinLevel: getEnvFloat("MARGIN_MIN_LEVEL", 1.5),
+		MarginCheckSec: getEnvInt("MARGIN_CHECK_SEC", 300),
+
+		FundingHighRate:         getEnvFloat("FUNDING_HIGH_RATE", 0.0005),
+		FundingSustainedPeriods: getEnvInt("FUNDING_SUSTAINED_PERIODS", 3),
+		FundingMaxCostPct:       getEnvFloat("FUNDING_MAX_COST_PCT", 0),
+		FundingAutoClose:        getEnvBool("FUNDING_AUTO_CLOSE", false),
+
+		LiqBufferPct:      getEnvFloat("LIQ_BUFFER_PCT", 10),
+		LiqReduceFraction: getEnvFloat("LIQ_REDUCE_FRACTION", 0),
+		LiqCheckSec:       getEnvInt("LIQ_CHECK_SEC", 60),
+
+		VolatilityMovePct:     getEnvFloat("VOLATILITY_MOVE_PCT", 0),
+		VolatilityWindowMin:   getEnvInt("VOLATILITY_WINDOW_MIN", 5),
+		VolatilityCooldownMin: getEnvInt("VOLATILITY_COOLDOWN_MIN", 30),
+
+		ChurnWindowMin:     getEnvInt("CHURN_WINDOW_MIN", 0),
+		ChurnMinConfidence: getEnvFloat("CHURN_MIN_CONFIDENCE", 0.85),
+
+		DrawdownMaxPct:     getEnvFloat("DRAWDOWN_MAX_PCT", 0),
+		DrawdownWindowDays: getEnvInt("DRAWDOWN_WINDOW_DAYS", 30),
+		DrawdownForceClose: getEnvBool("DRAWDOWN_FORCE_CLOSE", false),
+
+		PortfolioMode: getEnvBool("PORTFOLIO_MODE", false),
+
+		CapitalBudgetUSDT:  getEnvFloat("CAPITAL_BUDGET_USDT", 0),
+		CapitalWeighting:   getEnv("CAPITAL_WEIGHTING", "volatility"),
+		CapitalATRInterval: getEnv("CAPITAL_ATR_INTERVAL", "4h"),
+		CapitalATRPeriod:   getEnvInt("CAPITAL_ATR_PERIOD", 14),
+
+		ApprovalMode:       getEnv("APPROVAL_MODE", "off"),
+		ApprovalTimeoutMin: getEnvInt("APPROVAL_TIMEOUT_MIN", 15),
+
+		CycleConcurrency: getEnv("CYCLE_CONCURRENCY", "reject"),
+		CycleWorkers:     getEnvInt("CYCLE_WORKERS", 2),
+		CycleQueueMax:    getEnvInt("CYCLE_QUEUE_MAX", 50),
+
+		TelegramBotToken: getEnv("TELEGRAM_BOT_TOKEN", ""),
+		TelegramChatID:   getEnv("TELEGRAM_CHAT_ID", ""),
+		NotifyEvents:     getEnv("NOTIFY_EVENTS", "fill,reject,failure,daily,approval"),
+
+		ReportFeeRate: getEnvFloat("REPORT_FEE_RATE", 0.001),
+
+		DisplayCurrency: getEnv("DISPLAY_CURRENCY", "USDT"),
+		DisplayFXRate:   getEnvFloat("DISPLAY_FX_RATE", 0),
+		FXCacheMin:      getEnvInt("FX_CACHE_MIN", 30),
+
+		Strategy:       getEnv("STRATEGY", "default"),
+		PairStrategies: getEnv("PAIR_STRATEGIES", ""),
+
+		PartialFillTimeoutSec: getEnvInt("PARTIAL_FILL_TIMEOUT_SEC", 60),
+		PartialFillAction:     getEnv("PARTIAL_FILL_ACTION", "cancel"),
+
+		ProtectiveReconcileSec: getEnvInt("PROTECTIVE_RECONCILE_SEC", 30),
+		StopLimitSlippagePct:   getEnvFloat("STOP_LIMIT_SLIPPAGE_PCT", 0.5),
+
+		BookTickerEnabled:   getEnvBool("BOOK_TICKER_ENABLED", false),
+		BookTickerMaxAgeSec: getEnvInt("BOOK_TICKER_MAX_AGE_SEC", 5),
+		SpotStreamURL:       getEnv("SPOT_STREAM_URL", "wss://stream.binance.com:9443"),
+		FuturesStreamURL:    getEnv("FUTURES_STREAM_URL", "wss://fstream.binance.com"),
+		PriceSanityMaxPct:   getEnvFloat("PRICE_SANITY_MAX_PCT", 2),
+
+		UserStreamEnabled: getEnvBool("USER_STREAM_ENABLED", false),
+
+		OrderType:              getEnv("ORDER_TYPE", "market"),
+		LimitOrderWaitSec:      getEnvInt("LIMIT_ORDER_WAIT_SEC", 10),
+		LimitOrderReplaces:     getEnvInt("LIMIT_ORDER_REPLACES", 2),
+		LimitOrderImproveTicks: getEnvInt("LIMIT_ORDER_IMPROVE_TICKS", 0),
+		LimitOrderFallback:     getEnv("LIMIT_ORDER_FALLBACK", "market"),
+
+		TWAPMinNotionalUSDT: getEnvFloat("TWAP_MIN_NOTIONAL_USDT", 0),
+		TWAPSlices:          getEnvInt("TWAP_SLICES", 4),
+		TWAPIntervalSec:     getEnvInt("TWAP_INTERVAL_SEC", 30),
+
+		ExecutionPolicy: getEnv("EXECUTION_POLICY", ""),
+
+		BatchTriggerIntervalSec: getEnvInt("BATCH_TRIGGER_INTERVAL_SEC", 30),
+		BatchExpireHours:        getEnvInt("BATCH_EXPIRE_HOURS", 24),
+
+		TakeProfitLadder: getEnv("TAKE_PROFIT_LADDER", ""),
+
+		TrailingStopPct:         getEnvFloat("TRAILING_STOP_PCT", 0),
+		TrailingStopATRMult:     getEnvFloat("TRAILING_STOP_ATR_MULT", 0),
+		TrailingStopATRInterval: getEnv("TRAILING_STOP_ATR_INTERVAL", "1h"),
+		TrailingStopATRPeriod:   getEnvInt("TRAILING_STOP_ATR_PERIOD", 14),
+		TrailingStopCheckSec:    getEnvInt("TRAILING_STOP_CHECK_SEC", 30),
 
 		AutoRunEnabled:  getEnvBool("AUTO_RUN_ENABLED", false),
 		AutoRunInterval: getEnvInt("AUTO_RUN_INTERVAL_SEC", 60),
 		AutoRunPairs:    getEnv("AUTO_RUN_PAIRS", "BTC/USDT"),
 
+		ShadowPairs:       getEnv("SHADOW_PAIRS", ""),
+		ShadowIntervalSec: getEnvInt("SHADOW_INTERVAL_SEC", 3600),
+		ShadowHorizonMin:  getEnvInt("SHADOW_HORIZON_MIN", 240),
+
+		SandboxIntervalSec: getEnvInt("SANDBOX_INTERVAL_SEC", 0),
+
+		ScreenerEnabled:         getEnvBool("SCREENER_ENABLED", false),
+		ScreenerIntervalMin:     getEnvInt("SCREENER_INTERVAL_MIN", 60),
+		ScreenerQuote:           getEnv("SCREENER_QUOTE", "USDT"),
+		ScreenerMinQuoteVolume:  getEnvFloat("SCREENER_MIN_QUOTE_VOLUME", 20000000),
+		ScreenerMinChangePct:    getEnvFloat("SCREENER_MIN_CHANGE_PCT", 3),
+		ScreenerMinRangePct:     getEnvFloat("SCREENER_MIN_RANGE_PCT", 5),
+		ScreenerRequireTrending: getEnvBool("SCREENER_REQUIRE_TRENDING", false),
+		ScreenerMaxCandidates:   getEnvInt("SCREENER_MAX_CANDIDATES", 10),
+		ScreenerAutoShadow:      getEnvBool("SCREENER_AUTO_SHADOW", false),
+		ScreenerExclude:         getEnv("SCREENER_EXCLUDE", "USDC,FDUSD,TUSD,BUSD,DAI,USDP,EUR,TRY,BRL"),
+
+		TradingTimezone: getEnv("TRADING_TIMEZONE", "UTC"),
+
+		CostBasisMethod: getEnv("COST_BASIS_METHOD", "average"),
+
+		DisabledStages: getEnv("DISABLED_STAGES", ""),
+
+		PruneEnabled:             getEnvBool("PRUNE_ENABLED", false),
+		PruneIntervalMin:         getEnvInt("PRUNE_INTERVAL_MIN", 60),
+		PruneLogRetentionDays:    getEnvInt("PRUNE_LOG_RETENTION_DAYS", 7),
+		PruneFailedRetentionDays: getEnvInt("PRUNE_FAILED_RETENTION_DAYS", 30),
+
+		TraceHTTPHeaders: getEnvBool("TRACE_HTTP_HEADERS", false),
+		TraceLogOutbound: getEnvBool("TRACE_LOG_OUTBOUND", false),
+
+		UIPasswordHash:  getEnv("UI_PASSWORD_HASH", ""),
+		SessionTTLHours: getEnvInt("SESSION_TTL_HOURS", 168),
+		APIKeys:         getEnv("API_KEYS", ""),
+
 		OAuthStoragePath: getEnv("OAUTH_STORAGE_PATH", ""),
 
 		LLMAuthMode:     getEnv("LLM_AUTH_MODE", "auto"),
 		LLMAuthProvider: getEnv("LLM_AUTH_PROVIDER", "openai"),
+
+		AnthropicAPIKey:         getEnv("ANTHROPIC_API_KEY", ""),
+		AnthropicModel:          getEnv("ANTHROPIC_MODEL", "claude-sonnet-4-5"),
+		AnthropicBaseURL:        getEnv("ANTHROPIC_BASE_URL", "https://api.anthropic.com"),
+		AnthropicMaxTokens:      getEnvInt("ANTHROPIC_MAX_TOKENS", 4096),
+		AnthropicThinkingBudget: getEnvInt("ANTHROPIC_THINKING_BUDGET", 0),
 	}
 }
 
@@ -0,0 +1,111 @@
+// Package costbasis 持仓成本核算：按配置的方法（加权平均 / 先进先出）维护买入批次，
+// 持仓更新、已实现盈亏统计共用同一套核算逻辑，保证各处口径一致。
+package costbasis
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Method 成本核算方法
+type Method string
+
+const (
+	Average Method = "average" // 加权平均成本
+	FIFO    Method = "fifo"    // 先进先出
+)
+
+var method atomic.Value
+
+// Configure 设置全局成本核算方法，空字符串表示加权平均
+func Configure(name string) error {
+	switch m := Method(strings.ToLower(strings.TrimSpace(name))); m {
+	case "", Average:
+		method.Store(Average)
+	case FIFO:
+		method.Store(FIFO)
+	default:
+		return fmt.Errorf("未知的成本核算方法 %q（可选 average / fifo）", name)
+	}
+	return nil
+}
+
+// Current 返回当前成本核算方法，未配置时为加权平均
+func Current() Method {
+	if m, ok := method.Load().(Method); ok {
+		return m
+	}
+	return Average
+}
+
+// Lot 一笔买入批次
+type Lot struct {
+	Qty   float64
+	Price float64
+}
+
+// Book 按币对记录买入批次；加权平均法下每个币对只保留一个合并批次
+type Book struct {
+	method Method
+	lots   map[string][]Lot
+}
+
+// NewBook 按指定方法创建账本
+func NewBook(m Method) *Book {
+	return &Book{method: m, lots: make(map[string][]Lot)}
+}
+
+// Buy 记录买入
+func (b *Book) Buy(pair string, qty, price float64) {
+	if qty <= 0 {
+		return
+	}
+	lots := b.lots[pair]
+	if b.method == Average && len(lots) > 0 {
+		l := lots[0]
+		total := l.Qty + qty
+		b.lots[pair] = []Lot{{Qty: total, Price: (l.Qty*l.Price + qty*price) / total}}
+		return
+	}
+	b.lots[pair] = append(lots, Lot{Qty: qty, Price: price})
+}
+
+// Sell 记录卖出，返回已实现盈亏与实际匹配到成本的数量；
+// 超出已知持仓的部分没有成本记录，不计盈亏
+func (b *Book) Sell(pair string, qty, price float64) (realized, matched float64) {
+	lots := b.lots[pair]
+	for qty > 0 && len(lots) > 0 {
+		take := qty
+		if lots[0].Qty < take {
+			take = lots[0].Qty
+		}
+		realized += (price - lots[0].Price) * take
+		matched += take
+		qty -= take
+		lots[0].Qty -= take
+		if lots[0].Qty <= 1e-12 {
+			lots = lots[1:]
+		}
+	}
+	b.lots[pair] = lots
+	return realized, matched
+}
+
+// Position 返回币对当前剩余数量与剩余成本
+func (b *Book) Position(pair string) (qty, cost float64) {
+	for _, l := range b.lots[pair] {
+		qty += l.Qty
+		cost += l.Qty * l.Price
+	}
+	return qty, cost
+}
+
+// Pairs 返回账本中出现过的全部币对
+func (b *Book) Pairs() []string {
+	pairs := make([]string, 0, len(b.lots))
+	for p := range b.lots {
+		pairs = append(pairs, p)
+	}
+	return pairs
+}
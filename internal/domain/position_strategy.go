@@ -17,6 +17,9 @@ type PositionStrategy struct {
 	
 	// 分批建仓计划
 	Batches []PositionBatch `json:"batches"`
+
+	// 分批止盈计划（为空表示止盈一次性卖出）
+	ExitBatches []ExitBatch `json:"exit_batches,omitempty"`
 	
 	// 止盈止损
 	TakeProfitPercent float64 `json:"take_profit_percent"` // 止盈百分比
@@ -39,6 +42,24 @@ type PositionBatch struct {
 	ExecutedAt    *time.Time `json:"executed_at"` // 执行时间
 }
 
+// ExitBatch 分批止盈批次：盈利达到 ProfitPercent 时卖出触发时持仓的 Fraction
+type ExitBatch struct {
+	BatchNo       int        `json:"batch_no"`
+	ProfitPercent float64    `json:"profit_percent"` // 相对持仓均价的盈利百分比
+	Fraction      float64    `json:"fraction"`       // 卖出比例（占触发时持仓），1 = 卖出剩余全部
+	Status        string     `json:"status"`         // "pending", "executed", "cancelled"
+	ExecutedPrice float64    `json:"executed_price"`
+	ExecutedQty   float64    `json:"executed_qty"`
+	ExecutedAt    *time.Time `json:"executed_at"`
+}
+
+// 批次状态
+const (
+	BatchPending   = "pending"
+	BatchExecuted  = "executed"
+	BatchCancelled = "cancelled"
+)
+
 // StrategyType 建仓策略类型
 const (
 	StrategyFull    = "full"    // 全仓：一次性建仓
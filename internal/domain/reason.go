@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// ReasonCode 周期被拒绝 / 执行失败的归类代码，与原始文本一起保存，便于统计
+type ReasonCode string
+
+// 风控拒绝
+const (
+	ReasonSignalNone     ReasonCode = "signal_none"       // 信号为观望
+	ReasonLowConfidence  ReasonCode = "low_confidence"    // 置信度低于阈值
+	ReasonDailyLossLimit ReasonCode = "daily_loss_limit"  // 当日亏损达到上限
+	ReasonCooldown       ReasonCode = "cooldown"          // 同币对开仓冷却中
+	ReasonLossStreak     ReasonCode = "loss_streak"       // 同币对连续亏损后的冷却期
+	ReasonMaxExposure    ReasonCode = "max_exposure"      // 持仓敞口达到上限
+	ReasonZeroStake      ReasonCode = "zero_stake"        // 计算出的下单金额为 0
+	ReasonNotionalLimit  ReasonCode = "pair_notional_min" // 低于交易对下单金额下限
+
+	ReasonDrawdownHalt     ReasonCode = "drawdown_halt"     // 回撤熔断暂停开仓
+	ReasonApprovalRejected ReasonCode = "approval_rejected" // 人工审批拒绝
+	ReasonApprovalTimeout  ReasonCode = "approval_timeout"  // 审批超时自动取消
+	ReasonDailyTradeLimit  ReasonCode = "daily_trade_limit" // 同币对当日成交次数达到上限
+)
+
+// 执行失败
+const (
+	ReasonInsufficientBalance ReasonCode = "insufficient_balance" // 可用余额不足
+	ReasonExchangeMinimum     ReasonCode = "exchange_minimum"     // 低于交易所最小数量 / 名义价值
+	ReasonAPIKeyRejected      ReasonCode = "api_key_rejected"     // API Key / IP 白名单 / 权限被拒
+	ReasonExchangeError       ReasonCode = "exchange_error"       // 其他交易所下单错误
+	ReasonMarketData          ReasonCode = "market_data"          // 行情数据缺失（降级策略中止）
+	ReasonLLMError            ReasonCode = "llm_error"            // 大模型调用 / 解析失败、预算超限
+	ReasonInternal            ReasonCode = "internal"             // 存储 / 建仓策略等内部错误
+	ReasonOther               ReasonCode = "other"                // 无法归类
+)
+
+// ClassifyReason 按原始文本归类；用于交易所 / 大模型等只返回自由文本的错误，以及旧数据的回填
+func ClassifyReason(msg string) ReasonCode {
+	m := strings.ToLower(msg)
+	switch {
+	case m == "":
+		return ""
+	case strings.Contains(m, "signal side is none"):
+		return ReasonSignalNone
+	case strings.Contains(m, "confidence") && strings.Contains(m, "below min"):
+		return ReasonLowConfidence
+	case strings.Contains(m, "max loss limit"):
+		return ReasonDailyLossLimit
+	case strings.Contains(m, "consecutive losing"):
+		return ReasonLossStreak
+	case strings.Contains(m, "daily trade limit"):
+		return ReasonDailyTradeLimit
+	case strings.Contains(m, "cooldown"):
+		return ReasonCooldown
+	case strings.Contains(m, "max exposure"):
+		return ReasonMaxExposure
+	case strings.Contains(m, "max stake is zero"):
+		return ReasonZeroStake
+	case strings.Contains(m, "交易对下限"):
+		return ReasonNotionalLimit
+	case strings.Contains(m, "回撤熔断"):
+		return ReasonDrawdownHalt
+	case strings.Contains(m, "余额不足"), strings.Contains(m, "insufficient balance"), strings.Contains(m, "-2010"), strings.Contains(m, "-2019"):
+		return ReasonInsufficientBalance
+	case strings.Contains(m, "最小交易量"), strings.Contains(m, "交易所下限"), strings.Contains(m, "min_notional"),
+		strings.Contains(m, "notional"), strings.Contains(m, "lot_size"), strings.Contains(m, "-1013"):
+		return ReasonExchangeMinimum
+	case strings.Contains(m, "-2015"), strings.Contains(m, "-2014"), strings.Contains(m, "api key"), strings.Contains(m, "api-key"):
+		return ReasonAPIKeyRejected
+	case strings.Contains(m, "行情"), strings.Contains(m, "kline"), strings.Contains(m, "ticker"):
+		return ReasonMarketData
+	case strings.Contains(m, "llm"), strings.Contains(m, "大模型"), strings.Contains(m, "openrouter"), strings.Contains(m, "预算"):
+		return ReasonLLMError
+	case strings.Contains(m, "binance"), strings.Contains(m, "okx"), strings.Contains(m, "下单"), strings.Contains(m, "order"):
+		return ReasonExchangeError
+	}
+	return ReasonOther
+}
+
+// ReasonStats 某交易日按归类代码汇总的拒绝 / 失败次数
+type ReasonStats struct {
+	Date   string      `json:"date"`
+	Status CycleStatus `json:"status"` // rejected / failed
+	Code   ReasonCode  `json:"code"`
+	Count  int         `json:"count"`
+}
+
+// CycleOutcome 周期结果（用于统计拒绝原因）
+type CycleOutcome struct {
+	Status       CycleStatus
+	ReasonCode   ReasonCode
+	ErrorMessage string
+	CreatedAt    time.Time
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 标签来源
+const (
+	TagSourceAuto   = "auto"   // 周期执行时按规则自动打上
+	TagSourceManual = "manual" // 用户手动添加
+)
+
+// 自动标签
+const (
+	TagHighVolatility = "high-volatility" // 24h 涨跌幅超过阈值
+	TagNewsDriven     = "news-driven"     // 信号理由提及新闻 / 热搜等事件
+	TagFundingAlert   = "funding-alert"   // 资金费率规则给出提示
+	TagFundingClose   = "funding-close"   // 资金费率成本超限自动平仓
+)
+
+// ErrInvalidTag 标签格式不合法
+var ErrInvalidTag = errors.New("标签不合法")
+
+// CycleTag 周期标签
+type CycleTag struct {
+	CycleID   string    `json:"cycle_id"`
+	Tag       string    `json:"tag"`
+	Source    string    `json:"source"` // auto / manual
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TagStats 按标签汇总的周期表现，用于分析维度
+type TagStats struct {
+	Tag           string  `json:"tag"`
+	Cycles        int     `json:"cycles"`
+	Success       int     `json:"success"`
+	Rejected      int     `json:"rejected"`
+	Failed        int     `json:"failed"`
+	Orders        int     `json:"orders"` // 已成交订单数
+	StakeUSDT     float64 `json:"stake_usdt"`
+	AvgConfidence float64 `json:"avg_confidence"`
+}
+
+// NormalizeTag 统一标签格式：小写、空白转 "-"，只允许字母数字和 "-" "_" ":"，长度 1-32
+func NormalizeTag(tag string) (string, error) {
+	t := strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+	if t == "" || len(t) > 32 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+	}
+	for _, r := range t {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == ':') {
+			return "", fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+		}
+	}
+	return t, nil
+}
@@ -1,6 +1,11 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+)
 
 type Side string
 
@@ -18,6 +23,16 @@ const (
 	CycleStatusRejected CycleStatus = "rejected"
 	CycleStatusSuccess  CycleStatus = "success"
 	CycleStatusFailed   CycleStatus = "failed"
+
+	CycleStatusPendingApproval CycleStatus = "pending_approval" // 人工审批模式下等待确认下单
+)
+
+// CycleType 周期类型
+type CycleType string
+
+const (
+	CycleTypeAuto   CycleType = "auto"   // 由大模型信号驱动（定时或手动触发）
+	CycleTypeManual CycleType = "manual" // 人工下单 / 手动平仓，不调用大模型
 )
 
 type Cycle struct {
@@ -25,6 +40,10 @@ type Cycle struct {
 	Pair         string      `json:"pair"`
 	Status       CycleStatus `json:"status"`
 	ErrorMessage string      `json:"error_message,omitempty"`
+	ReasonCode   ReasonCode  `json:"reason_code,omitempty"` // 拒绝 / 失败原因归类
+	Preset       string      `json:"preset,omitempty"`      // 本周期使用的风险偏好预设
+	Strategy     string      `json:"strategy,omitempty"`    // 本周期使用的交易策略
+	Type         CycleType   `json:"type"`
 	CreatedAt    time.Time   `json:"created_at"`
 	UpdatedAt    time.Time   `json:"updated_at"`
 }
@@ -50,38 +69,127 @@ type Signal struct {
 	CompletionTokens int       `json:"completion_tokens,omitempty"` // 回复 token 数
 	TotalTokens      int       `json:"total_tokens,omitempty"`      // 总 token 数
 	ModelName        string    `json:"model_name,omitempty"`        // 使用的模型名称
+	CostUSD          float64   `json:"cost_usd,omitempty"`          // 估算的大模型调用成本
+	CloseFraction    float64   `json:"close_fraction,omitempty"`    // close 信号的平仓比例，0 或 1 = 全部平仓
+	DataGaps         []string  `json:"data_gaps,omitempty"`         // 本轮缺失的行情数据组件（只写入周期日志，不入库）
+	CallAttempts     []string  `json:"call_attempts,omitempty"`     // 大模型调用重试 / 备用端点切换记录（只写入周期日志，不入库）
+	PromptVersion    string    `json:"prompt_version,omitempty"`    // 生成信号使用的提示词模板版本（内容哈希）
 	TTLSeconds       int       `json:"ttl_seconds"`
 	CreatedAt        time.Time `json:"created_at"`
 }
 
+// DecisionMemory 交易对最近一轮周期的决策与结果，写入提示词避免模型反复开平
+type DecisionMemory struct {
+	CycleID     string      `json:"cycle_id"`
+	Status      CycleStatus `json:"status"`
+	Side        Side        `json:"side"`
+	Confidence  float64     `json:"confidence"`
+	Approved    bool        `json:"approved"`
+	FilledPrice float64     `json:"filled_price,omitempty"`
+	RealizedPnL float64     `json:"realized_pnl,omitempty"` // 该周期平仓的已实现盈亏
+	Reason      string      `json:"reason"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// NormalizeCloseFraction 平仓比例规范化：不在 (0,1) 区间的值视为全部平仓（1）
+func NormalizeCloseFraction(f float64) float64 {
+	if f <= 0 || f >= 1 {
+		return 1
+	}
+	return f
+}
+
 type PortfolioState struct {
-	DailyPnLUSDT     float64 `json:"daily_pnl_usdt"`
+	DailyPnLUSDT     float64 `json:"daily_pnl_usdt"` // 当前交易日已实现盈亏 + 未实现盈亏相对交易日开始时的变化
 	OpenExposureUSDT float64 `json:"open_exposure_usdt"`
 }
 
 type RiskDecision struct {
-	ID           string    `json:"id"`
-	CycleID      string    `json:"cycle_id"`
-	SignalID     string    `json:"signal_id"`
-	Approved     bool      `json:"approved"`
-	RejectReason string    `json:"reject_reason,omitempty"`
-	MaxStakeUSDT float64   `json:"max_stake_usdt"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           string     `json:"id"`
+	CycleID      string     `json:"cycle_id"`
+	SignalID     string     `json:"signal_id"`
+	Approved     bool       `json:"approved"`
+	RejectReason string     `json:"reject_reason,omitempty"`
+	RejectCode   ReasonCode `json:"reject_code,omitempty"`
+	MaxStakeUSDT float64    `json:"max_stake_usdt"`
+	CreatedAt    time.Time  `json:"created_at"`
+
+	// 开仓金额的计算过程，平仓 / 观望时为空
+	Sizing *SizingBreakdown `json:"sizing,omitempty"`
 }
 
+// SizingBreakdown 开仓金额的计算依据：风控限额、组合敞口、下单前的余额快照，以及之后每一步对金额的调整
+type SizingBreakdown struct {
+	MaxSingleStakeUSDT    float64      `json:"max_single_stake_usdt"`
+	MaxExposureUSDT       float64      `json:"max_exposure_usdt"`
+	OpenExposureUSDT      float64      `json:"open_exposure_usdt"`
+	RemainingExposureUSDT float64      `json:"remaining_exposure_usdt"`
+	RiskStakeUSDT         float64      `json:"risk_stake_usdt"` // min(单笔上限, 剩余敞口)
+	Leverage              int          `json:"leverage"`
+	BalanceFreeUSDT       float64      `json:"balance_free_usdt"` // 下单前 USDT 可用余额（含按需赎回的理财），未查询时为 0
+	BalanceEarnUSDT       float64      `json:"balance_earn_usdt"`
+	FinalStakeUSDT        float64      `json:"final_stake_usdt"` // 实际下单金额（分批建仓为第一批），未下单时为 0
+	Adjustments           []SizingStep `json:"adjustments"`
+}
+
+// SizingStep 一次开仓金额调整
+type SizingStep struct {
+	Stage    string  `json:"stage"` // capital / allocation / batch / balance / notional
+	FromUSDT float64 `json:"from_usdt"`
+	ToUSDT   float64 `json:"to_usdt"`
+	Factor   float64 `json:"factor"` // ToUSDT / FromUSDT
+	Note     string  `json:"note,omitempty"`
+}
+
+// 开仓金额调整阶段
+const (
+	SizingCapital    = "capital"    // 多交易对资金分配
+	SizingAllocation = "allocation" // 组合分配计划权重
+	SizingBatch      = "batch"      // 分批建仓只执行第一批
+	SizingBalance    = "balance"    // 可用余额不足
+	SizingNotional   = "notional"   // 交易对单笔金额上限
+	SizingManual     = "manual"     // 人工下单指定金额
+)
+
+// Adjust 记录一次金额调整；b 为 nil 时忽略
+func (b *SizingBreakdown) Adjust(stage string, from, to float64, note string) {
+	if b == nil {
+		return
+	}
+	step := SizingStep{Stage: stage, FromUSDT: from, ToUSDT: to, Note: note}
+	if from > 0 {
+		step.Factor = math.Round(to/from*10000) / 10000
+	}
+	b.Adjustments = append(b.Adjustments, step)
+}
+
+// 订单成交方式
+const (
+	FillMarket        = "market"          // 市价单
+	FillLimit         = "limit"           // 首笔只做 maker 限价单成交
+	FillLimitReplaced = "limit_replaced"  // 撤单重挂后的限价单成交
+	FillLimitToMarket = "limit_to_market" // 限价单超时未全部成交，剩余部分转市价
+	FillTWAP          = "twap"            // 大额订单按时间拆分执行（父订单与子单）
+)
+
 type Order struct {
 	ID              string    `json:"id"`
 	CycleID         string    `json:"cycle_id"`
 	SignalID        string    `json:"signal_id"`
 	ClientOrderID   string    `json:"client_order_id"`
+	IdempotencyKey  string    `json:"idempotency_key,omitempty"` // 同一键只允许一笔订单（周期 / 分批 / 止盈批次）
 	Pair            string    `json:"pair"`
 	Side            Side      `json:"side"`
 	StakeUSDT       float64   `json:"stake_usdt"`
-	Leverage        int       `json:"leverage,omitempty"` // 杠杆倍数，现货=0，合约=2-20
+	ExpectedPrice   float64   `json:"expected_price,omitempty"` // 决策时的预估成交价，用于计算滑点
+	Leverage        int       `json:"leverage,omitempty"`       // 杠杆倍数，现货=0，合约=2-20
 	Status          string    `json:"status"`
 	ExchangeOrderID string    `json:"exchange_order_id,omitempty"`
 	FilledPrice     float64   `json:"filled_price,omitempty"`
 	FilledQuantity  float64   `json:"filled_qty,omitempty"`
+	RequestedQty    float64   `json:"requested_qty,omitempty"`   // 交易所返回的委托数量，用于计算部分成交的剩余量
+	ParentOrderID   string    `json:"parent_order_id,omitempty"` // 部分成交剩余量重新提交时指向原订单
+	FillStrategy    string    `json:"fill_strategy,omitempty"`   // 成交方式：market / limit / limit_replaced / limit_to_market / twap
 	RawResponse     string    `json:"raw_response,omitempty"`
 	CreatedAt       time.Time `json:"created_at"`
 }
@@ -100,6 +208,10 @@ type CycleReport struct {
 	Risk             *RiskDecision     `json:"risk,omitempty"`
 	PositionStrategy *PositionStrategy `json:"position_strategy,omitempty"`
 	Order            *Order            `json:"order,omitempty"`
+	OrderEvents      []OrderEvent      `json:"order_events,omitempty"`  // 订单状态变更历史
+	OrderGroups      []OrderGroup      `json:"order_groups,omitempty"`  // OCO / 止盈止损 / TWAP 等多腿订单
+	CloseOrigins     []CloseOrigin     `json:"close_origins,omitempty"` // 平仓订单对应的开仓来源
+	Tags             []CycleTag        `json:"tags,omitempty"`
 	Logs             []CycleLog        `json:"logs,omitempty"`
 }
 
@@ -111,6 +223,32 @@ type CycleResult struct {
 	Logs   []CycleLog   `json:"logs,omitempty"`
 }
 
+// CyclePreview 模拟周期结果：跑完行情、信号、风控、建仓策略，但不下单、不落库
+type CyclePreview struct {
+	PreviewID string            `json:"preview_id"`
+	Pair      string            `json:"pair"`
+	Preset    string            `json:"preset"`
+	Snapshot  MarketSnapshot    `json:"snapshot"`
+	Alerts    []string          `json:"alerts,omitempty"`
+	Signal    Signal            `json:"signal"`
+	Risk      RiskDecision      `json:"risk"`
+	Position  *PositionStrategy `json:"position,omitempty"`
+	Order     *PlannedOrder     `json:"order,omitempty"` // 风控通过时将要提交的订单
+	Notes     []string          `json:"notes,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// PlannedOrder 预览中计划提交的订单
+type PlannedOrder struct {
+	Side           Side    `json:"side"`
+	TradingMode    string  `json:"trading_mode"`
+	StakeUSDT      float64 `json:"stake_usdt"`
+	SellQuantity   float64 `json:"sell_quantity,omitempty"`
+	EstimatedPrice float64 `json:"estimated_price"`
+	Leverage       int     `json:"leverage,omitempty"`
+	DryRun         bool    `json:"dry_run"`
+}
+
 // CycleSummary 周期列表摘要视图（用于分页列表展示）
 type CycleSummary struct {
 	CycleID      string      `json:"cycle_id"`
@@ -127,19 +265,61 @@ type CycleSummary struct {
 	FilledPrice  float64     `json:"filled_price,omitempty"`
 	OrderStatus  string      `json:"order_status,omitempty"`
 	ErrorMessage string      `json:"error_message,omitempty"`
+	ReasonCode   ReasonCode  `json:"reason_code,omitempty"`
+	Tags         []string    `json:"tags,omitempty"`
+	Preset       string      `json:"preset,omitempty"`
+	Type         CycleType   `json:"type"`
 	CreatedAt    time.Time   `json:"created_at"`
 }
 
-// Holding 当前持仓快照（按币对聚合）
+// 合约持仓方向：现货与合约多头为 LONG；SHORT 为交易所同步的空头（本系统只做多，不对空头下单）
+const (
+	PositionSideLong  = "LONG"
+	PositionSideShort = "SHORT"
+)
+
+// Holding 当前持仓快照（按币对与持仓方向聚合）
 type Holding struct {
-	ID        int64     `json:"id"`
-	Pair      string    `json:"pair"`       // 如 DOGE/USDT
-	Symbol    string    `json:"symbol"`     // 如 DOGE
-	Quantity  float64   `json:"quantity"`   // 当前持有数量
-	AvgPrice  float64   `json:"avg_price"`  // 平均买入价格
-	TotalCost float64   `json:"total_cost"` // 总成本 (USDT)
-	Source    string    `json:"source"`     // "local"=订单聚合, "exchange"=交易所同步
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           int64     `json:"id"`
+	Pair         string    `json:"pair"`          // 如 DOGE/USDT
+	PositionSide string    `json:"position_side"` // LONG / SHORT，空值按 LONG 处理
+	Symbol       string    `json:"symbol"`        // 如 DOGE
+	Quantity     float64   `json:"quantity"`      // 当前持有数量
+	AvgPrice     float64   `json:"avg_price"`     // 平均买入价格
+	TotalCost    float64   `json:"total_cost"`    // 总成本 (USDT)
+	LastPrice    float64   `json:"last_price"`    // 最近一次获取的市价（用于排序）
+	Source       string    `json:"source"`        // "local"=订单聚合, "exchange"=交易所同步, "import"=冷启动导入
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// 冷启动导入时单个币种的核对结果
+const (
+	ImportMatched   = "matched"    // 成交回放数量与交易所余额一致
+	ImportPartial   = "partial"    // 成交历史不完整（充值、转账、超出可查范围），按回放均价估算剩余成本
+	ImportNoHistory = "no_history" // 没有可用的成交记录，成本记为 0
+	ImportFailed    = "failed"     // 查询成交记录失败
+)
+
+// PositionImport 冷启动导入的单个币种：交易所余额与成交回放的对比
+type PositionImport struct {
+	Pair             string  `json:"pair"`
+	Symbol           string  `json:"symbol"`
+	ExchangeQty      float64 `json:"exchange_qty"`      // 交易所余额
+	ReconstructedQty float64 `json:"reconstructed_qty"` // 成交回放得到的数量
+	AvgPrice         float64 `json:"avg_price"`
+	TotalCost        float64 `json:"total_cost"` // 按交易所余额计的成本
+	Trades           int     `json:"trades"`     // 查到的成交笔数
+	ImportedOrders   int     `json:"imported_orders"`
+	Truncated        bool    `json:"truncated,omitempty"` // 成交笔数达到单次查询上限，更早的成交未包含
+	Status           string  `json:"status"`
+	Note             string  `json:"note,omitempty"`
+}
+
+// PositionImportResult 冷启动导入结果
+type PositionImportResult struct {
+	DryRun         bool             `json:"dry_run"`
+	Positions      []PositionImport `json:"positions"`
+	ImportedOrders int              `json:"imported_orders"`
 }
 
 // HoldingView 持仓展示视图（附实时行情数据）
@@ -149,6 +329,86 @@ type HoldingView struct {
 	MarketValue   float64 `json:"market_value"`   // 市值 = 数量 × 当前价
 	UnrealizedPnL float64 `json:"unrealized_pnl"` // 未实现盈亏 = 市值 - 成本
 	PnLPercent    float64 `json:"pnl_percent"`    // 盈亏百分比
+
+	Mode    string           `json:"mode"`              // spot / futures
+	Futures *FuturesPosition `json:"futures,omitempty"` // 合约持仓详情，现货为空
+}
+
+// FuturesPosition 合约持仓详情（实盘来自 positionRisk，模拟盘按本地持仓估算）
+type FuturesPosition struct {
+	EntryPrice       float64 `json:"entry_price"`
+	MarkPrice        float64 `json:"mark_price"`
+	LiquidationPrice float64 `json:"liquidation_price"`   // 0 表示无强平风险（如保证金充足的全仓）
+	Margin           float64 `json:"margin"`              // 占用保证金（USDT）
+	Leverage         int     `json:"leverage"`            // 杠杆倍数
+	MarginType       string  `json:"margin_type"`         // cross / isolated
+	PositionSide     string  `json:"position_side"`       // BOTH（单向持仓）/ LONG（双向持仓），模拟盘为空
+	Notional         float64 `json:"notional"`            // 名义价值 = 数量 × 标记价格
+	UnrealizedPnL    float64 `json:"unrealized_pnl"`      // 未实现盈亏（USDT）
+	ROE              float64 `json:"roe"`                 // 保证金收益率 %
+	MarginRatio      float64 `json:"margin_ratio"`        // 保证金率 % = 维持保证金 / 保证金余额，达到 100% 时强平
+	LiqDistancePct   float64 `json:"liq_distance_pct"`    // 标记价距强平价的百分比，0 表示无强平价
+	FundingPeriods   int     `json:"funding_periods"`     // 开仓以来已结算的资金费率期数
+	FundingCostUSDT  float64 `json:"funding_cost_usdt"`   // 开仓以来累计资金费（USDT），正数表示多仓支付，负数表示收取
+	FundingCostPct   float64 `json:"funding_cost_pct"`    // 累计资金费占开仓名义价值的百分比
+	Estimated        bool    `json:"estimated,omitempty"` // 本地估算（模拟盘）
+}
+
+// MarginStatus 现货杠杆账户风险率（总资产 / 总负债），低于交易所平仓线时会被强制平仓
+type MarginStatus struct {
+	Pair              string  `json:"pair,omitempty"`  // 逐仓为对应交易对，全仓为空
+	MarginType        string  `json:"margin_type"`     // cross / isolated
+	Leverage          int     `json:"leverage"`        // 开仓杠杆倍数
+	MarginLevel       float64 `json:"margin_level"`    // 风险率，无借款时交易所返回 999
+	TotalAssetBTC     float64 `json:"total_asset_btc"` // 全仓总资产（BTC 计价）
+	TotalLiabilityBTC float64 `json:"total_liability_btc"`
+	MinLevel          float64 `json:"min_level"`           // 配置的预警线，0 = 不监控
+	Low               bool    `json:"low"`                 // 风险率低于预警线，暂停开仓
+	Estimated         bool    `json:"estimated,omitempty"` // 模拟盘未配置 API Key，无真实数据
+}
+
+// SlippageStats 一组订单的滑点统计，滑点以基点（bps）计，正数表示成交价比预估价差
+type SlippageStats struct {
+	Pair         string  `json:"pair,omitempty"`
+	Bucket       string  `json:"bucket,omitempty"`        // 成交金额区间，如 "50-200"
+	FillStrategy string  `json:"fill_strategy,omitempty"` // 成交方式，见 FillMarket 等
+	Orders       int     `json:"orders"`
+	AvgBps       float64 `json:"avg_bps"`
+	MedianBps    float64 `json:"median_bps"`
+	P90Bps       float64 `json:"p90_bps"`
+	WorstBps     float64 `json:"worst_bps"`
+	CostUSDT     float64 `json:"cost_usdt"` // 滑点造成的累计成本（负数为价格改善）
+}
+
+// ExecutionQualityReport 订单执行质量报告：决策时预估价与实际成交价的偏差
+type ExecutionQualityReport struct {
+	Since        time.Time       `json:"since"`
+	Simulated    bool            `json:"simulated"` // 是否包含模拟盘订单
+	Overall      SlippageStats   `json:"overall"`
+	ByPair       []SlippageStats `json:"by_pair"`
+	ByBucket     []SlippageStats `json:"by_bucket"`
+	ByPairBucket []SlippageStats `json:"by_pair_bucket"`
+	ByFill       []SlippageStats `json:"by_fill_strategy"` // 市价 / 限价等成交方式对比
+}
+
+// ErrInvalidSort 列表排序字段不受支持
+var ErrInvalidSort = errors.New("不支持的排序字段")
+
+// ListQuery 列表分页与排序参数
+type ListQuery struct {
+	Page     int
+	PageSize int
+	Sort     string // 排序字段，空表示默认排序
+	Desc     bool
+	Tag      string // 按周期标签过滤，空表示不过滤
+}
+
+// Offset 返回 SQL OFFSET
+func (q ListQuery) Offset() int {
+	if q.Page < 1 {
+		return 0
+	}
+	return (q.Page - 1) * q.PageSize
 }
 
 // PositionView 是订单的聚合视图，用于展示当前仓位。
@@ -165,5 +425,636 @@ type PositionView struct {
 	SignalReason    string    `json:"signal_reason,omitempty"`
 	Confidence      float64   `json:"confidence"`
 	CycleStatus     string    `json:"cycle_status"`
+	RealizedPnL     float64   `json:"realized_pnl"` // 已实现盈亏：平仓单为本次平仓盈亏，开仓单为该批次已兑现的盈亏
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// DailyPnL 按交易日汇总的已实现盈亏（交易日按配置时区划分）
+type DailyPnL struct {
+	Date            string  `json:"date"` // 交易日，如 2024-05-01
+	RealizedPnLUSDT float64 `json:"realized_pnl_usdt"`
+	BuyVolumeUSDT   float64 `json:"buy_volume_usdt"`
+	SellVolumeUSDT  float64 `json:"sell_volume_usdt"`
+	Trades          int     `json:"trades"`
+}
+
+// OutcomeHeatCell 按星期几 × 小时汇总的周期结果，用于发现不同时段（如美盘 / 亚盘）的表现差异
+type OutcomeHeatCell struct {
+	Weekday         int     `json:"weekday"` // 0 = 周日
+	Hour            int     `json:"hour"`    // 0-23
+	Cycles          int     `json:"cycles"`
+	Success         int     `json:"success"`
+	Rejected        int     `json:"rejected"`
+	Failed          int     `json:"failed"`
+	Closes          int     `json:"closes"` // 匹配到成本的平仓笔数
+	Wins            int     `json:"wins"`
+	WinRate         float64 `json:"win_rate"` // 盈利平仓占比（%）
+	RealizedPnLUSDT float64 `json:"realized_pnl_usdt"`
+	AvgPnLUSDT      float64 `json:"avg_pnl_usdt"` // 每笔平仓的平均盈亏
+}
+
+// RiskPreset 风险偏好预设：一次性切换下单上限、置信度门槛、杠杆、止盈止损与冷却时间
+type RiskPreset struct {
+	Name               string  `json:"name"`
+	MaxSingleStakeUSDT float64 `json:"max_single_stake_usdt"`
+	MaxDailyLossUSDT   float64 `json:"max_daily_loss_usdt"`
+	MaxExposureUSDT    float64 `json:"max_exposure_usdt"`
+	MinConfidence      float64 `json:"min_confidence"`
+	Leverage           int     `json:"leverage"`            // 仅合约模式生效
+	TakeProfitPercent  float64 `json:"take_profit_percent"` // 0 表示按建仓策略默认值
+	StopLossPercent    float64 `json:"stop_loss_percent"`   // 0 表示按建仓策略默认值
+	CooldownSec        int     `json:"cooldown_sec"`        // 同一币对两次开仓的最小间隔
+}
+
+// 保护单类型
+const (
+	ProtectiveStopLoss   = "stop_loss"
+	ProtectiveTakeProfit = "take_profit"
+)
+
+// 保护单状态
+const (
+	ProtectiveActive    = "active"
+	ProtectiveTriggered = "triggered"
+	ProtectiveCancelled = "cancelled"
+)
+
+// ProtectiveOrder 开仓后挂在交易所的止盈 / 止损保护单。
+// 合约为 STOP_MARKET / TAKE_PROFIT_MARKET（closePosition），现货为 OCO 的两条腿；同一次挂单的各条腿共享 GroupID
+type ProtectiveOrder struct {
+	ID              string    `json:"id"`
+	CycleID         string    `json:"cycle_id"`
+	Pair            string    `json:"pair"`
+	Kind            string    `json:"kind"` // stop_loss / take_profit
+	GroupID         string    `json:"group_id"`
+	ExchangeOrderID string    `json:"exchange_order_id"`
+	TriggerPrice    float64   `json:"trigger_price"`
+	Quantity        float64   `json:"quantity,omitempty"` // 合约 closePosition 时为 0
+	Status          string    `json:"status"`             // active / triggered / cancelled
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// OpenOrder 交易所上未完成的挂单（限价单、保护单等），用于排查和手动撤销卡住的订单
+type OpenOrder struct {
+	Pair            string    `json:"pair"`
+	Mode            string    `json:"mode"` // spot / futures
+	ExchangeOrderID string    `json:"exchange_order_id"`
+	ClientOrderID   string    `json:"client_order_id,omitempty"`
+	Side            string    `json:"side"` // buy / sell
+	Type            string    `json:"type"` // limit / stop_market / take_profit_market ...
+	Price           float64   `json:"price"`
+	StopPrice       float64   `json:"stop_price,omitempty"`
+	OrigQty         float64   `json:"orig_qty"` // 合约 closePosition 条件单为 0
+	ExecutedQty     float64   `json:"executed_qty"`
+	Status          string    `json:"status"`
+	ClosePosition   bool      `json:"close_position,omitempty"`
+	ProtectiveKind  string    `json:"protective_kind,omitempty"` // 本系统挂出的保护单：stop_loss / take_profit
 	CreatedAt       time.Time `json:"created_at"`
 }
+
+// 订单组类型
+const (
+	OrderGroupOCO  = "oco"   // 现货 OCO：任一腿成交后交易所自动撤销另一腿
+	OrderGroupTPSL = "tp_sl" // 合约独立挂出的止盈 / 止损条件单
+	OrderGroupTWAP = "twap"  // 按时间拆分执行的子订单
+)
+
+// 订单组状态
+const (
+	OrderGroupActive    = "active"
+	OrderGroupDone      = "done"      // 某条腿触发（OCO / 止盈止损）或全部子单执行完（TWAP）
+	OrderGroupCancelled = "cancelled" // 全部腿已撤销
+)
+
+// OrderGroup 多腿执行的订单组：父订单（通常是开仓单）下挂的 OCO 腿、止盈止损对或 TWAP 子单，
+// 周期报告按组展示，而不是若干条互不相关的订单
+type OrderGroup struct {
+	ID             string          `json:"id"`
+	CycleID        string          `json:"cycle_id"`
+	Pair           string          `json:"pair"`
+	Kind           string          `json:"kind"` // oco / tp_sl / twap
+	ParentOrderID  string          `json:"parent_order_id,omitempty"`
+	ExchangeListID string          `json:"exchange_list_id,omitempty"` // Binance orderListId（仅 OCO）
+	Status         string          `json:"status"`
+	Legs           []OrderGroupLeg `json:"legs"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// OrderGroupLeg 订单组中的一条腿，RefID 指向本地的保护单或订单记录
+type OrderGroupLeg struct {
+	LegNo           int       `json:"leg_no"`
+	Role            string    `json:"role"` // stop_loss / take_profit / child
+	RefID           string    `json:"ref_id,omitempty"`
+	ExchangeOrderID string    `json:"exchange_order_id,omitempty"`
+	Price           float64   `json:"price"`
+	Quantity        float64   `json:"quantity,omitempty"`
+	Status          string    `json:"status"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ShadowCycle 影子周期：只模拟不下单的交易对按计划跑出的假设交易及其事后结果
+type ShadowCycle struct {
+	ID                string          `json:"id"`
+	Pair              string          `json:"pair"`
+	Side              Side            `json:"side"`
+	Confidence        float64         `json:"confidence"`
+	Approved          bool            `json:"approved"`
+	RejectReason      string          `json:"reject_reason,omitempty"`
+	StakeUSDT         float64         `json:"stake_usdt"`
+	EntryPrice        float64         `json:"entry_price"`
+	Leverage          int             `json:"leverage,omitempty"`
+	TakeProfitPercent float64         `json:"take_profit_percent,omitempty"`
+	StopLossPercent   float64         `json:"stop_loss_percent,omitempty"`
+	Reason            string          `json:"reason"`
+	Preview           json.RawMessage `json:"preview,omitempty"` // 完整预览结果（仅详情返回）
+	CreatedAt         time.Time       `json:"created_at"`
+
+	// 事后评估：观察期结束时的价格与假设盈亏
+	EvaluatedAt    *time.Time `json:"evaluated_at,omitempty"`
+	ExitPrice      float64    `json:"exit_price,omitempty"`
+	PriceChangePct float64    `json:"price_change_pct,omitempty"`
+	PnLUSDT        float64    `json:"pnl_usdt,omitempty"` // 仅风控通过的开仓/平仓信号计算
+}
+
+// ShadowStats 某交易对影子周期汇总，用于判断是否值得开启实盘
+type ShadowStats struct {
+	Pair         string  `json:"pair"`
+	Total        int     `json:"total"`
+	Approved     int     `json:"approved"`
+	Evaluated    int     `json:"evaluated"`
+	Wins         int     `json:"wins"`
+	WinRate      float64 `json:"win_rate"`
+	TotalPnLUSDT float64 `json:"total_pnl_usdt"`
+}
+
+// PaperWallet 模拟盘钱包：DRY_RUN 下模拟成交按手续费模型扣款 / 入账，替代交易所余额
+type PaperWallet struct {
+	InitialUSDT float64   `json:"initial_usdt"`
+	CashUSDT    float64   `json:"cash_usdt"`    // 可用 USDT
+	MarginUSDT  float64   `json:"margin_usdt"`  // 合约 / 杠杆持仓占用的保证金
+	FeesUSDT    float64   `json:"fees_usdt"`    // 累计手续费
+	RealizedPnL float64   `json:"realized_pnl"` // 累计已实现盈亏（不含手续费）
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PaperLedgerEntry 模拟盘钱包的一笔资金变动
+type PaperLedgerEntry struct {
+	ID           int64     `json:"id"`
+	OrderID      string    `json:"order_id"`
+	Pair         string    `json:"pair"`
+	Side         Side      `json:"side"`
+	Price        float64   `json:"price"`
+	Quantity     float64   `json:"quantity"`
+	NotionalUSDT float64   `json:"notional_usdt"`
+	FeeUSDT      float64   `json:"fee_usdt"`
+	CashDelta    float64   `json:"cash_delta"`   // 可用 USDT 变动（含手续费）
+	MarginDelta  float64   `json:"margin_delta"` // 占用保证金变动
+	RealizedPnL  float64   `json:"realized_pnl"`
+	CashAfter    float64   `json:"cash_after"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// PaperAccount 模拟盘账户概况
+type PaperAccount struct {
+	PaperWallet
+	EquityUSDT float64            `json:"equity_usdt"` // 可用 + 保证金 + 持仓市值（合约只计未实现盈亏）
+	ReturnPct  float64            `json:"return_pct"`
+	FeeRate    float64            `json:"fee_rate"`
+	Ledger     []PaperLedgerEntry `json:"ledger"` // 最近的资金变动，新的在前
+}
+
+// Sandbox 沙盒会话：拥有独立虚拟钱包与持仓的模拟交易实验，与实盘 / 模拟盘记录互不影响
+type Sandbox struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	InitialUSDT float64   `json:"initial_usdt"`
+	Leverage    int       `json:"leverage,omitempty"` // 模拟成交的杠杆倍数，0 / 1 = 不加杠杆
+	Model       string    `json:"model,omitempty"`    // 信号模型覆盖，为空使用默认模型
+	Pairs       string    `json:"pairs,omitempty"`    // 定时运行的交易对（逗号分隔），为空只能手动运行
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SandboxTrade 沙盒内的一笔模拟成交
+type SandboxTrade struct {
+	ID          string    `json:"id"`
+	SandboxID   string    `json:"sandbox_id"`
+	PreviewID   string    `json:"preview_id"` // 产生该成交的预览周期
+	Pair        string    `json:"pair"`
+	Side        Side      `json:"side"`
+	Price       float64   `json:"price"`
+	Quantity    float64   `json:"quantity"`
+	StakeUSDT   float64   `json:"stake_usdt"` // 开仓占用 / 平仓释放的保证金
+	FeeUSDT     float64   `json:"fee_usdt"`
+	RealizedPnL float64   `json:"realized_pnl"` // 平仓已实现盈亏（不含手续费）
+	Confidence  float64   `json:"confidence"`
+	Reason      string    `json:"reason"`
+	ModelName   string    `json:"model_name,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SandboxPosition 沙盒持仓（按成交回放）
+type SandboxPosition struct {
+	Pair          string  `json:"pair"`
+	Quantity      float64 `json:"quantity"`
+	AvgPrice      float64 `json:"avg_price"`
+	MarginUSDT    float64 `json:"margin_usdt"`
+	CurrentPrice  float64 `json:"current_price,omitempty"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+}
+
+// SandboxSummary 沙盒钱包与持仓概况
+type SandboxSummary struct {
+	Sandbox
+	CashUSDT      float64           `json:"cash_usdt"`
+	EquityUSDT    float64           `json:"equity_usdt"`
+	RealizedPnL   float64           `json:"realized_pnl"`
+	UnrealizedPnL float64           `json:"unrealized_pnl"`
+	FeesUSDT      float64           `json:"fees_usdt"`
+	ReturnPct     float64           `json:"return_pct"`
+	Trades        int               `json:"trades"`
+	Positions     []SandboxPosition `json:"positions"`
+}
+
+// SandboxRun 沙盒运行一次周期的结果：完整预览及按预览模拟的成交（无成交时为 nil）
+type SandboxRun struct {
+	Preview CyclePreview  `json:"preview"`
+	Trade   *SandboxTrade `json:"trade,omitempty"`
+	Note    string        `json:"note,omitempty"`
+}
+
+// FillOrigin 已成交订单及其所属周期的策略，用于平仓归因
+type FillOrigin struct {
+	Order
+	Strategy      string // 开仓周期使用的交易策略
+	EntryStrategy string // 建仓策略（full / pyramid / grid）
+}
+
+// CloseOrigin 平仓订单与其卖出的开仓批次的关联：按成本核算方法（先进先出 / 加权平均）匹配，
+// 来源未知的数量（交易所同步、导入的持仓）记为空 EntryOrderID
+type CloseOrigin struct {
+	ID            int64     `json:"id"`
+	CloseOrderID  string    `json:"close_order_id"`
+	CloseCycleID  string    `json:"close_cycle_id,omitempty"`
+	Pair          string    `json:"pair"`
+	EntryOrderID  string    `json:"entry_order_id,omitempty"`
+	EntryCycleID  string    `json:"entry_cycle_id,omitempty"`
+	Strategy      string    `json:"strategy,omitempty"`
+	EntryStrategy string    `json:"entry_strategy,omitempty"`
+	Quantity      float64   `json:"quantity"`
+	EntryPrice    float64   `json:"entry_price,omitempty"`
+	ExitPrice     float64   `json:"exit_price"`
+	RealizedPnL   float64   `json:"realized_pnl"` // 按该批次成本计算，不含手续费
+	EntryAt       time.Time `json:"entry_at,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TrailingStop 持仓的移动止损状态：最高价只升不降，止损价跟随最高价上移，价格回落到止损价时平仓
+type TrailingStop struct {
+	Pair      string    `json:"pair"`
+	HighWater float64   `json:"high_water"` // 跟踪以来的最高价
+	StopPrice float64   `json:"stop_price"` // 当前止损价（只上移）
+	Distance  float64   `json:"distance"`   // 最近一次计算的回撤距离（价格单位）
+	LastPrice float64   `json:"last_price"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// 分享链接可公开的看板
+const (
+	ShareEquity   = "equity"   // 收益率曲线（按首个权益快照归一化，不含金额）
+	ShareCycles   = "cycles"   // 最近周期的决策
+	ShareHoldings = "holdings" // 持仓交易对与盈亏比例（不含数量、成本与余额）
+)
+
+// ShareLink 只读分享链接：令牌只在创建时返回一次，库中只保存 SHA-256
+type ShareLink struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Dashboards []string   `json:"dashboards"`
+	TokenHash  string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// SharedEquityPoint 分享看板的收益率曲线点
+type SharedEquityPoint struct {
+	Time      time.Time `json:"time"`
+	ReturnPct float64   `json:"return_pct"`
+}
+
+// SharedCycle 分享看板的周期决策（不含金额、模型与错误信息）
+type SharedCycle struct {
+	Pair       string      `json:"pair"`
+	Status     CycleStatus `json:"status"`
+	SignalSide Side        `json:"signal_side"`
+	Confidence float64     `json:"confidence"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// SharedHolding 分享看板的持仓（只有交易对、盈亏比例与仓位占比）
+type SharedHolding struct {
+	Pair       string  `json:"pair"`
+	Mode       string  `json:"mode"`
+	PnLPercent float64 `json:"pnl_percent"`
+	WeightPct  float64 `json:"weight_pct"` // 占全部持仓市值的比例
+}
+
+// SharedDashboard 分享链接返回的只读看板，未选中的部分为空
+type SharedDashboard struct {
+	Name      string              `json:"name"`
+	ExpiresAt time.Time           `json:"expires_at"`
+	Equity    []SharedEquityPoint `json:"equity,omitempty"`
+	Cycles    []SharedCycle       `json:"cycles,omitempty"`
+	Holdings  []SharedHolding     `json:"holdings,omitempty"`
+}
+
+// OrderEvent 订单状态变更记录（只追加，不修改）
+type OrderEvent struct {
+	ID          int64     `json:"id"`
+	OrderID     string    `json:"order_id"`
+	CycleID     string    `json:"cycle_id"`
+	Status      string    `json:"status"`
+	FilledQty   float64   `json:"filled_qty"`
+	FilledPrice float64   `json:"filled_price"`
+	Detail      string    `json:"detail,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AuditEntry 一次状态变更操作的审计记录（只追加，清空数据时保留）
+type AuditEntry struct {
+	ID            int64     `json:"id"`
+	Actor         string    `json:"actor"`  // ui / key:<名称>
+	Action        string    `json:"action"` // 方法 + 路由模板，如 POST /api/v1/data/reset
+	Path          string    `json:"path"`
+	Params        string    `json:"params,omitempty"`         // 路径参数，如 id=xxx
+	PayloadSHA256 string    `json:"payload_sha256,omitempty"` // 请求体摘要，空请求体为空
+	Status        int       `json:"status"`                   // 响应状态码
+	RequestID     string    `json:"request_id,omitempty"`
+	ClientIP      string    `json:"client_ip,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PairCandidate 交易对筛选给出的候选
+type PairCandidate struct {
+	Pair            string   `json:"pair"`
+	LastPrice       float64  `json:"last_price"`
+	Change24hPct    float64  `json:"change_24h_pct"`
+	RangePct        float64  `json:"range_pct"` // 24h 振幅 (最高-最低)/最低
+	QuoteVolumeUSDT float64  `json:"quote_volume_usdt"`
+	TrendingRank    int      `json:"trending_rank,omitempty"` // CoinGecko 热门排名，0 = 不在榜
+	Score           float64  `json:"score"`
+	Reasons         []string `json:"reasons"`
+	ShadowAdded     bool     `json:"shadow_added,omitempty"` // 本次筛选已自动加入影子周期
+}
+
+// ScreenerResult 一次交易对筛选的结果
+type ScreenerResult struct {
+	ScannedAt  time.Time       `json:"scanned_at"`
+	Scanned    int             `json:"scanned"` // 参与筛选的交易对数
+	Candidates []PairCandidate `json:"candidates"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// SchedulerState 定时自动交易的运行时配置（通过 API 修改后持久化，重启后沿用）
+type SchedulerState struct {
+	Paused      bool       `json:"paused"`
+	IntervalSec int        `json:"interval_sec"`
+	Pairs       []string   `json:"pairs"`
+	LastRunAt   *time.Time `json:"last_run_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// LLMCost 单个信号的大模型用量与估算成本
+type LLMCost struct {
+	CycleID          string    `json:"cycle_id"`
+	Pair             string    `json:"pair"`
+	ModelName        string    `json:"model_name"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// CostBucket 按交易日或模型汇总的大模型成本
+type CostBucket struct {
+	Key              string  `json:"key"` // 交易日（2024-05-01）或模型名
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// CostSummary 大模型成本汇总与预算使用情况（上限为 0 表示不限制）
+type CostSummary struct {
+	TodayUSD      float64      `json:"today_usd"`
+	MonthUSD      float64      `json:"month_usd"`
+	DailyLimit    float64      `json:"daily_limit_usd"`
+	MonthlyLimit  float64      `json:"monthly_limit_usd"`
+	CycleLimit    float64      `json:"cycle_limit_usd"`
+	BudgetBlocked bool         `json:"budget_blocked"` // 已达每日或每月上限，后续周期跳过大模型
+	Days          []CostBucket `json:"days"`
+	Models        []CostBucket `json:"models"`
+}
+
+// BehaviorStats 一组大模型信号的输出分布
+type BehaviorStats struct {
+	Signals       int              `json:"signals"`
+	SideShare     map[Side]float64 `json:"side_share"` // 各方向信号占比 %
+	AvgConfidence float64          `json:"avg_confidence"`
+}
+
+// ModelBehavior 单个模型最近信号与此前基线的分布对比，分布突变通常意味着提示词回归或服务商更换了模型
+type ModelBehavior struct {
+	ModelName string        `json:"model_name"`
+	Recent    BehaviorStats `json:"recent"`
+	Baseline  BehaviorStats `json:"baseline"`
+	Anomalies []string      `json:"anomalies,omitempty"` // 超过阈值的变化，空 = 正常或样本不足
+}
+
+// CapitalAllocation 多交易对资金分配的当前状态
+type CapitalAllocation struct {
+	Enabled          bool               `json:"enabled"`
+	BudgetUSDT       float64            `json:"budget_usdt"`
+	Weighting        string             `json:"weighting"`
+	OpenExposureUSDT float64            `json:"open_exposure_usdt"`
+	Pairs            []CapitalPairShare `json:"pairs"`
+}
+
+// CapitalPairShare 单个交易对的预算份额与当前持仓
+type CapitalPairShare struct {
+	Pair         string  `json:"pair"`
+	Share        float64 `json:"share"`       // 0-1
+	TargetUSDT   float64 `json:"target_usdt"` // 预算 × 份额（置信度为 1 时的上限）
+	ExposureUSDT float64 `json:"exposure_usdt"`
+}
+
+// OutboxItem 写库失败、等待重试的记录
+type OutboxItem struct {
+	Desc      string    `json:"desc"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	QueuedAt  time.Time `json:"queued_at"`
+	Dead      bool      `json:"dead,omitempty"` // 重试次数用尽已移出队列，需人工补录
+}
+
+// 组合分配建议动作
+const (
+	AllocationBuy    = "buy"    // 可以加仓 / 开仓
+	AllocationHold   = "hold"   // 维持现状，由单币周期决定是否平仓
+	AllocationReduce = "reduce" // 倾向减仓 / 平仓
+	AllocationAvoid  = "avoid"  // 不开仓；无持仓时跳过该交易对的周期
+)
+
+// PairAllocation 组合分配计划中单个交易对的建议
+type PairAllocation struct {
+	Pair   string  `json:"pair"`
+	Rank   int     `json:"rank"`   // 1 为最优先
+	Action string  `json:"action"` // buy / hold / reduce / avoid
+	Weight float64 `json:"weight"` // 建议占最大敞口的比例（0-1），用于限制单币开仓金额
+	Reason string  `json:"reason,omitempty"`
+}
+
+// AllocationPlan 组合级大模型调用生成的分配计划，驱动随后各交易对的周期
+type AllocationPlan struct {
+	ID               string           `json:"id"`
+	Summary          string           `json:"summary"`
+	Allocations      []PairAllocation `json:"allocations"`
+	ModelName        string           `json:"model_name"`
+	PromptTokens     int              `json:"prompt_tokens"`
+	CompletionTokens int              `json:"completion_tokens"`
+	CostUSD          float64          `json:"cost_usd"`
+	CreatedAt        time.Time        `json:"created_at"`
+}
+
+// 人工审批状态
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+	ApprovalExpired  = "expired"
+)
+
+// CycleApproval 人工审批模式下等待确认的下单，周期在建仓策略之后暂停，审批通过后继续执行
+type CycleApproval struct {
+	CycleID     string     `json:"cycle_id"`
+	Pair        string     `json:"pair"`
+	Side        Side       `json:"side"`
+	StakeUSDT   float64    `json:"stake_usdt"` // 风控通过的下单金额（平仓为 0）
+	Leverage    int        `json:"leverage,omitempty"`
+	Reason      string     `json:"reason"`                 // 信号理由
+	Status      string     `json:"status"`                 // pending / approved / rejected / expired
+	RequestedBy string     `json:"requested_by,omitempty"` // 触发周期的调用方：scheduler / ui / key:<名称>
+	DecidedBy   string     `json:"decided_by,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+}
+
+// EquitySnapshot 账户权益快照（USDT 余额含活期理财 + 持仓市值），用于计算回撤
+type EquitySnapshot struct {
+	ID            int64     `json:"id"`
+	EquityUSDT    float64   `json:"equity_usdt"`
+	CashUSDT      float64   `json:"cash_usdt"`
+	PositionsUSDT float64   `json:"positions_usdt"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// EquityAnalytics 基于权益快照的收益分析：收益率、波动率、夏普比率、最大回撤与各交易对的盈亏贡献
+type EquityAnalytics struct {
+	Days            int                `json:"days"`
+	From            time.Time          `json:"from"`
+	To              time.Time          `json:"to"`
+	Snapshots       int                `json:"snapshots"`
+	StartEquityUSDT float64            `json:"start_equity_usdt"`
+	EndEquityUSDT   float64            `json:"end_equity_usdt"`
+	ReturnPct       float64            `json:"return_pct"`
+	VolatilityPct   float64            `json:"volatility_pct"` // 日收益率标准差年化（×√365）
+	SharpeRatio     float64            `json:"sharpe_ratio"`   // 日收益率均值 / 标准差 × √365，无风险利率按 0
+	MaxDrawdownPct  float64            `json:"max_drawdown_pct"`
+	Daily           []EquityDay        `json:"daily"`
+	Pairs           []PairContribution `json:"pairs"`
+}
+
+// EquityDay 交易日收盘（当日最后一个快照）权益与日收益率
+type EquityDay struct {
+	Date       string  `json:"date"`
+	EquityUSDT float64 `json:"equity_usdt"`
+	ReturnPct  float64 `json:"return_pct"`
+}
+
+// PairContribution 交易对在区间内的盈亏贡献：已实现盈亏 + 当前持仓浮动盈亏，占期初权益的百分比
+type PairContribution struct {
+	Pair              string  `json:"pair"`
+	RealizedPnLUSDT   float64 `json:"realized_pnl_usdt"`
+	UnrealizedPnLUSDT float64 `json:"unrealized_pnl_usdt"`
+	TotalPnLUSDT      float64 `json:"total_pnl_usdt"`
+	ContributionPct   float64 `json:"contribution_pct"`
+	Trades            int     `json:"trades"`
+}
+
+// 绩效报告周期
+const (
+	ReportDaily  = "daily"
+	ReportWeekly = "weekly"
+)
+
+// PerformanceReport 日报 / 周报：区间内的成交、已实现盈亏、胜率、手续费、大模型成本与值得关注的信号
+type PerformanceReport struct {
+	Period          string          `json:"period"`     // daily / weekly
+	StartDate       string          `json:"start_date"` // 首个交易日，如 2024-05-01
+	EndDate         string          `json:"end_date"`   // 最后一个交易日（含）
+	Cycles          int             `json:"cycles"`
+	CycleStatus     map[string]int  `json:"cycle_status"` // 按周期状态计数
+	Trades          int             `json:"trades"`
+	Entries         int             `json:"entries"`
+	Closes          int             `json:"closes"`
+	Wins            int             `json:"wins"`
+	Losses          int             `json:"losses"`
+	WinRatePct      float64         `json:"win_rate_pct"` // 盈利平仓笔数 / 有成本匹配的平仓笔数
+	RealizedPnLUSDT float64         `json:"realized_pnl_usdt"`
+	BestTradeUSDT   float64         `json:"best_trade_usdt"`
+	WorstTradeUSDT  float64         `json:"worst_trade_usdt"`
+	VolumeUSDT      float64         `json:"volume_usdt"`
+	FeesUSDT        float64         `json:"fees_usdt"` // 按成交额 × 手续费率估算
+	LLMCalls        int             `json:"llm_calls"`
+	LLMCostUSD      float64         `json:"llm_cost_usd"`
+	NetPnLUSDT      float64         `json:"net_pnl_usdt"` // 已实现盈亏 − 手续费
+	Pairs           []PairReport    `json:"pairs"`
+	NotableSignals  []NotableSignal `json:"notable_signals"`
+	GeneratedAt     time.Time       `json:"generated_at"`
+}
+
+// PairReport 报告区间内单个交易对的成交与已实现盈亏
+type PairReport struct {
+	Pair            string  `json:"pair"`
+	Trades          int     `json:"trades"`
+	RealizedPnLUSDT float64 `json:"realized_pnl_usdt"`
+}
+
+// NotableSignal 报告中值得关注的信号（区间内置信度最高的开仓 / 平仓信号）
+type NotableSignal struct {
+	CycleID     string    `json:"cycle_id"`
+	Pair        string    `json:"pair"`
+	Side        Side      `json:"side"`
+	Confidence  float64   `json:"confidence"`
+	Reason      string    `json:"reason"`
+	CycleStatus string    `json:"cycle_status"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DrawdownHalt 回撤熔断状态（单行表），触发后暂停新开仓，需手动恢复
+type DrawdownHalt struct {
+	Halted      bool       `json:"halted"`
+	Reason      string     `json:"reason,omitempty"`
+	DrawdownPct float64    `json:"drawdown_pct"`
+	PeakUSDT    float64    `json:"peak_usdt"`
+	EquityUSDT  float64    `json:"equity_usdt"`
+	HaltedAt    *time.Time `json:"halted_at,omitempty"`
+	ResumedAt   *time.Time `json:"resumed_at,omitempty"` // 手动恢复时间，之后的峰值从该时间起算
+}
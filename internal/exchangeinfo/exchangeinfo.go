@@ -0,0 +1,221 @@
+// Package exchangeinfo 缓存 Binance exchangeInfo 中的交易规则（PRICE_FILTER tickSize、
+// LOT_SIZE stepSize/minQty、最小名义价值），为提示词展示和下单提供按币种的真实精度。
+package exchangeinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai_quant/internal/ratelimit"
+)
+
+const (
+	cacheTTL   = 24 * time.Hour
+	retryAfter = 5 * time.Minute // 拉取失败后的重试间隔，避免每次调用都请求交易所
+)
+
+// Filters 交易对的下单规则，字段为 0 表示交易所未返回
+type Filters struct {
+	TickSize    float64 // PRICE_FILTER 价格步长
+	StepSize    float64 // LOT_SIZE 数量步长
+	MinQty      float64 // LOT_SIZE 最小数量
+	MinNotional float64 // NOTIONAL / MIN_NOTIONAL 最小名义价值（USDT）
+}
+
+// Cache 按交易对缓存交易规则
+type Cache struct {
+	client    *http.Client
+	url       string
+	perSymbol bool // 现货支持 ?symbol= 按币种查询；合约只能拉取全量
+
+	mu       sync.Mutex
+	filters  map[string]Filters
+	loadedAt map[string]time.Time
+	failedAt map[string]time.Time
+}
+
+// Spot 现货 exchangeInfo 缓存（公开接口，无需 API Key）
+var Spot = NewSpot("https://api.binance.com")
+
+// NewSpot 创建现货缓存
+func NewSpot(baseURL string) *Cache {
+	return newCache(strings.TrimRight(baseURL, "/")+"/api/v3/exchangeInfo", true)
+}
+
+// NewFutures 创建 USDT-M 合约缓存
+func NewFutures(baseURL string) *Cache {
+	return newCache(strings.TrimRight(baseURL, "/")+"/fapi/v1/exchangeInfo", false)
+}
+
+func newCache(url string, perSymbol bool) *Cache {
+	return &Cache{
+		client:    ratelimit.NewClient(10 * time.Second),
+		url:       url,
+		perSymbol: perSymbol,
+		filters:   make(map[string]Filters),
+		loadedAt:  make(map[string]time.Time),
+		failedAt:  make(map[string]time.Time),
+	}
+}
+
+// TickSize 返回交易对的价格步长，缓存缺失或过期时向交易所拉取；拿不到时返回 false
+func (c *Cache) TickSize(ctx context.Context, symbol string) (float64, bool) {
+	f, ok := c.Filters(ctx, symbol)
+	return f.TickSize, ok && f.TickSize > 0
+}
+
+// Filters 返回交易对的下单规则，缓存缺失或过期时向交易所拉取；拿不到时返回 false
+func (c *Cache) Filters(ctx context.Context, symbol string) (Filters, bool) {
+	symbol = strings.ToUpper(strings.ReplaceAll(symbol, "/", ""))
+	key := symbol
+	if !c.perSymbol {
+		key = "*"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, ok := c.filters[symbol]
+	fresh := time.Since(c.loadedAt[key]) < cacheTTL || time.Since(c.loadedAt["*"]) < cacheTTL
+	if (ok && fresh) || time.Since(c.failedAt[key]) < retryAfter {
+		return f, ok
+	}
+
+	loaded, err := c.load(ctx, symbol)
+	if err != nil {
+		c.failedAt[key] = time.Now()
+		log.Printf("[精度] ⚠ 获取 exchangeInfo 失败 %s: %v", symbol, err)
+		return f, ok // 过期的缓存仍比猜测准确
+	}
+	for s, v := range loaded {
+		c.filters[s] = v
+	}
+	c.loadedAt[key] = time.Now()
+	f, ok = c.filters[symbol]
+	return f, ok
+}
+
+// Preload 启动时一次性拉取全部交易对的规则，之后按 TTL 刷新
+func (c *Cache) Preload(ctx context.Context) error {
+	loaded, err := c.load(ctx, "")
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for s, v := range loaded {
+		c.filters[s] = v
+	}
+	c.loadedAt["*"] = time.Now()
+	log.Printf("[精度] 已加载 %d 个交易对的 exchangeInfo", len(loaded))
+	return nil
+}
+
+func (c *Cache) load(ctx context.Context, symbol string) (map[string]Filters, error) {
+	url := c.url
+	if c.perSymbol && symbol != "" {
+		url += "?symbol=" + symbol
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var info struct {
+		Symbols []struct {
+			Symbol  string `json:"symbol"`
+			Filters []struct {
+				FilterType  string `json:"filterType"`
+				TickSize    string `json:"tickSize"`
+				StepSize    string `json:"stepSize"`
+				MinQty      string `json:"minQty"`
+				MinNotional string `json:"minNotional"` // 现货
+				Notional    string `json:"notional"`    // 合约 MIN_NOTIONAL
+			} `json:"filters"`
+		} `json:"symbols"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("解析 exchangeInfo: %w", err)
+	}
+
+	out := make(map[string]Filters, len(info.Symbols))
+	for _, s := range info.Symbols {
+		var f Filters
+		for _, flt := range s.Filters {
+			switch flt.FilterType {
+			case "PRICE_FILTER":
+				f.TickSize = parsePositive(flt.TickSize)
+			case "LOT_SIZE":
+				f.StepSize = parsePositive(flt.StepSize)
+				f.MinQty = parsePositive(flt.MinQty)
+			case "NOTIONAL", "MIN_NOTIONAL":
+				if v := parsePositive(flt.MinNotional); v > 0 {
+					f.MinNotional = v
+				} else {
+					f.MinNotional = parsePositive(flt.Notional)
+				}
+			}
+		}
+		out[s.Symbol] = f
+	}
+	return out, nil
+}
+
+func parsePositive(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v <= 0 {
+		return 0
+	}
+	return v
+}
+
+// Decimals 返回步长对应的小数位数，如 0.00001 -> 5，1 -> 0
+func Decimals(step float64) int {
+	if step <= 0 || step >= 1 {
+		return 0
+	}
+	return int(math.Round(-math.Log10(step)))
+}
+
+// EstimateDecimals 没有 tickSize 时按价格量级估算小数位，保证至少 4 位有效数字（避免低价币显示为 0.0000）
+func EstimateDecimals(price float64) int {
+	price = math.Abs(price)
+	switch {
+	case price >= 1000:
+		return 2
+	case price >= 1:
+		return 4
+	case price <= 0:
+		return 4
+	}
+	// 小于 1：前导零个数 + 4 位有效数字
+	return int(math.Floor(-math.Log10(price))) + 4
+}
+
+// FloorToTick 按步长向下取整并格式化；tick 为 0 时按价格量级估算精度
+func FloorToTick(price, tick float64) string {
+	if tick <= 0 {
+		decimals := EstimateDecimals(price)
+		scale := math.Pow(10, float64(decimals))
+		return strconv.FormatFloat(math.Floor(price*scale)/scale, 'f', decimals, 64)
+	}
+	// 加一个极小量抵消浮点误差（如 0.3/0.1 = 2.9999999）
+	steps := math.Floor(price/tick + 1e-9)
+	return strconv.FormatFloat(steps*tick, 'f', Decimals(tick), 64)
+}
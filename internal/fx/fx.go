@@ -0,0 +1,159 @@
+// Package fx 缓存 USDT 对法币（CNY / EUR 等）的汇率，只用于报表展示；
+// 下单、风控与记账始终使用 USDT。
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ai_quant/internal/trace"
+)
+
+const (
+	priceURL   = "https://api.coingecko.com/api/v3/simple/price?ids=tether&vs_currencies=%s"
+	retryAfter = 5 * time.Minute // 拉取失败后的重试间隔
+)
+
+// Quote 一次汇率报价：1 USDT = Rate Currency
+type Quote struct {
+	Currency  string    `json:"currency"`
+	Rate      float64   `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Stale     bool      `json:"stale,omitempty"` // 刷新失败，使用的是过期汇率
+}
+
+// Convert 把 USDT 金额换算为展示币种，保留两位小数
+func (q Quote) Convert(usdt float64) float64 {
+	return math.Round(usdt*q.Rate*100) / 100
+}
+
+// Display 报表中的法币展示块
+type Display struct {
+	Quote
+	Values map[string]float64 `json:"values,omitempty"` // 与响应中 USDT 字段同名的换算结果
+}
+
+// Report 按报价换算一组 USDT 金额
+func (q Quote) Report(values map[string]float64) *Display {
+	d := &Display{Quote: q}
+	if len(values) > 0 {
+		d.Values = make(map[string]float64, len(values))
+		for k, v := range values {
+			d.Values[k] = q.Convert(v)
+		}
+	}
+	return d
+}
+
+// Converter 按 TTL 缓存 USDT/法币 汇率
+type Converter struct {
+	currency string
+	fixed    float64 // 固定汇率，> 0 时不请求外部接口
+	ttl      time.Duration
+	client   *http.Client
+
+	mu       sync.Mutex
+	quote    Quote
+	failedAt time.Time
+}
+
+// New 创建汇率缓存；currency 为空或 USDT 时返回 nil（不换算）
+func New(currency string, fixedRate float64, ttl time.Duration) (*Converter, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" || currency == "USDT" {
+		return nil, nil
+	}
+	if len(currency) != 3 {
+		return nil, fmt.Errorf("展示币种 %q 不是三位法币代码", currency)
+	}
+	if fixedRate < 0 {
+		return nil, fmt.Errorf("固定汇率不能为负数")
+	}
+	if ttl <= 0 {
+		ttl = 30 * time.Minute
+	}
+	return &Converter{
+		currency: currency,
+		fixed:    fixedRate,
+		ttl:      ttl,
+		client:   trace.NewClient(10 * time.Second),
+	}, nil
+}
+
+// Currency 展示币种，未启用时为 USDT
+func (c *Converter) Currency() string {
+	if c == nil {
+		return "USDT"
+	}
+	return c.currency
+}
+
+// Quote 返回当前汇率，缓存过期时刷新；刷新失败时返回过期汇率并标记 Stale，从未成功过时返回错误
+func (c *Converter) Quote(ctx context.Context) (Quote, error) {
+	if c == nil {
+		return Quote{Currency: "USDT", Rate: 1}, nil
+	}
+	if c.fixed > 0 {
+		return Quote{Currency: c.currency, Rate: c.fixed}, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q := c.quote
+	if q.Rate > 0 && time.Since(q.UpdatedAt) < c.ttl {
+		return q, nil
+	}
+	if time.Since(c.failedAt) < retryAfter {
+		return c.staleQuote()
+	}
+
+	rate, err := c.fetch(ctx)
+	if err != nil {
+		c.failedAt = time.Now()
+		log.Printf("[汇率] ⚠ 获取 USDT/%s 汇率失败: %v", c.currency, err)
+		return c.staleQuote()
+	}
+	c.quote = Quote{Currency: c.currency, Rate: rate, UpdatedAt: time.Now().UTC()}
+	return c.quote, nil
+}
+
+func (c *Converter) staleQuote() (Quote, error) {
+	if c.quote.Rate <= 0 {
+		return Quote{}, fmt.Errorf("USDT/%s 汇率暂不可用", c.currency)
+	}
+	q := c.quote
+	q.Stale = true
+	return q, nil
+}
+
+func (c *Converter) fetch(ctx context.Context) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(priceURL, strings.ToLower(c.currency)), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var body map[string]map[string]float64
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, err
+	}
+	rate := body["tether"][strings.ToLower(c.currency)]
+	if rate <= 0 {
+		return 0, fmt.Errorf("不支持的展示币种 %s", c.currency)
+	}
+	return rate, nil
+}
@@ -0,0 +1,201 @@
+package httpapi
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader 调用方通过该请求头（或 Authorization: Bearer）传入 API Key
+const APIKeyHeader = "X-API-Key"
+
+// Scope 接口权限等级，高等级包含低等级的全部权限
+type Scope int
+
+const (
+	ScopeRead  Scope = iota + 1 // 只读：所有 GET 接口
+	ScopeTrade                  // 交易：运行周期、平仓、调杠杆、同步持仓等写操作
+	ScopeAdmin                  // 管理：清空数据、删除周期、OAuth / LLM 认证管理
+)
+
+func (s Scope) String() string {
+	switch s {
+	case ScopeRead:
+		return "read"
+	case ScopeTrade:
+		return "trade"
+	case ScopeAdmin:
+		return "admin"
+	}
+	return "none"
+}
+
+func parseScope(s string) (Scope, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "read":
+		return ScopeRead, true
+	case "trade":
+		return ScopeTrade, true
+	case "admin":
+		return ScopeAdmin, true
+	}
+	return 0, false
+}
+
+// adminRoutes 需要 admin 权限的路由（method + gin 路由模板）
+var adminRoutes = map[string]bool{
+	"POST /api/v1/data/reset":               true,
+	"POST /api/v1/risk/resume":              true,
+	"POST /api/v1/prompts/reload":           true,
+	"GET /api/v1/audit":                     true,
+	"DELETE /api/v1/cycles":                 true,
+	"DELETE /api/v1/cycles/:id":             true,
+	"GET /auth/profiles/:provider/token":    true,
+	"DELETE /auth/profiles/:provider":       true,
+	"POST /auth/profiles/:provider/refresh": true,
+	"POST /auth/callback/manual":            true,
+	"POST /llm-auth/mode":                   true,
+	"POST /llm-auth/provider":               true,
+	"POST /api/v1/shares":                   true,
+	"DELETE /api/v1/shares/:id":             true,
+}
+
+// requiredScope 路由所需权限：admin 路由单独列出，其余 GET/HEAD 只读，写操作需要 trade
+func requiredScope(method, route string) Scope {
+	if adminRoutes[method+" "+route] {
+		return ScopeAdmin
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return ScopeRead
+	}
+	return ScopeTrade
+}
+
+type apiKey struct {
+	name  string
+	scope Scope
+	hash  [sha256.Size]byte
+}
+
+// APIKeyAuth 按 API Key 鉴权，每个 Key 绑定一个权限等级
+type APIKeyAuth struct {
+	keys []apiKey
+}
+
+// NewAPIKeyAuth 解析 "name:scope:key,..."（scope 为 read / trade / admin），spec 为空时返回 nil（不启用）
+func NewAPIKeyAuth(spec string) *APIKeyAuth {
+	a := &APIKeyAuth{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, ":", 3)
+		if len(parts) != 3 || strings.TrimSpace(parts[0]) == "" || len(strings.TrimSpace(parts[2])) < 16 {
+			log.Fatalf("API_KEYS 格式错误: 应为 name:scope:key，且 key 至少 16 位")
+		}
+		scope, ok := parseScope(parts[1])
+		if !ok {
+			log.Fatalf("API_KEYS 中 %s 的权限 %q 无效（read / trade / admin）", parts[0], parts[1])
+		}
+		a.keys = append(a.keys, apiKey{
+			name:  strings.TrimSpace(parts[0]),
+			scope: scope,
+			hash:  sha256.Sum256([]byte(strings.TrimSpace(parts[2]))),
+		})
+	}
+	if len(a.keys) == 0 {
+		return nil
+	}
+	return a
+}
+
+// lookup 按常量时间比较查找 Key，返回名称和权限
+func (a *APIKeyAuth) lookup(key string) (string, Scope, bool) {
+	h := sha256.Sum256([]byte(key))
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(h[:], k.hash[:]) == 1 {
+			return k.name, k.scope, true
+		}
+	}
+	return "", 0, false
+}
+
+// callerKey 鉴权通过后记录调用方身份的 gin context 键
+const callerKey = "caller"
+
+// callerFrom 当前请求的调用方：key:<名称>（API Key）/ ui（Web UI 登录）；未启用鉴权时为 ui
+func callerFrom(c *gin.Context) string {
+	if v := c.GetString(callerKey); v != "" {
+		return v
+	}
+	return "ui"
+}
+
+// keyFromRequest 从 X-API-Key 或 Authorization: Bearer 读取 Key
+func keyFromRequest(c *gin.Context) string {
+	if k := strings.TrimSpace(c.GetHeader(APIKeyHeader)); k != "" {
+		return k
+	}
+	if v, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+// authMiddleware 统一鉴权：带 API Key 的请求按 Key 权限校验；否则走 Web UI 会话（登录用户视为 admin）。
+// 只配置了 API Key 时，未带 Key 的 API 请求一律 401
+func authMiddleware(session *SessionAuth, keys *APIKeyAuth) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if publicPaths[path] || strings.HasPrefix(path, sharePathPrefix) {
+			c.Next()
+			return
+		}
+
+		if key := keyFromRequest(c); key != "" && keys != nil {
+			name, scope, ok := keys.lookup(key)
+			if !ok {
+				log.Printf("[鉴权] ✘ 无效的 API Key request_id=%s client_ip=%s", requestIDFrom(c), c.ClientIP())
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+				return
+			}
+			need := requiredScope(c.Request.Method, c.FullPath())
+			if scope < need {
+				log.Printf("[鉴权] ✘ API Key %s 权限不足 需要=%s 拥有=%s path=%q", name, need, scope, path)
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient scope: requires " + need.String()})
+				return
+			}
+			c.Set(callerKey, "key:"+name)
+			c.Next()
+			return
+		}
+
+		if session != nil {
+			if session.valid(c) {
+				c.Set(callerKey, "ui")
+				c.Next()
+				return
+			}
+			if c.Request.Method == http.MethodGet && !strings.HasPrefix(path, "/api/") &&
+				strings.Contains(c.GetHeader("Accept"), "text/html") {
+				c.Redirect(http.StatusFound, "/login")
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "login required"})
+			return
+		}
+
+		// 未启用 Web UI 登录：页面与静态资源放行，接口与状态页（含权益数据）必须带 Key
+		if path == statusPagePath || strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/auth/") || strings.HasPrefix(path, "/llm-auth/") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "api key required"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,90 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"ai_quant/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auditSkipPaths 不记录审计的写操作（登录请求体含密码，连摘要也不保存）
+var auditSkipPaths = map[string]bool{
+	"/login":  true,
+	"/logout": true,
+}
+
+// auditLog 记录每个状态变更请求（非 GET/HEAD）：调用方、路由、路径参数、请求体 SHA-256 与响应状态码。
+// 放在鉴权之后，未通过鉴权的请求不记录
+func (h *Handler) auditLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions ||
+			c.FullPath() == "" || auditSkipPaths[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		var digest string
+		if c.Request.Body != nil {
+			body, err := io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			if err == nil && len(body) > 0 {
+				sum := sha256.Sum256(body)
+				digest = hex.EncodeToString(sum[:])
+			}
+		}
+
+		c.Next()
+
+		params := make([]string, 0, len(c.Params))
+		for _, p := range c.Params {
+			params = append(params, p.Key+"="+p.Value)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		h.service.RecordAudit(ctx, domain.AuditEntry{
+			Actor:         callerFrom(c),
+			Action:        method + " " + c.FullPath(),
+			Path:          c.Request.URL.Path,
+			Params:        strings.Join(params, "&"),
+			PayloadSHA256: digest,
+			Status:        c.Writer.Status(),
+			RequestID:     requestIDFrom(c),
+			ClientIP:      c.ClientIP(),
+			CreatedAt:     time.Now().UTC(),
+		})
+	}
+}
+
+// listAuditLog 分页查询操作审计日志，可按调用方过滤（如 actor=key:bot）
+func (h *Handler) listAuditLog(c *gin.Context) {
+	q, ok := parseListQuery(c, 50)
+	if !ok {
+		return
+	}
+	actor := strings.TrimSpace(c.Query("actor"))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	list, total, err := h.service.ListAuditLog(ctx, actor, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"total":       total,
+		"page":        q.Page,
+		"page_size":   q.PageSize,
+		"total_pages": (total + q.PageSize - 1) / q.PageSize,
+		"entries":     list,
+	})
+}
@@ -0,0 +1,88 @@
+package httpapi
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ai_quant/internal/trace"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// RequestIDHeader 请求 ID 响应头，客户端也可以主动传入以便串联日志
+	RequestIDHeader = trace.HeaderRequestID
+	requestIDKey    = "request_id"
+	maxRequestIDLen = 128
+)
+
+// requestLogger 为每个请求分配请求 ID，并以 key=value 形式记录方法/路径/状态码/耗时
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		reqID := c.GetHeader(RequestIDHeader)
+		if reqID == "" || len(reqID) > maxRequestIDLen {
+			reqID = uuid.NewString()
+		}
+		c.Set(requestIDKey, reqID)
+		c.Header(RequestIDHeader, reqID)
+		c.Request = c.Request.WithContext(trace.WithRequestID(c.Request.Context(), reqID))
+
+		c.Next()
+
+		path := c.Request.URL.Path
+		if strings.HasPrefix(path, sharePathPrefix) {
+			// 分享令牌等同凭证，不写入日志
+			path = sharePathPrefix + "***"
+		}
+		if raw := c.Request.URL.RawQuery; raw != "" {
+			path += "?" + raw
+		}
+		log.Printf("[HTTP] request_id=%s method=%s path=%q status=%d latency=%s client_ip=%s size=%d errors=%q",
+			reqID, c.Request.Method, path, c.Writer.Status(), time.Since(start).Round(time.Microsecond),
+			c.ClientIP(), c.Writer.Size(), c.Errors.ByType(gin.ErrorTypePrivate).String())
+	}
+}
+
+// readOnlyAllowed 只读模式下仍允许的写请求（登录会话不修改交易数据）
+var readOnlyAllowed = map[string]bool{
+	"/login":  true,
+	"/logout": true,
+}
+
+// readOnlyGuard 只读模式：拒绝所有 GET/HEAD/OPTIONS 以外的请求
+func readOnlyGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if readOnlyAllowed[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "server is running in read-only mode"})
+	}
+}
+
+// recovery 捕获 panic，返回带请求 ID 的 500，便于与服务端日志对应
+func recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, err any) {
+		reqID := requestIDFrom(c)
+		log.Printf("[HTTP] request_id=%s panic=%v", reqID, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error":      "internal server error",
+			"request_id": reqID,
+		})
+	})
+}
+
+// requestIDFrom 获取当前请求的请求 ID
+func requestIDFrom(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
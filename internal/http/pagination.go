@@ -0,0 +1,58 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ai_quant/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxPageSize = 200
+
+// parseListQuery 解析 page / page_size / sort / order 参数，参数非法时直接返回 400
+func parseListQuery(c *gin.Context, defaultPageSize int) (domain.ListQuery, bool) {
+	q := domain.ListQuery{Page: 1, PageSize: defaultPageSize, Desc: true}
+
+	if v := c.Query("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+			return q, false
+		}
+		q.Page = n
+	}
+	if v := c.Query("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page_size (1-200)"})
+			return q, false
+		}
+		q.PageSize = n
+	}
+
+	q.Sort = strings.ToLower(strings.TrimSpace(c.Query("sort")))
+	q.Tag = strings.ToLower(strings.TrimSpace(c.Query("tag")))
+	switch strings.ToLower(strings.TrimSpace(c.Query("order"))) {
+	case "", "desc":
+		q.Desc = true
+	case "asc":
+		q.Desc = false
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order, expected asc or desc"})
+		return q, false
+	}
+	return q, true
+}
+
+// writeListError 排序字段非法返回 400，其余返回 500
+func writeListError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrInvalidSort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,72 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ai_quant/internal/orchestrator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// paperAccount 模拟盘钱包、权益与最近的资金流水，?limit= 控制流水条数（默认 50）
+func (h *Handler) paperAccount(c *gin.Context) {
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit (1-500)"})
+			return
+		}
+		limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	acc, err := h.service.PaperAccount(ctx, limit)
+	if err != nil {
+		c.JSON(paperErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, acc)
+}
+
+type resetPaperRequest struct {
+	InitialUSDT float64 `json:"initial_usdt"` // 0 = 使用 PAPER_INITIAL_USDT
+}
+
+// resetPaperWallet 按初始资金重置模拟盘钱包（需先清空持仓）
+func (h *Handler) resetPaperWallet(c *gin.Context) {
+	var req resetPaperRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.InitialUSDT < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "initial_usdt must be positive"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	w, err := h.service.ResetPaperWallet(ctx, req.InitialUSDT)
+	if err != nil {
+		c.JSON(paperErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+// paperErrorStatus 未启用模拟盘返回 400，其余返回 500
+func paperErrorStatus(err error) int {
+	if errors.Is(err, orchestrator.ErrPaperDisabled) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,27 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// promptInfo 当前加载的提示词模板：默认模板版本与按交易对的覆盖
+func (h *Handler) promptInfo(c *gin.Context) {
+	info, err := h.service.PromptInfo()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// reloadPrompts 热加载提示词模板
+func (h *Handler) reloadPrompts(c *gin.Context) {
+	info, err := h.service.ReloadPrompts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
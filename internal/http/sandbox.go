@@ -0,0 +1,124 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"ai_quant/internal/orchestrator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listSandboxes 全部沙盒会话
+func (h *Handler) listSandboxes(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	list, err := h.service.ListSandboxes(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sandboxes": list})
+}
+
+// createSandbox 创建沙盒会话（独立虚拟钱包）
+func (h *Handler) createSandbox(c *gin.Context) {
+	var req orchestrator.CreateSandboxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	sb, err := h.service.CreateSandbox(ctx, req)
+	if err != nil {
+		c.JSON(sandboxErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sb)
+}
+
+// getSandbox 沙盒钱包、持仓与收益
+func (h *Handler) getSandbox(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	sum, err := h.service.SandboxSummary(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(sandboxErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sum)
+}
+
+// deleteSandbox 删除沙盒会话及其模拟成交
+func (h *Handler) deleteSandbox(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	if err := h.service.DeleteSandbox(ctx, c.Param("id")); err != nil {
+		c.JSON(sandboxErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "沙盒已删除"})
+}
+
+// runSandbox 在沙盒中跑一次周期并模拟成交
+func (h *Handler) runSandbox(c *gin.Context) {
+	var req struct {
+		Pair string `json:"pair"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	run, err := h.service.RunSandboxCycle(ctx, c.Param("id"), strings.TrimSpace(req.Pair))
+	if err != nil {
+		c.JSON(sandboxErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// listSandboxTrades 分页查询沙盒模拟成交
+func (h *Handler) listSandboxTrades(c *gin.Context) {
+	q, ok := parseListQuery(c, 50)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	list, total, err := h.service.ListSandboxTrades(ctx, c.Param("id"), q)
+	if err != nil {
+		c.JSON(sandboxErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"total":       total,
+		"page":        q.Page,
+		"page_size":   q.PageSize,
+		"total_pages": (total + q.PageSize - 1) / q.PageSize,
+		"trades":      list,
+	})
+}
+
+func sandboxErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, orchestrator.ErrSandboxNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, orchestrator.ErrSandboxExists):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// screenerResult 最近一次交易对筛选结果
+func (h *Handler) screenerResult(c *gin.Context) {
+	sc := h.service.Screener()
+	if sc == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "screener disabled"})
+		return
+	}
+	c.JSON(http.StatusOK, sc.Latest())
+}
+
+// runScreener 立即执行一次交易对筛选
+func (h *Handler) runScreener(c *gin.Context) {
+	sc := h.service.Screener()
+	if sc == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "screener disabled"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+	res := sc.Scan(ctx)
+	if res.Error != "" {
+		c.JSON(http.StatusBadGateway, gin.H{"error": res.Error})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -2,37 +2,66 @@ package httpapi
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/agent/signal"
 	"ai_quant/internal/auth"
 	"ai_quant/internal/domain"
+	"ai_quant/internal/fx"
+	"ai_quant/internal/market"
 	"ai_quant/internal/orchestrator"
+	"ai_quant/internal/ratelimit"
+	"ai_quant/internal/tradingday"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
 	service *orchestrator.Service
+	models  *signal.ModelCatalog
 	timeout time.Duration
 }
 
 type runCycleRequest struct {
-	Pair      string                 `json:"pair"`
-	Snapshot  *domain.MarketSnapshot `json:"snapshot"`
-	Portfolio domain.PortfolioState  `json:"portfolio"`
+	Pair      string                      `json:"pair"`
+	Snapshot  *domain.MarketSnapshot      `json:"snapshot"`
+	Portfolio domain.PortfolioState       `json:"portfolio"`
+	Model     string                      `json:"model"`
+	Provider  *signal.ProviderPreferences `json:"provider"`
 }
 
-func NewRouter(service *orchestrator.Service, authService *auth.Service, timeoutSec int) *gin.Engine {
-	router := gin.Default()
+func NewRouter(service *orchestrator.Service, authService *auth.Service, models *signal.ModelCatalog, session *SessionAuth, apiKeys *APIKeyAuth, timeoutSec int, readOnly bool) *gin.Engine {
+	router := gin.New()
+	router.Use(requestLogger(), recovery())
+	if readOnly {
+		// 只读实例：写接口在鉴权与审计之前直接拒绝
+		router.Use(readOnlyGuard())
+	}
 
 	h := &Handler{
 		service: service,
+		models:  models,
 		timeout: time.Duration(timeoutSec) * time.Second,
 	}
 
+	// 配置了 UI_PASSWORD_HASH 或 API_KEYS 时，API 需要登录会话或带权限的 API Key
+	if session != nil || apiKeys != nil {
+		router.Use(authMiddleware(session, apiKeys))
+	}
+	router.Use(h.auditLog())
+	if session != nil {
+		session.registerRoutes(router)
+	} else {
+		// 未启用登录时保留入口，避免前端退出按钮 404
+		router.GET("/login", func(c *gin.Context) { c.Redirect(http.StatusFound, "/") })
+		router.POST("/logout", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"message": "login disabled"}) })
+	}
+
 	authHandler := NewAuthHandler(authService)
 
 	// LLM 认证管理
@@ -44,6 +73,7 @@ func NewRouter(service *orchestrator.Service, authService *auth.Service, timeout
 	router.GET("/", func(c *gin.Context) {
 		c.File("./client/index.html")
 	})
+	router.GET(statusPagePath, h.statusPage)
 
 	// OAuth routes
 	authGroup := router.Group("/auth")
@@ -70,17 +100,87 @@ func NewRouter(service *orchestrator.Service, authService *auth.Service, timeout
 	{
 		v1.GET("/health", h.health)
 		v1.POST("/cycles/run", h.runCycle)
+		v1.POST("/cycles/preview", h.previewCycle)
+		v1.GET("/cycles/queue", h.cycleQueue)
+		v1.GET("/shadow", h.listShadowCycles)
+		v1.GET("/shadow/:id", h.getShadowCycle)
+		v1.GET("/sandboxes", h.listSandboxes)
+		v1.POST("/sandboxes", h.createSandbox)
+		v1.GET("/sandboxes/:id", h.getSandbox)
+		v1.DELETE("/sandboxes/:id", h.deleteSandbox)
+		v1.POST("/sandboxes/:id/run", h.runSandbox)
+		v1.GET("/sandboxes/:id/trades", h.listSandboxTrades)
 		v1.GET("/cycles", h.listCycles)
+		v1.DELETE("/cycles", h.pruneCycles)
 		v1.GET("/cycles/:id", h.getCycle)
 		v1.DELETE("/cycles/:id", h.deleteCycle)
+		v1.POST("/cycles/:id/tags", h.addCycleTags)
+		v1.POST("/cycles/:id/approve", h.approveCycle)
+		v1.POST("/cycles/:id/reject", h.rejectCycle)
+		v1.GET("/approvals", h.listApprovals)
+		v1.DELETE("/cycles/:id/tags/:tag", h.removeCycleTag)
+		v1.GET("/tags", h.tagStats)
 		v1.GET("/positions", h.listPositions)
+		v1.POST("/positions/close", h.closePosition)
 		v1.GET("/holdings", h.listHoldings)
+		v1.GET("/protective-orders", h.listProtectiveOrders)
+		v1.GET("/orders/open", h.listOpenOrders)
+		v1.POST("/orders/manual", h.manualOrder)
+		v1.DELETE("/orders/:id", h.cancelOpenOrder)
+		v1.GET("/trailing-stops", h.listTrailingStops)
+		v1.GET("/strategies", h.listStrategies)
 		v1.POST("/holdings/sync", h.syncHoldings)
+		v1.POST("/holdings/:pair/close", h.forceClosePosition)
+		v1.POST("/holdings/import", h.importPositions)
 		v1.POST("/trades/sync", h.syncTrades)
 		v1.GET("/balance", h.getBalance)
 		v1.POST("/data/reset", h.resetData)
+		v1.GET("/paper", h.paperAccount)
+		v1.POST("/paper/reset", h.resetPaperWallet)
+		v1.GET("/llm/models", h.listLLMModels)
+		v1.GET("/prompts", h.promptInfo)
+		v1.POST("/prompts/reload", h.reloadPrompts)
+		v1.GET("/pnl/daily", h.dailyPnL)
+		v1.GET("/analytics/equity", h.equityAnalytics)
+		v1.GET("/reports/:period", h.performanceReport)
+		v1.GET("/indicators", h.indicators)
+		v1.GET("/stats/reasons", h.reasonStats)
+		v1.GET("/stats/heatmap", h.outcomeHeatmap)
+		v1.GET("/costs", h.costSummary)
+		v1.GET("/llm/behavior", h.modelBehavior)
+		v1.GET("/outbox", h.outboxPending)
+		v1.GET("/execution/quality", h.executionQuality)
+		v1.GET("/portfolio/plan", h.allocationPlan)
+		v1.GET("/portfolio/capital", h.capitalAllocation)
+		v1.GET("/portfolio", h.getPortfolio)
+		v1.GET("/risk/drawdown", h.drawdownStatus)
+		v1.POST("/risk/resume", h.resumeTrading)
+		v1.GET("/presets", h.listPresets)
+		v1.POST("/presets/active", h.applyPreset)
+		v1.GET("/futures/leverage", h.listLeverage)
+		v1.PUT("/futures/:pair/leverage", h.setLeverage)
+		v1.GET("/margin/:pair", h.marginStatus)
+		v1.POST("/exchange/validate", h.validateExchange)
+		v1.GET("/datasources/status", h.dataSourceStatus)
+		v1.GET("/pipeline/stages", h.pipelineStages)
+		v1.PUT("/pipeline/stages", h.setPipelineStage)
+		v1.GET("/audit", h.listAuditLog)
+		v1.GET("/scheduler", h.schedulerState)
+		v1.POST("/scheduler/pause", h.pauseScheduler)
+		v1.POST("/scheduler/resume", h.resumeScheduler)
+		v1.PUT("/scheduler/interval", h.setSchedulerInterval)
+		v1.POST("/scheduler/pairs", h.addSchedulerPair)
+		v1.DELETE("/scheduler/pairs/:pair", h.removeSchedulerPair)
+		v1.GET("/screener", h.screenerResult)
+		v1.POST("/screener/scan", h.runScreener)
+		v1.GET("/shares", h.listShareLinks)
+		v1.POST("/shares", h.createShareLink)
+		v1.DELETE("/shares/:id", h.revokeShareLink)
 	}
 
+	// 只读分享看板（凭令牌访问）
+	router.GET(sharePathPrefix+":token", h.sharedDashboard)
+
 	return router
 }
 
@@ -108,17 +208,276 @@ func (h *Handler) runCycle(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
 	defer cancel()
 
-	result, err := h.service.RunCycle(ctx, orchestrator.RunRequest{
+	// 手动触发优先于定时任务执行
+	result, err := h.service.SubmitCycle(ctx, orchestrator.RunRequest{
 		Pair:      req.Pair,
 		Snapshot:  req.Snapshot,
 		Portfolio: req.Portfolio,
+		Model:     strings.TrimSpace(req.Model),
+		Provider:  req.Provider,
+
+		RequestedBy: callerFrom(c),
+	}, orchestrator.PriorityManual, 0)
+	if err != nil {
+		c.JSON(cycleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+type closePositionRequest struct {
+	Pair     string  `json:"pair"`
+	Fraction float64 `json:"fraction"` // 平仓比例 (0,1]，0 或省略 = 全部平仓
+}
+
+// closePosition 手动平仓，支持按比例减仓（剩余持仓的分批计划与保护单保留）
+func (h *Handler) closePosition(c *gin.Context) {
+	var req closePositionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Pair) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pair is required"})
+		return
+	}
+	if req.Fraction < 0 || req.Fraction > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fraction must be between 0 and 1"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	result, err := h.service.ClosePosition(ctx, req.Pair, req.Fraction)
+	if err != nil {
+		c.JSON(cycleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+type manualOrderRequest struct {
+	Pair        string  `json:"pair"`
+	Side        string  `json:"side"`       // buy / sell
+	StakeUSDT   float64 `json:"stake_usdt"` // 与 quantity 二选一
+	Quantity    float64 `json:"quantity"`
+	EnforceRisk *bool   `json:"enforce_risk"` // 省略时默认经过风控
+	Note        string  `json:"note"`
+}
+
+// manualOrder 人工下单，跳过大模型按指定金额 / 数量买卖，可选择是否经过风控；记为 manual 类型的周期
+func (h *Handler) manualOrder(c *gin.Context) {
+	var req manualOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Pair) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pair is required"})
+		return
+	}
+	enforce := req.EnforceRisk == nil || *req.EnforceRisk
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	result, err := h.service.ManualTrade(ctx, req.Pair, orchestrator.ManualTrade{
+		Side:        req.Side,
+		StakeUSDT:   req.StakeUSDT,
+		Quantity:    req.Quantity,
+		EnforceRisk: enforce,
+		Note:        req.Note,
+	}, callerFrom(c))
+	if err != nil {
+		status := cycleErrorStatus(err)
+		if errors.Is(err, orchestrator.ErrInvalidManualTrade) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// cycleErrorStatus 同一交易对周期执行中或重复下单返回 409，队列已满返回 503，其余为 500
+func cycleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, orchestrator.ErrCycleInProgress), errors.Is(err, orchestrator.ErrDuplicateOrder):
+		return http.StatusConflict
+	case errors.Is(err, orchestrator.ErrQueueFull):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// cycleQueue 周期队列深度、执行中的交易对与累计计数
+func (h *Handler) cycleQueue(c *gin.Context) {
+	st := h.service.QueueStats()
+	if st == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
+
+// previewCycle 模拟一次周期：返回信号、风控与建仓计划，不下单
+func (h *Handler) previewCycle(c *gin.Context) {
+	var req runCycleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	preview, err := h.service.PreviewCycle(ctx, orchestrator.RunRequest{
+		Pair:      strings.TrimSpace(req.Pair),
+		Snapshot:  req.Snapshot,
+		Portfolio: req.Portfolio,
+		Model:     strings.TrimSpace(req.Model),
+		Provider:  req.Provider,
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, preview)
+}
+
+// listShadowCycles 分页查询影子周期，并附带各交易对汇总
+func (h *Handler) listShadowCycles(c *gin.Context) {
+	q, ok := parseListQuery(c, 50)
+	if !ok {
+		return
+	}
+	pair := strings.ToUpper(strings.TrimSpace(c.Query("pair")))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	list, total, err := h.service.ListShadowCycles(ctx, pair, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	stats, err := h.service.ShadowStats(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":         total,
+		"page":          q.Page,
+		"page_size":     q.PageSize,
+		"total_pages":   (total + q.PageSize - 1) / q.PageSize,
+		"shadow_cycles": list,
+		"stats":         stats,
+	})
+}
+
+// listProtectiveOrders 查询止盈止损保护单，支持 ?pair=&status= 过滤
+func (h *Handler) listProtectiveOrders(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	list, err := h.service.ListProtectiveOrders(ctx, strings.TrimSpace(c.Query("pair")), strings.TrimSpace(c.Query("status")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"protective_orders": list})
+}
+
+// listOpenOrders 交易所未完成挂单（?pair= 过滤），本系统的保护单带 protective_kind
+func (h *Handler) listOpenOrders(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	pair := ""
+	if v := c.Query("pair"); v != "" {
+		pair = pairFromParam(v)
+	}
+	orders, err := h.service.ListOpenOrders(ctx, pair)
+	if errors.Is(err, orchestrator.ErrOpenOrdersUnsupported) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"orders": orders})
+}
+
+// cancelOpenOrder 撤销交易所挂单，:id 为交易所订单 ID，需带 ?pair=
+func (h *Handler) cancelOpenOrder(c *gin.Context) {
+	pair := pairFromParam(c.Query("pair"))
+	if pair == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing pair"})
+		return
+	}
+	id := strings.TrimSpace(c.Param("id"))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	if err := h.service.CancelOpenOrder(ctx, pair, id); err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, orchestrator.ErrOpenOrdersUnsupported) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pair": pair, "exchange_order_id": id, "cancelled": true})
+}
+
+// listTrailingStops 当前跟踪中的移动止损（最高价与止损价）
+func (h *Handler) listTrailingStops(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	list, err := h.service.ListTrailingStops(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trailing_stops": list, "enabled": h.service.TrailingStopEnabled()})
+}
+
+// listStrategies 查询仍有待触发批次的分批建仓策略，支持 ?pair= 过滤
+func (h *Handler) listStrategies(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	list, err := h.service.ListPendingStrategies(ctx, strings.TrimSpace(c.Query("pair")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"strategies": list})
+}
+
+// getShadowCycle 获取影子周期详情（含完整预览）
+func (h *Handler) getShadowCycle(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	sc, err := h.service.GetShadowCycle(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if sc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "shadow cycle not found"})
+		return
+	}
+	c.JSON(http.StatusOK, sc)
 }
 
 // listCycles 分页查询历史周期
@@ -139,7 +498,8 @@ func (h *Handler) listCycles(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
 	defer cancel()
 
-	cycles, total, err := h.service.ListCycles(ctx, page, pageSize)
+	tag := strings.ToLower(strings.TrimSpace(c.Query("tag")))
+	cycles, total, err := h.service.ListCycles(ctx, page, pageSize, tag)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -156,6 +516,63 @@ func (h *Handler) listCycles(c *gin.Context) {
 	})
 }
 
+type cycleTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// addCycleTags 手动给周期添加标签
+func (h *Handler) addCycleTags(c *gin.Context) {
+	var req cycleTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	tags, err := h.service.AddCycleTags(ctx, strings.TrimSpace(c.Param("id")), req.Tags)
+	if err != nil {
+		writeTagError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// removeCycleTag 删除周期上的标签
+func (h *Handler) removeCycleTag(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	if err := h.service.RemoveCycleTag(ctx, strings.TrimSpace(c.Param("id")), c.Param("tag")); err != nil {
+		writeTagError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "tag removed"})
+}
+
+// tagStats 按标签汇总周期表现
+func (h *Handler) tagStats(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	stats, err := h.service.TagStats(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": stats})
+}
+
+// writeTagError 标签不合法返回 400，其余返回 500
+func writeTagError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrInvalidTag) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
 func (h *Handler) getCycle(c *gin.Context) {
 	cycleID := strings.TrimSpace(c.Param("id"))
 	if cycleID == "" {
@@ -193,63 +610,158 @@ func (h *Handler) deleteCycle(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "cycle deleted successfully"})
 }
 
+// pruneCycles 批量删除周期：?status=failed,rejected&before=2024-01-01，before 缺省为当前时间；
+// ?dry_run=true 只返回会被删除的数量。存在成交订单的周期不会被删除
+func (h *Handler) pruneCycles(c *gin.Context) {
+	var statuses []domain.CycleStatus
+	for _, v := range strings.Split(c.Query("status"), ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			statuses = append(statuses, domain.CycleStatus(v))
+		}
+	}
+	if len(statuses) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing status"})
+		return
+	}
+	before := time.Now().UTC()
+	if v := strings.TrimSpace(c.Query("before")); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t, err = time.Parse("2006-01-02", v)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid before, expected 2006-01-02 or RFC3339"})
+			return
+		}
+		before = t
+	}
+	dryRun := c.Query("dry_run") == "true"
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+
+	n, err := h.service.PruneCycles(ctx, statuses, before, dryRun)
+	if err != nil {
+		if errors.Is(err, orchestrator.ErrPruneStatus) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{"dry_run": true, "matched": n, "before": before})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"dry_run": false, "deleted": n, "before": before})
+}
+
+// listPositions 分页查询仓位（订单）列表，兼容旧参数 limit
 func (h *Handler) listPositions(c *gin.Context) {
-	limit := 50
-	if v := c.Query("limit"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			limit = n
+	q, ok := parseListQuery(c, 50)
+	if !ok {
+		return
+	}
+	if c.Query("page_size") == "" {
+		if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 && n <= maxPageSize {
+			q.PageSize = n
 		}
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
 	defer cancel()
 
-	positions, err := h.service.ListPositions(ctx, limit)
+	positions, total, err := h.service.ListPositions(ctx, q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeListError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"total":     len(positions),
-		"positions": positions,
+		"total":       total,
+		"page":        q.Page,
+		"page_size":   q.PageSize,
+		"total_pages": (total + q.PageSize - 1) / q.PageSize,
+		"positions":   positions,
+		"display":     h.display(ctx, nil),
 	})
 }
 
-// listHoldings 获取当前持仓汇总（含实时行情）
+// listHoldings 分页获取持仓（含实时行情），汇总字段覆盖全部持仓
 func (h *Handler) listHoldings(c *gin.Context) {
+	q, ok := parseListQuery(c, 50)
+	if !ok {
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
 	defer cancel()
 
-	views, err := h.service.GetHoldings(ctx)
+	views, total, err := h.service.GetHoldings(ctx, q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeListError(c, err)
 		return
 	}
 
-	// 计算汇总
-	totalCost := 0.0
-	totalValue := 0.0
-	totalPnL := 0.0
-	for _, v := range views {
-		totalCost += v.TotalCost
-		totalValue += v.MarketValue
-		totalPnL += v.UnrealizedPnL
+	// 计算汇总（本页价格刚刷新，其余持仓使用最近一次市价）
+	totalCost, totalValue, err := h.service.SumHoldings(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
+	totalPnL := totalValue - totalCost
 	pnlPercent := 0.0
 	if totalCost > 0 {
 		pnlPercent = (totalPnL / totalCost) * 100
 	}
 
+	shorts, err := h.service.GetShortHoldings(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"holdings":    views,
-		"total_cost":  totalCost,
-		"total_value": totalValue,
-		"total_pnl":   totalPnL,
-		"pnl_percent": pnlPercent,
+		"holdings":       views,
+		"short_holdings": shorts,
+		"total":          total,
+		"page":           q.Page,
+		"page_size":      q.PageSize,
+		"total_pages":    (total + q.PageSize - 1) / q.PageSize,
+		"total_cost":     totalCost,
+		"total_value":    totalValue,
+		"total_pnl":      totalPnL,
+		"pnl_percent":    pnlPercent,
+		"display": h.display(ctx, map[string]float64{
+			"total_cost":  totalCost,
+			"total_value": totalValue,
+			"total_pnl":   totalPnL,
+		}),
 	})
 }
 
+// forceClosePosition 强制平仓（紧急按钮）：不经过大模型与周期流水线，直接市价全部平仓
+func (h *Handler) forceClosePosition(c *gin.Context) {
+	pair := pairFromParam(c.Param("pair"))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	ord, err := h.service.ForceClosePosition(ctx, pair)
+	if err != nil {
+		status := http.StatusBadGateway
+		switch {
+		case errors.Is(err, orchestrator.ErrNoPosition):
+			status = http.StatusNotFound
+		case errors.Is(err, orchestrator.ErrCycleInProgress):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pair": pair, "order": ord})
+}
+
 // syncHoldings 手动触发持仓同步
 // 支持 ?source=exchange 强制从交易所同步（即使模拟模式）
 // 支持 ?source=orders 强制从订单聚合
@@ -278,6 +790,22 @@ func (h *Handler) syncHoldings(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": msg})
 }
 
+// importPositions 冷启动导入：按交易所余额回放成交历史重建持仓成本并导入成交记录
+// 支持 ?dry_run=true 只预览核对结果
+func (h *Handler) importPositions(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true"
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+
+	result, err := h.service.ImportPositions(ctx, dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 // syncTrades 从币安同步成交记录
 func (h *Handler) syncTrades(c *gin.Context) {
 	pair := c.DefaultQuery("pair", "DOGE/USDT")
@@ -313,18 +841,21 @@ func (h *Handler) getBalance(c *gin.Context) {
 	usdtFree := 0.0
 	usdtLocked := 0.0
 	usdtTotal := 0.0
+	usdtEarn := 0.0
 	assets := make([]gin.H, 0)
 	for _, b := range balances {
 		if b.Symbol == "USDT" {
 			usdtFree = b.Free
 			usdtLocked = b.Locked
 			usdtTotal = b.Total
+			usdtEarn = b.Earn
 		}
 		assets = append(assets, gin.H{
 			"symbol": b.Symbol,
 			"free":   b.Free,
 			"locked": b.Locked,
 			"total":  b.Total,
+			"earn":   b.Earn,
 		})
 	}
 
@@ -332,7 +863,14 @@ func (h *Handler) getBalance(c *gin.Context) {
 		"usdt_free":   usdtFree,
 		"usdt_locked": usdtLocked,
 		"usdt_total":  usdtTotal,
+		"usdt_earn":   usdtEarn,
 		"assets":      assets,
+		"display": h.display(ctx, map[string]float64{
+			"usdt_free":   usdtFree,
+			"usdt_locked": usdtLocked,
+			"usdt_total":  usdtTotal,
+			"usdt_earn":   usdtEarn,
+		}),
 	})
 }
 
@@ -348,3 +886,705 @@ func (h *Handler) resetData(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "所有数据已清空"})
 }
+
+// listLLMModels 列出当前 LLM 后端的可用模型（支持 ?refresh=true 跳过缓存）
+func (h *Handler) listLLMModels(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	refresh := c.Query("refresh") == "true"
+	models, err := h.models.List(ctx, refresh)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"backend": h.models.Backend(),
+		"current": h.models.CurrentModel(),
+		"total":   len(models),
+		"models":  models,
+	})
+}
+
+// modelBehavior 各模型最近信号与基线的方向占比、平均置信度对比
+func (h *Handler) modelBehavior(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	models, err := h.service.ModelBehavior(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"models": models})
+}
+
+// outboxPending 写库失败、等待重试的记录
+func (h *Handler) outboxPending(c *gin.Context) {
+	items := h.service.OutboxPending()
+	c.JSON(http.StatusOK, gin.H{"items": items, "total": len(items)})
+}
+
+// costSummary 大模型成本汇总：今日 / 本月花费、预算上限、按交易日和按模型的明细
+func (h *Handler) costSummary(c *gin.Context) {
+	days := 30
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 366 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days (1-366)"})
+			return
+		}
+		days = n
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	sum, err := h.service.CostSummary(ctx, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sum)
+}
+
+// executionQuality 订单执行质量：预估价与成交价的滑点，按交易对和成交金额区间统计；simulated=true 时包含模拟盘订单
+func (h *Handler) executionQuality(c *gin.Context) {
+	days := 30
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 366 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days (1-366)"})
+			return
+		}
+		days = n
+	}
+	simulated := c.Query("simulated") == "true"
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	report, err := h.service.ExecutionQuality(ctx, days, simulated)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+type approvalRequest struct {
+	Note string `json:"note"`
+}
+
+// approveCycle 审批通过等待中的下单并立即执行，返回执行后的周期结果
+func (h *Handler) approveCycle(c *gin.Context) {
+	var req approvalRequest
+	_ = c.ShouldBindJSON(&req) // note 可选，允许空请求体
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	result, err := h.service.ApproveCycle(ctx, c.Param("id"), callerFrom(c), strings.TrimSpace(req.Note))
+	if err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// rejectCycle 拒绝等待中的下单
+func (h *Handler) rejectCycle(c *gin.Context) {
+	var req approvalRequest
+	_ = c.ShouldBindJSON(&req)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	if err := h.service.RejectCycle(ctx, c.Param("id"), callerFrom(c), strings.TrimSpace(req.Note)); err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "rejected"})
+}
+
+func approvalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, orchestrator.ErrApprovalNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, orchestrator.ErrApprovalClosed):
+		return http.StatusConflict
+	case errors.Is(err, orchestrator.ErrApprovalSelf):
+		return http.StatusForbidden
+	}
+	return cycleErrorStatus(err)
+}
+
+// listApprovals 审批记录，?status=pending 只看待审批
+func (h *Handler) listApprovals(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	list, err := h.service.ListApprovals(ctx, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mode": h.service.ApprovalMode(), "items": list})
+}
+
+// allocationPlan 最近一次组合分配计划（组合分配模式未开启时 plan 可能为空）
+func (h *Handler) allocationPlan(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	plan, err := h.service.LatestAllocationPlan(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": h.service.PortfolioMode(), "plan": plan})
+}
+
+// capitalAllocation 多交易对资金分配：全局预算、各交易对份额与当前持仓
+func (h *Handler) capitalAllocation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	alloc, err := h.service.CapitalAllocation(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, alloc)
+}
+
+// equityAnalytics 基于权益快照的收益分析：收益率、波动率、夏普比率、最大回撤与各交易对贡献
+func (h *Handler) equityAnalytics(c *gin.Context) {
+	days := 30
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 366 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days (1-366)"})
+			return
+		}
+		days = n
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	a, err := h.service.EquityAnalytics(ctx, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, struct {
+		domain.EquityAnalytics
+		Display *fx.Display `json:"display,omitempty"`
+	}{a, h.display(ctx, map[string]float64{
+		"start_equity_usdt": a.StartEquityUSDT,
+		"end_equity_usdt":   a.EndEquityUSDT,
+	})})
+}
+
+// performanceReport 日报 / 周报（:period = daily / weekly）；date 为区间内任一交易日，默认上一个完整区间，
+// format=text 时作为文本文件下载
+func (h *Handler) performanceReport(c *gin.Context) {
+	period := c.Param("period")
+	if period != domain.ReportDaily && period != domain.ReportWeekly {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown report period (daily / weekly)"})
+		return
+	}
+	date := c.Query("date")
+	if date != "" {
+		if _, err := time.Parse(tradingday.KeyLayout, date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date (YYYY-MM-DD)"})
+			return
+		}
+	} else {
+		last := time.Now().AddDate(0, 0, -1)
+		if period == domain.ReportWeekly {
+			last = time.Now().AddDate(0, 0, -7)
+		}
+		date = tradingday.Key(last)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	rep, err := h.service.PerformanceReport(ctx, period, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("format") == "text" {
+		c.Header("Content-Disposition", `attachment; filename="report-`+rep.Period+"-"+rep.StartDate+`.txt"`)
+		c.String(http.StatusOK, orchestrator.FormatReport(rep)+"\n")
+		return
+	}
+	c.JSON(http.StatusOK, rep)
+}
+
+// indicators 按 K 线计算的指标序列（rsi / macd / atr / ema20 / ema50），与提示词使用相同的 K 线与算法；
+// 不指定 limit 时 5m / 4h 周期与提示词的 K 线数量一致
+func (h *Handler) indicators(c *gin.Context) {
+	pair := strings.TrimSpace(c.Query("pair"))
+	if pair == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pair is required"})
+		return
+	}
+	interval := c.DefaultQuery("interval", "4h")
+	var names []string
+	for _, n := range strings.Split(c.Query("indicators"), ",") {
+		if n = strings.ToLower(strings.TrimSpace(n)); n != "" {
+			names = append(names, n)
+		}
+	}
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit (1-1000)"})
+			return
+		}
+		limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	series, err := h.service.Indicators(ctx, pair, interval, names, limit)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, orchestrator.ErrIndicatorsUnavailable) {
+			status = http.StatusServiceUnavailable
+		} else if errors.Is(err, market.ErrInvalidIndicatorQuery) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// drawdownStatus 回撤熔断状态与峰值窗口内的权益快照
+func (h *Handler) drawdownStatus(c *gin.Context) {
+	limit := 500
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 5000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit (1-5000)"})
+			return
+		}
+		limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	st, err := h.service.GetDrawdownStatus(ctx, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, struct {
+		orchestrator.DrawdownStatus
+		Display *fx.Display `json:"display,omitempty"`
+	}{st, h.display(ctx, map[string]float64{
+		"peak_usdt":   st.PeakUSDT,
+		"equity_usdt": st.EquityUSDT,
+	})})
+}
+
+// resumeTrading 手动解除回撤熔断
+func (h *Handler) resumeTrading(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	halt, err := h.service.ResumeTrading(ctx)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, halt)
+}
+
+// reasonStats 按交易日汇总被拒绝 / 失败周期的原因代码，并给出区间合计
+func (h *Handler) reasonStats(c *gin.Context) {
+	days := 7
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 366 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days (1-366)"})
+			return
+		}
+		days = n
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	items, err := h.service.ReasonStats(ctx, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	totals := make(map[domain.ReasonCode]int)
+	for _, it := range items {
+		totals[it.Code] += it.Count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"timezone": tradingday.Location().String(),
+		"days":     items,
+		"totals":   totals,
+	})
+}
+
+// outcomeHeatmap 按星期几 × 小时汇总周期结果与平仓盈亏，并给出按小时、按星期的合计；
+// tz 参数可切换统计时区（默认交易日时区），便于对照美盘 / 亚盘时段设置禁止交易窗口
+func (h *Handler) outcomeHeatmap(c *gin.Context) {
+	days := 30
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 366 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days (1-366)"})
+			return
+		}
+		days = n
+	}
+	loc := tradingday.Location()
+	if v := c.Query("tz"); v != "" {
+		l, err := time.LoadLocation(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tz: " + err.Error()})
+			return
+		}
+		loc = l
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	cells, err := h.service.OutcomeHeatmap(ctx, days, loc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	byHour := make([]domain.OutcomeHeatCell, 24)
+	byWeekday := make([]domain.OutcomeHeatCell, 7)
+	for i := range byHour {
+		byHour[i] = domain.OutcomeHeatCell{Weekday: -1, Hour: i}
+	}
+	for i := range byWeekday {
+		byWeekday[i] = domain.OutcomeHeatCell{Weekday: i, Hour: -1}
+	}
+	for _, cell := range cells {
+		mergeHeatCell(&byHour[cell.Hour], cell)
+		mergeHeatCell(&byWeekday[cell.Weekday], cell)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"timezone":   loc.String(),
+		"days":       days,
+		"cells":      cells,
+		"by_hour":    byHour,
+		"by_weekday": byWeekday,
+	})
+}
+
+// mergeHeatCell 把格子的计数累加到合计行并重新计算胜率与平均盈亏
+func mergeHeatCell(dst *domain.OutcomeHeatCell, src domain.OutcomeHeatCell) {
+	dst.Cycles += src.Cycles
+	dst.Success += src.Success
+	dst.Rejected += src.Rejected
+	dst.Failed += src.Failed
+	dst.Closes += src.Closes
+	dst.Wins += src.Wins
+	dst.RealizedPnLUSDT += src.RealizedPnLUSDT
+	if dst.Closes > 0 {
+		dst.WinRate = float64(dst.Wins) / float64(dst.Closes) * 100
+		dst.AvgPnLUSDT = dst.RealizedPnLUSDT / float64(dst.Closes)
+	}
+}
+
+// dailyPnL 按交易日（配置时区）汇总已实现盈亏
+func (h *Handler) dailyPnL(c *gin.Context) {
+	days := 30
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 366 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days (1-366)"})
+			return
+		}
+		days = n
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	items, err := h.service.DailyPnL(ctx, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	total := 0.0
+	for _, d := range items {
+		total += d.RealizedPnLUSDT
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"timezone":           tradingday.Location().String(),
+		"today":              tradingday.Key(time.Now()),
+		"days":               items,
+		"total_realized_pnl": total,
+		"display":            h.display(ctx, map[string]float64{"total_realized_pnl": total}),
+	})
+}
+
+// display 报表的法币展示块，values 中的 USDT 金额按缓存汇率换算；未配置展示币种或汇率不可用时为 nil
+func (h *Handler) display(ctx context.Context, values map[string]float64) *fx.Display {
+	q, ok := h.service.DisplayQuote(ctx)
+	if !ok {
+		return nil
+	}
+	return q.Report(values)
+}
+
+// getPortfolio 返回风控使用的组合状态（当日盈亏、持仓敞口）
+func (h *Handler) getPortfolio(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	state, err := h.service.BuildPortfolioState(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, struct {
+		domain.PortfolioState
+		Display *fx.Display `json:"display,omitempty"`
+	}{state, h.display(ctx, map[string]float64{
+		"daily_pnl_usdt":     state.DailyPnLUSDT,
+		"open_exposure_usdt": state.OpenExposureUSDT,
+	})})
+}
+
+// listPresets 列出风险预设及当前生效的预设
+func (h *Handler) listPresets(c *gin.Context) {
+	presets, active := h.service.ListPresets()
+	c.JSON(http.StatusOK, gin.H{
+		"active":  active,
+		"presets": presets,
+	})
+}
+
+type applyPresetRequest struct {
+	Name string `json:"name"`
+}
+
+// applyPreset 切换风险预设
+func (h *Handler) applyPreset(c *gin.Context) {
+	var req applyPresetRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing preset name"})
+		return
+	}
+
+	p, err := h.service.ApplyPreset(req.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"active": p.Name, "preset": p})
+}
+
+// pairFromParam 路径中的交易对不能带 "/"，支持 BTC-USDT、BTC_USDT、BTCUSDT 写法
+func pairFromParam(raw string) string {
+	p := strings.ToUpper(strings.TrimSpace(raw))
+	p = strings.NewReplacer("-", "/", "_", "/").Replace(p)
+	if !strings.Contains(p, "/") && strings.HasSuffix(p, "USDT") && len(p) > 4 {
+		p = strings.TrimSuffix(p, "USDT") + "/USDT"
+	}
+	return p
+}
+
+// listLeverage 列出按交易对保存的合约杠杆
+func (h *Handler) listLeverage(c *gin.Context) {
+	levs, err := h.service.ListPairLeverage(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"leverage": levs})
+}
+
+type setLeverageRequest struct {
+	Leverage int `json:"leverage"`
+}
+
+// setLeverage 调整合约交易对杠杆并持久化，后续周期按该杠杆下单
+func (h *Handler) setLeverage(c *gin.Context) {
+	var req setLeverageRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Leverage <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing leverage"})
+		return
+	}
+	pair := pairFromParam(c.Param("pair"))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	if err := h.service.SetPairLeverage(ctx, pair, req.Leverage); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pair": pair, "leverage": req.Leverage})
+}
+
+// marginStatus 查询交易对所在现货杠杆账户的风险率
+func (h *Handler) marginStatus(c *gin.Context) {
+	pair := pairFromParam(c.Param("pair"))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	st, err := h.service.MarginStatus(ctx, pair)
+	if errors.Is(err, orchestrator.ErrNotMargin) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
+
+// dataSourceStatus 外部数据源健康状态：成功/失败次数、最近成功时间、是否陈旧
+func (h *Handler) dataSourceStatus(c *gin.Context) {
+	sources := h.service.DataSourceStatus()
+	degraded := 0
+	for _, s := range sources {
+		if s.Status == market.SourceStale || s.Status == market.SourceFailing {
+			degraded++
+		}
+	}
+	resp := gin.H{
+		"sources":     sources,
+		"degraded":    degraded,
+		"rate_limits": ratelimit.Snapshot(),
+	}
+	if offset, syncedAt, ok := execution.ClockOffset(); ok {
+		resp["exchange_clock"] = gin.H{"offset_ms": offset.Milliseconds(), "synced_at": syncedAt}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// validateExchange 校验交易所 API Key 可用性与权限
+func (h *Handler) validateExchange(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	check, err := h.service.ValidateExchangeKeys(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	status := http.StatusOK
+	if !check.OK {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, check)
+}
+
+// scheduler 返回定时调度器，未启用时写入 503 并返回 nil
+func (h *Handler) scheduler(c *gin.Context) orchestrator.SchedulerControl {
+	sc := h.service.Scheduler()
+	if sc == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "scheduler not available"})
+	}
+	return sc
+}
+
+// schedulerState 定时器当前配置：是否暂停、执行间隔、交易对
+func (h *Handler) schedulerState(c *gin.Context) {
+	if sc := h.scheduler(c); sc != nil {
+		c.JSON(http.StatusOK, sc.State())
+	}
+}
+
+// pauseScheduler 暂停自动交易
+func (h *Handler) pauseScheduler(c *gin.Context) {
+	h.updateScheduler(c, func(ctx context.Context, sc orchestrator.SchedulerControl) error {
+		return sc.Pause(ctx)
+	})
+}
+
+// resumeScheduler 恢复自动交易
+func (h *Handler) resumeScheduler(c *gin.Context) {
+	h.updateScheduler(c, func(ctx context.Context, sc orchestrator.SchedulerControl) error {
+		return sc.Resume(ctx)
+	})
+}
+
+type schedulerIntervalRequest struct {
+	IntervalSec int `json:"interval_sec"`
+}
+
+// setSchedulerInterval 调整自动交易执行间隔（秒）
+func (h *Handler) setSchedulerInterval(c *gin.Context) {
+	var req schedulerIntervalRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.IntervalSec <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing interval_sec"})
+		return
+	}
+	h.updateScheduler(c, func(ctx context.Context, sc orchestrator.SchedulerControl) error {
+		return sc.SetInterval(ctx, req.IntervalSec)
+	})
+}
+
+type schedulerPairRequest struct {
+	Pair string `json:"pair"`
+}
+
+// addSchedulerPair 添加自动交易的交易对
+func (h *Handler) addSchedulerPair(c *gin.Context) {
+	var req schedulerPairRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Pair) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing pair"})
+		return
+	}
+	pair := pairFromParam(req.Pair)
+	h.updateScheduler(c, func(ctx context.Context, sc orchestrator.SchedulerControl) error {
+		return sc.AddPair(ctx, pair)
+	})
+}
+
+// removeSchedulerPair 移除自动交易的交易对
+func (h *Handler) removeSchedulerPair(c *gin.Context) {
+	pair := pairFromParam(c.Param("pair"))
+	h.updateScheduler(c, func(ctx context.Context, sc orchestrator.SchedulerControl) error {
+		return sc.RemovePair(ctx, pair)
+	})
+}
+
+// updateScheduler 执行定时器修改并返回修改后的配置
+func (h *Handler) updateScheduler(c *gin.Context, fn func(context.Context, orchestrator.SchedulerControl) error) {
+	sc := h.scheduler(c)
+	if sc == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	if err := fn(ctx, sc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sc.State())
+}
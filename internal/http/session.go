@@ -0,0 +1,152 @@
+package httpapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const sessionCookieName = "aq_session"
+
+// publicPaths 未登录也可访问的路径
+var publicPaths = map[string]bool{
+	"/login":            true,
+	"/static/style.css": true,
+	"/api/v1/health":    true,
+}
+
+// SessionAuth 单用户密码登录：bcrypt 校验密码，服务端保存会话，Cookie 只存随机令牌
+type SessionAuth struct {
+	passwordHash []byte
+	ttl          time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]time.Time // token → 过期时间
+}
+
+// NewSessionAuth 创建会话认证，passwordHash 为空时返回 nil（不启用登录）
+func NewSessionAuth(passwordHash string, ttlHours int) *SessionAuth {
+	passwordHash = strings.TrimSpace(passwordHash)
+	if passwordHash == "" {
+		return nil
+	}
+	if _, err := bcrypt.Cost([]byte(passwordHash)); err != nil {
+		log.Fatalf("UI_PASSWORD_HASH 不是有效的 bcrypt 哈希: %v", err)
+	}
+	if ttlHours <= 0 {
+		ttlHours = 168
+	}
+	return &SessionAuth{
+		passwordHash: []byte(passwordHash),
+		ttl:          time.Duration(ttlHours) * time.Hour,
+		sessions:     make(map[string]time.Time),
+	}
+}
+
+func (a *SessionAuth) valid(c *gin.Context) bool {
+	token, err := c.Cookie(sessionCookieName)
+	if err != nil || token == "" {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	exp, ok := a.sessions[token]
+	if !ok {
+		return false
+	}
+	if time.Now().After(exp) {
+		delete(a.sessions, token)
+		return false
+	}
+	return true
+}
+
+type loginRequest struct {
+	Password string `json:"password" form:"password"`
+}
+
+// loginPage 登录页
+func (a *SessionAuth) loginPage(c *gin.Context) {
+	if a.valid(c) {
+		c.Redirect(http.StatusFound, "/")
+		return
+	}
+	c.File("./client/login.html")
+}
+
+// login 校验密码并下发会话 Cookie
+func (a *SessionAuth) login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBind(&req); err != nil || req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing password"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword(a.passwordHash, []byte(req.Password)); err != nil {
+		// 固定延迟，减缓暴力破解
+		time.Sleep(time.Second)
+		log.Printf("[登录] ✘ 密码错误 request_id=%s client_ip=%s", requestIDFrom(c), c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid password"})
+		return
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+	token := hex.EncodeToString(buf)
+
+	now := time.Now()
+	a.mu.Lock()
+	// 顺带清理过期会话
+	for t, exp := range a.sessions {
+		if now.After(exp) {
+			delete(a.sessions, t)
+		}
+	}
+	a.sessions[token] = now.Add(a.ttl)
+	a.mu.Unlock()
+
+	a.setCookie(c, token, int(a.ttl.Seconds()))
+	log.Printf("[登录] ✔ 登录成功 client_ip=%s", c.ClientIP())
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// logout 删除会话并清除 Cookie
+func (a *SessionAuth) logout(c *gin.Context) {
+	if token, err := c.Cookie(sessionCookieName); err == nil {
+		a.mu.Lock()
+		delete(a.sessions, token)
+		a.mu.Unlock()
+	}
+	a.setCookie(c, "", -1)
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// setCookie HttpOnly + SameSite=Lax（OAuth 回调跳转需要携带 Cookie），HTTPS 下加 Secure
+func (a *SessionAuth) setCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// registerRoutes 注册登录相关路由（会话校验由 authMiddleware 统一完成）
+func (a *SessionAuth) registerRoutes(router *gin.Engine) {
+	router.GET("/login", a.loginPage)
+	router.POST("/login", a.login)
+	router.POST("/logout", a.logout)
+}
@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"ai_quant/internal/orchestrator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sharePathPrefix 只读分享看板的公开路径，凭令牌访问，不需要登录或 API Key
+const sharePathPrefix = "/share/"
+
+// listShareLinks 全部分享链接（不含令牌）
+func (h *Handler) listShareLinks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	links, err := h.service.ListShareLinks(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"links": links})
+}
+
+// createShareLink 创建只读分享链接，令牌与地址只在本次响应中返回
+func (h *Handler) createShareLink(c *gin.Context) {
+	var req orchestrator.CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	link, token, err := h.service.CreateShareLink(ctx, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"link":  link,
+		"token": token,
+		"path":  sharePathPrefix + token,
+	})
+}
+
+// revokeShareLink 撤销分享链接
+func (h *Handler) revokeShareLink(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	if err := h.service.RevokeShareLink(ctx, c.Param("id")); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, orchestrator.ErrShareNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "分享链接已撤销"})
+}
+
+// sharedDashboard 凭令牌访问的只读看板
+func (h *Handler) sharedDashboard(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	dash, err := h.service.SharedDashboard(ctx, c.Param("token"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, orchestrator.ErrShareNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, dash)
+}
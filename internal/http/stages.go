@@ -0,0 +1,32 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pipelineStages 流水线各环节的启用状态
+func (h *Handler) pipelineStages(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"stages": h.service.PipelineStages()})
+}
+
+type setStageRequest struct {
+	Stage   string `json:"stage"`
+	Enabled *bool  `json:"enabled"`
+}
+
+// setPipelineStage 运行时启用 / 关闭流水线环节（重启后恢复为 DISABLED_STAGES 配置）
+func (h *Handler) setPipelineStage(c *gin.Context) {
+	var req setStageRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Stage == "" || req.Enabled == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing stage or enabled"})
+		return
+	}
+	st, err := h.service.SetPipelineStage(req.Stage, *req.Enabled)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stages": st})
+}
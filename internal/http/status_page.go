@@ -0,0 +1,150 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"ai_quant/internal/domain"
+	"ai_quant/internal/market"
+	"ai_quant/internal/orchestrator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusPagePath 服务端渲染的轻量状态页，不依赖前端 SPA，便于手机快速查看
+const statusPagePath = "/status"
+
+// sparkline 权益曲线 SVG 尺寸，与模板中的 viewBox 一致
+const (
+	sparkWidth  = 320
+	sparkHeight = 60
+)
+
+// statusView 状态页模板数据
+type statusView struct {
+	orchestrator.StatusPage
+	Spark    string // SVG polyline 的 points
+	Degraded int
+}
+
+// sparkPoints 把权益快照缩放到 sparkline 画布上，少于两个点时返回空串
+func sparkPoints(snaps []domain.EquitySnapshot) string {
+	if len(snaps) < 2 {
+		return ""
+	}
+	lo, hi := snaps[0].EquityUSDT, snaps[0].EquityUSDT
+	for _, e := range snaps {
+		lo = min(lo, e.EquityUSDT)
+		hi = max(hi, e.EquityUSDT)
+	}
+	span := hi - lo
+	var b strings.Builder
+	for i, e := range snaps {
+		x := float64(i) / float64(len(snaps)-1) * sparkWidth
+		y := float64(sparkHeight) / 2
+		if span > 0 {
+			y = sparkHeight - (e.EquityUSDT-lo)/span*(sparkHeight-4) - 2
+		}
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%.1f,%.1f", x, y)
+	}
+	return b.String()
+}
+
+var statusTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"clock":  func(t interface{ Format(string) string }) string { return t.Format("01-02 15:04") },
+	"usd":    func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"pct":    func(v float64) string { return fmt.Sprintf("%+.2f%%", v) },
+	"conf":   func(v float64) string { return fmt.Sprintf("%.0f%%", v*100) },
+	"srcBad": func(s string) bool { return s == market.SourceStale || s == market.SourceFailing },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<meta http-equiv="refresh" content="60">
+<title>AI Quant 状态</title>
+<style>
+body{font-family:-apple-system,system-ui,sans-serif;background:#0f1117;color:#e4e6eb;margin:0;padding:12px;font-size:14px}
+h1{font-size:18px;margin:0 0 8px}h2{font-size:15px;margin:16px 0 6px;color:#9aa0aa}
+.card{background:#1a1d27;border-radius:8px;padding:10px 12px;margin-bottom:10px}
+.big{font-size:24px;font-weight:600}.dim{color:#8a909c;font-size:12px}
+.up{color:#22c55e}.down{color:#ef4444}.warn{color:#f59e0b}
+table{width:100%;border-collapse:collapse}td{padding:4px 2px;border-bottom:1px solid #262a36;font-size:13px}
+svg{width:100%;height:60px}
+</style>
+</head>
+<body>
+<h1>AI Quant 状态</h1>
+<div class="dim">更新于 {{clock .GeneratedAt}}，每分钟自动刷新</div>
+{{if .Halted}}<div class="card down">⚠ 回撤熔断中，已暂停新开仓</div>{{end}}
+
+<div class="card">
+  <div class="dim">权益（近 7 天）</div>
+  {{if .Equity}}
+  <div class="big">{{usd .EquityUSDT}} U <span class="{{if ge .EquityDelta 0.0}}up{{else}}down{{end}}" style="font-size:14px">{{pct .EquityDelta}}</span></div>
+  {{if .Spark}}<svg viewBox="0 0 320 60" preserveAspectRatio="none"><polyline fill="none" stroke="#3b82f6" stroke-width="2" points="{{.Spark}}"/></svg>{{end}}
+  {{else}}<div class="dim">暂无权益快照</div>{{end}}
+</div>
+
+<h2>最近周期</h2>
+<div class="card">
+  {{if .Cycles}}<table>
+  {{range .Cycles}}<tr>
+    <td class="dim">{{clock .CreatedAt}}</td>
+    <td>{{.Pair}}</td>
+    <td>{{if .SignalSide}}{{.SignalSide}} {{conf .Confidence}}{{else}}-{{end}}</td>
+    <td class="{{if eq .Status "success"}}up{{else if eq .Status "failed"}}down{{else if eq .Status "rejected"}}warn{{end}}">{{.Status}}</td>
+  </tr>{{end}}
+  </table>{{else}}<div class="dim">暂无周期</div>{{end}}
+</div>
+
+<h2>数据源{{if .Degraded}} <span class="warn">{{.Degraded}} 个异常</span>{{end}}</h2>
+<div class="card">
+  {{if .Sources}}<table>
+  {{range .Sources}}<tr>
+    <td>{{.Name}}</td>
+    <td class="{{if srcBad .Status}}down{{else}}up{{end}}">{{.Status}}{{if .Disabled}} (已停用){{end}}</td>
+    <td class="dim">{{if .LastSuccessAt}}{{clock .LastSuccessAt}}{{else}}-{{end}}</td>
+  </tr>{{end}}
+  </table>{{else}}<div class="dim">暂无调用记录</div>{{end}}
+</div>
+
+<h2>大模型花费</h2>
+<div class="card">
+  <table>
+  <tr><td>今日</td><td>{{usd .Costs.TodayUSD}} USD{{if gt .Costs.DailyLimit 0.0}} / {{usd .Costs.DailyLimit}}{{end}}</td></tr>
+  <tr><td>本月</td><td>{{usd .Costs.MonthUSD}} USD{{if gt .Costs.MonthlyLimit 0.0}} / {{usd .Costs.MonthlyLimit}}{{end}}</td></tr>
+  </table>
+  {{if .Costs.BudgetBlocked}}<div class="down">已达预算上限，周期跳过大模型</div>{{end}}
+</div>
+</body>
+</html>`))
+
+// statusPage 服务端渲染的状态页：权益曲线、最近周期、数据源健康与大模型花费
+func (h *Handler) statusPage(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	page, err := h.service.StatusPage(ctx)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "加载状态失败: %v", err)
+		return
+	}
+	view := statusView{StatusPage: page, Spark: sparkPoints(page.Equity)}
+	for _, s := range page.Sources {
+		if s.Status == market.SourceStale || s.Status == market.SourceFailing {
+			view.Degraded++
+		}
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := statusTemplate.Execute(c.Writer, view); err != nil {
+		c.Status(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,73 @@
+// Package llmcost 按模型计算大模型调用成本（价格单位：USD / 百万 token）。
+package llmcost
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Price 单个模型的 token 价格（USD / 百万 token）
+type Price struct {
+	PromptPerM     float64 `json:"prompt_per_m"`
+	CompletionPerM float64 `json:"completion_per_m"`
+}
+
+// Table 模型价格表：按模型名精确匹配，其次匹配去掉 "provider/" 前缀的名称，都没有时使用默认价格
+type Table struct {
+	Default Price
+	models  map[string]Price
+}
+
+// ParseTable 解析 "openai/gpt-4o=2.5/10,deepseek/deepseek-chat=0.27/1.1" 格式的模型价格（提示词/回复）
+func ParseTable(def Price, spec string) (*Table, error) {
+	t := &Table{Default: def, models: make(map[string]Price)}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		model, prices, ok := strings.Cut(item, "=")
+		model = strings.ToLower(strings.TrimSpace(model))
+		promptStr, completionStr, ok2 := strings.Cut(prices, "/")
+		if !ok || !ok2 || model == "" {
+			return nil, fmt.Errorf("模型价格配置格式错误: %q（应为 gpt-4o=2.5/10）", item)
+		}
+		prompt, err1 := strconv.ParseFloat(strings.TrimSpace(promptStr), 64)
+		completion, err2 := strconv.ParseFloat(strings.TrimSpace(completionStr), 64)
+		if err1 != nil || err2 != nil || prompt < 0 || completion < 0 {
+			return nil, fmt.Errorf("模型价格配置格式错误: %q（价格须为非负数）", item)
+		}
+		t.models[model] = Price{PromptPerM: prompt, CompletionPerM: completion}
+	}
+	return t, nil
+}
+
+// Price 返回模型价格
+func (t *Table) Price(model string) Price {
+	m := strings.ToLower(strings.TrimSpace(model))
+	if p, ok := t.models[m]; ok {
+		return p
+	}
+	if _, short, ok := strings.Cut(m, "/"); ok {
+		if p, ok := t.models[short]; ok {
+			return p
+		}
+	}
+	return t.Default
+}
+
+// Cost 按 token 数计算成本（USD）
+func (t *Table) Cost(model string, promptTokens, completionTokens int) float64 {
+	p := t.Price(model)
+	return (float64(promptTokens)*p.PromptPerM + float64(completionTokens)*p.CompletionPerM) / 1e6
+}
+
+// Models 返回单独配置了价格的模型
+func (t *Table) Models() map[string]Price {
+	out := make(map[string]Price, len(t.models))
+	for k, v := range t.models {
+		out[k] = v
+	}
+	return out
+}
@@ -9,6 +9,9 @@ import (
 	"net/http"
 	"strconv"
 	"time"
+
+	"ai_quant/internal/exchangeinfo"
+	"ai_quant/internal/ratelimit"
 )
 
 const (
@@ -41,6 +44,7 @@ type SentimentData struct {
 type CoinSnapshot struct {
 	Pair         string
 	Price        float64
+	TickSize     float64 // exchangeInfo PRICE_FILTER 价格步长，0 = 未知
 	Change24hPct float64
 	FundingRate  float64
 	OpenInterest float64
@@ -66,6 +70,9 @@ type CoinSnapshot struct {
 
 	// Google Trends daily trending check (free)
 	GoogleTrends GoogleTrendsData
+
+	// 获取失败的数据组件（Component* 常量），由降级策略决定继续 / 观望 / 中止
+	Missing []string
 }
 
 // Client fetches market data from Binance public APIs (no API key required).
@@ -73,12 +80,14 @@ type Client struct {
 	http           *http.Client
 	CryptoPanicKey string // 可选，为空则跳过新闻获取
 	LunarCrushKey  string // 可选，为空则跳过社交数据获取
+	endpoints      Endpoints
 }
 
 // NewClient creates a Binance market data client.
 func NewClient() *Client {
 	return &Client{
-		http: &http.Client{Timeout: 10 * time.Second},
+		http:      ratelimit.NewClient(10 * time.Second),
+		endpoints: DefaultEndpoints(),
 	}
 }
 
@@ -98,47 +107,61 @@ func (c *Client) FetchSnapshot(ctx context.Context, pair string) (CoinSnapshot,
 	}
 	snap.Price = ticker.LastPrice
 	snap.Change24hPct = ticker.PriceChangePercent
+	snap.TickSize, _ = exchangeinfo.Spot.TickSize(ctx, symbol)
 
-	// 2. Short-term klines (5m, last 50 candles ≈ 4 hours)
-	shortKlines, err := c.fetchKlines(ctx, symbol, "5m", 50)
-	if err != nil {
-		return snap, fmt.Errorf("klines 5m %s: %w", symbol, err)
+	missing := func(component string, ok bool) {
+		if !ok {
+			snap.Missing = append(snap.Missing, component)
+		}
 	}
-	snap.ShortKlines = shortKlines
 
+	// 2. Short-term klines (5m, last 50 candles ≈ 4 hours)
 	// 3. Long-term klines (4h, last 30 candles ≈ 5 days)
-	longKlines, err := c.fetchKlines(ctx, symbol, "4h", 30)
-	if err != nil {
-		return snap, fmt.Errorf("klines 4h %s: %w", symbol, err)
+	shortKlines, shortErr := c.fetchKlines(ctx, symbol, "5m", 50)
+	longKlines, longErr := c.fetchKlines(ctx, symbol, "4h", 30)
+	snap.ShortKlines, snap.LongKlines = shortKlines, longKlines
+	if shortErr != nil || longErr != nil {
+		log.Printf("[行情] ⚠ %s K线获取失败: 5m=%v 4h=%v", symbol, shortErr, longErr)
 	}
-	snap.LongKlines = longKlines
+	missing(ComponentKlines, shortErr == nil && longErr == nil)
 
 	// 4. Funding rate (futures, best effort)
-	funding, _ := c.fetchFundingRate(ctx, symbol)
+	funding, err := c.fetchFundingRate(ctx, symbol)
+	recordFetch(SourceFunding, err)
 	snap.FundingRate = funding
+	missing(ComponentFunding, err == nil)
 
 	// 5. Open interest (futures, best effort)
-	oi, _ := c.fetchOpenInterest(ctx, symbol)
+	oi, err := c.fetchOpenInterest(ctx, symbol)
 	snap.OpenInterest = oi
+	missing(ComponentOpenInterest, err == nil)
 
 	// 6. Sentiment (all best effort, failures won't block)
-	snap.Sentiment.LongShortRatio, _ = c.fetchRatio(ctx, symbol, "globalLongShortAccountRatio")
-	snap.Sentiment.TopLongShortRatio, _ = c.fetchRatio(ctx, symbol, "topLongShortAccountRatio")
-	snap.Sentiment.TopPositionRatio, _ = c.fetchRatio(ctx, symbol, "topLongShortPositionRatio")
-	snap.Sentiment.TakerBuySellRatio, _ = c.fetchRatio(ctx, symbol, "takerlongshortRatio")
-	snap.Sentiment.FearGreedIndex, snap.Sentiment.FearGreedLabel, _ = fetchFearGreedIndex(ctx, c.http)
-
-	// 7. News from CryptoPanic (best effort, empty key or failure → skip)
-	snap.News = c.fetchNews(ctx, pair)
+	var e1, e2, e3, e4 error
+	snap.Sentiment.LongShortRatio, e1 = c.fetchRatio(ctx, symbol, "globalLongShortAccountRatio")
+	snap.Sentiment.TopLongShortRatio, e2 = c.fetchRatio(ctx, symbol, "topLongShortAccountRatio")
+	snap.Sentiment.TopPositionRatio, e3 = c.fetchRatio(ctx, symbol, "topLongShortPositionRatio")
+	snap.Sentiment.TakerBuySellRatio, e4 = c.fetchRatio(ctx, symbol, "takerlongshortRatio")
+	missing(ComponentSentiment, e1 == nil && e2 == nil && e3 == nil && e4 == nil)
+	snap.Sentiment.FearGreedIndex, snap.Sentiment.FearGreedLabel = c.sharedFearGreed(ctx)
+	missing(ComponentFearGreed, snap.Sentiment.FearGreedLabel != "")
+
+	// 7. News from CryptoPanic (best effort, empty key → skip)
+	var ok bool
+	snap.News, ok = c.fetchNews(ctx, pair)
+	missing(ComponentNews, ok)
 
 	// 8. Social media metrics from LunarCrush (best effort)
-	snap.Social = c.fetchSocialMetrics(ctx, pair)
+	snap.Social, ok = c.fetchSocialMetrics(ctx, pair)
+	missing(ComponentSocial, ok)
 
 	// 9. CoinGecko community & trending (free, no key needed)
-	snap.CoinGecko = c.fetchCoinGeckoData(ctx, pair)
+	snap.CoinGecko, ok = c.fetchCoinGeckoData(ctx, pair)
+	missing(ComponentCoinGecko, ok)
 
 	// 10. Google Trends daily trending check (free)
-	snap.GoogleTrends = c.fetchGoogleTrends(ctx, pair)
+	snap.GoogleTrends, ok = c.fetchGoogleTrends(ctx, pair)
+	missing(ComponentGoogleTrends, ok)
 
 	return snap, nil
 }
@@ -157,9 +180,38 @@ func (c *Client) FetchPrice(ctx context.Context, pair string) (float64, error) {
 	return strconv.ParseFloat(result.Price, 64)
 }
 
+// FetchATR 最近 period 根 interval K 线的 ATR（平均真实波幅，价格单位）
+func (c *Client) FetchATR(ctx context.Context, pair, interval string, period int) (float64, error) {
+	klines, err := c.fetchKlines(ctx, pairToSymbol(pair), interval, period*3)
+	if err != nil {
+		return 0, err
+	}
+	if len(klines) <= period {
+		return 0, fmt.Errorf("%s K线数量不足: %d", pair, len(klines))
+	}
+	highs := make([]float64, len(klines))
+	lows := make([]float64, len(klines))
+	closes := make([]float64, len(klines))
+	for i, k := range klines {
+		highs[i], lows[i], closes[i] = k.High, k.Low, k.Close
+	}
+	atr := ATR(highs, lows, closes, period)
+	if len(atr) == 0 || atr[len(atr)-1] <= 0 {
+		return 0, fmt.Errorf("%s ATR 计算失败", pair)
+	}
+	return atr[len(atr)-1], nil
+}
+
 // FetchLightSnapshot 轻量级快照：只获取价格、涨跌幅、短期K线和资金费率
 // 用于关联币对参考（如 BTC），不拉新闻/社交/情绪等耗时数据
+// 调度器同一 tick 内多个交易对共用参考币对时只拉取一次（见 WithTickCache）
 func (c *Client) FetchLightSnapshot(ctx context.Context, pair string) (CoinSnapshot, error) {
+	return c.sharedLightSnapshot(ctx, pair, func() (CoinSnapshot, error) {
+		return c.fetchLightSnapshot(ctx, pair)
+	})
+}
+
+func (c *Client) fetchLightSnapshot(ctx context.Context, pair string) (CoinSnapshot, error) {
 	symbol := pairToSymbol(pair)
 	snap := CoinSnapshot{
 		Pair:          pair,
@@ -173,6 +225,7 @@ func (c *Client) FetchLightSnapshot(ctx context.Context, pair string) (CoinSnaps
 	}
 	snap.Price = ticker.LastPrice
 	snap.Change24hPct = ticker.PriceChangePercent
+	snap.TickSize, _ = exchangeinfo.Spot.TickSize(ctx, symbol)
 
 	// 2. 短期 K 线（5m x 50 = 4h，用于计算 RSI）
 	shortKlines, err := c.fetchKlines(ctx, symbol, "5m", 50)
@@ -183,7 +236,8 @@ func (c *Client) FetchLightSnapshot(ctx context.Context, pair string) (CoinSnaps
 	}
 
 	// 3. 资金费率（参考指标）
-	snap.FundingRate, _ = c.fetchFundingRate(ctx, symbol)
+	snap.FundingRate, err = c.fetchFundingRate(ctx, symbol)
+	recordFetch(SourceFunding, err)
 
 	return snap, nil
 }
@@ -211,6 +265,12 @@ func (c *Client) fetch24hTicker(ctx context.Context, symbol string) (tickerResul
 }
 
 func (c *Client) fetchKlines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error) {
+	return cachedFetchKlines(fmt.Sprintf("%s|%s|%d", symbol, interval, limit), func() ([]Kline, error) {
+		return c.fetchKlinesRaw(ctx, symbol, interval, limit)
+	})
+}
+
+func (c *Client) fetchKlinesRaw(ctx context.Context, symbol, interval string, limit int) ([]Kline, error) {
 	url := fmt.Sprintf("%s/api/v3/klines?symbol=%s&interval=%s&limit=%d",
 		binanceSpotBase, symbol, interval, limit)
 
@@ -289,14 +349,12 @@ func (c *Client) fetchRatio(ctx context.Context, symbol, endpoint string) (float
 }
 
 // fetchFearGreedIndex gets Fear & Greed Index from alternative.me (best effort).
-func fetchFearGreedIndex(ctx context.Context, client *http.Client) (int, string, error) {
-	url := "https://api.alternative.me/fng/?limit=1"
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+func (c *Client) fetchFearGreedIndex(ctx context.Context) (int, string, error) {
+	req, err := c.newRequest(ctx, SourceFearGreed, c.endpoints.FearGreed+"/fng/?limit=1")
 	if err != nil {
 		return 0, "", err
 	}
-	resp, err := client.Do(req)
+	resp, err := c.http.Do(req)
 	if err != nil {
 		return 0, "", err
 	}
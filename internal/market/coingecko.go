@@ -6,11 +6,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strings"
 )
 
-const coingeckoBase = "https://api.coingecko.com/api/v3"
-
 // CoinGeckoData 保存 CoinGecko 社区与趋势数据
 type CoinGeckoData struct {
 	// 是否在 CoinGecko 热门趋势中（top 15）
@@ -44,43 +43,57 @@ func coinToGeckoID(pair string) string {
 }
 
 // fetchCoinGeckoData 从 CoinGecko 获取趋势和社区数据。
-// 完全免费，无需 API key。失败时静默跳过。
-func (c *Client) fetchCoinGeckoData(ctx context.Context, pair string) CoinGeckoData {
+// 完全免费，无需 API key。失败时跳过对应部分，ok=false 表示至少一项请求失败。
+func (c *Client) fetchCoinGeckoData(ctx context.Context, pair string) (CoinGeckoData, bool) {
 	var data CoinGeckoData
 	coinID := coinToGeckoID(pair)
 	symbol := strings.ToUpper(strings.Split(pair, "/")[0])
 
 	// 1. 检查是否在趋势榜
-	data.IsTrending, data.TrendingRank = c.checkCoinGeckoTrending(ctx, symbol)
+	var trendingOK bool
+	data.IsTrending, data.TrendingRank, trendingOK = c.checkCoinGeckoTrending(ctx, symbol)
 	if data.IsTrending {
 		log.Printf("[社区] %s 在 CoinGecko 趋势榜排名 #%d 🔥", symbol, data.TrendingRank)
 	}
 
 	// 2. 获取社区数据
-	c.fetchCoinGeckoCommunity(ctx, coinID, &data)
+	communityOK := c.fetchCoinGeckoCommunity(ctx, coinID, &data)
 
-	return data
+	return data, trendingOK && communityOK
 }
 
-// checkCoinGeckoTrending 检查币种是否在 CoinGecko 趋势 top 15
-func (c *Client) checkCoinGeckoTrending(ctx context.Context, symbol string) (bool, int) {
-	url := coingeckoBase + "/search/trending"
+// checkCoinGeckoTrending 检查币种是否在 CoinGecko 趋势 top 15，最后一个返回值表示请求是否成功
+func (c *Client) checkCoinGeckoTrending(ctx context.Context, symbol string) (bool, int, bool) {
+	symbols, err := c.TrendingSymbols(ctx)
+	if err != nil {
+		log.Printf("[社区] CoinGecko trending 获取失败: %v，跳过", err)
+		return false, 0, false
+	}
+	for i, s := range symbols {
+		if strings.EqualFold(s, symbol) {
+			return true, i + 1, true
+		}
+	}
+	return false, 0, true
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// TrendingSymbols 获取 CoinGecko 热门趋势币种（大写），按热度排序
+func (c *Client) TrendingSymbols(ctx context.Context) ([]string, error) {
+	req, err := c.newRequest(ctx, SourceCoinGecko, c.endpoints.CoinGecko+"/search/trending")
 	if err != nil {
-		return false, 0
+		return nil, err
 	}
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		log.Printf("[社区] CoinGecko trending 请求失败: %v，跳过", err)
-		return false, 0
+		recordFetch(SourceCoinGecko, err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("[社区] CoinGecko trending 返回 HTTP %d，跳过", resp.StatusCode)
-		return false, 0
+		recordFailure(SourceCoinGecko, "trending HTTP %d", resp.StatusCode)
+		return nil, fmt.Errorf("trending HTTP %d", resp.StatusCode)
 	}
 
 	var result struct {
@@ -91,44 +104,44 @@ func (c *Client) checkCoinGeckoTrending(ctx context.Context, symbol string) (boo
 			} `json:"item"`
 		} `json:"coins"`
 	}
-
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Printf("[社区] 解析 CoinGecko trending 失败: %v", err)
-		return false, 0
+		recordFetch(SourceCoinGecko, err)
+		return nil, err
 	}
+	recordFetch(SourceCoinGecko, nil)
 
+	sort.SliceStable(result.Coins, func(i, j int) bool { return result.Coins[i].Item.Score < result.Coins[j].Item.Score })
+	symbols := make([]string, 0, len(result.Coins))
 	for _, coin := range result.Coins {
-		if strings.EqualFold(coin.Item.Symbol, symbol) {
-			rank := coin.Item.Score + 1 // score 0 → rank 1
-			return true, rank
-		}
+		symbols = append(symbols, strings.ToUpper(coin.Item.Symbol))
 	}
-
-	return false, 0
+	return symbols, nil
 }
 
-// fetchCoinGeckoCommunity 获取币种的社区指标
-func (c *Client) fetchCoinGeckoCommunity(ctx context.Context, coinID string, data *CoinGeckoData) {
+// fetchCoinGeckoCommunity 获取币种的社区指标，返回请求是否成功
+func (c *Client) fetchCoinGeckoCommunity(ctx context.Context, coinID string, data *CoinGeckoData) bool {
 	url := fmt.Sprintf(
 		"%s/coins/%s?localization=false&tickers=false&market_data=false&community_data=true&developer_data=false&sparkline=false",
-		coingeckoBase, coinID,
+		c.endpoints.CoinGecko, coinID,
 	)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := c.newRequest(ctx, SourceCoinGecko, url)
 	if err != nil {
-		return
+		return false
 	}
 
 	resp, err := c.http.Do(req)
 	if err != nil {
 		log.Printf("[社区] CoinGecko coin detail 请求失败: %v，跳过社区数据", err)
-		return
+		recordFetch(SourceCoinGecko, err)
+		return false
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("[社区] CoinGecko coin detail 返回 HTTP %d，跳过社区数据", resp.StatusCode)
-		return
+		recordFailure(SourceCoinGecko, "coin detail HTTP %d", resp.StatusCode)
+		return false
 	}
 
 	var result struct {
@@ -145,8 +158,10 @@ func (c *Client) fetchCoinGeckoCommunity(ctx context.Context, coinID string, dat
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		log.Printf("[社区] 解析 CoinGecko coin detail 失败: %v", err)
-		return
+		recordFetch(SourceCoinGecko, err)
+		return false
 	}
+	recordFetch(SourceCoinGecko, nil)
 
 	data.CommunityScore = result.CommunityScore
 	data.SentimentVotesUpPct = result.SentimentUp
@@ -158,4 +173,5 @@ func (c *Client) fetchCoinGeckoCommunity(ctx context.Context, coinID string, dat
 	log.Printf("[社区] CoinGecko %s: 社区评分=%.0f 看涨投票=%.1f%% Twitter粉丝=%d Reddit订阅=%d",
 		coinID, data.CommunityScore, data.SentimentVotesUpPct,
 		data.TwitterFollowers, data.RedditSubscribers)
+	return true
 }
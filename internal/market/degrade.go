@@ -0,0 +1,95 @@
+package market
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 行情快照的数据组件（用于降级策略）
+const (
+	ComponentTicker       = "ticker"        // 24h 行情（价格）
+	ComponentKlines       = "klines"        // 5m / 4h K 线
+	ComponentFunding      = "funding"       // 资金费率
+	ComponentOpenInterest = "open_interest" // 持仓量
+	ComponentSentiment    = "sentiment"     // 多空比 / 主动买卖比
+	ComponentFearGreed    = "fear_greed"    // 恐慌贪婪指数
+	ComponentNews         = "news"          // CryptoPanic 新闻
+	ComponentSocial       = "social"        // LunarCrush 社交数据
+	ComponentCoinGecko    = "coingecko"     // CoinGecko 社区 / 趋势
+	ComponentGoogleTrends = "google_trends" // Google 热搜
+)
+
+// DegradeAction 数据组件缺失时的处理方式
+type DegradeAction string
+
+const (
+	DegradeProceed DegradeAction = "proceed" // 照常继续，缺失部分不进入提示词
+	DegradeHold    DegradeAction = "hold"    // 不调用大模型，本轮观望
+	DegradeAbort   DegradeAction = "abort"   // 中止周期并记为失败
+)
+
+// severity 多个组件缺失时取最严格的处理
+var severity = map[DegradeAction]int{DegradeProceed: 0, DegradeHold: 1, DegradeAbort: 2}
+
+// DegradePolicy 组件 -> 缺失时的处理方式，未列出的组件按 proceed 处理
+type DegradePolicy map[string]DegradeAction
+
+// DefaultDegradePolicy 价格和 K 线缺失时中止（指标全部失真），其余辅助数据缺失照常继续
+func DefaultDegradePolicy() DegradePolicy {
+	return DegradePolicy{
+		ComponentTicker: DegradeAbort,
+		ComponentKlines: DegradeAbort,
+	}
+}
+
+// ParseDegradePolicy 解析 "klines=abort,funding=hold,news=proceed"，在默认策略基础上覆盖
+func ParseDegradePolicy(spec string) (DegradePolicy, error) {
+	p := DefaultDegradePolicy()
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		comp, action, ok := strings.Cut(item, "=")
+		comp = strings.ToLower(strings.TrimSpace(comp))
+		a := DegradeAction(strings.ToLower(strings.TrimSpace(action)))
+		if _, known := severity[a]; !ok || !known {
+			return nil, fmt.Errorf("降级策略格式错误: %q（应为 klines=abort，动作为 proceed/hold/abort）", item)
+		}
+		if !knownComponent(comp) {
+			return nil, fmt.Errorf("未知的数据组件: %q", comp)
+		}
+		p[comp] = a
+	}
+	return p, nil
+}
+
+func knownComponent(c string) bool {
+	switch c {
+	case ComponentTicker, ComponentKlines, ComponentFunding, ComponentOpenInterest, ComponentSentiment,
+		ComponentFearGreed, ComponentNews, ComponentSocial, ComponentCoinGecko, ComponentGoogleTrends:
+		return true
+	}
+	return false
+}
+
+// Decide 根据缺失的组件返回最严格的处理方式，以及导致该处理的组件
+func (p DegradePolicy) Decide(missing []string) (DegradeAction, []string) {
+	action := DegradeProceed
+	var blocking []string
+	for _, m := range missing {
+		a, ok := p[m]
+		if !ok {
+			a = DegradeProceed
+		}
+		switch {
+		case severity[a] > severity[action]:
+			action, blocking = a, []string{m}
+		case a == action && a != DegradeProceed:
+			blocking = append(blocking, m)
+		}
+	}
+	sort.Strings(blocking)
+	return action, blocking
+}
@@ -0,0 +1,99 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Endpoints 第三方数据源的基础地址与额外请求头，用于在访问受限的地区走镜像或缓存代理
+type Endpoints struct {
+	CryptoPanic string
+	LunarCrush  string
+	CoinGecko   string
+	FearGreed   string
+	Headers     map[string]http.Header // 数据源（Source* 常量）-> 额外请求头
+}
+
+// DefaultEndpoints 各数据源的官方地址
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		CryptoPanic: "https://cryptopanic.com/api/v1",
+		LunarCrush:  "https://lunarcrush.com/api4",
+		CoinGecko:   "https://api.coingecko.com/api/v3",
+		FearGreed:   "https://api.alternative.me",
+	}
+}
+
+// SetEndpoints 覆盖数据源地址，空字段保留官方地址
+func (c *Client) SetEndpoints(e Endpoints) {
+	def := DefaultEndpoints()
+	pick := func(v, fallback string) string {
+		if v = strings.TrimRight(strings.TrimSpace(v), "/"); v != "" {
+			return v
+		}
+		return fallback
+	}
+	c.endpoints = Endpoints{
+		CryptoPanic: pick(e.CryptoPanic, def.CryptoPanic),
+		LunarCrush:  pick(e.LunarCrush, def.LunarCrush),
+		CoinGecko:   pick(e.CoinGecko, def.CoinGecko),
+		FearGreed:   pick(e.FearGreed, def.FearGreed),
+		Headers:     e.Headers,
+	}
+}
+
+// newRequest 创建 GET 请求并附加该数据源配置的请求头
+func (c *Client) newRequest(ctx context.Context, source, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range c.endpoints.Headers[source] {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	return req, nil
+}
+
+// ParseSourceHeaders 解析 "coingecko=x-cg-pro-api-key:KEY;X-Proxy-Token:abc,cryptopanic=..."：
+// 数据源之间用 "," 分隔，同一数据源的多个请求头用 ";" 分隔
+func ParseSourceHeaders(spec string) (map[string]http.Header, error) {
+	result := make(map[string]http.Header)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		source, headers, ok := strings.Cut(item, "=")
+		source = strings.ToLower(strings.TrimSpace(source))
+		if !ok {
+			return nil, fmt.Errorf("请求头配置格式错误: %q（应为 coingecko=Header:value）", item)
+		}
+		switch source {
+		case SourceCryptoPanic, SourceLunarCrush, SourceCoinGecko, SourceFearGreed:
+		default:
+			return nil, fmt.Errorf("未知的数据源: %q（可选 %s/%s/%s/%s）", source,
+				SourceCryptoPanic, SourceLunarCrush, SourceCoinGecko, SourceFearGreed)
+		}
+		h := result[source]
+		if h == nil {
+			h = make(http.Header)
+			result[source] = h
+		}
+		for _, kv := range strings.Split(headers, ";") {
+			if strings.TrimSpace(kv) == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(kv, ":")
+			k = strings.TrimSpace(k)
+			if !ok || k == "" {
+				return nil, fmt.Errorf("请求头格式错误: %q（应为 Header:value）", kv)
+			}
+			h.Add(k, strings.TrimSpace(v))
+		}
+	}
+	return result, nil
+}
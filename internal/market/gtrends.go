@@ -30,8 +30,8 @@ type rssFeed struct {
 
 // fetchGoogleTrends 检查币种是否出现在 Google 每日热搜中。
 // 使用 Google Trends 公开 RSS feed，完全免费，无需 API key。
-// 失败时静默返回空数据。
-func (c *Client) fetchGoogleTrends(ctx context.Context, pair string) GoogleTrendsData {
+// 热搜拉取失败时返回空数据和 ok=false。
+func (c *Client) fetchGoogleTrends(ctx context.Context, pair string) (GoogleTrendsData, bool) {
 	coin := strings.ToLower(strings.Split(pair, "/")[0])
 
 	// 搜索关键词：币名和全称
@@ -40,51 +40,71 @@ func (c *Client) fetchGoogleTrends(ctx context.Context, pair string) GoogleTrend
 	// Google Trends 每日热搜 RSS（美国区，加密货币用户集中）
 	geos := []string{"US"}
 
+	fetched := false
 	for _, geo := range geos {
-		url := "https://trends.google.com/trends/trendingsearches/daily/rss?geo=" + geo
-
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-		if err != nil {
-			continue
-		}
-		req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; AIQuant/1.0)")
-
-		resp, err := c.http.Do(req)
-		if err != nil {
-			log.Printf("[热搜] Google Trends RSS 请求失败: %v，跳过", err)
-			continue
-		}
-
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-
-		if err != nil || resp.StatusCode != http.StatusOK {
-			log.Printf("[热搜] Google Trends RSS 返回 HTTP %d，跳过", resp.StatusCode)
-			continue
-		}
-
-		var feed rssFeed
-		if err := xml.Unmarshal(body, &feed); err != nil {
-			log.Printf("[热搜] 解析 Google Trends RSS 失败: %v", err)
+		titles, ok := c.sharedTrendingTitles(ctx, geo)
+		if !ok {
 			continue
 		}
+		fetched = true
 
 		// 在热搜条目中查找与币种相关的关键词
-		for _, item := range feed.Channel.Items {
-			title := strings.ToLower(item.Title)
+		for _, t := range titles {
+			title := strings.ToLower(t)
 			for _, kw := range keywords {
 				if strings.Contains(title, kw) {
-					log.Printf("[热搜] 🔥 %s 出现在 Google 热搜！匹配: %q", strings.ToUpper(coin), item.Title)
+					log.Printf("[热搜] 🔥 %s 出现在 Google 热搜！匹配: %q", strings.ToUpper(coin), t)
 					return GoogleTrendsData{
 						IsTrending: true,
-						Title:      item.Title,
-					}
+						Title:      t,
+					}, true
 				}
 			}
 		}
 	}
 
-	return GoogleTrendsData{}
+	return GoogleTrendsData{}, fetched
+}
+
+// fetchTrendingTitles 拉取某地区的 Google 每日热搜标题，失败时返回 false
+func (c *Client) fetchTrendingTitles(ctx context.Context, geo string) ([]string, bool) {
+	url := "https://trends.google.com/trends/trendingsearches/daily/rss?geo=" + geo
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; AIQuant/1.0)")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		log.Printf("[热搜] Google Trends RSS 请求失败: %v，跳过", err)
+		recordFetch(SourceGoogleTrends, err)
+		return nil, false
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil || resp.StatusCode != http.StatusOK {
+		log.Printf("[热搜] Google Trends RSS 返回 HTTP %d，跳过", resp.StatusCode)
+		recordFailure(SourceGoogleTrends, "HTTP %d", resp.StatusCode)
+		return nil, false
+	}
+
+	var feed rssFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		log.Printf("[热搜] 解析 Google Trends RSS 失败: %v", err)
+		recordFetch(SourceGoogleTrends, err)
+		return nil, false
+	}
+	recordFetch(SourceGoogleTrends, nil)
+
+	titles := make([]string, 0, len(feed.Channel.Items))
+	for _, item := range feed.Channel.Items {
+		titles = append(titles, item.Title)
+	}
+	return titles, true
 }
 
 // coinToKeywords 将币种缩写映射为搜索关键词列表
@@ -0,0 +1,127 @@
+package market
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 外部数据源名称（用于健康状态统计）
+const (
+	SourceFunding      = "binance_funding"
+	SourceCryptoPanic  = "cryptopanic"
+	SourceLunarCrush   = "lunarcrush"
+	SourceCoinGecko    = "coingecko"
+	SourceFearGreed    = "fear_greed"
+	SourceGoogleTrends = "google_trends"
+)
+
+// staleAfter 超过该时间没有成功拉取即视为数据陈旧
+const staleAfter = time.Hour
+
+// 数据源状态
+const (
+	SourceOK       = "ok"       // 最近一次拉取成功
+	SourceFailing  = "failing"  // 最近一次拉取失败，上次成功仍在有效期内
+	SourceStale    = "stale"    // 超过 staleAfter 没有成功数据，提示词缺少该部分
+	SourceDisabled = "disabled" // 未配置 Key，主动跳过
+	SourceUnknown  = "unknown"  // 本次启动尚未调用
+)
+
+// SourceStatus 单个外部数据源的健康状态
+type SourceStatus struct {
+	Name                string     `json:"name"`
+	Status              string     `json:"status"`
+	Successes           int64      `json:"successes"`
+	Failures            int64      `json:"failures"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	Disabled            bool       `json:"disabled"`
+}
+
+// sourceHealth 进程内记录各数据源的调用结果（所有 Client 共享）
+var sourceHealth = struct {
+	mu      sync.Mutex
+	sources map[string]*SourceStatus
+}{sources: map[string]*SourceStatus{
+	SourceFunding:      {Name: SourceFunding},
+	SourceCryptoPanic:  {Name: SourceCryptoPanic},
+	SourceLunarCrush:   {Name: SourceLunarCrush},
+	SourceCoinGecko:    {Name: SourceCoinGecko},
+	SourceFearGreed:    {Name: SourceFearGreed},
+	SourceGoogleTrends: {Name: SourceGoogleTrends},
+}}
+
+func healthEntry(source string) *SourceStatus {
+	s, ok := sourceHealth.sources[source]
+	if !ok {
+		s = &SourceStatus{Name: source}
+		sourceHealth.sources[source] = s
+	}
+	return s
+}
+
+// recordFetch 记录一次数据源调用结果，err 为 nil 视为成功
+func recordFetch(source string, err error) {
+	now := time.Now().UTC()
+	sourceHealth.mu.Lock()
+	defer sourceHealth.mu.Unlock()
+
+	s := healthEntry(source)
+	s.Disabled = false
+	if err == nil {
+		s.Successes++
+		s.ConsecutiveFailures = 0
+		s.LastSuccessAt = &now
+		return
+	}
+	s.Failures++
+	s.ConsecutiveFailures++
+	s.LastFailureAt = &now
+	s.LastError = err.Error()
+}
+
+// recordFailure 记录一次失败（只有描述没有 error 的场景）
+func recordFailure(source, format string, args ...any) {
+	recordFetch(source, fmt.Errorf(format, args...))
+}
+
+// recordDisabled 标记数据源因未配置 Key 被跳过
+func recordDisabled(source string) {
+	sourceHealth.mu.Lock()
+	defer sourceHealth.mu.Unlock()
+	healthEntry(source).Disabled = true
+}
+
+// SourceHealth 返回全部外部数据源的健康状态快照，按名称排序
+func SourceHealth() []SourceStatus {
+	now := time.Now().UTC()
+	sourceHealth.mu.Lock()
+	defer sourceHealth.mu.Unlock()
+
+	out := make([]SourceStatus, 0, len(sourceHealth.sources))
+	for _, s := range sourceHealth.sources {
+		v := *s
+		v.Status = sourceState(v, now)
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func sourceState(s SourceStatus, now time.Time) string {
+	switch {
+	case s.Disabled:
+		return SourceDisabled
+	case s.LastSuccessAt == nil && s.LastFailureAt == nil:
+		return SourceUnknown
+	case s.LastSuccessAt == nil || now.Sub(*s.LastSuccessAt) > staleAfter:
+		return SourceStale
+	case s.ConsecutiveFailures > 0:
+		return SourceFailing
+	}
+	return SourceOK
+}
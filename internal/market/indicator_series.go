@@ -0,0 +1,142 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidIndicatorQuery 指标查询参数不合法（周期或指标名不支持）
+var ErrInvalidIndicatorQuery = errors.New("invalid indicator query")
+
+// klineCacheTTL 相同交易对 / 周期 / 数量的 K 线在该时间内复用，指标接口与提示词看到的是同一批 K 线
+const klineCacheTTL = 30 * time.Second
+
+type cachedKlines struct {
+	klines    []Kline
+	fetchedAt time.Time
+}
+
+// klineCache 进程内共享的 K 线缓存（各 Client 实例共用）
+var klineCache = struct {
+	mu sync.Mutex
+	m  map[string]cachedKlines
+}{m: make(map[string]cachedKlines)}
+
+// cachedFetchKlines 优先返回缓存中未过期的 K 线，否则拉取并写入缓存（失败结果不缓存）
+func cachedFetchKlines(key string, fetch func() ([]Kline, error)) ([]Kline, error) {
+	klineCache.mu.Lock()
+	if c, ok := klineCache.m[key]; ok && time.Since(c.fetchedAt) < klineCacheTTL {
+		klineCache.mu.Unlock()
+		return c.klines, nil
+	}
+	klineCache.mu.Unlock()
+
+	klines, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	klineCache.mu.Lock()
+	for k, c := range klineCache.m {
+		if time.Since(c.fetchedAt) >= klineCacheTTL {
+			delete(klineCache.m, k)
+		}
+	}
+	klineCache.m[key] = cachedKlines{klines: klines, fetchedAt: time.Now()}
+	klineCache.mu.Unlock()
+	return klines, nil
+}
+
+// IndicatorNames 指标接口支持的指标，参数与提示词一致
+var IndicatorNames = []string{"rsi", "macd", "atr", "ema20", "ema50"}
+
+// DefaultIndicators 未指定指标时返回的指标
+var DefaultIndicators = []string{"rsi", "macd", "atr"}
+
+// klineIntervals Binance 支持的 K 线周期
+var klineIntervals = map[string]bool{
+	"1m": true, "3m": true, "5m": true, "15m": true, "30m": true,
+	"1h": true, "2h": true, "4h": true, "6h": true, "8h": true, "12h": true,
+	"1d": true, "3d": true, "1w": true, "1M": true,
+}
+
+// DefaultKlineLimit 提示词使用的 K 线数量（5m × 50、4h × 30），其他周期默认 100 根
+func DefaultKlineLimit(interval string) int {
+	switch interval {
+	case "5m":
+		return 50
+	case "4h":
+		return 30
+	}
+	return 100
+}
+
+// IndicatorSeries 按 K 线计算的指标序列，与写入提示词的指标使用相同的 K 线与算法
+type IndicatorSeries struct {
+	Pair     string               `json:"pair"`
+	Interval string               `json:"interval"`
+	Times    []time.Time          `json:"times"` // K 线开盘时间
+	Close    []float64            `json:"close"`
+	Series   map[string][]float64 `json:"series"`
+}
+
+// FetchIndicators 拉取 limit 根 K 线并计算指定指标；limit <= 0 时使用提示词的 K 线数量
+func (c *Client) FetchIndicators(ctx context.Context, pair, interval string, names []string, limit int) (IndicatorSeries, error) {
+	if !klineIntervals[interval] {
+		return IndicatorSeries{}, fmt.Errorf("%w: 不支持的 K 线周期 %s", ErrInvalidIndicatorQuery, interval)
+	}
+	if len(names) == 0 {
+		names = DefaultIndicators
+	}
+	for _, n := range names {
+		if !validIndicator(n) {
+			return IndicatorSeries{}, fmt.Errorf("%w: 不支持的指标 %s（可选 %s）", ErrInvalidIndicatorQuery, n, strings.Join(IndicatorNames, " / "))
+		}
+	}
+	if limit <= 0 {
+		limit = DefaultKlineLimit(interval)
+	}
+
+	klines, err := c.fetchKlines(ctx, pairToSymbol(pair), interval, limit)
+	if err != nil {
+		return IndicatorSeries{}, err
+	}
+	closes := extractCloses(klines)
+	out := IndicatorSeries{
+		Pair:     pair,
+		Interval: interval,
+		Times:    make([]time.Time, len(klines)),
+		Close:    closes,
+		Series:   make(map[string][]float64, len(names)),
+	}
+	for i, k := range klines {
+		out.Times[i] = k.OpenTime
+	}
+	for _, n := range names {
+		switch n {
+		case "rsi":
+			out.Series[n] = RSI(closes, 14)
+		case "macd":
+			out.Series[n] = MACD(closes)
+		case "atr":
+			out.Series[n] = ATR(extractHighs(klines), extractLows(klines), closes, 14)
+		case "ema20":
+			out.Series[n] = EMA(closes, 20)
+		case "ema50":
+			out.Series[n] = EMA(closes, 50)
+		}
+	}
+	return out, nil
+}
+
+func validIndicator(name string) bool {
+	for _, n := range IndicatorNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
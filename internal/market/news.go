@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"ai_quant/internal/stages"
 )
 
 // NewsItem 表示一条加密货币新闻（来自 CryptoPanic）
@@ -20,37 +22,44 @@ type NewsItem struct {
 }
 
 // fetchNews 从 CryptoPanic 获取指定币种的最新新闻。
-// 任何错误（无 key、额度耗尽、网络异常）都返回 nil，不影响主流程。
-func (c *Client) fetchNews(ctx context.Context, pair string) []NewsItem {
+// 任何错误（额度耗尽、网络异常）都返回 nil 和 ok=false，由降级策略决定是否继续；未配置 key 视为正常跳过。
+func (c *Client) fetchNews(ctx context.Context, pair string) ([]NewsItem, bool) {
 	if c.CryptoPanicKey == "" {
-		return nil
+		recordDisabled(SourceCryptoPanic)
+		return nil, true
+	}
+	// 新闻环节被运行时关闭：视为正常跳过，不影响数据源健康状态
+	if !stages.Enabled(stages.News) {
+		return nil, true
 	}
 
 	// "DOGE/USDT" → "DOGE"
 	coin := strings.Split(pair, "/")[0]
 
 	url := fmt.Sprintf(
-		"https://cryptopanic.com/api/v1/posts/?auth_token=%s&currencies=%s&kind=news&public=true",
-		c.CryptoPanicKey, coin,
+		"%s/posts/?auth_token=%s&currencies=%s&kind=news&public=true",
+		c.endpoints.CryptoPanic, c.CryptoPanicKey, coin,
 	)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := c.newRequest(ctx, SourceCryptoPanic, url)
 	if err != nil {
 		log.Printf("[新闻] 创建请求失败: %v", err)
-		return nil
+		return nil, false
 	}
 
 	resp, err := c.http.Do(req)
 	if err != nil {
 		log.Printf("[新闻] 请求 CryptoPanic 失败: %v，跳过新闻数据", err)
-		return nil
+		recordFetch(SourceCryptoPanic, err)
+		return nil, false
 	}
 	defer resp.Body.Close()
 
 	// 非 200（含 429 额度耗尽）→ 静默跳过
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("[新闻] CryptoPanic 返回 HTTP %d（额度用完或其他错误），跳过新闻数据", resp.StatusCode)
-		return nil
+		recordFailure(SourceCryptoPanic, "HTTP %d", resp.StatusCode)
+		return nil, false
 	}
 
 	var result struct {
@@ -70,8 +79,10 @@ func (c *Client) fetchNews(ctx context.Context, pair string) []NewsItem {
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		log.Printf("[新闻] 解析 CryptoPanic 响应失败: %v，跳过新闻数据", err)
-		return nil
+		recordFetch(SourceCryptoPanic, err)
+		return nil, false
 	}
+	recordFetch(SourceCryptoPanic, nil)
 
 	// 最多取 5 条最新新闻
 	limit := 5
@@ -102,7 +113,7 @@ func (c *Client) fetchNews(ctx context.Context, pair string) []NewsItem {
 	}
 
 	log.Printf("[新闻] 获取到 %d 条 %s 相关新闻", len(items), coin)
-	return items
+	return items, true
 }
 
 // sanitizeNewsTitle 清洗新闻标题中可能触发内容安全过滤的敏感词
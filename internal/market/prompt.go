@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 	"text/template"
+
+	"ai_quant/internal/exchangeinfo"
 )
 
 // PromptData holds all template fields for UserPrompt.md.
@@ -91,6 +93,12 @@ type PromptData struct {
 
 	// Positions
 	Positions []PositionData
+
+	// 程序规则计算的风险提示（如资金费率成本），不依赖模型判断
+	Alerts []string
+
+	// 该交易对最近几个周期的决策与结果（按时间倒序）
+	History []DecisionData
 }
 
 // NewsItemData holds a single news item for prompt rendering.
@@ -127,10 +135,23 @@ type PositionData struct {
 	CurrentPrice string
 	UnrealizedPnl string
 	Leverage     string
+	LiquidationPrice string // 合约强平价，现货为空
+	FundingCost  string // 合约开仓以来累计资金费，现货为空
 	ProfitTarget string
 	StopLoss     string
 }
 
+// DecisionData holds one past decision and its outcome for prompt rendering.
+type DecisionData struct {
+	Ago        string // 距今时长，如 "35m"
+	Side       string
+	Confidence string
+	Outcome    string // filled / rejected by risk / failed / no trade
+	Price      string // 成交价，未成交为空
+	PnL        string // 平仓已实现盈亏，其余为空
+	Reason     string
+}
+
 // BuildPrompt generates the user prompt from a CoinSnapshot and account info.
 func BuildPrompt(tmpl string, snap CoinSnapshot, account AccountInfo, extraSnaps []CoinSnapshot) (string, error) {
 	data := buildPromptData(snap, account, extraSnaps)
@@ -157,6 +178,8 @@ type AccountInfo struct {
 	TradingMode    string // "spot" 或 "futures"
 	Leverage       int    // 杠杆倍数
 	Positions      []PositionData
+	Alerts         []string // 风险提示，原样写入提示词
+	History        []DecisionData // 历史决策与结果
 }
 
 func buildPromptData(snap CoinSnapshot, account AccountInfo, extras []CoinSnapshot) PromptData {
@@ -185,7 +208,7 @@ func buildPromptData(snap CoinSnapshot, account AccountInfo, extras []CoinSnapsh
 		MinutesElapsed: account.MinutesElapsed,
 		Pair:           snap.Pair,
 
-		Price:        ff(snap.Price, pricePrecision(snap.Pair)),
+		Price:        ff(snap.Price, pricePrecision(snap)),
 		Change24hPct: ff(snap.Change24hPct, 2),
 		FundingRate:  ff(snap.FundingRate, 6),
 		OpenInterest: ff(snap.OpenInterest, 2),
@@ -193,19 +216,19 @@ func buildPromptData(snap CoinSnapshot, account AccountInfo, extras []CoinSnapsh
 
 		ShortInterval: snap.ShortInterval,
 		ShortCount:    shortN,
-		ShortPrices:   joinLast(shortCloses, shortN, pricePrecision(snap.Pair)),
-		ShortEMA20:    joinLast(shortEMA20, shortN, pricePrecision(snap.Pair)),
+		ShortPrices:   joinLast(shortCloses, shortN, pricePrecision(snap)),
+		ShortEMA20:    joinLast(shortEMA20, shortN, pricePrecision(snap)),
 		ShortMACD:     joinLast(shortMACD, shortN, 4),
 		ShortRSI14:    joinLast(shortRSI14, shortN, 1),
 		ShortVolume:   joinLast(shortVols, shortN, 0),
 
 		LongCount:       len(longCloses),
-		LongPrices:      joinLast(longCloses, min(len(longCloses), 10), pricePrecision(snap.Pair)),
-		LongEMA20Latest: lastFF(longEMA20, pricePrecision(snap.Pair)),
-		LongEMA50Latest: lastFF(longEMA50, pricePrecision(snap.Pair)),
+		LongPrices:      joinLast(longCloses, min(len(longCloses), 10), pricePrecision(snap)),
+		LongEMA20Latest: lastFF(longEMA20, pricePrecision(snap)),
+		LongEMA50Latest: lastFF(longEMA50, pricePrecision(snap)),
 		LongMACD:        joinLast(longMACD, min(len(longMACD), 10), 4),
 		LongRSI14:       joinLast(longRSI14, min(len(longRSI14), 10), 1),
-		LongATR14:       lastFF(longATR14, pricePrecision(snap.Pair)),
+		LongATR14:       lastFF(longATR14, pricePrecision(snap)),
 		LongVolumeAvg:   ff(avg(longVols), 0),
 
 		LongShortRatio:    ff(snap.Sentiment.LongShortRatio, 4),
@@ -223,6 +246,8 @@ func buildPromptData(snap CoinSnapshot, account AccountInfo, extras []CoinSnapsh
 		Leverage:      fmt.Sprintf("%d", account.Leverage),
 		IsFutures:     account.TradingMode == "futures",
 		Positions:     account.Positions,
+		Alerts:        account.Alerts,
+		History:       account.History,
 	}
 
 	// CoinGecko data (always attempt, free)
@@ -282,11 +307,21 @@ func buildPromptData(snap CoinSnapshot, account AccountInfo, extras []CoinSnapsh
 
 	// Extra pairs for correlation
 	for _, es := range extras {
+		if es.Pair == TotalMarketPair {
+			data.ExtraPairs = append(data.ExtraPairs, ExtraPairData{
+				Pair:         "TOTAL (crypto market cap)",
+				Price:        formatMarketCap(es.Price),
+				Change24hPct: ff(es.Change24hPct, 2),
+				FundingRate:  "N/A",
+				RSI14:        "N/A",
+			})
+			continue
+		}
 		ec := extractCloses(es.ShortKlines)
 		eRSI := RSI(ec, 14)
 		data.ExtraPairs = append(data.ExtraPairs, ExtraPairData{
 			Pair:         es.Pair,
-			Price:        ff(es.Price, pricePrecision(es.Pair)),
+			Price:        ff(es.Price, pricePrecision(es)),
 			Change24hPct: ff(es.Change24hPct, 2),
 			FundingRate:  ff(es.FundingRate, 6),
 			RSI14:        lastFF(eRSI, 1),
@@ -377,20 +412,24 @@ func formatLargeNumber(n int) string {
 	return fmt.Sprintf("%d", n)
 }
 
-func pricePrecision(pair string) int {
-	p := strings.ToUpper(pair)
+// formatMarketCap 将总市值格式化为 $2.41T / $850.3B
+func formatMarketCap(v float64) string {
 	switch {
-	case strings.HasPrefix(p, "BTC"):
-		return 2
-	case strings.HasPrefix(p, "ETH"):
-		return 2
-	case strings.HasPrefix(p, "BNB"):
-		return 2
-	case strings.HasPrefix(p, "DOGE"), strings.HasPrefix(p, "XRP"):
-		return 4
+	case v >= 1e12:
+		return fmt.Sprintf("$%.2fT", v/1e12)
+	case v >= 1e9:
+		return fmt.Sprintf("$%.1fB", v/1e9)
 	default:
-		return 4
+		return fmt.Sprintf("$%.0f", v)
+	}
+}
+
+// pricePrecision 价格显示精度：优先使用交易所 tickSize，未知时按价格量级估算
+func pricePrecision(snap CoinSnapshot) int {
+	if snap.TickSize > 0 {
+		return exchangeinfo.Decimals(snap.TickSize)
 	}
+	return exchangeinfo.EstimateDecimals(snap.Price)
 }
 
 func min(a, b int) int {
@@ -0,0 +1,109 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TotalMarketPair 伪交易对：加密货币总市值（来自 CoinGecko /global）
+const TotalMarketPair = "TOTAL"
+
+// ReferencePairs 每个交易对的关联参考币对，"*" 为默认规则
+type ReferencePairs map[string][]string
+
+// ParseReferencePairs 解析参考币对配置，格式：
+//
+//	DOGE/USDT=BTC/USDT+ETH/USDT;SOL/USDT=BTC/USDT+TOTAL;*=BTC/USDT
+//
+// 未匹配的交易对使用 "*" 规则；交易对自身会被自动排除
+func ParseReferencePairs(spec string) (ReferencePairs, error) {
+	refs := ReferencePairs{}
+	for _, rule := range strings.Split(spec, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(rule, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return nil, fmt.Errorf("参考币对规则格式错误: %q", rule)
+		}
+		var pairs []string
+		for _, p := range strings.Split(value, "+") {
+			p = strings.ToUpper(strings.TrimSpace(p))
+			if p == "" {
+				continue
+			}
+			if p != TotalMarketPair && !strings.Contains(p, "/") {
+				return nil, fmt.Errorf("参考币对格式错误: %q（应为 BTC/USDT 或 TOTAL）", p)
+			}
+			pairs = append(pairs, p)
+		}
+		refs[key] = pairs
+	}
+	return refs, nil
+}
+
+// For 返回交易对对应的参考币对
+func (r ReferencePairs) For(pair string) []string {
+	pair = strings.ToUpper(pair)
+	pairs, ok := r[pair]
+	if !ok {
+		pairs = r["*"]
+	}
+	out := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		if p != pair {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// FetchReferenceSnapshot 获取参考币对的轻量快照；TOTAL 为全市场总市值
+func (c *Client) FetchReferenceSnapshot(ctx context.Context, pair string) (CoinSnapshot, error) {
+	if pair != TotalMarketPair {
+		return c.FetchLightSnapshot(ctx, pair)
+	}
+	return c.sharedLightSnapshot(ctx, pair, func() (CoinSnapshot, error) {
+		snap, err := c.fetchTotalMarket(ctx)
+		recordFetch(SourceCoinGecko, err)
+		return snap, err
+	})
+}
+
+// fetchTotalMarket 从 CoinGecko 获取加密货币总市值及 24h 变化（Price 字段存放总市值 USD）
+func (c *Client) fetchTotalMarket(ctx context.Context) (CoinSnapshot, error) {
+	var result struct {
+		Data struct {
+			TotalMarketCap           map[string]float64 `json:"total_market_cap"`
+			MarketCapChangePct24hUSD float64            `json:"market_cap_change_percentage_24h_usd"`
+		} `json:"data"`
+	}
+	ctx, cancel := context.WithTimeout(ctx, 8*time.Second)
+	defer cancel()
+	req, err := c.newRequest(ctx, SourceCoinGecko, c.endpoints.CoinGecko+"/global")
+	if err != nil {
+		return CoinSnapshot{}, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return CoinSnapshot{}, fmt.Errorf("coingecko global: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return CoinSnapshot{}, fmt.Errorf("coingecko global HTTP %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return CoinSnapshot{}, err
+	}
+	return CoinSnapshot{
+		Pair:         TotalMarketPair,
+		Price:        result.Data.TotalMarketCap["usd"],
+		Change24hPct: result.Data.MarketCapChangePct24hUSD,
+	}, nil
+}
@@ -0,0 +1,45 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// Ticker24h 全市场 24h 行情中的一项（用于交易对筛选）
+type Ticker24h struct {
+	Symbol      string
+	LastPrice   float64
+	ChangePct   float64
+	HighPrice   float64
+	LowPrice    float64
+	QuoteVolume float64 // 24h 计价币成交额
+	TradeCount  int64
+}
+
+// FetchAllTickers 获取全部现货交易对的 24h 行情（权重较高，只用于低频筛选）
+func (c *Client) FetchAllTickers(ctx context.Context) ([]Ticker24h, error) {
+	var raw []struct {
+		Symbol             string `json:"symbol"`
+		LastPrice          string `json:"lastPrice"`
+		PriceChangePercent string `json:"priceChangePercent"`
+		HighPrice          string `json:"highPrice"`
+		LowPrice           string `json:"lowPrice"`
+		QuoteVolume        string `json:"quoteVolume"`
+		Count              int64  `json:"count"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/api/v3/ticker/24hr", binanceSpotBase), &raw); err != nil {
+		return nil, err
+	}
+	out := make([]Ticker24h, 0, len(raw))
+	for _, r := range raw {
+		t := Ticker24h{Symbol: r.Symbol, TradeCount: r.Count}
+		t.LastPrice, _ = strconv.ParseFloat(r.LastPrice, 64)
+		t.ChangePct, _ = strconv.ParseFloat(r.PriceChangePercent, 64)
+		t.HighPrice, _ = strconv.ParseFloat(r.HighPrice, 64)
+		t.LowPrice, _ = strconv.ParseFloat(r.LowPrice, 64)
+		t.QuoteVolume, _ = strconv.ParseFloat(r.QuoteVolume, 64)
+		out = append(out, t)
+	}
+	return out, nil
+}
@@ -0,0 +1,94 @@
+package market
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// tickCache 一次调度 tick 内多个交易对共享的行情上下文：
+// BTC 等参考币对快照、恐慌贪婪指数、Google 热搜只需各拉取一次
+type tickCache struct {
+	mu        sync.Mutex
+	light     map[string]CoinSnapshot
+	fearGreed *fearGreedResult
+	trends    map[string][]string // geo -> 热搜标题
+}
+
+type fearGreedResult struct {
+	value int
+	label string
+}
+
+type tickCacheKey struct{}
+
+// WithTickCache 返回携带共享缓存的 context，同一 context 派生出的所有周期复用已拉取的公共数据
+func WithTickCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tickCacheKey{}, &tickCache{
+		light:  make(map[string]CoinSnapshot),
+		trends: make(map[string][]string),
+	})
+}
+
+func tickCacheFrom(ctx context.Context) *tickCache {
+	tc, _ := ctx.Value(tickCacheKey{}).(*tickCache)
+	return tc
+}
+
+// sharedLightSnapshot 优先从共享缓存取轻量快照，未命中时拉取并写入缓存（失败结果不缓存）
+func (c *Client) sharedLightSnapshot(ctx context.Context, pair string, fetch func() (CoinSnapshot, error)) (CoinSnapshot, error) {
+	tc := tickCacheFrom(ctx)
+	if tc == nil {
+		return fetch()
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if snap, ok := tc.light[pair]; ok {
+		log.Printf("[行情] 复用本轮已获取的 %s 参考快照", pair)
+		return snap, nil
+	}
+	snap, err := fetch()
+	if err == nil {
+		tc.light[pair] = snap
+	}
+	return snap, err
+}
+
+// sharedFearGreed 恐慌贪婪指数每个 tick 只拉取一次
+func (c *Client) sharedFearGreed(ctx context.Context) (int, string) {
+	tc := tickCacheFrom(ctx)
+	if tc == nil {
+		v, l, err := c.fetchFearGreedIndex(ctx)
+		recordFetch(SourceFearGreed, err)
+		return v, l
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.fearGreed == nil {
+		v, l, err := c.fetchFearGreedIndex(ctx)
+		recordFetch(SourceFearGreed, err)
+		if err != nil {
+			return v, l
+		}
+		tc.fearGreed = &fearGreedResult{value: v, label: l}
+	}
+	return tc.fearGreed.value, tc.fearGreed.label
+}
+
+// sharedTrendingTitles Google 热搜 RSS 与币种无关，每个 tick 每个地区只拉取一次
+func (c *Client) sharedTrendingTitles(ctx context.Context, geo string) ([]string, bool) {
+	tc := tickCacheFrom(ctx)
+	if tc == nil {
+		return c.fetchTrendingTitles(ctx, geo)
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if titles, ok := tc.trends[geo]; ok {
+		return titles, true
+	}
+	titles, ok := c.fetchTrendingTitles(ctx, geo)
+	if ok {
+		tc.trends[geo] = titles
+	}
+	return titles, ok
+}
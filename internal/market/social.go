@@ -10,8 +10,6 @@ import (
 	"time"
 )
 
-const lunarCrushBase = "https://lunarcrush.com/api4"
-
 // SocialMetrics 保存 LunarCrush 社交媒体指标
 type SocialMetrics struct {
 	GalaxyScore     float64 // 综合评分 0-100（社交+市场）
@@ -54,10 +52,11 @@ func coinToTopic(pair string) string {
 }
 
 // fetchSocialMetrics 从 LunarCrush 获取社交指标。
-// 无 key 或请求失败 → 返回零值，不影响主流程。
-func (c *Client) fetchSocialMetrics(ctx context.Context, pair string) SocialMetrics {
+// 无 key 返回零值且 ok=true；topic 概览请求失败时 ok=false，由降级策略决定是否继续。
+func (c *Client) fetchSocialMetrics(ctx context.Context, pair string) (SocialMetrics, bool) {
 	if c.LunarCrushKey == "" {
-		return SocialMetrics{}
+		recordDisabled(SourceLunarCrush)
+		return SocialMetrics{}, true
 	}
 
 	var metrics SocialMetrics
@@ -93,7 +92,7 @@ func (c *Client) fetchSocialMetrics(ctx context.Context, pair string) SocialMetr
 		metrics.InfluencerPosts = posts
 	}
 
-	return metrics
+	return metrics, topicData != nil
 }
 
 // fetchInfluencerPosts 获取指定 KOL 的最新热帖
@@ -155,9 +154,9 @@ func (c *Client) fetchInfluencerPosts(ctx context.Context, network, username str
 // lunarGet 发起 LunarCrush API GET 请求（带 Bearer Token）
 // 任何错误返回 nil（静默失败）
 func (c *Client) lunarGet(ctx context.Context, path string) map[string]interface{} {
-	url := lunarCrushBase + path
+	url := c.endpoints.LunarCrush + path
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := c.newRequest(ctx, SourceLunarCrush, url)
 	if err != nil {
 		log.Printf("[社交] 创建请求失败: %v", err)
 		return nil
@@ -167,20 +166,24 @@ func (c *Client) lunarGet(ctx context.Context, path string) map[string]interface
 	resp, err := c.http.Do(req)
 	if err != nil {
 		log.Printf("[社交] LunarCrush 请求失败: %v，跳过社交数据", err)
+		recordFetch(SourceLunarCrush, err)
 		return nil
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("[社交] LunarCrush 返回 HTTP %d（额度不足或无权限），跳过社交数据", resp.StatusCode)
+		recordFailure(SourceLunarCrush, "HTTP %d", resp.StatusCode)
 		return nil
 	}
 
 	var result map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		log.Printf("[社交] 解析 LunarCrush 响应失败: %v", err)
+		recordFetch(SourceLunarCrush, err)
 		return nil
 	}
+	recordFetch(SourceLunarCrush, nil)
 	return result
 }
 
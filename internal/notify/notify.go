@@ -0,0 +1,104 @@
+// Package notify 交易通知：成交、风控拒绝、周期失败、每日盈亏汇总，经 Notifier 推送到外部渠道（如 Telegram）。
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Kind 通知类型
+type Kind string
+
+const (
+	KindFill     Kind = "fill"     // 订单成交
+	KindReject   Kind = "reject"   // 风控拒绝（不含观望信号）
+	KindFailure  Kind = "failure"  // 周期执行失败
+	KindDaily    Kind = "daily"    // 日报 / 周报摘要
+	KindApproval Kind = "approval" // 等待人工审批
+)
+
+// Event 一条通知
+type Event struct {
+	Kind  Kind
+	Title string
+	Text  string
+}
+
+// Notifier 通知渠道
+type Notifier interface {
+	Notify(ctx context.Context, ev Event) error
+}
+
+// ParseKinds 解析 "fill,reject,failure,daily,approval" 格式的通知类型，为空时全部开启
+func ParseKinds(spec string) (map[Kind]bool, error) {
+	all := []Kind{KindFill, KindReject, KindFailure, KindDaily, KindApproval}
+	kinds := make(map[Kind]bool)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		known := false
+		for _, k := range all {
+			if Kind(item) == k {
+				kinds[k], known = true, true
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("未知通知类型 %q（支持 fill / reject / failure / daily / approval）", item)
+		}
+	}
+	if len(kinds) == 0 {
+		for _, k := range all {
+			kinds[k] = true
+		}
+	}
+	return kinds, nil
+}
+
+// queueSize 待发送通知的缓冲数量，渠道阻塞时超出的通知直接丢弃
+const queueSize = 64
+
+// Dispatcher 按类型过滤并异步发送通知，发送失败只记录日志，不影响交易流程
+type Dispatcher struct {
+	notifier Notifier
+	kinds    map[Kind]bool
+	queue    chan Event
+}
+
+// NewDispatcher 创建通知分发器并启动后台发送
+func NewDispatcher(n Notifier, kinds map[Kind]bool) *Dispatcher {
+	d := &Dispatcher{notifier: n, kinds: kinds, queue: make(chan Event, queueSize)}
+	go d.loop()
+	return d
+}
+
+// Enabled 是否推送该类型的通知
+func (d *Dispatcher) Enabled(kind Kind) bool {
+	return d != nil && d.kinds[kind]
+}
+
+// Send 提交一条通知（非阻塞）；未开启的类型直接忽略
+func (d *Dispatcher) Send(ev Event) {
+	if !d.Enabled(ev.Kind) {
+		return
+	}
+	select {
+	case d.queue <- ev:
+	default:
+		log.Printf("[通知] ⚠ 发送队列已满，丢弃通知: %s", ev.Title)
+	}
+}
+
+func (d *Dispatcher) loop() {
+	for ev := range d.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		if err := d.notifier.Notify(ctx, ev); err != nil {
+			log.Printf("[通知] ✘ 发送失败 (%s): %v", ev.Title, err)
+		}
+		cancel()
+	}
+}
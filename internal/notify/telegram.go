@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Telegram 通过 Bot API 发送消息到指定聊天
+type Telegram struct {
+	token  string
+	chatID string
+	client *http.Client
+}
+
+// NewTelegram 创建 Telegram 通知渠道；token 与 chatID 都为空时返回 nil（不启用），只配置一个时报错
+func NewTelegram(token, chatID string) (*Telegram, error) {
+	token, chatID = strings.TrimSpace(token), strings.TrimSpace(chatID)
+	if token == "" && chatID == "" {
+		return nil, nil
+	}
+	if token == "" || chatID == "" {
+		return nil, fmt.Errorf("TELEGRAM_BOT_TOKEN 与 TELEGRAM_CHAT_ID 需要同时配置")
+	}
+	// 不使用 trace 客户端：请求路径中包含 bot token，不能写入外部请求日志
+	return &Telegram{token: token, chatID: chatID, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+var kindIcons = map[Kind]string{
+	KindFill:     "✅",
+	KindReject:   "⚠️",
+	KindFailure:  "❌",
+	KindDaily:    "📊",
+	KindApproval: "⏸",
+}
+
+// Notify 发送一条纯文本消息
+func (t *Telegram) Notify(ctx context.Context, ev Event) error {
+	text := ev.Title
+	if icon := kindIcons[ev.Kind]; icon != "" {
+		text = icon + " " + text
+	}
+	if ev.Text != "" {
+		text += "\n" + ev.Text
+	}
+	body, err := json.Marshal(map[string]any{
+		"chat_id":                  t.chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := "https://api.telegram.org/bot" + t.token + "/sendMessage"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// 错误信息中的 URL 含 token，只返回脱敏后的原因
+		return fmt.Errorf("telegram 请求失败: %s", strings.ReplaceAll(err.Error(), t.token, "***"))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("telegram API %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
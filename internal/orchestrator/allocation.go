@@ -0,0 +1,167 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"ai_quant/internal/agent/signal"
+	"ai_quant/internal/domain"
+)
+
+// SetPortfolioMode 开启组合分配模式：定时器每轮先让大模型看全部交易对生成分配计划，再按计划逐个执行周期
+func (s *Service) SetPortfolioMode(enabled bool) {
+	s.portfolioMode = enabled
+	if enabled {
+		if _, ok := s.signal.(signal.Allocator); !ok {
+			log.Println("[组合] ⚠ 当前信号组件不支持组合分配，仍按交易对独立决策")
+			s.portfolioMode = false
+			return
+		}
+		log.Println("[组合] 已启用组合分配模式")
+	}
+}
+
+// PortfolioMode 是否启用组合分配模式
+func (s *Service) PortfolioMode() bool {
+	return s.portfolioMode
+}
+
+// PlanAllocation 汇总各交易对的轻量行情、持仓与可用资金，调用大模型生成按优先级排序的分配计划并保存；
+// 计划中遗漏的交易对补为 hold，不在 pairs 中的交易对会被丢弃
+func (s *Service) PlanAllocation(ctx context.Context, pairs []string) (domain.AllocationPlan, error) {
+	allocator, ok := s.signal.(signal.Allocator)
+	if !ok {
+		return domain.AllocationPlan{}, fmt.Errorf("当前信号组件不支持组合分配")
+	}
+	if len(pairs) == 0 {
+		return domain.AllocationPlan{}, fmt.Errorf("没有可分配的交易对")
+	}
+
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		log.Printf("[组合] ⚠ 查询持仓失败: %v", err)
+	}
+	held := make(map[string]domain.Holding, len(holdings))
+	for _, h := range holdings {
+		held[strings.ToUpper(h.Pair)] = h
+	}
+
+	input := signal.AllocationInput{MaxExposureUSDT: s.presets.Active().MaxExposureUSDT}
+	input.CashUSDT, _ = s.fetchAccountDataForPrompt(ctx, pairs[0])
+	for _, pair := range pairs {
+		snap := signal.PairSnapshot{Pair: pair}
+		if price, change, tErr := s.fetchQuickTicker(ctx, pair); tErr == nil {
+			snap.Price, snap.Change24h = price, change
+		} else {
+			log.Printf("[组合] ⚠ %s 行情获取失败: %v", pair, tErr)
+		}
+		if h, ok := held[pair]; ok && h.Quantity > 0 {
+			snap.HoldingQty = h.Quantity
+			snap.AvgPrice = h.AvgPrice
+			snap.HoldingValue = h.Quantity * snap.Price
+		}
+		input.Pairs = append(input.Pairs, snap)
+	}
+
+	plan, err := allocator.Allocate(ctx, input)
+	if err != nil {
+		if plan.CostUSD > 0 {
+			// 调用已产生费用：保存空计划以计入预算
+			plan.Summary = "分配计划解析失败: " + err.Error()
+			if sErr := s.repo.InsertAllocationPlan(ctx, plan); sErr != nil {
+				log.Printf("[组合] ⚠ 保存分配计划失败: %v", sErr)
+			}
+		}
+		return plan, err
+	}
+	plan.Allocations = normalizeAllocations(plan.Allocations, pairs)
+
+	if err := s.repo.InsertAllocationPlan(ctx, plan); err != nil {
+		log.Printf("[组合] ⚠ 保存分配计划失败: %v", err)
+	}
+	log.Printf("[组合] ✔ 分配计划: %s", plan.Summary)
+	for _, a := range plan.Allocations {
+		log.Printf("[组合]   #%d %s %s 权重=%.2f %s", a.Rank, a.Pair, a.Action, a.Weight, a.Reason)
+	}
+	return plan, nil
+}
+
+// LatestAllocationPlan 最近一次组合分配计划，没有时返回 nil
+func (s *Service) LatestAllocationPlan(ctx context.Context) (*domain.AllocationPlan, error) {
+	return s.repo.LatestAllocationPlan(ctx)
+}
+
+// normalizeAllocations 只保留配置中的交易对，规范动作与权重，补齐遗漏的交易对并按优先级排序；
+// 权重合计超过 1 时等比缩放
+func normalizeAllocations(in []domain.PairAllocation, pairs []string) []domain.PairAllocation {
+	wanted := make(map[string]bool, len(pairs))
+	for _, p := range pairs {
+		wanted[p] = true
+	}
+	seen := make(map[string]bool, len(pairs))
+	out := make([]domain.PairAllocation, 0, len(pairs))
+	total := 0.0
+	for _, a := range in {
+		a.Pair = strings.ToUpper(strings.TrimSpace(a.Pair))
+		if !wanted[a.Pair] || seen[a.Pair] {
+			continue
+		}
+		seen[a.Pair] = true
+		switch a.Action = strings.ToLower(strings.TrimSpace(a.Action)); a.Action {
+		case domain.AllocationBuy, domain.AllocationHold, domain.AllocationReduce, domain.AllocationAvoid:
+		default:
+			a.Action = domain.AllocationHold
+		}
+		if a.Weight < 0 {
+			a.Weight = 0
+		}
+		if a.Action == domain.AllocationAvoid {
+			a.Weight = 0
+		}
+		total += a.Weight
+		out = append(out, a)
+	}
+	if total > 1 {
+		for i := range out {
+			out[i].Weight /= total
+		}
+	}
+	for _, p := range pairs {
+		if !seen[p] {
+			out = append(out, domain.PairAllocation{Pair: p, Rank: len(pairs), Action: domain.AllocationHold, Reason: "分配计划未覆盖"})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ri, rj := out[i].Rank, out[j].Rank
+		if ri <= 0 {
+			ri = len(pairs) + 1
+		}
+		if rj <= 0 {
+			rj = len(pairs) + 1
+		}
+		return ri < rj
+	})
+	for i := range out {
+		out[i].Rank = i + 1
+	}
+	return out
+}
+
+// allocationBlocksEntry 分配计划是否禁止该交易对本轮开仓
+func allocationBlocksEntry(a *domain.PairAllocation) bool {
+	return a != nil && (a.Action == domain.AllocationAvoid || a.Action == domain.AllocationReduce || a.Weight <= 0)
+}
+
+// allocationAlert 分配计划写入单币提示词的提示
+func allocationAlert(a *domain.PairAllocation) string {
+	return fmt.Sprintf("Portfolio allocator ranked %s #%d with action %q and target weight %.0f%% of max exposure (%s). "+
+		"Stay consistent with this plan unless the detailed data clearly contradicts it.", a.Pair, a.Rank, a.Action, a.Weight*100, a.Reason)
+}
+
+// HasHolding 本地持仓表中交易对是否有未平仓数量（查询失败时按有持仓处理）
+func (s *Service) HasHolding(ctx context.Context, pair string) bool {
+	return s.hasHolding(ctx, pair)
+}
@@ -0,0 +1,181 @@
+package orchestrator
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"ai_quant/internal/costbasis"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/tradingday"
+)
+
+const (
+	// analyticsMaxSnapshots 单次分析读取的权益快照上限（按分钟级周期约一个月）
+	analyticsMaxSnapshots = 50000
+	// promptPerformanceDays 写入提示词的收益率与夏普比率的统计窗口
+	promptPerformanceDays = 30
+)
+
+// EquityAnalytics 最近 days 天的收益分析：收益率、年化波动率、夏普比率、最大回撤与各交易对盈亏贡献
+func (s *Service) EquityAnalytics(ctx context.Context, days int) (domain.EquityAnalytics, error) {
+	if days <= 0 {
+		days = 30
+	}
+	since := time.Now().UTC().AddDate(0, 0, -days)
+	out := domain.EquityAnalytics{Days: days, Daily: []domain.EquityDay{}, Pairs: []domain.PairContribution{}}
+
+	snaps, err := s.repo.ListEquitySnapshots(ctx, since, analyticsMaxSnapshots)
+	if err != nil {
+		return out, err
+	}
+	st := equityStats(snaps)
+	out.Snapshots = len(snaps)
+	out.Daily = st.daily
+	out.ReturnPct = round2(st.returnPct)
+	out.VolatilityPct = round2(st.volatilityPct)
+	out.SharpeRatio = round2(st.sharpe)
+	out.MaxDrawdownPct = round2(st.maxDrawdownPct)
+	if len(snaps) > 0 {
+		out.From, out.To = snaps[0].CreatedAt, snaps[len(snaps)-1].CreatedAt
+		out.StartEquityUSDT = round2(snaps[0].EquityUSDT)
+		out.EndEquityUSDT = round2(snaps[len(snaps)-1].EquityUSDT)
+	}
+
+	orders, err := s.repo.ListFilledOrders(ctx)
+	if err != nil {
+		return out, err
+	}
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return out, err
+	}
+	out.Pairs = pairContributions(orders, holdings, since, out.StartEquityUSDT)
+	return out, nil
+}
+
+// promptPerformance 写入提示词的账户表现（最近 promptPerformanceDays 天的收益率与夏普比率）；没有快照时为 0
+func (s *Service) promptPerformance(ctx context.Context) (returnPct, sharpe float64) {
+	since := time.Now().UTC().AddDate(0, 0, -promptPerformanceDays)
+	snaps, err := s.repo.ListEquitySnapshots(ctx, since, analyticsMaxSnapshots)
+	if err != nil {
+		return 0, 0
+	}
+	st := equityStats(snaps)
+	return round2(st.returnPct), round2(st.sharpe)
+}
+
+// equitySummary equityStats 的计算结果
+type equitySummary struct {
+	daily          []domain.EquityDay
+	returnPct      float64
+	volatilityPct  float64
+	sharpe         float64
+	maxDrawdownPct float64
+}
+
+// equityStats 按时间升序的快照计算区间收益率与最大回撤；波动率与夏普比率基于交易日收盘权益的日收益率，
+// 少于两个日收益率时为 0
+func equityStats(snaps []domain.EquitySnapshot) equitySummary {
+	st := equitySummary{daily: []domain.EquityDay{}}
+	if len(snaps) == 0 {
+		return st
+	}
+	if first := snaps[0].EquityUSDT; first > 0 {
+		st.returnPct = (snaps[len(snaps)-1].EquityUSDT/first - 1) * 100
+	}
+
+	peak := 0.0
+	for _, e := range snaps {
+		peak = max(peak, e.EquityUSDT)
+		if peak > 0 {
+			st.maxDrawdownPct = max(st.maxDrawdownPct, (peak-e.EquityUSDT)/peak*100)
+		}
+		key := tradingday.Key(e.CreatedAt)
+		if n := len(st.daily); n > 0 && st.daily[n-1].Date == key {
+			st.daily[n-1].EquityUSDT = e.EquityUSDT
+		} else {
+			st.daily = append(st.daily, domain.EquityDay{Date: key, EquityUSDT: e.EquityUSDT})
+		}
+	}
+
+	returns := make([]float64, 0, len(st.daily))
+	for i := range st.daily {
+		if i > 0 && st.daily[i-1].EquityUSDT > 0 {
+			r := st.daily[i].EquityUSDT/st.daily[i-1].EquityUSDT - 1
+			returns = append(returns, r)
+			st.daily[i].ReturnPct = round2(r * 100)
+		}
+		st.daily[i].EquityUSDT = round2(st.daily[i].EquityUSDT)
+	}
+	if len(returns) < 2 {
+		return st
+	}
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	st.volatilityPct = std * math.Sqrt(365) * 100
+	if std > 0 {
+		st.sharpe = mean / std * math.Sqrt(365)
+	}
+	return st
+}
+
+// pairContributions 回放全部成交得到各交易对 since 之后的已实现盈亏，加上当前持仓的浮动盈亏，按总盈亏降序
+func pairContributions(orders []domain.Order, holdings []domain.Holding, since time.Time, startEquity float64) []domain.PairContribution {
+	book := costbasis.NewBook(costbasis.Current())
+	byPair := make(map[string]*domain.PairContribution)
+	get := func(pair string) *domain.PairContribution {
+		c, ok := byPair[pair]
+		if !ok {
+			c = &domain.PairContribution{Pair: pair}
+			byPair[pair] = c
+		}
+		return c
+	}
+
+	for _, o := range orders {
+		var realized float64
+		switch o.Side {
+		case domain.SideLong:
+			book.Buy(o.Pair, o.FilledQuantity, o.FilledPrice)
+		case domain.SideClose:
+			realized, _ = book.Sell(o.Pair, o.FilledQuantity, o.FilledPrice)
+		default:
+			continue
+		}
+		if o.CreatedAt.Before(since) {
+			continue
+		}
+		c := get(o.Pair)
+		c.RealizedPnLUSDT += realized
+		c.Trades++
+	}
+	for _, h := range holdings {
+		if h.Quantity <= 0 || h.LastPrice <= 0 {
+			continue
+		}
+		get(h.Pair).UnrealizedPnLUSDT += h.Quantity*h.LastPrice - h.TotalCost
+	}
+
+	out := make([]domain.PairContribution, 0, len(byPair))
+	for _, c := range byPair {
+		c.TotalPnLUSDT = round2(c.RealizedPnLUSDT + c.UnrealizedPnLUSDT)
+		c.RealizedPnLUSDT = round2(c.RealizedPnLUSDT)
+		c.UnrealizedPnLUSDT = round2(c.UnrealizedPnLUSDT)
+		if startEquity > 0 {
+			c.ContributionPct = round2(c.TotalPnLUSDT / startEquity * 100)
+		}
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TotalPnLUSDT > out[j].TotalPnLUSDT })
+	return out
+}
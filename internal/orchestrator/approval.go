@@ -0,0 +1,273 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/notify"
+)
+
+// 人工审批模式
+const (
+	ApprovalOff  = "off"  // 不需要审批
+	ApprovalLive = "live" // 只有实盘下单需要审批
+	ApprovalAll  = "all"  // 模拟盘也需要审批
+)
+
+var (
+	// ErrApprovalNotFound 周期没有审批记录
+	ErrApprovalNotFound = errors.New("该周期没有待审批的下单")
+	// ErrApprovalClosed 审批已处理或已超时
+	ErrApprovalClosed = errors.New("审批已处理或已超时")
+	// ErrApprovalSelf 触发周期的 API Key 不能审批自己的下单
+	ErrApprovalSelf = errors.New("不能审批自己发起的下单，需要另一个 API Key 或 Web UI 确认")
+)
+
+// SetApproval 设置人工审批模式：周期在建仓策略之后暂停，等待 POST /api/v1/cycles/:id/approve 确认后再下单，
+// 超过 timeout 未确认自动取消
+func (s *Service) SetApproval(mode string, timeout time.Duration) error {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "", ApprovalOff:
+		s.approvalMode = ApprovalOff
+		return nil
+	case ApprovalLive, ApprovalAll:
+	default:
+		return fmt.Errorf("未知审批模式 %q（支持 off / live / all）", mode)
+	}
+	if timeout <= 0 {
+		timeout = 15 * time.Minute
+	}
+	s.approvalMode, s.approvalTimeout = mode, timeout
+	log.Printf("[审批] 已启用人工审批 模式=%s 超时=%s", mode, timeout)
+	return nil
+}
+
+// ApprovalMode 当前人工审批模式
+func (s *Service) ApprovalMode() string {
+	if s.approvalMode == "" {
+		return ApprovalOff
+	}
+	return s.approvalMode
+}
+
+// approvalRequired 本次下单是否需要人工审批
+func (s *Service) approvalRequired(executor execution.Executor) bool {
+	switch s.approvalMode {
+	case ApprovalAll:
+		return true
+	case ApprovalLive:
+		return !executor.IsDryRun()
+	}
+	return false
+}
+
+// holdForApproval 保存待审批记录并结束本轮周期，下单在审批通过后由 ApproveCycle 继续
+func (s *Service) holdForApproval(ctx context.Context, ce cycleExecution) (domain.CycleResult, error) {
+	cycle := ce.cycle
+	addLog := s.cycleLogger(ctx, cycle.ID, &ce.logs)
+
+	now := time.Now().UTC()
+	a := domain.CycleApproval{
+		CycleID:     cycle.ID,
+		Pair:        ce.pair,
+		Side:        ce.sig.Side,
+		StakeUSDT:   ce.risk.MaxStakeUSDT,
+		Leverage:    ce.leverage,
+		Reason:      ce.sig.Reason,
+		Status:      domain.ApprovalPending,
+		RequestedBy: ce.requestedBy,
+		ExpiresAt:   now.Add(s.approvalTimeout),
+		CreatedAt:   now,
+	}
+	if err := s.repo.InsertCycleApproval(ctx, a); err != nil {
+		log.Printf("[周期:%s] ✘ 保存审批记录失败: %v", cycle.ID[:8], err)
+		s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, domain.ReasonInternal, err.Error())
+		return domain.CycleResult{}, err
+	}
+	s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusPendingApproval, "", "")
+	msg := fmt.Sprintf("等待人工审批 方向=%s 金额=%.2f USDT，%s 前未确认将自动取消",
+		a.Side, a.StakeUSDT, a.ExpiresAt.Local().Format("15:04:05"))
+	_ = addLog("审批", msg)
+	log.Printf("[周期:%s] ⏸ %s", cycle.ID[:8], msg)
+
+	s.notifier.Send(notify.Event{
+		Kind:  notify.KindApproval,
+		Title: fmt.Sprintf("%s %s 等待审批", a.Pair, a.Side),
+		Text: fmt.Sprintf("金额 %.2f USDT 置信度 %.2f\n理由: %s\n周期 %s，%s 前未确认自动取消",
+			a.StakeUSDT, ce.sig.Confidence, a.Reason, cycle.ID, a.ExpiresAt.Local().Format("15:04")),
+	})
+
+	cycle.Status = domain.CycleStatusPendingApproval
+	cycle.UpdatedAt = now
+	log.Printf("[周期:%s] ■ 执行完毕 状态=等待审批 总耗时=%s", cycle.ID[:8], time.Since(ce.start))
+	return domain.CycleResult{Cycle: cycle, Signal: ce.sig, Risk: ce.risk, Logs: ce.logs}, nil
+}
+
+// ListApprovals 按状态查询审批记录，status 为空时返回全部
+func (s *Service) ListApprovals(ctx context.Context, status string) ([]domain.CycleApproval, error) {
+	return s.repo.ListCycleApprovals(ctx, status, 50)
+}
+
+// pendingApproval 查询仍可处理的审批记录；已超时的顺便取消
+func (s *Service) pendingApproval(ctx context.Context, cycleID string) (*domain.CycleApproval, error) {
+	a, err := s.repo.GetCycleApproval(ctx, cycleID)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrApprovalNotFound
+	}
+	if a.Status != domain.ApprovalPending {
+		return nil, ErrApprovalClosed
+	}
+	if time.Now().After(a.ExpiresAt) {
+		s.closeApproval(ctx, *a, domain.ApprovalExpired, "", "审批超时自动取消")
+		return nil, ErrApprovalClosed
+	}
+	return a, nil
+}
+
+// ApproveCycle 审批通过：按审批时的最新价格继续执行周期的下单阶段；by 为审批人
+func (s *Service) ApproveCycle(ctx context.Context, cycleID, by, note string) (domain.CycleResult, error) {
+	a, err := s.pendingApproval(ctx, cycleID)
+	if err != nil {
+		return domain.CycleResult{}, err
+	}
+	if isAPIKeyCaller(by) && by == a.RequestedBy {
+		return domain.CycleResult{}, ErrApprovalSelf
+	}
+	release, err := s.lockPair(ctx, a.Pair)
+	if err != nil {
+		return domain.CycleResult{}, err
+	}
+	defer release()
+	report, err := s.repo.GetCycleReport(ctx, cycleID)
+	if err != nil {
+		return domain.CycleResult{}, err
+	}
+	if report.Signal == nil || report.Risk == nil {
+		return domain.CycleResult{}, fmt.Errorf("周期 %s 缺少信号或风控记录，无法继续执行", cycleID)
+	}
+	if h, halted := s.drawdownHalted(); halted && (report.Signal.Side == domain.SideLong || report.Signal.Side == domain.SideShort) {
+		return domain.CycleResult{}, fmt.Errorf("回撤熔断中，暂不能批准开仓: %s", h.Reason)
+	}
+	price, _, err := s.fetchQuickTicker(ctx, a.Pair)
+	if err != nil {
+		return domain.CycleResult{}, fmt.Errorf("获取 %s 最新价格失败: %w", a.Pair, err)
+	}
+
+	ok, err := s.repo.DecideCycleApproval(ctx, cycleID, domain.ApprovalApproved, by, note)
+	if err != nil {
+		return domain.CycleResult{}, err
+	}
+	if !ok {
+		return domain.CycleResult{}, ErrApprovalClosed
+	}
+
+	var ps domain.PositionStrategy
+	if p, err := s.repo.GetPositionStrategy(ctx, cycleID); err != nil {
+		log.Printf("[审批] ⚠ 查询建仓策略失败: %v", err)
+	} else if p != nil {
+		ps = *p
+	}
+	risk := *report.Risk
+	risk.MaxStakeUSDT = a.StakeUSDT
+
+	cycle := report.Cycle
+	cycle.Status = domain.CycleStatusRunning
+	s.persistCycleStatus(ctx, cycleID, domain.CycleStatusRunning, "", "")
+	logs := report.Logs
+	msg := "审批通过 审批人=" + by
+	if note != "" {
+		msg += "：" + note
+	}
+	_ = s.cycleLogger(ctx, cycleID, &logs)("审批", msg)
+	log.Printf("[周期:%s] ▶ %s，继续下单 价格=%.6f", cycleID[:8], msg, price)
+
+	result, err := s.executeCycle(ctx, cycleExecution{
+		cycle:       cycle,
+		pair:        a.Pair,
+		sig:         *report.Signal,
+		risk:        risk,
+		posStrategy: ps,
+		price:       price,
+		leverage:    a.Leverage,
+		logs:        logs,
+		start:       time.Now(),
+	})
+	s.notifyCycle(a.Pair, result, err)
+	return result, err
+}
+
+// RejectCycle 审批拒绝：周期记为已拒绝，取消待触发的分批批次
+func (s *Service) RejectCycle(ctx context.Context, cycleID, by, note string) error {
+	a, err := s.pendingApproval(ctx, cycleID)
+	if err != nil {
+		return err
+	}
+	if note == "" {
+		note = "人工审批拒绝"
+	}
+	if !s.closeApproval(ctx, *a, domain.ApprovalRejected, by, note) {
+		return ErrApprovalClosed
+	}
+	return nil
+}
+
+// ExpireApprovals 取消所有已超时的待审批下单，返回取消数量
+func (s *Service) ExpireApprovals(ctx context.Context) (int, error) {
+	pending, err := s.repo.ListCycleApprovals(ctx, domain.ApprovalPending, 500)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	now := time.Now()
+	for _, a := range pending {
+		if now.After(a.ExpiresAt) && s.closeApproval(ctx, a, domain.ApprovalExpired, "", "审批超时自动取消") {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// closeApproval 以拒绝 / 超时结束审批，更新周期状态并取消分批批次；记录已被处理时返回 false
+func (s *Service) closeApproval(ctx context.Context, a domain.CycleApproval, status, by, note string) bool {
+	ok, err := s.repo.DecideCycleApproval(ctx, a.CycleID, status, by, note)
+	if err != nil {
+		log.Printf("[审批] ⚠ 更新审批记录失败: %v", err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	code := domain.ReasonApprovalRejected
+	if status == domain.ApprovalExpired {
+		code = domain.ReasonApprovalTimeout
+	}
+	s.persistCycleStatus(ctx, a.CycleID, domain.CycleStatusRejected, code, note)
+	var logs []domain.CycleLog
+	_ = s.cycleLogger(ctx, a.CycleID, &logs)("审批", note)
+	if ps, err := s.repo.GetPositionStrategy(ctx, a.CycleID); err == nil && ps != nil {
+		s.cancelPendingBatches(ctx, *ps, note)
+	}
+	log.Printf("[审批] %s %s %s（周期 %s）", a.Pair, a.Side, note, a.CycleID[:8])
+	return true
+}
+
+// isAPIKeyCaller 调用方是否为 API Key（Web UI 只有一个登录密码，无法区分不同的人，不做同人校验）
+func isAPIKeyCaller(by string) bool {
+	return strings.HasPrefix(by, "key:")
+}
+
+// awaitingApproval 周期是否仍在等待人工审批
+func (s *Service) awaitingApproval(ctx context.Context, cycleID string) bool {
+	a, err := s.repo.GetCycleApproval(ctx, cycleID)
+	return err == nil && a != nil && a.Status == domain.ApprovalPending
+}
@@ -0,0 +1,152 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai_quant/internal/costbasis"
+	"ai_quant/internal/domain"
+)
+
+// entryLot 回放订单得到的未平开仓批次
+type entryLot struct {
+	fill domain.FillOrigin
+	qty  float64
+}
+
+// recordCloseOrigins 把平仓订单卖出的数量匹配到开仓批次并落库：先进先出时从最早的开仓卖起，
+// 加权平均时按各批次剩余数量等比例分摊；超出已知开仓的数量记为来源未知
+func (s *Service) recordCloseOrigins(ctx context.Context, order domain.Order) {
+	origins, err := s.closeOrigins(ctx, order)
+	if err != nil {
+		log.Printf("[归因] ⚠ %s 平仓归因失败: %v", order.Pair, err)
+		return
+	}
+	if len(origins) == 0 {
+		return
+	}
+	if err := s.repo.InsertCloseOrigins(ctx, origins); err != nil {
+		log.Printf("[归因] ⚠ 保存平仓归因失败: %v", err)
+		return
+	}
+
+	parts := make([]string, 0, len(origins))
+	for _, o := range origins {
+		if o.EntryOrderID == "" {
+			parts = append(parts, fmt.Sprintf("来源未知 %.8f", o.Quantity))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("周期 %s（%s/%s）%.8f @ %.6f", shortID(o.EntryCycleID), o.Strategy, o.EntryStrategy, o.Quantity, o.EntryPrice))
+	}
+	msg := "平仓来源: " + strings.Join(parts, "；")
+	log.Printf("[归因] %s %s", order.Pair, msg)
+	if order.CycleID != "" {
+		s.addBatchLog(ctx, order.CycleID, "归因", msg)
+	}
+}
+
+// closeOrigins 按成交历史回放该交易对的开仓批次（不含本订单），计算本次卖出对应的来源
+func (s *Service) closeOrigins(ctx context.Context, order domain.Order) ([]domain.CloseOrigin, error) {
+	fills, err := s.repo.ListPairFills(ctx, order.Pair)
+	if err != nil {
+		return nil, err
+	}
+
+	method := costbasis.Current()
+	var lots []entryLot
+	for _, f := range fills {
+		if f.ID == order.ID {
+			continue
+		}
+		switch f.Side {
+		case domain.SideLong:
+			lots = append(lots, entryLot{fill: f, qty: f.FilledQuantity})
+		case domain.SideClose:
+			lots, _ = consumeLots(lots, f.FilledQuantity, method)
+		}
+	}
+
+	_, taken := consumeLots(lots, order.FilledQuantity, method)
+	now := time.Now().UTC()
+	origins := make([]domain.CloseOrigin, 0, len(taken)+1)
+	matched := 0.0
+	for _, t := range taken {
+		matched += t.qty
+		origins = append(origins, domain.CloseOrigin{
+			CloseOrderID:  order.ID,
+			CloseCycleID:  order.CycleID,
+			Pair:          order.Pair,
+			EntryOrderID:  t.fill.ID,
+			EntryCycleID:  t.fill.CycleID,
+			Strategy:      t.fill.Strategy,
+			EntryStrategy: t.fill.EntryStrategy,
+			Quantity:      t.qty,
+			EntryPrice:    t.fill.FilledPrice,
+			ExitPrice:     order.FilledPrice,
+			RealizedPnL:   (order.FilledPrice - t.fill.FilledPrice) * t.qty,
+			EntryAt:       t.fill.CreatedAt,
+			CreatedAt:     now,
+		})
+	}
+	// 持仓来自交易所同步或导入时没有开仓订单，剩余数量记为来源未知
+	if rest := order.FilledQuantity - matched; rest > 1e-8*order.FilledQuantity {
+		origins = append(origins, domain.CloseOrigin{
+			CloseOrderID: order.ID,
+			CloseCycleID: order.CycleID,
+			Pair:         order.Pair,
+			Quantity:     rest,
+			ExitPrice:    order.FilledPrice,
+			CreatedAt:    now,
+		})
+	}
+	return origins, nil
+}
+
+// consumeLots 从开仓批次中卖出 qty，返回剩余批次与被卖出的部分
+func consumeLots(lots []entryLot, qty float64, method costbasis.Method) (rest, taken []entryLot) {
+	if qty <= 0 || len(lots) == 0 {
+		return lots, nil
+	}
+	if method == costbasis.Average {
+		var total float64
+		for _, l := range lots {
+			total += l.qty
+		}
+		ratio := qty / total
+		if ratio > 1 {
+			ratio = 1
+		}
+		for _, l := range lots {
+			take := l.qty * ratio
+			taken = append(taken, entryLot{fill: l.fill, qty: take})
+			if l.qty-take > 1e-12 {
+				rest = append(rest, entryLot{fill: l.fill, qty: l.qty - take})
+			}
+		}
+		return rest, taken
+	}
+
+	for qty > 1e-12 && len(lots) > 0 {
+		take := qty
+		if lots[0].qty < take {
+			take = lots[0].qty
+		}
+		taken = append(taken, entryLot{fill: lots[0].fill, qty: take})
+		qty -= take
+		lots[0].qty -= take
+		if lots[0].qty <= 1e-12 {
+			lots = lots[1:]
+		}
+	}
+	return lots, taken
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,20 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+
+	"ai_quant/internal/domain"
+)
+
+// RecordAudit 记录一次状态变更操作；写入失败只记日志，不影响请求本身
+func (s *Service) RecordAudit(ctx context.Context, e domain.AuditEntry) {
+	if err := s.repo.InsertAuditEntry(ctx, e); err != nil {
+		log.Printf("[审计] ⚠ 写入审计日志失败 action=%q actor=%s: %v", e.Action, e.Actor, err)
+	}
+}
+
+// ListAuditLog 分页查询审计日志
+func (s *Service) ListAuditLog(ctx context.Context, actor string, q domain.ListQuery) ([]domain.AuditEntry, int, error) {
+	return s.repo.ListAuditLog(ctx, actor, q)
+}
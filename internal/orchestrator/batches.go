@@ -0,0 +1,312 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/agent/risk"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/tradingday"
+)
+
+// firstBatchGrace 首批尚未执行的策略超过该时长视为周期已结束
+const firstBatchGrace = 10 * time.Minute
+
+// errBatchBlocked 后续批次被开仓拦截规则挡住：批次保留待触发，下一轮再检查
+var errBatchBlocked = errors.New("开仓拦截")
+
+// ListPendingStrategies 查询仍有待触发批次的建仓策略，pair 为空时返回全部
+func (s *Service) ListPendingStrategies(ctx context.Context, pair string) ([]domain.PositionStrategy, error) {
+	return s.repo.ListPendingPositionStrategies(ctx, strings.ToUpper(pair))
+}
+
+// ProcessPendingBatches 检查分批建仓（金字塔 / 网格）的后续批次：
+// 最新价格跌到触发价时按批次金额下单，成交后更新批次状态并按新均价重挂保护单；
+// 首批未成交或超过 expire 的待触发批次直接取消。
+func (s *Service) ProcessPendingBatches(ctx context.Context, expire time.Duration) error {
+	strategies, err := s.repo.ListPendingPositionStrategies(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	prices := make(map[string]float64)
+	for _, ps := range strategies {
+		if ps.Side != domain.SideLong {
+			s.cancelPendingBatches(ctx, ps, "非开仓信号")
+			continue
+		}
+		if !hasExecutedBatch(ps.Batches) {
+			// 首批由周期本身执行并更新状态；周期早已结束仍未执行（如进程中断）视为本轮没有开仓，
+			// 等待人工审批的周期由审批流程处理
+			if time.Since(ps.CreatedAt) > firstBatchGrace && !s.awaitingApproval(ctx, ps.CycleID) {
+				s.cancelPendingBatches(ctx, ps, "首批未成交")
+			}
+			continue
+		}
+		if expire > 0 && time.Since(ps.CreatedAt) > expire {
+			s.cancelPendingBatches(ctx, ps, "超过有效期")
+			continue
+		}
+
+		price, ok := prices[ps.Pair]
+		if !ok {
+			price, err = s.fetchTickerPrice(ctx, ps.Pair)
+			if err != nil {
+				log.Printf("[分批] ⚠ 获取 %s 价格失败: %v", ps.Pair, err)
+				continue
+			}
+			prices[ps.Pair] = price
+		}
+
+		for i := range ps.Batches {
+			b := &ps.Batches[i]
+			if b.Status != domain.BatchPending || price > b.TriggerPrice {
+				continue
+			}
+			if err := s.executeBatch(ctx, &ps, b, price); errors.Is(err, ErrCycleInProgress) {
+				// 周期执行中，下一轮再检查
+				break
+			} else if errors.Is(err, errBatchBlocked) {
+				log.Printf("[分批] ⏸ %s 第%d批暂缓: %v", ps.Pair, b.BatchNo, err)
+				break
+			} else if err != nil {
+				log.Printf("[分批] ✘ %s 第%d批执行失败: %v", ps.Pair, b.BatchNo, err)
+				s.addBatchLog(ctx, ps.CycleID, "分批建仓", fmt.Sprintf("第%d批执行失败: %v", b.BatchNo, err))
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// claimRetryableKey 后台批次 / 止盈档位的幂等检查：已有提交或成交的订单时拒绝重复下单；
+// 之前的下单失败且没有成交时释放幂等键，允许下一次触发重试（周期的幂等键仍只允许下一次单）
+func (s *Service) claimRetryableKey(ctx context.Context, key string) error {
+	if exists, err := s.repo.ActiveOrderExistsByIdempotencyKey(ctx, key); err != nil {
+		return err
+	} else if exists {
+		return ErrDuplicateOrder
+	}
+	return s.repo.RetireFailedIdempotencyKey(ctx, key)
+}
+
+// executeBatch 执行单个批次并落库订单、持仓与批次状态
+func (s *Service) executeBatch(ctx context.Context, ps *domain.PositionStrategy, b *domain.PositionBatch, price float64) error {
+	release, ok := s.tryLockPair(ps.Pair)
+	if !ok {
+		return ErrCycleInProgress
+	}
+	defer release()
+	idemKey := fmt.Sprintf("batch:%s:%d", ps.ID, b.BatchNo)
+	if err := s.claimRetryableKey(ctx, idemKey); errors.Is(err, ErrDuplicateOrder) {
+		// 该批次已有提交或成交的订单（上次未能写入批次状态），补记为已执行，不再重复触发
+		now := time.Now().UTC()
+		b.Status = domain.BatchExecuted
+		b.ExecutedAt = &now
+		if err := s.repo.UpdatePositionBatches(ctx, ps.ID, ps.Batches); err != nil {
+			log.Printf("[分批] ⚠ 更新批次状态失败: %v", err)
+		}
+		log.Printf("[分批] %s 第%d批已有订单，标记为已执行", ps.Pair, b.BatchNo)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	executor := execution.ForPair(s.executor, ps.Pair)
+	if reason := s.batchEntryGuard(ctx, executor, ps.Pair); reason != "" {
+		return fmt.Errorf("%w: %s", errBatchBlocked, reason)
+	}
+	stake := b.Amount
+
+	// 实盘（及模拟盘钱包）按可用 USDT 调整金额，预留 1 USDT 手续费
+	if !executor.IsDryRun() || s.paperEnabled(executor) {
+		if balances, err := s.fetchBalances(ctx, executor); err == nil {
+			for _, bal := range balances {
+				if bal.Symbol == "USDT" {
+					free := bal.Free
+					if need := stake + 1.0 - free; need > 0 && bal.Earn > 0 {
+						free += s.redeemEarnUSDT(ctx, executor, need, bal.Earn)
+					}
+					if free-1.0 < 5 {
+						return fmt.Errorf("USDT余额不足 可用=%.2f 活期理财=%.2f", free, bal.Earn)
+					}
+					if stake > free-1.0 {
+						stake = free - 1.0
+					}
+					break
+				}
+			}
+		}
+	}
+
+	stake, err := s.applyPortfolioLimits(ctx, ps.CycleID, ps.Pair, stake)
+	if err != nil {
+		return err
+	}
+	stake, err = s.applyNotionalLimit(ps.Pair, stake)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[分批] 🚀 %s 第%d批触发 价格=%.6f ≤ 触发价=%.6f 金额=%.2f", ps.Pair, b.BatchNo, price, b.TriggerPrice, stake)
+	ord, err := executor.Execute(ctx, execution.Input{
+		IdempotencyKey: idemKey,
+		CycleID:        ps.CycleID,
+		SignalID:       ps.SignalID,
+		Pair:           ps.Pair,
+		Side:           domain.SideLong,
+		StakeUSDT:      stake,
+		EstimatedFill:  price,
+		Leverage:       s.leverageFor(ctx, ps.Pair, s.strategyFor(ps.Pair).RiskProfile(s.presets.Active()).Leverage),
+	})
+	if ord.ID != "" {
+		s.persistOrder(ctx, ord)
+	}
+	if err != nil {
+		return err
+	}
+	s.UpdateHoldingAfterTrade(ctx, ord)
+
+	now := time.Now().UTC()
+	b.Status = domain.BatchExecuted
+	b.ExecutedPrice = ord.FilledPrice
+	b.ExecutedQty = ord.FilledQuantity
+	b.ExecutedAt = &now
+	if err := s.repo.UpdatePositionBatches(ctx, ps.ID, ps.Batches); err != nil {
+		log.Printf("[分批] ⚠ 更新批次状态失败: %v", err)
+	}
+
+	msg := fmt.Sprintf("第%d/%d批已执行 金额=%.2f 成交价=%.6f 数量=%.8f 交易所ID=%s",
+		b.BatchNo, len(ps.Batches), stake, ord.FilledPrice, ord.FilledQuantity, ord.ExchangeOrderID)
+	log.Printf("[分批] ✔ %s %s", ps.Pair, msg)
+	s.addBatchLog(ctx, ps.CycleID, "分批建仓", msg)
+
+	// 加仓后均价变化，按新均价重挂保护单
+	if pmsg, err := s.syncProtectiveOrders(ctx, ps.CycleID, ord, ps.TakeProfitPercent, ps.StopLossPercent); err != nil {
+		log.Printf("[分批] ⚠ 保护单同步失败: %v", err)
+	} else if pmsg != "" {
+		s.addBatchLog(ctx, ps.CycleID, "止损", pmsg)
+	}
+	return nil
+}
+
+// batchEntryGuard 后续批次加仓前套用周期开仓的拦截规则（人工审批、波动 / 回撤熔断、杠杆风险率、
+// 防反复开平、每日交易次数），返回拦截原因；检查本身失败时与周期一样只记日志、不拦截
+func (s *Service) batchEntryGuard(ctx context.Context, executor execution.Executor, pair string) string {
+	if s.approvalRequired(executor) {
+		return "人工审批模式下不自动加仓"
+	}
+	if halt, err := s.checkVolatility(ctx, pair); err != nil {
+		log.Printf("[分批] ⚠ %s 波动熔断检查失败: %v", pair, err)
+	} else if halt != nil {
+		return "波动熔断: " + halt.Message
+	}
+	if ddHalt, err := s.checkDrawdown(ctx); ddHalt != nil {
+		return "回撤熔断: " + ddHalt.Reason
+	} else if err != nil {
+		log.Printf("[分批] ⚠ 权益快照 / 回撤熔断检查失败: %v", err)
+	}
+	if st, err := s.checkMargin(ctx, pair); err != nil {
+		log.Printf("[分批] ⚠ %s 杠杆风险率检查失败: %v", pair, err)
+	} else if st != nil && st.Low {
+		return marginAlert(st)
+	}
+	// 后续批次不是新的大模型判断，按置信度 0 参与防反复开平检查
+	if reason, err := s.checkChurn(ctx, pair, domain.Signal{Pair: pair, Side: domain.SideLong}); err != nil {
+		log.Printf("[分批] ⚠ %s 防反复开平检查失败: %v", pair, err)
+	} else if reason != "" {
+		return "防反复开平: " + reason
+	}
+	if limiter, ok := s.risk.(risk.TradeLimiter); ok && limiter.MaxDailyTrades() > 0 {
+		n, err := s.repo.CountFilledOrdersSince(ctx, pair, tradingday.Start(time.Now()))
+		if err != nil {
+			log.Printf("[分批] ⚠ %s 统计当日成交次数失败: %v", pair, err)
+		} else if n >= limiter.MaxDailyTrades() {
+			return fmt.Sprintf("当日已成交 %d 笔，达到每日交易次数上限 %d", n, limiter.MaxDailyTrades())
+		}
+	}
+	return ""
+}
+
+// markFirstBatch 周期下单后更新首批状态：成交则标记已执行，否则取消全部待触发批次
+func (s *Service) markFirstBatch(ctx context.Context, ps domain.PositionStrategy, ord domain.Order) {
+	if ps.Side != domain.SideLong || len(ps.Batches) == 0 {
+		return
+	}
+	if ord.FilledQuantity <= 0 {
+		s.cancelPendingBatches(ctx, ps, "首批未成交")
+		return
+	}
+	now := time.Now().UTC()
+	first := &ps.Batches[0]
+	first.Status = domain.BatchExecuted
+	first.ExecutedPrice = ord.FilledPrice
+	first.ExecutedQty = ord.FilledQuantity
+	first.ExecutedAt = &now
+	if err := s.repo.UpdatePositionBatches(ctx, ps.ID, ps.Batches); err != nil {
+		log.Printf("[分批] ⚠ 更新首批状态失败: %v", err)
+	}
+}
+
+// cancelPendingBatches 取消建仓策略中所有待触发的批次
+func (s *Service) cancelPendingBatches(ctx context.Context, ps domain.PositionStrategy, reason string) {
+	n := 0
+	for i := range ps.Batches {
+		if ps.Batches[i].Status == domain.BatchPending {
+			ps.Batches[i].Status = domain.BatchCancelled
+			n++
+		}
+	}
+	if n == 0 {
+		return
+	}
+	if err := s.repo.UpdatePositionBatches(ctx, ps.ID, ps.Batches); err != nil {
+		log.Printf("[分批] ⚠ 取消批次失败: %v", err)
+		return
+	}
+	log.Printf("[分批] %s 已取消 %d 个待触发批次（%s）", ps.Pair, n, reason)
+}
+
+// cancelPairBatches 平仓后取消该交易对所有待触发批次，避免止损后又被加仓
+func (s *Service) cancelPairBatches(ctx context.Context, pair, reason string) {
+	strategies, err := s.repo.ListPendingPositionStrategies(ctx, pair)
+	if err != nil {
+		log.Printf("[分批] ⚠ 查询待触发批次失败: %v", err)
+		return
+	}
+	for _, ps := range strategies {
+		s.cancelPendingBatches(ctx, ps, reason)
+	}
+	exits, err := s.repo.ListPendingExitStrategies(ctx, pair)
+	if err != nil {
+		log.Printf("[止盈] ⚠ 查询待触发止盈批次失败: %v", err)
+		return
+	}
+	for _, ps := range exits {
+		s.cancelExitBatches(ctx, ps, reason)
+	}
+}
+
+// addBatchLog 把后续批次的执行结果追加到原周期日志
+func (s *Service) addBatchLog(ctx context.Context, cycleID, stage, message string) {
+	_ = s.repo.InsertCycleLog(ctx, domain.CycleLog{
+		CycleID:   cycleID,
+		Stage:     stage,
+		Message:   message,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+func hasExecutedBatch(batches []domain.PositionBatch) bool {
+	for _, b := range batches {
+		if b.Status == domain.BatchExecuted {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// 资金分配的权重方式
+const (
+	WeightingEqual      = "equal"      // 各交易对等分预算
+	WeightingVolatility = "volatility" // 按 ATR 占价格百分比的倒数分配，波动越大份额越小
+)
+
+// capitalWeightTTL 交易对权重的缓存时间
+const capitalWeightTTL = time.Hour
+
+// CapitalAllocator 多交易对资金分配：全局预算按权重分给各交易对，单次开仓金额 = 该交易对份额 × 信号置信度 − 该交易对已有持仓，
+// 同时不超过预算减去整个组合的持仓敞口（预算即组合总敞口上限）
+type CapitalAllocator struct {
+	BudgetUSDT  float64  // 全局资金预算，0 = 不启用（各交易对按 MAX_SINGLE_STAKE_USDT 独立开仓）
+	Pairs       []string // 参与分配的交易对，通常为 AUTO_RUN_PAIRS
+	Weighting   string   // equal / volatility
+	ATRInterval string   // volatility 权重使用的 K 线周期
+	ATRPeriod   int
+}
+
+func (c CapitalAllocator) enabled() bool {
+	return c.BudgetUSDT > 0 && len(c.Pairs) > 0
+}
+
+// capitalState 最近一次计算的交易对权重
+type capitalState struct {
+	mu         sync.Mutex
+	shares     map[string]float64
+	computedAt time.Time
+}
+
+// SetCapitalAllocator 设置多交易对资金分配规则，atr 在按波动率分配时使用
+func (s *Service) SetCapitalAllocator(c CapitalAllocator, atr ATRSource) {
+	pairs := make([]string, 0, len(c.Pairs))
+	for _, p := range c.Pairs {
+		if p = strings.ToUpper(strings.TrimSpace(p)); p != "" {
+			pairs = append(pairs, p)
+		}
+	}
+	c.Pairs = pairs
+	if c.Weighting != WeightingVolatility {
+		c.Weighting = WeightingEqual
+	}
+	if c.ATRInterval == "" {
+		c.ATRInterval = "1h"
+	}
+	if c.ATRPeriod <= 0 {
+		c.ATRPeriod = 14
+	}
+	s.capital = c
+	s.capitalATR = atr
+	if c.enabled() {
+		log.Printf("[资金分配] 已启用: 预算 %.2f USDT 分给 %d 个交易对，权重=%s", c.BudgetUSDT, len(c.Pairs), c.Weighting)
+	}
+}
+
+// CapitalShares 各交易对在全局预算中的份额（0-1，合计为 1）；未启用时返回 nil
+func (s *Service) CapitalShares(ctx context.Context) map[string]float64 {
+	if !s.capital.enabled() {
+		return nil
+	}
+	s.capitalState.mu.Lock()
+	cached, at := s.capitalState.shares, s.capitalState.computedAt
+	s.capitalState.mu.Unlock()
+	if cached != nil && time.Since(at) < capitalWeightTTL {
+		return cached
+	}
+
+	shares := s.computeCapitalShares(ctx)
+	s.capitalState.mu.Lock()
+	s.capitalState.shares, s.capitalState.computedAt = shares, time.Now()
+	s.capitalState.mu.Unlock()
+	return shares
+}
+
+// computeCapitalShares 按权重方式计算份额；获取不到波动率的交易对使用其他交易对的平均权重
+func (s *Service) computeCapitalShares(ctx context.Context) map[string]float64 {
+	pairs := s.capital.Pairs
+	weights := make(map[string]float64, len(pairs))
+	if s.capital.Weighting == WeightingVolatility && s.capitalATR != nil {
+		for _, pair := range pairs {
+			atr, err := s.capitalATR.FetchATR(ctx, pair, s.capital.ATRInterval, s.capital.ATRPeriod)
+			if err != nil {
+				log.Printf("[资金分配] ⚠ %s 获取 ATR 失败: %v", pair, err)
+				continue
+			}
+			price, err := s.fetchTickerPrice(ctx, pair)
+			if err != nil || price <= 0 {
+				log.Printf("[资金分配] ⚠ %s 获取价格失败: %v", pair, err)
+				continue
+			}
+			if atrPct := atr / price * 100; atrPct > 0 {
+				weights[pair] = 1 / atrPct
+			}
+		}
+	}
+
+	fallback := 1.0
+	if len(weights) > 0 {
+		fallback = 0
+		for _, w := range weights {
+			fallback += w
+		}
+		fallback /= float64(len(weights))
+	}
+	total := 0.0
+	for _, pair := range pairs {
+		if _, ok := weights[pair]; !ok {
+			weights[pair] = fallback
+		}
+		total += weights[pair]
+	}
+	shares := make(map[string]float64, len(pairs))
+	for _, pair := range pairs {
+		shares[pair] = weights[pair] / total
+	}
+	log.Printf("[资金分配] 交易对份额: %s", formatShares(pairs, shares))
+	return shares
+}
+
+func formatShares(pairs []string, shares map[string]float64) string {
+	parts := make([]string, len(pairs))
+	for i, p := range pairs {
+		parts[i] = fmt.Sprintf("%s=%.1f%%", p, shares[p]*100)
+	}
+	return strings.Join(parts, " ")
+}
+
+// CapitalAllocation 资金分配的当前份额与各交易对持仓
+func (s *Service) CapitalAllocation(ctx context.Context) (domain.CapitalAllocation, error) {
+	out := domain.CapitalAllocation{
+		Enabled:    s.capital.enabled(),
+		BudgetUSDT: s.capital.BudgetUSDT,
+		Weighting:  s.capital.Weighting,
+		Pairs:      []domain.CapitalPairShare{},
+	}
+	if !out.Enabled {
+		return out, nil
+	}
+	state, err := s.BuildPortfolioState(ctx)
+	if err != nil {
+		return out, err
+	}
+	out.OpenExposureUSDT = state.OpenExposureUSDT
+	shares := s.CapitalShares(ctx)
+	for _, pair := range s.capital.Pairs {
+		out.Pairs = append(out.Pairs, domain.CapitalPairShare{
+			Pair:         pair,
+			Share:        round2(shares[pair]*100) / 100,
+			TargetUSDT:   round2(s.capital.BudgetUSDT * shares[pair]),
+			ExposureUSDT: round2(s.pairExposure(ctx, pair)),
+		})
+	}
+	return out, nil
+}
+
+// pairExposure 交易对当前持仓市值（无最近市价时按成本）
+func (s *Service) pairExposure(ctx context.Context, pair string) float64 {
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return 0
+	}
+	for _, h := range holdings {
+		if !strings.EqualFold(h.Pair, pair) || h.Quantity <= 0 {
+			continue
+		}
+		if h.LastPrice > 0 {
+			return h.Quantity * h.LastPrice
+		}
+		return h.TotalCost
+	}
+	return 0
+}
+
+// applyCapitalAllocation 按资金分配规则收紧已通过风控的开仓金额，预算用尽时改为拒绝；返回调整说明，未调整时为空
+func (s *Service) applyCapitalAllocation(ctx context.Context, pair string, sig domain.Signal, portfolio domain.PortfolioState, d *domain.RiskDecision) string {
+	if !s.capital.enabled() || !d.Approved || (sig.Side != domain.SideLong && sig.Side != domain.SideShort) {
+		return ""
+	}
+	budget := s.capital.BudgetUSDT
+	remaining := budget - portfolio.OpenExposureUSDT
+	if remaining <= 0 {
+		d.Approved = false
+		d.RejectCode = domain.ReasonMaxExposure
+		d.RejectReason = fmt.Sprintf("portfolio exposure %.2f reached capital budget %.2f", portfolio.OpenExposureUSDT, budget)
+		d.Sizing.Adjust(domain.SizingCapital, d.MaxStakeUSDT, 0, d.RejectReason)
+		return d.RejectReason
+	}
+
+	share, ok := s.CapitalShares(ctx)[strings.ToUpper(pair)]
+	if !ok {
+		// 不在分配列表中的交易对（如手动触发）按等分计算
+		share = 1 / float64(len(s.capital.Pairs)+1)
+	}
+	target := budget * share
+	held := s.pairExposure(ctx, pair)
+	stake := min(target*sig.Confidence-held, remaining, d.MaxStakeUSDT)
+	if stake <= 0 {
+		d.Approved = false
+		d.RejectCode = domain.ReasonMaxExposure
+		d.RejectReason = fmt.Sprintf("pair allocation %.2f (share %.1f%% x confidence %.2f) already used by holding %.2f",
+			target*sig.Confidence, share*100, sig.Confidence, held)
+		d.Sizing.Adjust(domain.SizingCapital, d.MaxStakeUSDT, 0, d.RejectReason)
+		return d.RejectReason
+	}
+	if stake >= d.MaxStakeUSDT {
+		return ""
+	}
+	note := fmt.Sprintf("资金分配: 份额 %.1f%% × 置信度 %.2f = %.2f，已持仓 %.2f，组合剩余预算 %.2f，开仓金额 %.2f → %.2f USDT",
+		share*100, sig.Confidence, target*sig.Confidence, held, remaining, d.MaxStakeUSDT, stake)
+	d.Sizing.Adjust(domain.SizingCapital, d.MaxStakeUSDT, stake, note)
+	d.MaxStakeUSDT = stake
+	return note
+}
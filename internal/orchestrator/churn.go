@@ -0,0 +1,50 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// ChurnGuard 防反复开平：上一笔成交后 WindowMin 分钟内出现反向信号（开仓后立即平仓、平仓后立即再开仓），
+// 置信度未达到 MinConfidence 时改为观望
+type ChurnGuard struct {
+	WindowMin     int     // 观察窗口（分钟），0 = 不启用
+	MinConfidence float64 // 窗口内允许反向操作的最低置信度
+}
+
+// SetChurnGuard 设置防反复开平规则
+func (s *Service) SetChurnGuard(g ChurnGuard) {
+	if g.MinConfidence <= 0 || g.MinConfidence > 1 {
+		g.MinConfidence = 0.85
+	}
+	s.churn = g
+	if g.WindowMin > 0 {
+		log.Printf("[风控] 防反复开平已启用: 成交后 %d 分钟内反向信号需置信度 ≥ %.2f", g.WindowMin, g.MinConfidence)
+	}
+}
+
+// checkChurn 判断信号是否与上一笔成交构成反复开平，返回拦截原因（为空表示放行）
+func (s *Service) checkChurn(ctx context.Context, pair string, sig domain.Signal) (string, error) {
+	if s.churn.WindowMin <= 0 || (sig.Side != domain.SideLong && sig.Side != domain.SideClose) {
+		return "", nil
+	}
+	if sig.Confidence >= s.churn.MinConfidence {
+		return "", nil
+	}
+	lastSide, lastAt, err := s.repo.LastFilledSide(ctx, pair)
+	if err != nil || lastAt.IsZero() {
+		return "", err
+	}
+	reversal := (sig.Side == domain.SideLong && lastSide == domain.SideClose) ||
+		(sig.Side == domain.SideClose && lastSide == domain.SideLong)
+	elapsed := time.Since(lastAt)
+	if !reversal || elapsed >= time.Duration(s.churn.WindowMin)*time.Minute {
+		return "", nil
+	}
+	return fmt.Sprintf("距上一笔 %s 成交仅 %d 分钟（窗口 %d 分钟），%s 信号置信度 %.2f 低于 %.2f",
+		lastSide, int(elapsed.Minutes()), s.churn.WindowMin, sig.Side, sig.Confidence, s.churn.MinConfidence), nil
+}
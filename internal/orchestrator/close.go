@@ -0,0 +1,174 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/notify"
+
+	"github.com/google/uuid"
+)
+
+// manualCloseModel 手动平仓信号的模型名称
+const manualCloseModel = "manual-close"
+
+// ClosePosition 手动平仓：跳过大模型直接生成 close 信号，fraction 在 (0,1) 时按比例减仓；按手动优先级排队
+func (s *Service) ClosePosition(ctx context.Context, pair string, fraction float64) (domain.CycleResult, error) {
+	return s.SubmitCycle(ctx, RunRequest{Pair: pair, ManualClose: true, CloseFraction: fraction}, PriorityManual, 0)
+}
+
+// ErrNoPosition 交易对没有可平的持仓
+var ErrNoPosition = errors.New("无持仓可平")
+
+// ForceClosePosition 强制平仓（紧急按钮）：不经过大模型、风控、周期队列与人工审批，撤销保护单后直接市价全部平仓；
+// 合约为 reduceOnly 平仓，数量精度由执行器按交易规则处理。交易对正在执行周期时等待其结束（受 ctx 限制）
+func (s *Service) ForceClosePosition(ctx context.Context, pair string) (domain.Order, error) {
+	pair = strings.ToUpper(strings.TrimSpace(pair))
+	release, err := s.pairLocks.acquire(ctx, pair, true)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	defer release()
+
+	// 先撤销保护单，释放现货 OCO 冻结的币
+	prevProtection, _ := s.repo.ListProtectiveOrders(ctx, pair, domain.ProtectiveActive)
+	if n, err := s.cancelProtectiveOrders(ctx, pair); err != nil {
+		return domain.Order{}, fmt.Errorf("撤销保护单失败: %w", err)
+	} else if n > 0 {
+		log.Printf("[执行] %s 强制平仓前已撤销 %d 条保护单", pair, n)
+	}
+
+	executor := execution.ForPair(s.executor, pair)
+	qty := s.closableQuantity(ctx, executor, pair)
+	if qty <= 0 {
+		return domain.Order{}, fmt.Errorf("%s %w", pair, ErrNoPosition)
+	}
+
+	price, _ := s.fetchTickerPrice(ctx, pair)
+	log.Printf("[执行] 🚨 %s 强制平仓 数量=%.8f", pair, qty)
+	ord, err := executor.Execute(ctx, execution.Input{
+		Pair:          pair,
+		Side:          domain.SideClose,
+		StakeUSDT:     qty * price,
+		EstimatedFill: price,
+		SellQuantity:  qty,
+		CloseFraction: 1,
+		ForceMarket:   true,
+	})
+	if ord.ID != "" {
+		s.persistOrder(ctx, ord)
+	}
+	if err != nil {
+		if _, rErr := s.restoreProtection(ctx, "", pair, prevProtection); rErr != nil {
+			log.Printf("[执行] ⚠ %s 恢复保护单失败: %v", pair, rErr)
+		}
+		s.notifier.Send(notify.Event{
+			Kind:  notify.KindFailure,
+			Title: "强制平仓失败",
+			Text:  pair + ": " + err.Error(),
+		})
+		return ord, err
+	}
+	s.UpdateHoldingAfterTrade(ctx, ord)
+	s.cancelPairBatches(ctx, pair, "已强制平仓")
+
+	msg := fmt.Sprintf("%s 强制平仓 成交价=%.8f 数量=%.8f", pair, ord.FilledPrice, ord.FilledQuantity)
+	log.Printf("[执行] ✔ %s", msg)
+	s.notifier.Send(notify.Event{
+		Kind:  notify.KindFill,
+		Title: "强制平仓",
+		Text:  msg,
+	})
+	return ord, nil
+}
+
+// closableQuantity 可平仓数量：实盘合约取交易所持仓，实盘现货取交易所可用余额，其余情况取本地持仓
+func (s *Service) closableQuantity(ctx context.Context, executor execution.Executor, pair string) float64 {
+	if !executor.IsDryRun() {
+		if executor.TradingMode() == "futures" {
+			if posAmt, err := executor.FetchPositionRisk(ctx, pair); err == nil && posAmt > 0 {
+				return posAmt
+			}
+		} else if balances, err := executor.FetchFullBalance(ctx); err == nil {
+			coin := strings.Split(pair, "/")[0]
+			for _, b := range balances {
+				if strings.EqualFold(b.Symbol, coin) {
+					return b.Free
+				}
+			}
+			return 0
+		}
+	}
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return 0
+	}
+	for _, h := range holdings {
+		if strings.EqualFold(h.Pair, pair) && h.Quantity > 0 {
+			return h.Quantity
+		}
+	}
+	return 0
+}
+
+func manualCloseSignal(cycleID, pair string, fraction float64) domain.Signal {
+	fraction = domain.NormalizeCloseFraction(fraction)
+	return domain.Signal{
+		ID:            uuid.NewString(),
+		CycleID:       cycleID,
+		Pair:          pair,
+		Side:          domain.SideClose,
+		Confidence:    1,
+		Reason:        fmt.Sprintf("手动平仓 %.0f%%", fraction*100),
+		ModelName:     manualCloseModel,
+		CloseFraction: fraction,
+		TTLSeconds:    60,
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+// restoreProtection 按比例减仓后，按原触发价为剩余持仓重新挂保护单（原保护单已在平仓前撤销）
+func (s *Service) restoreProtection(ctx context.Context, cycleID, pair string, prev []domain.ProtectiveOrder) (string, error) {
+	if len(prev) == 0 {
+		return "", nil
+	}
+	executor := execution.ForPair(s.executor, pair)
+	mgr, ok := executor.(execution.ProtectiveOrderManager)
+	if !ok {
+		return "", nil
+	}
+
+	req := execution.ProtectionRequest{Pair: pair}
+	for _, po := range prev {
+		switch po.Kind {
+		case domain.ProtectiveStopLoss:
+			req.StopPrice = po.TriggerPrice
+		case domain.ProtectiveTakeProfit:
+			req.TakeProfitPrice = po.TriggerPrice
+		}
+	}
+	if holdings, err := s.repo.ListHoldings(ctx); err == nil {
+		for _, h := range holdings {
+			if h.Pair == pair {
+				req.Quantity = h.Quantity
+				break
+			}
+		}
+	}
+	if req.Quantity <= 0 {
+		return "", nil
+	}
+
+	legs, err := mgr.PlaceProtection(ctx, req)
+	s.saveProtectiveLegs(ctx, cycleID, "", pair, req.Quantity, legs)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("剩余持仓 %.8f 已按原触发价重挂保护单 止损=%.6f 止盈=%.6f", req.Quantity, req.StopPrice, req.TakeProfitPrice), nil
+}
@@ -0,0 +1,80 @@
+package orchestrator
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"ai_quant/internal/agent/signal"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/tradingday"
+)
+
+// CostSummary 汇总大模型成本：今日 / 本月花费、预算上限，以及最近 days 天按交易日和按模型的明细
+func (s *Service) CostSummary(ctx context.Context, days int) (domain.CostSummary, error) {
+	if days <= 0 {
+		days = 30
+	}
+	now := time.Now()
+	since := tradingday.Start(now.AddDate(0, 0, -(days - 1)))
+	monthStart := tradingday.MonthStart(now)
+	// 同时覆盖明细区间和本月，保证本月合计完整
+	from := since
+	if monthStart.Before(from) {
+		from = monthStart
+	}
+
+	costs, err := s.repo.ListLLMCosts(ctx, from)
+	if err != nil {
+		return domain.CostSummary{}, err
+	}
+
+	var sum domain.CostSummary
+	sum.CycleLimit, sum.DailyLimit, sum.MonthlyLimit = signal.BudgetLimits(s.signal)
+
+	today := tradingday.Key(now)
+	byDay := make(map[string]*domain.CostBucket)
+	byModel := make(map[string]*domain.CostBucket)
+	for _, c := range costs {
+		if !c.CreatedAt.Before(monthStart) {
+			sum.MonthUSD += c.CostUSD
+		}
+		day := tradingday.Key(c.CreatedAt)
+		if day == today {
+			sum.TodayUSD += c.CostUSD
+		}
+		if c.CreatedAt.Before(since) {
+			continue
+		}
+		addCost(byDay, day, c)
+		addCost(byModel, c.ModelName, c)
+	}
+	sum.BudgetBlocked = (sum.DailyLimit > 0 && sum.TodayUSD >= sum.DailyLimit) ||
+		(sum.MonthlyLimit > 0 && sum.MonthUSD >= sum.MonthlyLimit)
+
+	sum.Days = sortedBuckets(byDay)
+	sort.Slice(sum.Days, func(i, j int) bool { return sum.Days[i].Key < sum.Days[j].Key })
+	sum.Models = sortedBuckets(byModel)
+	sort.Slice(sum.Models, func(i, j int) bool { return sum.Models[i].CostUSD > sum.Models[j].CostUSD })
+	return sum, nil
+}
+
+func addCost(m map[string]*domain.CostBucket, key string, c domain.LLMCost) {
+	b, ok := m[key]
+	if !ok {
+		b = &domain.CostBucket{Key: key}
+		m[key] = b
+	}
+	b.Calls++
+	b.PromptTokens += c.PromptTokens
+	b.CompletionTokens += c.CompletionTokens
+	b.CostUSD += c.CostUSD
+}
+
+func sortedBuckets(m map[string]*domain.CostBucket) []domain.CostBucket {
+	out := make([]domain.CostBucket, 0, len(m))
+	for _, b := range m {
+		out = append(out, *b)
+	}
+	return out
+}
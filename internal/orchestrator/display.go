@@ -0,0 +1,28 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+
+	"ai_quant/internal/fx"
+)
+
+// SetDisplayCurrency 注入报表展示币种的汇率缓存，为 nil 时报表只显示 USDT
+func (s *Service) SetDisplayCurrency(c *fx.Converter) {
+	s.fx = c
+	if c != nil {
+		log.Printf("[汇率] 报表展示币种: %s（内部记账仍为 USDT）", c.Currency())
+	}
+}
+
+// DisplayQuote 返回报表展示币种的汇率；未配置或汇率暂不可用时返回 false
+func (s *Service) DisplayQuote(ctx context.Context) (fx.Quote, bool) {
+	if s.fx == nil {
+		return fx.Quote{}, false
+	}
+	q, err := s.fx.Quote(ctx)
+	if err != nil {
+		return fx.Quote{}, false
+	}
+	return q, true
+}
@@ -0,0 +1,296 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/notify"
+
+	"github.com/google/uuid"
+)
+
+// DrawdownGuard 回撤熔断（不经过大模型）：账户权益从窗口内峰值回撤超过阈值时，所有交易对暂停新开仓，
+// 需通过 /api/v1/risk/resume 手动恢复
+type DrawdownGuard struct {
+	MaxPct     float64 // 回撤达到该百分比即触发，0 = 不启用
+	WindowDays int     // 峰值统计窗口（天）
+	ForceClose bool    // 熔断期间有持仓的交易对直接平仓，不调用大模型
+}
+
+// equitySnapshotInterval 多个交易对同一轮周期只记录一次权益快照（未启用回撤熔断时也记录，供收益分析使用）
+const equitySnapshotInterval = time.Minute
+
+// drawdownState 进程内的回撤熔断状态，与 drawdown_halt 表保持一致
+type drawdownState struct {
+	mu       sync.Mutex
+	halt     domain.DrawdownHalt
+	lastSnap time.Time
+}
+
+// SetDrawdownGuard 设置回撤熔断规则，并恢复重启前的熔断状态
+func (s *Service) SetDrawdownGuard(ctx context.Context, g DrawdownGuard) {
+	if g.WindowDays <= 0 {
+		g.WindowDays = 30
+	}
+	s.drawdownGuard = g
+	if g.MaxPct <= 0 {
+		return
+	}
+	log.Printf("[风控] 回撤熔断已启用: %d 天内权益回撤 ≥ %.2f%% 暂停开仓 强制平仓=%v", g.WindowDays, g.MaxPct, g.ForceClose)
+
+	h, err := s.repo.GetDrawdownHalt(ctx)
+	if err != nil {
+		log.Printf("[风控] ⚠ 读取回撤熔断状态失败: %v", err)
+		return
+	}
+	if h == nil {
+		return
+	}
+	s.drawdown.mu.Lock()
+	s.drawdown.halt = *h
+	s.drawdown.mu.Unlock()
+	if h.Halted {
+		log.Printf("[风控] 🚨 回撤熔断仍在生效: %s", h.Reason)
+	}
+}
+
+// accountEquity 计算账户权益：USDT 余额（含活期理财）加持仓价值；
+// 合约的保证金已在 USDT 余额中，只计入未实现盈亏
+func (s *Service) accountEquity(ctx context.Context) (domain.EquitySnapshot, error) {
+	snap := domain.EquitySnapshot{CreatedAt: time.Now().UTC()}
+	balances, err := s.fetchBalances(ctx, s.executor)
+	if err != nil {
+		return snap, fmt.Errorf("获取余额: %w", err)
+	}
+	for _, b := range balances {
+		if b.Symbol == "USDT" {
+			snap.CashUSDT = b.Total + b.Earn
+			break
+		}
+	}
+
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return snap, fmt.Errorf("查询持仓: %w", err)
+	}
+	for _, h := range holdings {
+		if h.Quantity <= 0 {
+			continue
+		}
+		price := h.LastPrice
+		if p, pErr := s.fetchTickerPrice(ctx, h.Pair); pErr == nil && p > 0 {
+			price = p
+		}
+		value := h.TotalCost
+		if price > 0 {
+			value = h.Quantity * price
+		}
+		if execution.ForPair(s.executor, h.Pair).TradingMode() == "futures" {
+			snap.PositionsUSDT += value - h.TotalCost
+		} else {
+			snap.PositionsUSDT += value
+		}
+	}
+	snap.EquityUSDT = snap.CashUSDT + snap.PositionsUSDT
+	return snap, nil
+}
+
+// checkDrawdown 记录权益快照并判断是否需要触发回撤熔断；返回 nil 表示可以正常开仓
+func (s *Service) checkDrawdown(ctx context.Context) (*domain.DrawdownHalt, error) {
+	s.drawdown.mu.Lock()
+	halt := s.drawdown.halt
+	due := time.Since(s.drawdown.lastSnap) >= equitySnapshotInterval
+	if due {
+		s.drawdown.lastSnap = time.Now()
+	}
+	s.drawdown.mu.Unlock()
+	if !due {
+		if halt.Halted {
+			return &halt, nil
+		}
+		return nil, nil
+	}
+
+	snap, err := s.accountEquity(ctx)
+	if err != nil {
+		if halt.Halted {
+			return &halt, err
+		}
+		return nil, err
+	}
+	if err := s.repo.InsertEquitySnapshot(ctx, snap); err != nil {
+		log.Printf("[风控] ⚠ 保存权益快照失败: %v", err)
+	}
+	if s.drawdownGuard.MaxPct <= 0 {
+		return nil, nil
+	}
+	if halt.Halted {
+		return &halt, nil
+	}
+
+	since := snap.CreatedAt.AddDate(0, 0, -s.drawdownGuard.WindowDays)
+	if halt.ResumedAt != nil && halt.ResumedAt.After(since) {
+		since = *halt.ResumedAt
+	}
+	peak, err := s.repo.PeakEquitySince(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	if peak <= 0 || snap.EquityUSDT >= peak {
+		return nil, nil
+	}
+	dd := (peak - snap.EquityUSDT) / peak * 100
+	if dd < s.drawdownGuard.MaxPct {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	halt = domain.DrawdownHalt{
+		Halted: true,
+		Reason: fmt.Sprintf("账户权益 %.2f USDT 较 %d 天内峰值 %.2f USDT 回撤 %.2f%%（阈值 %.2f%%），暂停开仓",
+			snap.EquityUSDT, s.drawdownGuard.WindowDays, peak, dd, s.drawdownGuard.MaxPct),
+		DrawdownPct: dd,
+		PeakUSDT:    peak,
+		EquityUSDT:  snap.EquityUSDT,
+		HaltedAt:    &now,
+		ResumedAt:   halt.ResumedAt,
+	}
+	if err := s.repo.SaveDrawdownHalt(ctx, halt); err != nil {
+		log.Printf("[风控] ⚠ 保存回撤熔断状态失败: %v", err)
+	}
+	s.drawdown.mu.Lock()
+	s.drawdown.halt = halt
+	s.drawdown.mu.Unlock()
+
+	log.Printf("[风控] 🚨 回撤熔断: %s", halt.Reason)
+	s.notifier.Send(notify.Event{
+		Kind:  notify.KindFailure,
+		Title: "回撤熔断已触发",
+		Text:  halt.Reason + "\n需手动恢复交易",
+	})
+	return &halt, nil
+}
+
+// drawdownHalted 当前是否处于回撤熔断中（不刷新权益）
+func (s *Service) drawdownHalted() (domain.DrawdownHalt, bool) {
+	if s.drawdownGuard.MaxPct <= 0 {
+		return domain.DrawdownHalt{}, false
+	}
+	s.drawdown.mu.Lock()
+	defer s.drawdown.mu.Unlock()
+	return s.drawdown.halt, s.drawdown.halt.Halted
+}
+
+// ResumeTrading 手动解除回撤熔断，之后的权益峰值从恢复时间起算
+func (s *Service) ResumeTrading(ctx context.Context) (domain.DrawdownHalt, error) {
+	s.drawdown.mu.Lock()
+	halt := s.drawdown.halt
+	s.drawdown.mu.Unlock()
+	if !halt.Halted {
+		return halt, fmt.Errorf("当前未处于回撤熔断")
+	}
+
+	now := time.Now().UTC()
+	halt.Halted = false
+	halt.ResumedAt = &now
+	if err := s.repo.SaveDrawdownHalt(ctx, halt); err != nil {
+		return halt, err
+	}
+	s.drawdown.mu.Lock()
+	s.drawdown.halt = halt
+	s.drawdown.lastSnap = time.Time{}
+	s.drawdown.mu.Unlock()
+	log.Printf("[风控] ✔ 回撤熔断已手动解除，恢复开仓")
+	return halt, nil
+}
+
+// DrawdownStatus 回撤熔断配置、状态与窗口内的权益快照
+type DrawdownStatus struct {
+	Enabled     bool                    `json:"enabled"`
+	MaxPct      float64                 `json:"max_pct"`
+	WindowDays  int                     `json:"window_days"`
+	ForceClose  bool                    `json:"force_close"`
+	Halt        domain.DrawdownHalt     `json:"halt"`
+	PeakUSDT    float64                 `json:"peak_usdt"`
+	EquityUSDT  float64                 `json:"equity_usdt"`
+	DrawdownPct float64                 `json:"drawdown_pct"`
+	Snapshots   []domain.EquitySnapshot `json:"snapshots"`
+}
+
+// GetDrawdownStatus 查询回撤熔断状态，当前权益取最近一次快照
+func (s *Service) GetDrawdownStatus(ctx context.Context, limit int) (DrawdownStatus, error) {
+	g := s.drawdownGuard
+	st := DrawdownStatus{Enabled: g.MaxPct > 0, MaxPct: g.MaxPct, WindowDays: g.WindowDays, ForceClose: g.ForceClose}
+	s.drawdown.mu.Lock()
+	st.Halt = s.drawdown.halt
+	s.drawdown.mu.Unlock()
+
+	days := g.WindowDays
+	if days <= 0 {
+		days = 30
+	}
+	since := time.Now().UTC().AddDate(0, 0, -days)
+	snaps, err := s.repo.ListEquitySnapshots(ctx, since, limit)
+	if err != nil {
+		return st, err
+	}
+	st.Snapshots = snaps
+	if len(snaps) == 0 {
+		return st, nil
+	}
+	if st.Halt.ResumedAt != nil && st.Halt.ResumedAt.After(since) {
+		since = *st.Halt.ResumedAt
+	}
+	if st.PeakUSDT, err = s.repo.PeakEquitySince(ctx, since); err != nil {
+		return st, err
+	}
+	st.EquityUSDT = snaps[len(snaps)-1].EquityUSDT
+	if st.PeakUSDT > 0 && st.EquityUSDT < st.PeakUSDT {
+		st.DrawdownPct = (st.PeakUSDT - st.EquityUSDT) / st.PeakUSDT * 100
+	}
+	return st, nil
+}
+
+// drawdownGuardModel 回撤熔断生成的信号使用的模型名
+const drawdownGuardModel = "drawdown_breaker"
+
+// drawdownHoldSignal 回撤熔断期间无持仓时不调用大模型，直接观望
+func drawdownHoldSignal(cycleID, pair string, h *domain.DrawdownHalt) domain.Signal {
+	return domain.Signal{
+		ID:         uuid.NewString(),
+		CycleID:    cycleID,
+		Pair:       pair,
+		Side:       domain.SideNone,
+		Confidence: 0,
+		Reason:     "回撤熔断观望：" + h.Reason,
+		ModelName:  drawdownGuardModel,
+		TTLSeconds: 60,
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+// drawdownCloseSignal 回撤熔断且开启强制平仓时，不调用大模型直接平仓
+func drawdownCloseSignal(cycleID, pair string, h *domain.DrawdownHalt) domain.Signal {
+	return domain.Signal{
+		ID:         uuid.NewString(),
+		CycleID:    cycleID,
+		Pair:       pair,
+		Side:       domain.SideClose,
+		Confidence: 1,
+		Reason:     "回撤熔断强制平仓：" + h.Reason,
+		ModelName:  drawdownGuardModel,
+		TTLSeconds: 60,
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+// drawdownAlert 回撤熔断期间有持仓时写入提示词的提示
+func drawdownAlert(h *domain.DrawdownHalt) string {
+	return fmt.Sprintf("Account equity is down %.2f%% from its recent peak (drawdown circuit breaker tripped). "+
+		"New entries are paused until manually resumed; only decide whether to hold or close the existing position.", h.DrawdownPct)
+}
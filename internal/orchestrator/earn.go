@@ -0,0 +1,42 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+
+	"ai_quant/internal/agent/execution"
+)
+
+// SetEarnAutoRedeem 开启后现货 USDT 可用余额不足时，自动从活期理财赎回差额
+func (s *Service) SetEarnAutoRedeem(enabled bool) {
+	s.earnAutoRedeem = enabled
+}
+
+// redeemEarnUSDT 按需从活期理财赎回 USDT，返回已提交赎回的数量；未开启自动赎回或赎回失败时返回 0
+func (s *Service) redeemEarnUSDT(ctx context.Context, executor execution.Executor, need, earn float64) float64 {
+	if !s.earnAutoRedeem {
+		log.Printf("[理财] 活期理财中有 %.2f USDT，未开启 EARN_AUTO_REDEEM，不自动赎回", earn)
+		return 0
+	}
+	er, ok := executor.(execution.EarnRedeemer)
+	if !ok {
+		return 0
+	}
+	if need > earn {
+		need = earn
+	}
+	redeemed, err := er.RedeemEarn(ctx, "USDT", need)
+	if err != nil {
+		log.Printf("[理财] ⚠ 赎回 %.2f USDT 失败: %v", need, err)
+		return 0
+	}
+	return redeemed
+}
+
+// spendableUSDT 提示词中的可用 USDT：开启自动赎回时活期理财也算可用资金
+func (s *Service) spendableUSDT(b execution.Balance) float64 {
+	if s.earnAutoRedeem {
+		return b.Free + b.Earn
+	}
+	return b.Free
+}
@@ -0,0 +1,109 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ai_quant/internal/agent/execution"
+)
+
+// 执行方式
+const (
+	VenueMarket = "market" // 直接市价
+	VenueLimit  = "limit"  // 限价挂单（仅 Binance 现货，其他执行器按市价）
+	VenueTWAP   = "twap"   // 按 TWAP 规则拆单
+)
+
+// ExecutionTier 名义金额达到 MinNotional 时使用的执行方式
+type ExecutionTier struct {
+	MinNotional float64 `json:"min_notional_usdt"`
+	Venue       string  `json:"venue"`
+}
+
+// ExecutionPolicy 按交易对的执行方式档位（按阈值升序），"*" 为未单独配置的交易对的默认档位；
+// 名义金额低于最低档阈值时市价下单
+type ExecutionPolicy map[string][]ExecutionTier
+
+// ParseExecutionPolicy 解析 "*=limit:50|twap:1000,DOGE/USDT=limit:20|twap:300" 格式的执行方式配置
+func ParseExecutionPolicy(spec string) (ExecutionPolicy, error) {
+	policy := make(ExecutionPolicy)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pair, tiersStr, ok := strings.Cut(item, "=")
+		pair = strings.ToUpper(strings.TrimSpace(pair))
+		if !ok || pair == "" || strings.TrimSpace(tiersStr) == "" {
+			return nil, fmt.Errorf("执行方式配置格式错误: %q（应为 DOGE/USDT=limit:20|twap:300）", item)
+		}
+		var tiers []ExecutionTier
+		for _, t := range strings.Split(tiersStr, "|") {
+			venue, minStr, ok := strings.Cut(strings.TrimSpace(t), ":")
+			venue = strings.ToLower(strings.TrimSpace(venue))
+			if !ok || (venue != VenueMarket && venue != VenueLimit && venue != VenueTWAP) {
+				return nil, fmt.Errorf("执行方式配置 %q: 档位 %q 无效（方式为 market / limit / twap）", item, t)
+			}
+			threshold, err := strconv.ParseFloat(strings.TrimSpace(minStr), 64)
+			if err != nil || threshold < 0 {
+				return nil, fmt.Errorf("执行方式配置 %q: 金额 %q 无效", item, minStr)
+			}
+			tiers = append(tiers, ExecutionTier{MinNotional: threshold, Venue: venue})
+		}
+		sort.SliceStable(tiers, func(i, j int) bool { return tiers[i].MinNotional < tiers[j].MinNotional })
+		policy[pair] = tiers
+	}
+	return policy, nil
+}
+
+// venue 按名义金额选择执行方式，交易对和默认档位都未配置时返回 false
+func (p ExecutionPolicy) venue(pair string, notional float64) (string, bool) {
+	tiers, ok := p[strings.ToUpper(pair)]
+	if !ok {
+		tiers, ok = p["*"]
+	}
+	if !ok {
+		return "", false
+	}
+	venue := VenueMarket
+	for _, t := range tiers {
+		if notional >= t.MinNotional {
+			venue = t.Venue
+		}
+	}
+	return venue, true
+}
+
+// SetExecutionPolicy 设置按名义金额的执行方式；未覆盖的交易对沿用 ORDER_TYPE 与 TWAP 阈值
+func (s *Service) SetExecutionPolicy(p ExecutionPolicy) {
+	s.execPolicy = p
+	if len(p) > 0 {
+		log.Printf("[执行] 按金额选择执行方式: %v", p)
+	}
+}
+
+// routeOrder 决定周期下单的执行方式：配置了执行方式档位时按档位，否则名义金额达到 TWAP 阈值时拆单。
+// 返回 twap=true 时按 TWAP 拆单，否则 in.OrderType 已按档位设置
+func (s *Service) routeOrder(in *execution.Input, notional float64) (twap bool) {
+	venue, ok := s.execPolicy.venue(in.Pair, notional)
+	if !ok {
+		return s.twap.enabled() && notional >= s.twap.MinNotional
+	}
+	switch venue {
+	case VenueTWAP:
+		if s.twap.Slices > 1 {
+			log.Printf("[执行] %s 名义金额 %.2f USDT → 拆单执行", in.Pair, notional)
+			return true
+		}
+		log.Printf("[执行] ⚠ %s 执行方式为 twap 但 TWAP_SLICES ≤ 1，直接下单", in.Pair)
+	case VenueLimit:
+		in.OrderType = execution.OrderTypeLimit
+	case VenueMarket:
+		in.OrderType = execution.OrderTypeMarket
+	}
+	log.Printf("[执行] %s 名义金额 %.2f USDT → %s", in.Pair, notional, venue)
+	return false
+}
@@ -0,0 +1,164 @@
+package orchestrator
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// slippageBuckets 成交金额（USDT，名义价值）区间上限，最后一档不设上限
+var slippageBuckets = []struct {
+	label string
+	upper float64
+}{
+	{"<50", 50},
+	{"50-200", 200},
+	{"200-1000", 1000},
+	{"1000-5000", 5000},
+	{">=5000", math.Inf(1)},
+}
+
+func slippageBucket(notional float64) string {
+	for _, b := range slippageBuckets {
+		if notional < b.upper {
+			return b.label
+		}
+	}
+	return slippageBuckets[len(slippageBuckets)-1].label
+}
+
+// slippageBps 订单滑点（基点）：买入成交价高于预估价、卖出成交价低于预估价为正
+func slippageBps(o domain.Order) float64 {
+	bps := (o.FilledPrice - o.ExpectedPrice) / o.ExpectedPrice * 10000
+	if o.Side == domain.SideClose {
+		bps = -bps
+	}
+	return bps
+}
+
+// slippageSample 单笔订单的滑点与对应成本
+type slippageSample struct {
+	bps  float64
+	cost float64
+}
+
+func summarizeSlippage(samples []slippageSample) domain.SlippageStats {
+	st := domain.SlippageStats{Orders: len(samples)}
+	if len(samples) == 0 {
+		return st
+	}
+	bps := make([]float64, len(samples))
+	var sum float64
+	for i, s := range samples {
+		bps[i] = s.bps
+		sum += s.bps
+		st.CostUSDT += s.cost
+	}
+	sort.Float64s(bps)
+	st.AvgBps = round2(sum / float64(len(bps)))
+	st.MedianBps = round2(percentile(bps, 0.5))
+	st.P90Bps = round2(percentile(bps, 0.9))
+	st.WorstBps = round2(bps[len(bps)-1])
+	st.CostUSDT = round2(st.CostUSDT)
+	return st
+}
+
+// percentile 已排序样本的分位数（线性插值）
+func percentile(sorted []float64, q float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// ExecutionQuality 统计最近 days 天订单的滑点（决策时预估价 vs 实际成交价），按交易对、成交金额区间和成交方式分组
+func (s *Service) ExecutionQuality(ctx context.Context, days int, includeSimulated bool) (domain.ExecutionQualityReport, error) {
+	if days <= 0 {
+		days = 30
+	}
+	since := time.Now().UTC().AddDate(0, 0, -days)
+	orders, err := s.repo.ListSlippageOrders(ctx, since, includeSimulated)
+	if err != nil {
+		return domain.ExecutionQualityReport{}, err
+	}
+
+	var all []slippageSample
+	byPair := make(map[string][]slippageSample)
+	byBucket := make(map[string][]slippageSample)
+	byPairBucket := make(map[[2]string][]slippageSample)
+	byFill := make(map[string][]slippageSample)
+	for _, o := range orders {
+		notional := o.FilledPrice * o.FilledQuantity
+		bps := slippageBps(o)
+		sample := slippageSample{bps: bps, cost: bps / 10000 * notional}
+		bucket := slippageBucket(notional)
+
+		all = append(all, sample)
+		byPair[o.Pair] = append(byPair[o.Pair], sample)
+		byBucket[bucket] = append(byBucket[bucket], sample)
+		byPairBucket[[2]string{o.Pair, bucket}] = append(byPairBucket[[2]string{o.Pair, bucket}], sample)
+		// 记录成交方式之前的订单都是市价单
+		fill := o.FillStrategy
+		if fill == "" {
+			fill = domain.FillMarket
+		}
+		byFill[fill] = append(byFill[fill], sample)
+	}
+
+	report := domain.ExecutionQualityReport{
+		Since:        since,
+		Simulated:    includeSimulated,
+		Overall:      summarizeSlippage(all),
+		ByPair:       make([]domain.SlippageStats, 0, len(byPair)),
+		ByBucket:     make([]domain.SlippageStats, 0, len(byBucket)),
+		ByPairBucket: make([]domain.SlippageStats, 0, len(byPairBucket)),
+		ByFill:       make([]domain.SlippageStats, 0, len(byFill)),
+	}
+
+	pairs := make([]string, 0, len(byPair))
+	for p := range byPair {
+		pairs = append(pairs, p)
+	}
+	sort.Strings(pairs)
+	for _, p := range pairs {
+		st := summarizeSlippage(byPair[p])
+		st.Pair = p
+		report.ByPair = append(report.ByPair, st)
+	}
+	// 区间按金额从小到大排列
+	for _, b := range slippageBuckets {
+		if samples, ok := byBucket[b.label]; ok {
+			st := summarizeSlippage(samples)
+			st.Bucket = b.label
+			report.ByBucket = append(report.ByBucket, st)
+		}
+		for _, p := range pairs {
+			if samples, ok := byPairBucket[[2]string{p, b.label}]; ok {
+				st := summarizeSlippage(samples)
+				st.Pair, st.Bucket = p, b.label
+				report.ByPairBucket = append(report.ByPairBucket, st)
+			}
+		}
+	}
+	sort.SliceStable(report.ByPairBucket, func(i, j int) bool {
+		return report.ByPairBucket[i].Pair < report.ByPairBucket[j].Pair
+	})
+	for _, f := range []string{domain.FillMarket, domain.FillLimit, domain.FillLimitReplaced, domain.FillLimitToMarket, domain.FillTWAP} {
+		if samples, ok := byFill[f]; ok {
+			st := summarizeSlippage(samples)
+			st.FillStrategy = f
+			report.ByFill = append(report.ByFill, st)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,204 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+)
+
+// SetExitLadder 设置分批止盈阶梯，开仓时由建仓策略生成对应的止盈批次
+func (s *Service) SetExitLadder(ladder []domain.ExitBatch) {
+	s.exitLadder = ladder
+	if len(ladder) > 0 {
+		log.Printf("[止盈] 分批止盈已启用: %s", formatExitLadder(ladder))
+	}
+}
+
+func formatExitLadder(ladder []domain.ExitBatch) string {
+	out := ""
+	for i, l := range ladder {
+		if i > 0 {
+			out += " → "
+		}
+		out += fmt.Sprintf("+%g%% 卖出 %.0f%%", l.ProfitPercent, l.Fraction*100)
+	}
+	return out
+}
+
+// ProcessExitBatches 检查分批止盈：每个交易对只跟踪最近一次开仓的止盈计划，
+// 最新价格相对持仓均价的盈利达到批次阈值时按比例卖出，剩余持仓按原触发价重挂保护单
+func (s *Service) ProcessExitBatches(ctx context.Context) error {
+	strategies, err := s.repo.ListPendingExitStrategies(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	// 同一交易对有多个计划时以最新的为准（按创建时间正序，后者覆盖前者）
+	latest := make(map[string]domain.PositionStrategy)
+	for _, ps := range strategies {
+		if prev, ok := latest[ps.Pair]; ok {
+			s.cancelExitBatches(ctx, prev, "被新的分批止盈计划取代")
+		}
+		latest[ps.Pair] = ps
+	}
+
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return fmt.Errorf("查询持仓失败: %w", err)
+	}
+	held := make(map[string]domain.Holding, len(holdings))
+	for _, h := range holdings {
+		held[h.Pair] = h
+	}
+
+	for _, ps := range latest {
+		if !hasExecutedBatch(ps.Batches) {
+			// 开仓未成交的计划不会再有持仓可止盈
+			if time.Since(ps.CreatedAt) > firstBatchGrace && !s.awaitingApproval(ctx, ps.CycleID) {
+				s.cancelExitBatches(ctx, ps, "开仓未成交")
+			}
+			continue
+		}
+		h, ok := held[ps.Pair]
+		if !ok || h.Quantity <= 0 || h.AvgPrice <= 0 {
+			s.cancelExitBatches(ctx, ps, "持仓已清空")
+			continue
+		}
+
+		price, err := s.fetchTickerPrice(ctx, ps.Pair)
+		if err != nil {
+			log.Printf("[止盈] ⚠ 获取 %s 价格失败: %v", ps.Pair, err)
+			continue
+		}
+		profitPct := (price - h.AvgPrice) / h.AvgPrice * 100
+
+		// 每轮最多执行一档，下一档按卖出后的持仓在下一轮检查
+		for i := range ps.ExitBatches {
+			b := &ps.ExitBatches[i]
+			if b.Status != domain.BatchPending {
+				continue
+			}
+			if profitPct < b.ProfitPercent {
+				break
+			}
+			if err := s.executeExit(ctx, &ps, b, h, price, profitPct); err != nil && !errors.Is(err, ErrCycleInProgress) {
+				log.Printf("[止盈] ✘ %s 第%d档止盈失败: %v", ps.Pair, b.BatchNo, err)
+				s.addBatchLog(ctx, ps.CycleID, "分批止盈", fmt.Sprintf("第%d档执行失败: %v", b.BatchNo, err))
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// executeExit 执行单档止盈：先撤保护单释放冻结的币，按比例卖出后为剩余持仓重挂保护单
+func (s *Service) executeExit(ctx context.Context, ps *domain.PositionStrategy, b *domain.ExitBatch, h domain.Holding, price, profitPct float64) error {
+	release, ok := s.tryLockPair(ps.Pair)
+	if !ok {
+		return ErrCycleInProgress
+	}
+	defer release()
+	idemKey := fmt.Sprintf("exit:%s:%d", ps.ID, b.BatchNo)
+	if err := s.claimRetryableKey(ctx, idemKey); errors.Is(err, ErrDuplicateOrder) {
+		// 该档已有提交或成交的订单（上次未能写入档位状态），补记为已执行，不再重复触发
+		now := time.Now().UTC()
+		b.Status = domain.BatchExecuted
+		b.ExecutedAt = &now
+		if err := s.repo.UpdatePositionExitBatches(ctx, ps.ID, ps.ExitBatches); err != nil {
+			log.Printf("[止盈] ⚠ 更新止盈批次状态失败: %v", err)
+		}
+		log.Printf("[止盈] %s 第%d档已有订单，标记为已执行", ps.Pair, b.BatchNo)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	executor := execution.ForPair(s.executor, ps.Pair)
+
+	prevProtection, _ := s.repo.ListProtectiveOrders(ctx, ps.Pair, domain.ProtectiveActive)
+	if _, err := s.cancelProtectiveOrders(ctx, ps.Pair); err != nil {
+		return fmt.Errorf("撤销保护单失败: %w", err)
+	}
+
+	qty := h.Quantity
+	if executor.TradingMode() == "futures" && !executor.IsDryRun() {
+		if posAmt, err := executor.FetchPositionRisk(ctx, ps.Pair); err == nil && posAmt > 0 {
+			qty = posAmt
+		}
+	}
+
+	log.Printf("[止盈] 🎯 %s 第%d档触发 盈利=%.2f%% ≥ %.2f%% 卖出 %.0f%% 数量=%.8f",
+		ps.Pair, b.BatchNo, profitPct, b.ProfitPercent, b.Fraction*100, qty*b.Fraction)
+	ord, err := executor.Execute(ctx, execution.Input{
+		IdempotencyKey: idemKey,
+		CycleID:        ps.CycleID,
+		SignalID:       ps.SignalID,
+		Pair:           ps.Pair,
+		Side:           domain.SideClose,
+		StakeUSDT:      qty * b.Fraction * price,
+		EstimatedFill:  price,
+		SellQuantity:   qty,
+		CloseFraction:  b.Fraction,
+	})
+	if ord.ID != "" {
+		s.persistOrder(ctx, ord)
+	}
+	if err != nil {
+		// 卖出失败：按原触发价恢复保护单
+		if _, rErr := s.restoreProtection(ctx, ps.CycleID, ps.Pair, prevProtection); rErr != nil {
+			log.Printf("[止盈] ⚠ %s 恢复保护单失败: %v", ps.Pair, rErr)
+		}
+		return err
+	}
+	s.UpdateHoldingAfterTrade(ctx, ord)
+
+	now := time.Now().UTC()
+	b.Status = domain.BatchExecuted
+	b.ExecutedPrice = ord.FilledPrice
+	b.ExecutedQty = ord.FilledQuantity
+	b.ExecutedAt = &now
+	if err := s.repo.UpdatePositionExitBatches(ctx, ps.ID, ps.ExitBatches); err != nil {
+		log.Printf("[止盈] ⚠ 更新止盈批次状态失败: %v", err)
+	}
+
+	msg := fmt.Sprintf("第%d/%d档已执行 盈利=%.2f%% 卖出 %.0f%% 成交价=%.6f 数量=%.8f 交易所ID=%s",
+		b.BatchNo, len(ps.ExitBatches), profitPct, b.Fraction*100, ord.FilledPrice, ord.FilledQuantity, ord.ExchangeOrderID)
+	log.Printf("[止盈] ✔ %s %s", ps.Pair, msg)
+	s.addBatchLog(ctx, ps.CycleID, "分批止盈", msg)
+
+	if b.Fraction >= 1 {
+		s.cancelPairBatches(ctx, ps.Pair, "分批止盈已全部卖出")
+		return nil
+	}
+	if pmsg, err := s.restoreProtection(ctx, ps.CycleID, ps.Pair, prevProtection); err != nil {
+		log.Printf("[止盈] ⚠ %s 剩余持仓保护单重挂失败: %v", ps.Pair, err)
+		s.addBatchLog(ctx, ps.CycleID, "止损", "剩余持仓重挂失败: "+err.Error())
+	} else if pmsg != "" {
+		s.addBatchLog(ctx, ps.CycleID, "止损", pmsg)
+	}
+	return nil
+}
+
+// cancelExitBatches 取消止盈计划中所有待触发的批次
+func (s *Service) cancelExitBatches(ctx context.Context, ps domain.PositionStrategy, reason string) {
+	n := 0
+	for i := range ps.ExitBatches {
+		if ps.ExitBatches[i].Status == domain.BatchPending {
+			ps.ExitBatches[i].Status = domain.BatchCancelled
+			n++
+		}
+	}
+	if n == 0 {
+		return
+	}
+	if err := s.repo.UpdatePositionExitBatches(ctx, ps.ID, ps.ExitBatches); err != nil {
+		log.Printf("[止盈] ⚠ 取消止盈批次失败: %v", err)
+		return
+	}
+	log.Printf("[止盈] %s 已取消 %d 个待触发止盈批次（%s）", ps.Pair, n, reason)
+}
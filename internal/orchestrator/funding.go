@@ -0,0 +1,205 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/ratelimit"
+
+	"github.com/google/uuid"
+)
+
+// FundingGuard 合约多仓资金费率规则（不经过大模型）：
+// 连续多期高费率时在提示词中给出强烈离场提示；累计费率成本超过阈值时直接平仓
+type FundingGuard struct {
+	HighRate         float64 // 单期费率达到该值视为高费率，如 0.0005 = 0.05%/8h
+	SustainedPeriods int     // 连续多少期高费率视为持续
+	MaxCostPct       float64 // 开仓以来累计费率成本占名义价值的百分比上限，0 = 不限制
+	AutoClose        bool    // 超过上限时自动平仓；false 时只给出提示
+}
+
+// fundingStatus 当前持仓的资金费率情况
+type fundingStatus struct {
+	Periods    int     // 开仓以来结算期数
+	CostPct    float64 // 累计费率成本（%，正数表示多仓支付）
+	CostUSDT   float64
+	LatestRate float64
+	Sustained  bool
+	rates      []float64 // 各期费率（按时间升序）
+}
+
+// SetFundingGuard 设置资金费率规则（仅合约模式生效）
+func (s *Service) SetFundingGuard(g FundingGuard) {
+	s.funding = g
+}
+
+// checkFunding 计算交易对当前多仓开仓以来的资金费率成本；无持仓或非合约模式时返回 nil
+func (s *Service) checkFunding(ctx context.Context, pair string) (*fundingStatus, error) {
+	if execution.ForPair(s.executor, pair).TradingMode() != "futures" || (s.funding.HighRate <= 0 && s.funding.MaxCostPct <= 0) {
+		return nil, nil
+	}
+
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var holding *domain.Holding
+	for i := range holdings {
+		if holdings[i].Pair == pair && holdings[i].Quantity > 0 {
+			holding = &holdings[i]
+			break
+		}
+	}
+	if holding == nil {
+		return nil, nil
+	}
+
+	st, err := s.positionFunding(ctx, *holding)
+	if err != nil || st == nil {
+		return nil, err
+	}
+
+	if n := s.funding.SustainedPeriods; s.funding.HighRate > 0 && n > 0 && len(st.rates) >= n {
+		st.Sustained = true
+		for _, r := range st.rates[len(st.rates)-n:] {
+			if r < s.funding.HighRate {
+				st.Sustained = false
+				break
+			}
+		}
+	}
+	return st, nil
+}
+
+// positionFunding 持仓开仓以来已结算的资金费率成本（按开仓均价的名义价值估算），尚无结算时返回 nil
+func (s *Service) positionFunding(ctx context.Context, h domain.Holding) (*fundingStatus, error) {
+	openedAt, err := s.repo.PositionOpenedAt(ctx, h.Pair)
+	if err != nil {
+		return nil, err
+	}
+	if openedAt.IsZero() {
+		openedAt = h.UpdatedAt
+	}
+
+	rates, err := fetchFundingHistory(ctx, h.Pair, openedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(rates) == 0 {
+		return nil, nil
+	}
+
+	st := &fundingStatus{Periods: len(rates), LatestRate: rates[len(rates)-1], rates: rates}
+	var sum float64
+	for _, r := range rates {
+		sum += r
+	}
+	st.CostPct = sum * 100
+	st.CostUSDT = sum * h.Quantity * h.AvgPrice
+	return st, nil
+}
+
+// applyFunding 把开仓以来的资金费率成本写入合约持仓详情，查询失败时保持为空
+func (s *Service) applyFunding(ctx context.Context, h domain.Holding, d *domain.FuturesPosition) {
+	if d == nil {
+		return
+	}
+	st, err := s.positionFunding(ctx, h)
+	if err != nil {
+		log.Printf("[资金费率] ⚠ 查询 %s 资金费率历史失败: %v", h.Pair, err)
+		return
+	}
+	if st == nil {
+		return
+	}
+	d.FundingPeriods = st.Periods
+	d.FundingCostPct = st.CostPct
+	d.FundingCostUSDT = st.CostUSDT
+}
+
+// formatFunding 提示词中的资金费率成本，正数表示多仓已支付
+func formatFunding(d *domain.FuturesPosition) string {
+	if d == nil || d.FundingPeriods == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%+.4f USDT (%+.3f%% over %d periods)", d.FundingCostUSDT, d.FundingCostPct, d.FundingPeriods)
+}
+
+// overLimit 累计费率成本是否超过上限
+func (g FundingGuard) overLimit(st *fundingStatus) bool {
+	return st != nil && g.MaxCostPct > 0 && st.CostPct >= g.MaxCostPct
+}
+
+// alert 生成写入提示词的资金费率提示，无需提示时返回空字符串
+func (g FundingGuard) alert(pair string, st *fundingStatus) string {
+	if st == nil || (!st.Sustained && !g.overLimit(st)) {
+		return ""
+	}
+	return fmt.Sprintf("%s long position has paid funding for %d periods: cumulative cost %.3f%% of notional (%.2f USDT), latest rate %.4f%%/8h. "+
+		"Sustained high funding erodes the position — strongly consider closing unless a clear breakout is underway.",
+		pair, st.Periods, st.CostPct, st.CostUSDT, st.LatestRate*100)
+}
+
+// fundingGuardModel 资金费率规则生成的信号使用的模型名
+const fundingGuardModel = "funding_guard"
+
+// fundingCloseSignal 累计费率成本超限时生成确定性的平仓信号（不调用大模型）
+func fundingCloseSignal(cycleID, pair string, st *fundingStatus, limit float64) domain.Signal {
+	return domain.Signal{
+		ID:         uuid.NewString(),
+		CycleID:    cycleID,
+		Pair:       pair,
+		Side:       domain.SideClose,
+		Confidence: 1,
+		Reason: fmt.Sprintf("资金费率规则平仓：开仓以来累计费率成本 %.3f%%（%.2f USDT）超过上限 %.2f%%",
+			st.CostPct, st.CostUSDT, limit),
+		ModelName:  fundingGuardModel,
+		TTLSeconds: 60,
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+// fetchFundingHistory 获取 since 之后已结算的资金费率（公开接口，按时间升序）
+func fetchFundingHistory(ctx context.Context, pair string, since time.Time) ([]float64, error) {
+	symbol := strings.ReplaceAll(strings.ToUpper(pair), "/", "")
+	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/fundingRate?symbol=%s&startTime=%d&limit=1000",
+		symbol, since.UnixMilli())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ratelimit.NewClient(5 * time.Second).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("funding rate API %d", resp.StatusCode)
+	}
+
+	var results []struct {
+		FundingRate string `json:"fundingRate"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, err
+	}
+	rates := make([]float64, 0, len(results))
+	for _, r := range results {
+		v, err := strconv.ParseFloat(r.FundingRate, 64)
+		if err != nil {
+			log.Printf("[资金费率] 解析费率失败 %q: %v", r.FundingRate, err)
+			continue
+		}
+		rates = append(rates, v)
+	}
+	return rates, nil
+}
@@ -0,0 +1,56 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+)
+
+// estimatedMaintMarginRate 模拟盘估算强平价使用的维持保证金率（Binance 最低档约 0.4%）
+const estimatedMaintMarginRate = 0.004
+
+// futuresPositionDetail 合约持仓详情：实盘查询 positionRisk，模拟盘或查询失败时按本地持仓估算
+func (s *Service) futuresPositionDetail(ctx context.Context, executor execution.Executor, h domain.Holding, price float64) *domain.FuturesPosition {
+	if d, ok := executor.(execution.PositionDetailer); ok && !executor.IsDryRun() {
+		detail, err := d.FetchPositionDetail(ctx, h.Pair)
+		if err == nil && detail != nil {
+			applyMarginHealth(detail)
+			return detail
+		}
+		if err != nil {
+			log.Printf("[合约] ⚠ 查询 %s 持仓详情失败: %v，使用本地估算", h.Pair, err)
+		}
+	}
+	d := estimateFuturesPosition(h, price, s.leverageFor(ctx, h.Pair, executor.Leverage()))
+	applyMarginHealth(d)
+	return d
+}
+
+// estimateFuturesPosition 按逐仓多头估算：保证金 = 开仓名义价值 / 杠杆，
+// 强平价 = 开仓价 × (1 - 1/杠杆) / (1 - 维持保证金率)
+func estimateFuturesPosition(h domain.Holding, price float64, leverage int) *domain.FuturesPosition {
+	if leverage < 1 {
+		leverage = 1
+	}
+	if price <= 0 {
+		price = h.AvgPrice
+	}
+	d := &domain.FuturesPosition{
+		EntryPrice: h.AvgPrice,
+		MarkPrice:  price,
+		Leverage:   leverage,
+		Notional:   h.Quantity * price,
+		Margin:     h.Quantity * h.AvgPrice / float64(leverage),
+		Estimated:  true,
+	}
+	d.UnrealizedPnL = (price - h.AvgPrice) * h.Quantity
+	if leverage > 1 {
+		d.LiquidationPrice = h.AvgPrice * (1 - 1/float64(leverage)) / (1 - estimatedMaintMarginRate)
+	}
+	if d.Margin > 0 {
+		d.ROE = d.UnrealizedPnL / d.Margin * 100
+	}
+	return d
+}
@@ -0,0 +1,103 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"ai_quant/internal/costbasis"
+	"ai_quant/internal/domain"
+)
+
+// importTradeLimit 冷启动导入每个交易对查询的成交笔数（交易所单次上限）
+const importTradeLimit = 1000
+
+// importQtyTolerance 成交回放数量与余额的相对误差在该范围内视为一致（手续费扣币等）
+const importQtyTolerance = 0.005
+
+// ImportPositions 冷启动导入：按交易所当前余额逐个币种回放成交历史，重建持仓数量与成本并导入成交记录；
+// dryRun 时只返回核对结果，不写入数据库
+func (s *Service) ImportPositions(ctx context.Context, dryRun bool) (domain.PositionImportResult, error) {
+	result := domain.PositionImportResult{DryRun: dryRun, Positions: []domain.PositionImport{}}
+	balances, err := s.executor.FetchAccountBalances(ctx)
+	if err != nil {
+		return result, fmt.Errorf("获取交易所余额失败: %w", err)
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Symbol < balances[j].Symbol })
+
+	book := costbasis.NewBook(costbasis.Current())
+	now := time.Now().UTC()
+	for _, b := range balances {
+		if b.Total <= 0 {
+			continue
+		}
+		p := domain.PositionImport{Pair: b.Symbol + "/USDT", Symbol: b.Symbol, ExchangeQty: b.Total}
+
+		trades, err := s.executor.FetchTradeHistory(ctx, p.Pair, importTradeLimit)
+		if err != nil {
+			p.Status = domain.ImportFailed
+			p.Note = err.Error()
+			result.Positions = append(result.Positions, p)
+			log.Printf("[导入] ⚠ %s 查询成交失败: %v", p.Pair, err)
+			continue
+		}
+		sort.Slice(trades, func(i, j int) bool { return trades[i].Timestamp.Before(trades[j].Timestamp) })
+		p.Trades = len(trades)
+		p.Truncated = len(trades) >= importTradeLimit
+
+		for _, t := range trades {
+			if t.IsBuyer {
+				book.Buy(p.Pair, t.Quantity, t.Price)
+			} else {
+				book.Sell(p.Pair, t.Quantity, t.Price)
+			}
+		}
+		qty, cost := book.Position(p.Pair)
+		p.ReconstructedQty = qty
+		if qty > 0 {
+			p.AvgPrice = cost / qty
+		}
+		p.TotalCost = p.AvgPrice * b.Total
+
+		switch {
+		case qty <= 0:
+			p.Status = domain.ImportNoHistory
+			p.Note = "成交记录无法还原当前余额（可能来自充值或转入），成本记为 0"
+		case math.Abs(qty-b.Total) <= b.Total*importQtyTolerance:
+			p.Status = domain.ImportMatched
+		default:
+			p.Status = domain.ImportPartial
+			p.Note = fmt.Sprintf("成交回放数量 %.8g 与余额 %.8g 不一致，按回放均价估算成本", qty, b.Total)
+		}
+		if p.Truncated && p.Status == domain.ImportMatched {
+			p.Note = fmt.Sprintf("成交笔数达到查询上限 %d，更早的成交未包含", importTradeLimit)
+		}
+
+		if !dryRun {
+			p.ImportedOrders = s.importTrades(ctx, p.Pair, trades)
+			result.ImportedOrders += p.ImportedOrders
+			h := domain.Holding{
+				Pair:      p.Pair,
+				Symbol:    p.Symbol,
+				Quantity:  b.Total,
+				AvgPrice:  p.AvgPrice,
+				TotalCost: p.TotalCost,
+				Source:    "import",
+				UpdatedAt: now,
+			}
+			if err := s.repo.UpsertHolding(ctx, h); err != nil {
+				return result, fmt.Errorf("更新持仓 %s: %w", p.Pair, err)
+			}
+		}
+		result.Positions = append(result.Positions, p)
+		log.Printf("[导入] %s 余额=%.8g 回放=%.8g 均价=%.8g 成交=%d 状态=%s", p.Pair, b.Total, qty, p.AvgPrice, p.Trades, p.Status)
+	}
+
+	if !dryRun {
+		log.Printf("[导入] ✔ 冷启动导入完成: %d 个币种，新导入 %d 笔成交", len(result.Positions), result.ImportedOrders)
+	}
+	return result, nil
+}
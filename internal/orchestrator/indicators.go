@@ -0,0 +1,30 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"ai_quant/internal/market"
+)
+
+// IndicatorSource 按 K 线计算指标序列（market.Client 实现）
+type IndicatorSource interface {
+	FetchIndicators(ctx context.Context, pair, interval string, names []string, limit int) (market.IndicatorSeries, error)
+}
+
+// ErrIndicatorsUnavailable 未注入指标数据源
+var ErrIndicatorsUnavailable = errors.New("指标数据源未配置")
+
+// SetIndicatorSource 注入指标计算数据源
+func (s *Service) SetIndicatorSource(src IndicatorSource) {
+	s.indicators = src
+}
+
+// Indicators 计算交易对的指标序列，与提示词使用相同的 K 线与算法
+func (s *Service) Indicators(ctx context.Context, pair, interval string, names []string, limit int) (market.IndicatorSeries, error) {
+	if s.indicators == nil {
+		return market.IndicatorSeries{}, ErrIndicatorsUnavailable
+	}
+	return s.indicators.FetchIndicators(ctx, strings.ToUpper(strings.TrimSpace(pair)), interval, names, limit)
+}
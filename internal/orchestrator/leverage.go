@@ -0,0 +1,48 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"ai_quant/internal/agent/execution"
+)
+
+// SetPairLeverage 手动调整合约交易对杠杆：先在交易所生效，再持久化，后续周期和加仓都使用该杠杆
+func (s *Service) SetPairLeverage(ctx context.Context, pair string, leverage int) error {
+	pair = strings.ToUpper(strings.TrimSpace(pair))
+	executor := execution.ForPair(s.executor, pair)
+	if executor.TradingMode() != "futures" {
+		return fmt.Errorf("%s 不是合约交易对，无法设置杠杆", pair)
+	}
+	ls, ok := executor.(execution.LeverageSetter)
+	if !ok {
+		return fmt.Errorf("当前执行器不支持调整杠杆")
+	}
+	if err := ls.SetLeverage(ctx, pair, leverage); err != nil {
+		return err
+	}
+	if err := s.repo.SetPairLeverage(ctx, pair, leverage); err != nil {
+		return err
+	}
+	log.Printf("[合约] ✔ %s 杠杆已调整为 %dx 并保存", pair, leverage)
+	return nil
+}
+
+// ListPairLeverage 列出按交易对保存的杠杆
+func (s *Service) ListPairLeverage(ctx context.Context) (map[string]int, error) {
+	return s.repo.ListPairLeverage(ctx)
+}
+
+// leverageFor 返回交易对下单使用的杠杆：手动保存的杠杆优先，否则用风险预设的杠杆 fallback
+func (s *Service) leverageFor(ctx context.Context, pair string, fallback int) int {
+	lev, err := s.repo.GetPairLeverage(ctx, strings.ToUpper(pair))
+	if err != nil {
+		log.Printf("[合约] ⚠ 读取 %s 保存的杠杆失败: %v", pair, err)
+	}
+	if lev > 0 {
+		return lev
+	}
+	return fallback
+}
@@ -0,0 +1,185 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/notify"
+)
+
+// LiquidationGuard 合约强平预警规则：标记价距强平价不足 BufferPct 时告警，ReduceFraction > 0 时自动减仓
+type LiquidationGuard struct {
+	BufferPct      float64 // 标记价与强平价的距离（% 标记价）低于该值视为危险，0 = 不监控
+	ReduceFraction float64 // 进入危险区时自动平掉的比例（0-1），0 = 只告警
+}
+
+// liquidationState 危险状态：同一交易对进入危险区只告警 / 减仓一次，恢复后重新计
+type liquidationState struct {
+	mu     sync.Mutex
+	danger map[string]bool
+}
+
+// SetLiquidationGuard 设置合约强平预警规则
+func (s *Service) SetLiquidationGuard(g LiquidationGuard) {
+	if g.ReduceFraction < 0 {
+		g.ReduceFraction = 0
+	}
+	if g.ReduceFraction > 1 {
+		g.ReduceFraction = 1
+	}
+	s.liquidation = g
+	s.liqState.danger = make(map[string]bool)
+	if g.BufferPct > 0 {
+		log.Printf("[风控] 合约强平预警已启用: 距强平价 ≤ %.2f%% 告警，自动减仓 %.0f%%", g.BufferPct, g.ReduceFraction*100)
+	}
+}
+
+// LiquidationGuardEnabled 是否配置了强平预警
+func (s *Service) LiquidationGuardEnabled() bool {
+	return s.liquidation.BufferPct > 0
+}
+
+// applyMarginHealth 计算强平距离与保证金率：保证金余额 = 占用保证金 + 未实现盈亏，
+// 维持保证金按最低档维持保证金率估算（positionRisk 不返回维持保证金）
+func applyMarginHealth(d *domain.FuturesPosition) {
+	if d == nil {
+		return
+	}
+	if d.MarkPrice > 0 && d.LiquidationPrice > 0 {
+		d.LiqDistancePct = round2((d.MarkPrice - d.LiquidationPrice) / d.MarkPrice * 100)
+	}
+	if balance := d.Margin + d.UnrealizedPnL; balance > 0 {
+		d.MarginRatio = round2(d.Notional * estimatedMaintMarginRate / balance * 100)
+	}
+}
+
+// CheckLiquidationRisk 巡检合约持仓与强平价的距离，进入危险区时告警并按配置自动减仓
+func (s *Service) CheckLiquidationRisk(ctx context.Context) error {
+	if !s.LiquidationGuardEnabled() {
+		return nil
+	}
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return fmt.Errorf("查询持仓失败: %w", err)
+	}
+	for _, h := range holdings {
+		executor := execution.ForPair(s.executor, h.Pair)
+		if h.Quantity <= 0 || executor.TradingMode() != "futures" {
+			continue
+		}
+		price, _ := s.fetchTickerPrice(ctx, h.Pair)
+		d := s.futuresPositionDetail(ctx, executor, h, price)
+		danger := d != nil && d.LiquidationPrice > 0 && d.LiqDistancePct <= s.liquidation.BufferPct
+		if !s.markLiquidation(h.Pair, danger) {
+			continue
+		}
+
+		msg := fmt.Sprintf("%s 标记价 %.6f 距强平价 %.6f 仅 %.2f%%（预警 %.2f%%），保证金率 %.2f%%",
+			h.Pair, d.MarkPrice, d.LiquidationPrice, d.LiqDistancePct, s.liquidation.BufferPct, d.MarginRatio)
+		log.Printf("[风控] 🚨 %s", msg)
+		s.notifier.Send(notify.Event{
+			Kind:  notify.KindFailure,
+			Title: "合约接近强平",
+			Text:  msg,
+		})
+		if s.liquidation.ReduceFraction > 0 {
+			if err := s.reduceForLiquidation(ctx, executor, h, d.MarkPrice); err != nil {
+				// 减仓失败时清除危险标记，下一轮巡检重新告警并重试减仓
+				s.clearLiquidation(h.Pair)
+				if errors.Is(err, ErrCycleInProgress) {
+					log.Printf("[风控] ⏸ %s 有周期正在执行，下一轮重试自动减仓", h.Pair)
+					continue
+				}
+				log.Printf("[风控] ✘ %s 自动减仓失败: %v，下一轮重试", h.Pair, err)
+				s.notifier.Send(notify.Event{
+					Kind:  notify.KindFailure,
+					Title: "强平预警减仓失败",
+					Text:  h.Pair + ": " + err.Error(),
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// markLiquidation 更新交易对的危险状态，返回是否刚进入危险区
+func (s *Service) markLiquidation(pair string, danger bool) bool {
+	s.liqState.mu.Lock()
+	defer s.liqState.mu.Unlock()
+	was := s.liqState.danger[pair]
+	s.liqState.danger[pair] = danger
+	if was && !danger {
+		log.Printf("[风控] %s 已脱离强平预警区", pair)
+	}
+	return danger && !was
+}
+
+// clearLiquidation 清除交易对的危险标记，下一轮巡检按刚进入危险区处理
+func (s *Service) clearLiquidation(pair string) {
+	s.liqState.mu.Lock()
+	delete(s.liqState.danger, pair)
+	s.liqState.mu.Unlock()
+}
+
+// reduceForLiquidation 按比例市价减仓：先撤保护单，减仓后为剩余持仓重挂
+func (s *Service) reduceForLiquidation(ctx context.Context, executor execution.Executor, h domain.Holding, price float64) error {
+	release, ok := s.tryLockPair(h.Pair)
+	if !ok {
+		return ErrCycleInProgress
+	}
+	defer release()
+
+	prevProtection, _ := s.repo.ListProtectiveOrders(ctx, h.Pair, domain.ProtectiveActive)
+	if _, err := s.cancelProtectiveOrders(ctx, h.Pair); err != nil {
+		return fmt.Errorf("撤销保护单失败: %w", err)
+	}
+
+	qty := h.Quantity
+	if !executor.IsDryRun() {
+		if posAmt, err := executor.FetchPositionRisk(ctx, h.Pair); err == nil && posAmt > 0 {
+			qty = posAmt
+		}
+	}
+	fraction := s.liquidation.ReduceFraction
+	log.Printf("[风控] %s 强平预警自动减仓 %.0f%% 数量=%.8f", h.Pair, fraction*100, qty*fraction)
+	ord, err := executor.Execute(ctx, execution.Input{
+		Pair:          h.Pair,
+		Side:          domain.SideClose,
+		StakeUSDT:     qty * fraction * price,
+		EstimatedFill: price,
+		SellQuantity:  qty,
+		CloseFraction: fraction,
+		ForceMarket:   true,
+	})
+	if ord.ID != "" {
+		s.persistOrder(ctx, ord)
+	}
+	if err != nil {
+		if _, rErr := s.restoreProtection(ctx, "", h.Pair, prevProtection); rErr != nil {
+			log.Printf("[风控] ⚠ %s 恢复保护单失败: %v", h.Pair, rErr)
+		}
+		return err
+	}
+	s.UpdateHoldingAfterTrade(ctx, ord)
+
+	msg := fmt.Sprintf("%s 强平预警减仓 成交价=%.8f 数量=%.8f", h.Pair, ord.FilledPrice, ord.FilledQuantity)
+	log.Printf("[风控] ✔ %s", msg)
+	s.notifier.Send(notify.Event{
+		Kind:  notify.KindFill,
+		Title: "强平预警减仓",
+		Text:  msg,
+	})
+	if fraction >= 1 {
+		s.cancelPairBatches(ctx, h.Pair, "强平预警已全部平仓")
+		return nil
+	}
+	if _, err := s.restoreProtection(ctx, "", h.Pair, prevProtection); err != nil {
+		log.Printf("[风控] ⚠ %s 剩余持仓保护单重挂失败: %v", h.Pair, err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai_quant/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// manualTradeModel 人工下单信号的模型名称
+const manualTradeModel = "manual-trade"
+
+// ErrInvalidManualTrade 人工下单参数不合法
+var ErrInvalidManualTrade = errors.New("invalid manual trade")
+
+// ManualTrade 人工下单参数：StakeUSDT 与 Quantity 二选一
+type ManualTrade struct {
+	Side        string  // buy / sell；sell 为卖出 / 平掉已有持仓（执行器只支持做多，不开空）
+	StakeUSDT   float64 // 下单金额
+	Quantity    float64 // 币数量，买入时按当前价格折算金额，卖出时按占持仓的比例平仓
+	EnforceRisk bool    // true 时照常经过风控与熔断拦截，金额不超过风控上限；false 时跳过
+	Note        string
+}
+
+// ManualTrade 人工下单：跳过大模型按指定方向与金额 / 数量下单，记为 manual 类型的周期；按手动优先级排队
+func (s *Service) ManualTrade(ctx context.Context, pair string, t ManualTrade, requestedBy string) (domain.CycleResult, error) {
+	t.Side = strings.ToLower(strings.TrimSpace(t.Side))
+	if t.Side != "buy" && t.Side != "sell" {
+		return domain.CycleResult{}, fmt.Errorf("%w: side 只支持 buy / sell", ErrInvalidManualTrade)
+	}
+	if t.StakeUSDT < 0 || t.Quantity < 0 || (t.StakeUSDT > 0) == (t.Quantity > 0) {
+		return domain.CycleResult{}, fmt.Errorf("%w: stake_usdt 与 quantity 需且只能指定一个正数", ErrInvalidManualTrade)
+	}
+	return s.SubmitCycle(ctx, RunRequest{Pair: pair, Manual: &t, RequestedBy: requestedBy}, PriorityManual, 0)
+}
+
+// manualTradeSignal 把人工下单换算成信号：买入返回下单金额，卖出按持仓比例生成平仓信号
+func (s *Service) manualTradeSignal(ctx context.Context, cycleID, pair string, t ManualTrade, price float64) (domain.Signal, float64, error) {
+	sig := domain.Signal{
+		ID:         uuid.NewString(),
+		CycleID:    cycleID,
+		Pair:       pair,
+		Side:       domain.SideLong,
+		Confidence: 1,
+		ModelName:  manualTradeModel,
+		TTLSeconds: 60,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if t.Side == "sell" {
+		sig.Side = domain.SideClose
+		held := 0.0
+		if holdings, err := s.repo.ListHoldings(ctx); err == nil {
+			for _, h := range holdings {
+				if strings.EqualFold(h.Pair, pair) {
+					held = h.Quantity
+					break
+				}
+			}
+		}
+		if held <= 0 {
+			return sig, 0, fmt.Errorf("%s 无持仓可卖", pair)
+		}
+		qty := t.Quantity
+		if qty <= 0 {
+			if price <= 0 {
+				return sig, 0, fmt.Errorf("%s 无法获取当前价格，不能按金额卖出", pair)
+			}
+			qty = t.StakeUSDT / price
+		}
+		sig.CloseFraction = domain.NormalizeCloseFraction(qty / held)
+		sig.Reason = manualTradeReason(fmt.Sprintf("人工卖出 %.8f（持仓 %.0f%%）", min(qty, held), sig.CloseFraction*100), t.Note)
+		return sig, 0, nil
+	}
+
+	stake := t.StakeUSDT
+	if stake <= 0 {
+		if price <= 0 {
+			return sig, 0, fmt.Errorf("%s 无法获取当前价格，不能按数量折算金额", pair)
+		}
+		stake = t.Quantity * price
+	}
+	sig.Reason = manualTradeReason(fmt.Sprintf("人工买入 %.2f USDT", stake), t.Note)
+	return sig, stake, nil
+}
+
+func manualTradeReason(reason, note string) string {
+	if note = strings.TrimSpace(note); note != "" {
+		return reason + "：" + note
+	}
+	return reason
+}
+
+// manualRiskDecision 跳过风控的人工下单直接放行指定金额
+func manualRiskDecision(sig domain.Signal, stake float64) domain.RiskDecision {
+	return domain.RiskDecision{
+		ID:           uuid.NewString(),
+		CycleID:      sig.CycleID,
+		SignalID:     sig.ID,
+		Approved:     true,
+		MaxStakeUSDT: stake,
+		CreatedAt:    time.Now().UTC(),
+	}
+}
@@ -0,0 +1,119 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/notify"
+)
+
+// ErrNotMargin 交易对不是现货杠杆模式
+var ErrNotMargin = errors.New("该交易对不是现货杠杆模式")
+
+// marginState 风险率告警状态：同一账户低于预警线只告警一次，恢复后重新计
+type marginState struct {
+	mu  sync.Mutex
+	low map[string]bool // 全仓为 ""，逐仓为交易对
+}
+
+// SetMarginMinLevel 设置现货杠杆风险率预警线，低于该值时暂停开仓并告警，0 = 不监控
+func (s *Service) SetMarginMinLevel(level float64) {
+	s.marginMinLevel = level
+	s.margin.low = make(map[string]bool)
+	if level > 0 {
+		log.Printf("[风控] 杠杆风险率预警已启用: 低于 %.2f 暂停开仓", level)
+	}
+}
+
+// MarginStatus 查询交易对所在杠杆账户的风险率
+func (s *Service) MarginStatus(ctx context.Context, pair string) (*domain.MarginStatus, error) {
+	mm, ok := execution.ForPair(s.executor, pair).(execution.MarginMonitor)
+	if !ok {
+		return nil, ErrNotMargin
+	}
+	st, err := mm.FetchMarginStatus(ctx, pair)
+	if err != nil {
+		return nil, err
+	}
+	s.markMargin(st)
+	return st, nil
+}
+
+// checkMargin 开仓前检查杠杆风险率；非杠杆交易对或未启用预警时返回 nil
+func (s *Service) checkMargin(ctx context.Context, pair string) (*domain.MarginStatus, error) {
+	if s.marginMinLevel <= 0 {
+		return nil, nil
+	}
+	if _, ok := execution.ForPair(s.executor, pair).(execution.MarginMonitor); !ok {
+		return nil, nil
+	}
+	return s.MarginStatus(ctx, pair)
+}
+
+// CheckMarginLevels 巡检所有杠杆持仓的风险率，低于预警线时推送告警
+func (s *Service) CheckMarginLevels(ctx context.Context) error {
+	if s.marginMinLevel <= 0 {
+		return nil
+	}
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return fmt.Errorf("查询持仓失败: %w", err)
+	}
+	checked := make(map[string]bool)
+	for _, h := range holdings {
+		st, err := s.checkMargin(ctx, h.Pair)
+		if err != nil {
+			log.Printf("[风控] ⚠ 查询 %s 杠杆风险率失败: %v", h.Pair, err)
+			continue
+		}
+		if st == nil || checked[st.Pair] {
+			continue
+		}
+		checked[st.Pair] = true
+		log.Printf("[风控] 杠杆风险率 %s: %.2f（预警线 %.2f）", marginAccount(st), st.MarginLevel, st.MinLevel)
+	}
+	return nil
+}
+
+// markMargin 按预警线标记风险率状态，首次跌破时推送告警
+func (s *Service) markMargin(st *domain.MarginStatus) {
+	st.MinLevel = s.marginMinLevel
+	st.Low = st.MinLevel > 0 && !st.Estimated && st.MarginLevel > 0 && st.MarginLevel < st.MinLevel
+
+	s.margin.mu.Lock()
+	wasLow := s.margin.low[st.Pair]
+	if s.margin.low != nil {
+		s.margin.low[st.Pair] = st.Low
+	}
+	s.margin.mu.Unlock()
+
+	if st.Low && !wasLow {
+		log.Printf("[风控] 🚨 %s", marginAlert(st))
+		s.notifier.Send(notify.Event{
+			Kind:  notify.KindFailure,
+			Title: "杠杆风险率过低",
+			Text:  marginAlert(st),
+		})
+	} else if !st.Low && wasLow {
+		log.Printf("[风控] 杠杆风险率已恢复 %s: %.2f", marginAccount(st), st.MarginLevel)
+	}
+}
+
+// marginAccount 风险率所属账户的展示名
+func marginAccount(st *domain.MarginStatus) string {
+	if st.Pair == "" {
+		return "全仓账户"
+	}
+	return st.Pair + " 逐仓账户"
+}
+
+// marginAlert 风险率低于预警线时写入提示词的风险提示
+func marginAlert(st *domain.MarginStatus) string {
+	return fmt.Sprintf("%s风险率 %.2f 低于预警线 %.2f，已暂停新开仓，请考虑减仓还款以降低强平风险",
+		marginAccount(st), st.MarginLevel, st.MinLevel)
+}
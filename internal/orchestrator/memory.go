@@ -0,0 +1,56 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+
+	"ai_quant/internal/costbasis"
+	"ai_quant/internal/domain"
+)
+
+// SetDecisionMemory 设置写入提示词的历史决策条数，0 = 不启用
+func (s *Service) SetDecisionMemory(cycles int) {
+	s.memoryCycles = cycles
+	if cycles > 0 {
+		log.Printf("[信号] 决策记忆已启用: 提示词附带最近 %d 个周期的决策与结果", cycles)
+	}
+}
+
+// decisionMemory 交易对最近几个周期的决策与结果（按时间倒序），平仓周期附带按成本核算方法回放的已实现盈亏
+func (s *Service) decisionMemory(ctx context.Context, pair string) []domain.DecisionMemory {
+	if s.memoryCycles <= 0 {
+		return nil
+	}
+	list, err := s.repo.ListRecentDecisions(ctx, pair, s.memoryCycles)
+	if err != nil {
+		log.Printf("[信号] ⚠ 查询 %s 历史决策失败: %v", pair, err)
+		return nil
+	}
+	if len(list) == 0 {
+		return nil
+	}
+
+	orders, err := s.repo.ListFilledOrders(ctx)
+	if err != nil {
+		log.Printf("[信号] ⚠ 查询成交订单失败: %v，历史决策不含盈亏", err)
+		return list
+	}
+	book := costbasis.NewBook(costbasis.Current())
+	realized := make(map[string]float64)
+	for _, o := range orders {
+		if o.Pair != pair {
+			continue
+		}
+		switch o.Side {
+		case domain.SideLong:
+			book.Buy(o.Pair, o.FilledQuantity, o.FilledPrice)
+		case domain.SideClose:
+			pnl, _ := book.Sell(o.Pair, o.FilledQuantity, o.FilledPrice)
+			realized[o.CycleID] += pnl
+		}
+	}
+	for i := range list {
+		list[i].RealizedPnL = realized[list[i].CycleID]
+	}
+	return list
+}
@@ -0,0 +1,187 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai_quant/internal/domain"
+	"ai_quant/internal/notify"
+)
+
+// behaviorLookback 统计模型输出分布时查询的信号时间范围
+const behaviorLookback = 30 * 24 * time.Hour
+
+// BehaviorMonitor 模型输出分布监控：每个模型最近 Window 个信号与之前 Baseline 个信号对比，
+// 任一方向占比变化达到 SideShiftPct 个百分点或平均置信度变化达到 ConfidenceShift 时告警
+type BehaviorMonitor struct {
+	Window          int     // 最近信号数，0 = 不监控
+	Baseline        int     // 基线信号数
+	SideShiftPct    float64 // 方向占比变化阈值（百分点），0 = 不检查
+	ConfidenceShift float64 // 平均置信度变化阈值（0-1），0 = 不检查
+}
+
+func (m BehaviorMonitor) enabled() bool {
+	return m.Window > 0 && m.Baseline > 0 && (m.SideShiftPct > 0 || m.ConfidenceShift > 0)
+}
+
+// behaviorState 已告警的模型：同一模型异常期间只告警一次，恢复正常后重新计
+type behaviorState struct {
+	mu      sync.Mutex
+	alerted map[string]bool
+}
+
+// SetBehaviorMonitor 设置模型输出分布监控规则
+func (s *Service) SetBehaviorMonitor(m BehaviorMonitor) {
+	if m.Baseline <= 0 {
+		m.Baseline = m.Window * 5
+	}
+	s.behavior = m
+	s.behaviorState.alerted = make(map[string]bool)
+	if m.enabled() {
+		log.Printf("[模型监控] 已启用: 最近 %d 个信号对比之前 %d 个，方向占比变化 ≥ %.0f 个百分点或平均置信度变化 ≥ %.2f 时告警",
+			m.Window, m.Baseline, m.SideShiftPct, m.ConfidenceShift)
+	}
+}
+
+// BehaviorMonitorEnabled 是否配置了模型输出分布监控
+func (s *Service) BehaviorMonitorEnabled() bool {
+	return s.behavior.enabled()
+}
+
+// ModelBehavior 各模型最近信号与基线的分布对比，按模型名排序
+func (s *Service) ModelBehavior(ctx context.Context) ([]domain.ModelBehavior, error) {
+	window, baseline := s.behavior.Window, s.behavior.Baseline
+	if window <= 0 {
+		window, baseline = 20, 100
+	}
+	signals, err := s.repo.ListModelSignals(ctx, time.Now().Add(-behaviorLookback))
+	if err != nil {
+		return nil, err
+	}
+	byModel := make(map[string][]domain.Signal)
+	for _, sig := range signals {
+		name := sig.ModelName
+		if name == "" {
+			name = "unknown"
+		}
+		byModel[name] = append(byModel[name], sig)
+	}
+
+	out := make([]domain.ModelBehavior, 0, len(byModel))
+	for name, list := range byModel {
+		// 信号按时间倒序：前 window 个为最近，其后 baseline 个为基线
+		recent := list[:min(window, len(list))]
+		base := list[len(recent):min(len(recent)+baseline, len(list))]
+		b := domain.ModelBehavior{
+			ModelName: name,
+			Recent:    behaviorStats(recent),
+			Baseline:  behaviorStats(base),
+		}
+		// 最近窗口未满或基线样本少于窗口时不判断，避免新模型刚上线就误报
+		if len(recent) == window && len(base) >= window {
+			b.Anomalies = s.behaviorAnomalies(b.Recent, b.Baseline)
+		}
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ModelName < out[j].ModelName })
+	return out, nil
+}
+
+// behaviorStats 统计一组信号的方向占比与平均置信度
+func behaviorStats(signals []domain.Signal) domain.BehaviorStats {
+	st := domain.BehaviorStats{Signals: len(signals), SideShare: make(map[domain.Side]float64)}
+	if len(signals) == 0 {
+		return st
+	}
+	counts := make(map[domain.Side]int)
+	sum := 0.0
+	for _, sig := range signals {
+		counts[sig.Side]++
+		sum += sig.Confidence
+	}
+	for side, n := range counts {
+		st.SideShare[side] = round2(float64(n) / float64(len(signals)) * 100)
+	}
+	st.AvgConfidence = round2(sum / float64(len(signals)))
+	return st
+}
+
+// behaviorAnomalies 对比最近与基线分布，返回超过阈值的变化描述
+func (s *Service) behaviorAnomalies(recent, base domain.BehaviorStats) []string {
+	var out []string
+	if s.behavior.SideShiftPct > 0 {
+		sides := make(map[domain.Side]bool)
+		for side := range recent.SideShare {
+			sides[side] = true
+		}
+		for side := range base.SideShare {
+			sides[side] = true
+		}
+		keys := make([]string, 0, len(sides))
+		for side := range sides {
+			keys = append(keys, string(side))
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			side := domain.Side(k)
+			if d := recent.SideShare[side] - base.SideShare[side]; math.Abs(d) >= s.behavior.SideShiftPct {
+				out = append(out, fmt.Sprintf("%s 占比 %.0f%% → %.0f%%", side, base.SideShare[side], recent.SideShare[side]))
+			}
+		}
+	}
+	if s.behavior.ConfidenceShift > 0 && math.Abs(recent.AvgConfidence-base.AvgConfidence) >= s.behavior.ConfidenceShift {
+		out = append(out, fmt.Sprintf("平均置信度 %.2f → %.2f", base.AvgConfidence, recent.AvgConfidence))
+	}
+	return out
+}
+
+// CheckModelBehavior 巡检各模型输出分布，出现突变时告警（同一模型异常期间只告警一次）
+func (s *Service) CheckModelBehavior(ctx context.Context) error {
+	if !s.BehaviorMonitorEnabled() {
+		return nil
+	}
+	models, err := s.ModelBehavior(ctx)
+	if err != nil {
+		return fmt.Errorf("统计模型输出分布失败: %w", err)
+	}
+	for _, m := range models {
+		anomalous := len(m.Anomalies) > 0
+		if !s.markBehavior(m.ModelName, anomalous) {
+			continue
+		}
+		if !anomalous {
+			log.Printf("[模型监控] ✔ %s 输出分布已恢复正常", m.ModelName)
+			continue
+		}
+		msg := fmt.Sprintf("%s 最近 %d 个信号相对之前 %d 个出现突变: %s（可能是提示词回归或服务商更换了模型）",
+			m.ModelName, m.Recent.Signals, m.Baseline.Signals, strings.Join(m.Anomalies, "；"))
+		log.Printf("[模型监控] ⚠ %s", msg)
+		s.notifier.Send(notify.Event{
+			Kind:  notify.KindFailure,
+			Title: "模型输出分布突变",
+			Text:  msg,
+		})
+	}
+	return nil
+}
+
+// markBehavior 记录模型是否异常，状态变化时返回 true
+func (s *Service) markBehavior(model string, anomalous bool) bool {
+	s.behaviorState.mu.Lock()
+	defer s.behaviorState.mu.Unlock()
+	if s.behaviorState.alerted[model] == anomalous {
+		return false
+	}
+	if anomalous {
+		s.behaviorState.alerted[model] = true
+	} else {
+		delete(s.behaviorState.alerted, model)
+	}
+	return true
+}
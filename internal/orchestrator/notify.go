@@ -0,0 +1,60 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+
+	"ai_quant/internal/domain"
+	"ai_quant/internal/notify"
+)
+
+// SetNotifier 注入交易通知分发器，为 nil 时不推送
+func (s *Service) SetNotifier(d *notify.Dispatcher) {
+	s.notifier = d
+}
+
+// notifyCycle 按周期结果推送成交 / 风控拒绝 / 失败通知；观望信号被拒绝属于正常情况，不推送
+func (s *Service) notifyCycle(pair string, result domain.CycleResult, err error) {
+	if s.notifier == nil {
+		return
+	}
+	if result.Cycle.Pair != "" {
+		pair = result.Cycle.Pair
+	}
+	switch {
+	case err != nil:
+		s.notifier.Send(notify.Event{
+			Kind:  notify.KindFailure,
+			Title: fmt.Sprintf("%s 周期失败", pair),
+			Text:  err.Error(),
+		})
+	case result.Cycle.Status == domain.CycleStatusFailed:
+		s.notifier.Send(notify.Event{
+			Kind:  notify.KindFailure,
+			Title: fmt.Sprintf("%s 周期失败", pair),
+			Text:  fmt.Sprintf("[%s] %s", result.Cycle.ReasonCode, result.Cycle.ErrorMessage),
+		})
+	case result.Cycle.Status == domain.CycleStatusRejected:
+		if result.Cycle.ReasonCode == domain.ReasonSignalNone {
+			return
+		}
+		s.notifier.Send(notify.Event{
+			Kind:  notify.KindReject,
+			Title: fmt.Sprintf("%s %s 信号被拒绝", pair, result.Signal.Side),
+			Text:  fmt.Sprintf("[%s] %s\n置信度 %.2f", result.Cycle.ReasonCode, result.Cycle.ErrorMessage, result.Signal.Confidence),
+		})
+	case result.Order != nil && isFilled(result.Order.Status):
+		o := result.Order
+		s.notifier.Send(notify.Event{
+			Kind:  notify.KindFill,
+			Title: fmt.Sprintf("%s %s 已成交", pair, o.Side),
+			Text: fmt.Sprintf("成交价 %.6g 数量 %.6g 金额 %.2f USDT\n置信度 %.2f 理由: %s",
+				o.FilledPrice, o.FilledQuantity, o.FilledPrice*o.FilledQuantity, result.Signal.Confidence, result.Signal.Reason),
+		})
+	}
+}
+
+// isFilled 订单状态是否为（部分）成交，含模拟成交
+func isFilled(status string) bool {
+	return strings.Contains(strings.ToLower(status), "filled")
+}
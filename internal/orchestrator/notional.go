@@ -0,0 +1,81 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// NotionalLimit 单笔开仓名义金额（USDT）的上下限，0 表示不限制；独立于交易所的最小下单额
+type NotionalLimit struct {
+	Min float64 `json:"min_usdt"`
+	Max float64 `json:"max_usdt"`
+}
+
+// ParseNotionalLimits 解析 "DOGE/USDT=10-100,BTC/USDT=20-500" 格式的交易对下单金额限制，
+// 只写一边时另一边不限制，如 "SOL/USDT=15-" 或 "XRP/USDT=-80"
+func ParseNotionalLimits(spec string) (map[string]NotionalLimit, error) {
+	limits := make(map[string]NotionalLimit)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pair, rng, ok := strings.Cut(item, "=")
+		pair = strings.ToUpper(strings.TrimSpace(pair))
+		minStr, maxStr, hasDash := strings.Cut(strings.TrimSpace(rng), "-")
+		if !ok || pair == "" || !hasDash {
+			return nil, fmt.Errorf("交易对下单金额配置格式错误: %q（应为 DOGE/USDT=10-100）", item)
+		}
+		var l NotionalLimit
+		var err error
+		if l.Min, err = parseLimit(minStr); err != nil {
+			return nil, fmt.Errorf("交易对下单金额配置 %q: %w", item, err)
+		}
+		if l.Max, err = parseLimit(maxStr); err != nil {
+			return nil, fmt.Errorf("交易对下单金额配置 %q: %w", item, err)
+		}
+		if l.Max > 0 && l.Min > l.Max {
+			return nil, fmt.Errorf("交易对下单金额配置 %q: 下限大于上限", item)
+		}
+		limits[pair] = l
+	}
+	return limits, nil
+}
+
+func parseLimit(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("金额 %q 无效", s)
+	}
+	return v, nil
+}
+
+// SetNotionalLimits 注入按交易对的单笔下单金额上下限
+func (s *Service) SetNotionalLimits(limits map[string]NotionalLimit) {
+	s.notionalLimits = limits
+	if len(limits) > 0 {
+		log.Printf("[风控] 交易对下单金额限制: %v", limits)
+	}
+}
+
+// applyNotionalLimit 按交易对限制调整开仓金额：超过上限时压到上限，低于下限时拒绝（避免产生灰尘持仓）
+func (s *Service) applyNotionalLimit(pair string, stake float64) (float64, error) {
+	l, ok := s.notionalLimits[strings.ToUpper(pair)]
+	if !ok {
+		return stake, nil
+	}
+	if l.Max > 0 && stake > l.Max {
+		log.Printf("[风控] %s 下单金额 %.2f 超过上限 %.2f USDT，按上限下单", pair, stake, l.Max)
+		stake = l.Max
+	}
+	if l.Min > 0 && stake < l.Min {
+		return stake, fmt.Errorf("%s 下单金额 %.2f USDT 低于交易对下限 %.2f USDT", pair, stake, l.Min)
+	}
+	return stake, nil
+}
@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+)
+
+// ErrOpenOrdersUnsupported 执行器不支持查询或撤销挂单
+var ErrOpenOrdersUnsupported = errors.New("当前执行器不支持查询或撤销挂单")
+
+// ListOpenOrders 查询交易所未完成挂单，pair 为空时返回全部；本系统挂出的保护单标注类型
+func (s *Service) ListOpenOrders(ctx context.Context, pair string) ([]domain.OpenOrder, error) {
+	lister, ok := s.executor.(execution.OpenOrderLister)
+	if !ok {
+		return nil, ErrOpenOrdersUnsupported
+	}
+	pair = strings.ToUpper(strings.TrimSpace(pair))
+	orders, err := lister.FetchOpenOrders(ctx, pair)
+	if err != nil {
+		return nil, fmt.Errorf("查询挂单失败: %w", err)
+	}
+	protective, err := s.repo.ListProtectiveOrders(ctx, pair, domain.ProtectiveActive)
+	if err != nil {
+		return nil, err
+	}
+	kinds := make(map[string]string, len(protective))
+	for _, po := range protective {
+		kinds[po.ExchangeOrderID] = po.Kind
+	}
+	for i := range orders {
+		orders[i].ProtectiveKind = kinds[orders[i].ExchangeOrderID]
+	}
+	return orders, nil
+}
+
+// CancelOpenOrder 手动撤销交易所挂单；撤销的是本系统的保护单时同步标记为已撤销
+func (s *Service) CancelOpenOrder(ctx context.Context, pair, exchangeOrderID string) error {
+	pair = strings.ToUpper(strings.TrimSpace(pair))
+	if pair == "" || exchangeOrderID == "" {
+		return fmt.Errorf("缺少交易对或订单 ID")
+	}
+	tracker, ok := execution.ForPair(s.executor, pair).(execution.OrderTracker)
+	if !ok {
+		return ErrOpenOrdersUnsupported
+	}
+	if err := tracker.CancelOrder(ctx, pair, exchangeOrderID); err != nil {
+		return fmt.Errorf("撤单失败: %w", err)
+	}
+
+	active, err := s.repo.ListProtectiveOrders(ctx, pair, domain.ProtectiveActive)
+	if err != nil {
+		return nil
+	}
+	for _, po := range active {
+		if po.ExchangeOrderID == exchangeOrderID {
+			s.setProtectiveStatus(ctx, po, domain.ProtectiveCancelled)
+			log.Printf("[止损] ⚠ %s 保护单 %s 已被手动撤销，该持仓当前无交易所保护", pair, exchangeOrderID)
+			break
+		}
+	}
+	return nil
+}
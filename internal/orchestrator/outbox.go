@@ -0,0 +1,231 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ai_quant/internal/domain"
+	"ai_quant/internal/notify"
+)
+
+// defaultOutboxAlertAfter 重试多少次仍失败时告警
+const defaultOutboxAlertAfter = 10
+
+// defaultOutboxDeadAfter 重试多少次仍失败时移出队列
+const defaultOutboxDeadAfter = 30
+
+// outboxEntry 一条写库失败、等待重试的记录
+type outboxEntry struct {
+	desc     string
+	write    func(ctx context.Context) error
+	attempts int
+	lastErr  string
+	queuedAt time.Time
+	alerted  bool
+}
+
+// writeOutbox 进程内的写库重试队列：周期中已在交易所执行的订单、成交与周期状态写库失败时不丢弃，
+// 由 FlushOutbox 按入队顺序重试。重试次数用尽的记录移入 dead，不再阻塞后续写入。
+// 队列不落盘，进程退出时仍未写入的记录会打印到日志
+type writeOutbox struct {
+	flushMu    sync.Mutex // 同一时间只有一个 FlushOutbox 在重放，避免同一条记录写入两次
+	mu         sync.Mutex
+	entries    []*outboxEntry
+	dead       []*outboxEntry
+	alertAfter int
+	deadAfter  int
+}
+
+// SetOutboxAlertAfter 设置写库重试多少次仍失败时发送告警
+func (s *Service) SetOutboxAlertAfter(n int) {
+	if n <= 0 {
+		n = defaultOutboxAlertAfter
+	}
+	s.outbox.mu.Lock()
+	s.outbox.alertAfter = n
+	s.outbox.mu.Unlock()
+}
+
+// SetOutboxDeadAfter 设置写库重试多少次仍失败时移出队列
+func (s *Service) SetOutboxDeadAfter(n int) {
+	if n <= 0 {
+		n = defaultOutboxDeadAfter
+	}
+	s.outbox.mu.Lock()
+	s.outbox.deadAfter = n
+	s.outbox.mu.Unlock()
+}
+
+// outboxPending 重试队列中的记录数
+func (s *Service) outboxPending() int {
+	s.outbox.mu.Lock()
+	defer s.outbox.mu.Unlock()
+	return len(s.outbox.entries)
+}
+
+// persist 执行一次写库，失败时放入重试队列而不是丢弃；desc 用于日志与告警
+func (s *Service) persist(ctx context.Context, desc string, write func(ctx context.Context) error) {
+	pending := s.outboxPending()
+
+	// 队列非空时直接排队，保证同一订单先插入后更新
+	if pending == 0 {
+		err := write(ctx)
+		if err == nil {
+			return
+		}
+		log.Printf("[写库] ⚠ %s 失败，加入重试队列: %v", desc, err)
+		s.enqueue(desc, write, err)
+		return
+	}
+	log.Printf("[写库] 重试队列中有 %d 条记录，%s 排队写入", pending, desc)
+	s.enqueue(desc, write, nil)
+}
+
+func (s *Service) enqueue(desc string, write func(ctx context.Context) error, err error) {
+	e := &outboxEntry{desc: desc, write: write, queuedAt: time.Now().UTC()}
+	if err != nil {
+		e.attempts = 1
+		e.lastErr = err.Error()
+	}
+	s.outbox.mu.Lock()
+	s.outbox.entries = append(s.outbox.entries, e)
+	s.outbox.mu.Unlock()
+}
+
+// persistOrder 保存订单（已提交到交易所的订单不能因写库失败丢失）
+func (s *Service) persistOrder(ctx context.Context, ord domain.Order) {
+	s.persist(ctx, fmt.Sprintf("保存订单 %s %s %s", ord.Pair, ord.Side, ord.ID), func(ctx context.Context) error {
+		return s.repo.InsertOrder(ctx, ord)
+	})
+}
+
+// persistOrderFill 更新订单成交
+func (s *Service) persistOrderFill(ctx context.Context, id, status string, price, qty float64) {
+	s.persist(ctx, fmt.Sprintf("更新订单成交 %s → %s", id, status), func(ctx context.Context) error {
+		return s.repo.UpdateOrderFill(ctx, id, status, price, qty)
+	})
+}
+
+// persistCycleStatus 更新周期状态
+func (s *Service) persistCycleStatus(ctx context.Context, cycleID string, status domain.CycleStatus, code domain.ReasonCode, msg string) {
+	s.persist(ctx, fmt.Sprintf("更新周期 %s 状态 → %s", cycleID, status), func(ctx context.Context) error {
+		return s.repo.UpdateCycleStatus(ctx, cycleID, status, code, msg)
+	})
+}
+
+// persistHoldingFill 按成交更新持仓。写入时才读取当前持仓并叠加本次成交，
+// 排队重放时基于前面已写入的结果计算，不会用入队时的旧快照覆盖先前成交
+func (s *Service) persistHoldingFill(ctx context.Context, order domain.Order) {
+	s.persist(ctx, fmt.Sprintf("更新持仓 %s %s %s", order.Pair, order.Side, order.ID), func(ctx context.Context) error {
+		return s.applyFillToHolding(ctx, order)
+	})
+}
+
+// FlushOutbox 按入队顺序重试写库，遇到失败即停止（保持先后顺序）；重试次数达到阈值时告警一次，
+// 达到移出阈值时移出队列并继续写入后续记录。返回本次写入成功的条数
+func (s *Service) FlushOutbox(ctx context.Context) int {
+	s.outbox.flushMu.Lock()
+	defer s.outbox.flushMu.Unlock()
+
+	s.outbox.mu.Lock()
+	entries := append([]*outboxEntry(nil), s.outbox.entries...)
+	alertAfter, deadAfter := s.outbox.alertAfter, s.outbox.deadAfter
+	s.outbox.mu.Unlock()
+	if len(entries) == 0 {
+		return 0
+	}
+	if alertAfter <= 0 {
+		alertAfter = defaultOutboxAlertAfter
+	}
+	if deadAfter <= 0 {
+		deadAfter = defaultOutboxDeadAfter
+	}
+
+	done := 0
+	var dead []*outboxEntry
+	for _, e := range entries {
+		err := e.write(ctx)
+		if err == nil {
+			done++
+			continue
+		}
+		s.outbox.mu.Lock()
+		e.attempts++
+		e.lastErr = err.Error()
+		alert := !e.alerted && e.attempts >= alertAfter
+		if alert {
+			e.alerted = true
+		}
+		s.outbox.mu.Unlock()
+
+		log.Printf("[写库] ✘ 重试 %s 失败（第 %d 次）: %v", e.desc, e.attempts, err)
+		if e.attempts >= deadAfter {
+			// 持续失败的记录（如唯一约束冲突）移出队列，避免阻塞其后的订单、周期与持仓写入
+			dead = append(dead, e)
+			log.Printf("[写库] ✘ %s 重试 %d 次仍失败，移出队列，需人工补录", e.desc, e.attempts)
+			s.notifier.Send(notify.Event{
+				Kind:  notify.KindFailure,
+				Title: "写库放弃重试",
+				Text: fmt.Sprintf("%s 已重试 %d 次仍失败（%s 起），已移出重试队列，需人工补录: %v",
+					e.desc, e.attempts, e.queuedAt.Format(time.RFC3339), err),
+			})
+			continue
+		}
+		if alert {
+			s.notifier.Send(notify.Event{
+				Kind:  notify.KindFailure,
+				Title: "写库持续失败",
+				Text: fmt.Sprintf("%s 已重试 %d 次仍失败（%s 起），队列中共 %d 条待写入: %v",
+					e.desc, e.attempts, e.queuedAt.Format(time.RFC3339), len(entries)-done-len(dead), err),
+			})
+		}
+		break
+	}
+
+	if processed := done + len(dead); processed > 0 {
+		s.outbox.mu.Lock()
+		s.outbox.entries = s.outbox.entries[processed:]
+		s.outbox.dead = append(s.outbox.dead, dead...)
+		left := len(s.outbox.entries)
+		s.outbox.mu.Unlock()
+		if done > 0 {
+			log.Printf("[写库] ✔ 重试写入 %d 条，剩余 %d 条", done, left)
+		}
+	}
+	return done
+}
+
+// OutboxPending 尚未写入的记录：先列出已移出队列、需人工补录的记录，再列出重试队列
+func (s *Service) OutboxPending() []domain.OutboxItem {
+	s.outbox.mu.Lock()
+	defer s.outbox.mu.Unlock()
+	out := make([]domain.OutboxItem, 0, len(s.outbox.dead)+len(s.outbox.entries))
+	for _, e := range s.outbox.dead {
+		out = append(out, outboxItem(e, true))
+	}
+	for _, e := range s.outbox.entries {
+		out = append(out, outboxItem(e, false))
+	}
+	return out
+}
+
+func outboxItem(e *outboxEntry, dead bool) domain.OutboxItem {
+	return domain.OutboxItem{
+		Desc:      e.desc,
+		Attempts:  e.attempts,
+		LastError: e.lastErr,
+		QueuedAt:  e.queuedAt,
+		Dead:      dead,
+	}
+}
+
+// LogPendingOutbox 退出前打印仍未写入的记录，便于人工补录
+func (s *Service) LogPendingOutbox() {
+	for _, e := range s.OutboxPending() {
+		log.Printf("[写库] ✘ 退出时仍未写入: %s（入队 %s，重试 %d 次）: %s",
+			e.Desc, e.QueuedAt.Format(time.RFC3339), e.Attempts, e.LastError)
+	}
+}
@@ -0,0 +1,90 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// 同一交易对已有周期在执行时的处理方式
+const (
+	ConcurrencyReject = "reject" // 直接拒绝
+	ConcurrencyQueue  = "queue"  // 排队等待上一个周期结束
+)
+
+var (
+	// ErrCycleInProgress 同一交易对已有周期在执行
+	ErrCycleInProgress = errors.New("该交易对已有周期正在执行")
+	// ErrDuplicateOrder 周期已经下过单（重复触发或重复审批）
+	ErrDuplicateOrder = errors.New("该周期已下单，拒绝重复下单")
+)
+
+// pairLocks 按交易对的执行锁：同一交易对同时只有一个周期 / 后台任务在下单
+type pairLocks struct {
+	mu    sync.Mutex
+	locks map[string]chan struct{}
+}
+
+func (p *pairLocks) slot(pair string) chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.locks == nil {
+		p.locks = make(map[string]chan struct{})
+	}
+	ch, ok := p.locks[pair]
+	if !ok {
+		ch = make(chan struct{}, 1)
+		p.locks[pair] = ch
+	}
+	return ch
+}
+
+// acquire 获取交易对执行锁；wait 为 false 时锁被占用立即返回 ErrCycleInProgress，否则等待直到 ctx 结束
+func (p *pairLocks) acquire(ctx context.Context, pair string, wait bool) (func(), error) {
+	ch := p.slot(pair)
+	release := func() { <-ch }
+	select {
+	case ch <- struct{}{}:
+		return release, nil
+	default:
+	}
+	if !wait {
+		return nil, ErrCycleInProgress
+	}
+	select {
+	case ch <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w（等待超时）", ErrCycleInProgress)
+	}
+}
+
+// SetCycleConcurrency 设置同一交易对并发触发周期时的处理方式：reject（默认）/ queue
+func (s *Service) SetCycleConcurrency(mode string) error {
+	switch mode = strings.ToLower(strings.TrimSpace(mode)); mode {
+	case "", ConcurrencyReject:
+		s.queueCycles = false
+	case ConcurrencyQueue:
+		s.queueCycles = true
+	default:
+		return fmt.Errorf("未知的并发处理方式 %q（可选 reject / queue）", mode)
+	}
+	if s.queueCycles {
+		log.Printf("[周期] 同一交易对并发触发时排队执行")
+	}
+	return nil
+}
+
+// lockPair 周期开始前获取交易对执行锁
+func (s *Service) lockPair(ctx context.Context, pair string) (func(), error) {
+	return s.pairLocks.acquire(ctx, pair, s.queueCycles)
+}
+
+// tryLockPair 后台任务（分批、止盈、移动止损、强平减仓、部分成交重提、模拟保护单）下单前获取执行锁，周期执行中时跳过本轮
+func (s *Service) tryLockPair(pair string) (func(), bool) {
+	release, err := s.pairLocks.acquire(context.Background(), pair, false)
+	return release, err == nil
+}
@@ -0,0 +1,197 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+)
+
+// PaperTrading 模拟盘钱包规则：DRY_RUN 下用持久化的虚拟余额替代交易所余额，模拟成交按费率扣手续费
+type PaperTrading struct {
+	InitialUSDT float64
+	FeeRate     float64 // 按名义价值收取
+}
+
+// ErrPaperDisabled 未启用模拟盘钱包
+var ErrPaperDisabled = errors.New("模拟盘钱包未启用（需要 DRY_RUN=true 且 PAPER_TRADING=true）")
+
+// SetPaperTrading 启用模拟盘钱包，钱包不存在时按初始资金创建
+func (s *Service) SetPaperTrading(ctx context.Context, p PaperTrading) error {
+	if p.InitialUSDT <= 0 {
+		p.InitialUSDT = 1000
+	}
+	if p.FeeRate < 0 {
+		p.FeeRate = 0
+	}
+	s.paper = &p
+	w, err := s.paperWallet(ctx)
+	if err != nil {
+		s.paper = nil
+		return err
+	}
+	log.Printf("[模拟盘] 钱包已启用 可用=%.2f USDT 保证金=%.2f USDT 手续费率=%.3f%%",
+		w.CashUSDT, w.MarginUSDT, p.FeeRate*100)
+	return nil
+}
+
+// paperEnabled 执行器是否使用模拟盘钱包
+func (s *Service) paperEnabled(executor execution.Executor) bool {
+	return s.paper != nil && executor.IsDryRun()
+}
+
+// paperWallet 当前钱包；钱包被清空（如重置数据）后按初始资金重建
+func (s *Service) paperWallet(ctx context.Context) (domain.PaperWallet, error) {
+	w, err := s.repo.GetPaperWallet(ctx)
+	if err != nil {
+		return domain.PaperWallet{}, err
+	}
+	if w != nil {
+		return *w, nil
+	}
+	return s.repo.ResetPaperWallet(ctx, s.paper.InitialUSDT)
+}
+
+// fetchBalances 账户完整余额：模拟盘返回虚拟钱包与本地持仓，其余查询交易所
+func (s *Service) fetchBalances(ctx context.Context, executor execution.Executor) ([]execution.Balance, error) {
+	if !s.paperEnabled(executor) {
+		return executor.FetchFullBalance(ctx)
+	}
+	w, err := s.paperWallet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 占用的保证金计入 USDT 总额（与交易所合约账户口径一致）
+	balances := []execution.Balance{{
+		Symbol: "USDT",
+		Free:   w.CashUSDT,
+		Locked: w.MarginUSDT,
+		Total:  w.CashUSDT + w.MarginUSDT,
+	}}
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range holdings {
+		if h.Quantity <= 0 || execution.ForPair(s.executor, h.Pair).TradingMode() == "futures" {
+			continue
+		}
+		balances = append(balances, execution.Balance{Symbol: h.Symbol, Free: h.Quantity, Total: h.Quantity})
+	}
+	return balances, nil
+}
+
+// applyPaperFill 模拟成交计入钱包：现货买入扣成交额、卖出入账；合约 / 杠杆开仓冻结保证金，
+// 平仓释放保证金并结算盈亏。手续费按成交额收取。existing 为成交前的本地持仓
+func (s *Service) applyPaperFill(ctx context.Context, order domain.Order, existing *domain.Holding) {
+	if s.paper == nil || order.Status != "simulated_filled" {
+		return
+	}
+	if _, err := s.paperWallet(ctx); err != nil {
+		log.Printf("[模拟盘] ⚠ 查询钱包失败: %v", err)
+		return
+	}
+
+	notional := order.FilledQuantity * order.FilledPrice
+	lev := float64(order.Leverage)
+	if lev < 1 {
+		lev = 1
+	}
+	e := domain.PaperLedgerEntry{
+		OrderID:      order.ID,
+		Pair:         order.Pair,
+		Side:         order.Side,
+		Price:        order.FilledPrice,
+		Quantity:     order.FilledQuantity,
+		NotionalUSDT: notional,
+		FeeUSDT:      notional * s.paper.FeeRate,
+		CreatedAt:    time.Now().UTC(),
+	}
+	switch order.Side {
+	case domain.SideLong:
+		margin := notional / lev
+		e.CashDelta = -(margin + e.FeeUSDT)
+		if lev > 1 {
+			e.MarginDelta = margin
+		}
+	case domain.SideClose:
+		e.CashDelta = notional - e.FeeUSDT
+		if existing != nil && existing.Quantity > 0 && existing.AvgPrice > 0 {
+			qty := order.FilledQuantity
+			if qty > existing.Quantity {
+				qty = existing.Quantity
+			}
+			e.RealizedPnL = (order.FilledPrice - existing.AvgPrice) * qty
+			if lev > 1 {
+				release := existing.AvgPrice * qty / lev
+				e.MarginDelta = -release
+				e.CashDelta = release + e.RealizedPnL - e.FeeUSDT
+			}
+		}
+	default:
+		return
+	}
+
+	w, err := s.repo.ApplyPaperEntry(ctx, e)
+	if err != nil {
+		log.Printf("[模拟盘] ⚠ %s 记账失败: %v", order.Pair, err)
+		return
+	}
+	log.Printf("[模拟盘] %s %s 成交额=%.2f 手续费=%.4f 资金变动=%+.4f 可用=%.2f 保证金=%.2f",
+		order.Pair, order.Side, notional, e.FeeUSDT, e.CashDelta, w.CashUSDT, w.MarginUSDT)
+	if w.CashUSDT < 0 {
+		log.Printf("[模拟盘] ⚠ 可用余额为负 %.2f USDT", w.CashUSDT)
+	}
+}
+
+// PaperAccount 模拟盘钱包概况与最近的资金流水
+func (s *Service) PaperAccount(ctx context.Context, limit int) (domain.PaperAccount, error) {
+	if s.paper == nil {
+		return domain.PaperAccount{}, ErrPaperDisabled
+	}
+	w, err := s.paperWallet(ctx)
+	if err != nil {
+		return domain.PaperAccount{}, err
+	}
+	acc := domain.PaperAccount{PaperWallet: w, FeeRate: s.paper.FeeRate}
+	if snap, err := s.accountEquity(ctx); err == nil {
+		acc.EquityUSDT = round2(snap.EquityUSDT)
+		if w.InitialUSDT > 0 {
+			acc.ReturnPct = round2((snap.EquityUSDT - w.InitialUSDT) / w.InitialUSDT * 100)
+		}
+	} else {
+		log.Printf("[模拟盘] ⚠ 计算权益失败: %v", err)
+	}
+	if acc.Ledger, err = s.repo.ListPaperLedger(ctx, limit); err != nil {
+		return acc, err
+	}
+	return acc, nil
+}
+
+// ResetPaperWallet 按初始资金重置模拟盘钱包（initialUSDT <= 0 时沿用配置），有持仓时拒绝，避免余额与持仓脱节
+func (s *Service) ResetPaperWallet(ctx context.Context, initialUSDT float64) (domain.PaperWallet, error) {
+	if s.paper == nil {
+		return domain.PaperWallet{}, ErrPaperDisabled
+	}
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return domain.PaperWallet{}, err
+	}
+	for _, h := range holdings {
+		if h.Quantity > 0 {
+			return domain.PaperWallet{}, fmt.Errorf("%s 仍有持仓，请先平仓或清空数据后再重置钱包", h.Pair)
+		}
+	}
+	if initialUSDT <= 0 {
+		initialUSDT = s.paper.InitialUSDT
+	}
+	w, err := s.repo.ResetPaperWallet(ctx, initialUSDT)
+	if err == nil {
+		log.Printf("[模拟盘] 钱包已重置 初始资金=%.2f USDT", initialUSDT)
+	}
+	return w, err
+}
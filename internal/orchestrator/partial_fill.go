@@ -0,0 +1,140 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+)
+
+// 部分成交剩余量的处理方式
+const (
+	PartialFillCancel   = "cancel"   // 撤销剩余量
+	PartialFillResubmit = "resubmit" // 撤销后按剩余量重新下单
+)
+
+// ResolvePartialFills 处理超过 timeout 仍为部分成交的订单：
+// 先向交易所查询最新成交并把新增成交量计入持仓，再撤销剩余量，按 action 决定是否重新提交。
+func (s *Service) ResolvePartialFills(ctx context.Context, timeout time.Duration, action string) error {
+	s.fillMu.Lock()
+	defer s.fillMu.Unlock()
+
+	orders, err := s.repo.ListPartialOrders(ctx, time.Now().Add(-timeout))
+	if err != nil {
+		return err
+	}
+
+	for _, ord := range orders {
+		tracker, ok := execution.ForPair(s.executor, ord.Pair).(execution.OrderTracker)
+		if !ok {
+			continue
+		}
+		st, err := tracker.QueryOrder(ctx, ord.Pair, ord.ExchangeOrderID)
+		if err != nil {
+			log.Printf("[部分成交] ⚠ 查询订单失败 %s 订单ID=%s: %v", ord.Pair, ord.ExchangeOrderID, err)
+			continue
+		}
+
+		// 查询结果没有成交信息时沿用下单时记录的成交
+		if st.ExecutedQty <= 0 {
+			st.ExecutedQty = ord.FilledQuantity
+			st.AvgPrice = ord.FilledPrice
+		}
+		if st.OrigQty <= 0 {
+			st.OrigQty = ord.RequestedQty
+		}
+		// 先落库最新成交，持仓按订单历史回放（先进先出）时才能看到新增部分
+		s.persistOrderFill(ctx, ord.ID, ord.Status, st.AvgPrice, st.ExecutedQty)
+		s.applyFillDelta(ctx, ord, st)
+
+		if st.Status == "filled" || st.Remaining() <= 0 {
+			s.persistOrderFill(ctx, ord.ID, "filled", st.AvgPrice, st.ExecutedQty)
+			log.Printf("[部分成交] ✔ %s 订单ID=%s 已全部成交 数量=%.8f", ord.Pair, ord.ExchangeOrderID, st.ExecutedQty)
+			continue
+		}
+
+		s.cancelRemainder(ctx, tracker, ord, st, action)
+	}
+	return nil
+}
+
+// cancelRemainder 撤销剩余量，action 为 resubmit 时重新提交。重新提交前获取交易对执行锁，
+// 周期执行中时本轮不撤单也不重新提交，订单保持部分成交，下一轮再处理
+func (s *Service) cancelRemainder(ctx context.Context, tracker execution.OrderTracker, ord domain.Order, st execution.OrderState, action string) {
+	if action == PartialFillResubmit {
+		release, ok := s.tryLockPair(ord.Pair)
+		if !ok {
+			log.Printf("[部分成交] ⏸ %s 有周期正在执行，下一轮再处理剩余量 订单ID=%s", ord.Pair, ord.ExchangeOrderID)
+			return
+		}
+		defer release()
+	}
+
+	if err := tracker.CancelOrder(ctx, ord.Pair, ord.ExchangeOrderID); err != nil {
+		log.Printf("[部分成交] ⚠ 撤销剩余量失败 %s 订单ID=%s: %v", ord.Pair, ord.ExchangeOrderID, err)
+		return
+	}
+	s.persistOrderFill(ctx, ord.ID, "partial_cancelled", st.AvgPrice, st.ExecutedQty)
+	log.Printf("[部分成交] 已撤销剩余量 %s 订单ID=%s 成交=%.8f 剩余=%.8f",
+		ord.Pair, ord.ExchangeOrderID, st.ExecutedQty, st.Remaining())
+
+	if action == PartialFillResubmit {
+		s.resubmitRemainder(ctx, ord, st)
+	}
+}
+
+// applyFillDelta 把下单后新增的成交量按增量均价计入持仓，避免重复计算已入账部分
+func (s *Service) applyFillDelta(ctx context.Context, ord domain.Order, st execution.OrderState) {
+	delta := st.ExecutedQty - ord.FilledQuantity
+	if delta <= 0 || st.AvgPrice <= 0 {
+		return
+	}
+	deltaPrice := (st.AvgPrice*st.ExecutedQty - ord.FilledPrice*ord.FilledQuantity) / delta
+	if deltaPrice <= 0 {
+		deltaPrice = st.AvgPrice
+	}
+	s.UpdateHoldingAfterTrade(ctx, domain.Order{
+		Pair:           ord.Pair,
+		Side:           ord.Side,
+		FilledPrice:    deltaPrice,
+		FilledQuantity: delta,
+	})
+}
+
+// resubmitRemainder 按剩余数量重新下单，新订单通过 parent_order_id 关联原订单；调用方需持有交易对执行锁
+func (s *Service) resubmitRemainder(ctx context.Context, ord domain.Order, st execution.OrderState) {
+	remaining := st.Remaining()
+	input := execution.Input{
+		CycleID:       ord.CycleID,
+		SignalID:      ord.SignalID,
+		Pair:          ord.Pair,
+		Side:          ord.Side,
+		EstimatedFill: st.AvgPrice,
+		Leverage:      ord.Leverage,
+	}
+	if ord.Side == domain.SideLong {
+		// 开仓按金额下单：剩余数量 × 成交均价 折算回保证金
+		input.StakeUSDT = remaining * st.AvgPrice
+		if ord.Leverage > 1 {
+			input.StakeUSDT /= float64(ord.Leverage)
+		}
+	} else {
+		input.SellQuantity = remaining
+		input.StakeUSDT = remaining * st.AvgPrice
+	}
+
+	child, err := execution.ForPair(s.executor, ord.Pair).Execute(ctx, input)
+	child.ParentOrderID = ord.ID
+	if child.ID != "" {
+		s.persistOrder(ctx, child)
+	}
+	if err != nil {
+		log.Printf("[部分成交] ✘ 剩余量重新提交失败 %s: %v", ord.Pair, err)
+		return
+	}
+	s.UpdateHoldingAfterTrade(ctx, child)
+	log.Printf("[部分成交] ✔ 剩余量已重新提交 %s 数量=%.8f 状态=%s 交易所ID=%s",
+		ord.Pair, remaining, child.Status, child.ExchangeOrderID)
+}
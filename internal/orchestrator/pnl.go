@@ -0,0 +1,174 @@
+package orchestrator
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"ai_quant/internal/costbasis"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/tradingday"
+)
+
+// DailyPnL 按交易日汇总最近 days 天的已实现盈亏（按配置的成本核算方法计算），日期升序
+func (s *Service) DailyPnL(ctx context.Context, days int) ([]domain.DailyPnL, error) {
+	if days <= 0 {
+		days = 30
+	}
+	orders, err := s.repo.ListFilledOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := realizedPnLByDay(orders)
+	since := tradingday.Key(time.Now().AddDate(0, 0, -(days - 1)))
+
+	result := make([]domain.DailyPnL, 0, len(buckets))
+	for key, b := range buckets {
+		if key < since {
+			continue
+		}
+		result = append(result, *b)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result, nil
+}
+
+// realizedPnLByDay 按时间顺序回放订单：买入记入成本批次，卖出按配置的成本核算方法结算盈亏，并按交易日归档
+func realizedPnLByDay(orders []domain.Order) map[string]*domain.DailyPnL {
+	book := costbasis.NewBook(costbasis.Current())
+	buckets := make(map[string]*domain.DailyPnL)
+
+	for _, o := range orders {
+		if o.Side != domain.SideLong && o.Side != domain.SideClose {
+			continue
+		}
+		key := tradingday.Key(o.CreatedAt)
+		b, ok := buckets[key]
+		if !ok {
+			b = &domain.DailyPnL{Date: key}
+			buckets[key] = b
+		}
+
+		value := o.FilledQuantity * o.FilledPrice
+		switch o.Side {
+		case domain.SideLong:
+			book.Buy(o.Pair, o.FilledQuantity, o.FilledPrice)
+			b.BuyVolumeUSDT += value
+		case domain.SideClose:
+			realized, _ := book.Sell(o.Pair, o.FilledQuantity, o.FilledPrice)
+			b.RealizedPnLUSDT += realized
+			b.SellVolumeUSDT += value
+		}
+		b.Trades++
+	}
+	return buckets
+}
+
+// lossStreak 按时间顺序回放订单，返回交易对最近连续亏损的平仓笔数与最后一次亏损平仓时间；
+// 没有匹配到成本的平仓（如外部持仓）不计入
+func (s *Service) lossStreak(ctx context.Context, pair string) (int, time.Time, error) {
+	orders, err := s.repo.ListFilledOrders(ctx)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	book := costbasis.NewBook(costbasis.Current())
+	streak := 0
+	var lastLoss time.Time
+	for _, o := range orders {
+		if o.Pair != pair {
+			continue
+		}
+		switch o.Side {
+		case domain.SideLong:
+			book.Buy(o.Pair, o.FilledQuantity, o.FilledPrice)
+		case domain.SideClose:
+			realized, matched := book.Sell(o.Pair, o.FilledQuantity, o.FilledPrice)
+			if matched <= 0 {
+				continue
+			}
+			if realized < 0 {
+				streak++
+				lastLoss = o.CreatedAt
+			} else {
+				streak = 0
+			}
+		}
+	}
+	return streak, lastLoss, nil
+}
+
+// OutcomeHeatmap 按 loc 时区的星期几 × 小时汇总最近 days 天的周期状态与平仓盈亏；
+// 盈亏按成本核算方法回放全部订单，记在平仓所在时段。只返回有数据的格子，按星期、小时升序
+func (s *Service) OutcomeHeatmap(ctx context.Context, days int, loc *time.Location) ([]domain.OutcomeHeatCell, error) {
+	if days <= 0 {
+		days = 30
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	cycles, err := s.repo.ListCycleStatuses(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.repo.ListFilledOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var grid [7][24]*domain.OutcomeHeatCell
+	cell := func(t time.Time) *domain.OutcomeHeatCell {
+		local := t.In(loc)
+		wd, h := int(local.Weekday()), local.Hour()
+		if grid[wd][h] == nil {
+			grid[wd][h] = &domain.OutcomeHeatCell{Weekday: wd, Hour: h}
+		}
+		return grid[wd][h]
+	}
+
+	for _, c := range cycles {
+		b := cell(c.CreatedAt)
+		b.Cycles++
+		switch c.Status {
+		case domain.CycleStatusSuccess:
+			b.Success++
+		case domain.CycleStatusRejected:
+			b.Rejected++
+		case domain.CycleStatusFailed:
+			b.Failed++
+		}
+	}
+
+	book := costbasis.NewBook(costbasis.Current())
+	for _, o := range orders {
+		switch o.Side {
+		case domain.SideLong:
+			book.Buy(o.Pair, o.FilledQuantity, o.FilledPrice)
+		case domain.SideClose:
+			realized, matched := book.Sell(o.Pair, o.FilledQuantity, o.FilledPrice)
+			if matched <= 0 || o.CreatedAt.Before(since) {
+				continue
+			}
+			b := cell(o.CreatedAt)
+			b.Closes++
+			if realized > 0 {
+				b.Wins++
+			}
+			b.RealizedPnLUSDT += realized
+		}
+	}
+
+	result := make([]domain.OutcomeHeatCell, 0)
+	for wd := range grid {
+		for h := range grid[wd] {
+			b := grid[wd][h]
+			if b == nil {
+				continue
+			}
+			if b.Closes > 0 {
+				b.WinRate = float64(b.Wins) / float64(b.Closes) * 100
+				b.AvgPnLUSDT = b.RealizedPnLUSDT / float64(b.Closes)
+			}
+			result = append(result, *b)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,150 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/tradingday"
+)
+
+// BuildPortfolioState 根据订单与持仓计算风控所需的组合状态：
+//   - DailyPnLUSDT: 当前交易日已实现盈亏 + 未实现盈亏相对交易日开始时的变化。
+//     交易日内第一次计算时记录各持仓的未实现盈亏作为基准（服务跨日未运行时基准晚于零点），
+//     之后扣除全部基准：当日平掉的仓位，其已实现盈亏中交易日之前的浮盈浮亏也随基准一起扣除
+//   - OpenExposureUSDT: 当前持仓市值（无法获取价格时按最近市价，再退回成本）
+func (s *Service) BuildPortfolioState(ctx context.Context) (domain.PortfolioState, error) {
+	var state domain.PortfolioState
+
+	orders, err := s.repo.ListFilledOrders(ctx)
+	if err != nil {
+		return state, err
+	}
+	day := tradingday.Key(time.Now())
+	if today, ok := realizedPnLByDay(orders)[day]; ok {
+		state.DailyPnLUSDT = today.RealizedPnLUSDT
+	}
+	dayOpen, dayOpenOK, err := s.repo.DayOpenUnrealized(ctx, day)
+	if err != nil {
+		return state, err
+	}
+
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return state, err
+	}
+	unrealized := make(map[string]float64, len(holdings))
+	for _, h := range holdings {
+		price := h.LastPrice
+		if p, pErr := s.fetchTickerPrice(ctx, h.Pair); pErr == nil && p > 0 {
+			price = p
+			_ = s.updateHoldingPrice(ctx, h.Pair, p)
+		}
+
+		if price <= 0 {
+			// 没有任何价格信息时按成本计入敞口，未实现盈亏视为与基准相同
+			state.OpenExposureUSDT += h.TotalCost
+			if pnl, ok := dayOpen[h.Pair]; ok {
+				unrealized[h.Pair] = pnl
+			}
+			continue
+		}
+		value := h.Quantity * price
+		state.OpenExposureUSDT += value
+
+		// 合约实盘：未实现盈亏以交易所 unRealizedProfit（按标记价格计算）为准
+		if executor := execution.ForPair(s.executor, h.Pair); executor.TradingMode() == "futures" && !executor.IsDryRun() {
+			if d, ok := executor.(execution.PositionDetailer); ok {
+				if detail, dErr := d.FetchPositionDetail(ctx, h.Pair); dErr == nil && detail != nil {
+					unrealized[h.Pair] = detail.UnrealizedPnL
+					continue
+				}
+			}
+		}
+		if h.TotalCost > 0 {
+			unrealized[h.Pair] = value - h.TotalCost
+		} else if pnl, ok := dayOpen[h.Pair]; ok {
+			unrealized[h.Pair] = pnl
+		}
+	}
+
+	if !dayOpenOK {
+		dayOpen = unrealized
+		if !s.readOnly {
+			if err := s.repo.InitDayOpenUnrealized(ctx, day, dayOpen); err != nil {
+				log.Printf("[风控] ⚠ 保存交易日 %s 盈亏基准失败: %v", day, err)
+			}
+		}
+	}
+	for _, pnl := range unrealized {
+		state.DailyPnLUSDT += pnl
+	}
+	for _, pnl := range dayOpen {
+		state.DailyPnLUSDT -= pnl
+	}
+	return state, nil
+}
+
+// portfolioCache 最近一次成功计算的组合状态，数据库 / 行情查询失败时兜底，避免定时任务传入的空状态让风控失效
+type portfolioCache struct {
+	mu    sync.Mutex
+	state domain.PortfolioState
+	day   string // 计算时的交易日，跨日后当日盈亏不再适用
+	ok    bool
+}
+
+// resolvePortfolio 每个周期都重新计算组合状态；调用方显式传入的值只会让风控更严格。
+// 计算失败时使用同一交易日内最近一次成功的结果，都没有时才使用请求传入的值
+func (s *Service) resolvePortfolio(ctx context.Context, cycleID string, given domain.PortfolioState) domain.PortfolioState {
+	today := tradingday.Key(time.Now())
+	state, err := s.BuildPortfolioState(ctx)
+	if err != nil {
+		s.lastPortfolio.mu.Lock()
+		cached, ok := s.lastPortfolio.state, s.lastPortfolio.ok && s.lastPortfolio.day == today
+		s.lastPortfolio.mu.Unlock()
+		if !ok {
+			log.Printf("[周期:%s] ⚠ 计算组合状态失败: %v，使用请求传入的值", cycleID[:8], err)
+			return given
+		}
+		log.Printf("[周期:%s] ⚠ 计算组合状态失败: %v，使用最近一次计算结果", cycleID[:8], err)
+		state = cached
+	} else {
+		s.lastPortfolio.mu.Lock()
+		s.lastPortfolio.state, s.lastPortfolio.day, s.lastPortfolio.ok = state, today, true
+		s.lastPortfolio.mu.Unlock()
+	}
+	if given.DailyPnLUSDT < state.DailyPnLUSDT {
+		state.DailyPnLUSDT = given.DailyPnLUSDT
+	}
+	if given.OpenExposureUSDT > state.OpenExposureUSDT {
+		state.OpenExposureUSDT = given.OpenExposureUSDT
+	}
+	return state
+}
+
+// applyPortfolioLimits 分批建仓的后续批次不经过风控评估，下单前同样检查回撤熔断，并按实际组合状态检查每日亏损与总敞口上限
+func (s *Service) applyPortfolioLimits(ctx context.Context, cycleID, pair string, stake float64) (float64, error) {
+	if h, ok := s.drawdownHalted(); ok {
+		return 0, fmt.Errorf("回撤熔断中: %s", h.Reason)
+	}
+	preset := s.strategyFor(pair).RiskProfile(s.presets.Active())
+	state := s.resolvePortfolio(ctx, cycleID, domain.PortfolioState{})
+	if preset.MaxDailyLossUSDT > 0 && state.DailyPnLUSDT <= -preset.MaxDailyLossUSDT {
+		return 0, fmt.Errorf("当日盈亏 %.2f USDT 已达到亏损上限 -%.2f USDT", state.DailyPnLUSDT, preset.MaxDailyLossUSDT)
+	}
+	if preset.MaxExposureUSDT > 0 {
+		remaining := preset.MaxExposureUSDT - state.OpenExposureUSDT
+		if remaining <= 0 {
+			return 0, fmt.Errorf("持仓敞口 %.2f USDT 已达到上限 %.2f USDT", state.OpenExposureUSDT, preset.MaxExposureUSDT)
+		}
+		if stake > remaining {
+			log.Printf("[分批] %s 金额 %.2f 超过剩余敞口 %.2f USDT，按剩余敞口下单", pair, stake, remaining)
+			stake = remaining
+		}
+	}
+	return stake, nil
+}
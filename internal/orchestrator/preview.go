@@ -0,0 +1,220 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/agent/position"
+	"ai_quant/internal/agent/risk"
+	"ai_quant/internal/agent/signal"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/trace"
+	"ai_quant/internal/tradingday"
+
+	"github.com/google/uuid"
+)
+
+// PreviewCycle 模拟一次交易周期：行情 → 信号 → 风控 → 建仓策略，返回完整计划但不下单、不写库
+func (s *Service) PreviewCycle(ctx context.Context, req RunRequest) (domain.CyclePreview, error) {
+	pair := strings.ToUpper(strings.TrimSpace(req.Pair))
+	if pair == "" {
+		pair = "BTC/USDT"
+	}
+	strat := s.strategyFor(pair)
+	activePreset := strat.RiskProfile(s.presets.Active())
+	executor := execution.ForPair(s.executor, pair)
+
+	preview := domain.CyclePreview{
+		PreviewID: uuid.NewString(),
+		Pair:      pair,
+		Preset:    activePreset.Name,
+		CreatedAt: time.Now().UTC(),
+	}
+	id := preview.PreviewID
+	ctx = trace.WithCycleID(ctx, id)
+	log.Printf("[预览:%s] ▶ 模拟周期 交易对=%s 策略=%s 风险预设=%s", id[:8], pair, strat.Name(), activePreset.Name)
+
+	// ---- 行情 ----
+	snapshot := fallbackSnapshot(pair, req.Snapshot)
+	if snapshot.LastPrice == 0 {
+		if price, change, err := s.fetchQuickTicker(ctx, pair); err == nil {
+			snapshot.LastPrice = price
+			snapshot.Change24h = change
+		} else {
+			preview.Notes = append(preview.Notes, "快速行情获取失败: "+err.Error())
+		}
+	}
+	preview.Snapshot = snapshot
+
+	// ---- 资金费率规则 ----
+	fundingSt, err := s.checkFunding(ctx, pair)
+	if err != nil {
+		preview.Notes = append(preview.Notes, "资金费率检查失败: "+err.Error())
+	} else if msg := s.funding.alert(pair, fundingSt); msg != "" {
+		preview.Alerts = append(preview.Alerts, msg)
+	}
+	if halt, err := s.checkVolatility(ctx, pair); err != nil {
+		preview.Notes = append(preview.Notes, "波动熔断检查失败: "+err.Error())
+	} else if halt != nil {
+		preview.Notes = append(preview.Notes, "波动熔断中，实际周期不会开仓: "+halt.Message)
+	}
+	if st, err := s.checkMargin(ctx, pair); err != nil {
+		preview.Notes = append(preview.Notes, "杠杆风险率检查失败: "+err.Error())
+	} else if st != nil && st.Low {
+		preview.Alerts = append(preview.Alerts, marginAlert(st))
+		preview.Notes = append(preview.Notes, "杠杆风险率过低，实际周期不会开仓")
+	}
+
+	// ---- 信号 ----
+	var sig domain.Signal
+	if s.funding.AutoClose && s.funding.overLimit(fundingSt) {
+		sig = fundingCloseSignal(id, pair, fundingSt, s.funding.MaxCostPct)
+	} else {
+		// 沙盒有独立的虚拟账本，实盘的历史决策对它没有参考意义
+		var history []domain.DecisionMemory
+		if req.sandbox == nil {
+			history = s.decisionMemory(ctx, pair)
+		}
+		sig, err = strat.SignalAgent().Generate(ctx, signal.Input{
+			CycleID:  id,
+			Pair:     pair,
+			Snapshot: snapshot,
+			Model:    req.Model,
+			Provider: req.Provider,
+			Alerts:   preview.Alerts,
+			History:  history,
+
+			TradingMode: executor.TradingMode(),
+			Leverage:    s.leverageFor(ctx, pair, executor.Leverage()),
+		})
+		if err != nil {
+			log.Printf("[预览:%s] ✘ 信号生成失败: %v", id[:8], err)
+			return domain.CyclePreview{}, fmt.Errorf("信号生成失败: %w", err)
+		}
+	}
+	preview.Signal = sig
+	if req.sandbox == nil {
+		if reason, err := s.checkChurn(ctx, pair, sig); err != nil {
+			preview.Notes = append(preview.Notes, "防反复开平检查失败: "+err.Error())
+		} else if reason != "" {
+			preview.Notes = append(preview.Notes, "防反复开平，实际周期会改为观望: "+reason)
+		}
+	}
+
+	// ---- 风控 ----
+	var (
+		portfolio   domain.PortfolioState
+		lastEntryAt time.Time
+		streak      int
+		lastLossAt  time.Time
+		dailyTrades int
+	)
+	if sb := req.sandbox; sb != nil {
+		portfolio, lastEntryAt, streak, lastLossAt = sb.portfolio, sb.lastEntryAt, sb.lossStreak, sb.lastLossAt
+		dailyTrades = sb.dailyTrades
+	} else {
+		portfolio = s.resolvePortfolio(ctx, id, req.Portfolio)
+		lastEntryAt, err = s.repo.LastEntryTime(ctx, pair)
+		if err != nil {
+			preview.Notes = append(preview.Notes, "查询最近开仓时间失败: "+err.Error())
+		}
+		streak, lastLossAt, err = s.lossStreak(ctx, pair)
+		if err != nil {
+			preview.Notes = append(preview.Notes, "统计连续亏损失败: "+err.Error())
+		}
+		dailyTrades, err = s.repo.CountFilledOrdersSince(ctx, pair, tradingday.Start(time.Now()))
+		if err != nil {
+			preview.Notes = append(preview.Notes, "统计当日成交次数失败: "+err.Error())
+		}
+	}
+	riskDecision, err := s.risk.Evaluate(ctx, risk.Input{
+		CycleID:     id,
+		Signal:      sig,
+		Portfolio:   portfolio,
+		Preset:      &activePreset,
+		LastEntryAt: lastEntryAt,
+		LossStreak:  streak,
+		LastLossAt:  lastLossAt,
+		DailyTrades: dailyTrades,
+	})
+	if err != nil {
+		log.Printf("[预览:%s] ✘ 风控评估失败: %v", id[:8], err)
+		return domain.CyclePreview{}, fmt.Errorf("风控评估失败: %w", err)
+	}
+	if req.sandbox == nil {
+		if note := s.applyCapitalAllocation(ctx, pair, sig, portfolio, &riskDecision); note != "" {
+			preview.Notes = append(preview.Notes, note)
+		}
+	}
+	preview.Risk = riskDecision
+	if !riskDecision.Approved {
+		log.Printf("[预览:%s] ■ 风控拒绝: %s", id[:8], riskDecision.RejectReason)
+		return preview, nil
+	}
+
+	// ---- 建仓策略 ----
+	posStrategy, err := generatePosition(ctx, strat.PositionAgent(), position.Input{
+		CycleID:      id,
+		SignalID:     sig.ID,
+		Pair:         pair,
+		Side:         sig.Side,
+		Signal:       sig,
+		MaxStakeUSDT: riskDecision.MaxStakeUSDT,
+		CurrentPrice: snapshot.LastPrice,
+
+		TakeProfitPercent: activePreset.TakeProfitPercent,
+		StopLossPercent:   activePreset.StopLossPercent,
+		ExitLadder:        s.exitLadder,
+	})
+	if err != nil {
+		log.Printf("[预览:%s] ✘ 建仓策略生成失败: %v", id[:8], err)
+		return domain.CyclePreview{}, fmt.Errorf("建仓策略生成失败: %w", err)
+	}
+	preview.Position = &posStrategy
+
+	// ---- 计划订单（不提交） ----
+	planned := domain.PlannedOrder{
+		Side:           sig.Side,
+		TradingMode:    executor.TradingMode(),
+		StakeUSDT:      riskDecision.MaxStakeUSDT,
+		EstimatedPrice: snapshot.LastPrice,
+		DryRun:         executor.IsDryRun(),
+	}
+	if planned.TradingMode == "futures" {
+		planned.Leverage = executor.Leverage()
+		if activePreset.Leverage > 0 {
+			planned.Leverage = activePreset.Leverage
+		}
+		planned.Leverage = s.leverageFor(ctx, pair, planned.Leverage)
+	}
+	if sig.Side == domain.SideLong && len(posStrategy.Batches) > 0 {
+		planned.StakeUSDT = posStrategy.Batches[0].Amount
+		preview.Notes = append(preview.Notes, fmt.Sprintf("分批建仓：本周期执行第1批（共%d批）", len(posStrategy.Batches)))
+	}
+	if sig.Side == domain.SideClose && req.sandbox != nil {
+		planned.SellQuantity = req.sandbox.holdingQty
+	} else if sig.Side == domain.SideClose {
+		holdings, hErr := s.repo.ListHoldings(ctx)
+		if hErr == nil {
+			for _, h := range holdings {
+				if strings.EqualFold(h.Pair, pair) && h.Quantity > 0 {
+					planned.SellQuantity = h.Quantity
+					break
+				}
+			}
+		}
+		if planned.SellQuantity <= 0 {
+			preview.Notes = append(preview.Notes, "本地无持仓记录，实际执行时将以交易所持仓为准")
+		}
+	}
+	if sig.Side != domain.SideNone {
+		preview.Order = &planned
+	}
+
+	log.Printf("[预览:%s] ■ 模拟完成 方向=%s 金额=%.2f", id[:8], sig.Side, planned.StakeUSDT)
+	return preview, nil
+}
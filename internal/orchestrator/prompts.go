@@ -0,0 +1,22 @@
+package orchestrator
+
+import (
+	"fmt"
+
+	"ai_quant/internal/agent/signal"
+	"ai_quant/internal/prompt"
+)
+
+// PromptInfo 当前加载的提示词模板概况
+func (s *Service) PromptInfo() (prompt.Info, error) {
+	info, ok := signal.PromptInfo(s.signal)
+	if !ok {
+		return info, fmt.Errorf("当前未使用大模型，没有提示词模板")
+	}
+	return info, nil
+}
+
+// ReloadPrompts 从模板目录重新加载提示词，之后的周期立即使用新模板
+func (s *Service) ReloadPrompts() (prompt.Info, error) {
+	return signal.ReloadPrompts(s.signal)
+}
@@ -0,0 +1,323 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// syncProtectiveOrders 仓位变化后同步交易所止盈止损保护单：撤掉旧保护单，按最新持仓均价重新挂出。
+// 仅对支持 ProtectiveOrderManager 的执行器生效；平仓后只撤单不再挂出。
+func (s *Service) syncProtectiveOrders(ctx context.Context, cycleID string, ord domain.Order, takeProfitPercent, stopLossPercent float64) (string, error) {
+	executor := execution.ForPair(s.executor, ord.Pair)
+	mgr, ok := executor.(execution.ProtectiveOrderManager)
+	if !ok || ord.FilledQuantity <= 0 {
+		return "", nil
+	}
+
+	cancelled, err := s.cancelProtectiveOrders(ctx, ord.Pair)
+	if err != nil {
+		return "", err
+	}
+
+	if ord.Side != domain.SideLong || (stopLossPercent <= 0 && takeProfitPercent <= 0) {
+		if cancelled > 0 {
+			return fmt.Sprintf("已撤销 %d 条保护单", cancelled), nil
+		}
+		return "", nil
+	}
+
+	// 止盈止损价按加仓后的持仓均价计算，保证整个仓位使用同一组保护单
+	entry, qty := ord.FilledPrice, ord.FilledQuantity
+	if holdings, err := s.repo.ListHoldings(ctx); err == nil {
+		for _, h := range holdings {
+			if h.Pair == ord.Pair && h.AvgPrice > 0 {
+				entry, qty = h.AvgPrice, h.Quantity
+				break
+			}
+		}
+	}
+	// 现货实盘以交易所可用余额为准，旧保护单撤销后冻结的币已释放
+	if executor.TradingMode() != "futures" && !executor.IsDryRun() {
+		coin := strings.Split(ord.Pair, "/")[0]
+		if balances, err := executor.FetchFullBalance(ctx); err == nil {
+			for _, b := range balances {
+				if strings.EqualFold(b.Symbol, coin) && b.Free > 0 {
+					qty = b.Free
+					break
+				}
+			}
+		}
+	}
+
+	req := execution.ProtectionRequest{Pair: ord.Pair, Quantity: qty}
+	if stopLossPercent > 0 {
+		req.StopPrice = entry * (1 - stopLossPercent/100)
+	}
+	if takeProfitPercent > 0 {
+		req.TakeProfitPrice = entry * (1 + takeProfitPercent/100)
+	}
+
+	legs, placeErr := mgr.PlaceProtection(ctx, req)
+	s.saveProtectiveLegs(ctx, cycleID, ord.ID, ord.Pair, req.Quantity, legs)
+	if placeErr != nil {
+		return "", placeErr
+	}
+	return fmt.Sprintf("保护单已挂出 止损=%.6f 止盈=%.6f (均价 %.6f -%.2f%%/+%.2f%%) 共%d条",
+		req.StopPrice, req.TakeProfitPrice, entry, stopLossPercent, takeProfitPercent, len(legs)), nil
+}
+
+// saveProtectiveLegs 保存同一次挂出的保护单（共享 GroupID），并以 parentOrderID 为父订单记录订单组
+func (s *Service) saveProtectiveLegs(ctx context.Context, cycleID, parentOrderID, pair string, qty float64, legs []execution.ProtectiveLeg) {
+	if len(legs) == 0 {
+		return
+	}
+	groupID := uuid.NewString()
+	now := time.Now().UTC()
+	group := domain.OrderGroup{
+		ID:            groupID,
+		CycleID:       cycleID,
+		Pair:          pair,
+		Kind:          domain.OrderGroupTPSL,
+		ParentOrderID: parentOrderID,
+		Status:        domain.OrderGroupActive,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	for _, leg := range legs {
+		po := domain.ProtectiveOrder{
+			ID:              uuid.NewString(),
+			CycleID:         cycleID,
+			Pair:            pair,
+			Kind:            leg.Kind,
+			GroupID:         groupID,
+			ExchangeOrderID: leg.ExchangeOrderID,
+			TriggerPrice:    leg.TriggerPrice,
+			Quantity:        qty,
+			Status:          domain.ProtectiveActive,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		if err := s.repo.InsertProtectiveOrder(ctx, po); err != nil {
+			log.Printf("[止损] 保存保护单记录失败: %v", err)
+		}
+		if leg.ListID != "" {
+			group.Kind = domain.OrderGroupOCO
+			group.ExchangeListID = leg.ListID
+		}
+		group.Legs = append(group.Legs, domain.OrderGroupLeg{
+			Role:            leg.Kind,
+			RefID:           po.ID,
+			ExchangeOrderID: leg.ExchangeOrderID,
+			Price:           leg.TriggerPrice,
+			Quantity:        qty,
+			Status:          domain.ProtectiveActive,
+		})
+	}
+	if err := s.repo.InsertOrderGroup(ctx, group); err != nil {
+		log.Printf("[止损] 保存订单组失败: %v", err)
+	}
+}
+
+// setProtectiveStatus 更新保护单状态，同步订单组中对应腿的状态
+func (s *Service) setProtectiveStatus(ctx context.Context, po domain.ProtectiveOrder, status string) {
+	_ = s.repo.UpdateProtectiveOrderStatus(ctx, po.ID, status)
+	_ = s.repo.UpdateOrderGroupLegStatus(ctx, po.ID, status)
+}
+
+// cancelProtectiveOrders 撤销交易对上所有生效中的保护单，返回撤销条数。
+// 现货平仓前必须先撤单，否则 OCO 冻结的币无法卖出。
+func (s *Service) cancelProtectiveOrders(ctx context.Context, pair string) (int, error) {
+	mgr, ok := execution.ForPair(s.executor, pair).(execution.ProtectiveOrderManager)
+	if !ok {
+		return 0, nil
+	}
+	active, err := s.repo.ListProtectiveOrders(ctx, pair, domain.ProtectiveActive)
+	if err != nil {
+		return 0, err
+	}
+	groups := make(map[string]bool)
+	for _, po := range active {
+		if err := mgr.CancelOrder(ctx, po.Pair, po.ExchangeOrderID); err != nil {
+			return 0, fmt.Errorf("撤销旧保护单失败: %w", err)
+		}
+		s.setProtectiveStatus(ctx, po, domain.ProtectiveCancelled)
+		groups[po.GroupID] = true
+	}
+	for gid := range groups {
+		_ = s.repo.UpdateOrderGroupStatus(ctx, gid, domain.OrderGroupCancelled)
+	}
+	return len(active), nil
+}
+
+// ListProtectiveOrders 查询保护单，pair / status 为空时不过滤
+func (s *Service) ListProtectiveOrders(ctx context.Context, pair, status string) ([]domain.ProtectiveOrder, error) {
+	return s.repo.ListProtectiveOrders(ctx, strings.ToUpper(pair), status)
+}
+
+// ReconcileProtectiveOrders 对账生效中的保护单：
+// 实盘向交易所查询状态，某条腿成交后记录平仓、撤销同组其他腿，被撤销 / 过期的标记为已撤销；
+// 模拟盘按最新价格判断是否触发，触发后模拟平仓。
+func (s *Service) ReconcileProtectiveOrders(ctx context.Context) error {
+	s.fillMu.Lock()
+	defer s.fillMu.Unlock()
+
+	active, err := s.repo.ListProtectiveOrders(ctx, "", domain.ProtectiveActive)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string][]domain.ProtectiveOrder)
+	var order []string
+	for _, po := range active {
+		if _, ok := groups[po.GroupID]; !ok {
+			order = append(order, po.GroupID)
+		}
+		groups[po.GroupID] = append(groups[po.GroupID], po)
+	}
+
+	prices := make(map[string]float64)
+	for _, gid := range order {
+		legs := groups[gid]
+		pair := legs[0].Pair
+		executor := execution.ForPair(s.executor, pair)
+		mgr, ok := executor.(execution.ProtectiveOrderManager)
+		if !ok {
+			continue
+		}
+
+		if executor.IsDryRun() {
+			price, ok := prices[pair]
+			if !ok {
+				price, err = s.fetchTickerPrice(ctx, pair)
+				if err != nil {
+					log.Printf("[止损] ⚠ 获取 %s 价格失败: %v", pair, err)
+					continue
+				}
+				prices[pair] = price
+			}
+			for _, leg := range legs {
+				hit := (leg.Kind == domain.ProtectiveStopLoss && price <= leg.TriggerPrice) ||
+					(leg.Kind == domain.ProtectiveTakeProfit && price >= leg.TriggerPrice)
+				if hit {
+					// 模拟平仓会下单，与周期、分批等后台任务一样先获取交易对执行锁，周期执行中时下一轮再处理
+					release, ok := s.tryLockPair(pair)
+					if !ok {
+						log.Printf("[止损] ⏸ %s 有周期正在执行，下一轮再处理保护单触发", pair)
+						break
+					}
+					s.settleProtectiveTrigger(ctx, mgr, legs, leg, execution.OrderState{}, price)
+					release()
+					break
+				}
+			}
+			continue
+		}
+
+		for _, leg := range legs {
+			st, err := mgr.QueryOrder(ctx, leg.Pair, leg.ExchangeOrderID)
+			if err != nil {
+				log.Printf("[止损] ⚠ 查询保护单失败 %s 订单ID=%s: %v", leg.Pair, leg.ExchangeOrderID, err)
+				continue
+			}
+			if st.Status == "filled" {
+				s.settleProtectiveTrigger(ctx, mgr, legs, leg, st, 0)
+				break
+			}
+			if st.Status == "rejected" {
+				s.setProtectiveStatus(ctx, leg, domain.ProtectiveCancelled)
+				log.Printf("[止损] %s 保护单 %s 已在交易所撤销或过期", leg.Pair, leg.ExchangeOrderID)
+			}
+		}
+	}
+	return nil
+}
+
+// settleProtectiveTrigger 保护单触发：标记触发、撤销同组其他腿，并记录平仓订单更新持仓。
+// 实盘使用交易所成交数据；模拟盘按触发时价格模拟平仓。
+func (s *Service) settleProtectiveTrigger(ctx context.Context, mgr execution.ProtectiveOrderManager, legs []domain.ProtectiveOrder, hit domain.ProtectiveOrder, st execution.OrderState, price float64) {
+	s.setProtectiveStatus(ctx, hit, domain.ProtectiveTriggered)
+	_ = s.repo.UpdateOrderGroupStatus(ctx, hit.GroupID, domain.OrderGroupDone)
+	for _, leg := range legs {
+		if leg.ID == hit.ID {
+			continue
+		}
+		// 现货 OCO 的另一腿交易所已自动撤销，撤单返回 -2011 视为成功
+		if err := mgr.CancelOrder(ctx, leg.Pair, leg.ExchangeOrderID); err != nil {
+			log.Printf("[止损] ⚠ 撤销同组保护单失败 %s 订单ID=%s: %v", leg.Pair, leg.ExchangeOrderID, err)
+			continue
+		}
+		s.setProtectiveStatus(ctx, leg, domain.ProtectiveCancelled)
+	}
+
+	label := "[止损]"
+	if hit.Kind == domain.ProtectiveTakeProfit {
+		label = "[止盈]"
+	}
+
+	var ord domain.Order
+	if price > 0 {
+		qty := hit.Quantity
+		if holdings, err := s.repo.ListHoldings(ctx); err == nil {
+			for _, h := range holdings {
+				if h.Pair == hit.Pair && h.Quantity > 0 {
+					qty = h.Quantity
+					break
+				}
+			}
+		}
+		if qty <= 0 {
+			log.Printf("%s %s 触发但无持仓可平", label, hit.Pair)
+			return
+		}
+		var err error
+		ord, err = execution.ForPair(s.executor, hit.Pair).Execute(ctx, execution.Input{
+			CycleID:       hit.CycleID,
+			Pair:          hit.Pair,
+			Side:          domain.SideClose,
+			StakeUSDT:     qty * price,
+			EstimatedFill: price,
+			SellQuantity:  qty,
+			ForceMarket:   true,
+		})
+		if err != nil {
+			log.Printf("%s ✘ %s 模拟平仓失败: %v", label, hit.Pair, err)
+			return
+		}
+	} else {
+		fill := st.AvgPrice
+		if fill <= 0 {
+			fill = hit.TriggerPrice
+		}
+		ord = domain.Order{
+			ID:              uuid.NewString(),
+			CycleID:         hit.CycleID,
+			ClientOrderID:   fmt.Sprintf("aqpt%s", uuid.NewString()[:8]),
+			Pair:            hit.Pair,
+			Side:            domain.SideClose,
+			StakeUSDT:       st.ExecutedQty * fill,
+			ExpectedPrice:   hit.TriggerPrice,
+			Status:          "filled",
+			ExchangeOrderID: hit.ExchangeOrderID,
+			FilledPrice:     fill,
+			FilledQuantity:  st.ExecutedQty,
+			RequestedQty:    st.OrigQty,
+			CreatedAt:       time.Now().UTC(),
+		}
+	}
+
+	if err := s.repo.InsertOrder(ctx, ord); err != nil {
+		log.Printf("%s ⚠ 保存平仓订单失败: %v", label, err)
+	}
+	s.UpdateHoldingAfterTrade(ctx, ord)
+	s.cancelPairBatches(ctx, hit.Pair, "保护单已触发")
+	log.Printf("%s ✔ %s 保护单触发 触发价=%.6f 成交=%.6f 数量=%.8f 交易所ID=%s",
+		label, hit.Pair, hit.TriggerPrice, ord.FilledPrice, ord.FilledQuantity, hit.ExchangeOrderID)
+}
@@ -0,0 +1,321 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// 周期任务优先级：数值越大越先执行，同优先级按提交顺序
+const (
+	PriorityScheduled = 1 // 定时器触发
+	PriorityManual    = 2 // 接口手动触发（运行周期、手动平仓）
+)
+
+// ErrQueueFull 等待执行的周期数已达上限
+var ErrQueueFull = errors.New("周期队列已满，请稍后再试")
+
+// QueueStats 周期队列状态
+type QueueStats struct {
+	Workers       int            `json:"workers"`
+	MaxDepth      int            `json:"max_depth"`
+	Depth         int            `json:"depth"`               // 等待执行的任务数
+	Running       []string       `json:"running"`             // 正在执行的交易对
+	PendingByPrio map[string]int `json:"pending_by_priority"` // manual / scheduled 等待数
+	OldestWaitSec float64        `json:"oldest_wait_sec"`     // 最早入队任务已等待的秒数
+	Completed     int64          `json:"completed"`           // 启动以来执行完成的任务数
+	Rejected      int64          `json:"rejected"`            // 队列已满或交易对忙被拒绝的任务数
+	Expired       int64          `json:"expired"`             // 开始执行前调用方已超时 / 取消的任务数
+	AvgWaitSec    float64        `json:"avg_wait_sec"`        // 已执行任务的平均排队时间
+	LastStartedAt *time.Time     `json:"last_started_at,omitempty"`
+}
+
+// cycleJob 排队中的周期任务
+type cycleJob struct {
+	ctx      context.Context
+	req      RunRequest
+	pair     string
+	priority int
+	seq      uint64
+	timeout  time.Duration // 开始执行后的超时，0 = 沿用 ctx
+	enqueued time.Time
+	done     chan cycleJobResult
+}
+
+type cycleJobResult struct {
+	result domain.CycleResult
+	err    error
+}
+
+// cycleQueue 周期任务队列：固定数量的 worker 按优先级取任务，同一交易对同时只执行一个
+type cycleQueue struct {
+	service  *Service
+	workers  int
+	maxDepth int
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []*cycleJob
+	running map[string]bool
+	seq     uint64
+	closed  bool
+
+	completed   int64
+	rejected    int64
+	expired     int64
+	totalWait   time.Duration
+	lastStarted *time.Time
+}
+
+// SetCycleQueue 启用周期任务队列：定时器与接口触发的周期都经队列执行，
+// workers 为并发执行的周期数，maxDepth 为等待任务上限
+func (s *Service) SetCycleQueue(workers, maxDepth int) {
+	if workers <= 0 {
+		workers = 1
+	}
+	if maxDepth <= 0 {
+		maxDepth = 100
+	}
+	q := &cycleQueue{
+		service:  s,
+		workers:  workers,
+		maxDepth: maxDepth,
+		running:  make(map[string]bool),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	s.queue = q
+	log.Printf("[队列] 周期队列已启动 worker=%d 上限=%d", workers, maxDepth)
+}
+
+// StopCycleQueue 停止取新任务，等待中的任务返回错误
+func (s *Service) StopCycleQueue() {
+	if s.queue == nil {
+		return
+	}
+	q := s.queue
+	q.mu.Lock()
+	q.closed = true
+	for _, j := range q.pending {
+		j.done <- cycleJobResult{err: errors.New("服务正在关闭")}
+	}
+	q.pending = nil
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// SubmitCycle 把周期放入队列并等待执行结果
+func (s *Service) SubmitCycle(ctx context.Context, req RunRequest, priority int, timeout time.Duration) (domain.CycleResult, error) {
+	wait, err := s.EnqueueCycle(ctx, req, priority, timeout)
+	if err != nil {
+		return domain.CycleResult{}, err
+	}
+	return wait()
+}
+
+// EnqueueCycle 把周期放入队列，返回等待执行结果的函数；timeout > 0 时从开始执行起计时（排队时间不计入），
+// 调用方 ctx 结束时未开始的任务直接移出队列。未启用队列时在 wait 中直接执行
+func (s *Service) EnqueueCycle(ctx context.Context, req RunRequest, priority int, timeout time.Duration) (func() (domain.CycleResult, error), error) {
+	if s.queue == nil {
+		return func() (domain.CycleResult, error) {
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			return s.RunCycle(ctx, req)
+		}, nil
+	}
+	job, err := s.queue.push(ctx, req, priority, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return func() (domain.CycleResult, error) {
+		select {
+		case r := <-job.done:
+			return r.result, r.err
+		case <-ctx.Done():
+			if s.queue.remove(job) {
+				return domain.CycleResult{}, fmt.Errorf("排队等待超时: %w", ctx.Err())
+			}
+			// 已开始执行：等待本次周期结束，避免调用方误以为没有下单
+			r := <-job.done
+			return r.result, r.err
+		}
+	}, nil
+}
+
+// QueueStats 周期队列状态，未启用队列时返回 nil
+func (s *Service) QueueStats() *QueueStats {
+	if s.queue == nil {
+		return nil
+	}
+	return s.queue.stats()
+}
+
+func (q *cycleQueue) push(ctx context.Context, req RunRequest, priority int, timeout time.Duration) (*cycleJob, error) {
+	pair := strings.ToUpper(strings.TrimSpace(req.Pair))
+	if pair == "" {
+		pair = "BTC/USDT"
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, errors.New("服务正在关闭")
+	}
+	if len(q.pending) >= q.maxDepth {
+		q.rejected++
+		return nil, ErrQueueFull
+	}
+	busy := q.running[pair]
+	for _, j := range q.pending {
+		if j.pair != pair {
+			continue
+		}
+		busy = true
+		// 同一交易对已有定时任务在排队，新的定时触发没有意义
+		if priority == PriorityScheduled && j.priority == PriorityScheduled {
+			q.rejected++
+			return nil, fmt.Errorf("%w（已在队列中）", ErrCycleInProgress)
+		}
+	}
+	// reject 模式下交易对已在执行或排队时直接拒绝，与未启用队列时一致
+	if busy && !q.service.queueCycles {
+		q.rejected++
+		return nil, ErrCycleInProgress
+	}
+
+	q.seq++
+	job := &cycleJob{
+		ctx:      ctx,
+		req:      req,
+		pair:     pair,
+		priority: priority,
+		seq:      q.seq,
+		timeout:  timeout,
+		enqueued: time.Now(),
+		done:     make(chan cycleJobResult, 1),
+	}
+	q.pending = append(q.pending, job)
+	sort.SliceStable(q.pending, func(i, j int) bool {
+		if q.pending[i].priority != q.pending[j].priority {
+			return q.pending[i].priority > q.pending[j].priority
+		}
+		return q.pending[i].seq < q.pending[j].seq
+	})
+	q.cond.Signal()
+	return job, nil
+}
+
+// remove 移除尚未开始的任务，已开始时返回 false
+func (q *cycleQueue) remove(job *cycleJob) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, j := range q.pending {
+		if j == job {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			q.expired++
+			return true
+		}
+	}
+	return false
+}
+
+// next 取优先级最高且交易对空闲的任务，没有可执行的任务时等待
+func (q *cycleQueue) next() *cycleJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		if q.closed {
+			return nil
+		}
+		for i, j := range q.pending {
+			if q.running[j.pair] {
+				continue
+			}
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			q.running[j.pair] = true
+			now := time.Now().UTC()
+			q.lastStarted = &now
+			q.totalWait += time.Since(j.enqueued)
+			return j
+		}
+		q.cond.Wait()
+	}
+}
+
+func (q *cycleQueue) work() {
+	for {
+		job := q.next()
+		if job == nil {
+			return
+		}
+		q.run(job)
+
+		q.mu.Lock()
+		delete(q.running, job.pair)
+		q.completed++
+		q.mu.Unlock()
+		// 交易对释放后，排在后面的同交易对任务可以被其它 worker 取走
+		q.cond.Broadcast()
+	}
+}
+
+func (q *cycleQueue) run(job *cycleJob) {
+	if wait := time.Since(job.enqueued); wait > 5*time.Second {
+		log.Printf("[队列] %s 排队 %.1fs 后开始执行", job.pair, wait.Seconds())
+	}
+	ctx := job.ctx
+	if job.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.timeout)
+		defer cancel()
+	}
+	result, err := q.service.RunCycle(ctx, job.req)
+	job.done <- cycleJobResult{result: result, err: err}
+}
+
+func (q *cycleQueue) stats() *QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	st := &QueueStats{
+		Workers:       q.workers,
+		MaxDepth:      q.maxDepth,
+		Depth:         len(q.pending),
+		Running:       make([]string, 0, len(q.running)),
+		PendingByPrio: map[string]int{"manual": 0, "scheduled": 0},
+		Completed:     q.completed,
+		Rejected:      q.rejected,
+		Expired:       q.expired,
+		LastStartedAt: q.lastStarted,
+	}
+	for pair := range q.running {
+		st.Running = append(st.Running, pair)
+	}
+	sort.Strings(st.Running)
+	for _, j := range q.pending {
+		if j.priority >= PriorityManual {
+			st.PendingByPrio["manual"]++
+		} else {
+			st.PendingByPrio["scheduled"]++
+		}
+		if w := time.Since(j.enqueued).Seconds(); w > st.OldestWaitSec {
+			st.OldestWaitSec = w
+		}
+	}
+	st.OldestWaitSec = round2(st.OldestWaitSec)
+	if started := q.completed + int64(len(q.running)); started > 0 {
+		st.AvgWaitSec = round2(q.totalWait.Seconds() / float64(started))
+	}
+	return st
+}
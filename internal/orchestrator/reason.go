@@ -0,0 +1,64 @@
+package orchestrator
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"ai_quant/internal/domain"
+	"ai_quant/internal/tradingday"
+)
+
+// signalFailureCode 信号生成失败的归类：行情缺失中止单独统计，其余视为大模型问题
+func signalFailureCode(err error) domain.ReasonCode {
+	if code := domain.ClassifyReason(err.Error()); code == domain.ReasonMarketData {
+		return code
+	}
+	return domain.ReasonLLMError
+}
+
+// executionFailureCode 下单失败的归类，无法识别时记为交易所错误
+func executionFailureCode(err error) domain.ReasonCode {
+	switch code := domain.ClassifyReason(err.Error()); code {
+	case domain.ReasonInsufficientBalance, domain.ReasonExchangeMinimum, domain.ReasonAPIKeyRejected:
+		return code
+	}
+	return domain.ReasonExchangeError
+}
+
+// ReasonStats 按交易日、状态和归类代码汇总最近 days 天被拒绝 / 失败的周期，日期升序、同日按次数降序
+func (s *Service) ReasonStats(ctx context.Context, days int) ([]domain.ReasonStats, error) {
+	if days <= 0 {
+		days = 7
+	}
+	since := tradingday.Start(time.Now().AddDate(0, 0, -(days - 1)))
+	outcomes, err := s.repo.ListCycleOutcomes(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		date   string
+		status domain.CycleStatus
+		code   domain.ReasonCode
+	}
+	counts := make(map[key]int)
+	for _, o := range outcomes {
+		counts[key{tradingday.Key(o.CreatedAt), o.Status, o.ReasonCode}]++
+	}
+
+	result := make([]domain.ReasonStats, 0, len(counts))
+	for k, n := range counts {
+		result = append(result, domain.ReasonStats{Date: k.date, Status: k.status, Code: k.code, Count: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Date != result[j].Date {
+			return result[i].Date < result[j].Date
+		}
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Code < result[j].Code
+	})
+	return result, nil
+}
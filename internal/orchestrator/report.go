@@ -0,0 +1,255 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"ai_quant/internal/costbasis"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/notify"
+	"ai_quant/internal/tradingday"
+)
+
+const (
+	// reportNotableSignals 报告中列出的信号条数
+	reportNotableSignals = 5
+	// reportReasonRunes 报告中信号理由的最大长度
+	reportReasonRunes = 160
+)
+
+// SetReportFeeRate 设置日报 / 周报估算手续费使用的费率（按成交额）
+func (s *Service) SetReportFeeRate(rate float64) {
+	if rate < 0 {
+		rate = 0
+	}
+	s.reportFeeRate = rate
+}
+
+// reportRange 报告区间：日报为 date 所在交易日，周报为 date 所在的周一至周日；返回起止交易日与 [from, to) 时间范围
+func reportRange(period, date string) (startKey, endKey string, from, to time.Time, err error) {
+	day, err := time.ParseInLocation(tradingday.KeyLayout, date, tradingday.Location())
+	if err != nil {
+		return "", "", time.Time{}, time.Time{}, fmt.Errorf("日期格式错误（应为 %s）: %s", tradingday.KeyLayout, date)
+	}
+	days := 1
+	switch period {
+	case domain.ReportDaily:
+	case domain.ReportWeekly:
+		day = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		days = 7
+	default:
+		return "", "", time.Time{}, time.Time{}, fmt.Errorf("不支持的报告周期: %s（可选 daily / weekly）", period)
+	}
+	end := day.AddDate(0, 0, days)
+	return day.Format(tradingday.KeyLayout), end.AddDate(0, 0, -1).Format(tradingday.KeyLayout), day.UTC(), end.UTC(), nil
+}
+
+// PerformanceReport 查询报告：区间已结束且已生成过时返回保存的报告，否则现场生成（区间已结束时同时保存）
+func (s *Service) PerformanceReport(ctx context.Context, period, date string) (domain.PerformanceReport, error) {
+	startKey, _, _, to, err := reportRange(period, date)
+	if err != nil {
+		return domain.PerformanceReport{}, err
+	}
+	ended := !time.Now().Before(to)
+	if ended {
+		if rep, err := s.repo.GetPerformanceReport(ctx, period, startKey); err != nil {
+			return domain.PerformanceReport{}, err
+		} else if rep != nil {
+			return *rep, nil
+		}
+	}
+	rep, err := s.buildPerformanceReport(ctx, period, date)
+	if err != nil {
+		return rep, err
+	}
+	if ended && !s.readOnly {
+		if err := s.repo.SavePerformanceReport(ctx, rep); err != nil {
+			log.Printf("[报告] ⚠ 保存 %s %s 报告失败: %v", period, rep.StartDate, err)
+		}
+	}
+	return rep, nil
+}
+
+// GenerateReport 生成并保存报告，开启每日汇总通知时推送摘要
+func (s *Service) GenerateReport(ctx context.Context, period, date string) {
+	rep, err := s.buildPerformanceReport(ctx, period, date)
+	if err != nil {
+		log.Printf("[报告] ⚠ 生成 %s %s 报告失败: %v", period, date, err)
+		return
+	}
+	if err := s.repo.SavePerformanceReport(ctx, rep); err != nil {
+		log.Printf("[报告] ⚠ 保存 %s %s 报告失败: %v", period, rep.StartDate, err)
+	}
+	log.Printf("[报告] ✔ %s 已生成: 成交 %d 笔 已实现 %+.2f USDT 胜率 %.1f%%",
+		reportTitle(rep), rep.Trades, rep.RealizedPnLUSDT, rep.WinRatePct)
+
+	if !s.notifier.Enabled(notify.KindDaily) {
+		return
+	}
+	text := FormatReport(rep)
+	if q, ok := s.DisplayQuote(ctx); ok {
+		text += fmt.Sprintf("\n净盈亏 ≈ %+.2f %s", q.Convert(rep.NetPnLUSDT), q.Currency)
+	}
+	s.notifier.Send(notify.Event{Kind: notify.KindDaily, Title: reportTitle(rep), Text: text})
+}
+
+// buildPerformanceReport 按订单、周期、信号与大模型成本汇总报告
+func (s *Service) buildPerformanceReport(ctx context.Context, period, date string) (domain.PerformanceReport, error) {
+	startKey, endKey, from, to, err := reportRange(period, date)
+	if err != nil {
+		return domain.PerformanceReport{}, err
+	}
+	rep := domain.PerformanceReport{
+		Period:      period,
+		StartDate:   startKey,
+		EndDate:     endKey,
+		Pairs:       []domain.PairReport{},
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	orders, err := s.repo.ListFilledOrders(ctx)
+	if err != nil {
+		return rep, err
+	}
+	feeRate := s.reportFeeRate
+	if s.paper != nil {
+		feeRate = s.paper.FeeRate
+	}
+	book := costbasis.NewBook(costbasis.Current())
+	pairs := make(map[string]*domain.PairReport)
+	for _, o := range orders {
+		if o.Side != domain.SideLong && o.Side != domain.SideClose {
+			continue
+		}
+		var realized, matched float64
+		if o.Side == domain.SideLong {
+			book.Buy(o.Pair, o.FilledQuantity, o.FilledPrice)
+		} else {
+			realized, matched = book.Sell(o.Pair, o.FilledQuantity, o.FilledPrice)
+		}
+		if o.CreatedAt.Before(from) || !o.CreatedAt.Before(to) {
+			continue
+		}
+
+		p, ok := pairs[o.Pair]
+		if !ok {
+			p = &domain.PairReport{Pair: o.Pair}
+			pairs[o.Pair] = p
+		}
+		p.Trades++
+		rep.Trades++
+		rep.VolumeUSDT += o.FilledQuantity * o.FilledPrice
+		if o.Side == domain.SideLong {
+			rep.Entries++
+			continue
+		}
+		rep.Closes++
+		if matched <= 0 {
+			continue // 没有匹配到成本的平仓（如外部持仓）不计入胜率
+		}
+		p.RealizedPnLUSDT += realized
+		rep.RealizedPnLUSDT += realized
+		if realized > 0 {
+			rep.Wins++
+		} else {
+			rep.Losses++
+		}
+		if rep.Wins+rep.Losses == 1 {
+			rep.BestTradeUSDT, rep.WorstTradeUSDT = realized, realized
+		} else {
+			rep.BestTradeUSDT = max(rep.BestTradeUSDT, realized)
+			rep.WorstTradeUSDT = min(rep.WorstTradeUSDT, realized)
+		}
+	}
+	if n := rep.Wins + rep.Losses; n > 0 {
+		rep.WinRatePct = round2(float64(rep.Wins) / float64(n) * 100)
+	}
+	rep.FeesUSDT = round2(rep.VolumeUSDT * feeRate)
+	rep.NetPnLUSDT = round2(rep.RealizedPnLUSDT - rep.FeesUSDT)
+	rep.RealizedPnLUSDT = round2(rep.RealizedPnLUSDT)
+	rep.BestTradeUSDT = round2(rep.BestTradeUSDT)
+	rep.WorstTradeUSDT = round2(rep.WorstTradeUSDT)
+	rep.VolumeUSDT = round2(rep.VolumeUSDT)
+	for _, p := range pairs {
+		p.RealizedPnLUSDT = round2(p.RealizedPnLUSDT)
+		rep.Pairs = append(rep.Pairs, *p)
+	}
+	sort.Slice(rep.Pairs, func(i, j int) bool { return rep.Pairs[i].RealizedPnLUSDT > rep.Pairs[j].RealizedPnLUSDT })
+
+	costs, err := s.repo.ListLLMCosts(ctx, from)
+	if err != nil {
+		return rep, err
+	}
+	for _, c := range costs {
+		if c.CreatedAt.Before(to) {
+			rep.LLMCalls++
+			rep.LLMCostUSD += c.CostUSD
+		}
+	}
+	rep.LLMCostUSD = math.Round(rep.LLMCostUSD*10000) / 10000
+
+	if rep.CycleStatus, err = s.repo.CountCyclesByStatus(ctx, from, to); err != nil {
+		return rep, err
+	}
+	for _, n := range rep.CycleStatus {
+		rep.Cycles += n
+	}
+	if rep.NotableSignals, err = s.repo.ListNotableSignals(ctx, from, to, reportNotableSignals); err != nil {
+		return rep, err
+	}
+	for i := range rep.NotableSignals {
+		if r := []rune(rep.NotableSignals[i].Reason); len(r) > reportReasonRunes {
+			rep.NotableSignals[i].Reason = string(r[:reportReasonRunes]) + "…"
+		}
+	}
+	return rep, nil
+}
+
+// reportTitle 报告标题，如 "2024-05-01 日报"、"2024-04-29 ~ 2024-05-05 周报"
+func reportTitle(rep domain.PerformanceReport) string {
+	if rep.Period == domain.ReportWeekly {
+		return fmt.Sprintf("%s ~ %s 周报", rep.StartDate, rep.EndDate)
+	}
+	return rep.StartDate + " 日报"
+}
+
+// FormatReport 报告的纯文本摘要，用于通知推送与下载
+func FormatReport(rep domain.PerformanceReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "已实现盈亏 %+.2f USDT 手续费(估) %.2f USDT 净盈亏 %+.2f USDT\n",
+		rep.RealizedPnLUSDT, rep.FeesUSDT, rep.NetPnLUSDT)
+	fmt.Fprintf(&b, "成交 %d 笔（开仓 %d 平仓 %d）成交额 %.2f USDT\n", rep.Trades, rep.Entries, rep.Closes, rep.VolumeUSDT)
+	if rep.Wins+rep.Losses > 0 {
+		fmt.Fprintf(&b, "胜率 %.1f%%（%d 胜 %d 负）最佳 %+.2f 最差 %+.2f USDT\n",
+			rep.WinRatePct, rep.Wins, rep.Losses, rep.BestTradeUSDT, rep.WorstTradeUSDT)
+	}
+	fmt.Fprintf(&b, "周期 %d 个", rep.Cycles)
+	statuses := make([]string, 0, len(rep.CycleStatus))
+	for st := range rep.CycleStatus {
+		statuses = append(statuses, st)
+	}
+	sort.Strings(statuses)
+	for _, st := range statuses {
+		fmt.Fprintf(&b, " %s=%d", st, rep.CycleStatus[st])
+	}
+	fmt.Fprintf(&b, "\n大模型调用 %d 次 成本 $%.4f\n", rep.LLMCalls, rep.LLMCostUSD)
+	if len(rep.Pairs) > 0 {
+		b.WriteString("\n交易对:\n")
+		for _, p := range rep.Pairs {
+			fmt.Fprintf(&b, "  %s 成交 %d 笔 已实现 %+.2f USDT\n", p.Pair, p.Trades, p.RealizedPnLUSDT)
+		}
+	}
+	if len(rep.NotableSignals) > 0 {
+		b.WriteString("\n值得关注的信号:\n")
+		for _, n := range rep.NotableSignals {
+			fmt.Fprintf(&b, "  %s %s %s 置信度 %.2f [%s] %s\n", n.CreatedAt.In(tradingday.Location()).Format("01-02 15:04"),
+				n.Pair, n.Side, n.Confidence, n.CycleStatus, n.Reason)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,332 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai_quant/internal/costbasis"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/tradingday"
+
+	"github.com/google/uuid"
+)
+
+// sandboxFeeRate 沙盒模拟成交的手续费率（按名义价值）
+const sandboxFeeRate = 0.001
+
+var (
+	// ErrSandboxNotFound 沙盒会话不存在
+	ErrSandboxNotFound = errors.New("沙盒不存在")
+	// ErrSandboxExists 沙盒名称已被使用
+	ErrSandboxExists = errors.New("沙盒名称已存在")
+)
+
+// sandboxRisk 沙盒预览时传给风控的状态，全部来自沙盒自己的成交
+type sandboxRisk struct {
+	portfolio   domain.PortfolioState
+	lastEntryAt time.Time
+	lossStreak  int
+	lastLossAt  time.Time
+	dailyTrades int
+	holdingQty  float64
+}
+
+// sandboxLedger 按成交回放出的沙盒钱包与持仓
+type sandboxLedger struct {
+	cash, realized, fees float64
+	todayPnL             float64
+	book                 *costbasis.Book
+	margin               map[string]float64
+	lastEntry            map[string]time.Time
+	streak               map[string]int
+	lastLoss             map[string]time.Time
+	todayTrades          map[string]int
+}
+
+// CreateSandboxRequest 创建沙盒会话的参数
+type CreateSandboxRequest struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	InitialUSDT float64 `json:"initial_usdt"`
+	Leverage    int     `json:"leverage"`
+	Model       string  `json:"model"`
+	Pairs       string  `json:"pairs"`
+}
+
+// CreateSandbox 创建沙盒会话：独立的虚拟钱包，初始余额默认 1000 USDT
+func (s *Service) CreateSandbox(ctx context.Context, req CreateSandboxRequest) (domain.Sandbox, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return domain.Sandbox{}, fmt.Errorf("沙盒名称不能为空")
+	}
+	if req.InitialUSDT < 0 || req.Leverage < 0 {
+		return domain.Sandbox{}, fmt.Errorf("初始余额与杠杆不能为负数")
+	}
+	if req.InitialUSDT == 0 {
+		req.InitialUSDT = 1000
+	}
+	existing, err := s.repo.GetSandbox(ctx, name)
+	if err != nil {
+		return domain.Sandbox{}, err
+	}
+	if existing != nil {
+		return domain.Sandbox{}, ErrSandboxExists
+	}
+
+	sb := domain.Sandbox{
+		ID:          uuid.NewString(),
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+		InitialUSDT: req.InitialUSDT,
+		Leverage:    req.Leverage,
+		Model:       strings.TrimSpace(req.Model),
+		Pairs:       strings.ToUpper(strings.ReplaceAll(req.Pairs, " ", "")),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := s.repo.CreateSandbox(ctx, sb); err != nil {
+		return domain.Sandbox{}, err
+	}
+	log.Printf("[沙盒] ✔ 创建 %s 初始余额=%.2f 杠杆=%d 模型=%q 交易对=%q", sb.Name, sb.InitialUSDT, sb.Leverage, sb.Model, sb.Pairs)
+	return sb, nil
+}
+
+// ListSandboxes 全部沙盒会话
+func (s *Service) ListSandboxes(ctx context.Context) ([]domain.Sandbox, error) {
+	return s.repo.ListSandboxes(ctx)
+}
+
+// DeleteSandbox 删除沙盒会话及其模拟成交
+func (s *Service) DeleteSandbox(ctx context.Context, id string) error {
+	sb, err := s.getSandbox(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteSandbox(ctx, sb.ID); err != nil {
+		return err
+	}
+	log.Printf("[沙盒] 已删除 %s", sb.Name)
+	return nil
+}
+
+// ListSandboxTrades 分页查询沙盒模拟成交
+func (s *Service) ListSandboxTrades(ctx context.Context, id string, q domain.ListQuery) ([]domain.SandboxTrade, int, error) {
+	sb, err := s.getSandbox(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListSandboxTradesPage(ctx, sb.ID, q)
+}
+
+// SandboxSummary 沙盒钱包、持仓与收益（持仓按当前市价估值）
+func (s *Service) SandboxSummary(ctx context.Context, id string) (domain.SandboxSummary, error) {
+	sb, err := s.getSandbox(ctx, id)
+	if err != nil {
+		return domain.SandboxSummary{}, err
+	}
+	trades, err := s.repo.ListSandboxTrades(ctx, sb.ID)
+	if err != nil {
+		return domain.SandboxSummary{}, err
+	}
+	l := replaySandbox(*sb, trades)
+
+	sum := domain.SandboxSummary{
+		Sandbox:     *sb,
+		CashUSDT:    l.cash,
+		RealizedPnL: l.realized,
+		FeesUSDT:    l.fees,
+		Trades:      len(trades),
+		Positions:   []domain.SandboxPosition{},
+	}
+	sum.EquityUSDT = l.cash
+	for _, pair := range l.book.Pairs() {
+		qty, cost := l.book.Position(pair)
+		if qty <= 0 {
+			continue
+		}
+		p := domain.SandboxPosition{Pair: pair, Quantity: qty, AvgPrice: cost / qty, MarginUSDT: l.margin[pair]}
+		if price, pErr := s.fetchTickerPrice(ctx, pair); pErr == nil && price > 0 {
+			p.CurrentPrice = price
+			p.UnrealizedPnL = qty * (price - p.AvgPrice)
+		}
+		sum.UnrealizedPnL += p.UnrealizedPnL
+		sum.EquityUSDT += p.MarginUSDT + p.UnrealizedPnL
+		sum.Positions = append(sum.Positions, p)
+	}
+	if sb.InitialUSDT > 0 {
+		sum.ReturnPct = (sum.EquityUSDT - sb.InitialUSDT) / sb.InitialUSDT * 100
+	}
+	return sum, nil
+}
+
+// RunSandboxCycle 在沙盒中跑一次周期：预览（信号 → 风控 → 建仓策略，风控使用沙盒自己的组合状态），
+// 再按预览价格在沙盒钱包中模拟成交；不下单，也不写入周期、订单与持仓表
+func (s *Service) RunSandboxCycle(ctx context.Context, id, pair string) (domain.SandboxRun, error) {
+	sb, err := s.getSandbox(ctx, id)
+	if err != nil {
+		return domain.SandboxRun{}, err
+	}
+	pair = strings.ToUpper(strings.TrimSpace(pair))
+	if pair == "" {
+		return domain.SandboxRun{}, fmt.Errorf("交易对不能为空")
+	}
+	trades, err := s.repo.ListSandboxTrades(ctx, sb.ID)
+	if err != nil {
+		return domain.SandboxRun{}, err
+	}
+	l := replaySandbox(*sb, trades)
+	qty, _ := l.book.Position(pair)
+
+	exposure := 0.0
+	for _, m := range l.margin {
+		exposure += m
+	}
+	preview, err := s.PreviewCycle(ctx, RunRequest{
+		Pair:  pair,
+		Model: sb.Model,
+		sandbox: &sandboxRisk{
+			portfolio:   domain.PortfolioState{DailyPnLUSDT: l.todayPnL, OpenExposureUSDT: exposure},
+			lastEntryAt: l.lastEntry[pair],
+			lossStreak:  l.streak[pair],
+			lastLossAt:  l.lastLoss[pair],
+			dailyTrades: l.todayTrades[pair],
+			holdingQty:  qty,
+		},
+	})
+	if err != nil {
+		return domain.SandboxRun{}, err
+	}
+	run := domain.SandboxRun{Preview: preview}
+	trade, note := l.fill(*sb, preview, qty)
+	run.Note = note
+	if trade == nil {
+		log.Printf("[沙盒] %s %s 信号=%s 未成交: %s", sb.Name, pair, preview.Signal.Side, note)
+		return run, nil
+	}
+	if err := s.repo.InsertSandboxTrade(ctx, *trade); err != nil {
+		return run, err
+	}
+	run.Trade = trade
+	log.Printf("[沙盒] %s %s %s 价格=%.6f 数量=%.6f 保证金=%.2f 已实现=%.2f", sb.Name, pair, trade.Side,
+		trade.Price, trade.Quantity, trade.StakeUSDT, trade.RealizedPnL)
+	return run, nil
+}
+
+// getSandbox 按 ID 或名称查找沙盒
+func (s *Service) getSandbox(ctx context.Context, id string) (*domain.Sandbox, error) {
+	sb, err := s.repo.GetSandbox(ctx, strings.TrimSpace(id))
+	if err != nil {
+		return nil, err
+	}
+	if sb == nil {
+		return nil, ErrSandboxNotFound
+	}
+	return sb, nil
+}
+
+// replaySandbox 从初始余额开始按时间顺序回放沙盒成交
+func replaySandbox(sb domain.Sandbox, trades []domain.SandboxTrade) *sandboxLedger {
+	l := &sandboxLedger{
+		cash:        sb.InitialUSDT,
+		book:        costbasis.NewBook(costbasis.Current()),
+		margin:      make(map[string]float64),
+		lastEntry:   make(map[string]time.Time),
+		streak:      make(map[string]int),
+		lastLoss:    make(map[string]time.Time),
+		todayTrades: make(map[string]int),
+	}
+	today := tradingday.Key(time.Now())
+	for _, t := range trades {
+		l.fees += t.FeeUSDT
+		if tradingday.Key(t.CreatedAt) == today {
+			l.todayTrades[t.Pair]++
+		}
+		switch t.Side {
+		case domain.SideLong:
+			l.cash -= t.StakeUSDT + t.FeeUSDT
+			l.book.Buy(t.Pair, t.Quantity, t.Price)
+			l.margin[t.Pair] += t.StakeUSDT
+			l.lastEntry[t.Pair] = t.CreatedAt
+		case domain.SideClose:
+			before, _ := l.book.Position(t.Pair)
+			realized, matched := l.book.Sell(t.Pair, t.Quantity, t.Price)
+			released := 0.0
+			if before > 0 {
+				released = l.margin[t.Pair] * matched / before
+			}
+			l.margin[t.Pair] -= released
+			l.cash += released + realized - t.FeeUSDT
+			l.realized += realized
+			if tradingday.Key(t.CreatedAt) == today {
+				l.todayPnL += realized - t.FeeUSDT
+			}
+			if realized < 0 {
+				l.streak[t.Pair]++
+				l.lastLoss[t.Pair] = t.CreatedAt
+			} else {
+				l.streak[t.Pair] = 0
+			}
+		}
+	}
+	return l
+}
+
+// fill 按预览结果在沙盒钱包中模拟成交；不成交时返回原因
+func (l *sandboxLedger) fill(sb domain.Sandbox, p domain.CyclePreview, held float64) (*domain.SandboxTrade, string) {
+	if !p.Risk.Approved {
+		return nil, "风控拒绝: " + p.Risk.RejectReason
+	}
+	if p.Order == nil {
+		return nil, "信号观望"
+	}
+	price := p.Order.EstimatedPrice
+	if price <= 0 {
+		return nil, "没有可用的成交价格"
+	}
+	t := &domain.SandboxTrade{
+		ID:         uuid.NewString(),
+		SandboxID:  sb.ID,
+		PreviewID:  p.PreviewID,
+		Pair:       p.Pair,
+		Side:       p.Signal.Side,
+		Price:      price,
+		Confidence: p.Signal.Confidence,
+		Reason:     p.Signal.Reason,
+		ModelName:  p.Signal.ModelName,
+		CreatedAt:  time.Now().UTC(),
+	}
+	lev := float64(sb.Leverage)
+	if lev < 1 {
+		lev = 1
+	}
+
+	switch p.Signal.Side {
+	case domain.SideLong:
+		stake := p.Order.StakeUSDT
+		if stake > l.cash/(1+sandboxFeeRate*lev) {
+			stake = l.cash / (1 + sandboxFeeRate*lev)
+		}
+		if stake <= 0 {
+			return nil, fmt.Sprintf("沙盒余额不足（%.2f USDT）", l.cash)
+		}
+		t.StakeUSDT = stake
+		t.Quantity = stake * lev / price
+		t.FeeUSDT = stake * lev * sandboxFeeRate
+	case domain.SideClose:
+		if held <= 0 {
+			return nil, "沙盒无持仓，无需平仓"
+		}
+		fraction := domain.NormalizeCloseFraction(p.Signal.CloseFraction)
+		t.Quantity = held * fraction
+		_, cost := l.book.Position(p.Pair)
+		t.StakeUSDT = l.margin[p.Pair] * fraction
+		t.RealizedPnL = t.Quantity * (price - cost/held)
+		t.FeeUSDT = t.Quantity * price * sandboxFeeRate
+	default:
+		return nil, fmt.Sprintf("沙盒只模拟开多与平仓，忽略 %s 信号", p.Signal.Side)
+	}
+	return t, ""
+}
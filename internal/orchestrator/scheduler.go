@@ -0,0 +1,37 @@
+package orchestrator
+
+import (
+	"context"
+
+	"ai_quant/internal/domain"
+)
+
+// SchedulerControl 运行时控制定时自动交易（由 scheduler.Scheduler 实现，HTTP 接口通过 Service 调用）
+type SchedulerControl interface {
+	State() domain.SchedulerState
+	Pause(ctx context.Context) error
+	Resume(ctx context.Context) error
+	SetInterval(ctx context.Context, sec int) error
+	AddPair(ctx context.Context, pair string) error
+	RemovePair(ctx context.Context, pair string) error
+}
+
+// SetScheduler 注入定时调度器，供运行时暂停 / 恢复和调整交易对
+func (s *Service) SetScheduler(sc SchedulerControl) {
+	s.scheduler = sc
+}
+
+// Scheduler 返回定时调度器，未注入时返回 nil
+func (s *Service) Scheduler() SchedulerControl {
+	return s.scheduler
+}
+
+// LoadSchedulerState 读取保存的定时器配置，从未通过接口修改过时返回 nil
+func (s *Service) LoadSchedulerState(ctx context.Context) (*domain.SchedulerState, error) {
+	return s.repo.GetSchedulerState(ctx)
+}
+
+// SaveSchedulerState 保存定时器配置，重启后沿用
+func (s *Service) SaveSchedulerState(ctx context.Context, st domain.SchedulerState) error {
+	return s.repo.SaveSchedulerState(ctx, st)
+}
@@ -0,0 +1,23 @@
+package orchestrator
+
+import (
+	"context"
+
+	"ai_quant/internal/domain"
+)
+
+// ScreenerControl 交易对筛选（由 scheduler.Screener 实现，HTTP 接口通过 Service 调用）
+type ScreenerControl interface {
+	Latest() domain.ScreenerResult
+	Scan(ctx context.Context) domain.ScreenerResult
+}
+
+// SetScreener 注入交易对筛选任务
+func (s *Service) SetScreener(sc ScreenerControl) {
+	s.screener = sc
+}
+
+// Screener 返回交易对筛选任务，未启用时返回 nil
+func (s *Service) Screener() ScreenerControl {
+	return s.screener
+}
@@ -2,21 +2,29 @@ package orchestrator
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
-	"strconv"
+	"math"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"ai_quant/internal/agent/execution"
 	"ai_quant/internal/agent/position"
 	"ai_quant/internal/agent/risk"
 	"ai_quant/internal/agent/signal"
+	"ai_quant/internal/costbasis"
 	"ai_quant/internal/domain"
+	"ai_quant/internal/fx"
 	"ai_quant/internal/market"
+	"ai_quant/internal/notify"
+	"ai_quant/internal/preset"
 	"ai_quant/internal/store"
+	"ai_quant/internal/strategy"
+	"ai_quant/internal/trace"
+	"ai_quant/internal/tradingday"
 
 	"github.com/google/uuid"
 )
@@ -27,21 +35,102 @@ type Service struct {
 	risk     risk.Agent
 	position position.Agent
 	executor execution.Executor
+	presets  *preset.Manager
+	funding  FundingGuard
+	keys     *execution.KeyValidator
+
+	earnAutoRedeem bool                     // USDT 不足时自动从活期理财赎回
+	notionalLimits map[string]NotionalLimit // 按交易对的单笔开仓金额上下限
+	breaker        VolatilityBreaker        // 波动熔断规则
+	volatility     volatilityState
+	drawdownGuard  DrawdownGuard // 回撤熔断规则
+	drawdown       drawdownState
+	scheduler      SchedulerControl // 定时自动交易，未启用时为 nil
+	screener       ScreenerControl  // 交易对筛选，未启用时为 nil
+	portfolioMode  bool             // 组合分配模式：定时器先生成分配计划再逐个执行
+	notifier       *notify.Dispatcher
+	fx             *fx.Converter  // 报表展示币种汇率，未配置时为 nil
+	lastPortfolio  portfolioCache // 最近一次成功计算的组合状态
+
+	approvalMode    string        // 人工审批模式：off / live / all
+	approvalTimeout time.Duration // 审批超时自动取消
+
+	strategies *strategy.Set // 按交易对分配的策略，为空时使用上面注入的组件
+
+	memoryCycles int // 写入提示词的历史决策条数，0 = 不启用
+
+	marginMinLevel float64 // 现货杠杆风险率预警线，0 = 不监控
+	margin         marginState
+
+	churn ChurnGuard // 防反复开平规则
+
+	exitLadder []domain.ExitBatch // 分批止盈阶梯，空 = 不启用
+
+	trailing TrailingStop // 移动止损规则
+	atr      ATRSource
+
+	pairLocks   pairLocks     // 按交易对的执行锁，防止并发周期重复下单
+	queueCycles bool          // 交易对执行中时新周期排队等待，false = 直接拒绝
+	queue       *cycleQueue   // 周期任务队列，nil = 调用方直接执行
+	twap        TWAP          // 大额订单拆分执行规则
+	paper       *PaperTrading // 模拟盘钱包，nil = 未启用
+
+	execPolicy ExecutionPolicy // 按名义金额的执行方式档位，空 = 沿用 ORDER_TYPE 与 TWAP 阈值
+
+	liquidation LiquidationGuard // 合约强平预警规则
+	liqState    liquidationState
+
+	behavior      BehaviorMonitor // 模型输出分布监控规则
+	behaviorState behaviorState
+
+	capital      CapitalAllocator // 多交易对资金分配规则
+	capitalATR   ATRSource
+	capitalState capitalState
+
+	reportFeeRate float64 // 日报 / 周报估算手续费的费率（模拟盘使用模拟盘费率）
+
+	indicators IndicatorSource // 指标接口的数据源，nil = 未启用
+
+	outbox writeOutbox // 写库失败的重试队列
+	fillMu sync.Mutex  // 串行化成交推送、保护单对账与部分成交处理，避免同一成交重复入账
+
+	readOnly bool // 只读实例：查询接口不回写数据库
 }
 
 type RunRequest struct {
 	Pair      string
 	Snapshot  *domain.MarketSnapshot
 	Portfolio domain.PortfolioState
+
+	// 可选：覆盖本次周期使用的模型 / OpenRouter 提供商偏好
+	Model    string
+	Provider *signal.ProviderPreferences
+
+	// 可选：手动平仓，跳过大模型直接生成 close 信号；CloseFraction 在 (0,1) 时按比例减仓
+	ManualClose   bool
+	CloseFraction float64
+
+	// 可选：人工下单，跳过大模型按指定方向与金额 / 数量下单
+	Manual *ManualTrade
+
+	// 可选：触发周期的调用方（scheduler / ui / key:<名称>），人工审批时禁止同一 API Key 自己审批
+	RequestedBy string
+
+	// 可选：组合分配模式下该交易对在本轮分配计划中的建议，写入提示词并限制开仓金额
+	Allocation *domain.PairAllocation
+
+	// 沙盒会话的预览：风控使用沙盒自己的组合状态与开仓 / 亏损记录，不读取实盘数据
+	sandbox *sandboxRisk
 }
 
-func New(repo store.Repository, signalAgent signal.Agent, riskAgent risk.Agent, positionAgent position.Agent, executor execution.Executor) *Service {
+func New(repo store.Repository, signalAgent signal.Agent, riskAgent risk.Agent, positionAgent position.Agent, executor execution.Executor, presets *preset.Manager) *Service {
 	svc := &Service{
 		repo:     repo,
 		signal:   signalAgent,
 		risk:     riskAgent,
 		position: positionAgent,
 		executor: executor,
+		presets:  presets,
 	}
 
 	// 注入真实账户数据回调到 signal agent
@@ -49,28 +138,84 @@ func New(repo store.Repository, signalAgent signal.Agent, riskAgent risk.Agent,
 		return svc.fetchAccountDataForPrompt(ctx, pair)
 	})
 
+	// 注入基于权益快照的收益率与夏普比率到 signal agent
+	signal.SetPerformanceFunc(signalAgent, svc.promptPerformance)
+
 	// 注入交易模式信息到 signal agent
 	signal.SetTradingMode(signalAgent, executor.TradingMode(), executor.Leverage())
 
+	// 注入已花费成本查询到 signal agent（用于每日预算上限）
+	signal.SetSpendFunc(signalAgent, repo.SumLLMCostSince)
+
 	return svc
 }
 
+// SetStrategies 注入按交易对分配的策略集
+func (s *Service) SetStrategies(set *strategy.Set) {
+	s.strategies = set
+}
+
+// strategyFor 返回交易对使用的策略
+func (s *Service) strategyFor(pair string) strategy.Strategy {
+	if s.strategies == nil {
+		return strategy.Compose(strategy.Default, s.signal, s.position, nil)
+	}
+	return s.strategies.For(pair)
+}
+
+// RunCycle 执行一个交易周期，并按结果推送成交 / 风控拒绝 / 失败通知
 func (s *Service) RunCycle(ctx context.Context, req RunRequest) (domain.CycleResult, error) {
+	pair := strings.ToUpper(strings.TrimSpace(req.Pair))
+	if pair == "" {
+		pair = "BTC/USDT"
+	}
+	// 同一交易对同时只执行一个周期（定时器与手动触发撞在一起时不会重复下单）
+	release, err := s.lockPair(ctx, pair)
+	if err != nil {
+		log.Printf("[周期] ⏭ %s %v，本次触发已忽略", pair, err)
+		return domain.CycleResult{}, err
+	}
+	defer release()
+
+	result, err := s.runCycle(ctx, req)
+	s.notifyCycle(pair, result, err)
+	return result, err
+}
+
+func (s *Service) runCycle(ctx context.Context, req RunRequest) (domain.CycleResult, error) {
 	cycleStart := time.Now()
 	pair := strings.ToUpper(strings.TrimSpace(req.Pair))
 	if pair == "" {
 		pair = "BTC/USDT"
 	}
 
+	// 周期开始时固定策略与风险预设，整个周期内各环节使用同一套参数
+	strat := s.strategyFor(pair)
+	activePreset := strat.RiskProfile(s.presets.Active())
+
+	// 按交易对选择现货 / 合约执行器
+	executor := execution.ForPair(s.executor, pair)
+
+	// 人工下单未要求风控时，跳过风控评估与熔断 / 防反复开平等拦截
+	manualBypass := req.Manual != nil && !req.Manual.EnforceRisk
+
 	now := time.Now().UTC()
 	cycle := domain.Cycle{
 		ID:        uuid.NewString(),
 		Pair:      pair,
 		Status:    domain.CycleStatusRunning,
+		Preset:    activePreset.Name,
+		Strategy:  strat.Name(),
+		Type:      domain.CycleTypeAuto,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
-	log.Printf("[周期:%s] ▶ 开始执行 交易对=%s", cycle.ID[:8], pair)
+	if req.ManualClose || req.Manual != nil {
+		cycle.Type = domain.CycleTypeManual
+	}
+	// 周期 ID 写入 context，后续行情、大模型、下单请求都能追溯到本周期
+	ctx = trace.WithCycleID(ctx, cycle.ID)
+	log.Printf("[周期:%s] ▶ 开始执行 交易对=%s 策略=%s 风险预设=%s %s", cycle.ID[:8], pair, strat.Name(), activePreset.Name, trace.Fields(ctx))
 
 	if err := s.repo.CreateCycle(ctx, cycle); err != nil {
 		log.Printf("[周期:%s] ✘ 创建周期失败: %v", cycle.ID[:8], err)
@@ -78,26 +223,14 @@ func (s *Service) RunCycle(ctx context.Context, req RunRequest) (domain.CycleRes
 	}
 
 	logs := make([]domain.CycleLog, 0, 6)
-	addLog := func(stage, message string) error {
-		entry := domain.CycleLog{
-			CycleID:   cycle.ID,
-			Stage:     stage,
-			Message:   message,
-			CreatedAt: time.Now().UTC(),
-		}
-		if err := s.repo.InsertCycleLog(ctx, entry); err != nil {
-			return err
-		}
-		logs = append(logs, entry)
-		return nil
-	}
+	addLog := s.cycleLogger(ctx, cycle.ID, &logs)
 
-	_ = addLog("启动", "周期开始执行")
+	_ = addLog("启动", "周期开始执行 策略="+strat.Name()+" 风险预设="+activePreset.Name)
 
 	snapshot := fallbackSnapshot(pair, req.Snapshot)
 	// 如果没有外部传入行情（定时器自动触发），快速从 Binance 拉取实时价格
 	if snapshot.LastPrice == 0 {
-		if price, change, err := fetchQuickTicker(ctx, pair); err == nil {
+		if price, change, err := s.fetchQuickTicker(ctx, pair); err == nil {
 			snapshot.LastPrice = price
 			snapshot.Change24h = change
 			log.Printf("[周期:%s] 📊 已从 Binance 获取实时行情 价格=%.6f 24h涨跌=%.2f%%", cycle.ID[:8], price, change)
@@ -108,47 +241,236 @@ func (s *Service) RunCycle(ctx context.Context, req RunRequest) (domain.CycleRes
 	log.Printf("[周期:%s] 📊 行情快照 价格=%.6f 24h涨跌=%.2f%%", cycle.ID[:8], snapshot.LastPrice, snapshot.Change24h)
 	_ = addLog("行情", fmt.Sprintf("价格=%.6f 24h涨跌=%.2f%%", snapshot.LastPrice, snapshot.Change24h))
 
+	// ---- 资金费率规则（合约多仓） ----
+	var alerts []string
+	fundingSt, err := s.checkFunding(ctx, pair)
+	if err != nil {
+		log.Printf("[周期:%s] ⚠ 资金费率检查失败: %v", cycle.ID[:8], err)
+	} else if msg := s.funding.alert(pair, fundingSt); msg != "" {
+		alerts = append(alerts, msg)
+		_ = addLog("资金费率", fmt.Sprintf("累计成本=%.3f%% (%.2f USDT) 期数=%d 持续高费率=%v",
+			fundingSt.CostPct, fundingSt.CostUSDT, fundingSt.Periods, fundingSt.Sustained))
+	}
+
+	// ---- 波动熔断 ----
+	halt, err := s.checkVolatility(ctx, pair)
+	if err != nil {
+		log.Printf("[周期:%s] ⚠ 波动熔断检查失败: %v", cycle.ID[:8], err)
+	}
+
+	// ---- 回撤熔断 ----
+	ddHalt, err := s.checkDrawdown(ctx)
+	if err != nil {
+		log.Printf("[周期:%s] ⚠ 权益快照 / 回撤熔断检查失败: %v", cycle.ID[:8], err)
+	}
+	ddForceClose := ddHalt != nil && s.drawdownGuard.ForceClose
+
+	holdingOpen := false
+	if halt != nil || ddHalt != nil {
+		holdingOpen = s.hasHolding(ctx, pair)
+	}
+	if halt != nil {
+		_ = addLog("熔断", halt.Message)
+		if holdingOpen {
+			alerts = append(alerts, volatilityAlert(halt))
+		}
+	}
+	if ddHalt != nil {
+		_ = addLog("熔断", ddHalt.Reason)
+		if holdingOpen && !ddForceClose {
+			alerts = append(alerts, drawdownAlert(ddHalt))
+		}
+	}
+
+	// ---- 杠杆风险率 ----
+	marginSt, err := s.checkMargin(ctx, pair)
+	if err != nil {
+		log.Printf("[周期:%s] ⚠ 杠杆风险率检查失败: %v", cycle.ID[:8], err)
+	}
+	marginLow := marginSt != nil && marginSt.Low
+	if marginLow {
+		_ = addLog("风控", marginAlert(marginSt))
+		alerts = append(alerts, marginAlert(marginSt))
+	}
+
+	// ---- 组合分配建议 ----
+	if req.Allocation != nil {
+		alerts = append(alerts, allocationAlert(req.Allocation))
+		_ = addLog("组合", fmt.Sprintf("排名=#%d 动作=%s 权重=%.2f %s", req.Allocation.Rank, req.Allocation.Action, req.Allocation.Weight, req.Allocation.Reason))
+	}
+
 	// ---- 信号生成 ----
 	signalStart := time.Now()
-	log.Printf("[周期:%s] 🤖 信号: 正在调用大模型分析 %s ...", cycle.ID[:8], pair)
-	sig, err := s.signal.Generate(ctx, signal.Input{CycleID: cycle.ID, Pair: pair, Snapshot: snapshot})
+	var sig domain.Signal
+	var manualStake float64
+	if req.ManualClose {
+		sig = manualCloseSignal(cycle.ID, pair, req.CloseFraction)
+		log.Printf("[周期:%s] ✋ %s", cycle.ID[:8], sig.Reason)
+	} else if req.Manual != nil {
+		sig, manualStake, err = s.manualTradeSignal(ctx, cycle.ID, pair, *req.Manual, snapshot.LastPrice)
+		if err != nil {
+			log.Printf("[周期:%s] ✘ 人工下单失败: %v", cycle.ID[:8], err)
+			s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, domain.ReasonInternal, err.Error())
+			_ = addLog("信号", "人工下单失败: "+err.Error())
+			return domain.CycleResult{}, err
+		}
+		log.Printf("[周期:%s] ✋ %s 风控=%v", cycle.ID[:8], sig.Reason, !manualBypass)
+	} else if s.funding.AutoClose && s.funding.overLimit(fundingSt) {
+		// 累计费率成本超限：不调用大模型，直接生成平仓信号
+		sig = fundingCloseSignal(cycle.ID, pair, fundingSt, s.funding.MaxCostPct)
+		log.Printf("[周期:%s] 💸 %s", cycle.ID[:8], sig.Reason)
+	} else if ddForceClose && holdingOpen {
+		// 回撤熔断且开启强制平仓：不调用大模型，直接平仓
+		sig = drawdownCloseSignal(cycle.ID, pair, ddHalt)
+		log.Printf("[周期:%s] 🚨 %s", cycle.ID[:8], sig.Reason)
+	} else if ddHalt != nil && !holdingOpen {
+		sig = drawdownHoldSignal(cycle.ID, pair, ddHalt)
+		log.Printf("[周期:%s] 🚨 %s", cycle.ID[:8], sig.Reason)
+	} else if halt != nil && !holdingOpen {
+		// 熔断期间没有持仓可处理：不调用大模型，直接观望
+		sig = volatilityHoldSignal(cycle.ID, pair, halt)
+		log.Printf("[周期:%s] 🚨 %s", cycle.ID[:8], sig.Reason)
+	} else {
+		log.Printf("[周期:%s] 🤖 信号: 正在调用大模型分析 %s ...", cycle.ID[:8], pair)
+		sig, err = strat.SignalAgent().Generate(ctx, signal.Input{
+			CycleID:  cycle.ID,
+			Pair:     pair,
+			Snapshot: snapshot,
+			Model:    req.Model,
+			Provider: req.Provider,
+			Alerts:   alerts,
+			History:  s.decisionMemory(ctx, pair),
+
+			TradingMode: executor.TradingMode(),
+			Leverage:    s.leverageFor(ctx, pair, executor.Leverage()),
+		})
+	}
 	signalElapsed := time.Since(signalStart)
 	if err != nil {
 		log.Printf("[周期:%s] ✘ 信号生成失败 耗时%s: %v", cycle.ID[:8], signalElapsed, err)
-		_ = s.repo.UpdateCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, err.Error())
+		s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, signalFailureCode(err), err.Error())
 		_ = addLog("信号", "信号生成失败: "+err.Error())
 		return domain.CycleResult{}, err
 	}
 	log.Printf("[周期:%s] ✔ 信号: 方向=%s 置信度=%.2f 理由=%q (耗时%s)", cycle.ID[:8], sig.Side, sig.Confidence, sig.Reason, signalElapsed)
+	if halt != nil && !manualBypass && (sig.Side == domain.SideLong || sig.Side == domain.SideShort) {
+		log.Printf("[周期:%s] 🚨 波动熔断中，%s 开仓信号改为观望", cycle.ID[:8], sig.Side)
+		sig.Reason = fmt.Sprintf("波动熔断拦截 %s 开仓：%s（原理由：%s）", sig.Side, halt.Message, sig.Reason)
+		sig.Side = domain.SideNone
+	}
+	if ddHalt != nil && !manualBypass && (sig.Side == domain.SideLong || sig.Side == domain.SideShort) {
+		log.Printf("[周期:%s] 🚨 回撤熔断中，%s 开仓信号改为观望", cycle.ID[:8], sig.Side)
+		sig.Reason = fmt.Sprintf("回撤熔断拦截 %s 开仓：%s（原理由：%s）", sig.Side, ddHalt.Reason, sig.Reason)
+		sig.Side = domain.SideNone
+	}
+	if marginLow && !manualBypass && (sig.Side == domain.SideLong || sig.Side == domain.SideShort) {
+		log.Printf("[周期:%s] 🚨 杠杆风险率过低，%s 开仓信号改为观望", cycle.ID[:8], sig.Side)
+		sig.Reason = fmt.Sprintf("杠杆风险率拦截 %s 开仓：%s（原理由：%s）", sig.Side, marginAlert(marginSt), sig.Reason)
+		sig.Side = domain.SideNone
+	}
+	if !req.ManualClose && !manualBypass {
+		if reason, err := s.checkChurn(ctx, pair, sig); err != nil {
+			log.Printf("[周期:%s] ⚠ 防反复开平检查失败: %v", cycle.ID[:8], err)
+		} else if reason != "" {
+			log.Printf("[周期:%s] 🔁 防反复开平: %s，改为观望", cycle.ID[:8], reason)
+			_ = addLog("风控", "防反复开平: "+reason)
+			sig.Reason = fmt.Sprintf("防反复开平拦截 %s：%s（原理由：%s）", sig.Side, reason, sig.Reason)
+			sig.Side = domain.SideNone
+		}
+	}
+	if allocationBlocksEntry(req.Allocation) && (sig.Side == domain.SideLong || sig.Side == domain.SideShort) {
+		log.Printf("[周期:%s] 📋 分配计划为 %s（权重 %.2f），%s 开仓信号改为观望", cycle.ID[:8], req.Allocation.Action, req.Allocation.Weight, sig.Side)
+		sig.Reason = fmt.Sprintf("组合分配计划拦截 %s 开仓：动作=%s 权重=%.2f（原理由：%s）", sig.Side, req.Allocation.Action, req.Allocation.Weight, sig.Reason)
+		sig.Side = domain.SideNone
+	}
+	if len(sig.DataGaps) > 0 {
+		log.Printf("[周期:%s] ⚠ 行情数据缺失: %v", cycle.ID[:8], sig.DataGaps)
+		_ = addLog("行情", "数据缺失(按降级策略处理): "+strings.Join(sig.DataGaps, ","))
+	}
+	for _, a := range sig.CallAttempts {
+		_ = addLog("信号", "大模型调用: "+a)
+	}
 
 	if err := s.repo.InsertSignal(ctx, sig); err != nil {
 		log.Printf("[周期:%s] ✘ 保存信号失败: %v", cycle.ID[:8], err)
-		_ = s.repo.UpdateCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, err.Error())
+		s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, domain.ReasonInternal, err.Error())
 		return domain.CycleResult{}, err
 	}
 	_ = addLog("信号", fmt.Sprintf("方向=%s 置信度=%.2f 理由=%s", sig.Side, sig.Confidence, sig.Reason))
 
+	if tags := autoTags(snapshot, sig, alerts); len(tags) > 0 {
+		if err := s.repo.AddCycleTags(ctx, cycle.ID, domain.TagSourceAuto, tags); err != nil {
+			log.Printf("[周期:%s] ⚠ 保存自动标签失败: %v", cycle.ID[:8], err)
+		}
+	}
+
 	// ---- 风控评估 ----
-	log.Printf("[周期:%s] 🛡️ 风控: 正在评估 ...", cycle.ID[:8])
-	riskDecision, err := s.risk.Evaluate(ctx, risk.Input{CycleID: cycle.ID, Signal: sig, Portfolio: req.Portfolio})
-	if err != nil {
-		log.Printf("[周期:%s] ✘ 风控评估失败: %v", cycle.ID[:8], err)
-		_ = s.repo.UpdateCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, err.Error())
-		_ = addLog("风控", "风控评估失败: "+err.Error())
-		return domain.CycleResult{}, err
+	var riskDecision domain.RiskDecision
+	if manualBypass {
+		riskDecision = manualRiskDecision(sig, manualStake)
+		log.Printf("[周期:%s] ✋ 风控: 人工下单已跳过风控 金额=%.2f USDT", cycle.ID[:8], manualStake)
+		_ = addLog("风控", fmt.Sprintf("人工下单跳过风控 金额=%.2f", manualStake))
+	} else {
+		log.Printf("[周期:%s] 🛡️ 风控: 正在评估 ...", cycle.ID[:8])
+		portfolio := s.resolvePortfolio(ctx, cycle.ID, req.Portfolio)
+		log.Printf("[周期:%s] 📊 组合状态: 当日盈亏=%.2f USDT 持仓敞口=%.2f USDT", cycle.ID[:8], portfolio.DailyPnLUSDT, portfolio.OpenExposureUSDT)
+		_ = addLog("风控", fmt.Sprintf("组合状态 当日盈亏=%.2f 持仓敞口=%.2f", portfolio.DailyPnLUSDT, portfolio.OpenExposureUSDT))
+		lastEntryAt, err := s.repo.LastEntryTime(ctx, pair)
+		if err != nil {
+			log.Printf("[周期:%s] ⚠ 查询最近开仓时间失败: %v", cycle.ID[:8], err)
+		}
+		streak, lastLossAt, err := s.lossStreak(ctx, pair)
+		if err != nil {
+			log.Printf("[周期:%s] ⚠ 统计连续亏损失败: %v", cycle.ID[:8], err)
+		}
+		dailyTrades, err := s.repo.CountFilledOrdersSince(ctx, pair, tradingday.Start(time.Now()))
+		if err != nil {
+			log.Printf("[周期:%s] ⚠ 统计当日成交次数失败: %v", cycle.ID[:8], err)
+		}
+		riskDecision, err = s.risk.Evaluate(ctx, risk.Input{
+			CycleID:     cycle.ID,
+			Signal:      sig,
+			Portfolio:   portfolio,
+			Preset:      &activePreset,
+			LastEntryAt: lastEntryAt,
+			LossStreak:  streak,
+			LastLossAt:  lastLossAt,
+			DailyTrades: dailyTrades,
+		})
+		if err != nil {
+			log.Printf("[周期:%s] ✘ 风控评估失败: %v", cycle.ID[:8], err)
+			s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, domain.ReasonInternal, err.Error())
+			_ = addLog("风控", "风控评估失败: "+err.Error())
+			return domain.CycleResult{}, err
+		}
+		if req.sandbox == nil {
+			if note := s.applyCapitalAllocation(ctx, pair, sig, portfolio, &riskDecision); note != "" {
+				log.Printf("[周期:%s] 💰 %s", cycle.ID[:8], note)
+				_ = addLog("组合", note)
+			}
+		}
+		if manualStake > 0 && riskDecision.Approved && manualStake < riskDecision.MaxStakeUSDT {
+			note := fmt.Sprintf("人工指定金额 %.2f USDT", manualStake)
+			riskDecision.Sizing.Adjust(domain.SizingManual, riskDecision.MaxStakeUSDT, manualStake, note)
+			riskDecision.MaxStakeUSDT = manualStake
+			_ = addLog("风控", note)
+		} else if manualStake > riskDecision.MaxStakeUSDT && riskDecision.Approved {
+			_ = addLog("风控", fmt.Sprintf("人工指定金额 %.2f 超过风控上限，按 %.2f 下单", manualStake, riskDecision.MaxStakeUSDT))
+		}
 	}
 	if err := s.repo.InsertRiskDecision(ctx, riskDecision); err != nil {
 		log.Printf("[周期:%s] ✘ 保存风控决策失败: %v", cycle.ID[:8], err)
-		_ = s.repo.UpdateCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, err.Error())
+		s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, domain.ReasonInternal, err.Error())
 		return domain.CycleResult{}, err
 	}
 
 	if !riskDecision.Approved {
 		log.Printf("[周期:%s] ⚠️ 风控: 已拒绝 原因=%q", cycle.ID[:8], riskDecision.RejectReason)
 		_ = addLog("风控", "已拒绝: "+riskDecision.RejectReason)
-		_ = s.repo.UpdateCycleStatus(ctx, cycle.ID, domain.CycleStatusRejected, riskDecision.RejectReason)
+		s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusRejected, riskDecision.RejectCode, riskDecision.RejectReason)
 		cycle.Status = domain.CycleStatusRejected
 		cycle.ErrorMessage = riskDecision.RejectReason
+		cycle.ReasonCode = riskDecision.RejectCode
 		cycle.UpdatedAt = time.Now().UTC()
 
 		log.Printf("[周期:%s] ■ 执行完毕 状态=已拒绝 总耗时=%s", cycle.ID[:8], time.Since(cycleStart))
@@ -161,10 +483,19 @@ func (s *Service) RunCycle(ctx context.Context, req RunRequest) (domain.CycleRes
 	}
 	log.Printf("[周期:%s] ✔ 风控: 已通过 最大仓位=%.2f USDT", cycle.ID[:8], riskDecision.MaxStakeUSDT)
 	_ = addLog("风控", fmt.Sprintf("已通过 最大仓位=%.2f", riskDecision.MaxStakeUSDT))
+	if a := req.Allocation; a != nil && sig.Side != domain.SideClose && activePreset.MaxExposureUSDT > 0 {
+		if limit := a.Weight * activePreset.MaxExposureUSDT; riskDecision.MaxStakeUSDT > limit {
+			log.Printf("[周期:%s] 📋 分配计划权重 %.2f，开仓金额 %.2f → %.2f USDT", cycle.ID[:8], a.Weight, riskDecision.MaxStakeUSDT, limit)
+			_ = addLog("组合", fmt.Sprintf("按分配权重 %.2f 调整开仓金额 %.2f → %.2f", a.Weight, riskDecision.MaxStakeUSDT, limit))
+			riskDecision.Sizing.Adjust(domain.SizingAllocation, riskDecision.MaxStakeUSDT, limit, fmt.Sprintf("分配计划权重 %.2f", a.Weight))
+			riskDecision.MaxStakeUSDT = limit
+			s.saveSizing(ctx, riskDecision)
+		}
+	}
 
 	// ---- 建仓策略生成 ----
 	log.Printf("[周期:%s] 📊 建仓策略: 正在生成 ...", cycle.ID[:8])
-	posStrategy, err := s.position.Generate(ctx, position.Input{
+	posInput := position.Input{
 		CycleID:      cycle.ID,
 		SignalID:     sig.ID,
 		Pair:         pair,
@@ -172,10 +503,21 @@ func (s *Service) RunCycle(ctx context.Context, req RunRequest) (domain.CycleRes
 		Signal:       sig,
 		MaxStakeUSDT: riskDecision.MaxStakeUSDT,
 		CurrentPrice: snapshot.LastPrice,
-	})
+
+		TakeProfitPercent: activePreset.TakeProfitPercent,
+		StopLossPercent:   activePreset.StopLossPercent,
+		ExitLadder:        s.exitLadder,
+	}
+	var posStrategy domain.PositionStrategy
+	if req.Manual != nil {
+		// 人工下单按指定金额一次性成交，不分批
+		posStrategy = singleEntryPosition(posInput, "人工下单，按指定金额一次性建仓")
+	} else {
+		posStrategy, err = generatePosition(ctx, strat.PositionAgent(), posInput)
+	}
 	if err != nil {
 		log.Printf("[周期:%s] ✘ 建仓策略生成失败: %v", cycle.ID[:8], err)
-		_ = s.repo.UpdateCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, err.Error())
+		s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, domain.ReasonInternal, err.Error())
 		_ = addLog("建仓策略", "生成失败: "+err.Error())
 		return domain.CycleResult{}, err
 	}
@@ -190,42 +532,145 @@ func (s *Service) RunCycle(ctx context.Context, req RunRequest) (domain.CycleRes
 		posStrategy.TakeProfitPercent, posStrategy.StopLossPercent)
 	_ = addLog("建仓策略", fmt.Sprintf("%s: %s", posStrategy.Strategy, posStrategy.Reason))
 
+	ce := cycleExecution{
+		cycle:       cycle,
+		pair:        pair,
+		sig:         sig,
+		risk:        riskDecision,
+		posStrategy: posStrategy,
+		price:       snapshot.LastPrice,
+		leverage:    activePreset.Leverage,
+		logs:        logs,
+		start:       cycleStart,
+		requestedBy: req.RequestedBy,
+	}
+
+	// ---- 人工审批 ----
+	if s.approvalRequired(executor) {
+		return s.holdForApproval(ctx, ce)
+	}
+	return s.executeCycle(ctx, ce)
+}
+
+// saveSizing 保存风控决策的开仓金额计算过程，失败只记日志
+func (s *Service) saveSizing(ctx context.Context, d domain.RiskDecision) {
+	if d.Sizing == nil {
+		return
+	}
+	if err := s.repo.UpdateRiskSizing(ctx, d.ID, d.Sizing); err != nil {
+		log.Printf("[风控] ⚠ 保存开仓金额计算过程失败: %v", err)
+	}
+}
+
+// cycleLogger 返回写入周期日志的函数，写入成功的日志同时追加到 logs
+func (s *Service) cycleLogger(ctx context.Context, cycleID string, logs *[]domain.CycleLog) func(stage, message string) error {
+	return func(stage, message string) error {
+		entry := domain.CycleLog{
+			CycleID:   cycleID,
+			Stage:     stage,
+			Message:   message,
+			CreatedAt: time.Now().UTC(),
+		}
+		if err := s.repo.InsertCycleLog(ctx, entry); err != nil {
+			return err
+		}
+		*logs = append(*logs, entry)
+		return nil
+	}
+}
+
+// cycleExecution 周期下单阶段所需的上下文；人工审批模式下审批通过后从数据库恢复
+type cycleExecution struct {
+	cycle       domain.Cycle
+	pair        string
+	sig         domain.Signal
+	risk        domain.RiskDecision
+	posStrategy domain.PositionStrategy
+	price       float64 // 预估成交价
+	leverage    int     // 风险预设杠杆（交易对单独设置时以交易对为准）
+	logs        []domain.CycleLog
+	start       time.Time
+	requestedBy string
+}
+
+// executeCycle 周期下单阶段：按余额 / 交易对限制调整金额，下单后更新持仓、分批批次与保护单
+func (s *Service) executeCycle(ctx context.Context, ce cycleExecution) (domain.CycleResult, error) {
+	cycle, pair, sig, riskDecision, posStrategy := ce.cycle, ce.pair, ce.sig, ce.risk, ce.posStrategy
+	cycleStart := ce.start
+	executor := execution.ForPair(s.executor, pair)
+	logs := ce.logs
+	addLog := s.cycleLogger(ctx, cycle.ID, &logs)
+
+	// 幂等保护：同一周期只下一次单（重复审批、进程重入等）
+	idemKey := "cycle:" + cycle.ID
+	if exists, err := s.repo.OrderExistsByIdempotencyKey(ctx, idemKey); err != nil {
+		log.Printf("[周期:%s] ⚠ 查询幂等键失败: %v", cycle.ID[:8], err)
+	} else if exists {
+		log.Printf("[周期:%s] ✘ %v", cycle.ID[:8], ErrDuplicateOrder)
+		_ = addLog("执行", ErrDuplicateOrder.Error())
+		return domain.CycleResult{Cycle: cycle, Signal: sig, Risk: riskDecision, Logs: logs}, ErrDuplicateOrder
+	}
+
 	// ---- 下单执行 ----
-	// 注意：当前版本执行第一批次，后续批次需要单独实现触发逻辑
+	// 周期只执行第一批次，后续批次由 ProcessPendingBatches 按触发价执行
 	execInput := execution.Input{
-		CycleID:       cycle.ID,
-		SignalID:      sig.ID,
-		Pair:          pair,
-		Side:          sig.Side,
-		StakeUSDT:     riskDecision.MaxStakeUSDT,
-		EstimatedFill: snapshot.LastPrice,
+		IdempotencyKey: idemKey,
+		CycleID:        cycle.ID,
+		SignalID:       sig.ID,
+		Pair:           pair,
+		Side:           sig.Side,
+		StakeUSDT:      riskDecision.MaxStakeUSDT,
+		EstimatedFill:  ce.price,
+		Leverage:       s.leverageFor(ctx, pair, ce.leverage),
 	}
 
 	// 如果是买入且有分批策略，只执行第一批
 	if sig.Side == domain.SideLong && len(posStrategy.Batches) > 0 {
 		firstBatch := posStrategy.Batches[0]
+		if firstBatch.Amount != execInput.StakeUSDT {
+			riskDecision.Sizing.Adjust(domain.SizingBatch, execInput.StakeUSDT, firstBatch.Amount,
+				fmt.Sprintf("分批建仓，先执行第1批（共%d批）", len(posStrategy.Batches)))
+		}
 		execInput.StakeUSDT = firstBatch.Amount
 		log.Printf("[周期:%s] 📦 执行第1批: %.2f USDT (共%d批)", cycle.ID[:8], firstBatch.Amount, len(posStrategy.Batches))
 	}
 
-	// 买入信号：检查实际可用余额，自动调整金额避免余额不足
-	if sig.Side == domain.SideLong && !s.executor.IsDryRun() {
-		balances, bErr := s.executor.FetchFullBalance(ctx)
+	// 买入信号：检查实际可用余额（模拟盘为虚拟钱包），自动调整金额避免余额不足
+	if sig.Side == domain.SideLong && (!executor.IsDryRun() || s.paperEnabled(executor)) {
+		balances, bErr := s.fetchBalances(ctx, executor)
 		if bErr == nil {
 			for _, b := range balances {
 				if b.Symbol == "USDT" {
 					available := b.Free
+					// 资金放在活期理财时按需赎回差额（含 1 USDT 手续费缓冲）
+					if need := execInput.StakeUSDT + 1.0 - available; need > 0 && b.Earn > 0 {
+						if redeemed := s.redeemEarnUSDT(ctx, executor, need, b.Earn); redeemed > 0 {
+							available += redeemed
+							_ = addLog("执行", fmt.Sprintf("从活期理财赎回 %.2f USDT", redeemed))
+						}
+					}
+					if sz := riskDecision.Sizing; sz != nil {
+						sz.BalanceFreeUSDT, sz.BalanceEarnUSDT = available, b.Earn
+					}
 					// 预留 1 USDT 作为手续费缓冲
 					maxCanSpend := available - 1.0
 					if maxCanSpend < 5 {
-						log.Printf("[周期:%s] ⚠ USDT余额不足: 可用=%.2f，最少需5U，跳过本轮", cycle.ID[:8], available)
-						_ = addLog("执行", fmt.Sprintf("跳过: USDT余额不足 可用=%.2f", available))
-						_ = s.repo.UpdateCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, "USDT余额不足")
+						riskDecision.Sizing.Adjust(domain.SizingBalance, execInput.StakeUSDT, 0, "USDT余额不足")
+						s.saveSizing(ctx, riskDecision)
+						log.Printf("[周期:%s] ⚠ USDT余额不足: 可用=%.2f 理财=%.2f，最少需5U，跳过本轮", cycle.ID[:8], available, b.Earn)
+						_ = addLog("执行", fmt.Sprintf("跳过: USDT余额不足 可用=%.2f 活期理财=%.2f", available, b.Earn))
+						s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, domain.ReasonInsufficientBalance, "USDT余额不足")
+						s.cancelPendingBatches(ctx, posStrategy, "USDT余额不足")
+						cycle.Status = domain.CycleStatusFailed
+						cycle.ErrorMessage = "USDT余额不足"
+						cycle.ReasonCode = domain.ReasonInsufficientBalance
 						return domain.CycleResult{Cycle: cycle, Signal: sig, Risk: riskDecision, Logs: logs}, nil
 					}
 					if execInput.StakeUSDT > maxCanSpend {
 						log.Printf("[周期:%s] 💰 余额调整: 计划=%.2f 可用=%.2f → 实际下单=%.2f",
 							cycle.ID[:8], execInput.StakeUSDT, available, maxCanSpend)
+						riskDecision.Sizing.Adjust(domain.SizingBalance, execInput.StakeUSDT, maxCanSpend,
+							fmt.Sprintf("可用余额 %.2f，预留 1 USDT 手续费", available))
 						execInput.StakeUSDT = maxCanSpend
 					}
 					break
@@ -236,11 +681,53 @@ func (s *Service) RunCycle(ctx context.Context, req RunRequest) (domain.CycleRes
 		}
 	}
 
+	// 开仓：按交易对的单笔金额上下限调整（在余额调整之后，最终下单金额也要满足下限）
+	if sig.Side != domain.SideClose {
+		stake, lErr := s.applyNotionalLimit(pair, execInput.StakeUSDT)
+		if lErr != nil {
+			log.Printf("[周期:%s] ⚠ %v，跳过本轮", cycle.ID[:8], lErr)
+			_ = addLog("风控", "跳过: "+lErr.Error())
+			riskDecision.Sizing.Adjust(domain.SizingNotional, execInput.StakeUSDT, 0, lErr.Error())
+			s.saveSizing(ctx, riskDecision)
+			s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusRejected, domain.ReasonNotionalLimit, lErr.Error())
+			s.cancelPendingBatches(ctx, posStrategy, "低于交易对下单金额下限")
+			cycle.Status = domain.CycleStatusRejected
+			cycle.ErrorMessage = lErr.Error()
+			cycle.ReasonCode = domain.ReasonNotionalLimit
+			return domain.CycleResult{Cycle: cycle, Signal: sig, Risk: riskDecision, Logs: logs}, nil
+		}
+		if stake != execInput.StakeUSDT {
+			_ = addLog("风控", fmt.Sprintf("下单金额 %.2f 超过交易对上限，调整为 %.2f", execInput.StakeUSDT, stake))
+			riskDecision.Sizing.Adjust(domain.SizingNotional, execInput.StakeUSDT, stake, "交易对单笔金额上限")
+			execInput.StakeUSDT = stake
+		}
+		if sz := riskDecision.Sizing; sz != nil {
+			sz.FinalStakeUSDT = execInput.StakeUSDT
+			s.saveSizing(ctx, riskDecision)
+		}
+	}
+
 	// close 信号：查询持仓数量，用币数量卖出/平仓
+	closeFraction := 1.0
+	var prevProtection []domain.ProtectiveOrder
 	if sig.Side == domain.SideClose {
-		if s.executor.TradingMode() == "futures" {
+		closeFraction = domain.NormalizeCloseFraction(sig.CloseFraction)
+		execInput.CloseFraction = closeFraction
+		if closeFraction < 1 {
+			// 按比例减仓：记下原保护单触发价，减仓后为剩余持仓重挂
+			prevProtection, _ = s.repo.ListProtectiveOrders(ctx, pair, domain.ProtectiveActive)
+			log.Printf("[周期:%s] ✂ 按比例平仓 %.0f%%", cycle.ID[:8], closeFraction*100)
+			_ = addLog("执行", fmt.Sprintf("按比例平仓 %.0f%%", closeFraction*100))
+		}
+		// 先撤销保护单，释放现货 OCO 冻结的币
+		if n, cErr := s.cancelProtectiveOrders(ctx, pair); cErr != nil {
+			log.Printf("[周期:%s] ⚠ 平仓前撤销保护单失败: %v", cycle.ID[:8], cErr)
+		} else if n > 0 {
+			_ = addLog("止损", fmt.Sprintf("平仓前已撤销 %d 条保护单", n))
+		}
+		if executor.TradingMode() == "futures" {
 			// 合约模式：通过 positionRisk API 获取持仓数量
-			posAmt, pErr := s.executor.FetchPositionRisk(ctx, pair)
+			posAmt, pErr := executor.FetchPositionRisk(ctx, pair)
 			if pErr == nil && posAmt > 0 {
 				execInput.SellQuantity = posAmt
 				log.Printf("[周期:%s] 📦 合约平仓: %s 持仓数量=%.4f", cycle.ID[:8], pair, posAmt)
@@ -262,7 +749,7 @@ func (s *Service) RunCycle(ctx context.Context, req RunRequest) (domain.CycleRes
 			// 现货模式
 			coin := strings.Split(pair, "/")[0]
 
-			if s.executor.IsDryRun() {
+			if executor.IsDryRun() {
 				// 模拟盘：用本地 holdings 表
 				holdings, hErr := s.repo.ListHoldings(ctx)
 				if hErr == nil {
@@ -276,7 +763,7 @@ func (s *Service) RunCycle(ctx context.Context, req RunRequest) (domain.CycleRes
 				}
 			} else {
 				// 实盘：以交易所真实余额为准（避免本地数据与实际不一致）
-				balances, bErr := s.executor.FetchFullBalance(ctx)
+				balances, bErr := executor.FetchFullBalance(ctx)
 				if bErr == nil {
 					for _, b := range balances {
 						if strings.EqualFold(b.Symbol, coin) && b.Free > 0 {
@@ -305,7 +792,7 @@ func (s *Service) RunCycle(ctx context.Context, req RunRequest) (domain.CycleRes
 		if execInput.SellQuantity <= 0 {
 			log.Printf("[周期:%s] ⚠ 平仓跳过: %s 无持仓可卖", cycle.ID[:8], pair)
 			_ = addLog("执行", "平仓跳过: 无持仓可卖")
-			_ = s.repo.UpdateCycleStatus(ctx, cycle.ID, domain.CycleStatusSuccess, "")
+			s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusSuccess, "", "")
 			return domain.CycleResult{
 				Cycle:  cycle,
 				Signal: sig,
@@ -316,25 +803,52 @@ func (s *Service) RunCycle(ctx context.Context, req RunRequest) (domain.CycleRes
 	}
 
 	log.Printf("[周期:%s] 🚀 执行: 正在下单 方向=%s 金额=%.2f 数量=%.4f ...", cycle.ID[:8], sig.Side, execInput.StakeUSDT, execInput.SellQuantity)
-	ord, execErr := s.executor.Execute(ctx, execInput)
-	if ord.ID != "" {
-		_ = s.repo.InsertOrder(ctx, ord)
-	}
+	ord, twap, execErr := s.placeOrder(ctx, executor, execInput)
 	if execErr != nil {
 		log.Printf("[周期:%s] ✘ 下单失败: %v", cycle.ID[:8], execErr)
-		_ = s.repo.UpdateCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, execErr.Error())
+		s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusFailed, executionFailureCode(execErr), execErr.Error())
 		_ = addLog("执行", "下单失败: "+execErr.Error())
+		s.cancelPendingBatches(ctx, posStrategy, "首批下单失败")
 		return domain.CycleResult{}, execErr
 	}
 
 	log.Printf("[周期:%s] ✔ 执行: 订单状态=%s 交易所ID=%s", cycle.ID[:8], ord.Status, ord.ExchangeOrderID)
 	_ = addLog("执行", fmt.Sprintf("订单状态=%s 交易所ID=%s", ord.Status, ord.ExchangeOrderID))
-	_ = s.repo.UpdateCycleStatus(ctx, cycle.ID, domain.CycleStatusSuccess, "")
+	s.persistCycleStatus(ctx, cycle.ID, domain.CycleStatusSuccess, "", "")
 	cycle.Status = domain.CycleStatusSuccess
 	cycle.UpdatedAt = time.Now().UTC()
 
-	// 交易成功后更新持仓
-	s.UpdateHoldingAfterTrade(ctx, ord)
+	// 交易成功后更新持仓（拆单的子单已逐笔计入）
+	if !twap {
+		s.UpdateHoldingAfterTrade(ctx, ord)
+	}
+
+	// 分批建仓：记录首批成交；全部平仓后取消该交易对剩余的待触发批次，按比例减仓时保留
+	if sig.Side == domain.SideClose {
+		if closeFraction >= 1 {
+			s.cancelPairBatches(ctx, pair, "已平仓")
+		}
+	} else {
+		s.markFirstBatch(ctx, posStrategy, ord)
+	}
+
+	// 同步交易所止盈止损保护单，程序离线时依然有效
+	if msg, err := s.syncProtectiveOrders(ctx, cycle.ID, ord, posStrategy.TakeProfitPercent, posStrategy.StopLossPercent); err != nil {
+		log.Printf("[周期:%s] ⚠ 保护单同步失败: %v", cycle.ID[:8], err)
+		_ = addLog("止损", "同步失败: "+err.Error())
+	} else if msg != "" {
+		log.Printf("[周期:%s] 🛡 %s", cycle.ID[:8], msg)
+		_ = addLog("止损", msg)
+	}
+	if closeFraction < 1 {
+		if msg, err := s.restoreProtection(ctx, cycle.ID, pair, prevProtection); err != nil {
+			log.Printf("[周期:%s] ⚠ 剩余持仓保护单重挂失败: %v", cycle.ID[:8], err)
+			_ = addLog("止损", "剩余持仓重挂失败: "+err.Error())
+		} else if msg != "" {
+			log.Printf("[周期:%s] 🛡 %s", cycle.ID[:8], msg)
+			_ = addLog("止损", msg)
+		}
+	}
 
 	log.Printf("[周期:%s] ■ 执行完毕 状态=成功 总耗时=%s", cycle.ID[:8], time.Since(cycleStart))
 	return domain.CycleResult{
@@ -354,8 +868,62 @@ func (s *Service) DeleteCycle(ctx context.Context, cycleID string) error {
 	return s.repo.DeleteCycle(ctx, cycleID)
 }
 
-func (s *Service) ListPositions(ctx context.Context, limit int) ([]domain.PositionView, error) {
-	return s.repo.ListPositions(ctx, limit)
+// ErrPruneStatus 批量删除只允许已结束的周期状态
+var ErrPruneStatus = errors.New("status 只能是 failed / rejected / success")
+
+// PruneCycles 批量删除指定状态且早于 before 的周期及其关联数据，存在成交订单的周期保留；
+// dryRun 时只返回会被删除的数量
+func (s *Service) PruneCycles(ctx context.Context, statuses []domain.CycleStatus, before time.Time, dryRun bool) (int64, error) {
+	for _, st := range statuses {
+		switch st {
+		case domain.CycleStatusFailed, domain.CycleStatusRejected, domain.CycleStatusSuccess:
+		default:
+			// 执行中与待审批的周期不能删除
+			return 0, fmt.Errorf("%w: %s", ErrPruneStatus, st)
+		}
+	}
+	if dryRun {
+		return s.repo.CountPrunableCycles(ctx, statuses, before)
+	}
+	n, err := s.repo.PruneCycles(ctx, statuses, before)
+	if err == nil && n > 0 {
+		log.Printf("[清理] ✔ 手动删除 %d 个 %s 之前的周期 状态=%v", n, before.Format("2006-01-02 15:04"), statuses)
+	}
+	return n, err
+}
+
+// ListPositions 分页获取仓位（订单）列表
+func (s *Service) ListPositions(ctx context.Context, q domain.ListQuery) ([]domain.PositionView, int, error) {
+	positions, err := s.repo.ListPositions(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.CountPositions(ctx, q.Tag)
+	if err != nil {
+		return nil, 0, err
+	}
+	return positions, total, nil
+}
+
+// DataSourceStatus 返回外部数据源（资金费率、新闻、社交、社区、恐慌贪婪、热搜）的健康状态
+func (s *Service) DataSourceStatus() []market.SourceStatus {
+	return market.SourceHealth()
+}
+
+// ListPresets 返回所有风险预设及当前生效的预设名
+func (s *Service) ListPresets() ([]domain.RiskPreset, string) {
+	return s.presets.List(), s.presets.Active().Name
+}
+
+// ApplyPreset 切换风险预设，下一个周期开始生效
+func (s *Service) ApplyPreset(name string) (domain.RiskPreset, error) {
+	p, err := s.presets.Apply(name)
+	if err != nil {
+		return p, err
+	}
+	log.Printf("[风控] 风险预设已切换为 %s: 单笔上限=%.2f 敞口上限=%.2f 最小置信度=%.2f 杠杆=%dx 止盈=%.1f%% 止损=%.1f%% 冷却=%ds",
+		p.Name, p.MaxSingleStakeUSDT, p.MaxExposureUSDT, p.MinConfidence, p.Leverage, p.TakeProfitPercent, p.StopLossPercent, p.CooldownSec)
+	return p, nil
 }
 
 // TradingInfo 返回当前交易模式信息
@@ -363,23 +931,58 @@ type TradingInfo struct {
 	Mode     string `json:"mode"`     // "spot" 或 "futures"
 	Leverage int    `json:"leverage"` // 杠杆倍数
 	DryRun   bool   `json:"dry_run"`  // 是否模拟模式
+
+	PairModes map[string]string `json:"pair_modes,omitempty"` // 按交易对指定的模式
+
+	KeyAlert *execution.KeyAlert `json:"key_alert,omitempty"` // API Key 告警（-2015 / 即将到期）
+
+	Strategy       string            `json:"strategy,omitempty"`        // 默认策略
+	PairStrategies map[string]string `json:"pair_strategies,omitempty"` // 按交易对指定的策略
+
+	NotionalLimits map[string]NotionalLimit `json:"notional_limits,omitempty"` // 按交易对的单笔开仓金额上下限
+
+	VolatilityHalts []VolatilityHalt     `json:"volatility_halts,omitempty"` // 处于冷却期的波动熔断
+	DrawdownHalt    *domain.DrawdownHalt `json:"drawdown_halt,omitempty"`    // 生效中的回撤熔断
+
+	Queue *QueueStats `json:"queue,omitempty"` // 周期队列深度与执行情况
+
+	ReadOnly bool `json:"read_only,omitempty"` // 只读实例，写接口已禁用
 }
 
 func (s *Service) GetTradingInfo() TradingInfo {
-	return TradingInfo{
+	info := TradingInfo{
 		Mode:     s.executor.TradingMode(),
 		Leverage: s.executor.Leverage(),
 		DryRun:   s.executor.IsDryRun(),
+
+		NotionalLimits:  s.notionalLimits,
+		VolatilityHalts: s.VolatilityHalts(),
+		Queue:           s.QueueStats(),
+		ReadOnly:        s.readOnly,
+	}
+	if h, ok := s.drawdownHalted(); ok {
+		info.DrawdownHalt = &h
+	}
+	if r, ok := s.executor.(*execution.Router); ok {
+		info.PairModes = r.PairModes()
+	}
+	if s.strategies != nil {
+		info.Strategy = s.strategies.DefaultName()
+		info.PairStrategies = s.strategies.PairStrategies()
+	}
+	if !info.DryRun {
+		info.KeyAlert = execution.CurrentKeyAlert()
 	}
+	return info
 }
 
 // ListCycles 分页获取历史周期列表
-func (s *Service) ListCycles(ctx context.Context, page, pageSize int) ([]domain.CycleSummary, int, error) {
-	total, err := s.repo.CountCycles(ctx)
+func (s *Service) ListCycles(ctx context.Context, page, pageSize int, tag string) ([]domain.CycleSummary, int, error) {
+	total, err := s.repo.CountCycles(ctx, tag)
 	if err != nil {
 		return nil, 0, err
 	}
-	cycles, err := s.repo.ListCycles(ctx, page, pageSize)
+	cycles, err := s.repo.ListCycles(ctx, page, pageSize, tag)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -394,11 +997,12 @@ type AccountBalance struct {
 	Free   float64 `json:"free"`
 	Locked float64 `json:"locked"`
 	Total  float64 `json:"total"`
+	Earn   float64 `json:"earn"` // 活期理财
 }
 
 // GetAccountBalances 从交易所获取完整余额
 func (s *Service) GetAccountBalances(ctx context.Context) ([]AccountBalance, error) {
-	rawBalances, err := s.executor.FetchFullBalance(ctx)
+	rawBalances, err := s.fetchBalances(ctx, s.executor)
 	if err != nil {
 		return nil, err
 	}
@@ -409,6 +1013,7 @@ func (s *Service) GetAccountBalances(ctx context.Context) ([]AccountBalance, err
 			Free:   b.Free,
 			Locked: b.Locked,
 			Total:  b.Total,
+			Earn:   b.Earn,
 		})
 	}
 	return balances, nil
@@ -438,17 +1043,36 @@ func (s *Service) SyncHoldingsForceExchange(ctx context.Context) error {
 	return s.syncHoldingsFromExchange(ctx)
 }
 
-// SyncTradesFromExchange 从币安同步成交记录，并自动更新持仓
+// SyncTradesFromExchange 从交易所同步成交记录，并自动更新持仓
 func (s *Service) SyncTradesFromExchange(ctx context.Context, pair string) (int, error) {
 	trades, err := s.executor.FetchTradeHistory(ctx, pair, 500)
 	if err != nil {
 		return 0, fmt.Errorf("获取交易记录失败: %w", err)
 	}
 
+	imported := s.importTrades(ctx, pair, trades)
+	log.Printf("[同步] %s 共 %d 笔成交，新导入 %d 笔", pair, len(trades), imported)
+
+	// 同步完成后重新聚合持仓
+	if imported > 0 {
+		if err := s.syncHoldingsFromOrders(ctx); err != nil {
+			log.Printf("[同步] 重新聚合持仓失败: %v", err)
+		}
+	}
+
+	return imported, nil
+}
+
+// importTrades 把交易所成交写入订单表（按成交 ID 去重），返回新导入的笔数
+func (s *Service) importTrades(ctx context.Context, pair string, trades []execution.Trade) int {
 	imported := 0
 	for _, t := range trades {
-		// 用 "binance-{tradeID}" 作为 exchange_order_id 去重
-		exID := fmt.Sprintf("binance-%d", t.TradeID)
+		// 用 "{exchange}-{tradeID}" 作为 exchange_order_id 去重
+		exchange := t.Exchange
+		if exchange == "" {
+			exchange = execution.ExchangeBinance
+		}
+		exID := fmt.Sprintf("%s-%d", exchange, t.TradeID)
 		exists, _ := s.repo.OrderExistsByExchangeID(ctx, exID)
 		if exists {
 			continue
@@ -470,7 +1094,7 @@ func (s *Service) SyncTradesFromExchange(ctx context.Context, pair string) (int,
 			ID:              uuid.NewString(),
 			CycleID:         "", // 外部交易，无周期
 			SignalID:        "",
-			ClientOrderID:   fmt.Sprintf("binance-ord-%d", t.OrderID),
+			ClientOrderID:   fmt.Sprintf("%s-ord-%d", exchange, t.OrderID),
 			Pair:            pairFmt,
 			Side:            side,
 			StakeUSDT:       t.QuoteQty,
@@ -488,17 +1112,7 @@ func (s *Service) SyncTradesFromExchange(ctx context.Context, pair string) (int,
 		}
 		imported++
 	}
-
-	log.Printf("[同步] %s 共 %d 笔成交，新导入 %d 笔", pair, len(trades), imported)
-
-	// 同步完成后重新聚合持仓
-	if imported > 0 {
-		if err := s.syncHoldingsFromOrders(ctx); err != nil {
-			log.Printf("[同步] 重新聚合持仓失败: %v", err)
-		}
-	}
-
-	return imported, nil
+	return imported
 }
 
 // syncHoldingsFromOrders 从本地订单历史聚合持仓（模拟盘）
@@ -544,34 +1158,188 @@ func (s *Service) syncHoldingsFromExchange(ctx context.Context) error {
 		count++
 	}
 	log.Printf("[持仓] 从交易所同步完成，共 %d 个币对", count)
+
+	if lister, ok := s.executor.(execution.PositionLegLister); ok {
+		s.syncFuturesPositions(ctx, lister, now)
+	}
 	return nil
 }
 
-// GetHoldings 获取持仓列表，附带实时行情
-func (s *Service) GetHoldings(ctx context.Context) ([]domain.HoldingView, error) {
-	holdings, err := s.repo.ListHoldings(ctx)
+// syncFuturesPositions 按方向同步合约持仓：双向持仓下多头、空头各记一条，已平掉的空头清零
+func (s *Service) syncFuturesPositions(ctx context.Context, lister execution.PositionLegLister, now time.Time) {
+	legs, err := lister.FetchPositionLegs(ctx)
 	if err != nil {
-		return nil, err
+		log.Printf("[持仓] ⚠ 合约持仓同步失败: %v", err)
+		return
+	}
+
+	openShorts := make(map[string]bool)
+	for _, leg := range legs {
+		pair := strings.TrimSuffix(leg.Symbol, "USDT") + "/USDT"
+		if execution.ForPair(s.executor, pair).TradingMode() != "futures" {
+			continue
+		}
+		h := domain.Holding{
+			Pair:         pair,
+			PositionSide: leg.Side,
+			Symbol:       strings.TrimSuffix(leg.Symbol, "USDT"),
+			Quantity:     leg.Quantity,
+			AvgPrice:     leg.EntryPrice,
+			TotalCost:    leg.Quantity * leg.EntryPrice,
+			Source:       "exchange",
+			UpdatedAt:    now,
+		}
+		if err := s.repo.UpsertHolding(ctx, h); err != nil {
+			log.Printf("[持仓] 更新合约 %s %s 失败: %v", pair, leg.Side, err)
+			continue
+		}
+		if leg.Side == domain.PositionSideShort {
+			openShorts[pair] = true
+		}
+	}
+
+	shorts, err := s.repo.ListShortHoldings(ctx)
+	if err != nil {
+		log.Printf("[持仓] 查询空头持仓失败: %v", err)
+		return
+	}
+	for _, h := range shorts {
+		if openShorts[h.Pair] {
+			continue
+		}
+		h.Quantity, h.AvgPrice, h.TotalCost, h.UpdatedAt = 0, 0, 0, now
+		if err := s.repo.UpsertHolding(ctx, h); err != nil {
+			log.Printf("[持仓] 清零空头 %s 失败: %v", h.Pair, err)
+		}
+	}
+	log.Printf("[持仓] 合约持仓同步完成，共 %d 条（空头 %d 个币对）", len(legs), len(openShorts))
+}
+
+// liveHoldingSorts 依赖实时行情的持仓排序字段：取全部持仓的实时行情后在内存中排序再分页
+var liveHoldingSorts = map[string]bool{"pnl": true, "market_value": true}
+
+// GetHoldings 分页获取持仓列表，附带实时行情
+func (s *Service) GetHoldings(ctx context.Context, q domain.ListQuery) ([]domain.HoldingView, int, error) {
+	if liveHoldingSorts[q.Sort] {
+		return s.getHoldingsByLiveValue(ctx, q)
+	}
+
+	holdings, err := s.repo.ListHoldingsPage(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.CountHoldings(ctx)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	views := make([]domain.HoldingView, 0, len(holdings))
 	for _, h := range holdings {
-		view := domain.HoldingView{Holding: h}
-
-		// 获取实时价格
-		symbol := strings.Replace(h.Pair, "/", "", 1)
-		price, pErr := s.fetchTickerPrice(ctx, symbol)
-		if pErr == nil && price > 0 {
-			view.CurrentPrice = price
-			view.MarketValue = h.Quantity * price
-			view.UnrealizedPnL = view.MarketValue - h.TotalCost
-			if h.TotalCost > 0 {
-				view.PnLPercent = (view.UnrealizedPnL / h.TotalCost) * 100
+		views = append(views, s.holdingView(ctx, h))
+	}
+	return views, total, nil
+}
+
+// getHoldingsByLiveValue 按实时盈亏 / 市值排序分页：合约持仓使用标记价与交易所持仓的未实现盈亏，
+// 避免按库中可能过期的 last_price 与现货公式排序
+func (s *Service) getHoldingsByLiveValue(ctx context.Context, q domain.ListQuery) ([]domain.HoldingView, int, error) {
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	views := make([]domain.HoldingView, 0, len(holdings))
+	for _, h := range holdings {
+		views = append(views, s.holdingView(ctx, h))
+	}
+
+	key := func(v domain.HoldingView) float64 {
+		if q.Sort == "market_value" {
+			return v.MarketValue
+		}
+		return v.UnrealizedPnL
+	}
+	sort.SliceStable(views, func(i, j int) bool {
+		a, b := key(views[i]), key(views[j])
+		if a == b {
+			return views[i].ID < views[j].ID
+		}
+		if q.Desc {
+			return a > b
+		}
+		return a < b
+	})
+
+	if q.PageSize <= 0 {
+		q.PageSize = 50
+	}
+	total := len(views)
+	from := min(q.Offset(), total)
+	to := min(from+q.PageSize, total)
+	return views[from:to], total, nil
+}
+
+// holdingView 组装单个持仓的实时行情视图，并回写最近市价
+func (s *Service) holdingView(ctx context.Context, h domain.Holding) domain.HoldingView {
+	view := domain.HoldingView{Holding: h, Mode: "spot"}
+
+	// 获取实时价格
+	price, pErr := s.fetchTickerPrice(ctx, h.Pair)
+
+	// 合约持仓：展示开仓价、标记价、强平价、保证金与杠杆，盈亏按保证金收益率计算
+	if executor := execution.ForPair(s.executor, h.Pair); executor.TradingMode() == "futures" {
+		view.Mode = "futures"
+		view.Futures = s.futuresPositionDetail(ctx, executor, h, price)
+		s.applyFunding(ctx, h, view.Futures)
+		view.CurrentPrice = view.Futures.MarkPrice
+		view.MarketValue = view.Futures.Notional
+		view.UnrealizedPnL = view.Futures.UnrealizedPnL
+		view.PnLPercent = view.Futures.ROE
+		if view.CurrentPrice > 0 {
+			view.LastPrice = view.CurrentPrice
+			if uErr := s.updateHoldingPrice(ctx, h.Pair, view.CurrentPrice); uErr != nil {
+				log.Printf("[持仓] ⚠ 更新 %s 市价失败: %v", h.Pair, uErr)
 			}
 		}
-		views = append(views, view)
+		return view
 	}
-	return views, nil
+
+	if pErr == nil && price > 0 {
+		view.CurrentPrice = price
+		view.LastPrice = price
+		view.MarketValue = h.Quantity * price
+		// 记录最近市价，供汇总市值使用
+		if uErr := s.updateHoldingPrice(ctx, h.Pair, price); uErr != nil {
+			log.Printf("[持仓] ⚠ 更新 %s 市价失败: %v", h.Pair, uErr)
+		}
+		view.UnrealizedPnL = view.MarketValue - h.TotalCost
+		if h.TotalCost > 0 {
+			view.PnLPercent = (view.UnrealizedPnL / h.TotalCost) * 100
+		}
+	}
+	return view
+}
+
+// updateHoldingPrice 回写持仓最新价，只读实例跳过
+func (s *Service) updateHoldingPrice(ctx context.Context, pair string, price float64) error {
+	if s.readOnly {
+		return nil
+	}
+	return s.repo.UpdateHoldingPrice(ctx, pair, price)
+}
+
+// SetReadOnly 只读实例：查询接口不回写数据库（写接口由 HTTP 层拒绝）
+func (s *Service) SetReadOnly(readOnly bool) {
+	s.readOnly = readOnly
+}
+
+// SumHoldings 汇总全部持仓的成本与市值
+func (s *Service) SumHoldings(ctx context.Context) (totalCost, totalValue float64, err error) {
+	return s.repo.SumHoldings(ctx)
+}
+
+// GetShortHoldings 获取从交易所同步的合约空头持仓（不参与本系统的下单与风控）
+func (s *Service) GetShortHoldings(ctx context.Context) ([]domain.Holding, error) {
+	return s.repo.ListShortHoldings(ctx)
 }
 
 // UpdateHoldingAfterTrade 交易成功后更新持仓
@@ -580,88 +1348,150 @@ func (s *Service) UpdateHoldingAfterTrade(ctx context.Context, order domain.Orde
 		return
 	}
 
-	// 从 DB 获取现有持仓
-	holdings, _ := s.repo.ListHoldings(ctx)
-	var existing *domain.Holding
+	// 重试队列中有未写入的订单 / 持仓时先尝试写入，模拟盘结算与平仓归因读取到最新数据
+	if s.outboxPending() > 0 {
+		s.FlushOutbox(ctx)
+	}
+
+	// 模拟盘按成交前的持仓结算钱包
+	existing, _ := s.findHolding(ctx, order.Pair)
+	s.applyPaperFill(ctx, order, existing)
+
+	if order.Side == domain.SideClose {
+		// 平仓归因：记录本次卖出对应的开仓批次
+		s.recordCloseOrigins(ctx, order)
+	}
+	s.persistHoldingFill(ctx, order)
+}
+
+// findHolding 查询单个币对的持仓，没有时返回 nil
+func (s *Service) findHolding(ctx context.Context, pair string) (*domain.Holding, error) {
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return nil, err
+	}
 	for i, h := range holdings {
-		if h.Pair == order.Pair {
-			existing = &holdings[i]
-			break
+		if h.Pair == pair {
+			return &holdings[i], nil
 		}
 	}
+	return nil, nil
+}
+
+// applyFillToHolding 读取当前持仓并叠加一笔成交后写回；读取失败时返回错误，由写库重试队列稍后重放
+func (s *Service) applyFillToHolding(ctx context.Context, order domain.Order) error {
+	existing, err := s.findHolding(ctx, order.Pair)
+	if err != nil {
+		return err
+	}
 
 	now := time.Now().UTC()
 	symbol := strings.Split(order.Pair, "/")[0]
 
 	if order.Side == domain.SideLong {
 		// 买入：增加持仓
+		h := domain.Holding{
+			Pair:      order.Pair,
+			Symbol:    symbol,
+			Quantity:  order.FilledQuantity,
+			AvgPrice:  order.FilledPrice,
+			TotalCost: order.FilledQuantity * order.FilledPrice,
+			Source:    "local",
+			UpdatedAt: now,
+		}
 		if existing != nil {
-			newQty := existing.Quantity + order.FilledQuantity
-			newCost := existing.TotalCost + (order.FilledQuantity * order.FilledPrice)
-			_ = s.repo.UpsertHolding(ctx, domain.Holding{
-				Pair:      order.Pair,
-				Symbol:    symbol,
-				Quantity:  newQty,
-				AvgPrice:  newCost / newQty,
-				TotalCost: newCost,
-				Source:    "local",
-				UpdatedAt: now,
-			})
-		} else {
-			_ = s.repo.UpsertHolding(ctx, domain.Holding{
-				Pair:      order.Pair,
-				Symbol:    symbol,
-				Quantity:  order.FilledQuantity,
-				AvgPrice:  order.FilledPrice,
-				TotalCost: order.FilledQuantity * order.FilledPrice,
-				Source:    "local",
-				UpdatedAt: now,
-			})
+			h.Quantity += existing.Quantity
+			h.TotalCost += existing.TotalCost
+			h.AvgPrice = h.TotalCost / h.Quantity
+		}
+		if err := s.repo.UpsertHolding(ctx, h); err != nil {
+			return err
 		}
 		log.Printf("[持仓] 买入更新 %s: +%.4f @ %.8f", order.Pair, order.FilledQuantity, order.FilledPrice)
-	} else if order.Side == domain.SideClose {
-		// 卖出：减少持仓
-		if existing != nil {
-			newQty := existing.Quantity - order.FilledQuantity
-			if newQty < 0 {
-				newQty = 0
-			}
-			ratio := order.FilledQuantity / existing.Quantity
-			if ratio > 1 {
-				ratio = 1
-			}
-			newCost := existing.TotalCost * (1 - ratio)
-			avgPrice := 0.0
-			if newQty > 0 {
-				avgPrice = newCost / newQty
-			}
-			_ = s.repo.UpsertHolding(ctx, domain.Holding{
-				Pair:      order.Pair,
-				Symbol:    symbol,
-				Quantity:  newQty,
-				AvgPrice:  avgPrice,
-				TotalCost: newCost,
-				Source:    "local",
-				UpdatedAt: now,
-			})
-			log.Printf("[持仓] 卖出更新 %s: -%.4f 剩余=%.4f", order.Pair, order.FilledQuantity, newQty)
+		return nil
+	}
+	if order.Side != domain.SideClose || existing == nil {
+		return nil
+	}
+
+	// 卖出：减少持仓
+	newQty := existing.Quantity - order.FilledQuantity
+	if newQty < 0 {
+		newQty = 0
+	}
+	ratio := order.FilledQuantity / existing.Quantity
+	if ratio > 1 {
+		ratio = 1
+	}
+	newCost := existing.TotalCost * (1 - ratio)
+	// 先进先出：按订单历史回放批次得到剩余成本，历史与持仓对不上时沿用平均成本
+	if costbasis.Current() == costbasis.FIFO {
+		if cost, ok := s.fifoRemainingCost(ctx, order.Pair, newQty); ok {
+			newCost = cost
 		}
 	}
+	avgPrice := 0.0
+	if newQty > 0 {
+		avgPrice = newCost / newQty
+	}
+	if err := s.repo.UpsertHolding(ctx, domain.Holding{
+		Pair:      order.Pair,
+		Symbol:    symbol,
+		Quantity:  newQty,
+		AvgPrice:  avgPrice,
+		TotalCost: newCost,
+		Source:    "local",
+		UpdatedAt: now,
+	}); err != nil {
+		return err
+	}
+	log.Printf("[持仓] 卖出更新 %s: -%.4f 剩余=%.4f", order.Pair, order.FilledQuantity, newQty)
+	if newQty <= 0 {
+		// 清仓后移动止损重新从下一次开仓开始跟踪
+		_ = s.repo.DeleteTrailingStop(ctx, order.Pair)
+	}
+	return nil
+}
+
+// fifoRemainingCost 按先进先出回放该币对的订单，返回剩余持仓成本；
+// 回放数量与 expectedQty 不一致（如持仓来自交易所同步）时返回 false
+func (s *Service) fifoRemainingCost(ctx context.Context, pair string, expectedQty float64) (float64, bool) {
+	orders, err := s.repo.ListFilledOrders(ctx)
+	if err != nil {
+		return 0, false
+	}
+	book := costbasis.NewBook(costbasis.FIFO)
+	for _, o := range orders {
+		if o.Pair != pair {
+			continue
+		}
+		switch o.Side {
+		case domain.SideLong:
+			book.Buy(pair, o.FilledQuantity, o.FilledPrice)
+		case domain.SideClose:
+			book.Sell(pair, o.FilledQuantity, o.FilledPrice)
+		}
+	}
+	qty, cost := book.Position(pair)
+	if math.Abs(qty-expectedQty) > 1e-8*math.Max(1, expectedQty) {
+		return 0, false
+	}
+	return cost, true
 }
 
-// fetchTickerPrice 从 Binance 获取当前价格
 // fetchAccountDataForPrompt 获取真实余额和持仓数据，用于填充 AI 提示词
 func (s *Service) fetchAccountDataForPrompt(ctx context.Context, pair string) (float64, []market.PositionData) {
 	var usdtBalance float64
+	executor := execution.ForPair(s.executor, pair)
 
 	// 1. 获取 USDT 余额
-	balances, err := s.executor.FetchFullBalance(ctx)
+	balances, err := s.fetchBalances(ctx, executor)
 	if err != nil {
 		log.Printf("[账户] ⚠ 获取余额失败: %v，使用默认值 0", err)
 	} else {
 		for _, b := range balances {
 			if b.Symbol == "USDT" {
-				usdtBalance = b.Free
+				usdtBalance = s.spendableUSDT(b)
 				break
 			}
 		}
@@ -670,22 +1500,40 @@ func (s *Service) fetchAccountDataForPrompt(ctx context.Context, pair string) (f
 	// 2. 获取当前持仓
 	var positions []market.PositionData
 
-	// 合约实盘模式：优先从 positionRisk API 获取
-	if s.executor.TradingMode() == "futures" && !s.executor.IsDryRun() {
-		posAmt, pErr := s.executor.FetchPositionRisk(ctx, pair)
+	// 合约实盘模式：从 positionRisk API 获取，未实现盈亏使用交易所的 unRealizedProfit
+	if executor.TradingMode() == "futures" && !executor.IsDryRun() {
+		posAmt, pErr := executor.FetchPositionRisk(ctx, pair)
 		if pErr == nil && posAmt > 0 {
-			sym := strings.Replace(pair, "/", "", 1)
-			currentPrice, _ := s.fetchTickerPrice(ctx, sym)
-			leverage := s.executor.Leverage()
-			positions = append(positions, market.PositionData{
-				Symbol:        pair,
-				Side:          "LONG",
-				Quantity:      fmt.Sprintf("%.4f", posAmt),
-				EntryPrice:    "N/A",
-				CurrentPrice:  fmt.Sprintf("%.6f", currentPrice),
-				UnrealizedPnl: fmt.Sprintf("x%d leverage", leverage),
-				Leverage:      fmt.Sprintf("%d", leverage),
-			})
+			pos := market.PositionData{
+				Symbol:   pair,
+				Side:     "LONG",
+				Quantity: fmt.Sprintf("%.4f", posAmt),
+				Leverage: fmt.Sprintf("%d", executor.Leverage()),
+			}
+			var detail *domain.FuturesPosition
+			if d, ok := executor.(execution.PositionDetailer); ok {
+				var dErr error
+				if detail, dErr = d.FetchPositionDetail(ctx, pair); dErr != nil {
+					log.Printf("[账户] ⚠ 获取合约持仓详情失败: %v", dErr)
+				}
+			}
+			if detail != nil {
+				pos.EntryPrice = fmt.Sprintf("%.6f", detail.EntryPrice)
+				pos.CurrentPrice = fmt.Sprintf("%.6f", detail.MarkPrice)
+				pos.UnrealizedPnl = fmt.Sprintf("%.4f USDT (ROE %.2f%%)", detail.UnrealizedPnL, detail.ROE)
+				pos.Leverage = fmt.Sprintf("%d", detail.Leverage)
+				if detail.LiquidationPrice > 0 {
+					pos.LiquidationPrice = fmt.Sprintf("%.6f", detail.LiquidationPrice)
+				}
+				s.applyFunding(ctx, domain.Holding{Pair: pair, Quantity: posAmt, AvgPrice: detail.EntryPrice}, detail)
+				pos.FundingCost = formatFunding(detail)
+			} else {
+				currentPrice, _ := s.fetchTickerPrice(ctx, pair)
+				pos.EntryPrice = "N/A"
+				pos.CurrentPrice = fmt.Sprintf("%.6f", currentPrice)
+				pos.UnrealizedPnl = "N/A"
+			}
+			positions = append(positions, pos)
 		}
 	} else {
 		// 现货模式或 dry-run：从本地 holdings 表获取
@@ -698,8 +1546,7 @@ func (s *Service) fetchAccountDataForPrompt(ctx context.Context, pair string) (f
 			if h.Quantity <= 0 {
 				continue
 			}
-			sym := strings.Replace(h.Pair, "/", "", 1)
-			currentPrice, pErr := s.fetchTickerPrice(ctx, sym)
+			currentPrice, pErr := s.fetchTickerPrice(ctx, h.Pair)
 			if pErr != nil {
 				currentPrice = h.AvgPrice
 			}
@@ -717,8 +1564,8 @@ func (s *Service) fetchAccountDataForPrompt(ctx context.Context, pair string) (f
 				pnlPct = (unrealizedPnL / h.TotalCost) * 100
 			}
 
-			leverage := fmt.Sprintf("%d", s.executor.Leverage())
-			positions = append(positions, market.PositionData{
+			leverage := fmt.Sprintf("%d", executor.Leverage())
+			pos := market.PositionData{
 				Symbol:        h.Pair,
 				Side:          "LONG",
 				Quantity:      fmt.Sprintf("%.4f", h.Quantity),
@@ -726,68 +1573,36 @@ func (s *Service) fetchAccountDataForPrompt(ctx context.Context, pair string) (f
 				CurrentPrice:  fmt.Sprintf("%.6f", currentPrice),
 				UnrealizedPnl: fmt.Sprintf("%.4f USDT (%.2f%%)", unrealizedPnL, pnlPct),
 				Leverage:      leverage,
-			})
+			}
+			// 合约模拟盘同样按公开费率历史估算开仓以来的资金费
+			if execution.ForPair(s.executor, h.Pair).TradingMode() == "futures" {
+				var d domain.FuturesPosition
+				s.applyFunding(ctx, h, &d)
+				pos.FundingCost = formatFunding(&d)
+			}
+			positions = append(positions, pos)
 		}
 	}
 
 	return usdtBalance, positions
 }
 
-func (s *Service) fetchTickerPrice(ctx context.Context, symbol string) (float64, error) {
-	apiURL := fmt.Sprintf("https://api.binance.com/api/v3/ticker/price?symbol=%s", symbol)
-	client := &http.Client{Timeout: 5 * time.Second}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
-	if err != nil {
-		return 0, err
-	}
-	resp, err := client.Do(req)
+// fetchTickerPrice 从当前交易所获取交易对最新价格
+func (s *Service) fetchTickerPrice(ctx context.Context, pair string) (float64, error) {
+	t, err := execution.FetchTicker(ctx, s.executor, pair)
 	if err != nil {
 		return 0, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return 0, fmt.Errorf("HTTP %d", resp.StatusCode)
-	}
-
-	var result struct {
-		Price string `json:"price"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, err
-	}
-	p, _ := strconv.ParseFloat(result.Price, 64)
-	return p, nil
+	return t.Price, nil
 }
 
-// fetchQuickTicker 快速从 Binance 获取 24h 价格和涨跌幅（轻量级，不含 K 线）
-func fetchQuickTicker(ctx context.Context, pair string) (price, change float64, err error) {
-	symbol := strings.ReplaceAll(strings.ToUpper(pair), "/", "")
-	url := fmt.Sprintf("https://api.binance.com/api/v3/ticker/24hr?symbol=%s", symbol)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return 0, 0, err
-	}
-
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
+// fetchQuickTicker 通过交易对对应的交易所快速获取 24h 价格和涨跌幅（轻量级，不含 K 线）
+func (s *Service) fetchQuickTicker(ctx context.Context, pair string) (price, change float64, err error) {
+	t, err := execution.FetchTicker(ctx, s.executor, pair)
 	if err != nil {
 		return 0, 0, err
 	}
-	defer resp.Body.Close()
-
-	var ticker struct {
-		LastPrice          string `json:"lastPrice"`
-		PriceChangePercent string `json:"priceChangePercent"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&ticker); err != nil {
-		return 0, 0, err
-	}
-
-	price, _ = strconv.ParseFloat(ticker.LastPrice, 64)
-	change, _ = strconv.ParseFloat(ticker.PriceChangePercent, 64)
-	return price, change, nil
+	return t.Price, t.Change24hPct, nil
 }
 
 func fallbackSnapshot(pair string, in *domain.MarketSnapshot) domain.MarketSnapshot {
@@ -811,3 +1626,16 @@ func fallbackSnapshot(pair string, in *domain.MarketSnapshot) domain.MarketSnaps
 	}
 	return copy
 }
+
+// SetKeyValidator 设置交易所 API Key 校验器
+func (s *Service) SetKeyValidator(v *execution.KeyValidator) {
+	s.keys = v
+}
+
+// ValidateExchangeKeys 校验交易所 API Key 是否可用、权限是否安全
+func (s *Service) ValidateExchangeKeys(ctx context.Context) (execution.KeyCheck, error) {
+	if s.keys == nil {
+		return execution.KeyCheck{}, fmt.Errorf("未配置 API Key 校验")
+	}
+	return s.keys.Validate(ctx), nil
+}
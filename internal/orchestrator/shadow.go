@@ -0,0 +1,125 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// RunShadowCycle 对只模拟的交易对跑一次预览周期，并把假设交易保存下来供事后评估
+func (s *Service) RunShadowCycle(ctx context.Context, pair string) (domain.ShadowCycle, error) {
+	preview, err := s.PreviewCycle(ctx, RunRequest{Pair: pair})
+	if err != nil {
+		return domain.ShadowCycle{}, err
+	}
+	raw, err := json.Marshal(preview)
+	if err != nil {
+		return domain.ShadowCycle{}, fmt.Errorf("序列化预览结果: %w", err)
+	}
+
+	sc := domain.ShadowCycle{
+		ID:           preview.PreviewID,
+		Pair:         preview.Pair,
+		Side:         preview.Signal.Side,
+		Confidence:   preview.Signal.Confidence,
+		Approved:     preview.Risk.Approved,
+		RejectReason: preview.Risk.RejectReason,
+		EntryPrice:   preview.Snapshot.LastPrice,
+		Reason:       preview.Signal.Reason,
+		Preview:      raw,
+		CreatedAt:    preview.CreatedAt,
+	}
+	if preview.Order != nil {
+		sc.StakeUSDT = preview.Order.StakeUSDT
+		sc.Leverage = preview.Order.Leverage
+	}
+	if preview.Position != nil {
+		sc.TakeProfitPercent = preview.Position.TakeProfitPercent
+		sc.StopLossPercent = preview.Position.StopLossPercent
+	}
+	if err := s.repo.InsertShadowCycle(ctx, sc); err != nil {
+		return domain.ShadowCycle{}, err
+	}
+	return sc, nil
+}
+
+// EvaluateShadowCycles 对超过观察期的影子周期按当前价格计算假设盈亏
+func (s *Service) EvaluateShadowCycles(ctx context.Context, horizon time.Duration) error {
+	pending, err := s.repo.ListUnevaluatedShadowCycles(ctx, time.Now().Add(-horizon))
+	if err != nil {
+		return err
+	}
+
+	prices := make(map[string]float64)
+	for _, sc := range pending {
+		if sc.EntryPrice <= 0 {
+			// 当时没有拿到价格，无法评估，记为 0 收益避免反复处理
+			_ = s.repo.UpdateShadowOutcome(ctx, sc.ID, 0, 0, 0, time.Now())
+			continue
+		}
+		price, ok := prices[sc.Pair]
+		if !ok {
+			p, _, err := s.fetchQuickTicker(ctx, sc.Pair)
+			if err != nil {
+				log.Printf("[影子] ⚠ %s 获取价格失败: %v，下次再评估", sc.Pair, err)
+				continue
+			}
+			price = p
+			prices[sc.Pair] = p
+		}
+
+		changePct := (price - sc.EntryPrice) / sc.EntryPrice * 100
+		pnl := shadowPnL(sc, changePct)
+		if err := s.repo.UpdateShadowOutcome(ctx, sc.ID, price, changePct, pnl, time.Now()); err != nil {
+			return err
+		}
+		log.Printf("[影子] %s %s 信号=%s 入场=%.6f 现价=%.6f 涨跌=%.2f%% 假设盈亏=%.2f USDT",
+			sc.ID[:8], sc.Pair, sc.Side, sc.EntryPrice, price, changePct, pnl)
+	}
+	return nil
+}
+
+// shadowPnL 假设盈亏：开多按涨跌计算（含杠杆），平仓按规避的涨跌计算；
+// 只看观察期末价格，开多收益按止盈止损比例封顶（近似）
+func shadowPnL(sc domain.ShadowCycle, changePct float64) float64 {
+	if !sc.Approved || sc.StakeUSDT <= 0 {
+		return 0
+	}
+	pct := changePct
+	if sc.TakeProfitPercent > 0 && pct > sc.TakeProfitPercent {
+		pct = sc.TakeProfitPercent
+	}
+	if sc.StopLossPercent > 0 && pct < -sc.StopLossPercent {
+		pct = -sc.StopLossPercent
+	}
+	lev := float64(sc.Leverage)
+	if lev < 1 {
+		lev = 1
+	}
+	switch sc.Side {
+	case domain.SideLong:
+		return sc.StakeUSDT * pct / 100 * lev
+	case domain.SideClose:
+		return -sc.StakeUSDT * changePct / 100
+	}
+	return 0
+}
+
+// ListShadowCycles 分页查询影子周期
+func (s *Service) ListShadowCycles(ctx context.Context, pair string, q domain.ListQuery) ([]domain.ShadowCycle, int, error) {
+	return s.repo.ListShadowCycles(ctx, pair, q)
+}
+
+// GetShadowCycle 获取影子周期详情（含完整预览）
+func (s *Service) GetShadowCycle(ctx context.Context, id string) (*domain.ShadowCycle, error) {
+	return s.repo.GetShadowCycle(ctx, id)
+}
+
+// ShadowStats 按交易对汇总影子周期表现
+func (s *Service) ShadowStats(ctx context.Context) ([]domain.ShadowStats, error) {
+	return s.repo.ShadowStats(ctx)
+}
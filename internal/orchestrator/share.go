@@ -0,0 +1,212 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai_quant/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultShareTTLHours = 7 * 24
+	maxShareTTLHours     = 90 * 24
+	sharedEquityDays     = 90 // 收益率曲线的时间范围
+	sharedCycleCount     = 20 // 最近周期条数
+)
+
+var (
+	// ErrShareNotFound 分享链接不存在、已撤销或已过期（对外不区分，避免探测令牌）
+	ErrShareNotFound = errors.New("分享链接无效或已过期")
+)
+
+// shareDashboards 可分享的看板
+var shareDashboards = map[string]bool{
+	domain.ShareEquity:   true,
+	domain.ShareCycles:   true,
+	domain.ShareHoldings: true,
+}
+
+// CreateShareLinkRequest 创建分享链接的参数
+type CreateShareLinkRequest struct {
+	Name       string   `json:"name"`
+	Dashboards []string `json:"dashboards"` // equity / cycles / holdings，为空 = 全部
+	TTLHours   int      `json:"ttl_hours"`  // 有效期，默认 7 天，最长 90 天
+}
+
+// CreateShareLink 创建只读分享链接，返回的令牌只出现这一次
+func (s *Service) CreateShareLink(ctx context.Context, req CreateShareLinkRequest) (domain.ShareLink, string, error) {
+	dashboards := make([]string, 0, len(req.Dashboards))
+	seen := make(map[string]bool)
+	for _, d := range req.Dashboards {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == "" || seen[d] {
+			continue
+		}
+		if !shareDashboards[d] {
+			return domain.ShareLink{}, "", fmt.Errorf("不支持分享的看板: %s（可选 equity / cycles / holdings）", d)
+		}
+		seen[d] = true
+		dashboards = append(dashboards, d)
+	}
+	if len(dashboards) == 0 {
+		dashboards = []string{domain.ShareEquity, domain.ShareCycles, domain.ShareHoldings}
+	}
+	if req.TTLHours < 0 || req.TTLHours > maxShareTTLHours {
+		return domain.ShareLink{}, "", fmt.Errorf("有效期需在 1-%d 小时之间", maxShareTTLHours)
+	}
+	if req.TTLHours == 0 {
+		req.TTLHours = defaultShareTTLHours
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return domain.ShareLink{}, "", fmt.Errorf("生成令牌失败: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	now := time.Now().UTC()
+	link := domain.ShareLink{
+		ID:         uuid.NewString(),
+		Name:       strings.TrimSpace(req.Name),
+		Dashboards: dashboards,
+		TokenHash:  shareTokenHash(token),
+		ExpiresAt:  now.Add(time.Duration(req.TTLHours) * time.Hour),
+		CreatedAt:  now,
+	}
+	if err := s.repo.InsertShareLink(ctx, link); err != nil {
+		return domain.ShareLink{}, "", err
+	}
+	log.Printf("[分享] 已创建分享链接 %s 看板=%s 有效期至 %s", link.ID[:8], strings.Join(dashboards, ","), link.ExpiresAt.Format(time.RFC3339))
+	return link, token, nil
+}
+
+// ListShareLinks 全部分享链接（不含令牌）
+func (s *Service) ListShareLinks(ctx context.Context) ([]domain.ShareLink, error) {
+	return s.repo.ListShareLinks(ctx)
+}
+
+// RevokeShareLink 撤销分享链接，立即失效
+func (s *Service) RevokeShareLink(ctx context.Context, id string) error {
+	ok, err := s.repo.RevokeShareLink(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrShareNotFound
+	}
+	log.Printf("[分享] 已撤销分享链接 %s", id)
+	return nil
+}
+
+// SharedDashboard 按令牌返回分享的只读看板：只包含比例与决策，不暴露金额、数量和余额
+func (s *Service) SharedDashboard(ctx context.Context, token string) (domain.SharedDashboard, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return domain.SharedDashboard{}, ErrShareNotFound
+	}
+	link, err := s.repo.GetShareLinkByToken(ctx, shareTokenHash(token))
+	if err != nil {
+		return domain.SharedDashboard{}, err
+	}
+	if link == nil || link.RevokedAt != nil || time.Now().After(link.ExpiresAt) {
+		return domain.SharedDashboard{}, ErrShareNotFound
+	}
+
+	out := domain.SharedDashboard{Name: link.Name, ExpiresAt: link.ExpiresAt}
+	for _, d := range link.Dashboards {
+		switch d {
+		case domain.ShareEquity:
+			out.Equity, err = s.sharedEquity(ctx)
+		case domain.ShareCycles:
+			out.Cycles, err = s.sharedCycles(ctx)
+		case domain.ShareHoldings:
+			out.Holdings, err = s.sharedHoldings(ctx)
+		}
+		if err != nil {
+			return domain.SharedDashboard{}, err
+		}
+	}
+	return out, nil
+}
+
+// sharedEquity 收益率曲线：以区间内首个权益快照为基准的累计收益率
+func (s *Service) sharedEquity(ctx context.Context) ([]domain.SharedEquityPoint, error) {
+	snaps, err := s.repo.ListEquitySnapshots(ctx, time.Now().UTC().AddDate(0, 0, -sharedEquityDays), 500)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]domain.SharedEquityPoint, 0, len(snaps))
+	var base float64
+	for _, e := range snaps {
+		if base <= 0 {
+			base = e.EquityUSDT
+		}
+		if base <= 0 {
+			continue
+		}
+		points = append(points, domain.SharedEquityPoint{
+			Time:      e.CreatedAt,
+			ReturnPct: round2((e.EquityUSDT/base - 1) * 100),
+		})
+	}
+	return points, nil
+}
+
+// sharedCycles 最近周期的决策摘要
+func (s *Service) sharedCycles(ctx context.Context) ([]domain.SharedCycle, error) {
+	cycles, err := s.repo.ListCycles(ctx, 1, sharedCycleCount, "")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]domain.SharedCycle, 0, len(cycles))
+	for _, c := range cycles {
+		out = append(out, domain.SharedCycle{
+			Pair:       c.Pair,
+			Status:     c.Status,
+			SignalSide: c.SignalSide,
+			Confidence: c.Confidence,
+			CreatedAt:  c.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+// sharedHoldings 持仓交易对、盈亏比例与仓位占比
+func (s *Service) sharedHoldings(ctx context.Context) ([]domain.SharedHolding, error) {
+	views, _, err := s.GetHoldings(ctx, domain.ListQuery{Page: 1, PageSize: 100})
+	if err != nil {
+		return nil, err
+	}
+	var total float64
+	values := make([]float64, len(views))
+	for i, v := range views {
+		values[i] = v.MarketValue
+		if v.Futures != nil {
+			values[i] = v.Futures.Notional
+		}
+		total += values[i]
+	}
+	out := make([]domain.SharedHolding, 0, len(views))
+	for i, v := range views {
+		h := domain.SharedHolding{Pair: v.Pair, Mode: v.Mode, PnLPercent: round2(v.PnLPercent)}
+		if total > 0 {
+			h.WeightPct = round2(values[i] / total * 100)
+		}
+		out = append(out, h)
+	}
+	return out, nil
+}
+
+func shareTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ai_quant/internal/agent/position"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/stages"
+
+	"github.com/google/uuid"
+)
+
+// PipelineStages 流水线各环节的启用状态
+func (s *Service) PipelineStages() map[stages.Stage]bool {
+	return stages.Snapshot()
+}
+
+// SetPipelineStage 运行时启用 / 关闭流水线环节，从下一个周期开始生效
+func (s *Service) SetPipelineStage(name string, enabled bool) (map[stages.Stage]bool, error) {
+	if err := stages.Set(name, enabled); err != nil {
+		return nil, err
+	}
+	action := "关闭"
+	if enabled {
+		action = "启用"
+	}
+	log.Printf("[流水线] 已%s环节 %s", action, name)
+	return stages.Snapshot(), nil
+}
+
+// generatePosition 生成建仓策略；建仓策略环节关闭时按风控金额一次性建仓，止盈止损取风险预设
+func generatePosition(ctx context.Context, agent position.Agent, in position.Input) (domain.PositionStrategy, error) {
+	if stages.Enabled(stages.PositionStrategy) {
+		return agent.Generate(ctx, in)
+	}
+	return singleEntryPosition(in, "建仓策略环节已关闭，按风控金额一次性建仓"), nil
+}
+
+// singleEntryPosition 按 MaxStakeUSDT 一次性建仓的建仓策略（平仓时没有批次）
+func singleEntryPosition(in position.Input, reason string) domain.PositionStrategy {
+	ps := domain.PositionStrategy{
+		ID:                "ps_" + uuid.NewString(),
+		CycleID:           in.CycleID,
+		SignalID:          in.SignalID,
+		Pair:              in.Pair,
+		Side:              in.Side,
+		Strategy:          domain.StrategyFull,
+		EntryLevels:       1,
+		Batches:           []domain.PositionBatch{},
+		TakeProfitPercent: in.TakeProfitPercent,
+		StopLossPercent:   in.StopLossPercent,
+		Reason:            reason,
+		CreatedAt:         time.Now().UTC(),
+	}
+	if in.Side == domain.SideLong {
+		ps.TotalAmount = in.MaxStakeUSDT
+		ps.Batches = append(ps.Batches, domain.PositionBatch{
+			BatchNo:      1,
+			TriggerPrice: in.CurrentPrice,
+			Amount:       in.MaxStakeUSDT,
+			Percentage:   100,
+			Status:       domain.BatchPending,
+		})
+	}
+	return ps
+}
@@ -0,0 +1,56 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"ai_quant/internal/domain"
+	"ai_quant/internal/market"
+)
+
+// 状态页展示范围
+const (
+	statusEquityDays   = 7
+	statusEquityPoints = 300
+	statusCycleCount   = 10
+)
+
+// StatusPage 手机快速查看用的状态页数据：近 7 天权益、最近周期、数据源健康与大模型花费
+type StatusPage struct {
+	Equity      []domain.EquitySnapshot
+	EquityUSDT  float64 // 最近一次快照的权益，无快照时为 0
+	EquityDelta float64 // 区间内权益变化百分比
+	Cycles      []domain.CycleSummary
+	Sources     []market.SourceStatus
+	Costs       domain.CostSummary
+	Halted      bool // 回撤熔断中
+	GeneratedAt time.Time
+}
+
+// StatusPage 汇总状态页数据；大模型花费只统计今日与本月
+func (s *Service) StatusPage(ctx context.Context) (StatusPage, error) {
+	page := StatusPage{Sources: s.DataSourceStatus(), GeneratedAt: time.Now()}
+
+	snaps, err := s.repo.ListEquitySnapshots(ctx, time.Now().UTC().AddDate(0, 0, -statusEquityDays), statusEquityPoints)
+	if err != nil {
+		return page, err
+	}
+	page.Equity = snaps
+	if n := len(snaps); n > 0 {
+		page.EquityUSDT = snaps[n-1].EquityUSDT
+		if first := snaps[0].EquityUSDT; first > 0 {
+			page.EquityDelta = round2((page.EquityUSDT/first - 1) * 100)
+		}
+	}
+
+	if page.Cycles, err = s.repo.ListCycles(ctx, 1, statusCycleCount, ""); err != nil {
+		return page, err
+	}
+	if page.Costs, err = s.CostSummary(ctx, 1); err != nil {
+		return page, err
+	}
+	s.drawdown.mu.Lock()
+	page.Halted = s.drawdown.halt.Halted
+	s.drawdown.mu.Unlock()
+	return page, nil
+}
@@ -0,0 +1,76 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"ai_quant/internal/domain"
+)
+
+// highVolatilityPct 24h 涨跌幅绝对值达到该百分比时打上 high-volatility 标签
+const highVolatilityPct = 8.0
+
+// newsKeywords 信号理由中出现这些词时视为事件驱动（news-driven）
+var newsKeywords = []string{
+	"新闻", "消息", "公告", "利好", "利空", "热搜", "推文", "马斯克",
+	"news", "announcement", "headline", "trending", "tweet", "elon",
+}
+
+// autoTags 根据行情、信号与风险提示生成自动标签
+func autoTags(snapshot domain.MarketSnapshot, sig domain.Signal, alerts []string) []string {
+	var tags []string
+	if math.Abs(snapshot.Change24h) >= highVolatilityPct {
+		tags = append(tags, domain.TagHighVolatility)
+	}
+	reason := strings.ToLower(sig.Reason)
+	for _, kw := range newsKeywords {
+		if strings.Contains(reason, kw) {
+			tags = append(tags, domain.TagNewsDriven)
+			break
+		}
+	}
+	if sig.ModelName == fundingGuardModel {
+		tags = append(tags, domain.TagFundingClose)
+	} else if len(alerts) > 0 {
+		tags = append(tags, domain.TagFundingAlert)
+	}
+	return tags
+}
+
+// AddCycleTags 手动给周期添加标签，返回周期当前全部标签
+func (s *Service) AddCycleTags(ctx context.Context, cycleID string, tags []string) ([]domain.CycleTag, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, t := range tags {
+		n, err := domain.NormalizeTag(t)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, n)
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("%w: 至少提供一个标签", domain.ErrInvalidTag)
+	}
+	if _, err := s.repo.GetCycleReport(ctx, cycleID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.AddCycleTags(ctx, cycleID, domain.TagSourceManual, normalized); err != nil {
+		return nil, err
+	}
+	return s.repo.ListCycleTags(ctx, cycleID)
+}
+
+// RemoveCycleTag 删除周期上的标签（自动标签同样可以删除）
+func (s *Service) RemoveCycleTag(ctx context.Context, cycleID, tag string) error {
+	n, err := domain.NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+	return s.repo.RemoveCycleTag(ctx, cycleID, n)
+}
+
+// TagStats 按标签汇总周期表现
+func (s *Service) TagStats(ctx context.Context) ([]domain.TagStats, error) {
+	return s.repo.TagStats(ctx)
+}
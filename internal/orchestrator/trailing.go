@@ -0,0 +1,212 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+	"ai_quant/internal/notify"
+)
+
+// ATRSource 按 K 线计算 ATR（market.Client 实现）
+type ATRSource interface {
+	FetchATR(ctx context.Context, pair, interval string, period int) (float64, error)
+}
+
+// TrailingStop 移动止损规则：止损价 = 最高价 - 回撤距离，回撤距离取百分比与 ATR 倍数中较大的一个
+type TrailingStop struct {
+	Percent     float64 // 从最高价回撤的百分比，0 = 不按百分比
+	ATRMult     float64 // ATR 倍数，0 = 不按 ATR
+	ATRInterval string  // ATR 使用的 K 线周期，如 1h
+	ATRPeriod   int     // ATR 周期
+}
+
+func (t TrailingStop) enabled() bool {
+	return t.Percent > 0 || t.ATRMult > 0
+}
+
+// SetTrailingStop 设置移动止损规则，atr 在按 ATR 计算回撤距离时使用
+func (s *Service) SetTrailingStop(t TrailingStop, atr ATRSource) {
+	if t.ATRPeriod <= 0 {
+		t.ATRPeriod = 14
+	}
+	if t.ATRInterval == "" {
+		t.ATRInterval = "1h"
+	}
+	s.trailing = t
+	s.atr = atr
+	if t.enabled() {
+		log.Printf("[移动止损] 已启用: 回撤 %.2f%% / %.1f×ATR(%s,%d)", t.Percent, t.ATRMult, t.ATRInterval, t.ATRPeriod)
+	}
+}
+
+// TrailingStopEnabled 是否配置了移动止损
+func (s *Service) TrailingStopEnabled() bool {
+	return s.trailing.enabled()
+}
+
+// ListTrailingStops 当前跟踪中的移动止损状态
+func (s *Service) ListTrailingStops(ctx context.Context) ([]domain.TrailingStop, error) {
+	return s.repo.ListTrailingStops(ctx)
+}
+
+// ProcessTrailingStops 更新每个多头持仓的最高价与止损价，价格回落到止损价时平仓。
+// 状态落库，重启后从上次的最高价继续跟踪
+func (s *Service) ProcessTrailingStops(ctx context.Context) error {
+	if !s.trailing.enabled() {
+		return nil
+	}
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return fmt.Errorf("查询持仓失败: %w", err)
+	}
+	states, err := s.repo.ListTrailingStops(ctx)
+	if err != nil {
+		return err
+	}
+	tracked := make(map[string]domain.TrailingStop, len(states))
+	for _, t := range states {
+		tracked[t.Pair] = t
+	}
+
+	held := make(map[string]bool, len(holdings))
+	for _, h := range holdings {
+		if h.Quantity <= 0 {
+			continue
+		}
+		held[h.Pair] = true
+
+		price, err := s.fetchTickerPrice(ctx, h.Pair)
+		if err != nil || price <= 0 {
+			log.Printf("[移动止损] ⚠ 获取 %s 价格失败: %v", h.Pair, err)
+			continue
+		}
+		st, ok := tracked[h.Pair]
+		if !ok {
+			st = domain.TrailingStop{Pair: h.Pair, HighWater: price, CreatedAt: time.Now().UTC()}
+		}
+		if price > st.HighWater {
+			st.HighWater = price
+		}
+		distance, err := s.trailingDistance(ctx, h.Pair, st.HighWater)
+		if err != nil {
+			// ATR 暂不可用时沿用上次的距离，从未计算过则跳过本轮
+			if st.Distance <= 0 {
+				log.Printf("[移动止损] ⚠ %s 计算回撤距离失败: %v", h.Pair, err)
+				continue
+			}
+			distance = st.Distance
+		}
+		st.Distance = distance
+		// 止损价只上移不下移
+		st.StopPrice = math.Max(st.StopPrice, st.HighWater-distance)
+		st.LastPrice = price
+		st.UpdatedAt = time.Now().UTC()
+		if err := s.repo.SaveTrailingStop(ctx, st); err != nil {
+			log.Printf("[移动止损] ⚠ 保存 %s 状态失败: %v", h.Pair, err)
+		}
+
+		if price > st.StopPrice {
+			continue
+		}
+		if err := s.triggerTrailingStop(ctx, h, st, price); err != nil {
+			log.Printf("[移动止损] ✘ %s 平仓失败: %v", h.Pair, err)
+			s.notifier.Send(notify.Event{
+				Kind:  notify.KindFailure,
+				Title: "移动止损平仓失败",
+				Text:  h.Pair + ": " + err.Error(),
+			})
+		}
+	}
+
+	// 持仓已清空的交易对不再跟踪
+	for pair := range tracked {
+		if !held[pair] {
+			_ = s.repo.DeleteTrailingStop(ctx, pair)
+		}
+	}
+	return nil
+}
+
+// trailingDistance 回撤距离：百分比与 ATR 倍数中取较大者，避免波动放大时被正常回调洗出
+func (s *Service) trailingDistance(ctx context.Context, pair string, high float64) (float64, error) {
+	distance := high * s.trailing.Percent / 100
+	if s.trailing.ATRMult > 0 && s.atr != nil {
+		atr, err := s.atr.FetchATR(ctx, pair, s.trailing.ATRInterval, s.trailing.ATRPeriod)
+		if err != nil {
+			if distance > 0 {
+				return distance, nil
+			}
+			return 0, err
+		}
+		distance = math.Max(distance, atr*s.trailing.ATRMult)
+	}
+	if distance <= 0 {
+		return 0, fmt.Errorf("回撤距离为 0")
+	}
+	return distance, nil
+}
+
+// triggerTrailingStop 价格跌破移动止损价：撤掉保护单后全部平仓
+func (s *Service) triggerTrailingStop(ctx context.Context, h domain.Holding, st domain.TrailingStop, price float64) error {
+	release, ok := s.tryLockPair(h.Pair)
+	if !ok {
+		// 周期执行中（可能正在平仓），下一轮按最新持仓再判断
+		return nil
+	}
+	defer release()
+
+	executor := execution.ForPair(s.executor, h.Pair)
+	log.Printf("[移动止损] 🔻 %s 价格 %.8f ≤ 止损价 %.8f（最高 %.8f），平仓 数量=%.8f",
+		h.Pair, price, st.StopPrice, st.HighWater, h.Quantity)
+
+	prevProtection, _ := s.repo.ListProtectiveOrders(ctx, h.Pair, domain.ProtectiveActive)
+	if _, err := s.cancelProtectiveOrders(ctx, h.Pair); err != nil {
+		return fmt.Errorf("撤销保护单失败: %w", err)
+	}
+
+	qty := h.Quantity
+	if executor.TradingMode() == "futures" && !executor.IsDryRun() {
+		if posAmt, err := executor.FetchPositionRisk(ctx, h.Pair); err == nil && posAmt > 0 {
+			qty = posAmt
+		}
+	}
+	ord, err := executor.Execute(ctx, execution.Input{
+		Pair:          h.Pair,
+		Side:          domain.SideClose,
+		StakeUSDT:     qty * price,
+		EstimatedFill: price,
+		SellQuantity:  qty,
+		ForceMarket:   true,
+	})
+	if ord.ID != "" {
+		s.persistOrder(ctx, ord)
+	}
+	if err != nil {
+		if _, rErr := s.restoreProtection(ctx, "", h.Pair, prevProtection); rErr != nil {
+			log.Printf("[移动止损] ⚠ %s 恢复保护单失败: %v", h.Pair, rErr)
+		}
+		return err
+	}
+	s.UpdateHoldingAfterTrade(ctx, ord)
+	s.cancelPairBatches(ctx, h.Pair, "移动止损平仓")
+	_ = s.repo.DeleteTrailingStop(ctx, h.Pair)
+
+	pnlPct := 0.0
+	if h.AvgPrice > 0 {
+		pnlPct = (ord.FilledPrice - h.AvgPrice) / h.AvgPrice * 100
+	}
+	msg := fmt.Sprintf("%s 移动止损平仓 成交价=%.8f 数量=%.8f 最高价=%.8f 止损价=%.8f 相对均价 %+.2f%%",
+		h.Pair, ord.FilledPrice, ord.FilledQuantity, st.HighWater, st.StopPrice, pnlPct)
+	log.Printf("[移动止损] ✔ %s", msg)
+	s.notifier.Send(notify.Event{
+		Kind:  notify.KindFill,
+		Title: "移动止损平仓",
+		Text:  msg,
+	})
+	return nil
+}
@@ -0,0 +1,189 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// TWAP 大额订单按时间拆分执行：名义金额达到 MinNotional 时拆成 Slices 笔，每隔 Interval 下一笔
+type TWAP struct {
+	MinNotional float64 // 触发拆单的名义金额（USDT），0 = 不拆单
+	Slices      int
+	Interval    time.Duration
+}
+
+func (t TWAP) enabled() bool {
+	return t.MinNotional > 0 && t.Slices > 1
+}
+
+// SetTWAP 设置大额订单拆分执行规则
+func (s *Service) SetTWAP(t TWAP) {
+	if t.Interval <= 0 {
+		t.Interval = 30 * time.Second
+	}
+	s.twap = t
+	if t.enabled() {
+		log.Printf("[拆单] 已启用: 名义金额 ≥ %.2f USDT 时拆成 %d 笔，间隔 %s", t.MinNotional, t.Slices, t.Interval)
+	}
+}
+
+// placeOrder 周期下单：按执行方式（见 routeOrder）直接下单并落库，或按 TWAP 拆分执行。
+// 返回 twap=true 时子单已逐笔计入持仓，调用方不再更新持仓
+func (s *Service) placeOrder(ctx context.Context, executor execution.Executor, in execution.Input) (ord domain.Order, twap bool, err error) {
+	notional := in.StakeUSDT
+	if in.Side == domain.SideClose {
+		notional = in.SellQuantity * domain.NormalizeCloseFraction(in.CloseFraction) * in.EstimatedFill
+	}
+	if !s.routeOrder(&in, notional) {
+		ord, err = executor.Execute(ctx, in)
+		if ord.ID != "" {
+			s.persistOrder(ctx, ord)
+		}
+		return ord, false, err
+	}
+	ord, err = s.executeTWAP(ctx, executor, in)
+	return ord, true, err
+}
+
+// executeTWAP 拆单执行：父订单记录整体计划与汇总成交，子单通过 parent_order_id 关联父订单并逐笔计入持仓。
+// 开仓每笔按金额均分；平仓每笔卖出剩余数量的 1/剩余笔数，最后一笔卖出全部剩余，避免留下零头
+func (s *Service) executeTWAP(ctx context.Context, executor execution.Executor, in execution.Input) (domain.Order, error) {
+	n := s.twap.Slices
+	now := time.Now().UTC()
+	parent := domain.Order{
+		ID:             uuid.NewString(),
+		CycleID:        in.CycleID,
+		SignalID:       in.SignalID,
+		IdempotencyKey: in.IdempotencyKey,
+		Pair:           in.Pair,
+		Side:           in.Side,
+		StakeUSDT:      in.StakeUSDT,
+		ExpectedPrice:  in.EstimatedFill,
+		Leverage:       in.Leverage,
+		Status:         "twap_running",
+		FillStrategy:   domain.FillTWAP,
+		CreatedAt:      now,
+	}
+	targetQty := in.SellQuantity * domain.NormalizeCloseFraction(in.CloseFraction)
+	if in.Side == domain.SideClose {
+		parent.RequestedQty = targetQty
+	}
+	if err := s.repo.InsertOrder(ctx, parent); err != nil {
+		return parent, fmt.Errorf("保存拆单父订单失败: %w", err)
+	}
+	log.Printf("[拆单] %s %s 开始拆单执行: %d 笔 间隔 %s 金额=%.2f 数量=%.8f 父订单=%s",
+		in.Pair, in.Side, n, s.twap.Interval, in.StakeUSDT, targetQty, parent.ID[:8])
+
+	// 拆单总耗时可能超过调用方的超时，已开始的计划不随调用方取消而中断
+	tctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Duration(n)*s.twap.Interval+time.Minute)
+	defer cancel()
+
+	group := domain.OrderGroup{
+		ID:            uuid.NewString(),
+		CycleID:       in.CycleID,
+		Pair:          in.Pair,
+		Kind:          domain.OrderGroupTWAP,
+		ParentOrderID: parent.ID,
+		Status:        domain.OrderGroupDone,
+		CreatedAt:     now,
+	}
+	var (
+		filledQty, filledCost, spent float64
+		failed                       int
+		lastErr                      error
+	)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			select {
+			case <-tctx.Done():
+			case <-time.After(s.twap.Interval):
+			}
+			if tctx.Err() != nil {
+				lastErr = tctx.Err()
+				break
+			}
+		}
+
+		child := in
+		child.IdempotencyKey = fmt.Sprintf("%s:twap:%d", in.IdempotencyKey, i+1)
+		if in.Side == domain.SideClose {
+			remaining := targetQty - filledQty
+			if remaining <= 0 {
+				break
+			}
+			child.SellQuantity = remaining
+			child.CloseFraction = 1 / float64(n-i)
+			child.StakeUSDT = remaining / float64(n-i) * in.EstimatedFill
+		} else {
+			// 前面失败的子单金额顺延到后续子单
+			child.StakeUSDT = (in.StakeUSDT - spent) / float64(n-i)
+		}
+
+		ord, err := executor.Execute(tctx, child)
+		ord.ParentOrderID = parent.ID
+		ord.FillStrategy = domain.FillTWAP
+		if ord.ID != "" {
+			s.persistOrder(tctx, ord)
+		}
+		leg := domain.OrderGroupLeg{
+			LegNo:           i + 1,
+			Role:            "child",
+			RefID:           ord.ID,
+			ExchangeOrderID: ord.ExchangeOrderID,
+			Price:           ord.FilledPrice,
+			Quantity:        ord.FilledQuantity,
+			Status:          ord.Status,
+			UpdatedAt:       time.Now().UTC(),
+		}
+		group.Legs = append(group.Legs, leg)
+		if err != nil {
+			failed++
+			lastErr = err
+			log.Printf("[拆单] ✘ %s 第%d/%d笔失败: %v", in.Pair, i+1, n, err)
+			continue
+		}
+		s.UpdateHoldingAfterTrade(tctx, ord)
+		filledQty += ord.FilledQuantity
+		filledCost += ord.FilledQuantity * ord.FilledPrice
+		if in.Side != domain.SideClose {
+			spent += child.StakeUSDT
+		}
+		log.Printf("[拆单] ✔ %s 第%d/%d笔 成交价=%.8f 数量=%.8f", in.Pair, i+1, n, ord.FilledPrice, ord.FilledQuantity)
+	}
+
+	parent.FilledQuantity = filledQty
+	if filledQty > 0 {
+		parent.FilledPrice = filledCost / filledQty
+	}
+	switch {
+	case filledQty <= 0:
+		parent.Status = "twap_failed"
+		group.Status = domain.OrderGroupCancelled
+	case failed > 0 || lastErr != nil:
+		parent.Status = "twap_partial"
+	default:
+		parent.Status = "twap_done"
+	}
+	group.UpdatedAt = time.Now().UTC()
+	s.persistOrderFill(tctx, parent.ID, parent.Status, parent.FilledPrice, parent.FilledQuantity)
+	if err := s.repo.InsertOrderGroup(tctx, group); err != nil {
+		log.Printf("[拆单] ⚠ 保存订单组失败: %v", err)
+	}
+	log.Printf("[拆单] %s 拆单结束 状态=%s 成交 %d/%d 笔 均价=%.8f 数量=%.8f",
+		in.Pair, parent.Status, len(group.Legs)-failed, n, parent.FilledPrice, parent.FilledQuantity)
+
+	if filledQty <= 0 {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("拆单未成交")
+		}
+		return parent, lastErr
+	}
+	return parent, nil
+}
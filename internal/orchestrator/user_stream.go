@@ -0,0 +1,103 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ai_quant/internal/agent/execution"
+	"ai_quant/internal/domain"
+)
+
+// StartUserStream 订阅交易所用户数据流，订单成交与保护单触发推送到达时立即更新本地记录；
+// 执行器不支持或未启用时返回 false，仍由定时对账任务处理
+func (s *Service) StartUserStream() bool {
+	streamer, ok := s.executor.(execution.UserStreamer)
+	if !ok {
+		return false
+	}
+	return streamer.StartUserStream(func(u execution.OrderUpdate) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		s.handleOrderUpdate(ctx, u)
+	})
+}
+
+// handleOrderUpdate 处理一条订单推送：保护单成交按触发结算，本系统订单补记新增成交量并计入持仓。
+// 与保护单对账、部分成交处理串行执行，同一笔成交只入账一次
+func (s *Service) handleOrderUpdate(ctx context.Context, u execution.OrderUpdate) {
+	if u.Status != "filled" && u.Status != "partial_filled" && u.Status != "rejected" {
+		return
+	}
+	s.fillMu.Lock()
+	defer s.fillMu.Unlock()
+
+	if s.settleProtectiveUpdate(ctx, u) {
+		return
+	}
+
+	ord, err := s.repo.GetOrderByExchangeID(ctx, u.ExchangeOrderID)
+	if err != nil {
+		log.Printf("[成交推送] ⚠ 查询订单失败 %s 订单ID=%s: %v", u.Pair, u.ExchangeOrderID, err)
+		return
+	}
+	// 不是本系统的订单，或同步下单结果尚未落库（落库时已带成交）
+	if ord == nil {
+		return
+	}
+
+	status := u.Status
+	if status == "rejected" && u.ExecutedQty > 0 {
+		status = "partial_cancelled"
+	}
+	if status == ord.Status && u.ExecutedQty <= ord.FilledQuantity {
+		return
+	}
+	st := u.State()
+	if st.ExecutedQty < ord.FilledQuantity {
+		// 推送乱序时不回退已记录的成交
+		st.ExecutedQty, st.AvgPrice = ord.FilledQuantity, ord.FilledPrice
+	}
+	s.persistOrderFill(ctx, ord.ID, status, st.AvgPrice, st.ExecutedQty)
+	s.applyFillDelta(ctx, *ord, st)
+	log.Printf("[成交推送] %s 订单ID=%s %s → %s 成交=%.8f 均价=%.8f",
+		u.Pair, u.ExchangeOrderID, ord.Status, status, st.ExecutedQty, st.AvgPrice)
+}
+
+// settleProtectiveUpdate 推送的是生效中的保护单时按成交 / 撤销处理，返回是否已处理
+func (s *Service) settleProtectiveUpdate(ctx context.Context, u execution.OrderUpdate) bool {
+	active, err := s.repo.ListProtectiveOrders(ctx, u.Pair, domain.ProtectiveActive)
+	if err != nil {
+		log.Printf("[成交推送] ⚠ 查询保护单失败 %s: %v", u.Pair, err)
+		return false
+	}
+	var hit *domain.ProtectiveOrder
+	var legs []domain.ProtectiveOrder
+	for i := range active {
+		if active[i].ExchangeOrderID == u.ExchangeOrderID {
+			hit = &active[i]
+		}
+	}
+	if hit == nil {
+		return false
+	}
+	for _, po := range active {
+		if po.GroupID == hit.GroupID {
+			legs = append(legs, po)
+		}
+	}
+
+	switch u.Status {
+	case "filled":
+		mgr, ok := execution.ForPair(s.executor, u.Pair).(execution.ProtectiveOrderManager)
+		if !ok {
+			return false
+		}
+		s.settleProtectiveTrigger(ctx, mgr, legs, *hit, u.State(), 0)
+	case "rejected":
+		s.setProtectiveStatus(ctx, *hit, domain.ProtectiveCancelled)
+		log.Printf("[止损] %s 保护单 %s 已在交易所撤销或过期", u.Pair, u.ExchangeOrderID)
+	}
+	// 部分成交等待全部成交的推送
+	return true
+}
@@ -0,0 +1,254 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai_quant/internal/domain"
+	"ai_quant/internal/ratelimit"
+
+	"github.com/google/uuid"
+)
+
+// VolatilityBreaker 波动熔断（不经过大模型）：短时间内价格波动超过阈值（闪崩 / 急拉）时，
+// 该交易对在冷却期内暂停新开仓，已有持仓仍可由大模型决定是否平仓
+type VolatilityBreaker struct {
+	MovePct     float64 // WindowMin 分钟内最高最低价波动达到该百分比即触发，0 = 不启用
+	WindowMin   int     // 观察窗口（分钟，按 1m K 线计算）
+	CooldownMin int     // 触发后暂停开仓的时长（分钟）
+}
+
+// VolatilityHalt 交易对当前的熔断状态（供前端横幅展示）
+type VolatilityHalt struct {
+	Pair    string    `json:"pair"`
+	MovePct float64   `json:"move_pct"` // 触发时的波动幅度（%，负数为下跌）
+	Message string    `json:"message"`
+	Since   time.Time `json:"since"`
+	Until   time.Time `json:"until"`
+}
+
+// klineCacheTTL 短周期 K 线缓存时长，同一交易对的周期 / 预览不会重复请求
+const klineCacheTTL = 20 * time.Second
+
+type shortKlines struct {
+	highs, lows []float64
+	fetchedAt   time.Time
+}
+
+// volatilityState 进程内的熔断状态与 K 线缓存
+type volatilityState struct {
+	mu     sync.Mutex
+	halts  map[string]VolatilityHalt
+	klines map[string]shortKlines
+}
+
+// SetVolatilityBreaker 设置波动熔断规则
+func (s *Service) SetVolatilityBreaker(b VolatilityBreaker) {
+	if b.WindowMin <= 0 {
+		b.WindowMin = 5
+	}
+	if b.CooldownMin <= 0 {
+		b.CooldownMin = 30
+	}
+	s.breaker = b
+	if b.MovePct > 0 {
+		log.Printf("[熔断] 已启用: %d 分钟内波动 ≥ %.2f%% 暂停开仓 %d 分钟", b.WindowMin, b.MovePct, b.CooldownMin)
+	}
+}
+
+// checkVolatility 开仓前检查交易对是否处于熔断冷却期，未熔断时用最近 1m K 线判断是否需要触发；
+// 返回 nil 表示可以正常开仓
+func (s *Service) checkVolatility(ctx context.Context, pair string) (*VolatilityHalt, error) {
+	if s.breaker.MovePct <= 0 {
+		return nil, nil
+	}
+	now := time.Now().UTC()
+
+	s.volatility.mu.Lock()
+	if h, ok := s.volatility.halts[pair]; ok {
+		if now.Before(h.Until) {
+			s.volatility.mu.Unlock()
+			return &h, nil
+		}
+		delete(s.volatility.halts, pair)
+		log.Printf("[熔断] ✔ %s 冷却期结束，恢复开仓", pair)
+	}
+	s.volatility.mu.Unlock()
+
+	k, err := s.cachedShortKlines(ctx, pair, s.breaker.WindowMin)
+	if err != nil {
+		return nil, err
+	}
+	move := priceMovePct(k.highs, k.lows)
+	if move < s.breaker.MovePct && -move < s.breaker.MovePct {
+		return nil, nil
+	}
+
+	kind := "急拉"
+	if move < 0 {
+		kind = "闪崩"
+	}
+	h := VolatilityHalt{
+		Pair:    pair,
+		MovePct: move,
+		Message: fmt.Sprintf("%s %d 分钟内%s %.2f%%（阈值 %.2f%%），暂停开仓 %d 分钟",
+			pair, s.breaker.WindowMin, kind, move, s.breaker.MovePct, s.breaker.CooldownMin),
+		Since: now,
+		Until: now.Add(time.Duration(s.breaker.CooldownMin) * time.Minute),
+	}
+	s.volatility.mu.Lock()
+	if s.volatility.halts == nil {
+		s.volatility.halts = make(map[string]VolatilityHalt)
+	}
+	s.volatility.halts[pair] = h
+	s.volatility.mu.Unlock()
+	log.Printf("[熔断] 🚨 %s", h.Message)
+	return &h, nil
+}
+
+// VolatilityHalts 返回仍在冷却期内的熔断，按交易对排序
+func (s *Service) VolatilityHalts() []VolatilityHalt {
+	now := time.Now().UTC()
+	s.volatility.mu.Lock()
+	defer s.volatility.mu.Unlock()
+	out := make([]VolatilityHalt, 0, len(s.volatility.halts))
+	for _, h := range s.volatility.halts {
+		if now.Before(h.Until) {
+			out = append(out, h)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Pair < out[j].Pair })
+	return out
+}
+
+// priceMovePct 窗口内最高价与最低价之间的波动幅度，最低价在后（下跌）时为负数
+func priceMovePct(highs, lows []float64) float64 {
+	if len(highs) == 0 || len(highs) != len(lows) {
+		return 0
+	}
+	hi, lo := 0, 0
+	for i := range highs {
+		if highs[i] > highs[hi] {
+			hi = i
+		}
+		if lows[i] < lows[lo] {
+			lo = i
+		}
+	}
+	if lows[lo] <= 0 {
+		return 0
+	}
+	move := (highs[hi] - lows[lo]) / lows[lo] * 100
+	if lo > hi {
+		return -move
+	}
+	return move
+}
+
+// cachedShortKlines 获取最近 window 根 1m K 线，klineCacheTTL 内复用缓存
+func (s *Service) cachedShortKlines(ctx context.Context, pair string, window int) (shortKlines, error) {
+	s.volatility.mu.Lock()
+	k, ok := s.volatility.klines[pair]
+	s.volatility.mu.Unlock()
+	if ok && len(k.highs) >= window && time.Since(k.fetchedAt) < klineCacheTTL {
+		return k, nil
+	}
+
+	highs, lows, err := fetchMinuteKlines(ctx, pair, window)
+	if err != nil {
+		return shortKlines{}, err
+	}
+	k = shortKlines{highs: highs, lows: lows, fetchedAt: time.Now()}
+	s.volatility.mu.Lock()
+	if s.volatility.klines == nil {
+		s.volatility.klines = make(map[string]shortKlines)
+	}
+	s.volatility.klines[pair] = k
+	s.volatility.mu.Unlock()
+	return k, nil
+}
+
+// fetchMinuteKlines 获取最近 limit 根 1m K 线的最高价 / 最低价（公开接口，按时间升序）
+func fetchMinuteKlines(ctx context.Context, pair string, limit int) (highs, lows []float64, err error) {
+	symbol := strings.ReplaceAll(strings.ToUpper(pair), "/", "")
+	url := fmt.Sprintf("https://api.binance.com/api/v3/klines?symbol=%s&interval=1m&limit=%d", symbol, limit)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := ratelimit.NewClient(5 * time.Second).Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("klines API %d", resp.StatusCode)
+	}
+
+	var rows [][]any
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, nil, err
+	}
+	for _, r := range rows {
+		if len(r) < 4 {
+			continue
+		}
+		hs, _ := r[2].(string)
+		ls, _ := r[3].(string)
+		h, hErr := strconv.ParseFloat(hs, 64)
+		l, lErr := strconv.ParseFloat(ls, 64)
+		if hErr != nil || lErr != nil {
+			continue
+		}
+		highs = append(highs, h)
+		lows = append(lows, l)
+	}
+	return highs, lows, nil
+}
+
+// volatilityGuardModel 波动熔断生成的信号使用的模型名
+const volatilityGuardModel = "volatility_breaker"
+
+// volatilityHoldSignal 熔断期间无持仓时不调用大模型，直接观望
+func volatilityHoldSignal(cycleID, pair string, h *VolatilityHalt) domain.Signal {
+	return domain.Signal{
+		ID:         uuid.NewString(),
+		CycleID:    cycleID,
+		Pair:       pair,
+		Side:       domain.SideNone,
+		Confidence: 0,
+		Reason:     "波动熔断观望：" + h.Message,
+		ModelName:  volatilityGuardModel,
+		TTLSeconds: 60,
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+// volatilityAlert 熔断期间有持仓时写入提示词的提示
+func volatilityAlert(h *VolatilityHalt) string {
+	return fmt.Sprintf("%s moved %.2f%% within minutes (volatility circuit breaker tripped). New entries are paused until %s UTC; "+
+		"only decide whether to hold or close the existing position.", h.Pair, h.MovePct, h.Until.Format("15:04"))
+}
+
+// hasHolding 本地持仓表中交易对是否有未平仓数量；查询失败时按有持仓处理，保留平仓机会
+func (s *Service) hasHolding(ctx context.Context, pair string) bool {
+	holdings, err := s.repo.ListHoldings(ctx)
+	if err != nil {
+		return true
+	}
+	for _, h := range holdings {
+		if strings.EqualFold(h.Pair, pair) && h.Quantity > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+// Package preset 管理风险偏好预设（conservative / balanced / aggressive），
+// 切换时一次性替换下单上限、置信度门槛、杠杆、止盈止损与冷却时间。
+package preset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"ai_quant/internal/config"
+	"ai_quant/internal/domain"
+)
+
+const (
+	Conservative = "conservative"
+	Balanced     = "balanced"
+	Aggressive   = "aggressive"
+	// Custom 直接使用配置文件中的风控参数
+	Custom = "custom"
+)
+
+// profile 预设模板：金额类参数为相对配置值的倍数，其余为固定值
+type profile struct {
+	stakeScale        float64
+	minConfidence     float64
+	leverage          int
+	takeProfitPercent float64
+	stopLossPercent   float64
+	cooldownSec       int
+}
+
+var profiles = map[string]profile{
+	Conservative: {stakeScale: 0.5, minConfidence: 0.70, leverage: 2, takeProfitPercent: 4, stopLossPercent: 1.5, cooldownSec: 3600},
+	Balanced:     {stakeScale: 1.0, minConfidence: 0.60, leverage: 3, takeProfitPercent: 6, stopLossPercent: 2.5, cooldownSec: 1800},
+	Aggressive:   {stakeScale: 1.5, minConfidence: 0.55, leverage: 5, takeProfitPercent: 10, stopLossPercent: 4, cooldownSec: 600},
+}
+
+// Manager 保存所有预设与当前生效的预设，并发安全
+type Manager struct {
+	mu      sync.RWMutex
+	presets map[string]domain.RiskPreset
+	active  string
+}
+
+// NewManager 根据配置生成预设，cfg.RiskPreset 为空或未知时使用 custom
+func NewManager(cfg config.Config) *Manager {
+	presets := map[string]domain.RiskPreset{
+		Custom: {
+			Name:               Custom,
+			MaxSingleStakeUSDT: cfg.MaxSingleStakeUSDT,
+			MaxDailyLossUSDT:   cfg.MaxDailyLossUSDT,
+			MaxExposureUSDT:    cfg.MaxExposureUSDT,
+			MinConfidence:      cfg.MinConfidence,
+			Leverage:           cfg.FuturesLeverage,
+			CooldownSec:        cfg.CooldownSec,
+		},
+	}
+	for name, p := range profiles {
+		presets[name] = domain.RiskPreset{
+			Name:               name,
+			MaxSingleStakeUSDT: cfg.MaxSingleStakeUSDT * p.stakeScale,
+			MaxDailyLossUSDT:   cfg.MaxDailyLossUSDT * p.stakeScale,
+			MaxExposureUSDT:    cfg.MaxExposureUSDT * p.stakeScale,
+			MinConfidence:      p.minConfidence,
+			Leverage:           p.leverage,
+			TakeProfitPercent:  p.takeProfitPercent,
+			StopLossPercent:    p.stopLossPercent,
+			CooldownSec:        p.cooldownSec,
+		}
+	}
+
+	m := &Manager{presets: presets, active: Custom}
+	if name := strings.ToLower(strings.TrimSpace(cfg.RiskPreset)); name != "" {
+		if _, ok := presets[name]; ok {
+			m.active = name
+		}
+	}
+	return m
+}
+
+// Active 返回当前生效的预设（值拷贝，单个周期内保持一致）
+func (m *Manager) Active() domain.RiskPreset {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.presets[m.active]
+}
+
+// Apply 切换当前预设
+func (m *Manager) Apply(name string) (domain.RiskPreset, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.presets[name]
+	if !ok {
+		return domain.RiskPreset{}, fmt.Errorf("未知的风险预设: %s", name)
+	}
+	m.active = name
+	return p, nil
+}
+
+// List 返回所有预设，按名称排序
+func (m *Manager) List() []domain.RiskPreset {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]domain.RiskPreset, 0, len(m.presets))
+	for _, p := range m.presets {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
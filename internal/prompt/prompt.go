@@ -0,0 +1,201 @@
+// Package prompt 管理信号提示词模板：从配置目录加载系统 / 用户提示词，支持按交易对覆盖，
+// 每个模板组合带内容哈希作为版本号，可在运行时重新加载。
+//
+// 目录结构（PROMPT_DIR）：
+//
+//	SystemPrompt.md              默认系统提示词
+//	UserPrompt.md                默认用户提示词模板
+//	pairs/DOGE_USDT/UserPrompt.md  按交易对覆盖（优先）
+//	pairs/DOGE/SystemPrompt.md     按基础币种覆盖
+//
+// 覆盖目录中缺少的文件使用默认模板。
+package prompt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	systemFile  = "SystemPrompt.md"
+	userFile    = "UserPrompt.md"
+	overrideDir = "pairs"
+)
+
+// Template 某个交易对实际使用的提示词
+type Template struct {
+	System  string
+	User    string
+	Version string // 系统 + 用户提示词内容的短哈希
+	Source  string // default 或覆盖目录名
+}
+
+// Override 按交易对 / 基础币种覆盖的模板
+type Override struct {
+	Name      string `json:"name"` // 目录名，如 DOGE_USDT 或 DOGE
+	HasSystem bool   `json:"has_system"`
+	HasUser   bool   `json:"has_user"`
+	Version   string `json:"version"` // 该覆盖与默认模板组合后的版本
+	system    string
+	user      string
+}
+
+// Info 当前加载的模板概况
+type Info struct {
+	Dir        string     `json:"dir"`
+	Version    string     `json:"version"` // 默认模板的版本
+	SystemHash string     `json:"system_hash"`
+	UserHash   string     `json:"user_hash"`
+	SystemLen  int        `json:"system_chars"`
+	UserLen    int        `json:"user_chars"`
+	Overrides  []Override `json:"overrides"`
+	LoadedAt   time.Time  `json:"loaded_at"`
+}
+
+// Library 提示词模板库
+type Library struct {
+	dir string
+
+	mu        sync.RWMutex
+	system    string
+	user      string
+	overrides map[string]Override
+	loadedAt  time.Time
+}
+
+// New 从目录加载模板库；默认模板缺失不报错（信号会退回简化提示词），只记录日志
+func New(dir string) (*Library, error) {
+	if strings.TrimSpace(dir) == "" {
+		dir = "."
+	}
+	l := &Library{dir: dir}
+	if _, err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Reload 重新读取目录中的全部模板；读取失败时保留原模板
+func (l *Library) Reload() (Info, error) {
+	system := readFile(filepath.Join(l.dir, systemFile), false)
+	user := readFile(filepath.Join(l.dir, userFile), false)
+
+	overrides := make(map[string]Override)
+	entries, err := os.ReadDir(filepath.Join(l.dir, overrideDir))
+	if err != nil && !os.IsNotExist(err) {
+		return l.Info(), fmt.Errorf("读取覆盖目录: %w", err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		name := strings.ToUpper(e.Name())
+		o := Override{Name: name}
+		o.system = readFile(filepath.Join(l.dir, overrideDir, e.Name(), systemFile), true)
+		o.user = readFile(filepath.Join(l.dir, overrideDir, e.Name(), userFile), true)
+		o.HasSystem, o.HasUser = o.system != "", o.user != ""
+		if !o.HasSystem && !o.HasUser {
+			continue
+		}
+		overrides[name] = o
+	}
+
+	l.mu.Lock()
+	l.system, l.user, l.overrides = system, user, overrides
+	l.loadedAt = time.Now().UTC()
+	l.mu.Unlock()
+
+	info := l.Info()
+	log.Printf("[提示词] ✔ 已加载 目录=%s 版本=%s 系统=%d字符 用户=%d字符 覆盖=%d个",
+		info.Dir, info.Version, info.SystemLen, info.UserLen, len(info.Overrides))
+	return info, nil
+}
+
+// For 返回交易对使用的模板：先找 DOGE_USDT 目录，再找 DOGE 目录，缺少的文件用默认模板
+func (l *Library) For(pair string) Template {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	t := Template{System: l.system, User: l.user, Source: "default"}
+	base, _, _ := strings.Cut(strings.ToUpper(strings.TrimSpace(pair)), "/")
+	for _, name := range []string{strings.ReplaceAll(strings.ToUpper(strings.TrimSpace(pair)), "/", "_"), base} {
+		o, ok := l.overrides[name]
+		if !ok {
+			continue
+		}
+		if o.HasSystem {
+			t.System = o.system
+		}
+		if o.HasUser {
+			t.User = o.user
+		}
+		t.Source = name
+		break
+	}
+	t.Version = version(t.System, t.User)
+	return t
+}
+
+// Info 当前加载的模板概况
+func (l *Library) Info() Info {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	info := Info{
+		Dir:        l.dir,
+		Version:    version(l.system, l.user),
+		SystemHash: hash(l.system),
+		UserHash:   hash(l.user),
+		SystemLen:  len(l.system),
+		UserLen:    len(l.user),
+		Overrides:  make([]Override, 0, len(l.overrides)),
+		LoadedAt:   l.loadedAt,
+	}
+	for _, o := range l.overrides {
+		system, user := l.system, l.user
+		if o.HasSystem {
+			system = o.system
+		}
+		if o.HasUser {
+			user = o.user
+		}
+		o.Version = version(system, user)
+		info.Overrides = append(info.Overrides, o)
+	}
+	sort.Slice(info.Overrides, func(i, j int) bool { return info.Overrides[i].Name < info.Overrides[j].Name })
+	return info
+}
+
+// readFile 读取模板文件；optional 为 true 时（覆盖目录）文件不存在不记录日志
+func readFile(path string, optional bool) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !optional || !os.IsNotExist(err) {
+			log.Printf("[提示词] 加载文件 %s 失败: %v", path, err)
+		}
+		return ""
+	}
+	return string(data)
+}
+
+func hash(s string) string {
+	if s == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// version 系统与用户提示词组合的版本号
+func version(system, user string) string {
+	sum := sha256.Sum256([]byte(system + "\x00" + user))
+	return hex.EncodeToString(sum[:])[:12]
+}
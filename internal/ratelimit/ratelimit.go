@@ -0,0 +1,205 @@
+// Package ratelimit 按主机跟踪交易所 API 的请求权重：从 Binance 响应头读取已用权重，
+// 接近上限时把请求延后到下一分钟，收到 429 / 418 时按 Retry-After 暂停对该主机的所有请求。
+// 同一进程内的行情、交易规则和下单客户端共用一份状态，IP 权重按主机累计。
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai_quant/internal/trace"
+)
+
+// HeaderUsedWeight Binance 返回的当前分钟已用权重（现货与合约相同）
+const HeaderUsedWeight = "X-Mbx-Used-Weight-1m"
+
+const (
+	defaultSafetyPct = 90
+	// 没有 Retry-After 时的暂停时间：429 为超频，418 为 IP 已被封禁
+	default429Backoff = 30 * time.Second
+	default418Backoff = 2 * time.Minute
+)
+
+// hostState 单个主机的权重与封禁状态
+type hostState struct {
+	limit       int       // 每分钟权重上限，0 = 不主动限速，只响应 429 / 418
+	used        int       // 最近一次响应头中的已用权重
+	usedMinute  time.Time // used 所属的分钟
+	bannedUntil time.Time
+}
+
+var (
+	mu        sync.Mutex
+	hosts     = make(map[string]*hostState)
+	safetyPct = defaultSafetyPct
+)
+
+// SetLimit 设置 baseURL 所在主机的每分钟权重上限（如现货 6000、合约 2400），0 = 不主动限速
+func SetLimit(baseURL string, weightPerMin int) {
+	host := hostOf(baseURL)
+	if host == "" {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	stateLocked(host).limit = weightPerMin
+	if weightPerMin > 0 {
+		log.Printf("[限流] %s 每分钟权重上限 %d，已用 %d%% 时延后请求", host, weightPerMin, safetyPct)
+	}
+}
+
+// SetSafetyPct 已用权重达到上限的百分比时开始延后请求
+func SetSafetyPct(pct int) {
+	if pct <= 0 || pct > 100 {
+		pct = defaultSafetyPct
+	}
+	mu.Lock()
+	safetyPct = pct
+	mu.Unlock()
+}
+
+// Stats 主机的限流状态
+type Stats struct {
+	Host        string     `json:"host"`
+	Limit       int        `json:"limit"`
+	UsedWeight  int        `json:"used_weight"`
+	BannedUntil *time.Time `json:"banned_until,omitempty"`
+}
+
+// Snapshot 返回各主机当前分钟的权重使用情况
+func Snapshot() []Stats {
+	mu.Lock()
+	defer mu.Unlock()
+	now := time.Now()
+	out := make([]Stats, 0, len(hosts))
+	for host, st := range hosts {
+		s := Stats{Host: host, Limit: st.limit}
+		if st.usedMinute.Equal(now.Truncate(time.Minute)) {
+			s.UsedWeight = st.used
+		}
+		if st.bannedUntil.After(now) {
+			t := st.bannedUntil
+			s.BannedUntil = &t
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func stateLocked(host string) *hostState {
+	st, ok := hosts[host]
+	if !ok {
+		st = &hostState{}
+		hosts[host] = st
+	}
+	return st
+}
+
+func hostOf(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
+// waitUntil 请求前需要等待到的时间，零值表示可以直接发送
+func waitUntil(host string, now time.Time) (time.Time, string) {
+	mu.Lock()
+	defer mu.Unlock()
+	st, ok := hosts[host]
+	if !ok {
+		return time.Time{}, ""
+	}
+	if st.bannedUntil.After(now) {
+		return st.bannedUntil, "交易所限流中"
+	}
+	minute := now.Truncate(time.Minute)
+	if st.limit > 0 && st.usedMinute.Equal(minute) && st.used*100 >= st.limit*safetyPct {
+		// 权重按分钟重置，延后到下一分钟开始
+		return minute.Add(time.Minute), fmt.Sprintf("已用权重 %d/%d", st.used, st.limit)
+	}
+	return time.Time{}, ""
+}
+
+// observe 根据响应更新主机状态
+func observe(host string, resp *http.Response, now time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	st := stateLocked(host)
+	if v := resp.Header.Get(HeaderUsedWeight); v != "" {
+		if used, err := strconv.Atoi(v); err == nil {
+			st.used = used
+			st.usedMinute = now.Truncate(time.Minute)
+		}
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusTeapot {
+		return
+	}
+	backoff := default429Backoff
+	if resp.StatusCode == http.StatusTeapot {
+		backoff = default418Backoff
+	}
+	if sec, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && sec > 0 {
+		backoff = time.Duration(sec) * time.Second
+	}
+	until := now.Add(backoff)
+	if until.After(st.bannedUntil) {
+		st.bannedUntil = until
+		log.Printf("[限流] ⚠ %s 返回 HTTP %d，暂停该主机请求至 %s", host, resp.StatusCode, until.Format("15:04:05"))
+	}
+}
+
+// Transport 在请求前按主机状态等待，响应后记录权重与 429 / 418
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	host := strings.ToLower(req.URL.Host)
+	ctx := req.Context()
+
+	if until, reason := waitUntil(host, time.Now()); !until.IsZero() {
+		if deadline, ok := ctx.Deadline(); ok && deadline.Before(until) {
+			return nil, fmt.Errorf("%s %s，需等待至 %s，超过请求超时", host, reason, until.Format("15:04:05"))
+		}
+		wait := time.Until(until)
+		log.Printf("[限流] %s %s，请求延后 %s", host, reason, wait.Round(time.Millisecond))
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := base.RoundTrip(req)
+	if resp != nil {
+		observe(host, resp, time.Now())
+	}
+	return resp, err
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// NewClient 创建带限流与追踪 Transport 的 HTTP 客户端，用于访问交易所 API
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: &Transport{Base: &trace.Transport{}}}
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ai_quant/internal/domain"
+	"ai_quant/internal/orchestrator"
+	"ai_quant/internal/tradingday"
+)
+
+// DailyReporter 交易日切换时生成上一交易日的日报；新的一周开始时同时生成上周周报。
+// 报告保存到数据库，开启每日汇总通知时推送摘要
+type DailyReporter struct {
+	service *orchestrator.Service
+	stop    chan struct{}
+}
+
+// NewDailyReporter 创建日报任务
+func NewDailyReporter(service *orchestrator.Service) *DailyReporter {
+	return &DailyReporter{service: service, stop: make(chan struct{})}
+}
+
+// Start 启动日报任务（非阻塞，每分钟检查一次交易日是否切换）
+func (r *DailyReporter) Start() {
+	log.Printf("[报告] 日报 / 周报已启动 时区=%s", tradingday.Location())
+
+	go func() {
+		day := tradingday.Key(time.Now())
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				if key := tradingday.Key(now); key != day {
+					ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+					r.service.GenerateReport(ctx, domain.ReportDaily, day)
+					if now.In(tradingday.Location()).Weekday() == time.Monday {
+						r.service.GenerateReport(ctx, domain.ReportWeekly, day)
+					}
+					cancel()
+					day = key
+				}
+			case <-r.stop:
+				log.Println("[报告] 日报 / 周报已停止")
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止日报任务
+func (r *DailyReporter) Stop() {
+	close(r.stop)
+}
@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ai_quant/internal/orchestrator"
+)
+
+// 交易实例的定时后台任务，统一由 Periodic 运行
+
+// OutboxJob 定时重试写库失败的订单、成交与周期状态；停止前最后重试一次，仍未写入的记录打印到日志
+func OutboxJob(service *orchestrator.Service, intervalSec int) Job {
+	if intervalSec <= 0 {
+		intervalSec = 30
+	}
+	return Job{
+		Tag:      "写库",
+		Name:     "重试任务",
+		Interval: time.Duration(intervalSec) * time.Second,
+		Run: func(ctx context.Context) error {
+			service.FlushOutbox(ctx)
+			return nil
+		},
+		OnStop: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), defaultJobTimeout)
+			defer cancel()
+			service.FlushOutbox(ctx)
+			service.LogPendingOutbox()
+		},
+	}
+}
+
+// ApprovalJob 定时取消超时未审批的下单
+func ApprovalJob(service *orchestrator.Service, interval time.Duration) Job {
+	return Job{
+		Tag:      "审批",
+		Name:     "超时检查",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			n, err := service.ExpireApprovals(ctx)
+			if n > 0 {
+				log.Printf("[审批] 已取消 %d 个超时未审批的下单", n)
+			}
+			return err
+		},
+	}
+}
+
+// FillJob 定时处理超时未完全成交的订单，每个超时周期检查一次；action 为 cancel 或 resubmit
+func FillJob(service *orchestrator.Service, timeoutSec int, action string) Job {
+	if action != orchestrator.PartialFillResubmit {
+		action = orchestrator.PartialFillCancel
+	}
+	timeout := time.Duration(timeoutSec) * time.Second
+	return Job{
+		Tag:      "部分成交",
+		Name:     "超时处理",
+		Interval: timeout,
+		Timeout:  60 * time.Second,
+		Detail:   "处理方式=" + action,
+		Run: func(ctx context.Context) error {
+			return service.ResolvePartialFills(ctx, timeout, action)
+		},
+	}
+}
+
+// BatchJob 定时检查分批建仓和分批止盈的待触发批次，expireHours 为待触发批次有效期（0 表示不过期）
+func BatchJob(service *orchestrator.Service, intervalSec, expireHours int) Job {
+	expire := time.Duration(expireHours) * time.Hour
+	return Job{
+		Tag:      "分批",
+		Name:     "待触发批次检查",
+		Interval: time.Duration(intervalSec) * time.Second,
+		Timeout:  60 * time.Second,
+		Detail:   fmt.Sprintf("有效期=%s", expire),
+		Run: func(ctx context.Context) error {
+			err := service.ProcessPendingBatches(ctx, expire)
+			// 分批止盈与分批建仓互不影响，失败时单独记录
+			if exitErr := service.ProcessExitBatches(ctx); exitErr != nil {
+				log.Printf("[止盈] ✘ 检查分批止盈失败: %v", exitErr)
+			}
+			return err
+		},
+	}
+}
+
+// ProtectionJob 定时对账交易所止盈止损保护单
+func ProtectionJob(service *orchestrator.Service, intervalSec int) Job {
+	return Job{
+		Tag:      "止损",
+		Name:     "保护单对账",
+		Interval: time.Duration(intervalSec) * time.Second,
+		Timeout:  60 * time.Second,
+		Run:      service.ReconcileProtectiveOrders,
+	}
+}
+
+// TrailingStopJob 定时更新持仓最高价与移动止损价，跌破止损价时平仓
+func TrailingStopJob(service *orchestrator.Service, intervalSec int) Job {
+	return Job{
+		Tag:      "移动止损",
+		Name:     "检查",
+		Interval: time.Duration(intervalSec) * time.Second,
+		Timeout:  60 * time.Second,
+		Run:      service.ProcessTrailingStops,
+	}
+}
+
+// MarginJob 定时巡检现货杠杆账户风险率
+func MarginJob(service *orchestrator.Service, intervalSec int) Job {
+	return Job{
+		Tag:      "风控",
+		Name:     "杠杆风险率巡检",
+		Interval: time.Duration(intervalSec) * time.Second,
+		Run:      service.CheckMarginLevels,
+	}
+}
+
+// LiquidationJob 定时巡检合约持仓与强平价的距离
+func LiquidationJob(service *orchestrator.Service, intervalSec int) Job {
+	return Job{
+		Tag:      "风控",
+		Name:     "合约强平预警巡检",
+		Interval: time.Duration(intervalSec) * time.Second,
+		Run:      service.CheckLiquidationRisk,
+	}
+}
+
+// BehaviorJob 定时巡检大模型输出分布
+func BehaviorJob(service *orchestrator.Service, intervalSec int) Job {
+	return Job{
+		Tag:      "模型监控",
+		Name:     "输出分布巡检",
+		Interval: time.Duration(intervalSec) * time.Second,
+		Run:      service.CheckModelBehavior,
+	}
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// defaultJobTimeout 单次执行的默认超时
+const defaultJobTimeout = 30 * time.Second
+
+// Job 按固定间隔执行的后台任务
+type Job struct {
+	Tag      string        // 日志前缀，如 "风控"
+	Name     string        // 任务名称，如 "合约强平预警巡检"
+	Interval time.Duration // 执行间隔
+	Timeout  time.Duration // 单次执行超时，0 = 30 秒
+	Detail   string        // 启动日志附加的配置说明（可选）
+	Run      func(ctx context.Context) error
+	OnStop   func() // 停止前执行的收尾（可选），Stop 会等待其完成
+}
+
+// Periodic 定时执行单个 Job 的运行器
+type Periodic struct {
+	job  Job
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewPeriodic 创建定时任务运行器
+func NewPeriodic(job Job) *Periodic {
+	if job.Timeout <= 0 {
+		job.Timeout = defaultJobTimeout
+	}
+	return &Periodic{
+		job:  job,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Start 启动任务（非阻塞）
+func (p *Periodic) Start() {
+	if p.job.Detail != "" {
+		log.Printf("[%s] %s已启动 间隔=%s %s", p.job.Tag, p.job.Name, p.job.Interval, p.job.Detail)
+	} else {
+		log.Printf("[%s] %s已启动 间隔=%s", p.job.Tag, p.job.Name, p.job.Interval)
+	}
+
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.job.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.runOnce()
+			case <-p.stop:
+				if p.job.OnStop != nil {
+					p.job.OnStop()
+				}
+				log.Printf("[%s] %s已停止", p.job.Tag, p.job.Name)
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止任务，等待进行中的执行与收尾完成
+func (p *Periodic) Stop() {
+	close(p.stop)
+	<-p.done
+}
+
+func (p *Periodic) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.job.Timeout)
+	defer cancel()
+	if err := p.job.Run(ctx); err != nil {
+		log.Printf("[%s] ✘ %s失败: %v", p.job.Tag, p.job.Name, err)
+	}
+}
@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ai_quant/internal/domain"
+	"ai_quant/internal/store"
+)
+
+// Pruner 定时清理过旧的 cycle_logs 以及失败/被拒绝的周期
+type Pruner struct {
+	repo                store.Repository
+	interval            time.Duration
+	logRetentionDays    int
+	failedRetentionDays int
+	stop                chan struct{}
+}
+
+// NewPruner 创建数据清理任务，保留天数为 0 表示不清理对应数据
+func NewPruner(repo store.Repository, intervalMin, logRetentionDays, failedRetentionDays int) *Pruner {
+	if intervalMin <= 0 {
+		intervalMin = 60
+	}
+	return &Pruner{
+		repo:                repo,
+		interval:            time.Duration(intervalMin) * time.Minute,
+		logRetentionDays:    logRetentionDays,
+		failedRetentionDays: failedRetentionDays,
+		stop:                make(chan struct{}),
+	}
+}
+
+// Start 启动清理任务（非阻塞，启动后立即执行一次）
+func (p *Pruner) Start() {
+	log.Printf("[清理] 已启动 间隔=%s 日志保留=%d天 失败周期保留=%d天",
+		p.interval, p.logRetentionDays, p.failedRetentionDays)
+
+	go func() {
+		p.runOnce()
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.runOnce()
+			case <-p.stop:
+				log.Println("[清理] 已停止")
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止清理任务
+func (p *Pruner) Stop() {
+	close(p.stop)
+}
+
+func (p *Pruner) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+
+	if p.logRetentionDays > 0 {
+		before := now.AddDate(0, 0, -p.logRetentionDays)
+		n, err := p.repo.PruneCycleLogs(ctx, before)
+		if err != nil {
+			log.Printf("[清理] ✘ 清理周期日志失败: %v", err)
+		} else if n > 0 {
+			log.Printf("[清理] ✔ 已删除 %d 条 %s 之前的周期日志", n, before.Format("2006-01-02 15:04"))
+		}
+	}
+
+	if p.failedRetentionDays > 0 {
+		before := now.AddDate(0, 0, -p.failedRetentionDays)
+		statuses := []domain.CycleStatus{domain.CycleStatusFailed, domain.CycleStatusRejected}
+		n, err := p.repo.PruneCycles(ctx, statuses, before)
+		if err != nil {
+			log.Printf("[清理] ✘ 清理失败/拒绝周期失败: %v", err)
+		} else if n > 0 {
+			log.Printf("[清理] ✔ 已删除 %d 个 %s 之前的失败/拒绝周期", n, before.Format("2006-01-02 15:04"))
+		}
+	}
+}
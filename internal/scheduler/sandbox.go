@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ai_quant/internal/market"
+	"ai_quant/internal/orchestrator"
+)
+
+// SandboxRunner 定时让每个沙盒会话按自己配置的交易对跑一次周期并模拟成交
+type SandboxRunner struct {
+	service  *orchestrator.Service
+	interval time.Duration
+	stop     chan struct{}
+}
+
+// NewSandboxRunner 创建沙盒定时任务
+func NewSandboxRunner(service *orchestrator.Service, intervalSec int) *SandboxRunner {
+	return &SandboxRunner{
+		service:  service,
+		interval: time.Duration(intervalSec) * time.Second,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 启动任务（非阻塞）
+func (r *SandboxRunner) Start() {
+	log.Printf("[沙盒] 定时运行已启动 间隔=%s", r.interval)
+
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.runAll()
+			case <-r.stop:
+				log.Println("[沙盒] 定时运行已停止")
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止任务
+func (r *SandboxRunner) Stop() {
+	close(r.stop)
+}
+
+func (r *SandboxRunner) runAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	sandboxes, err := r.service.ListSandboxes(ctx)
+	cancel()
+	if err != nil {
+		log.Printf("[沙盒] ✘ 查询沙盒失败: %v", err)
+		return
+	}
+
+	// 同一轮内各沙盒共用行情缓存，保证对比实验看到的是同一份市场数据
+	tickCtx := market.WithTickCache(context.Background())
+	for _, sb := range sandboxes {
+		for _, pair := range splitPairs(sb.Pairs) {
+			ctx, cancel := context.WithTimeout(tickCtx, 90*time.Second)
+			_, err := r.service.RunSandboxCycle(ctx, sb.ID, pair)
+			cancel()
+			if err != nil {
+				log.Printf("[沙盒] ✘ %s %s 运行失败: %v", sb.Name, pair, err)
+			}
+		}
+	}
+}
@@ -2,57 +2,82 @@ package scheduler
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"ai_quant/internal/domain"
+	"ai_quant/internal/market"
 	"ai_quant/internal/orchestrator"
 )
 
-// Scheduler 定时自动执行交易周期
+// minInterval 通过接口调整间隔时的下限，避免频繁调用大模型
+const minInterval = 10 * time.Second
+
+// Scheduler 定时自动执行交易周期；暂停、间隔和交易对可在运行时调整并持久化
 type Scheduler struct {
-	service  *orchestrator.Service
-	interval time.Duration
-	pairs    []string
-	stop     chan struct{}
+	service *orchestrator.Service
+	stop    chan struct{}
+	reset   chan time.Duration
+
+	mu        sync.Mutex
+	interval  time.Duration
+	pairs     []string
+	paused    bool
+	lastRunAt *time.Time
 }
 
-// New 创建定时调度器
-func New(service *orchestrator.Service, intervalSec int, pairsStr string) *Scheduler {
-	pairs := []string{}
-	for _, p := range strings.Split(pairsStr, ",") {
-		p = strings.TrimSpace(p)
-		if p != "" {
-			pairs = append(pairs, strings.ToUpper(p))
-		}
-	}
+// New 创建定时调度器；数据库中有通过接口保存的配置时以保存的为准，paused 为未保存配置时的初始状态
+func New(service *orchestrator.Service, intervalSec int, pairsStr string, paused bool) *Scheduler {
+	pairs := splitPairs(pairsStr)
 	if len(pairs) == 0 {
 		pairs = []string{"BTC/USDT"}
 	}
 
-	return &Scheduler{
+	s := &Scheduler{
 		service:  service,
 		interval: time.Duration(intervalSec) * time.Second,
 		pairs:    pairs,
+		paused:   paused,
 		stop:     make(chan struct{}),
+		reset:    make(chan time.Duration, 1),
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if st, err := service.LoadSchedulerState(ctx); err != nil {
+		log.Printf("[定时器] ⚠ 读取保存的配置失败，使用环境变量配置: %v", err)
+	} else if st != nil {
+		s.paused = st.Paused
+		if st.IntervalSec > 0 {
+			s.interval = time.Duration(st.IntervalSec) * time.Second
+		}
+		s.pairs = st.Pairs
+		log.Printf("[定时器] 已恢复保存的配置（%s 更新）", st.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	return s
 }
 
 // Start 启动定时任务（非阻塞，在后台 goroutine 运行）
 func (s *Scheduler) Start() {
-	log.Printf("[定时器] 已启动 间隔=%s 交易对=%v", s.interval, s.pairs)
+	st := s.State()
+	log.Printf("[定时器] 已启动 间隔=%ds 交易对=%v 暂停=%v", st.IntervalSec, st.Pairs, st.Paused)
 
 	go func() {
 		// 启动后立即执行一次
 		// s.runAll()
-		ticker := time.NewTicker(s.interval)
+		ticker := time.NewTicker(s.currentInterval())
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ticker.C:
 				s.runAll()
+			case d := <-s.reset:
+				ticker.Reset(d)
 			case <-s.stop:
 				log.Println("[定时器] 已停止")
 				return
@@ -66,28 +91,214 @@ func (s *Scheduler) Stop() {
 	close(s.stop)
 }
 
-func (s *Scheduler) runAll() {
-	for _, pair := range s.pairs {
-		s.runOnce(pair)
+func (s *Scheduler) currentInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.interval
+}
+
+// State 当前运行时配置
+func (s *Scheduler) State() domain.SchedulerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stateLocked()
+}
+
+func (s *Scheduler) stateLocked() domain.SchedulerState {
+	return domain.SchedulerState{
+		Paused:      s.paused,
+		IntervalSec: int(s.interval / time.Second),
+		Pairs:       slices.Clone(s.pairs),
+		LastRunAt:   s.lastRunAt,
+		UpdatedAt:   time.Now().UTC(),
 	}
 }
 
-func (s *Scheduler) runOnce(pair string) {
-	log.Printf("[定时器] 自动执行 %s", pair)
+// update 在锁内修改配置并持久化；保存失败时回滚，保证内存与数据库一致
+func (s *Scheduler) update(ctx context.Context, change func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
-	defer cancel()
+	prevPaused, prevInterval, prevPairs := s.paused, s.interval, slices.Clone(s.pairs)
+	if err := change(); err != nil {
+		return err
+	}
+	if err := s.service.SaveSchedulerState(ctx, s.stateLocked()); err != nil {
+		s.paused, s.interval, s.pairs = prevPaused, prevInterval, prevPairs
+		return err
+	}
+	return nil
+}
+
+// Pause 暂停自动交易（已在执行的周期不受影响）
+func (s *Scheduler) Pause(ctx context.Context) error {
+	if err := s.update(ctx, func() error { s.paused = true; return nil }); err != nil {
+		return err
+	}
+	log.Println("[定时器] ⏸ 已暂停")
+	return nil
+}
+
+// Resume 恢复自动交易
+func (s *Scheduler) Resume(ctx context.Context) error {
+	if err := s.update(ctx, func() error { s.paused = false; return nil }); err != nil {
+		return err
+	}
+	log.Println("[定时器] ▶ 已恢复")
+	return nil
+}
+
+// SetInterval 调整执行间隔，从调整时刻重新计时
+func (s *Scheduler) SetInterval(ctx context.Context, sec int) error {
+	d := time.Duration(sec) * time.Second
+	if d < minInterval {
+		return fmt.Errorf("执行间隔不能小于 %s", minInterval)
+	}
+	if err := s.update(ctx, func() error { s.interval = d; return nil }); err != nil {
+		return err
+	}
+	// 只保留最新的一次调整
+	select {
+	case <-s.reset:
+	default:
+	}
+	s.reset <- d
+	log.Printf("[定时器] 执行间隔已调整为 %s", d)
+	return nil
+}
+
+// AddPair 添加自动交易的交易对
+func (s *Scheduler) AddPair(ctx context.Context, pair string) error {
+	pair = strings.ToUpper(strings.TrimSpace(pair))
+	if !strings.Contains(pair, "/") {
+		return fmt.Errorf("交易对格式错误: %q（应为 BTC/USDT）", pair)
+	}
+	err := s.update(ctx, func() error {
+		if slices.Contains(s.pairs, pair) {
+			return fmt.Errorf("%s 已在自动交易列表中", pair)
+		}
+		s.pairs = append(s.pairs, pair)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("[定时器] ✚ 已添加交易对 %s", pair)
+	return nil
+}
 
-	result, err := s.service.RunCycle(ctx, orchestrator.RunRequest{
-		Pair:      pair,
-		Snapshot:  nil,
-		Portfolio: domain.PortfolioState{},
+// RemovePair 移除自动交易的交易对（已有持仓不受影响）
+func (s *Scheduler) RemovePair(ctx context.Context, pair string) error {
+	pair = strings.ToUpper(strings.TrimSpace(pair))
+	err := s.update(ctx, func() error {
+		i := slices.Index(s.pairs, pair)
+		if i < 0 {
+			return fmt.Errorf("%s 不在自动交易列表中", pair)
+		}
+		s.pairs = slices.Delete(s.pairs, i, i+1)
+		return nil
 	})
 	if err != nil {
-		log.Printf("[定时器] ✘ %s 执行失败: %v", pair, err)
+		return err
+	}
+	log.Printf("[定时器] ✖ 已移除交易对 %s", pair)
+	return nil
+}
+
+func (s *Scheduler) runAll() {
+	s.mu.Lock()
+	if s.paused {
+		s.mu.Unlock()
 		return
 	}
+	pairs := slices.Clone(s.pairs)
+	now := time.Now().UTC()
+	s.lastRunAt = &now
+	s.mu.Unlock()
+
+	// 同一 tick 内各交易对共享 BTC 参考快照、恐慌贪婪指数、Google 热搜等公共数据
+	tickCtx := market.WithTickCache(context.Background())
+	if s.service.PortfolioMode() {
+		if s.runPlanned(tickCtx, pairs) {
+			return
+		}
+	}
+	s.runQueued(tickCtx, pairs, nil)
+}
+
+// runPlanned 组合分配模式：先生成分配计划，再按优先级执行各交易对；
+// 计划失败时返回 false，由调用方回退为逐个交易对独立决策
+func (s *Scheduler) runPlanned(ctx context.Context, pairs []string) bool {
+	planCtx, cancel := context.WithTimeout(ctx, 90*time.Second)
+	plan, err := s.service.PlanAllocation(planCtx, pairs)
+	cancel()
+	if err != nil {
+		log.Printf("[定时器] ⚠ 组合分配计划失败: %v，按交易对独立执行", err)
+		return false
+	}
+	var (
+		planned     []string
+		allocations []*domain.PairAllocation
+	)
+	for i := range plan.Allocations {
+		a := &plan.Allocations[i]
+		// 不建议开仓且没有持仓可处理：跳过，节省一次大模型调用
+		if a.Action == domain.AllocationAvoid && !s.service.HasHolding(ctx, a.Pair) {
+			log.Printf("[定时器] ⏭ %s 分配计划为 avoid 且无持仓，跳过", a.Pair)
+			continue
+		}
+		planned = append(planned, a.Pair)
+		allocations = append(allocations, a)
+	}
+	s.runQueued(ctx, planned, allocations)
+	return true
+}
+
+// runQueued 按顺序把各交易对的周期放入队列（组合模式下即分配计划的优先级），等待本轮全部结束，
+// 避免下一次定时触发与本轮重叠；allocations 为 nil 时各交易对独立决策
+func (s *Scheduler) runQueued(ctx context.Context, pairs []string, allocations []*domain.PairAllocation) {
+	type queued struct {
+		pair string
+		wait func() (domain.CycleResult, error)
+	}
+	jobs := make([]queued, 0, len(pairs))
+	for i, pair := range pairs {
+		req := orchestrator.RunRequest{
+			Pair:        pair,
+			RequestedBy: "scheduler",
+		}
+		if allocations != nil {
+			req.Allocation = allocations[i]
+		}
+		// 组合状态由 orchestrator 在每个周期内根据订单与持仓自动计算；超时从开始执行起计时
+		wait, err := s.service.EnqueueCycle(ctx, req, orchestrator.PriorityScheduled, 90*time.Second)
+		if err != nil {
+			log.Printf("[定时器] ⏭ %s 未加入队列: %v", pair, err)
+			continue
+		}
+		log.Printf("[定时器] 自动执行 %s", pair)
+		jobs = append(jobs, queued{pair: pair, wait: wait})
+	}
+
+	for _, j := range jobs {
+		result, err := j.wait()
+		if err != nil {
+			log.Printf("[定时器] ✘ %s 执行失败: %v", j.pair, err)
+			continue
+		}
+		log.Printf("[定时器] ✔ %s 执行完成 状态=%s 信号=%s 置信度=%.2f",
+			j.pair, result.Cycle.Status, result.Signal.Side, result.Signal.Confidence)
+	}
+}
 
-	log.Printf("[定时器] ✔ %s 执行完成 状态=%s 信号=%s 置信度=%.2f",
-		pair, result.Cycle.Status, result.Signal.Side, result.Signal.Confidence)
+// splitPairs 解析逗号分隔的交易对列表
+func splitPairs(pairsStr string) []string {
+	pairs := []string{}
+	for _, p := range strings.Split(pairsStr, ",") {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			pairs = append(pairs, strings.ToUpper(p))
+		}
+	}
+	return pairs
 }
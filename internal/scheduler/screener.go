@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"ai_quant/internal/domain"
+	"ai_quant/internal/market"
+	"ai_quant/internal/orchestrator"
+	"ai_quant/internal/screener"
+)
+
+// Screener 定时扫描 Binance 全市场 24h 行情筛选候选交易对；开启 autoShadow 时把候选加入影子周期
+type Screener struct {
+	service  *orchestrator.Service
+	client   *market.Client
+	criteria screener.Criteria
+	interval time.Duration
+	shadow   *ShadowRunner // 为 nil 时只给出候选
+	stop     chan struct{}
+
+	mu     sync.Mutex
+	latest domain.ScreenerResult
+}
+
+// NewScreener 创建筛选任务；shadow 不为 nil 时候选自动加入影子周期
+func NewScreener(service *orchestrator.Service, endpoints market.Endpoints, criteria screener.Criteria, intervalMin int, shadow *ShadowRunner) *Screener {
+	if intervalMin <= 0 {
+		intervalMin = 60
+	}
+	client := market.NewClient()
+	client.SetEndpoints(endpoints)
+	return &Screener{
+		service:  service,
+		client:   client,
+		criteria: criteria,
+		interval: time.Duration(intervalMin) * time.Minute,
+		shadow:   shadow,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 启动任务（非阻塞，启动后立即扫描一次）
+func (s *Screener) Start() {
+	log.Printf("[筛选] 已启动 间隔=%s 计价=%s 最低成交额=%.0f 涨跌≥%.1f%% 振幅≥%.1f%% 只看热门=%v 自动影子=%v",
+		s.interval, s.criteria.Quote, s.criteria.MinQuoteVolume, s.criteria.MinChangePct, s.criteria.MinRangePct,
+		s.criteria.RequireTrending, s.shadow != nil)
+
+	go func() {
+		s.runOnce()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.runOnce()
+			case <-s.stop:
+				log.Println("[筛选] 已停止")
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止任务
+func (s *Screener) Stop() {
+	close(s.stop)
+}
+
+// Latest 最近一次筛选结果
+func (s *Screener) Latest() domain.ScreenerResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latest
+}
+
+// Scan 立即筛选一次并保存结果
+func (s *Screener) Scan(ctx context.Context) domain.ScreenerResult {
+	res := domain.ScreenerResult{ScannedAt: time.Now().UTC(), Candidates: []domain.PairCandidate{}}
+
+	tickers, err := s.client.FetchAllTickers(ctx)
+	if err != nil {
+		res.Error = "获取全市场行情失败: " + err.Error()
+		log.Printf("[筛选] ✘ %s", res.Error)
+		s.save(res)
+		return res
+	}
+	trending, err := s.client.TrendingSymbols(ctx)
+	if err != nil {
+		log.Printf("[筛选] ⚠ 获取 CoinGecko 热门失败: %v，本次不按热度筛选", err)
+	}
+
+	res.Candidates, res.Scanned = screener.Screen(tickers, trending, s.criteria, s.skipPairs())
+	for i := range res.Candidates {
+		c := &res.Candidates[i]
+		if s.shadow != nil {
+			c.ShadowAdded = s.shadow.AddPair(c.Pair)
+		}
+	}
+	log.Printf("[筛选] ✔ 扫描 %d 个交易对，候选 %d 个", res.Scanned, len(res.Candidates))
+	s.save(res)
+	return res
+}
+
+func (s *Screener) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	s.Scan(ctx)
+}
+
+func (s *Screener) save(res domain.ScreenerResult) {
+	s.mu.Lock()
+	s.latest = res
+	s.mu.Unlock()
+}
+
+// skipPairs 已在自动交易或影子周期中的交易对不再作为候选
+func (s *Screener) skipPairs() map[string]bool {
+	skip := make(map[string]bool)
+	if sc := s.service.Scheduler(); sc != nil {
+		for _, p := range sc.State().Pairs {
+			skip[p] = true
+		}
+	}
+	if s.shadow != nil {
+		for _, p := range s.shadow.Pairs() {
+			skip[p] = true
+		}
+	}
+	return skip
+}
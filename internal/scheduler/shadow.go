@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"ai_quant/internal/market"
+	"ai_quant/internal/orchestrator"
+)
+
+// ShadowRunner 定时对只模拟的交易对跑预览周期，并在观察期后评估假设盈亏
+type ShadowRunner struct {
+	service  *orchestrator.Service
+	interval time.Duration
+	horizon  time.Duration
+	stop     chan struct{}
+
+	mu    sync.Mutex
+	pairs []string
+}
+
+// NewShadowRunner 创建影子周期任务；同时在实盘列表 livePairs 中的交易对会被忽略
+func NewShadowRunner(service *orchestrator.Service, intervalSec, horizonMin int, pairsStr, livePairs string) *ShadowRunner {
+	live := make(map[string]bool)
+	for _, p := range splitPairs(livePairs) {
+		live[p] = true
+	}
+	pairs := []string{}
+	for _, p := range splitPairs(pairsStr) {
+		if live[p] {
+			log.Printf("[影子] ⚠ %s 已在 AUTO_RUN_PAIRS 中实盘运行，不再做影子周期", p)
+			continue
+		}
+		pairs = append(pairs, p)
+	}
+
+	return &ShadowRunner{
+		service:  service,
+		interval: time.Duration(intervalSec) * time.Second,
+		horizon:  time.Duration(horizonMin) * time.Minute,
+		pairs:    pairs,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 启动任务（非阻塞）；交易对为空时也会启动，等待筛选任务加入
+func (r *ShadowRunner) Start() {
+	log.Printf("[影子] 已启动 间隔=%s 观察期=%s 交易对=%v", r.interval, r.horizon, r.pairs)
+
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.runAll()
+			case <-r.stop:
+				log.Println("[影子] 已停止")
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止任务
+func (r *ShadowRunner) Stop() {
+	close(r.stop)
+}
+
+// AddPair 运行时加入影子交易对（如筛选出的候选），已存在时返回 false
+func (r *ShadowRunner) AddPair(pair string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.pairs {
+		if p == pair {
+			return false
+		}
+	}
+	r.pairs = append(r.pairs, pair)
+	log.Printf("[影子] + %s 已加入影子周期", pair)
+	return true
+}
+
+// Pairs 当前的影子交易对
+func (r *ShadowRunner) Pairs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.pairs...)
+}
+
+func (r *ShadowRunner) runAll() {
+	tickCtx := market.WithTickCache(context.Background())
+	for _, pair := range r.Pairs() {
+		ctx, cancel := context.WithTimeout(tickCtx, 90*time.Second)
+		sc, err := r.service.RunShadowCycle(ctx, pair)
+		cancel()
+		if err != nil {
+			log.Printf("[影子] ✘ %s 模拟失败: %v", pair, err)
+			continue
+		}
+		log.Printf("[影子] ✔ %s 信号=%s 置信度=%.2f 风控通过=%v 金额=%.2f",
+			pair, sc.Side, sc.Confidence, sc.Approved, sc.StakeUSDT)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	if err := r.service.EvaluateShadowCycles(ctx, r.horizon); err != nil {
+		log.Printf("[影子] ✘ 评估失败: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+// Package screener 按成交额、波动与热度从全市场 24h 行情中筛选候选交易对，
+// 让交易范围不再只依赖固定的 AUTO_RUN_PAIRS。
+package screener
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"ai_quant/internal/domain"
+	"ai_quant/internal/market"
+)
+
+// Criteria 筛选条件：成交额为硬性门槛，涨跌幅 / 振幅 / 热门满足任意一项即入选
+type Criteria struct {
+	Quote           string          // 计价币，如 USDT
+	MinQuoteVolume  float64         // 24h 最低成交额
+	MinChangePct    float64         // 24h 涨跌幅绝对值下限，0 = 不按涨跌幅入选
+	MinRangePct     float64         // 24h 振幅下限，0 = 不按振幅入选
+	RequireTrending bool            // 只保留 CoinGecko 热门币种
+	MaxCandidates   int             // 最多返回的候选数
+	Exclude         map[string]bool // 排除的基础币种（稳定币等）
+}
+
+// Screen 对全市场行情打分排序，返回候选（不含 skip 中已在交易 / 影子运行的交易对）
+func Screen(tickers []market.Ticker24h, trending []string, c Criteria, skip map[string]bool) ([]domain.PairCandidate, int) {
+	quote := strings.ToUpper(c.Quote)
+	if quote == "" {
+		quote = "USDT"
+	}
+	rank := make(map[string]int, len(trending))
+	for i, s := range trending {
+		if _, ok := rank[s]; !ok {
+			rank[s] = i + 1
+		}
+	}
+
+	scanned := 0
+	var out []domain.PairCandidate
+	for _, t := range tickers {
+		base, ok := strings.CutSuffix(t.Symbol, quote)
+		if !ok || base == "" || c.Exclude[base] || t.LastPrice <= 0 {
+			continue
+		}
+		pair := base + "/" + quote
+		if skip[pair] {
+			continue
+		}
+		scanned++
+		if t.QuoteVolume < c.MinQuoteVolume {
+			continue
+		}
+
+		cand := domain.PairCandidate{
+			Pair:            pair,
+			LastPrice:       t.LastPrice,
+			Change24hPct:    t.ChangePct,
+			QuoteVolumeUSDT: t.QuoteVolume,
+			TrendingRank:    rank[base],
+		}
+		if t.LowPrice > 0 {
+			cand.RangePct = (t.HighPrice - t.LowPrice) / t.LowPrice * 100
+		}
+		if c.RequireTrending && cand.TrendingRank == 0 {
+			continue
+		}
+
+		if c.MinChangePct > 0 && math.Abs(cand.Change24hPct) >= c.MinChangePct {
+			cand.Reasons = append(cand.Reasons, fmt.Sprintf("24h 涨跌 %.2f%%", cand.Change24hPct))
+		}
+		if c.MinRangePct > 0 && cand.RangePct >= c.MinRangePct {
+			cand.Reasons = append(cand.Reasons, fmt.Sprintf("24h 振幅 %.2f%%", cand.RangePct))
+		}
+		if cand.TrendingRank > 0 {
+			cand.Reasons = append(cand.Reasons, fmt.Sprintf("CoinGecko 热门 #%d", cand.TrendingRank))
+		}
+		if len(cand.Reasons) == 0 {
+			continue
+		}
+		cand.Score = score(cand)
+		out = append(out, cand)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if c.MaxCandidates > 0 && len(out) > c.MaxCandidates {
+		out = out[:c.MaxCandidates]
+	}
+	return out, scanned
+}
+
+// score 成交额（对数）+ 波动 + 热门加分，只用于排序
+func score(c domain.PairCandidate) float64 {
+	s := math.Log10(math.Max(c.QuoteVolumeUSDT, 1)) + math.Abs(c.Change24hPct)/5 + c.RangePct/10
+	if c.TrendingRank > 0 {
+		s += 3 - float64(c.TrendingRank)/10
+	}
+	return math.Round(s*100) / 100
+}
+
+// ParseExclude 解析逗号分隔的基础币种列表
+func ParseExclude(s string) map[string]bool {
+	out := make(map[string]bool)
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.ToUpper(strings.TrimSpace(p)); p != "" {
+			out[p] = true
+		}
+	}
+	return out
+}
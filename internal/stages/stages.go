@@ -0,0 +1,102 @@
+// Package stages 交易流水线各环节的运行时开关：关闭后对应环节直接跳过（使用默认值），
+// 用于削减成本 / 延迟或排查故障，无需改代码或重启。
+package stages
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Stage 可单独关闭的流水线环节
+type Stage string
+
+const (
+	PositionStrategy Stage = "position_strategy" // 建仓策略：关闭后按风控金额一次性建仓，止盈止损取风险预设
+	News             Stage = "news"              // 新闻抓取（CryptoPanic）：关闭后提示词不含新闻
+)
+
+var all = []Stage{PositionStrategy, News}
+
+var (
+	mu       sync.RWMutex
+	disabled = make(map[Stage]bool)
+)
+
+// Configure 解析 "news,position_strategy" 格式的关闭列表，替换当前设置
+func Configure(spec string) error {
+	next := make(map[Stage]bool)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		st, err := parse(item)
+		if err != nil {
+			return err
+		}
+		next[st] = true
+	}
+	mu.Lock()
+	disabled = next
+	mu.Unlock()
+	return nil
+}
+
+func parse(name string) (Stage, error) {
+	for _, st := range all {
+		if Stage(name) == st {
+			return st, nil
+		}
+	}
+	names := make([]string, len(all))
+	for i, st := range all {
+		names[i] = string(st)
+	}
+	return "", fmt.Errorf("未知流水线环节 %q（支持 %s）", name, strings.Join(names, " / "))
+}
+
+// Enabled 环节当前是否启用
+func Enabled(st Stage) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return !disabled[st]
+}
+
+// Set 运行时启用 / 关闭某个环节
+func Set(name string, enabled bool) error {
+	st, err := parse(strings.ToLower(strings.TrimSpace(name)))
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	disabled[st] = !enabled
+	mu.Unlock()
+	return nil
+}
+
+// Snapshot 全部环节的启用状态
+func Snapshot() map[Stage]bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make(map[Stage]bool, len(all))
+	for _, st := range all {
+		out[st] = !disabled[st]
+	}
+	return out
+}
+
+// Disabled 当前关闭的环节（按名称排序，用于日志）
+func Disabled() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	var out []string
+	for st, off := range disabled {
+		if off {
+			out = append(out, string(st))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
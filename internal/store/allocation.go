@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"ai_quant/internal/domain"
+)
+
+// InsertAllocationPlan 保存组合分配计划
+func (r *SQLiteRepository) InsertAllocationPlan(ctx context.Context, p domain.AllocationPlan) error {
+	allocations, err := json.Marshal(p.Allocations)
+	if err != nil {
+		return fmt.Errorf("marshal allocations: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO allocation_plans (id, summary, allocations, model_name, prompt_tokens, completion_tokens, cost_usd, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.ID, p.Summary, string(allocations), p.ModelName, p.PromptTokens, p.CompletionTokens, p.CostUSD, p.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert allocation plan: %w", err)
+	}
+	return r.appendLLMCost(ctx, p.ID, domain.LLMCost{
+		CycleID:          p.ID,
+		Pair:             "*",
+		ModelName:        p.ModelName,
+		PromptTokens:     p.PromptTokens,
+		CompletionTokens: p.CompletionTokens,
+		CostUSD:          p.CostUSD,
+		CreatedAt:        p.CreatedAt,
+	})
+}
+
+// LatestAllocationPlan 获取最近一次组合分配计划，没有时返回 nil
+func (r *SQLiteRepository) LatestAllocationPlan(ctx context.Context) (*domain.AllocationPlan, error) {
+	var p domain.AllocationPlan
+	var allocations string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, summary, allocations, model_name, prompt_tokens, completion_tokens, cost_usd, created_at
+		 FROM allocation_plans ORDER BY created_at DESC LIMIT 1`,
+	).Scan(&p.ID, &p.Summary, &allocations, &p.ModelName, &p.PromptTokens, &p.CompletionTokens, &p.CostUSD, &p.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询组合分配计划: %w", err)
+	}
+	if err := json.Unmarshal([]byte(allocations), &p.Allocations); err != nil {
+		return nil, fmt.Errorf("解析组合分配计划: %w", err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+const approvalColumns = `cycle_id, pair, side, stake_usdt, leverage, reason, status, requested_by, decided_by, note, expires_at, created_at, decided_at`
+
+// InsertCycleApproval 保存待审批的下单
+func (r *SQLiteRepository) InsertCycleApproval(ctx context.Context, a domain.CycleApproval) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO cycle_approvals (`+approvalColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, '', ?, ?, ?, NULL)`,
+		a.CycleID, a.Pair, string(a.Side), a.StakeUSDT, a.Leverage, a.Reason, a.Status, a.RequestedBy, a.Note, a.ExpiresAt.UTC(), a.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert cycle approval: %w", err)
+	}
+	return nil
+}
+
+// GetCycleApproval 查询周期的审批记录，没有时返回 nil
+func (r *SQLiteRepository) GetCycleApproval(ctx context.Context, cycleID string) (*domain.CycleApproval, error) {
+	a, err := scanApproval(r.db.QueryRowContext(ctx,
+		`SELECT `+approvalColumns+` FROM cycle_approvals WHERE cycle_id = ?`, cycleID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询审批记录: %w", err)
+	}
+	return &a, nil
+}
+
+// ListCycleApprovals 按状态查询审批记录（status 为空时返回全部），按创建时间倒序
+func (r *SQLiteRepository) ListCycleApprovals(ctx context.Context, status string, limit int) ([]domain.CycleApproval, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+approvalColumns+` FROM cycle_approvals WHERE (? = '' OR status = ?) ORDER BY created_at DESC LIMIT ?`,
+		status, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询审批记录: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.CycleApproval, 0)
+	for rows.Next() {
+		a, err := scanApproval(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// DecideCycleApproval 把待审批记录改为 status；记录已被处理（并发审批 / 已超时）时返回 false
+func (r *SQLiteRepository) DecideCycleApproval(ctx context.Context, cycleID, status, by, note string) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE cycle_approvals SET status = ?, decided_by = ?, note = ?, decided_at = ? WHERE cycle_id = ? AND status = ?`,
+		status, by, note, time.Now().UTC(), cycleID, domain.ApprovalPending)
+	if err != nil {
+		return false, fmt.Errorf("update cycle approval: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func scanApproval(row interface{ Scan(...any) error }) (domain.CycleApproval, error) {
+	var a domain.CycleApproval
+	var side string
+	var decidedAt sql.NullTime
+	if err := row.Scan(&a.CycleID, &a.Pair, &side, &a.StakeUSDT, &a.Leverage, &a.Reason, &a.Status, &a.RequestedBy, &a.DecidedBy, &a.Note,
+		&a.ExpiresAt, &a.CreatedAt, &decidedAt); err != nil {
+		return a, err
+	}
+	a.Side = domain.Side(side)
+	if decidedAt.Valid {
+		t := decidedAt.Time
+		a.DecidedAt = &t
+	}
+	return a, nil
+}
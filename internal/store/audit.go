@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"ai_quant/internal/domain"
+)
+
+// InsertAuditEntry 追加一条操作审计记录
+func (r *SQLiteRepository) InsertAuditEntry(ctx context.Context, e domain.AuditEntry) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO audit_log (actor, action, path, params, payload_sha256, status, request_id, client_ip, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Actor, e.Action, e.Path, e.Params, e.PayloadSHA256, e.Status, e.RequestID, e.ClientIP, e.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("写入审计日志: %w", err)
+	}
+	return nil
+}
+
+// ListAuditLog 分页查询审计日志（按时间倒序），actor 为空时返回全部调用方
+func (r *SQLiteRepository) ListAuditLog(ctx context.Context, actor string, q domain.ListQuery) ([]domain.AuditEntry, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM audit_log WHERE (? = '' OR actor = ?)`, actor, actor,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("统计审计日志: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, actor, action, path, params, payload_sha256, status, request_id, client_ip, created_at
+		FROM audit_log
+		WHERE (? = '' OR actor = ?)
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+	`, actor, actor, q.PageSize, q.Offset())
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询审计日志: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.AuditEntry, 0)
+	for rows.Next() {
+		var e domain.AuditEntry
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.Path, &e.Params, &e.PayloadSHA256,
+			&e.Status, &e.RequestID, &e.ClientIP, &e.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("扫描审计日志: %w", err)
+		}
+		list = append(list, e)
+	}
+	return list, total, rows.Err()
+}
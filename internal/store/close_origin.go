@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"ai_quant/internal/domain"
+)
+
+// ListPairFills 交易对全部已成交订单（按时间升序），附带所属周期的交易策略与建仓策略
+func (r *SQLiteRepository) ListPairFills(ctx context.Context, pair string) ([]domain.FillOrigin, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT o.id, o.cycle_id, o.pair, o.side, o.status, o.filled_price, o.filled_qty, o.created_at,
+			COALESCE(c.strategy, ''),
+			COALESCE((SELECT ps.strategy FROM position_strategies ps WHERE ps.cycle_id = o.cycle_id ORDER BY ps.created_at DESC LIMIT 1), '')
+		FROM orders o
+		LEFT JOIN cycles c ON c.id = o.cycle_id
+		WHERE o.pair = ? AND o.status IN (`+filledStatuses+`)
+		  AND o.filled_qty > 0 AND o.filled_price > 0
+		ORDER BY o.created_at ASC
+	`, pair)
+	if err != nil {
+		return nil, fmt.Errorf("查询成交记录: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.FillOrigin, 0)
+	for rows.Next() {
+		var f domain.FillOrigin
+		var side string
+		if err := rows.Scan(&f.ID, &f.CycleID, &f.Pair, &side, &f.Status, &f.FilledPrice, &f.FilledQuantity, &f.CreatedAt,
+			&f.Strategy, &f.EntryStrategy); err != nil {
+			return nil, fmt.Errorf("扫描成交记录: %w", err)
+		}
+		f.Side = domain.Side(side)
+		list = append(list, f)
+	}
+	return list, rows.Err()
+}
+
+// InsertCloseOrigins 保存平仓归因
+func (r *SQLiteRepository) InsertCloseOrigins(ctx context.Context, origins []domain.CloseOrigin) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, o := range origins {
+		var entryAt any
+		if !o.EntryAt.IsZero() {
+			entryAt = o.EntryAt.UTC()
+		}
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO close_origins (close_order_id, close_cycle_id, pair, entry_order_id, entry_cycle_id, strategy,
+			 entry_strategy, quantity, entry_price, exit_price, realized_pnl, entry_at, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			o.CloseOrderID, o.CloseCycleID, o.Pair, o.EntryOrderID, o.EntryCycleID, o.Strategy,
+			o.EntryStrategy, o.Quantity, o.EntryPrice, o.ExitPrice, o.RealizedPnL, entryAt, o.CreatedAt.UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("insert close origin: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListCloseOrigins 周期内平仓订单的开仓来源
+func (r *SQLiteRepository) ListCloseOrigins(ctx context.Context, closeCycleID string) ([]domain.CloseOrigin, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, close_order_id, close_cycle_id, pair, entry_order_id, entry_cycle_id, strategy, entry_strategy,
+			quantity, entry_price, exit_price, realized_pnl, entry_at, created_at
+		FROM close_origins WHERE close_cycle_id = ? ORDER BY id ASC
+	`, closeCycleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询平仓归因: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.CloseOrigin, 0)
+	for rows.Next() {
+		var o domain.CloseOrigin
+		var entryAt sql.NullTime
+		if err := rows.Scan(&o.ID, &o.CloseOrderID, &o.CloseCycleID, &o.Pair, &o.EntryOrderID, &o.EntryCycleID,
+			&o.Strategy, &o.EntryStrategy, &o.Quantity, &o.EntryPrice, &o.ExitPrice, &o.RealizedPnL, &entryAt, &o.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描平仓归因: %w", err)
+		}
+		if entryAt.Valid {
+			o.EntryAt = entryAt.Time
+		}
+		list = append(list, o)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// dayOpenSentinel 占位记录：交易日基准已建立（即使当时没有持仓）
+const dayOpenSentinel = "*"
+
+// DayOpenUnrealized 交易日开始时各持仓的未实现盈亏基准；当日尚未建立基准时 ok 为 false
+func (r *SQLiteRepository) DayOpenUnrealized(ctx context.Context, day string) (map[string]float64, bool, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT pair, unrealized_pnl FROM day_open_marks WHERE day = ?`, day)
+	if err != nil {
+		return nil, false, fmt.Errorf("查询交易日盈亏基准: %w", err)
+	}
+	defer rows.Close()
+
+	marks := make(map[string]float64)
+	ok := false
+	for rows.Next() {
+		var pair string
+		var pnl float64
+		if err := rows.Scan(&pair, &pnl); err != nil {
+			return nil, false, fmt.Errorf("扫描交易日盈亏基准: %w", err)
+		}
+		ok = true
+		if pair != dayOpenSentinel {
+			marks[pair] = pnl
+		}
+	}
+	return marks, ok, rows.Err()
+}
+
+// InitDayOpenUnrealized 建立交易日盈亏基准；同一交易日只建立一次，已存在的记录保持不变
+func (r *SQLiteRepository) InitDayOpenUnrealized(ctx context.Context, day string, marks map[string]float64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	insert := func(pair string, pnl float64) error {
+		_, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO day_open_marks (day, pair, unrealized_pnl, created_at) VALUES (?, ?, ?, ?)`,
+			day, pair, pnl, now,
+		)
+		return err
+	}
+	if err := insert(dayOpenSentinel, 0); err != nil {
+		return fmt.Errorf("保存交易日盈亏基准: %w", err)
+	}
+	for pair, pnl := range marks {
+		if err := insert(pair, pnl); err != nil {
+			return fmt.Errorf("保存交易日盈亏基准: %w", err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// InsertEquitySnapshot 保存账户权益快照
+func (r *SQLiteRepository) InsertEquitySnapshot(ctx context.Context, e domain.EquitySnapshot) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO equity_snapshots (equity_usdt, cash_usdt, positions_usdt, created_at) VALUES (?, ?, ?, ?)`,
+		e.EquityUSDT, e.CashUSDT, e.PositionsUSDT, e.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert equity snapshot: %w", err)
+	}
+	return nil
+}
+
+// PeakEquitySince since 之后的最高账户权益，没有快照时返回 0
+func (r *SQLiteRepository) PeakEquitySince(ctx context.Context, since time.Time) (float64, error) {
+	var peak float64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(equity_usdt), 0) FROM equity_snapshots WHERE created_at >= ?`, since.UTC(),
+	).Scan(&peak)
+	if err != nil {
+		return 0, fmt.Errorf("查询权益峰值: %w", err)
+	}
+	return peak, nil
+}
+
+// ListEquitySnapshots since 之后的权益快照，按时间升序，最多 limit 条（取最新的）
+func (r *SQLiteRepository) ListEquitySnapshots(ctx context.Context, since time.Time, limit int) ([]domain.EquitySnapshot, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, equity_usdt, cash_usdt, positions_usdt, created_at FROM (
+			SELECT * FROM equity_snapshots WHERE created_at >= ? ORDER BY created_at DESC LIMIT ?
+		) ORDER BY created_at ASC`, since.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询权益快照: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.EquitySnapshot, 0)
+	for rows.Next() {
+		var e domain.EquitySnapshot
+		if err := rows.Scan(&e.ID, &e.EquityUSDT, &e.CashUSDT, &e.PositionsUSDT, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// SaveDrawdownHalt 保存回撤熔断状态（单行表）
+func (r *SQLiteRepository) SaveDrawdownHalt(ctx context.Context, h domain.DrawdownHalt) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO drawdown_halt (id, halted, reason, drawdown_pct, peak_usdt, equity_usdt, halted_at, resumed_at)
+		 VALUES (1, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(id) DO UPDATE SET halted = excluded.halted, reason = excluded.reason,
+		 drawdown_pct = excluded.drawdown_pct, peak_usdt = excluded.peak_usdt, equity_usdt = excluded.equity_usdt,
+		 halted_at = excluded.halted_at, resumed_at = excluded.resumed_at`,
+		h.Halted, h.Reason, h.DrawdownPct, h.PeakUSDT, h.EquityUSDT, h.HaltedAt, h.ResumedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("save drawdown halt: %w", err)
+	}
+	return nil
+}
+
+// GetDrawdownHalt 读取回撤熔断状态，从未触发过时返回 nil
+func (r *SQLiteRepository) GetDrawdownHalt(ctx context.Context) (*domain.DrawdownHalt, error) {
+	var h domain.DrawdownHalt
+	var haltedAt, resumedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		`SELECT halted, reason, drawdown_pct, peak_usdt, equity_usdt, halted_at, resumed_at FROM drawdown_halt WHERE id = 1`,
+	).Scan(&h.Halted, &h.Reason, &h.DrawdownPct, &h.PeakUSDT, &h.EquityUSDT, &haltedAt, &resumedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询回撤熔断状态: %w", err)
+	}
+	if haltedAt.Valid {
+		t := haltedAt.Time
+		h.HaltedAt = &t
+	}
+	if resumedAt.Valid {
+		t := resumedAt.Time
+		h.ResumedAt = &t
+	}
+	return &h, nil
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// ListSlippageOrders 获取 since 之后带预估成交价的已成交订单（用于执行质量统计），
+// includeSimulated=false 时排除模拟盘订单
+func (r *SQLiteRepository) ListSlippageOrders(ctx context.Context, since time.Time, includeSimulated bool) ([]domain.Order, error) {
+	query := `
+		SELECT id, cycle_id, pair, side, stake_usdt, expected_price, status, filled_price, filled_qty, COALESCE(fill_strategy, ''), created_at
+		FROM orders
+		WHERE status IN (` + filledStatuses + `)
+		  AND filled_qty > 0 AND filled_price > 0 AND expected_price > 0
+		  AND created_at >= ?`
+	if !includeSimulated {
+		query += ` AND status != 'simulated_filled'`
+	}
+	rows, err := r.db.QueryContext(ctx, query+` ORDER BY created_at ASC`, since)
+	if err != nil {
+		return nil, fmt.Errorf("查询滑点订单: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]domain.Order, 0)
+	for rows.Next() {
+		var o domain.Order
+		var side string
+		if err := rows.Scan(&o.ID, &o.CycleID, &o.Pair, &side, &o.StakeUSDT, &o.ExpectedPrice, &o.Status,
+			&o.FilledPrice, &o.FilledQuantity, &o.FillStrategy, &o.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描滑点订单: %w", err)
+		}
+		o.Side = domain.Side(side)
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SetPairLeverage 保存交易对的合约杠杆（手动调整后持久化，重启后仍生效）
+func (r *SQLiteRepository) SetPairLeverage(ctx context.Context, pair string, leverage int) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO pair_leverage (pair, leverage, updated_at) VALUES (?, ?, ?)
+		 ON CONFLICT(pair) DO UPDATE SET leverage = excluded.leverage, updated_at = excluded.updated_at`,
+		pair, leverage, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("save pair leverage: %w", err)
+	}
+	return nil
+}
+
+// GetPairLeverage 获取交易对保存的杠杆，未设置时返回 0
+func (r *SQLiteRepository) GetPairLeverage(ctx context.Context, pair string) (int, error) {
+	var lev int
+	err := r.db.QueryRowContext(ctx, `SELECT leverage FROM pair_leverage WHERE pair = ?`, pair).Scan(&lev)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询交易对杠杆: %w", err)
+	}
+	return lev, nil
+}
+
+// ListPairLeverage 列出全部按交易对保存的杠杆
+func (r *SQLiteRepository) ListPairLeverage(ctx context.Context) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT pair, leverage FROM pair_leverage ORDER BY pair`)
+	if err != nil {
+		return nil, fmt.Errorf("查询交易对杠杆: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]int)
+	for rows.Next() {
+		var pair string
+		var lev int
+		if err := rows.Scan(&pair, &lev); err != nil {
+			return nil, fmt.Errorf("扫描交易对杠杆: %w", err)
+		}
+		out[pair] = lev
+	}
+	return out, rows.Err()
+}
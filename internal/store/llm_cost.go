@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// appendLLMCost 追加一条大模型调用成本到台账；sourceID 为信号或组合分配计划的 ID，重复写入时忽略
+func (r *SQLiteRepository) appendLLMCost(ctx context.Context, sourceID string, c domain.LLMCost) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT OR IGNORE INTO llm_cost_ledger (source_id, cycle_id, pair, model_name, prompt_tokens, completion_tokens, cost_usd, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		sourceID, c.CycleID, c.Pair, c.ModelName, c.PromptTokens, c.CompletionTokens, c.CostUSD, c.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("记录大模型成本: %w", err)
+	}
+	return nil
+}
+
+// ListLLMCosts 查询 since 之后每次大模型调用的用量与成本（来自成本台账，组合分配计划的 pair 为 "*"），按时间升序
+func (r *SQLiteRepository) ListLLMCosts(ctx context.Context, since time.Time) ([]domain.LLMCost, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT cycle_id, pair, model_name, COALESCE(prompt_tokens, 0), COALESCE(completion_tokens, 0),
+			COALESCE(cost_usd, 0), created_at
+		FROM llm_cost_ledger
+		WHERE created_at >= ?
+		ORDER BY created_at ASC, id ASC
+	`, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("查询大模型成本: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]domain.LLMCost, 0)
+	for rows.Next() {
+		var c domain.LLMCost
+		if err := rows.Scan(&c.CycleID, &c.Pair, &c.ModelName, &c.PromptTokens, &c.CompletionTokens,
+			&c.CostUSD, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描大模型成本: %w", err)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// SumLLMCostSince 统计某时间点之后的大模型估算成本（USD），以成本台账为准，不受周期清理 / 删除影响
+func (r *SQLiteRepository) SumLLMCostSince(ctx context.Context, since time.Time) (float64, error) {
+	var total float64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(cost_usd), 0) FROM llm_cost_ledger WHERE created_at >= ?`, since.UTC(),
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("统计大模型成本: %w", err)
+	}
+	return total, nil
+}
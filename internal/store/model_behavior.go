@@ -0,0 +1,33 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// ListModelSignals 查询 since 之后实际调用过大模型的信号（模型名、方向、置信度），按时间倒序
+func (r *SQLiteRepository) ListModelSignals(ctx context.Context, since time.Time) ([]domain.Signal, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT COALESCE(model_name, ''), side, confidence, created_at
+		FROM signals
+		WHERE created_at >= ? AND (COALESCE(cost_usd, 0) > 0 OR COALESCE(total_tokens, 0) > 0)
+		ORDER BY created_at DESC
+	`, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("查询模型信号: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]domain.Signal, 0)
+	for rows.Next() {
+		var s domain.Signal
+		if err := rows.Scan(&s.ModelName, &s.Side, &s.Confidence, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描模型信号: %w", err)
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
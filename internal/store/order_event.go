@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// execer 同时适配 *sql.DB 与 *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// insertOrderEvent 追加一条订单状态记录
+func insertOrderEvent(ctx context.Context, db execer, e domain.OrderEvent) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO order_events (order_id, cycle_id, status, filled_qty, filled_price, detail, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.OrderID, e.CycleID, e.Status, e.FilledQty, e.FilledPrice, e.Detail, e.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert order event: %w", err)
+	}
+	return nil
+}
+
+// ListOrderEvents 按时间顺序获取订单的全部状态记录
+func (r *SQLiteRepository) ListOrderEvents(ctx context.Context, orderID string) ([]domain.OrderEvent, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, order_id, cycle_id, status, filled_qty, filled_price, detail, created_at
+		 FROM order_events WHERE order_id = ? ORDER BY id ASC`,
+		orderID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query order events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]domain.OrderEvent, 0)
+	for rows.Next() {
+		var e domain.OrderEvent
+		if err := rows.Scan(&e.ID, &e.OrderID, &e.CycleID, &e.Status, &e.FilledQty, &e.FilledPrice, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan order event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// orderCreatedEvents 新订单的初始状态记录：created，以及执行器返回的状态（若不同）
+func orderCreatedEvents(order domain.Order) []domain.OrderEvent {
+	events := []domain.OrderEvent{{
+		OrderID:   order.ID,
+		CycleID:   order.CycleID,
+		Status:    "created",
+		Detail:    fmt.Sprintf("side=%s stake=%.2f", order.Side, order.StakeUSDT),
+		CreatedAt: order.CreatedAt,
+	}}
+	if order.Status != "" && order.Status != "created" {
+		detail := ""
+		if order.ExchangeOrderID != "" {
+			detail = "exchange_order_id=" + order.ExchangeOrderID
+		}
+		if order.ParentOrderID != "" {
+			detail += " parent=" + order.ParentOrderID
+		}
+		events = append(events, domain.OrderEvent{
+			OrderID:     order.ID,
+			CycleID:     order.CycleID,
+			Status:      order.Status,
+			FilledQty:   order.FilledQuantity,
+			FilledPrice: order.FilledPrice,
+			Detail:      detail,
+			CreatedAt:   time.Now().UTC(),
+		})
+	}
+	return events
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// InsertOrderGroup 保存订单组及其所有腿
+func (r *SQLiteRepository) InsertOrderGroup(ctx context.Context, g domain.OrderGroup) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始事务: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO order_groups (id, cycle_id, pair, kind, parent_order_id, exchange_list_id, status, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		g.ID, g.CycleID, g.Pair, g.Kind, g.ParentOrderID, g.ExchangeListID, g.Status, g.CreatedAt.UTC(), g.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert order group: %w", err)
+	}
+	for i, leg := range g.Legs {
+		if leg.LegNo == 0 {
+			leg.LegNo = i + 1
+		}
+		if leg.UpdatedAt.IsZero() {
+			leg.UpdatedAt = g.UpdatedAt
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO order_group_legs (group_id, leg_no, role, ref_id, exchange_order_id, price, quantity, status, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			g.ID, leg.LegNo, leg.Role, leg.RefID, leg.ExchangeOrderID, leg.Price, leg.Quantity, leg.Status, leg.UpdatedAt.UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("insert order group leg: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListOrderGroups 查询周期的订单组（按创建时间升序），腿按序号排列
+func (r *SQLiteRepository) ListOrderGroups(ctx context.Context, cycleID string) ([]domain.OrderGroup, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, cycle_id, pair, kind, parent_order_id, exchange_list_id, status, created_at, updated_at
+		 FROM order_groups WHERE cycle_id = ? ORDER BY created_at ASC`, cycleID)
+	if err != nil {
+		return nil, fmt.Errorf("query order groups: %w", err)
+	}
+	var groups []domain.OrderGroup
+	index := make(map[string]int)
+	for rows.Next() {
+		var g domain.OrderGroup
+		if err := rows.Scan(&g.ID, &g.CycleID, &g.Pair, &g.Kind, &g.ParentOrderID, &g.ExchangeListID,
+			&g.Status, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan order group: %w", err)
+		}
+		g.Legs = make([]domain.OrderGroupLeg, 0)
+		index[g.ID] = len(groups)
+		groups = append(groups, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return groups, nil
+	}
+
+	legRows, err := r.db.QueryContext(ctx,
+		`SELECT l.group_id, l.leg_no, l.role, l.ref_id, l.exchange_order_id, l.price, l.quantity, l.status, l.updated_at
+		 FROM order_group_legs l JOIN order_groups g ON g.id = l.group_id
+		 WHERE g.cycle_id = ? ORDER BY l.group_id, l.leg_no`, cycleID)
+	if err != nil {
+		return nil, fmt.Errorf("query order group legs: %w", err)
+	}
+	defer legRows.Close()
+	for legRows.Next() {
+		var groupID string
+		var leg domain.OrderGroupLeg
+		if err := legRows.Scan(&groupID, &leg.LegNo, &leg.Role, &leg.RefID, &leg.ExchangeOrderID,
+			&leg.Price, &leg.Quantity, &leg.Status, &leg.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan order group leg: %w", err)
+		}
+		if i, ok := index[groupID]; ok {
+			groups[i].Legs = append(groups[i].Legs, leg)
+		}
+	}
+	return groups, legRows.Err()
+}
+
+// UpdateOrderGroupStatus 更新订单组状态
+func (r *SQLiteRepository) UpdateOrderGroupStatus(ctx context.Context, id, status string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE order_groups SET status = ?, updated_at = ? WHERE id = ?`,
+		status, time.Now().UTC(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("update order group: %w", err)
+	}
+	return nil
+}
+
+// UpdateOrderGroupLegStatus 按引用的本地记录（保护单 / 订单 ID）更新腿的状态，没有对应的腿时忽略
+func (r *SQLiteRepository) UpdateOrderGroupLegStatus(ctx context.Context, refID, status string) error {
+	if refID == "" {
+		return nil
+	}
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE order_group_legs SET status = ?, updated_at = ? WHERE ref_id = ?`,
+		status, time.Now().UTC(), refID,
+	)
+	if err != nil {
+		return fmt.Errorf("update order group leg: %w", err)
+	}
+	return nil
+}
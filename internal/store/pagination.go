@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"ai_quant/internal/domain"
+)
+
+// holdingSortFields 持仓列表可排序字段 → SQL 表达式。
+// 盈亏 / 市值依赖实时行情（合约还需按标记价与交易所持仓计算），由 orchestrator 取价后在内存中排序，不在此列出
+var holdingSortFields = map[string]string{
+	"total_cost": "total_cost",
+	"quantity":   "quantity",
+	"pair":       "pair",
+	"updated_at": "updated_at",
+}
+
+// positionSortFields 仓位（订单）列表可排序字段 → SQL 表达式
+var positionSortFields = map[string]string{
+	"created_at":   "o.created_at",
+	"pnl":          positionRealizedPnL,
+	"market_value": "(COALESCE(o.filled_qty, 0) * COALESCE(o.filled_price, 0))",
+	"stake_usdt":   "o.stake_usdt",
+	"confidence":   "s.confidence",
+	"pair":         "o.pair",
+}
+
+// positionRealizedPnL 订单的已实现盈亏（o 为 orders 别名）：平仓单取本次平仓的盈亏，
+// 开仓单取该批次已被后续平仓兑现的盈亏，均来自平仓归因记录
+const positionRealizedPnL = `COALESCE((SELECT SUM(co.realized_pnl) FROM close_origins co
+	WHERE co.close_order_id = o.id OR co.entry_order_id = o.id), 0)`
+
+// tagFilter 按标签过滤周期（c 为 cycles 别名），参数依次为 tag, tag，tag 为空时不过滤
+const tagFilter = `(? = '' OR EXISTS (SELECT 1 FROM cycle_tags t WHERE t.cycle_id = c.id AND t.tag = ?))`
+
+// orderClause 根据白名单生成 ORDER BY 子句，未知字段返回 domain.ErrInvalidSort
+func orderClause(fields map[string]string, q domain.ListQuery, defaultSort string) (string, error) {
+	sort := q.Sort
+	if sort == "" {
+		sort = defaultSort
+	}
+	expr, ok := fields[sort]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", domain.ErrInvalidSort, sort)
+	}
+	dir := "ASC"
+	if q.Desc {
+		dir = "DESC"
+	}
+	return expr + " " + dir, nil
+}
+
+// CountPositions 统计仓位（订单）总数，tag 非空时只统计带该标签的周期
+func (r *SQLiteRepository) CountPositions(ctx context.Context, tag string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM orders o
+		JOIN signals s ON s.cycle_id = o.cycle_id
+		JOIN cycles c ON c.id = o.cycle_id
+		WHERE `+tagFilter+`
+	`, tag, tag).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("统计仓位数量: %w", err)
+	}
+	return count, nil
+}
+
+// ListHoldingsPage 分页查询持仓记录
+func (r *SQLiteRepository) ListHoldingsPage(ctx context.Context, q domain.ListQuery) ([]domain.Holding, error) {
+	if q.PageSize <= 0 {
+		q.PageSize = 50
+	}
+	orderBy, err := orderClause(holdingSortFields, q, "total_cost")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, pair, position_side, symbol, quantity, avg_price, total_cost, COALESCE(last_price, 0), source, updated_at
+		FROM holdings
+		WHERE quantity > 0 AND position_side = 'LONG'
+		ORDER BY `+orderBy+`, id ASC
+		LIMIT ? OFFSET ?
+	`, q.PageSize, q.Offset())
+	if err != nil {
+		return nil, fmt.Errorf("查询持仓: %w", err)
+	}
+	defer rows.Close()
+
+	holdings := make([]domain.Holding, 0)
+	for rows.Next() {
+		var h domain.Holding
+		if err := rows.Scan(&h.ID, &h.Pair, &h.PositionSide, &h.Symbol, &h.Quantity, &h.AvgPrice, &h.TotalCost, &h.LastPrice, &h.Source, &h.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("扫描持仓记录: %w", err)
+		}
+		holdings = append(holdings, h)
+	}
+	return holdings, rows.Err()
+}
+
+// CountHoldings 统计多头持仓数量
+func (r *SQLiteRepository) CountHoldings(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM holdings WHERE quantity > 0 AND position_side = 'LONG'").Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("统计持仓数量: %w", err)
+	}
+	return count, nil
+}
+
+// SumHoldings 汇总全部多头持仓的成本与市值（市值按最近一次市价计算）
+func (r *SQLiteRepository) SumHoldings(ctx context.Context) (totalCost, totalValue float64, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(total_cost), 0), COALESCE(SUM(quantity * COALESCE(last_price, 0)), 0)
+		FROM holdings
+		WHERE quantity > 0 AND position_side = 'LONG'
+	`).Scan(&totalCost, &totalValue)
+	if err != nil {
+		return 0, 0, fmt.Errorf("汇总持仓: %w", err)
+	}
+	return totalCost, totalValue, nil
+}
+
+// UpdateHoldingPrice 记录持仓最近一次市价
+func (r *SQLiteRepository) UpdateHoldingPrice(ctx context.Context, pair string, price float64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE holdings SET last_price = ? WHERE pair = ?`, price, pair)
+	if err != nil {
+		return fmt.Errorf("更新持仓市价: %w", err)
+	}
+	return nil
+}
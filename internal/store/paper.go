@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// GetPaperWallet 模拟盘钱包，尚未创建时返回 nil
+func (r *SQLiteRepository) GetPaperWallet(ctx context.Context) (*domain.PaperWallet, error) {
+	var w domain.PaperWallet
+	err := r.db.QueryRowContext(ctx,
+		`SELECT initial_usdt, cash_usdt, margin_usdt, fees_usdt, realized_pnl, created_at, updated_at FROM paper_wallet WHERE id = 1`,
+	).Scan(&w.InitialUSDT, &w.CashUSDT, &w.MarginUSDT, &w.FeesUSDT, &w.RealizedPnL, &w.CreatedAt, &w.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询模拟盘钱包: %w", err)
+	}
+	return &w, nil
+}
+
+// ResetPaperWallet 按初始资金重建模拟盘钱包并清空资金流水
+func (r *SQLiteRepository) ResetPaperWallet(ctx context.Context, initialUSDT float64) (domain.PaperWallet, error) {
+	now := time.Now().UTC()
+	w := domain.PaperWallet{InitialUSDT: initialUSDT, CashUSDT: initialUSDT, CreatedAt: now, UpdatedAt: now}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return w, fmt.Errorf("开始事务: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM paper_ledger`); err != nil {
+		return w, fmt.Errorf("清空模拟盘流水: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO paper_wallet (id, initial_usdt, cash_usdt, margin_usdt, fees_usdt, realized_pnl, created_at, updated_at)
+		 VALUES (1, ?, ?, 0, 0, 0, ?, ?)
+		 ON CONFLICT(id) DO UPDATE SET initial_usdt = excluded.initial_usdt, cash_usdt = excluded.cash_usdt,
+		 margin_usdt = 0, fees_usdt = 0, realized_pnl = 0, created_at = excluded.created_at, updated_at = excluded.updated_at`,
+		initialUSDT, initialUSDT, now, now,
+	); err != nil {
+		return w, fmt.Errorf("重建模拟盘钱包: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return w, fmt.Errorf("提交事务: %w", err)
+	}
+	return w, nil
+}
+
+// ApplyPaperEntry 在同一事务中更新钱包余额并记录资金流水，返回更新后的钱包
+func (r *SQLiteRepository) ApplyPaperEntry(ctx context.Context, e domain.PaperLedgerEntry) (domain.PaperWallet, error) {
+	var w domain.PaperWallet
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return w, fmt.Errorf("开始事务: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE paper_wallet SET cash_usdt = cash_usdt + ?, margin_usdt = MAX(margin_usdt + ?, 0),
+		 fees_usdt = fees_usdt + ?, realized_pnl = realized_pnl + ?, updated_at = ? WHERE id = 1`,
+		e.CashDelta, e.MarginDelta, e.FeeUSDT, e.RealizedPnL, e.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return w, fmt.Errorf("更新模拟盘钱包: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return w, fmt.Errorf("模拟盘钱包未初始化")
+	}
+	if err := tx.QueryRowContext(ctx,
+		`SELECT initial_usdt, cash_usdt, margin_usdt, fees_usdt, realized_pnl, created_at, updated_at FROM paper_wallet WHERE id = 1`,
+	).Scan(&w.InitialUSDT, &w.CashUSDT, &w.MarginUSDT, &w.FeesUSDT, &w.RealizedPnL, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		return w, fmt.Errorf("查询模拟盘钱包: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO paper_ledger (order_id, pair, side, price, quantity, notional_usdt, fee_usdt, cash_delta, margin_delta, realized_pnl, cash_after, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.OrderID, e.Pair, string(e.Side), e.Price, e.Quantity, e.NotionalUSDT, e.FeeUSDT,
+		e.CashDelta, e.MarginDelta, e.RealizedPnL, w.CashUSDT, e.CreatedAt.UTC(),
+	); err != nil {
+		return w, fmt.Errorf("记录模拟盘流水: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return w, fmt.Errorf("提交事务: %w", err)
+	}
+	return w, nil
+}
+
+// ListPaperLedger 最近的模拟盘资金流水（新的在前）
+func (r *SQLiteRepository) ListPaperLedger(ctx context.Context, limit int) ([]domain.PaperLedgerEntry, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, order_id, pair, side, price, quantity, notional_usdt, fee_usdt, cash_delta, margin_delta, realized_pnl, cash_after, created_at
+		 FROM paper_ledger ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询模拟盘流水: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.PaperLedgerEntry, 0)
+	for rows.Next() {
+		var e domain.PaperLedgerEntry
+		var side string
+		if err := rows.Scan(&e.ID, &e.OrderID, &e.Pair, &side, &e.Price, &e.Quantity, &e.NotionalUSDT, &e.FeeUSDT,
+			&e.CashDelta, &e.MarginDelta, &e.RealizedPnL, &e.CashAfter, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描模拟盘流水: %w", err)
+		}
+		e.Side = domain.Side(side)
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
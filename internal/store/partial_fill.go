@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// filledStatuses 计入持仓与盈亏的订单状态：只要有实际成交量就参与计算
+const filledStatuses = `'filled', 'simulated_filled', 'partial_filled', 'partial_cancelled'`
+
+// ListPartialOrders 获取创建时间早于 before 且仍处于部分成交状态的订单
+func (r *SQLiteRepository) ListPartialOrders(ctx context.Context, before time.Time) ([]domain.Order, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, cycle_id, signal_id, pair, side, stake_usdt, COALESCE(leverage, 0), status,
+		       COALESCE(exchange_order_id, ''), COALESCE(filled_price, 0), COALESCE(filled_qty, 0),
+		       COALESCE(requested_qty, 0), created_at
+		FROM orders
+		WHERE status = 'partial_filled' AND created_at <= ?
+		ORDER BY created_at ASC
+	`, before.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("查询部分成交订单: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]domain.Order, 0)
+	for rows.Next() {
+		var o domain.Order
+		var side string
+		if err := rows.Scan(&o.ID, &o.CycleID, &o.SignalID, &o.Pair, &side, &o.StakeUSDT, &o.Leverage, &o.Status,
+			&o.ExchangeOrderID, &o.FilledPrice, &o.FilledQuantity, &o.RequestedQty, &o.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描订单: %w", err)
+		}
+		o.Side = domain.Side(side)
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// GetOrderByExchangeID 按交易所订单 ID 获取订单（用户数据流推送成交时定位本地订单），不存在时返回 nil
+func (r *SQLiteRepository) GetOrderByExchangeID(ctx context.Context, exchangeOrderID string) (*domain.Order, error) {
+	var o domain.Order
+	var side string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, cycle_id, signal_id, pair, side, stake_usdt, COALESCE(leverage, 0), status,
+		       COALESCE(exchange_order_id, ''), COALESCE(filled_price, 0), COALESCE(filled_qty, 0),
+		       COALESCE(requested_qty, 0), created_at
+		FROM orders
+		WHERE exchange_order_id = ?
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, exchangeOrderID).Scan(&o.ID, &o.CycleID, &o.SignalID, &o.Pair, &side, &o.StakeUSDT, &o.Leverage, &o.Status,
+		&o.ExchangeOrderID, &o.FilledPrice, &o.FilledQuantity, &o.RequestedQty, &o.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询订单: %w", err)
+	}
+	o.Side = domain.Side(side)
+	return &o, nil
+}
+
+// UpdateOrderFill 更新订单的状态与累计成交（部分成交补齐或撤销剩余量后调用），
+// 状态或成交量有变化时追加一条状态记录
+func (r *SQLiteRepository) UpdateOrderFill(ctx context.Context, id, status string, filledPrice, filledQty float64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var cycleID, oldStatus string
+	var oldQty float64
+	err = tx.QueryRowContext(ctx,
+		`SELECT cycle_id, status, COALESCE(filled_qty, 0) FROM orders WHERE id = ?`, id,
+	).Scan(&cycleID, &oldStatus, &oldQty)
+	if err != nil {
+		return fmt.Errorf("query order: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE orders SET status = ?, filled_price = ?, filled_qty = ? WHERE id = ?`,
+		status, nullableFloat(filledPrice), nullableFloat(filledQty), id,
+	)
+	if err != nil {
+		return fmt.Errorf("update order fill: %w", err)
+	}
+
+	if status != oldStatus || filledQty != oldQty {
+		err = insertOrderEvent(ctx, tx, domain.OrderEvent{
+			OrderID:     id,
+			CycleID:     cycleID,
+			Status:      status,
+			FilledQty:   filledQty,
+			FilledPrice: filledPrice,
+			Detail:      fmt.Sprintf("from=%s", oldStatus),
+			CreatedAt:   time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
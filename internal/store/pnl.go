@@ -0,0 +1,148 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// ListFilledOrders 按时间升序获取已成交订单（用于盈亏计算）
+func (r *SQLiteRepository) ListFilledOrders(ctx context.Context) ([]domain.Order, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, cycle_id, pair, side, stake_usdt, status, filled_price, filled_qty, created_at
+		FROM orders
+		WHERE status IN (`+filledStatuses+`)
+		  AND filled_qty > 0 AND filled_price > 0
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("查询已成交订单: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]domain.Order, 0)
+	for rows.Next() {
+		var o domain.Order
+		var side string
+		if err := rows.Scan(&o.ID, &o.CycleID, &o.Pair, &side, &o.StakeUSDT, &o.Status,
+			&o.FilledPrice, &o.FilledQuantity, &o.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描订单: %w", err)
+		}
+		o.Side = domain.Side(side)
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// LastEntryTime 获取某币对最近一次成交的开仓（long）订单时间，没有记录时返回零值
+func (r *SQLiteRepository) LastEntryTime(ctx context.Context, pair string) (time.Time, error) {
+	var t time.Time
+	err := r.db.QueryRowContext(ctx, `
+		SELECT created_at FROM orders
+		WHERE pair = ? AND side = 'long' AND status IN (`+filledStatuses+`)
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, pair).Scan(&t)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("查询最近开仓时间: %w", err)
+	}
+	return t, nil
+}
+
+// CountFilledOrdersSince 统计某币对 since 之后成交的订单数（开仓与平仓都计入）
+func (r *SQLiteRepository) CountFilledOrdersSince(ctx context.Context, pair string, since time.Time) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM orders
+		WHERE pair = ? AND created_at >= ? AND status IN (`+filledStatuses+`)
+	`, pair, since).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("统计当日成交次数: %w", err)
+	}
+	return n, nil
+}
+
+// LastFilledSide 获取某币对最近一笔成交订单的方向和时间（无成交时返回空）
+func (r *SQLiteRepository) LastFilledSide(ctx context.Context, pair string) (domain.Side, time.Time, error) {
+	var (
+		side string
+		t    time.Time
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT side, created_at FROM orders
+		WHERE pair = ? AND status IN (`+filledStatuses+`)
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, pair).Scan(&side, &t)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", time.Time{}, nil
+		}
+		return "", time.Time{}, fmt.Errorf("查询最近成交方向: %w", err)
+	}
+	return domain.Side(side), t, nil
+}
+
+// ListRecentDecisions 某币对最近 limit 个已生成信号的周期（按时间倒序），附风控结果与首笔成交价
+func (r *SQLiteRepository) ListRecentDecisions(ctx context.Context, pair string, limit int) ([]domain.DecisionMemory, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.id, c.status, s.side, s.confidence, s.reason, COALESCE(rc.approved, 0),
+		       COALESCE((SELECT o.filled_price FROM orders o
+		                 WHERE o.cycle_id = c.id AND o.status IN (`+filledStatuses+`) AND o.filled_price > 0
+		                 ORDER BY o.created_at ASC LIMIT 1), 0),
+		       c.created_at
+		FROM cycles c
+		JOIN signals s ON s.cycle_id = c.id
+		LEFT JOIN risk_checks rc ON rc.cycle_id = c.id
+		WHERE c.pair = ?
+		ORDER BY c.created_at DESC
+		LIMIT ?
+	`, pair, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询最近决策: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.DecisionMemory, 0, limit)
+	for rows.Next() {
+		var (
+			d            domain.DecisionMemory
+			status, side string
+		)
+		if err := rows.Scan(&d.CycleID, &status, &side, &d.Confidence, &d.Reason, &d.Approved, &d.FilledPrice, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描最近决策: %w", err)
+		}
+		d.Status, d.Side = domain.CycleStatus(status), domain.Side(side)
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+// PositionOpenedAt 获取某币对当前持仓的开仓时间：最近一次平仓之后的第一笔开仓订单，没有记录时返回零值
+func (r *SQLiteRepository) PositionOpenedAt(ctx context.Context, pair string) (time.Time, error) {
+	var t time.Time
+	err := r.db.QueryRowContext(ctx, `
+		SELECT created_at FROM orders
+		WHERE pair = ? AND side = 'long' AND status IN (`+filledStatuses+`)
+		  AND created_at > COALESCE((
+			SELECT MAX(created_at) FROM orders
+			WHERE pair = ? AND side = 'close' AND status IN (`+filledStatuses+`)
+		  ), '')
+		ORDER BY created_at ASC
+		LIMIT 1
+	`, pair, pair).Scan(&t)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("查询开仓时间: %w", err)
+	}
+	return t, nil
+}
@@ -15,13 +15,17 @@ func (r *SQLiteRepository) InsertPositionStrategy(ctx context.Context, strategy
 	if err != nil {
 		return fmt.Errorf("序列化批次数据: %w", err)
 	}
+	exitsJSON, err := json.Marshal(exitBatchesOrEmpty(strategy.ExitBatches))
+	if err != nil {
+		return fmt.Errorf("序列化止盈批次数据: %w", err)
+	}
 
 	_, err = r.db.ExecContext(ctx, `
 		INSERT INTO position_strategies (
 			id, cycle_id, signal_id, pair, side, strategy,
-			total_amount, entry_levels, batches,
+			total_amount, entry_levels, batches, exit_batches,
 			take_profit_percent, stop_loss_percent, reason, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		strategy.ID,
 		strategy.CycleID,
@@ -32,6 +36,7 @@ func (r *SQLiteRepository) InsertPositionStrategy(ctx context.Context, strategy
 		strategy.TotalAmount,
 		strategy.EntryLevels,
 		string(batchesJSON),
+		string(exitsJSON),
 		strategy.TakeProfitPercent,
 		strategy.StopLossPercent,
 		strategy.Reason,
@@ -45,16 +50,101 @@ func (r *SQLiteRepository) InsertPositionStrategy(ctx context.Context, strategy
 
 // GetPositionStrategy 获取建仓策略
 func (r *SQLiteRepository) GetPositionStrategy(ctx context.Context, cycleID string) (*domain.PositionStrategy, error) {
-	var strategy domain.PositionStrategy
-	var batchesJSON string
-
-	err := r.db.QueryRowContext(ctx, `
-		SELECT id, cycle_id, signal_id, pair, side, strategy,
-			   total_amount, entry_levels, batches,
-			   take_profit_percent, stop_loss_percent, reason, created_at
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+positionStrategyColumns+`
 		FROM position_strategies
 		WHERE cycle_id = ?
-	`, cycleID).Scan(
+	`, cycleID)
+	strategy, err := scanPositionStrategy(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &strategy, nil
+}
+
+// ListPendingPositionStrategies 查询仍有待触发批次的建仓策略（按创建时间正序），pair 为空时不过滤
+func (r *SQLiteRepository) ListPendingPositionStrategies(ctx context.Context, pair string) ([]domain.PositionStrategy, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+positionStrategyColumns+`
+		FROM position_strategies
+		WHERE (? = '' OR pair = ?) AND batches LIKE '%"status":"pending"%'
+		ORDER BY created_at ASC
+	`, pair, pair)
+	if err != nil {
+		return nil, fmt.Errorf("查询待触发建仓策略: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.PositionStrategy, 0)
+	for rows.Next() {
+		strategy, err := scanPositionStrategy(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, strategy)
+	}
+	return list, rows.Err()
+}
+
+// ListPendingExitStrategies 查询仍有待触发止盈批次的建仓策略（按创建时间正序），pair 为空时不过滤
+func (r *SQLiteRepository) ListPendingExitStrategies(ctx context.Context, pair string) ([]domain.PositionStrategy, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+positionStrategyColumns+`
+		FROM position_strategies
+		WHERE (? = '' OR pair = ?) AND exit_batches LIKE '%"status":"pending"%'
+		ORDER BY created_at ASC
+	`, pair, pair)
+	if err != nil {
+		return nil, fmt.Errorf("查询待触发止盈批次: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.PositionStrategy, 0)
+	for rows.Next() {
+		strategy, err := scanPositionStrategy(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, strategy)
+	}
+	return list, rows.Err()
+}
+
+// UpdatePositionExitBatches 更新建仓策略的止盈批次状态
+func (r *SQLiteRepository) UpdatePositionExitBatches(ctx context.Context, id string, exits []domain.ExitBatch) error {
+	exitsJSON, err := json.Marshal(exitBatchesOrEmpty(exits))
+	if err != nil {
+		return fmt.Errorf("序列化止盈批次数据: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `UPDATE position_strategies SET exit_batches = ? WHERE id = ?`, string(exitsJSON), id); err != nil {
+		return fmt.Errorf("更新止盈批次状态: %w", err)
+	}
+	return nil
+}
+
+// UpdatePositionBatches 更新建仓策略的批次状态
+func (r *SQLiteRepository) UpdatePositionBatches(ctx context.Context, id string, batches []domain.PositionBatch) error {
+	batchesJSON, err := json.Marshal(batches)
+	if err != nil {
+		return fmt.Errorf("序列化批次数据: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `UPDATE position_strategies SET batches = ? WHERE id = ?`, string(batchesJSON), id); err != nil {
+		return fmt.Errorf("更新批次状态: %w", err)
+	}
+	return nil
+}
+
+const positionStrategyColumns = `id, cycle_id, signal_id, pair, side, strategy,
+			   total_amount, entry_levels, batches, COALESCE(exit_batches, '[]'),
+			   take_profit_percent, stop_loss_percent, reason, created_at`
+
+func scanPositionStrategy(row interface{ Scan(...any) error }) (domain.PositionStrategy, error) {
+	var strategy domain.PositionStrategy
+	var batchesJSON, exitsJSON string
+	err := row.Scan(
 		&strategy.ID,
 		&strategy.CycleID,
 		&strategy.SignalID,
@@ -64,23 +154,32 @@ func (r *SQLiteRepository) GetPositionStrategy(ctx context.Context, cycleID stri
 		&strategy.TotalAmount,
 		&strategy.EntryLevels,
 		&batchesJSON,
+		&exitsJSON,
 		&strategy.TakeProfitPercent,
 		&strategy.StopLossPercent,
 		&strategy.Reason,
 		&strategy.CreatedAt,
 	)
-
 	if err == sql.ErrNoRows {
-		return nil, nil
+		return strategy, err
 	}
 	if err != nil {
-		return nil, fmt.Errorf("查询建仓策略: %w", err)
+		return strategy, fmt.Errorf("查询建仓策略: %w", err)
 	}
 
 	// 反序列化批次数据
 	if err := json.Unmarshal([]byte(batchesJSON), &strategy.Batches); err != nil {
-		return nil, fmt.Errorf("反序列化批次数据: %w", err)
+		return strategy, fmt.Errorf("反序列化批次数据: %w", err)
+	}
+	if err := json.Unmarshal([]byte(exitsJSON), &strategy.ExitBatches); err != nil {
+		return strategy, fmt.Errorf("反序列化止盈批次数据: %w", err)
 	}
+	return strategy, nil
+}
 
-	return &strategy, nil
+func exitBatchesOrEmpty(exits []domain.ExitBatch) []domain.ExitBatch {
+	if exits == nil {
+		return []domain.ExitBatch{}
+	}
+	return exits
 }
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// InsertProtectiveOrder 保存止盈止损保护单记录
+func (r *SQLiteRepository) InsertProtectiveOrder(ctx context.Context, o domain.ProtectiveOrder) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO protective_orders (id, cycle_id, pair, kind, group_id, exchange_order_id, trigger_price, quantity, status, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		o.ID, o.CycleID, o.Pair, o.Kind, o.GroupID, o.ExchangeOrderID, o.TriggerPrice, o.Quantity, o.Status,
+		o.CreatedAt.UTC(), o.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert protective order: %w", err)
+	}
+	return nil
+}
+
+// ListProtectiveOrders 查询保护单（按时间倒序），pair / status 为空时不过滤
+func (r *SQLiteRepository) ListProtectiveOrders(ctx context.Context, pair, status string) ([]domain.ProtectiveOrder, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, cycle_id, pair, kind, group_id, exchange_order_id, trigger_price, quantity, status, created_at, updated_at
+		 FROM protective_orders
+		 WHERE (? = '' OR pair = ?) AND (? = '' OR status = ?)
+		 ORDER BY created_at DESC
+		 LIMIT 200`,
+		pair, pair, status, status,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query protective orders: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.ProtectiveOrder, 0)
+	for rows.Next() {
+		var o domain.ProtectiveOrder
+		if err := rows.Scan(&o.ID, &o.CycleID, &o.Pair, &o.Kind, &o.GroupID, &o.ExchangeOrderID,
+			&o.TriggerPrice, &o.Quantity, &o.Status, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan protective order: %w", err)
+		}
+		list = append(list, o)
+	}
+	return list, rows.Err()
+}
+
+// UpdateProtectiveOrderStatus 更新保护单状态
+func (r *SQLiteRepository) UpdateProtectiveOrderStatus(ctx context.Context, id, status string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE protective_orders SET status = ?, updated_at = ? WHERE id = ?`,
+		status, time.Now().UTC(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("update protective order: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// PruneCycleLogs 删除指定时间之前的 cycle_logs，返回删除行数
+func (r *SQLiteRepository) PruneCycleLogs(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM cycle_logs WHERE created_at < ?`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("清理 cycle_logs: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// pruneCycleWhere 可清理周期的筛选条件：指定状态且早于 before，存在成交订单的周期不清理
+func pruneCycleWhere(statuses []domain.CycleStatus, before time.Time) (string, []any) {
+	placeholders := make([]string, len(statuses))
+	args := make([]any, 0, len(statuses)+1)
+	for i, st := range statuses {
+		placeholders[i] = "?"
+		args = append(args, string(st))
+	}
+	args = append(args, before.UTC())
+	return fmt.Sprintf(`c.status IN (%s) AND c.created_at < ?
+		   AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.cycle_id = c.id AND o.status IN (`+filledStatuses+`))`,
+		strings.Join(placeholders, ", ")), args
+}
+
+// CountPrunableCycles 统计 PruneCycles 会删除的周期数（不删除）
+func (r *SQLiteRepository) CountPrunableCycles(ctx context.Context, statuses []domain.CycleStatus, before time.Time) (int64, error) {
+	if len(statuses) == 0 {
+		return 0, nil
+	}
+	where, args := pruneCycleWhere(statuses, before)
+	var n int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM cycles c WHERE `+where, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("统计待清理周期: %w", err)
+	}
+	return n, nil
+}
+
+// PruneCycles 删除指定状态且早于 before 的周期及其关联数据，返回删除的周期数。
+// 存在已成交订单的周期会被保留，避免影响持仓聚合。
+func (r *SQLiteRepository) PruneCycles(ctx context.Context, statuses []domain.CycleStatus, before time.Time) (int64, error) {
+	if len(statuses) == 0 {
+		return 0, nil
+	}
+	where, args := pruneCycleWhere(statuses, before)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("开始事务: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 先把待删除的周期 ID 放进临时表，保证各表删除范围一致
+	if _, err := tx.ExecContext(ctx, `CREATE TEMP TABLE IF NOT EXISTS prune_cycle_ids (id TEXT PRIMARY KEY)`); err != nil {
+		return 0, fmt.Errorf("创建临时表: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM prune_cycle_ids`); err != nil {
+		return 0, fmt.Errorf("清空临时表: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, `INSERT INTO prune_cycle_ids (id) SELECT c.id FROM cycles c WHERE `+where, args...); err != nil {
+		return 0, fmt.Errorf("筛选待清理周期: %w", err)
+	}
+
+	// 订单组的腿没有 cycle_id，先按所属订单组删除
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM order_group_legs WHERE group_id IN (SELECT id FROM order_groups WHERE cycle_id IN (SELECT id FROM prune_cycle_ids))`,
+	); err != nil {
+		return 0, fmt.Errorf("删除 order_group_legs: %w", err)
+	}
+
+	// 删除关联数据（按外键依赖顺序）
+	tables := []string{
+		"order_groups",
+		"cycle_logs",
+		"cycle_tags",
+		"cycle_approvals",
+		"order_events",
+		"orders",
+		"risk_checks",
+		"position_strategies",
+		"signals",
+	}
+	for _, table := range tables {
+		_, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE cycle_id IN (SELECT id FROM prune_cycle_ids)", table))
+		if err != nil {
+			return 0, fmt.Errorf("删除 %s: %w", table, err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM cycles WHERE id IN (SELECT id FROM prune_cycle_ids)`)
+	if err != nil {
+		return 0, fmt.Errorf("删除 cycles: %w", err)
+	}
+	n, _ := res.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交事务: %w", err)
+	}
+	return n, nil
+}
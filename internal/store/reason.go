@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// reasonCode 优先使用保存的归类代码；旧数据没有代码时按原始文本归类
+func reasonCode(code, msg string) domain.ReasonCode {
+	if code != "" {
+		return domain.ReasonCode(code)
+	}
+	return domain.ClassifyReason(msg)
+}
+
+// ListCycleOutcomes 查询 since 之后被拒绝或失败的周期（风控拒绝取风控决策的原因）
+func (r *SQLiteRepository) ListCycleOutcomes(ctx context.Context, since time.Time) ([]domain.CycleOutcome, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.status,
+			COALESCE(NULLIF(c.reason_code, ''), rc.reject_code, ''),
+			COALESCE(c.error_message, rc.reject_reason, ''),
+			c.created_at
+		FROM cycles c
+		LEFT JOIN risk_checks rc ON rc.cycle_id = c.id
+		WHERE c.status IN (?, ?) AND c.created_at >= ?
+		ORDER BY c.created_at ASC
+	`, string(domain.CycleStatusRejected), string(domain.CycleStatusFailed), since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("查询周期结果: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]domain.CycleOutcome, 0)
+	for rows.Next() {
+		var o domain.CycleOutcome
+		var status, code string
+		if err := rows.Scan(&status, &code, &o.ErrorMessage, &o.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描周期结果: %w", err)
+		}
+		o.Status = domain.CycleStatus(status)
+		o.ReasonCode = reasonCode(code, o.ErrorMessage)
+		if o.ReasonCode == "" {
+			o.ReasonCode = domain.ReasonOther
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// ListCycleStatuses 查询 since 之后所有周期的状态与时间（用于按时段统计，不含原因）
+func (r *SQLiteRepository) ListCycleStatuses(ctx context.Context, since time.Time) ([]domain.CycleOutcome, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT status, created_at FROM cycles
+		WHERE created_at >= ?
+		ORDER BY created_at ASC
+	`, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("查询周期状态: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]domain.CycleOutcome, 0)
+	for rows.Next() {
+		var o domain.CycleOutcome
+		var status string
+		if err := rows.Scan(&status, &o.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描周期状态: %w", err)
+		}
+		o.Status = domain.CycleStatus(status)
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
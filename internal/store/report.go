@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// SavePerformanceReport 保存日报 / 周报，同一周期与起始交易日重复生成时覆盖
+func (r *SQLiteRepository) SavePerformanceReport(ctx context.Context, rep domain.PerformanceReport) error {
+	data, err := json.Marshal(rep)
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO performance_reports (period, start_date, end_date, data, created_at) VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT(period, start_date) DO UPDATE SET end_date = excluded.end_date, data = excluded.data, created_at = excluded.created_at`,
+		rep.Period, rep.StartDate, rep.EndDate, string(data), rep.GeneratedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("保存绩效报告: %w", err)
+	}
+	return nil
+}
+
+// GetPerformanceReport 按周期与起始交易日读取报告，不存在时返回 nil
+func (r *SQLiteRepository) GetPerformanceReport(ctx context.Context, period, startDate string) (*domain.PerformanceReport, error) {
+	var data string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT data FROM performance_reports WHERE period = ? AND start_date = ?`, period, startDate,
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询绩效报告: %w", err)
+	}
+	var rep domain.PerformanceReport
+	if err := json.Unmarshal([]byte(data), &rep); err != nil {
+		return nil, fmt.Errorf("parse report: %w", err)
+	}
+	return &rep, nil
+}
+
+// CountCyclesByStatus 统计 [from, to) 内创建的周期按状态的数量
+func (r *SQLiteRepository) CountCyclesByStatus(ctx context.Context, from, to time.Time) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT status, COUNT(*) FROM cycles WHERE created_at >= ? AND created_at < ? GROUP BY status`,
+		from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("统计周期状态: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		out[status] = n
+	}
+	return out, rows.Err()
+}
+
+// ListNotableSignals [from, to) 内置信度最高的开仓 / 平仓信号（不含观望），最多 limit 条
+func (r *SQLiteRepository) ListNotableSignals(ctx context.Context, from, to time.Time, limit int) ([]domain.NotableSignal, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT s.cycle_id, s.pair, s.side, s.confidence, s.reason, COALESCE(c.status, ''), s.created_at
+		FROM signals s
+		LEFT JOIN cycles c ON c.id = s.cycle_id
+		WHERE s.created_at >= ? AND s.created_at < ? AND s.side != 'none'
+		ORDER BY s.confidence DESC, s.created_at DESC
+		LIMIT ?
+	`, from.UTC(), to.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询信号: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]domain.NotableSignal, 0)
+	for rows.Next() {
+		var n domain.NotableSignal
+		var side string
+		if err := rows.Scan(&n.CycleID, &n.Pair, &side, &n.Confidence, &n.Reason, &n.CycleStatus, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		n.Side = domain.Side(side)
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
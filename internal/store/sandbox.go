@@ -0,0 +1,138 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"ai_quant/internal/domain"
+)
+
+const sandboxTradeColumns = `id, sandbox_id, preview_id, pair, side, price, quantity, stake_usdt, fee_usdt,
+	realized_pnl, confidence, reason, model_name, created_at`
+
+// CreateSandbox 创建沙盒会话（名称唯一）
+func (r *SQLiteRepository) CreateSandbox(ctx context.Context, sb domain.Sandbox) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO sandboxes (id, name, description, initial_usdt, leverage, model, pairs, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		sb.ID, sb.Name, sb.Description, sb.InitialUSDT, sb.Leverage, sb.Model, sb.Pairs, sb.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert sandbox: %w", err)
+	}
+	return nil
+}
+
+// ListSandboxes 全部沙盒会话（按创建时间升序）
+func (r *SQLiteRepository) ListSandboxes(ctx context.Context) ([]domain.Sandbox, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, name, description, initial_usdt, leverage, model, pairs, created_at FROM sandboxes ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("查询沙盒: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.Sandbox, 0)
+	for rows.Next() {
+		var sb domain.Sandbox
+		if err := rows.Scan(&sb.ID, &sb.Name, &sb.Description, &sb.InitialUSDT, &sb.Leverage, &sb.Model, &sb.Pairs, &sb.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描沙盒: %w", err)
+		}
+		list = append(list, sb)
+	}
+	return list, rows.Err()
+}
+
+// GetSandbox 按 ID 或名称获取沙盒会话，不存在时返回 nil
+func (r *SQLiteRepository) GetSandbox(ctx context.Context, id string) (*domain.Sandbox, error) {
+	var sb domain.Sandbox
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, name, description, initial_usdt, leverage, model, pairs, created_at FROM sandboxes WHERE id = ? OR name = ?`,
+		id, id,
+	).Scan(&sb.ID, &sb.Name, &sb.Description, &sb.InitialUSDT, &sb.Leverage, &sb.Model, &sb.Pairs, &sb.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询沙盒: %w", err)
+	}
+	return &sb, nil
+}
+
+// DeleteSandbox 删除沙盒会话及其全部模拟成交
+func (r *SQLiteRepository) DeleteSandbox(ctx context.Context, id string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sandbox_trades WHERE sandbox_id = ?`, id); err != nil {
+		return fmt.Errorf("删除沙盒成交: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sandboxes WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("删除沙盒: %w", err)
+	}
+	return tx.Commit()
+}
+
+// InsertSandboxTrade 保存沙盒模拟成交
+func (r *SQLiteRepository) InsertSandboxTrade(ctx context.Context, t domain.SandboxTrade) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO sandbox_trades (`+sandboxTradeColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.ID, t.SandboxID, t.PreviewID, t.Pair, string(t.Side), t.Price, t.Quantity, t.StakeUSDT, t.FeeUSDT,
+		t.RealizedPnL, t.Confidence, t.Reason, t.ModelName, t.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert sandbox trade: %w", err)
+	}
+	return nil
+}
+
+// ListSandboxTrades 沙盒的全部成交（按时间升序，用于回放钱包与持仓）
+func (r *SQLiteRepository) ListSandboxTrades(ctx context.Context, sandboxID string) ([]domain.SandboxTrade, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+sandboxTradeColumns+` FROM sandbox_trades WHERE sandbox_id = ? ORDER BY created_at ASC`, sandboxID)
+	if err != nil {
+		return nil, fmt.Errorf("查询沙盒成交: %w", err)
+	}
+	defer rows.Close()
+	return scanSandboxTrades(rows)
+}
+
+// ListSandboxTradesPage 分页查询沙盒成交（按时间倒序）
+func (r *SQLiteRepository) ListSandboxTradesPage(ctx context.Context, sandboxID string, q domain.ListQuery) ([]domain.SandboxTrade, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM sandbox_trades WHERE sandbox_id = ?`, sandboxID,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("统计沙盒成交: %w", err)
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+sandboxTradeColumns+` FROM sandbox_trades WHERE sandbox_id = ? ORDER BY created_at DESC LIMIT ? OFFSET ?`,
+		sandboxID, q.PageSize, q.Offset())
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询沙盒成交: %w", err)
+	}
+	defer rows.Close()
+	list, err := scanSandboxTrades(rows)
+	return list, total, err
+}
+
+func scanSandboxTrades(rows *sql.Rows) ([]domain.SandboxTrade, error) {
+	list := make([]domain.SandboxTrade, 0)
+	for rows.Next() {
+		var (
+			t    domain.SandboxTrade
+			side string
+		)
+		if err := rows.Scan(&t.ID, &t.SandboxID, &t.PreviewID, &t.Pair, &side, &t.Price, &t.Quantity, &t.StakeUSDT,
+			&t.FeeUSDT, &t.RealizedPnL, &t.Confidence, &t.Reason, &t.ModelName, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描沙盒成交: %w", err)
+		}
+		t.Side = domain.Side(side)
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
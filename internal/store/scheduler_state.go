@@ -0,0 +1,48 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// SaveSchedulerState 保存定时器运行时配置（单行表）
+func (r *SQLiteRepository) SaveSchedulerState(ctx context.Context, st domain.SchedulerState) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO scheduler_state (id, paused, interval_sec, pairs, updated_at) VALUES (1, ?, ?, ?, ?)
+		 ON CONFLICT(id) DO UPDATE SET paused = excluded.paused, interval_sec = excluded.interval_sec,
+		 pairs = excluded.pairs, updated_at = excluded.updated_at`,
+		st.Paused, st.IntervalSec, strings.Join(st.Pairs, ","), time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("save scheduler state: %w", err)
+	}
+	return nil
+}
+
+// GetSchedulerState 读取保存的定时器配置，从未保存过时返回 nil
+func (r *SQLiteRepository) GetSchedulerState(ctx context.Context) (*domain.SchedulerState, error) {
+	var st domain.SchedulerState
+	var pairs string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT paused, interval_sec, pairs, updated_at FROM scheduler_state WHERE id = 1`,
+	).Scan(&st.Paused, &st.IntervalSec, &pairs, &st.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询定时器配置: %w", err)
+	}
+	st.Pairs = []string{}
+	for _, p := range strings.Split(pairs, ",") {
+		if p != "" {
+			st.Pairs = append(st.Pairs, p)
+		}
+	}
+	return &st, nil
+}
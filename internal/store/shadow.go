@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+const shadowColumns = `id, pair, side, confidence, approved, reject_reason, stake_usdt, entry_price, leverage,
+	take_profit_percent, stop_loss_percent, reason, created_at, evaluated_at, exit_price, price_change_pct, pnl_usdt`
+
+// InsertShadowCycle 保存影子周期结果
+func (r *SQLiteRepository) InsertShadowCycle(ctx context.Context, sc domain.ShadowCycle) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO shadow_cycles (id, pair, side, confidence, approved, reject_reason, stake_usdt, entry_price, leverage,
+			take_profit_percent, stop_loss_percent, reason, preview_json, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sc.ID, sc.Pair, string(sc.Side), sc.Confidence, sc.Approved, sc.RejectReason, sc.StakeUSDT, sc.EntryPrice, sc.Leverage,
+		sc.TakeProfitPercent, sc.StopLossPercent, sc.Reason, string(sc.Preview), sc.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert shadow cycle: %w", err)
+	}
+	return nil
+}
+
+// ListShadowCycles 分页查询影子周期（按时间倒序），pair 为空时返回全部交易对
+func (r *SQLiteRepository) ListShadowCycles(ctx context.Context, pair string, q domain.ListQuery) ([]domain.ShadowCycle, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM shadow_cycles WHERE (? = '' OR pair = ?)`, pair, pair,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("统计影子周期: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+shadowColumns+`
+		FROM shadow_cycles
+		WHERE (? = '' OR pair = ?)
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`, pair, pair, q.PageSize, q.Offset())
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询影子周期: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.ShadowCycle, 0)
+	for rows.Next() {
+		sc, err := scanShadowCycle(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		list = append(list, sc)
+	}
+	return list, total, rows.Err()
+}
+
+// GetShadowCycle 获取单个影子周期（含完整预览），不存在时返回 nil
+func (r *SQLiteRepository) GetShadowCycle(ctx context.Context, id string) (*domain.ShadowCycle, error) {
+	var preview string
+	row := r.db.QueryRowContext(ctx, `SELECT `+shadowColumns+`, preview_json FROM shadow_cycles WHERE id = ?`, id)
+	sc, err := scanShadowCycle(row, &preview)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	sc.Preview = []byte(preview)
+	return &sc, nil
+}
+
+// ListUnevaluatedShadowCycles 获取创建时间早于 before 且尚未评估结果的影子周期
+func (r *SQLiteRepository) ListUnevaluatedShadowCycles(ctx context.Context, before time.Time) ([]domain.ShadowCycle, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+shadowColumns+`
+		FROM shadow_cycles
+		WHERE evaluated_at IS NULL AND created_at < ?
+		ORDER BY created_at ASC
+	`, before.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("查询待评估影子周期: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.ShadowCycle, 0)
+	for rows.Next() {
+		sc, err := scanShadowCycle(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, sc)
+	}
+	return list, rows.Err()
+}
+
+// UpdateShadowOutcome 写入影子周期的事后结果
+func (r *SQLiteRepository) UpdateShadowOutcome(ctx context.Context, id string, exitPrice, changePct, pnl float64, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE shadow_cycles SET evaluated_at = ?, exit_price = ?, price_change_pct = ?, pnl_usdt = ? WHERE id = ?`,
+		at.UTC(), exitPrice, changePct, pnl, id,
+	)
+	if err != nil {
+		return fmt.Errorf("update shadow outcome: %w", err)
+	}
+	return nil
+}
+
+// ShadowStats 按交易对汇总影子周期表现
+func (r *SQLiteRepository) ShadowStats(ctx context.Context) ([]domain.ShadowStats, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT pair,
+		       COUNT(*),
+		       SUM(CASE WHEN approved THEN 1 ELSE 0 END),
+		       SUM(CASE WHEN approved AND evaluated_at IS NOT NULL AND side != 'none' THEN 1 ELSE 0 END),
+		       SUM(CASE WHEN approved AND evaluated_at IS NOT NULL AND pnl_usdt > 0 THEN 1 ELSE 0 END),
+		       COALESCE(SUM(pnl_usdt), 0)
+		FROM shadow_cycles
+		GROUP BY pair
+		ORDER BY pair
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("汇总影子周期: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]domain.ShadowStats, 0)
+	for rows.Next() {
+		var s domain.ShadowStats
+		if err := rows.Scan(&s.Pair, &s.Total, &s.Approved, &s.Evaluated, &s.Wins, &s.TotalPnLUSDT); err != nil {
+			return nil, fmt.Errorf("扫描影子周期汇总: %w", err)
+		}
+		if s.Evaluated > 0 {
+			s.WinRate = float64(s.Wins) / float64(s.Evaluated)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// scanShadowCycle 按 shadowColumns 顺序扫描一行，extra 为附加列
+func scanShadowCycle(row interface{ Scan(...any) error }, extra ...any) (domain.ShadowCycle, error) {
+	var (
+		sc          domain.ShadowCycle
+		side        string
+		evaluatedAt sql.NullTime
+	)
+	dest := []any{&sc.ID, &sc.Pair, &side, &sc.Confidence, &sc.Approved, &sc.RejectReason, &sc.StakeUSDT,
+		&sc.EntryPrice, &sc.Leverage, &sc.TakeProfitPercent, &sc.StopLossPercent, &sc.Reason, &sc.CreatedAt,
+		&evaluatedAt, &sc.ExitPrice, &sc.PriceChangePct, &sc.PnLUSDT}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sc, err
+		}
+		return sc, fmt.Errorf("扫描影子周期: %w", err)
+	}
+	sc.Side = domain.Side(side)
+	if evaluatedAt.Valid {
+		t := evaluatedAt.Time
+		sc.EvaluatedAt = &t
+	}
+	return sc, nil
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+const shareLinkColumns = `id, name, dashboards, token_hash, expires_at, revoked_at, created_at`
+
+// InsertShareLink 保存分享链接（只保存令牌哈希）
+func (r *SQLiteRepository) InsertShareLink(ctx context.Context, l domain.ShareLink) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO share_links (`+shareLinkColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		l.ID, l.Name, strings.Join(l.Dashboards, ","), l.TokenHash, l.ExpiresAt.UTC(), l.RevokedAt, l.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert share link: %w", err)
+	}
+	return nil
+}
+
+// ListShareLinks 全部分享链接（含已过期、已撤销），按创建时间倒序
+func (r *SQLiteRepository) ListShareLinks(ctx context.Context) ([]domain.ShareLink, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+shareLinkColumns+` FROM share_links ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("查询分享链接: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.ShareLink, 0)
+	for rows.Next() {
+		l, err := scanShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
+
+// GetShareLinkByToken 按令牌哈希查找分享链接，不存在时返回 nil
+func (r *SQLiteRepository) GetShareLinkByToken(ctx context.Context, tokenHash string) (*domain.ShareLink, error) {
+	l, err := scanShareLink(r.db.QueryRowContext(ctx,
+		`SELECT `+shareLinkColumns+` FROM share_links WHERE token_hash = ?`, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// RevokeShareLink 撤销分享链接，链接不存在或已撤销时返回 false
+func (r *SQLiteRepository) RevokeShareLink(ctx context.Context, id string) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE share_links SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, time.Now().UTC(), id)
+	if err != nil {
+		return false, fmt.Errorf("撤销分享链接: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func scanShareLink(row interface{ Scan(...any) error }) (domain.ShareLink, error) {
+	var l domain.ShareLink
+	var dashboards string
+	var revokedAt sql.NullTime
+	if err := row.Scan(&l.ID, &l.Name, &dashboards, &l.TokenHash, &l.ExpiresAt, &revokedAt, &l.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return l, err
+		}
+		return l, fmt.Errorf("扫描分享链接: %w", err)
+	}
+	l.Dashboards = []string{}
+	for _, d := range strings.Split(dashboards, ",") {
+		if d != "" {
+			l.Dashboards = append(l.Dashboards, d)
+		}
+	}
+	if revokedAt.Valid {
+		l.RevokedAt = &revokedAt.Time
+	}
+	return l, nil
+}
@@ -3,11 +3,13 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"ai_quant/internal/costbasis"
 	"ai_quant/internal/domain"
 
 	_ "modernc.org/sqlite"
@@ -17,28 +19,161 @@ type Repository interface {
 	Init(ctx context.Context) error
 	Close() error
 	CreateCycle(ctx context.Context, cycle domain.Cycle) error
-	UpdateCycleStatus(ctx context.Context, cycleID string, status domain.CycleStatus, errMsg string) error
+	UpdateCycleStatus(ctx context.Context, cycleID string, status domain.CycleStatus, code domain.ReasonCode, errMsg string) error
 	InsertSignal(ctx context.Context, signal domain.Signal) error
 	InsertRiskDecision(ctx context.Context, decision domain.RiskDecision) error
+	UpdateRiskSizing(ctx context.Context, id string, sizing *domain.SizingBreakdown) error
 	InsertOrder(ctx context.Context, order domain.Order) error
 	InsertCycleLog(ctx context.Context, log domain.CycleLog) error
 	GetCycleReport(ctx context.Context, cycleID string) (domain.CycleReport, error)
 	DeleteCycle(ctx context.Context, cycleID string) error
-	ListPositions(ctx context.Context, limit int) ([]domain.PositionView, error)
-	ListCycles(ctx context.Context, page, pageSize int) ([]domain.CycleSummary, error)
-	CountCycles(ctx context.Context) (int, error)
+	ListPositions(ctx context.Context, q domain.ListQuery) ([]domain.PositionView, error)
+	CountPositions(ctx context.Context, tag string) (int, error)
+	ListCycles(ctx context.Context, page, pageSize int, tag string) ([]domain.CycleSummary, error)
+	CountCycles(ctx context.Context, tag string) (int, error)
+	SumLLMCostSince(ctx context.Context, since time.Time) (float64, error)
+	ListLLMCosts(ctx context.Context, since time.Time) ([]domain.LLMCost, error)
+	ListModelSignals(ctx context.Context, since time.Time) ([]domain.Signal, error)
+
+	// 日报 / 周报
+	SavePerformanceReport(ctx context.Context, rep domain.PerformanceReport) error
+	GetPerformanceReport(ctx context.Context, period, startDate string) (*domain.PerformanceReport, error)
+	CountCyclesByStatus(ctx context.Context, from, to time.Time) (map[string]int, error)
+	ListNotableSignals(ctx context.Context, from, to time.Time, limit int) ([]domain.NotableSignal, error)
 
 	// Holdings 持仓管理
 	UpsertHolding(ctx context.Context, h domain.Holding) error
 	ListHoldings(ctx context.Context) ([]domain.Holding, error)
+	ListShortHoldings(ctx context.Context) ([]domain.Holding, error)
+	ListHoldingsPage(ctx context.Context, q domain.ListQuery) ([]domain.Holding, error)
+	CountHoldings(ctx context.Context) (int, error)
+	SumHoldings(ctx context.Context) (totalCost, totalValue float64, err error)
+	UpdateHoldingPrice(ctx context.Context, pair string, price float64) error
 	AggregateHoldingsFromOrders(ctx context.Context) ([]domain.Holding, error)
+	ListFilledOrders(ctx context.Context) ([]domain.Order, error)
+	LastEntryTime(ctx context.Context, pair string) (time.Time, error)
+	CountFilledOrdersSince(ctx context.Context, pair string, since time.Time) (int, error)
+	LastFilledSide(ctx context.Context, pair string) (domain.Side, time.Time, error)
+	ListSlippageOrders(ctx context.Context, since time.Time, includeSimulated bool) ([]domain.Order, error)
+	ListRecentDecisions(ctx context.Context, pair string, limit int) ([]domain.DecisionMemory, error)
+	PositionOpenedAt(ctx context.Context, pair string) (time.Time, error)
 
 	// Position Strategy 建仓策略管理
 	InsertPositionStrategy(ctx context.Context, strategy domain.PositionStrategy) error
 	GetPositionStrategy(ctx context.Context, cycleID string) (*domain.PositionStrategy, error)
+	ListPendingPositionStrategies(ctx context.Context, pair string) ([]domain.PositionStrategy, error)
+	ListPendingExitStrategies(ctx context.Context, pair string) ([]domain.PositionStrategy, error)
+	UpdatePositionExitBatches(ctx context.Context, id string, exits []domain.ExitBatch) error
+	UpdatePositionBatches(ctx context.Context, id string, batches []domain.PositionBatch) error
+
+	// 部分成交跟踪
+	ListPartialOrders(ctx context.Context, before time.Time) ([]domain.Order, error)
+	GetOrderByExchangeID(ctx context.Context, exchangeOrderID string) (*domain.Order, error)
+	UpdateOrderFill(ctx context.Context, id, status string, filledPrice, filledQty float64) error
+	ListOrderEvents(ctx context.Context, orderID string) ([]domain.OrderEvent, error)
+
+	// 交易所止盈止损保护单
+	InsertProtectiveOrder(ctx context.Context, o domain.ProtectiveOrder) error
+	ListProtectiveOrders(ctx context.Context, pair, status string) ([]domain.ProtectiveOrder, error)
+	UpdateProtectiveOrderStatus(ctx context.Context, id, status string) error
+
+	// 订单组（OCO / 止盈止损对 / TWAP 子单）
+	InsertOrderGroup(ctx context.Context, g domain.OrderGroup) error
+	ListOrderGroups(ctx context.Context, cycleID string) ([]domain.OrderGroup, error)
+	UpdateOrderGroupStatus(ctx context.Context, id, status string) error
+	UpdateOrderGroupLegStatus(ctx context.Context, refID, status string) error
+
+	// 影子周期（只模拟不下单的交易对）
+	InsertShadowCycle(ctx context.Context, sc domain.ShadowCycle) error
+	ListShadowCycles(ctx context.Context, pair string, q domain.ListQuery) ([]domain.ShadowCycle, int, error)
+	GetShadowCycle(ctx context.Context, id string) (*domain.ShadowCycle, error)
+	ListUnevaluatedShadowCycles(ctx context.Context, before time.Time) ([]domain.ShadowCycle, error)
+	UpdateShadowOutcome(ctx context.Context, id string, exitPrice, changePct, pnl float64, at time.Time) error
+	ShadowStats(ctx context.Context) ([]domain.ShadowStats, error)
+
+	// 沙盒会话（独立虚拟钱包的模拟交易实验）
+	CreateSandbox(ctx context.Context, sb domain.Sandbox) error
+	ListSandboxes(ctx context.Context) ([]domain.Sandbox, error)
+	GetSandbox(ctx context.Context, id string) (*domain.Sandbox, error)
+	DeleteSandbox(ctx context.Context, id string) error
+	InsertSandboxTrade(ctx context.Context, t domain.SandboxTrade) error
+	ListSandboxTrades(ctx context.Context, sandboxID string) ([]domain.SandboxTrade, error)
+	ListSandboxTradesPage(ctx context.Context, sandboxID string, q domain.ListQuery) ([]domain.SandboxTrade, int, error)
+
+	// 周期标签
+	AddCycleTags(ctx context.Context, cycleID, source string, tags []string) error
+	RemoveCycleTag(ctx context.Context, cycleID, tag string) error
+	ListCycleTags(ctx context.Context, cycleID string) ([]domain.CycleTag, error)
+	TagStats(ctx context.Context) ([]domain.TagStats, error)
+
+	// 按交易对保存的合约杠杆（配置类数据，重置时保留）
+	SetPairLeverage(ctx context.Context, pair string, leverage int) error
+	GetPairLeverage(ctx context.Context, pair string) (int, error)
+	ListPairLeverage(ctx context.Context) (map[string]int, error)
+
+	// 定时器运行时配置（配置类数据，重置时保留）
+	SaveSchedulerState(ctx context.Context, st domain.SchedulerState) error
+	GetSchedulerState(ctx context.Context) (*domain.SchedulerState, error)
+
+	// 账户权益与回撤熔断
+	InsertEquitySnapshot(ctx context.Context, e domain.EquitySnapshot) error
+	PeakEquitySince(ctx context.Context, since time.Time) (float64, error)
+	ListEquitySnapshots(ctx context.Context, since time.Time, limit int) ([]domain.EquitySnapshot, error)
+	DayOpenUnrealized(ctx context.Context, day string) (map[string]float64, bool, error)
+	InitDayOpenUnrealized(ctx context.Context, day string, marks map[string]float64) error
+	SaveDrawdownHalt(ctx context.Context, h domain.DrawdownHalt) error
+	GetDrawdownHalt(ctx context.Context) (*domain.DrawdownHalt, error)
+
+	// 订单幂等键
+	OrderExistsByIdempotencyKey(ctx context.Context, key string) (bool, error)
+	ActiveOrderExistsByIdempotencyKey(ctx context.Context, key string) (bool, error)
+	RetireFailedIdempotencyKey(ctx context.Context, key string) error
+
+	// 平仓归因（平仓订单 → 开仓订单 / 周期 / 策略）
+	ListPairFills(ctx context.Context, pair string) ([]domain.FillOrigin, error)
+	InsertCloseOrigins(ctx context.Context, origins []domain.CloseOrigin) error
+	ListCloseOrigins(ctx context.Context, closeCycleID string) ([]domain.CloseOrigin, error)
+
+	// 移动止损状态（按交易对，每个持仓一条）
+	SaveTrailingStop(ctx context.Context, t domain.TrailingStop) error
+	ListTrailingStops(ctx context.Context) ([]domain.TrailingStop, error)
+	DeleteTrailingStop(ctx context.Context, pair string) error
+
+	// 只读分享链接（配置类数据，重置时保留）
+	InsertShareLink(ctx context.Context, l domain.ShareLink) error
+	ListShareLinks(ctx context.Context) ([]domain.ShareLink, error)
+	GetShareLinkByToken(ctx context.Context, tokenHash string) (*domain.ShareLink, error)
+	RevokeShareLink(ctx context.Context, id string) (bool, error)
+
+	// 人工审批
+	InsertCycleApproval(ctx context.Context, a domain.CycleApproval) error
+	GetCycleApproval(ctx context.Context, cycleID string) (*domain.CycleApproval, error)
+	ListCycleApprovals(ctx context.Context, status string, limit int) ([]domain.CycleApproval, error)
+	DecideCycleApproval(ctx context.Context, cycleID, status, by, note string) (bool, error)
+
+	// 组合分配计划
+	InsertAllocationPlan(ctx context.Context, p domain.AllocationPlan) error
+	LatestAllocationPlan(ctx context.Context) (*domain.AllocationPlan, error)
+
+	// 拒绝 / 失败原因统计
+	ListCycleOutcomes(ctx context.Context, since time.Time) ([]domain.CycleOutcome, error)
+	ListCycleStatuses(ctx context.Context, since time.Time) ([]domain.CycleOutcome, error)
+
+	// 操作审计
+	InsertAuditEntry(ctx context.Context, e domain.AuditEntry) error
+	ListAuditLog(ctx context.Context, actor string, q domain.ListQuery) ([]domain.AuditEntry, int, error)
 
 	// 数据管理
 	ResetAllData(ctx context.Context) error
+	PruneCycleLogs(ctx context.Context, before time.Time) (int64, error)
+	PruneCycles(ctx context.Context, statuses []domain.CycleStatus, before time.Time) (int64, error)
+	CountPrunableCycles(ctx context.Context, statuses []domain.CycleStatus, before time.Time) (int64, error)
+
+	// 模拟盘钱包
+	GetPaperWallet(ctx context.Context) (*domain.PaperWallet, error)
+	ResetPaperWallet(ctx context.Context, initialUSDT float64) (domain.PaperWallet, error)
+	ApplyPaperEntry(ctx context.Context, e domain.PaperLedgerEntry) (domain.PaperWallet, error)
+	ListPaperLedger(ctx context.Context, limit int) ([]domain.PaperLedgerEntry, error)
 	OrderExistsByExchangeID(ctx context.Context, exchangeOrderID string) (bool, error)
 }
 
@@ -46,6 +181,15 @@ type SQLiteRepository struct {
 	db *sql.DB
 }
 
+// ReadOnlyDSN 在 DSN 上追加 query_only，该连接上的任何写入都会失败
+func ReadOnlyDSN(dsn string) string {
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + "_pragma=query_only(1)"
+}
+
 func NewSQLiteRepository(dsn string) (*SQLiteRepository, error) {
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
@@ -120,13 +264,15 @@ func (r *SQLiteRepository) Init(ctx context.Context) error {
 		);`,
 		`CREATE TABLE IF NOT EXISTS holdings (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			pair TEXT NOT NULL UNIQUE,
+			pair TEXT NOT NULL,
+			position_side TEXT NOT NULL DEFAULT 'LONG',
 			symbol TEXT NOT NULL,
 			quantity REAL NOT NULL DEFAULT 0,
 			avg_price REAL NOT NULL DEFAULT 0,
 			total_cost REAL NOT NULL DEFAULT 0,
 			source TEXT NOT NULL DEFAULT 'local',
-			updated_at TIMESTAMP NOT NULL
+			updated_at TIMESTAMP NOT NULL,
+			UNIQUE (pair, position_side)
 		);`,
 		`CREATE TABLE IF NOT EXISTS position_strategies (
 			id TEXT PRIMARY KEY,
@@ -145,7 +291,286 @@ func (r *SQLiteRepository) Init(ctx context.Context) error {
 			FOREIGN KEY (cycle_id) REFERENCES cycles(id),
 			FOREIGN KEY (signal_id) REFERENCES signals(id)
 		);`,
+		`CREATE TABLE IF NOT EXISTS stop_orders (
+			id TEXT PRIMARY KEY,
+			cycle_id TEXT NOT NULL,
+			pair TEXT NOT NULL,
+			exchange_order_id TEXT NOT NULL,
+			stop_price REAL NOT NULL,
+			status TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);`,
 		`CREATE INDEX IF NOT EXISTS idx_signals_cycle_id ON signals(cycle_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_stop_orders_pair ON stop_orders(pair, status);`,
+		`CREATE TABLE IF NOT EXISTS protective_orders (
+			id TEXT PRIMARY KEY,
+			cycle_id TEXT NOT NULL,
+			pair TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			group_id TEXT NOT NULL,
+			exchange_order_id TEXT NOT NULL,
+			trigger_price REAL NOT NULL,
+			quantity REAL DEFAULT 0,
+			status TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_protective_orders_pair ON protective_orders(pair, status);`,
+		`CREATE TABLE IF NOT EXISTS order_groups (
+			id TEXT PRIMARY KEY,
+			cycle_id TEXT NOT NULL,
+			pair TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			parent_order_id TEXT NOT NULL DEFAULT '',
+			exchange_list_id TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_order_groups_cycle_id ON order_groups(cycle_id);`,
+		`CREATE TABLE IF NOT EXISTS order_group_legs (
+			group_id TEXT NOT NULL,
+			leg_no INTEGER NOT NULL,
+			role TEXT NOT NULL,
+			ref_id TEXT NOT NULL DEFAULT '',
+			exchange_order_id TEXT NOT NULL DEFAULT '',
+			price REAL DEFAULT 0,
+			quantity REAL DEFAULT 0,
+			status TEXT NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (group_id, leg_no),
+			FOREIGN KEY (group_id) REFERENCES order_groups(id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_order_group_legs_ref ON order_group_legs(ref_id);`,
+		// 兼容旧库：stop_orders 中的止损单迁入 protective_orders（已迁移的忽略）
+		`INSERT OR IGNORE INTO protective_orders (id, cycle_id, pair, kind, group_id, exchange_order_id, trigger_price, quantity, status, created_at, updated_at)
+		 SELECT id, cycle_id, pair, 'stop_loss', id, exchange_order_id, stop_price, 0, status, created_at, updated_at FROM stop_orders;`,
+		`CREATE TABLE IF NOT EXISTS order_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			order_id TEXT NOT NULL,
+			cycle_id TEXT NOT NULL,
+			status TEXT NOT NULL,
+			filled_qty REAL DEFAULT 0,
+			filled_price REAL DEFAULT 0,
+			detail TEXT DEFAULT '',
+			created_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_order_events_order_id ON order_events(order_id);`,
+		`CREATE TABLE IF NOT EXISTS shadow_cycles (
+			id TEXT PRIMARY KEY,
+			pair TEXT NOT NULL,
+			side TEXT NOT NULL,
+			confidence REAL NOT NULL,
+			approved INTEGER NOT NULL,
+			reject_reason TEXT DEFAULT '',
+			stake_usdt REAL DEFAULT 0,
+			entry_price REAL NOT NULL,
+			leverage INTEGER DEFAULT 0,
+			take_profit_percent REAL DEFAULT 0,
+			stop_loss_percent REAL DEFAULT 0,
+			reason TEXT DEFAULT '',
+			preview_json TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			evaluated_at TIMESTAMP,
+			exit_price REAL DEFAULT 0,
+			price_change_pct REAL DEFAULT 0,
+			pnl_usdt REAL DEFAULT 0
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_shadow_cycles_pair ON shadow_cycles(pair, created_at);`,
+		`CREATE TABLE IF NOT EXISTS cycle_tags (
+			cycle_id TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			source TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (cycle_id, tag)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_cycle_tags_tag ON cycle_tags(tag);`,
+		`CREATE TABLE IF NOT EXISTS pair_leverage (
+			pair TEXT PRIMARY KEY,
+			leverage INTEGER NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS equity_snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			equity_usdt REAL NOT NULL,
+			cash_usdt REAL DEFAULT 0,
+			positions_usdt REAL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_equity_snapshots_created ON equity_snapshots(created_at);`,
+		`CREATE TABLE IF NOT EXISTS day_open_marks (
+			day TEXT NOT NULL,
+			pair TEXT NOT NULL,
+			unrealized_pnl REAL NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (day, pair)
+		);`,
+		`CREATE TABLE IF NOT EXISTS performance_reports (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			period TEXT NOT NULL,
+			start_date TEXT NOT NULL,
+			end_date TEXT NOT NULL,
+			data TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			UNIQUE(period, start_date)
+		);`,
+		`CREATE TABLE IF NOT EXISTS sandboxes (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT DEFAULT '',
+			initial_usdt REAL NOT NULL,
+			leverage INTEGER DEFAULT 0,
+			model TEXT DEFAULT '',
+			pairs TEXT DEFAULT '',
+			created_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS sandbox_trades (
+			id TEXT PRIMARY KEY,
+			sandbox_id TEXT NOT NULL,
+			preview_id TEXT DEFAULT '',
+			pair TEXT NOT NULL,
+			side TEXT NOT NULL,
+			price REAL NOT NULL,
+			quantity REAL NOT NULL,
+			stake_usdt REAL DEFAULT 0,
+			fee_usdt REAL DEFAULT 0,
+			realized_pnl REAL DEFAULT 0,
+			confidence REAL DEFAULT 0,
+			reason TEXT DEFAULT '',
+			model_name TEXT DEFAULT '',
+			created_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_sandbox_trades_sandbox ON sandbox_trades(sandbox_id, created_at);`,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			actor TEXT NOT NULL,
+			action TEXT NOT NULL,
+			path TEXT NOT NULL,
+			params TEXT DEFAULT '',
+			payload_sha256 TEXT DEFAULT '',
+			status INTEGER DEFAULT 0,
+			request_id TEXT DEFAULT '',
+			client_ip TEXT DEFAULT '',
+			created_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at);`,
+		`CREATE TABLE IF NOT EXISTS drawdown_halt (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			halted BOOLEAN NOT NULL DEFAULT 0,
+			reason TEXT NOT NULL DEFAULT '',
+			drawdown_pct REAL DEFAULT 0,
+			peak_usdt REAL DEFAULT 0,
+			equity_usdt REAL DEFAULT 0,
+			halted_at TIMESTAMP,
+			resumed_at TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS cycle_approvals (
+			cycle_id TEXT PRIMARY KEY,
+			pair TEXT NOT NULL,
+			side TEXT NOT NULL,
+			stake_usdt REAL DEFAULT 0,
+			leverage INTEGER DEFAULT 0,
+			reason TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			requested_by TEXT NOT NULL DEFAULT '',
+			decided_by TEXT NOT NULL DEFAULT '',
+			note TEXT NOT NULL DEFAULT '',
+			expires_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			decided_at TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_cycle_approvals_status ON cycle_approvals(status, created_at);`,
+		`CREATE TABLE IF NOT EXISTS allocation_plans (
+			id TEXT PRIMARY KEY,
+			summary TEXT NOT NULL DEFAULT '',
+			allocations TEXT NOT NULL,
+			model_name TEXT NOT NULL DEFAULT '',
+			prompt_tokens INTEGER DEFAULT 0,
+			completion_tokens INTEGER DEFAULT 0,
+			cost_usd REAL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL
+		);`,
+		// 大模型调用成本台账：只追加，清理 / 删除周期与重置数据都不删除，预算与成本统计以此为准
+		`CREATE TABLE IF NOT EXISTS llm_cost_ledger (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			source_id TEXT NOT NULL UNIQUE,
+			cycle_id TEXT NOT NULL DEFAULT '',
+			pair TEXT NOT NULL DEFAULT '',
+			model_name TEXT NOT NULL DEFAULT '',
+			prompt_tokens INTEGER DEFAULT 0,
+			completion_tokens INTEGER DEFAULT 0,
+			cost_usd REAL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS scheduler_state (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			paused INTEGER NOT NULL DEFAULT 0,
+			interval_sec INTEGER NOT NULL,
+			pairs TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS close_origins (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			close_order_id TEXT NOT NULL,
+			close_cycle_id TEXT DEFAULT '',
+			pair TEXT NOT NULL,
+			entry_order_id TEXT DEFAULT '',
+			entry_cycle_id TEXT DEFAULT '',
+			strategy TEXT DEFAULT '',
+			entry_strategy TEXT DEFAULT '',
+			quantity REAL NOT NULL,
+			entry_price REAL DEFAULT 0,
+			exit_price REAL NOT NULL,
+			realized_pnl REAL DEFAULT 0,
+			entry_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_close_origins_close ON close_origins(close_order_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_close_origins_cycle ON close_origins(close_cycle_id);`,
+		`CREATE TABLE IF NOT EXISTS trailing_stops (
+			pair TEXT PRIMARY KEY,
+			high_water REAL NOT NULL,
+			stop_price REAL NOT NULL,
+			distance REAL DEFAULT 0,
+			last_price REAL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS share_links (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT '',
+			dashboards TEXT NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			expires_at TIMESTAMP NOT NULL,
+			revoked_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS paper_wallet (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			initial_usdt REAL NOT NULL,
+			cash_usdt REAL NOT NULL,
+			margin_usdt REAL NOT NULL DEFAULT 0,
+			fees_usdt REAL NOT NULL DEFAULT 0,
+			realized_pnl REAL NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS paper_ledger (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			order_id TEXT NOT NULL DEFAULT '',
+			pair TEXT NOT NULL,
+			side TEXT NOT NULL,
+			price REAL NOT NULL,
+			quantity REAL NOT NULL,
+			notional_usdt REAL NOT NULL,
+			fee_usdt REAL NOT NULL,
+			cash_delta REAL NOT NULL,
+			margin_delta REAL NOT NULL DEFAULT 0,
+			realized_pnl REAL NOT NULL DEFAULT 0,
+			cash_after REAL NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		);`,
 		`CREATE INDEX IF NOT EXISTS idx_position_strategies_cycle_id ON position_strategies(cycle_id);`,
 		`CREATE INDEX IF NOT EXISTS idx_risk_cycle_id ON risk_checks(cycle_id);`,
 		`CREATE INDEX IF NOT EXISTS idx_orders_cycle_id ON orders(cycle_id);`,
@@ -162,6 +587,44 @@ func (r *SQLiteRepository) Init(ctx context.Context) error {
 		`ALTER TABLE orders ADD COLUMN leverage INTEGER DEFAULT 0;`,
 		// 兼容旧库：添加 model_name 列（记录使用的模型）
 		`ALTER TABLE signals ADD COLUMN model_name TEXT DEFAULT '';`,
+		// 兼容旧库：添加 cost_usd 列（估算的大模型调用成本）
+		`ALTER TABLE signals ADD COLUMN cost_usd REAL DEFAULT 0;`,
+		// 兼容旧库：添加 close_fraction 列（按比例平仓）
+		`ALTER TABLE signals ADD COLUMN close_fraction REAL DEFAULT 0;`,
+		// 兼容旧库：添加 last_price 列（最近市价，用于按市值/盈亏排序）
+		`ALTER TABLE holdings ADD COLUMN last_price REAL DEFAULT 0;`,
+		`CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at);`,
+		// 兼容旧库：添加 preset 列（周期使用的风险偏好预设）
+		`ALTER TABLE cycles ADD COLUMN preset TEXT DEFAULT '';`,
+		// 兼容旧库：添加拒绝 / 失败原因归类代码
+		`ALTER TABLE cycles ADD COLUMN reason_code TEXT DEFAULT '';`,
+		`ALTER TABLE risk_checks ADD COLUMN reject_code TEXT DEFAULT '';`,
+		// 部分成交跟踪
+		`ALTER TABLE orders ADD COLUMN requested_qty REAL DEFAULT 0;`,
+		// 兼容旧库：添加 prompt_version 列（提示词模板版本）
+		`ALTER TABLE signals ADD COLUMN prompt_version TEXT DEFAULT '';`,
+		`ALTER TABLE orders ADD COLUMN parent_order_id TEXT DEFAULT '';`,
+		// 兼容旧库：添加 expected_price 列（决策时预估成交价，用于滑点统计）
+		`ALTER TABLE orders ADD COLUMN expected_price REAL DEFAULT 0;`,
+		// 兼容旧库：添加 exit_batches 列（分批止盈计划）
+		`ALTER TABLE position_strategies ADD COLUMN exit_batches TEXT DEFAULT '[]';`,
+		// 兼容旧库：添加 strategy 列（周期使用的交易策略，用于平仓归因）
+		`ALTER TABLE cycles ADD COLUMN strategy TEXT DEFAULT '';`,
+		`ALTER TABLE cycles ADD COLUMN cycle_type TEXT DEFAULT 'auto';`,
+		// 兼容旧库：添加 idempotency_key 列（同一键只允许一笔订单）
+		`ALTER TABLE orders ADD COLUMN idempotency_key TEXT DEFAULT '';`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_idempotency_key ON orders(idempotency_key) WHERE idempotency_key != '';`,
+		// 兼容旧库：添加 fill_strategy 列（市价 / 限价 / 限价转市价）
+		`ALTER TABLE orders ADD COLUMN fill_strategy TEXT DEFAULT '';`,
+		// 兼容旧库：添加 sizing 列（开仓金额计算过程，JSON）
+		`ALTER TABLE risk_checks ADD COLUMN sizing TEXT DEFAULT '';`,
+		`CREATE INDEX IF NOT EXISTS idx_llm_cost_ledger_created_at ON llm_cost_ledger(created_at);`,
+		// 兼容旧库：把台账建立前已有的信号 / 组合分配计划成本补记到台账（按来源 ID 去重，可重复执行）
+		`INSERT OR IGNORE INTO llm_cost_ledger (source_id, cycle_id, pair, model_name, prompt_tokens, completion_tokens, cost_usd, created_at)
+		 SELECT id, cycle_id, pair, COALESCE(model_name, ''), COALESCE(prompt_tokens, 0), COALESCE(completion_tokens, 0), COALESCE(cost_usd, 0), created_at
+		 FROM signals WHERE COALESCE(cost_usd, 0) > 0 OR COALESCE(total_tokens, 0) > 0;`,
+		`INSERT OR IGNORE INTO llm_cost_ledger (source_id, cycle_id, pair, model_name, prompt_tokens, completion_tokens, cost_usd, created_at)
+		 SELECT id, id, '*', model_name, prompt_tokens, completion_tokens, cost_usd, created_at FROM allocation_plans;`,
 	}
 
 	for _, stmt := range stmts {
@@ -175,17 +638,65 @@ func (r *SQLiteRepository) Init(ctx context.Context) error {
 		}
 	}
 
-	return nil
+	return r.migrateHoldingsPositionSide(ctx)
+}
+
+// migrateHoldingsPositionSide 旧库的 holdings 按 pair 唯一，重建为按 (pair, position_side) 唯一；原有记录均为多头
+func (r *SQLiteRepository) migrateHoldingsPositionSide(ctx context.Context) error {
+	var n int
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM pragma_table_info('holdings') WHERE name = 'position_side'`,
+	).Scan(&n); err != nil {
+		return fmt.Errorf("migrate holdings: %w", err)
+	}
+	if n > 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmts := []string{
+		`CREATE TABLE holdings_v2 (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			pair TEXT NOT NULL,
+			position_side TEXT NOT NULL DEFAULT 'LONG',
+			symbol TEXT NOT NULL,
+			quantity REAL NOT NULL DEFAULT 0,
+			avg_price REAL NOT NULL DEFAULT 0,
+			total_cost REAL NOT NULL DEFAULT 0,
+			source TEXT NOT NULL DEFAULT 'local',
+			updated_at TIMESTAMP NOT NULL,
+			last_price REAL DEFAULT 0,
+			UNIQUE (pair, position_side)
+		);`,
+		`INSERT INTO holdings_v2 (id, pair, position_side, symbol, quantity, avg_price, total_cost, source, updated_at, last_price)
+		 SELECT id, pair, 'LONG', symbol, quantity, avg_price, total_cost, source, updated_at, COALESCE(last_price, 0) FROM holdings;`,
+		`DROP TABLE holdings;`,
+		`ALTER TABLE holdings_v2 RENAME TO holdings;`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migrate holdings: %w", err)
+		}
+	}
+	return tx.Commit()
 }
 
 func (r *SQLiteRepository) CreateCycle(ctx context.Context, cycle domain.Cycle) error {
 	_, err := r.db.ExecContext(
 		ctx,
-		`INSERT INTO cycles (id, pair, status, error_message, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO cycles (id, pair, status, error_message, preset, strategy, cycle_type, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		cycle.ID,
 		cycle.Pair,
 		string(cycle.Status),
 		nullableString(cycle.ErrorMessage),
+		cycle.Preset,
+		cycle.Strategy,
+		string(cycleType(cycle.Type)),
 		cycle.CreatedAt.UTC(),
 		cycle.UpdatedAt.UTC(),
 	)
@@ -195,11 +706,12 @@ func (r *SQLiteRepository) CreateCycle(ctx context.Context, cycle domain.Cycle)
 	return nil
 }
 
-func (r *SQLiteRepository) UpdateCycleStatus(ctx context.Context, cycleID string, status domain.CycleStatus, errMsg string) error {
+func (r *SQLiteRepository) UpdateCycleStatus(ctx context.Context, cycleID string, status domain.CycleStatus, code domain.ReasonCode, errMsg string) error {
 	_, err := r.db.ExecContext(
 		ctx,
-		`UPDATE cycles SET status = ?, error_message = ?, updated_at = ? WHERE id = ?`,
+		`UPDATE cycles SET status = ?, reason_code = ?, error_message = ?, updated_at = ? WHERE id = ?`,
 		string(status),
+		string(code),
 		nullableString(errMsg),
 		time.Now().UTC(),
 		cycleID,
@@ -213,7 +725,7 @@ func (r *SQLiteRepository) UpdateCycleStatus(ctx context.Context, cycleID string
 func (r *SQLiteRepository) InsertSignal(ctx context.Context, signal domain.Signal) error {
 	_, err := r.db.ExecContext(
 		ctx,
-		`INSERT INTO signals (id, cycle_id, pair, side, confidence, reason, thinking, prompt_tokens, completion_tokens, total_tokens, model_name, ttl_seconds, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO signals (id, cycle_id, pair, side, confidence, reason, thinking, prompt_tokens, completion_tokens, total_tokens, model_name, cost_usd, close_fraction, prompt_version, ttl_seconds, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		signal.ID,
 		signal.CycleID,
 		signal.Pair,
@@ -225,25 +737,45 @@ func (r *SQLiteRepository) InsertSignal(ctx context.Context, signal domain.Signa
 		signal.CompletionTokens,
 		signal.TotalTokens,
 		signal.ModelName,
+		signal.CostUSD,
+		signal.CloseFraction,
+		signal.PromptVersion,
 		signal.TTLSeconds,
 		signal.CreatedAt.UTC(),
 	)
 	if err != nil {
 		return fmt.Errorf("insert signal: %w", err)
 	}
+	if signal.CostUSD > 0 || signal.TotalTokens > 0 {
+		return r.appendLLMCost(ctx, signal.ID, domain.LLMCost{
+			CycleID:          signal.CycleID,
+			Pair:             signal.Pair,
+			ModelName:        signal.ModelName,
+			PromptTokens:     signal.PromptTokens,
+			CompletionTokens: signal.CompletionTokens,
+			CostUSD:          signal.CostUSD,
+			CreatedAt:        signal.CreatedAt,
+		})
+	}
 	return nil
 }
 
 func (r *SQLiteRepository) InsertRiskDecision(ctx context.Context, decision domain.RiskDecision) error {
-	_, err := r.db.ExecContext(
+	sizing, err := marshalSizing(decision.Sizing)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(
 		ctx,
-		`INSERT INTO risk_checks (id, cycle_id, signal_id, approved, reject_reason, max_stake_usdt, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO risk_checks (id, cycle_id, signal_id, approved, reject_reason, reject_code, max_stake_usdt, sizing, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		decision.ID,
 		decision.CycleID,
 		decision.SignalID,
 		boolToInt(decision.Approved),
 		nullableString(decision.RejectReason),
+		string(decision.RejectCode),
 		decision.MaxStakeUSDT,
+		sizing,
 		decision.CreatedAt.UTC(),
 	)
 	if err != nil {
@@ -253,28 +785,89 @@ func (r *SQLiteRepository) InsertRiskDecision(ctx context.Context, decision doma
 }
 
 func (r *SQLiteRepository) InsertOrder(ctx context.Context, order domain.Order) error {
-	_, err := r.db.ExecContext(
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
 		ctx,
-		`INSERT INTO orders (id, cycle_id, signal_id, client_order_id, pair, side, stake_usdt, leverage, status, exchange_order_id, filled_price, filled_qty, raw_response, created_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO orders (id, cycle_id, signal_id, client_order_id, idempotency_key, pair, side, stake_usdt, expected_price, leverage, status, exchange_order_id, filled_price, filled_qty, requested_qty, parent_order_id, fill_strategy, raw_response, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		order.ID,
 		order.CycleID,
 		order.SignalID,
 		order.ClientOrderID,
+		order.IdempotencyKey,
 		order.Pair,
 		string(order.Side),
 		order.StakeUSDT,
+		order.ExpectedPrice,
 		order.Leverage,
 		order.Status,
 		nullableString(order.ExchangeOrderID),
 		nullableFloat(order.FilledPrice),
 		nullableFloat(order.FilledQuantity),
+		order.RequestedQty,
+		order.ParentOrderID,
+		order.FillStrategy,
 		nullableString(order.RawResponse),
 		order.CreatedAt.UTC(),
 	)
 	if err != nil {
 		return fmt.Errorf("insert order: %w", err)
 	}
+
+	// 状态历史与订单同一事务写入
+	for _, e := range orderCreatedEvents(order) {
+		if err := insertOrderEvent(ctx, tx, e); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// OrderExistsByIdempotencyKey 是否已有使用该幂等键的订单
+func (r *SQLiteRepository) OrderExistsByIdempotencyKey(ctx context.Context, key string) (bool, error) {
+	if key == "" {
+		return false, nil
+	}
+	var n int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM orders WHERE idempotency_key = ?`, key).Scan(&n); err != nil {
+		return false, fmt.Errorf("查询幂等键: %w", err)
+	}
+	return n > 0, nil
+}
+
+// failedOrderCond 下单失败且没有任何成交的订单（交易所拒单、请求失败）
+const failedOrderCond = `status IN ('failed', 'rejected') AND COALESCE(filled_qty, 0) = 0`
+
+// ActiveOrderExistsByIdempotencyKey 是否已有使用该幂等键、且不是失败未成交的订单（已提交、成交或部分成交）
+func (r *SQLiteRepository) ActiveOrderExistsByIdempotencyKey(ctx context.Context, key string) (bool, error) {
+	if key == "" {
+		return false, nil
+	}
+	var n int
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM orders WHERE idempotency_key = ? AND NOT (`+failedOrderCond+`)`, key,
+	).Scan(&n); err != nil {
+		return false, fmt.Errorf("查询幂等键: %w", err)
+	}
+	return n > 0, nil
+}
+
+// RetireFailedIdempotencyKey 失败未成交的订单改用 "键#订单ID" 归档，释放幂等键与由它派生的 clientOrderId 供重试下单使用
+func (r *SQLiteRepository) RetireFailedIdempotencyKey(ctx context.Context, key string) error {
+	if key == "" {
+		return nil
+	}
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE orders SET idempotency_key = idempotency_key || '#' || id, client_order_id = client_order_id || '#' || id
+		 WHERE idempotency_key = ? AND `+failedOrderCond, key,
+	); err != nil {
+		return fmt.Errorf("释放幂等键: %w", err)
+	}
 	return nil
 }
 
@@ -324,6 +917,25 @@ func (r *SQLiteRepository) GetCycleReport(ctx context.Context, cycleID string) (
 	}
 	if order != nil {
 		report.Order = order
+		events, err := r.ListOrderEvents(ctx, order.ID)
+		if err != nil {
+			return report, err
+		}
+		report.OrderEvents = events
+	}
+
+	groups, err := r.ListOrderGroups(ctx, cycleID)
+	if err != nil {
+		return report, err
+	}
+	report.OrderGroups = groups
+
+	origins, err := r.ListCloseOrigins(ctx, cycleID)
+	if err != nil {
+		return report, err
+	}
+	if len(origins) > 0 {
+		report.CloseOrigins = origins
 	}
 
 	// 获取建仓策略
@@ -335,6 +947,12 @@ func (r *SQLiteRepository) GetCycleReport(ctx context.Context, cycleID string) (
 		report.PositionStrategy = posStrategy
 	}
 
+	tags, err := r.ListCycleTags(ctx, cycleID)
+	if err != nil {
+		return report, err
+	}
+	report.Tags = tags
+
 	logs, err := r.getLogs(ctx, cycleID)
 	if err != nil {
 		return report, err
@@ -346,14 +964,14 @@ func (r *SQLiteRepository) GetCycleReport(ctx context.Context, cycleID string) (
 
 func (r *SQLiteRepository) getCycle(ctx context.Context, cycleID string) (domain.Cycle, error) {
 	var cycle domain.Cycle
-	var status string
+	var status, code, typ string
 	var errMsg sql.NullString
 
 	err := r.db.QueryRowContext(
 		ctx,
-		`SELECT id, pair, status, error_message, created_at, updated_at FROM cycles WHERE id = ?`,
+		`SELECT id, pair, status, error_message, COALESCE(reason_code, ''), COALESCE(preset, ''), COALESCE(strategy, ''), COALESCE(cycle_type, ''), created_at, updated_at FROM cycles WHERE id = ?`,
 		cycleID,
-	).Scan(&cycle.ID, &cycle.Pair, &status, &errMsg, &cycle.CreatedAt, &cycle.UpdatedAt)
+	).Scan(&cycle.ID, &cycle.Pair, &status, &errMsg, &code, &cycle.Preset, &cycle.Strategy, &typ, &cycle.CreatedAt, &cycle.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return cycle, fmt.Errorf("cycle %s not found", cycleID)
@@ -365,6 +983,8 @@ func (r *SQLiteRepository) getCycle(ctx context.Context, cycleID string) (domain
 	if errMsg.Valid {
 		cycle.ErrorMessage = errMsg.String
 	}
+	cycle.ReasonCode = reasonCode(code, cycle.ErrorMessage)
+	cycle.Type = cycleType(domain.CycleType(typ))
 
 	return cycle, nil
 }
@@ -379,11 +999,12 @@ func (r *SQLiteRepository) getSignal(ctx context.Context, cycleID string) (*doma
 		ctx,
 		`SELECT id, cycle_id, pair, side, confidence, reason, COALESCE(thinking, ''),
 		        COALESCE(prompt_tokens, 0), COALESCE(completion_tokens, 0), COALESCE(total_tokens, 0),
-		        COALESCE(model_name, ''), ttl_seconds, created_at
+		        COALESCE(model_name, ''), COALESCE(cost_usd, 0), COALESCE(close_fraction, 0), COALESCE(prompt_version, ''),
+		        ttl_seconds, created_at
 		 FROM signals WHERE cycle_id = ? ORDER BY created_at DESC LIMIT 1`,
 		cycleID,
 	).Scan(&signal.ID, &signal.CycleID, &signal.Pair, &side, &signal.Confidence, &signal.Reason, &thinking,
-		&promptTok, &completionTok, &totalTok, &modelName,
+		&promptTok, &completionTok, &totalTok, &modelName, &signal.CostUSD, &signal.CloseFraction, &signal.PromptVersion,
 		&signal.TTLSeconds, &signal.CreatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -415,13 +1036,14 @@ func (r *SQLiteRepository) getRisk(ctx context.Context, cycleID string) (*domain
 	var risk domain.RiskDecision
 	var approved int
 	var rejectReason sql.NullString
+	var rejectCode, sizing string
 
 	err := r.db.QueryRowContext(
 		ctx,
-		`SELECT id, cycle_id, signal_id, approved, reject_reason, max_stake_usdt, created_at
+		`SELECT id, cycle_id, signal_id, approved, reject_reason, COALESCE(reject_code, ''), max_stake_usdt, COALESCE(sizing, ''), created_at
 		 FROM risk_checks WHERE cycle_id = ? ORDER BY created_at DESC LIMIT 1`,
 		cycleID,
-	).Scan(&risk.ID, &risk.CycleID, &risk.SignalID, &approved, &rejectReason, &risk.MaxStakeUSDT, &risk.CreatedAt)
+	).Scan(&risk.ID, &risk.CycleID, &risk.SignalID, &approved, &rejectReason, &rejectCode, &risk.MaxStakeUSDT, &sizing, &risk.CreatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -433,9 +1055,42 @@ func (r *SQLiteRepository) getRisk(ctx context.Context, cycleID string) (*domain
 	if rejectReason.Valid {
 		risk.RejectReason = rejectReason.String
 	}
+	if !risk.Approved {
+		risk.RejectCode = reasonCode(rejectCode, risk.RejectReason)
+	}
+	if sizing != "" {
+		risk.Sizing = &domain.SizingBreakdown{}
+		if err := json.Unmarshal([]byte(sizing), risk.Sizing); err != nil {
+			return nil, fmt.Errorf("parse risk sizing: %w", err)
+		}
+	}
 	return &risk, nil
 }
 
+// UpdateRiskSizing 更新风控决策的开仓金额计算过程（下单阶段的余额 / 上限调整在风控之后发生）
+func (r *SQLiteRepository) UpdateRiskSizing(ctx context.Context, id string, sizing *domain.SizingBreakdown) error {
+	data, err := marshalSizing(sizing)
+	if err != nil {
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, `UPDATE risk_checks SET sizing = ? WHERE id = ?`, data, id); err != nil {
+		return fmt.Errorf("update risk sizing: %w", err)
+	}
+	return nil
+}
+
+// marshalSizing 开仓金额计算过程序列化为 JSON，为空时存空字符串
+func marshalSizing(s *domain.SizingBreakdown) (string, error) {
+	if s == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", fmt.Errorf("marshal risk sizing: %w", err)
+	}
+	return string(data), nil
+}
+
 func (r *SQLiteRepository) getOrder(ctx context.Context, cycleID string) (*domain.Order, error) {
 	var order domain.Order
 	var side string
@@ -445,8 +1100,9 @@ func (r *SQLiteRepository) getOrder(ctx context.Context, cycleID string) (*domai
 
 	err := r.db.QueryRowContext(
 		ctx,
-		`SELECT id, cycle_id, signal_id, client_order_id, pair, side, stake_usdt, status, exchange_order_id, filled_price, raw_response, created_at
-		 FROM orders WHERE cycle_id = ? ORDER BY created_at DESC LIMIT 1`,
+		`SELECT id, cycle_id, signal_id, client_order_id, pair, side, stake_usdt, status, exchange_order_id, filled_price, COALESCE(fill_strategy, ''), raw_response, created_at
+		 FROM orders WHERE cycle_id = ?
+		 ORDER BY (fill_strategy = 'twap' AND COALESCE(parent_order_id, '') = '') DESC, created_at DESC LIMIT 1`,
 		cycleID,
 	).Scan(
 		&order.ID,
@@ -459,6 +1115,7 @@ func (r *SQLiteRepository) getOrder(ctx context.Context, cycleID string) (*domai
 		&order.Status,
 		&exchangeOrderID,
 		&filledPrice,
+		&order.FillStrategy,
 		&rawResp,
 		&order.CreatedAt,
 	)
@@ -491,9 +1148,20 @@ func (r *SQLiteRepository) DeleteCycle(ctx context.Context, cycleID string) erro
 	}
 	defer tx.Rollback()
 
+	// 订单组的腿没有 cycle_id，先按所属订单组删除
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM order_group_legs WHERE group_id IN (SELECT id FROM order_groups WHERE cycle_id = ?)`, cycleID,
+	); err != nil {
+		return fmt.Errorf("删除 order_group_legs: %w", err)
+	}
+
 	// 删除关联数据（按外键依赖顺序）
 	tables := []string{
+		"order_groups",
 		"cycle_logs",
+		"cycle_tags",
+		"cycle_approvals",
+		"order_events",
 		"orders",
 		"risk_checks",
 		"position_strategies",
@@ -541,20 +1209,26 @@ func (r *SQLiteRepository) getLogs(ctx context.Context, cycleID string) ([]domai
 	return logs, nil
 }
 
-func (r *SQLiteRepository) ListPositions(ctx context.Context, limit int) ([]domain.PositionView, error) {
-	if limit <= 0 {
-		limit = 50
+func (r *SQLiteRepository) ListPositions(ctx context.Context, q domain.ListQuery) ([]domain.PositionView, error) {
+	if q.PageSize <= 0 {
+		q.PageSize = 50
+	}
+	orderBy, err := orderClause(positionSortFields, q, "created_at")
+	if err != nil {
+		return nil, err
 	}
 	rows, err := r.db.QueryContext(ctx, `
 		SELECT
 			o.id, o.cycle_id, o.pair, o.side, o.stake_usdt, o.filled_price, o.filled_qty, o.status,
-			COALESCE(o.exchange_order_id, ''), s.reason, s.confidence, c.status, o.created_at
+			COALESCE(o.exchange_order_id, ''), s.reason, s.confidence, c.status, o.created_at,
+			`+positionRealizedPnL+`
 		FROM orders o
 		JOIN signals s ON s.cycle_id = o.cycle_id
 		JOIN cycles c ON c.id = o.cycle_id
-		ORDER BY o.created_at DESC
-		LIMIT ?
-	`, limit)
+		WHERE `+tagFilter+`
+		ORDER BY `+orderBy+`, o.id ASC
+		LIMIT ? OFFSET ?
+	`, q.Tag, q.Tag, q.PageSize, q.Offset())
 	if err != nil {
 		return nil, fmt.Errorf("查询仓位列表: %w", err)
 	}
@@ -568,6 +1242,7 @@ func (r *SQLiteRepository) ListPositions(ctx context.Context, limit int) ([]doma
 		if err := rows.Scan(
 			&p.OrderID, &p.CycleID, &p.Pair, &side, &p.StakeUSDT, &filledPrice, &filledQty, &p.Status,
 			&p.ExchangeOrderID, &p.SignalReason, &p.Confidence, &cycleStatus, &p.CreatedAt,
+			&p.RealizedPnL,
 		); err != nil {
 			return nil, fmt.Errorf("扫描仓位记录: %w", err)
 		}
@@ -593,14 +1268,14 @@ func (r *SQLiteRepository) ListPositions(ctx context.Context, limit int) ([]doma
 // ==================== 周期列表（分页） ====================
 
 // CountCycles 统计周期总数
-func (r *SQLiteRepository) CountCycles(ctx context.Context) (int, error) {
+func (r *SQLiteRepository) CountCycles(ctx context.Context, tag string) (int, error) {
 	var count int
-	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM cycles").Scan(&count)
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM cycles c WHERE "+tagFilter, tag, tag).Scan(&count)
 	return count, err
 }
 
 // ListCycles 分页查询周期摘要（含信号、风控、订单关键字段）
-func (r *SQLiteRepository) ListCycles(ctx context.Context, page, pageSize int) ([]domain.CycleSummary, error) {
+func (r *SQLiteRepository) ListCycles(ctx context.Context, page, pageSize int, tag string) ([]domain.CycleSummary, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -611,7 +1286,7 @@ func (r *SQLiteRepository) ListCycles(ctx context.Context, page, pageSize int) (
 
 	rows, err := r.db.QueryContext(ctx, `
 		SELECT
-			c.id, c.pair, c.status, COALESCE(c.error_message, ''),
+			c.id, c.pair, c.status, COALESCE(c.error_message, ''), COALESCE(c.reason_code, ''), COALESCE(c.preset, ''), COALESCE(c.cycle_type, ''),
 			COALESCE(s.side, ''),
 			COALESCE(s.confidence, 0),
 			COALESCE(s.reason, ''),
@@ -627,9 +1302,10 @@ func (r *SQLiteRepository) ListCycles(ctx context.Context, page, pageSize int) (
 		LEFT JOIN signals s ON s.cycle_id = c.id
 		LEFT JOIN risk_checks r ON r.cycle_id = c.id
 		LEFT JOIN orders o ON o.cycle_id = c.id
+		WHERE `+tagFilter+`
 		ORDER BY c.created_at DESC
 		LIMIT ? OFFSET ?
-	`, pageSize, offset)
+	`, tag, tag, pageSize, offset)
 	if err != nil {
 		return nil, fmt.Errorf("查询周期列表: %w", err)
 	}
@@ -638,11 +1314,11 @@ func (r *SQLiteRepository) ListCycles(ctx context.Context, page, pageSize int) (
 	results := make([]domain.CycleSummary, 0, pageSize)
 	for rows.Next() {
 		var cs domain.CycleSummary
-		var status, side, errMsg, reason, modelName, rejectReason, orderStatus string
+		var status, side, errMsg, code, typ, reason, modelName, rejectReason, orderStatus string
 		var riskApproved sql.NullInt64
 
 		if err := rows.Scan(
-			&cs.CycleID, &cs.Pair, &status, &errMsg,
+			&cs.CycleID, &cs.Pair, &status, &errMsg, &code, &cs.Preset, &typ,
 			&side, &cs.Confidence, &reason, &cs.TotalTokens, &modelName,
 			&riskApproved, &rejectReason,
 			&cs.StakeUSDT, &cs.FilledPrice, &orderStatus,
@@ -656,6 +1332,8 @@ func (r *SQLiteRepository) ListCycles(ctx context.Context, page, pageSize int) (
 		cs.SignalReason = reason
 		cs.ModelName = modelName
 		cs.ErrorMessage = errMsg
+		cs.ReasonCode = reasonCode(code, errMsg)
+		cs.Type = cycleType(domain.CycleType(typ))
 		cs.OrderStatus = orderStatus
 		cs.RejectReason = rejectReason
 		if riskApproved.Valid {
@@ -665,37 +1343,65 @@ func (r *SQLiteRepository) ListCycles(ctx context.Context, page, pageSize int) (
 
 		results = append(results, cs)
 	}
-	return results, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(results))
+	for i, cs := range results {
+		ids[i] = cs.CycleID
+	}
+	tags, err := r.tagsForCycles(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Tags = tags[results[i].CycleID]
+	}
+	return results, nil
 }
 
 // ==================== Holdings 持仓管理 ====================
 
-// UpsertHolding 插入或更新持仓（按 pair 唯一键）
+// UpsertHolding 插入或更新持仓（按 pair + position_side 唯一键，未指定方向时为多头）
 func (r *SQLiteRepository) UpsertHolding(ctx context.Context, h domain.Holding) error {
+	side := h.PositionSide
+	if side == "" {
+		side = domain.PositionSideLong
+	}
 	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO holdings (pair, symbol, quantity, avg_price, total_cost, source, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(pair) DO UPDATE SET
+		INSERT INTO holdings (pair, position_side, symbol, quantity, avg_price, total_cost, source, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(pair, position_side) DO UPDATE SET
 			quantity   = excluded.quantity,
 			avg_price  = excluded.avg_price,
 			total_cost = excluded.total_cost,
 			source     = excluded.source,
 			updated_at = excluded.updated_at
-	`, h.Pair, h.Symbol, h.Quantity, h.AvgPrice, h.TotalCost, h.Source, h.UpdatedAt.UTC())
+	`, h.Pair, side, h.Symbol, h.Quantity, h.AvgPrice, h.TotalCost, h.Source, h.UpdatedAt.UTC())
 	if err != nil {
 		return fmt.Errorf("upsert holding: %w", err)
 	}
 	return nil
 }
 
-// ListHoldings 获取所有持仓记录
+// ListHoldings 获取所有多头持仓记录（本系统下单、止盈止损与风控只针对多头）
 func (r *SQLiteRepository) ListHoldings(ctx context.Context) ([]domain.Holding, error) {
+	return r.listHoldingsBySide(ctx, domain.PositionSideLong)
+}
+
+// ListShortHoldings 获取从交易所同步的合约空头持仓
+func (r *SQLiteRepository) ListShortHoldings(ctx context.Context) ([]domain.Holding, error) {
+	return r.listHoldingsBySide(ctx, domain.PositionSideShort)
+}
+
+func (r *SQLiteRepository) listHoldingsBySide(ctx context.Context, side string) ([]domain.Holding, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT id, pair, symbol, quantity, avg_price, total_cost, source, updated_at
+		SELECT id, pair, position_side, symbol, quantity, avg_price, total_cost, COALESCE(last_price, 0), source, updated_at
 		FROM holdings
-		WHERE quantity > 0
+		WHERE quantity > 0 AND position_side = ?
 		ORDER BY total_cost DESC
-	`)
+	`, side)
 	if err != nil {
 		return nil, fmt.Errorf("查询持仓: %w", err)
 	}
@@ -704,7 +1410,7 @@ func (r *SQLiteRepository) ListHoldings(ctx context.Context) ([]domain.Holding,
 	holdings := make([]domain.Holding, 0)
 	for rows.Next() {
 		var h domain.Holding
-		if err := rows.Scan(&h.ID, &h.Pair, &h.Symbol, &h.Quantity, &h.AvgPrice, &h.TotalCost, &h.Source, &h.UpdatedAt); err != nil {
+		if err := rows.Scan(&h.ID, &h.Pair, &h.PositionSide, &h.Symbol, &h.Quantity, &h.AvgPrice, &h.TotalCost, &h.LastPrice, &h.Source, &h.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("扫描持仓记录: %w", err)
 		}
 		holdings = append(holdings, h)
@@ -717,7 +1423,7 @@ func (r *SQLiteRepository) AggregateHoldingsFromOrders(ctx context.Context) ([]d
 	rows, err := r.db.QueryContext(ctx, `
 		SELECT pair, side, filled_price, filled_qty
 		FROM orders
-		WHERE status IN ('filled', 'simulated_filled')
+		WHERE status IN (`+filledStatuses+`)
 		  AND filled_qty > 0 AND filled_price > 0
 		ORDER BY created_at ASC
 	`)
@@ -726,42 +1432,18 @@ func (r *SQLiteRepository) AggregateHoldingsFromOrders(ctx context.Context) ([]d
 	}
 	defer rows.Close()
 
-	// 按币对聚合：买入增加持仓，卖出减少持仓
-	type acc struct {
-		qty       float64
-		totalCost float64
-	}
-	pairMap := make(map[string]*acc)
-
+	// 按币对回放：买入记入成本批次，卖出按配置的成本核算方法冲减
+	book := costbasis.NewBook(costbasis.Current())
 	for rows.Next() {
 		var pair, side string
 		var price, qty float64
 		if err := rows.Scan(&pair, &side, &price, &qty); err != nil {
 			return nil, fmt.Errorf("扫描订单: %w", err)
 		}
-		a, ok := pairMap[pair]
-		if !ok {
-			a = &acc{}
-			pairMap[pair] = a
-		}
 		if side == "long" {
-			// 买入：增加持仓和成本
-			a.totalCost += qty * price
-			a.qty += qty
+			book.Buy(pair, qty, price)
 		} else if side == "close" {
-			// 卖出：减少持仓，按比例减少成本
-			if a.qty > 0 {
-				ratio := qty / a.qty
-				if ratio > 1 {
-					ratio = 1
-				}
-				a.totalCost -= a.totalCost * ratio
-			}
-			a.qty -= qty
-			if a.qty < 0 {
-				a.qty = 0
-				a.totalCost = 0
-			}
+			book.Sell(pair, qty, price)
 		}
 	}
 	if err := rows.Err(); err != nil {
@@ -769,22 +1451,19 @@ func (r *SQLiteRepository) AggregateHoldingsFromOrders(ctx context.Context) ([]d
 	}
 
 	now := time.Now().UTC()
-	result := make([]domain.Holding, 0, len(pairMap))
-	for pair, a := range pairMap {
-		if a.qty <= 0 {
+	pairs := book.Pairs()
+	result := make([]domain.Holding, 0, len(pairs))
+	for _, pair := range pairs {
+		qty, cost := book.Position(pair)
+		if qty <= 0 {
 			continue
 		}
-		symbol := strings.Split(pair, "/")[0]
-		avgPrice := 0.0
-		if a.qty > 0 {
-			avgPrice = a.totalCost / a.qty
-		}
 		result = append(result, domain.Holding{
 			Pair:      pair,
-			Symbol:    symbol,
-			Quantity:  a.qty,
-			AvgPrice:  avgPrice,
-			TotalCost: a.totalCost,
+			Symbol:    strings.Split(pair, "/")[0],
+			Quantity:  qty,
+			AvgPrice:  cost / qty,
+			TotalCost: cost,
 			Source:    "local",
 			UpdatedAt: now,
 		})
@@ -792,9 +1471,9 @@ func (r *SQLiteRepository) AggregateHoldingsFromOrders(ctx context.Context) ([]d
 	return result, nil
 }
 
-// ResetAllData 清空所有业务数据（保留表结构）
+// ResetAllData 清空所有业务数据（保留表结构）；操作审计日志不清空
 func (r *SQLiteRepository) ResetAllData(ctx context.Context) error {
-	tables := []string{"holdings", "cycle_logs", "orders", "risk_checks", "signals", "cycles"}
+	tables := []string{"performance_reports", "paper_ledger", "paper_wallet", "holdings", "trailing_stops", "close_origins", "equity_snapshots", "day_open_marks", "cycle_approvals", "cycle_tags", "shadow_cycles", "sandbox_trades", "sandboxes", "order_group_legs", "order_groups", "protective_orders", "stop_orders", "position_strategies", "cycle_logs", "order_events", "orders", "risk_checks", "signals", "cycles"}
 	for _, t := range tables {
 		if _, err := r.db.ExecContext(ctx, "DELETE FROM "+t); err != nil {
 			return fmt.Errorf("清空表 %s 失败: %w", t, err)
@@ -836,6 +1515,14 @@ func boolToInt(v bool) int {
 	return 0
 }
 
+// cycleType 旧数据与未指定类型的周期按大模型周期处理
+func cycleType(t domain.CycleType) domain.CycleType {
+	if t == "" {
+		return domain.CycleTypeAuto
+	}
+	return t
+}
+
 func nullableString(v string) any {
 	if v == "" {
 		return nil
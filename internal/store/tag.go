@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai_quant/internal/domain"
+)
+
+// AddCycleTags 给周期添加标签（已存在的标签忽略）
+func (r *SQLiteRepository) AddCycleTags(ctx context.Context, cycleID, source string, tags []string) error {
+	now := time.Now().UTC()
+	for _, t := range tags {
+		_, err := r.db.ExecContext(ctx,
+			`INSERT OR IGNORE INTO cycle_tags (cycle_id, tag, source, created_at) VALUES (?, ?, ?, ?)`,
+			cycleID, t, source, now,
+		)
+		if err != nil {
+			return fmt.Errorf("insert cycle tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// RemoveCycleTag 删除周期上的某个标签
+func (r *SQLiteRepository) RemoveCycleTag(ctx context.Context, cycleID, tag string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM cycle_tags WHERE cycle_id = ? AND tag = ?`, cycleID, tag)
+	if err != nil {
+		return fmt.Errorf("delete cycle tag: %w", err)
+	}
+	return nil
+}
+
+// ListCycleTags 获取周期的全部标签
+func (r *SQLiteRepository) ListCycleTags(ctx context.Context, cycleID string) ([]domain.CycleTag, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT cycle_id, tag, source, created_at FROM cycle_tags WHERE cycle_id = ? ORDER BY created_at ASC, tag ASC`,
+		cycleID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询周期标签: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make([]domain.CycleTag, 0)
+	for rows.Next() {
+		var t domain.CycleTag
+		if err := rows.Scan(&t.CycleID, &t.Tag, &t.Source, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描周期标签: %w", err)
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// TagStats 按标签汇总周期结果
+func (r *SQLiteRepository) TagStats(ctx context.Context) ([]domain.TagStats, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.tag,
+		       COUNT(DISTINCT c.id),
+		       COUNT(DISTINCT CASE WHEN c.status = 'success' THEN c.id END),
+		       COUNT(DISTINCT CASE WHEN c.status = 'rejected' THEN c.id END),
+		       COUNT(DISTINCT CASE WHEN c.status = 'failed' THEN c.id END),
+		       COUNT(DISTINCT CASE WHEN o.status IN (`+filledStatuses+`) THEN o.id END),
+		       COALESCE(SUM(CASE WHEN o.status IN (`+filledStatuses+`) THEN o.stake_usdt END), 0),
+		       COALESCE(AVG(s.confidence), 0)
+		FROM cycle_tags t
+		JOIN cycles c ON c.id = t.cycle_id
+		LEFT JOIN signals s ON s.cycle_id = c.id
+		LEFT JOIN orders o ON o.cycle_id = c.id
+		GROUP BY t.tag
+		ORDER BY COUNT(DISTINCT c.id) DESC, t.tag ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("汇总标签: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]domain.TagStats, 0)
+	for rows.Next() {
+		var s domain.TagStats
+		if err := rows.Scan(&s.Tag, &s.Cycles, &s.Success, &s.Rejected, &s.Failed,
+			&s.Orders, &s.StakeUSDT, &s.AvgConfidence); err != nil {
+			return nil, fmt.Errorf("扫描标签汇总: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// tagsForCycles 批量查询多个周期的标签名
+func (r *SQLiteRepository) tagsForCycles(ctx context.Context, cycleIDs []string) (map[string][]string, error) {
+	result := make(map[string][]string, len(cycleIDs))
+	if len(cycleIDs) == 0 {
+		return result, nil
+	}
+	placeholders := make([]string, len(cycleIDs))
+	args := make([]any, len(cycleIDs))
+	for i, id := range cycleIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT cycle_id, tag FROM cycle_tags WHERE cycle_id IN (`+strings.Join(placeholders, ", ")+`) ORDER BY tag ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询周期标签: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return nil, fmt.Errorf("扫描周期标签: %w", err)
+		}
+		result[id] = append(result[id], tag)
+	}
+	return result, rows.Err()
+}
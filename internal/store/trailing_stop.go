@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"ai_quant/internal/domain"
+)
+
+// SaveTrailingStop 保存交易对的移动止损状态（每个交易对一条，覆盖写入）
+func (r *SQLiteRepository) SaveTrailingStop(ctx context.Context, t domain.TrailingStop) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO trailing_stops (pair, high_water, stop_price, distance, last_price, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(pair) DO UPDATE SET high_water = excluded.high_water, stop_price = excluded.stop_price,
+		 distance = excluded.distance, last_price = excluded.last_price, updated_at = excluded.updated_at`,
+		t.Pair, t.HighWater, t.StopPrice, t.Distance, t.LastPrice, t.CreatedAt.UTC(), t.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("save trailing stop: %w", err)
+	}
+	return nil
+}
+
+// ListTrailingStops 全部移动止损状态
+func (r *SQLiteRepository) ListTrailingStops(ctx context.Context) ([]domain.TrailingStop, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT pair, high_water, stop_price, distance, last_price, created_at, updated_at FROM trailing_stops ORDER BY pair`)
+	if err != nil {
+		return nil, fmt.Errorf("查询移动止损: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]domain.TrailingStop, 0)
+	for rows.Next() {
+		var t domain.TrailingStop
+		if err := rows.Scan(&t.Pair, &t.HighWater, &t.StopPrice, &t.Distance, &t.LastPrice, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("扫描移动止损: %w", err)
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// DeleteTrailingStop 删除交易对的移动止损状态（持仓清空后重新开始跟踪）
+func (r *SQLiteRepository) DeleteTrailingStop(ctx context.Context, pair string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM trailing_stops WHERE pair = ?`, pair); err != nil {
+		return fmt.Errorf("删除移动止损: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package strategy
+
+import (
+	"ai_quant/internal/agent/position"
+	"ai_quant/internal/agent/signal"
+	"ai_quant/internal/domain"
+)
+
+// Rules 只用规则引擎出信号、不调用大模型的内置策略
+const Rules = "rules"
+
+func init() {
+	Register(Default, func(deps Deps) (Strategy, error) {
+		return Compose(Default, deps.Signal, deps.Position, nil), nil
+	})
+	Register(Rules, func(deps Deps) (Strategy, error) {
+		return Compose(Rules, &signal.RuleBasedAgent{}, deps.Position, nil), nil
+	})
+}
+
+// Compose 用现有组件拼装策略；risk 为空时直接使用全局风险预设
+func Compose(name string, sig signal.Agent, pos position.Agent, risk func(base domain.RiskPreset) domain.RiskPreset) Strategy {
+	return composed{name: name, signal: sig, position: pos, risk: risk}
+}
+
+type composed struct {
+	name     string
+	signal   signal.Agent
+	position position.Agent
+	risk     func(base domain.RiskPreset) domain.RiskPreset
+}
+
+func (c composed) Name() string                  { return c.name }
+func (c composed) SignalAgent() signal.Agent     { return c.signal }
+func (c composed) PositionAgent() position.Agent { return c.position }
+
+func (c composed) RiskProfile(base domain.RiskPreset) domain.RiskPreset {
+	if c.risk == nil {
+		return base
+	}
+	return c.risk(base)
+}
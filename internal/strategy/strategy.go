@@ -0,0 +1,158 @@
+// Package strategy 定义可插拔的完整交易策略：信号来源 + 风险偏好 + 建仓计划。
+// 策略包在 init 中调用 Register 注册，由 STRATEGY_PAIRS 分配给交易对，
+// 新增策略只需在 main 中匿名导入对应包，无需修改 orchestrator。
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"ai_quant/internal/agent/position"
+	"ai_quant/internal/agent/signal"
+	"ai_quant/internal/config"
+	"ai_quant/internal/domain"
+)
+
+// Default 未指定策略的交易对使用的内置策略
+const Default = "default"
+
+// Strategy 一套完整的交易策略
+type Strategy interface {
+	Name() string
+	// SignalAgent 信号来源（大模型、规则或自定义模型）
+	SignalAgent() signal.Agent
+	// RiskProfile 基于当前全局风险预设返回本策略使用的风控参数
+	RiskProfile(base domain.RiskPreset) domain.RiskPreset
+	// PositionAgent 建仓计划（分批、止盈止损）
+	PositionAgent() position.Agent
+}
+
+// Deps 构造策略时可复用的默认组件
+type Deps struct {
+	Config   config.Config
+	Signal   signal.Agent   // 全局配置的信号来源
+	Position position.Agent // 默认建仓计划
+}
+
+// Factory 根据默认组件创建策略
+type Factory func(deps Deps) (Strategy, error)
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register 注册策略，名称重复时 panic（与 database/sql 驱动注册一致）
+func Register(name string, f Factory) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	mu.Lock()
+	defer mu.Unlock()
+	if f == nil {
+		panic("strategy: Register factory is nil for " + name)
+	}
+	if _, dup := factories[name]; dup {
+		panic("strategy: Register called twice for " + name)
+	}
+	factories[name] = f
+}
+
+// Names 返回已注册的策略名称，按名称排序
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Set 已实例化的策略及交易对分配
+type Set struct {
+	defaultName string
+	strategies  map[string]Strategy
+	pairs       map[string]string // 交易对 -> 策略名称
+}
+
+// NewSet 实例化默认策略和 pairSpec（"BTC/USDT=trend,DOGE/USDT=rules"）中用到的策略
+func NewSet(deps Deps, defaultName, pairSpec string) (*Set, error) {
+	defaultName = strings.ToLower(strings.TrimSpace(defaultName))
+	if defaultName == "" {
+		defaultName = Default
+	}
+	pairs, err := ParsePairStrategies(pairSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &Set{defaultName: defaultName, strategies: make(map[string]Strategy), pairs: pairs}
+	needed := []string{defaultName}
+	for _, name := range pairs {
+		needed = append(needed, name)
+	}
+	for _, name := range needed {
+		if _, ok := set.strategies[name]; ok {
+			continue
+		}
+		mu.RLock()
+		f, ok := factories[name]
+		mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("未注册的策略: %s（可用: %s）", name, strings.Join(Names(), ", "))
+		}
+		st, err := f(deps)
+		if err != nil {
+			return nil, fmt.Errorf("创建策略 %s 失败: %w", name, err)
+		}
+		set.strategies[name] = st
+	}
+	if len(pairs) > 0 {
+		log.Printf("[策略] 默认=%s 指定=%v", defaultName, pairs)
+	}
+	return set, nil
+}
+
+// ParsePairStrategies 解析 "BTC/USDT=trend,DOGE/USDT=rules" 格式的交易对策略配置
+func ParsePairStrategies(spec string) (map[string]string, error) {
+	pairs := make(map[string]string)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pair, name, ok := strings.Cut(item, "=")
+		pair = strings.ToUpper(strings.TrimSpace(pair))
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || pair == "" || name == "" {
+			return nil, fmt.Errorf("交易对策略配置格式错误: %q（应为 BTC/USDT=trend）", item)
+		}
+		pairs[pair] = name
+	}
+	return pairs, nil
+}
+
+// For 返回交易对使用的策略
+func (s *Set) For(pair string) Strategy {
+	if name, ok := s.pairs[strings.ToUpper(pair)]; ok {
+		return s.strategies[name]
+	}
+	return s.strategies[s.defaultName]
+}
+
+// DefaultName 默认策略名称
+func (s *Set) DefaultName() string {
+	return s.defaultName
+}
+
+// PairStrategies 返回按交易对指定的策略（用于状态展示）
+func (s *Set) PairStrategies() map[string]string {
+	out := make(map[string]string, len(s.pairs))
+	for p, n := range s.pairs {
+		out[p] = n
+	}
+	return out
+}
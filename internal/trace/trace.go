@@ -0,0 +1,120 @@
+// Package trace 在 context 中传递请求 ID / 周期 ID，并把它们带到日志和对外 HTTP 请求上，
+// 使每一次外部调用都能追溯到触发它的周期或 API 请求。
+package trace
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// HeaderRequestID 对外请求携带的请求 ID 头
+	HeaderRequestID = "X-Request-ID"
+	// HeaderCycleID 对外请求携带的周期 ID 头
+	HeaderCycleID = "X-Cycle-ID"
+)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	cycleIDKey
+)
+
+var (
+	propagateHeaders atomic.Bool
+	logOutbound      atomic.Bool
+)
+
+// Configure 设置是否在对外 HTTP 请求中附带 ID 头、是否记录每次对外请求
+func Configure(headers, logRequests bool) {
+	propagateHeaders.Store(headers)
+	logOutbound.Store(logRequests)
+}
+
+// WithRequestID 在 context 中记录 API 请求 ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID 读取 context 中的请求 ID
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithCycleID 在 context 中记录周期 ID
+func WithCycleID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, cycleIDKey, id)
+}
+
+// CycleID 读取 context 中的周期 ID
+func CycleID(ctx context.Context) string {
+	id, _ := ctx.Value(cycleIDKey).(string)
+	return id
+}
+
+// Fields 返回 key=value 形式的日志字段，没有 ID 时返回空串
+func Fields(ctx context.Context) string {
+	var parts []string
+	if id := CycleID(ctx); id != "" {
+		parts = append(parts, "cycle_id="+id)
+	}
+	if id := RequestID(ctx); id != "" {
+		parts = append(parts, "request_id="+id)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Transport 对外请求的 RoundTripper：按配置附带 ID 头，并记录请求所属的周期
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx := req.Context()
+	cycleID, requestID := CycleID(ctx), RequestID(ctx)
+	if propagateHeaders.Load() && (cycleID != "" || requestID != "") {
+		// RoundTripper 不应修改原请求，复制后再设置请求头
+		req = req.Clone(ctx)
+		if cycleID != "" {
+			req.Header.Set(HeaderCycleID, cycleID)
+		}
+		if requestID != "" {
+			req.Header.Set(HeaderRequestID, requestID)
+		}
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	if logOutbound.Load() {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		// 只记录 host + path，避免把签名、API key 等查询参数写进日志
+		log.Printf("[外部请求] %s method=%s host=%s path=%s status=%d latency=%s err=%v",
+			Fields(ctx), req.Method, req.URL.Host, req.URL.Path, status, time.Since(start).Round(time.Millisecond), err)
+	}
+	return resp, err
+}
+
+// NewClient 创建带追踪 Transport 的 HTTP 客户端
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: &Transport{}}
+}
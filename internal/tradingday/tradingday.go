@@ -0,0 +1,52 @@
+// Package tradingday 按配置的时区划分交易日，用于每日亏损上限、每日预算和按日统计。
+package tradingday
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// KeyLayout 交易日标识格式
+const KeyLayout = "2006-01-02"
+
+var location atomic.Pointer[time.Location]
+
+// Configure 设置交易日时区（IANA 名称，如 Asia/Shanghai），空字符串表示 UTC
+func Configure(tz string) error {
+	if tz == "" {
+		location.Store(time.UTC)
+		return nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return fmt.Errorf("加载时区 %s: %w", tz, err)
+	}
+	location.Store(loc)
+	return nil
+}
+
+// Location 返回当前交易日时区，未配置时为 UTC
+func Location() *time.Location {
+	if loc := location.Load(); loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// Start 返回 t 所在交易日的起始时刻（UTC 表示，便于直接用于数据库查询）
+func Start(t time.Time) time.Time {
+	local := t.In(Location())
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location()).UTC()
+}
+
+// Key 返回 t 所在交易日的标识，如 2024-05-01
+func Key(t time.Time) string {
+	return t.In(Location()).Format(KeyLayout)
+}
+
+// MonthStart 返回 t 所在自然月（按交易日时区）第一个交易日的起始时刻（UTC 表示）
+func MonthStart(t time.Time) time.Time {
+	local := t.In(Location())
+	return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, local.Location()).UTC()
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"fmt"
 	"log"
+	"os"
+	"strings"
+	"time"
 
 	"ai_quant/internal/agent/execution"
 	"ai_quant/internal/agent/position"
@@ -10,23 +15,66 @@ import (
 	"ai_quant/internal/agent/signal"
 	"ai_quant/internal/auth"
 	"ai_quant/internal/config"
+	"ai_quant/internal/costbasis"
+	"ai_quant/internal/fx"
 	httpapi "ai_quant/internal/http"
+	"ai_quant/internal/market"
+	"ai_quant/internal/notify"
 	"ai_quant/internal/orchestrator"
+	"ai_quant/internal/preset"
+	"ai_quant/internal/ratelimit"
 	"ai_quant/internal/scheduler"
+	"ai_quant/internal/screener"
+	"ai_quant/internal/stages"
 	"ai_quant/internal/store"
+	"ai_quant/internal/strategy"
+	"ai_quant/internal/trace"
+	"ai_quant/internal/tradingday"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func main() {
+	// go run . hash-password：生成 UI_PASSWORD_HASH
+	if len(os.Args) > 1 && os.Args[1] == "hash-password" {
+		hashPassword()
+		return
+	}
+
 	cfg := config.Load()
+	trace.Configure(cfg.TraceHTTPHeaders, cfg.TraceLogOutbound)
+	ratelimit.SetSafetyPct(cfg.RateLimitSafetyPct)
+	ratelimit.SetLimit(cfg.ExchangeBaseURL, cfg.SpotWeightLimit)
+	ratelimit.SetLimit(cfg.FuturesBaseURL, cfg.FuturesWeightLimit)
+	execution.SetRecvWindow(cfg.RecvWindowMs)
+	if err := tradingday.Configure(cfg.TradingTimezone); err != nil {
+		log.Fatalf("交易日时区配置错误: %v", err)
+	}
+	if err := costbasis.Configure(cfg.CostBasisMethod); err != nil {
+		log.Fatalf("成本核算方法配置错误: %v", err)
+	}
+	if err := stages.Configure(cfg.DisabledStages); err != nil {
+		log.Fatalf("流水线环节配置错误: %v", err)
+	}
+	if off := stages.Disabled(); len(off) > 0 {
+		log.Printf("⏭️ 已关闭的流水线环节: %s", strings.Join(off, ", "))
+	}
 
-	repo, err := store.NewSQLiteRepository(cfg.SQLiteDSN)
+	dsn := cfg.SQLiteDSN
+	if cfg.ReadOnly {
+		// 只读实例可以指向交易实例的同一个库文件或其副本，连接上禁止任何写入，也不执行迁移
+		dsn = store.ReadOnlyDSN(dsn)
+	}
+	repo, err := store.NewSQLiteRepository(dsn)
 	if err != nil {
 		log.Fatalf("初始化数据库失败: %v", err)
 	}
 	defer repo.Close()
 
-	if err := repo.Init(context.Background()); err != nil {
-		log.Fatalf("数据库迁移失败: %v", err)
+	if !cfg.ReadOnly {
+		if err := repo.Init(context.Background()); err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
 	}
 
 	// 初始化 OAuth 服务（需要在 signal agent 之前）
@@ -46,42 +94,333 @@ func main() {
 	riskAgent := risk.New(cfg)
 	positionAgent := position.New()
 
-	// 根据交易模式选择 Executor
-	var execAgent execution.Executor
-	if cfg.TradingMode == "futures" {
-		execAgent = execution.NewFutures(cfg)
+	// 根据交易模式选择 Executor（配置 PAIR_TRADING_MODES 时按交易对路由）
+	execAgent, err := execution.NewFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("交易模式配置错误: %v", err)
+	}
+	if cfg.PairTradingModes != "" {
+		log.Printf("📈 交易模式: 按交易对路由 (%s)，默认 %s", cfg.PairTradingModes, cfg.TradingMode)
+	} else if cfg.TradingMode == "futures" {
 		log.Printf("📈 交易模式: USDT-M 永续合约 (%dx 杠杆)", cfg.FuturesLeverage)
+	} else if cfg.TradingMode == "margin" {
+		log.Printf("📈 交易模式: 现货杠杆 %s (%dx 杠杆)", cfg.MarginType, cfg.MarginLeverage)
 	} else {
-		execAgent = execution.New(cfg)
 		log.Println("📈 交易模式: 现货交易")
 	}
 
-	service := orchestrator.New(repo, signalAgent, riskAgent, positionAgent, execAgent)
+	presets := preset.NewManager(cfg)
+	log.Printf("🎚️ 风险预设: %s", presets.Active().Name)
 
-	// 启动时同步持仓（holdings 表为空则自动同步）
-	holdings, _ := repo.ListHoldings(context.Background())
-	if len(holdings) == 0 {
-		log.Println("[持仓] holdings 表为空，正在同步 ...")
-		if err := service.SyncHoldings(context.Background()); err != nil {
-			log.Printf("[持仓] ⚠ 初始同步失败: %v", err)
+	service := orchestrator.New(repo, signalAgent, riskAgent, positionAgent, execAgent, presets)
+	service.SetReadOnly(cfg.ReadOnly)
+	strategies, err := strategy.NewSet(strategy.Deps{Config: cfg, Signal: signalAgent, Position: positionAgent}, cfg.Strategy, cfg.PairStrategies)
+	if err != nil {
+		log.Fatalf("策略配置错误: %v", err)
+	}
+	service.SetStrategies(strategies)
+	service.SetDecisionMemory(cfg.DecisionMemoryCycles)
+	execution.ConfigureKeyAlert(cfg.PublicIPProbeURL, cfg.KeyExpiryWarnDays)
+	keyValidator := execution.NewKeyValidator(cfg, execution.NeedsFutures(execAgent))
+	service.SetKeyValidator(keyValidator)
+	if strings.EqualFold(cfg.Exchange, execution.ExchangeOKX) {
+		log.Println("[密钥] OKX 暂不支持启动时 API Key 权限校验，已跳过")
+	} else if !cfg.DryRun && cfg.ExchangeValidateOnStart {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		check := keyValidator.Validate(ctx)
+		cancel()
+		for _, w := range check.Warnings {
+			log.Printf("[密钥] ⚠ %s", w)
 		}
-	} else {
-		log.Printf("[持仓] 已有 %d 条持仓记录", len(holdings))
+		if !check.OK {
+			log.Fatalf("交易所 API Key 校验失败: %s", strings.Join(check.Errors, "；"))
+		}
+		log.Println("[密钥] ✔ 交易所 API Key 校验通过（未开启提现）")
+	}
+	service.SetEarnAutoRedeem(cfg.EarnAutoRedeem)
+	notionalLimits, err := orchestrator.ParseNotionalLimits(cfg.PairNotionalLimits)
+	if err != nil {
+		log.Fatalf("交易对下单金额配置错误: %v", err)
+	}
+	service.SetNotionalLimits(notionalLimits)
+	service.SetFundingGuard(orchestrator.FundingGuard{
+		HighRate:         cfg.FundingHighRate,
+		SustainedPeriods: cfg.FundingSustainedPeriods,
+		MaxCostPct:       cfg.FundingMaxCostPct,
+		AutoClose:        cfg.FundingAutoClose,
+	})
+	if execution.NeedsFutures(execAgent) {
+		service.SetLiquidationGuard(orchestrator.LiquidationGuard{
+			BufferPct:      cfg.LiqBufferPct,
+			ReduceFraction: cfg.LiqReduceFraction,
+		})
+	}
+	service.SetBehaviorMonitor(orchestrator.BehaviorMonitor{
+		Window:          cfg.BehaviorWindow,
+		Baseline:        cfg.BehaviorBaseline,
+		SideShiftPct:    cfg.BehaviorSideShiftPct,
+		ConfidenceShift: cfg.BehaviorConfidenceShift,
+	})
+	service.SetVolatilityBreaker(orchestrator.VolatilityBreaker{
+		MovePct:     cfg.VolatilityMovePct,
+		WindowMin:   cfg.VolatilityWindowMin,
+		CooldownMin: cfg.VolatilityCooldownMin,
+	})
+	service.SetChurnGuard(orchestrator.ChurnGuard{
+		WindowMin:     cfg.ChurnWindowMin,
+		MinConfidence: cfg.ChurnMinConfidence,
+	})
+	exitLadder, err := position.ParseExitLadder(cfg.TakeProfitLadder)
+	if err != nil {
+		log.Fatalf("TAKE_PROFIT_LADDER 配置错误: %v", err)
 	}
+	service.SetExitLadder(exitLadder)
+	service.SetTrailingStop(orchestrator.TrailingStop{
+		Percent:     cfg.TrailingStopPct,
+		ATRMult:     cfg.TrailingStopATRMult,
+		ATRInterval: cfg.TrailingStopATRInterval,
+		ATRPeriod:   cfg.TrailingStopATRPeriod,
+	}, market.NewClient())
+	service.SetDrawdownGuard(context.Background(), orchestrator.DrawdownGuard{
+		MaxPct:     cfg.DrawdownMaxPct,
+		WindowDays: cfg.DrawdownWindowDays,
+		ForceClose: cfg.DrawdownForceClose,
+	})
+	service.SetPortfolioMode(cfg.PortfolioMode)
+	service.SetCapitalAllocator(orchestrator.CapitalAllocator{
+		BudgetUSDT:  cfg.CapitalBudgetUSDT,
+		Pairs:       strings.Split(cfg.AutoRunPairs, ","),
+		Weighting:   cfg.CapitalWeighting,
+		ATRInterval: cfg.CapitalATRInterval,
+		ATRPeriod:   cfg.CapitalATRPeriod,
+	}, market.NewClient())
+	service.SetIndicatorSource(market.NewClient())
+	if execution.NeedsMargin(execAgent) {
+		service.SetMarginMinLevel(cfg.MarginMinLevel)
+	}
+
+	if err := service.SetApproval(cfg.ApprovalMode, time.Duration(cfg.ApprovalTimeoutMin)*time.Minute); err != nil {
+		log.Fatalf("人工审批配置错误: %v", err)
+	}
+	if err := service.SetCycleConcurrency(cfg.CycleConcurrency); err != nil {
+		log.Fatalf("CYCLE_CONCURRENCY 配置错误: %v", err)
+	}
+	service.SetCycleQueue(cfg.CycleWorkers, cfg.CycleQueueMax)
+	service.SetTWAP(orchestrator.TWAP{
+		MinNotional: cfg.TWAPMinNotionalUSDT,
+		Slices:      cfg.TWAPSlices,
+		Interval:    time.Duration(cfg.TWAPIntervalSec) * time.Second,
+	})
+	execPolicy, err := orchestrator.ParseExecutionPolicy(cfg.ExecutionPolicy)
+	if err != nil {
+		log.Fatalf("执行方式配置错误: %v", err)
+	}
+	service.SetExecutionPolicy(execPolicy)
+	service.SetOutboxAlertAfter(cfg.OutboxAlertAfter)
+	service.SetOutboxDeadAfter(cfg.OutboxDeadAfter)
+	if !cfg.ReadOnly {
+		// 先于周期队列注册，退出时在队列停止之后才停止，最后一次重试能覆盖收尾的周期
+		outbox := scheduler.NewPeriodic(scheduler.OutboxJob(service, cfg.OutboxRetrySec))
+		outbox.Start()
+		defer outbox.Stop()
+	}
+	defer service.StopCycleQueue()
+	if cfg.DryRun && cfg.PaperTrading && !cfg.ReadOnly {
+		if err := service.SetPaperTrading(context.Background(), orchestrator.PaperTrading{
+			InitialUSDT: cfg.PaperInitialUSDT,
+			FeeRate:     cfg.PaperFeeRate,
+		}); err != nil {
+			log.Fatalf("模拟盘钱包初始化失败: %v", err)
+		}
+	}
+	if service.ApprovalMode() != orchestrator.ApprovalOff && !cfg.ReadOnly {
+		approvals := scheduler.NewPeriodic(scheduler.ApprovalJob(service, 30*time.Second))
+		approvals.Start()
+		defer approvals.Stop()
+	}
+
+	// 报表展示币种
+	converter, err := fx.New(cfg.DisplayCurrency, cfg.DisplayFXRate, time.Duration(cfg.FXCacheMin)*time.Minute)
+	if err != nil {
+		log.Fatalf("展示币种配置错误: %v", err)
+	}
+	service.SetDisplayCurrency(converter)
+	service.SetReportFeeRate(cfg.ReportFeeRate)
+
+	// 交易通知
+	telegram, err := notify.NewTelegram(cfg.TelegramBotToken, cfg.TelegramChatID)
+	if err != nil {
+		log.Fatalf("通知配置错误: %v", err)
+	}
+	// 只读实例不推送通知，避免与交易实例重复
+	if telegram != nil && !cfg.ReadOnly {
+		kinds, err := notify.ParseKinds(cfg.NotifyEvents)
+		if err != nil {
+			log.Fatalf("通知配置错误: %v", err)
+		}
+		service.SetNotifier(notify.NewDispatcher(telegram, kinds))
+		log.Printf("📨 Telegram 通知已启用 类型=%s", cfg.NotifyEvents)
+	}
+
+	// 只读实例不启动任何会下单或写库的后台任务，由交易实例负责
+	if cfg.ReadOnly {
+		log.Println("👁️ 只读模式：写接口已禁用，定时交易与后台任务不启动")
+	} else {
+		// 启动时同步持仓（holdings 表为空则自动同步）
+		holdings, _ := repo.ListHoldings(context.Background())
+		if len(holdings) == 0 {
+			log.Println("[持仓] holdings 表为空，正在同步 ...")
+			if err := service.SyncHoldings(context.Background()); err != nil {
+				log.Printf("[持仓] ⚠ 初始同步失败: %v", err)
+			}
+		} else {
+			log.Printf("[持仓] 已有 %d 条持仓记录", len(holdings))
+		}
 
-	// 启动定时自动交易
-	if cfg.AutoRunEnabled {
-		sched := scheduler.New(service, cfg.AutoRunInterval, cfg.AutoRunPairs)
+		// 启动定时自动交易（AUTO_RUN_ENABLED=false 时以暂停状态启动，可通过 /api/v1/scheduler 恢复）
+		sched := scheduler.New(service, cfg.AutoRunInterval, cfg.AutoRunPairs, !cfg.AutoRunEnabled)
+		service.SetScheduler(sched)
 		sched.Start()
 		defer sched.Stop()
-	} else {
-		log.Println("[定时器] 未启用，设置 AUTO_RUN_ENABLED=true 开启自动交易")
+		if sched.State().Paused {
+			log.Println("[定时器] 已暂停，设置 AUTO_RUN_ENABLED=true 或调用 POST /api/v1/scheduler/resume 开启自动交易")
+		}
+
+		// 日报 / 周报：交易日切换时生成并保存，开启 daily 通知时推送摘要
+		reporter := scheduler.NewDailyReporter(service)
+		reporter.Start()
+		defer reporter.Stop()
+
+		// 启动影子周期（只模拟的交易对；开启筛选自动加入时即使没有配置交易对也启动）
+		var shadow *scheduler.ShadowRunner
+		if cfg.ShadowIntervalSec > 0 && (cfg.ShadowPairs != "" || (cfg.ScreenerEnabled && cfg.ScreenerAutoShadow)) {
+			shadow = scheduler.NewShadowRunner(service, cfg.ShadowIntervalSec, cfg.ShadowHorizonMin, cfg.ShadowPairs, cfg.AutoRunPairs)
+			shadow.Start()
+			defer shadow.Stop()
+		}
+
+		// 启动沙盒会话定时运行
+		if cfg.SandboxIntervalSec > 0 {
+			sandboxes := scheduler.NewSandboxRunner(service, cfg.SandboxIntervalSec)
+			sandboxes.Start()
+			defer sandboxes.Stop()
+		}
+
+		// 启动交易对筛选任务
+		if cfg.ScreenerEnabled {
+			var autoShadow *scheduler.ShadowRunner
+			if cfg.ScreenerAutoShadow {
+				autoShadow = shadow
+			}
+			screen := scheduler.NewScreener(service, market.Endpoints{CoinGecko: cfg.CoinGeckoBaseURL}, screener.Criteria{
+				Quote:           cfg.ScreenerQuote,
+				MinQuoteVolume:  cfg.ScreenerMinQuoteVolume,
+				MinChangePct:    cfg.ScreenerMinChangePct,
+				MinRangePct:     cfg.ScreenerMinRangePct,
+				RequireTrending: cfg.ScreenerRequireTrending,
+				MaxCandidates:   cfg.ScreenerMaxCandidates,
+				Exclude:         screener.ParseExclude(cfg.ScreenerExclude),
+			}, cfg.ScreenerIntervalMin, autoShadow)
+			service.SetScreener(screen)
+			screen.Start()
+			defer screen.Stop()
+		}
+
+		// 启动部分成交处理任务
+		if cfg.PartialFillTimeoutSec > 0 {
+			watcher := scheduler.NewPeriodic(scheduler.FillJob(service, cfg.PartialFillTimeoutSec, cfg.PartialFillAction))
+			watcher.Start()
+			defer watcher.Stop()
+		}
+
+		// 启动分批建仓触发任务
+		if cfg.BatchTriggerIntervalSec > 0 {
+			batches := scheduler.NewPeriodic(scheduler.BatchJob(service, cfg.BatchTriggerIntervalSec, cfg.BatchExpireHours))
+			batches.Start()
+			defer batches.Stop()
+		}
+
+		// 启动止盈止损保护单对账任务
+		if cfg.ProtectiveReconcileSec > 0 {
+			protection := scheduler.NewPeriodic(scheduler.ProtectionJob(service, cfg.ProtectiveReconcileSec))
+			protection.Start()
+			defer protection.Stop()
+		}
+
+		// 订阅交易所用户数据流：成交与保护单触发实时更新，上面的对账任务作为兜底
+		if cfg.UserStreamEnabled && !service.StartUserStream() {
+			log.Println("[成交推送] ⚠ 当前执行器不支持用户数据流（需 Binance 实盘且配置 API Key），继续使用定时对账")
+		}
+
+		// 启动移动止损任务
+		if service.TrailingStopEnabled() && cfg.TrailingStopCheckSec > 0 {
+			trailing := scheduler.NewPeriodic(scheduler.TrailingStopJob(service, cfg.TrailingStopCheckSec))
+			trailing.Start()
+			defer trailing.Stop()
+		}
+
+		// 启动现货杠杆风险率巡检任务
+		if execution.NeedsMargin(execAgent) && cfg.MarginMinLevel > 0 && cfg.MarginCheckSec > 0 {
+			marginWatcher := scheduler.NewPeriodic(scheduler.MarginJob(service, cfg.MarginCheckSec))
+			marginWatcher.Start()
+			defer marginWatcher.Stop()
+		}
+
+		// 启动合约强平预警巡检任务
+		if service.LiquidationGuardEnabled() && cfg.LiqCheckSec > 0 {
+			liquidation := scheduler.NewPeriodic(scheduler.LiquidationJob(service, cfg.LiqCheckSec))
+			liquidation.Start()
+			defer liquidation.Stop()
+		}
+
+		// 启动模型输出分布巡检任务
+		if service.BehaviorMonitorEnabled() && cfg.BehaviorCheckSec > 0 {
+			behavior := scheduler.NewPeriodic(scheduler.BehaviorJob(service, cfg.BehaviorCheckSec))
+			behavior.Start()
+			defer behavior.Stop()
+		}
+
+		// 启动数据清理任务
+		if cfg.PruneEnabled {
+			pruner := scheduler.NewPruner(repo, cfg.PruneIntervalMin, cfg.PruneLogRetentionDays, cfg.PruneFailedRetentionDays)
+			pruner.Start()
+			defer pruner.Stop()
+		}
 	}
 
-	router := httpapi.NewRouter(service, authService, cfg.RequestTimeoutSec)
+	modelCatalog := signal.NewModelCatalog(cfg)
+
+	session := httpapi.NewSessionAuth(cfg.UIPasswordHash, cfg.SessionTTLHours)
+	if session != nil {
+		log.Println("🔒 Web UI 登录已启用")
+	}
+	apiKeys := httpapi.NewAPIKeyAuth(cfg.APIKeys)
+	if apiKeys != nil {
+		log.Println("🔑 API Key 鉴权已启用")
+	}
+
+	router := httpapi.NewRouter(service, authService, modelCatalog, session, apiKeys, cfg.RequestTimeoutSec, cfg.ReadOnly)
 
 	log.Printf("AI Quant 服务启动 地址=%s 模式=%s 模拟=%v", cfg.HTTPAddr, cfg.TradingMode, cfg.DryRun)
 	if err := router.Run(cfg.HTTPAddr); err != nil {
 		log.Fatalf("启动服务失败: %v", err)
 	}
 }
+
+// hashPassword 从标准输入读取密码并输出 bcrypt 哈希
+func hashPassword() {
+	fmt.Fprint(os.Stderr, "请输入 Web UI 密码: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		log.Fatalf("读取密码失败: %v", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		log.Fatal("密码不能为空")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Fatalf("生成哈希失败: %v", err)
+	}
+	fmt.Printf("UI_PASSWORD_HASH='%s'\n", hash)
+}